@@ -0,0 +1,175 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/adewale/olsen/internal/database"
+	"github.com/adewale/olsen/internal/query"
+)
+
+// benchCase is one grid filter of the bench suite, as an explorer query
+// string
+type benchCase struct {
+	name   string
+	filter string
+}
+
+// benchSuite returns the filters bench times. Camera and year filters use
+// the library's most common values, so they select a realistic share of
+// photos on any catalog.
+func benchSuite(db *database.DB) []benchCase {
+	suite := []benchCase{
+		{"all photos", ""},
+		{"all, page 20", "offset=950"},
+	}
+
+	var year int
+	if err := db.QueryRow(`
+		SELECT CAST(strftime('%Y', date_taken) AS INTEGER) AS y FROM photos
+		WHERE date_taken IS NOT NULL GROUP BY y ORDER BY COUNT(*) DESC, y DESC LIMIT 1
+	`).Scan(&year); err == nil {
+		suite = append(suite, benchCase{"busiest year", fmt.Sprintf("year=%d", year)})
+	}
+	var cameraMake string
+	if err := db.QueryRow(`
+		SELECT camera_make FROM photos
+		WHERE camera_make IS NOT NULL GROUP BY camera_make ORDER BY COUNT(*) DESC, camera_make LIMIT 1
+	`).Scan(&cameraMake); err == nil {
+		suite = append(suite, benchCase{"commonest camera", "camera_make=" + url.QueryEscape(cameraMake)})
+	}
+
+	return append(suite,
+		benchCase{"colour", "color=blue"},
+		benchCase{"high ISO", "iso_min=3200"},
+		benchCase{"time of day", "time_of_day=golden_hour_evening"},
+		benchCase{"sorted by colour", "sort=colour"},
+		benchCase{"combined", "color=blue&iso_min=400&time_of_day=afternoon"},
+	)
+}
+
+// benchCommand times each suite filter's grid query (QueryCards) and facet
+// computation (ComputeFacets) runs times on the catalog, after one untimed
+// warm-up, and prints the median and 95th percentile of each
+func benchCommand(dbPath string, runs int) error {
+	if runs < 1 {
+		return usageError("-runs must be at least 1")
+	}
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return notFoundError("database not found: %s", dbPath)
+	}
+
+	// Read-only, so benchmarking alongside an indexer cannot disturb it
+	db, err := database.OpenReadOnly(dbPath, false)
+	if err != nil {
+		return dbError("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	count, err := db.GetPhotoCount()
+	if err != nil {
+		return dbError("failed to count photos: %v", err)
+	}
+	engine := query.NewEngine(db.DB)
+	mapper := query.NewURLMapper()
+
+	fmt.Printf("Benchmarking %s (%d photos, %d runs each)\n", dbPath, count, runs)
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Printf("%-18s %-8s %8s %10s %10s  %s\n", "Query", "Kind", "Matches", "p50", "p95", "Plan")
+
+	for _, c := range benchSuite(db) {
+		params, err := mapper.ParsePath("/photos", c.filter)
+		if err != nil {
+			return usageError("invalid bench filter %q: %v", c.filter, err)
+		}
+
+		var total int
+		grid, err := benchTimes(runs, func() error {
+			result, err := engine.QueryCards(params)
+			if err == nil {
+				total = result.Total
+			}
+			return err
+		})
+		if err != nil {
+			return dbError("%s: query failed: %v", c.name, err)
+		}
+		facets, err := benchTimes(runs, func() error {
+			_, err := engine.ComputeFacets(params)
+			return err
+		})
+		if err != nil {
+			return dbError("%s: facets failed: %v", c.name, err)
+		}
+
+		fmt.Printf("%-18s %-8s %8d %10s %10s  %s\n", c.name, "query", total,
+			formatBenchDuration(percentile(grid, 50)), formatBenchDuration(percentile(grid, 95)), queryPlan(db, engine, params))
+		fmt.Printf("%-18s %-8s %8s %10s %10s\n", "", "facets", "",
+			formatBenchDuration(percentile(facets, 50)), formatBenchDuration(percentile(facets, 95)))
+	}
+
+	fmt.Println("")
+	fmt.Println("Plan shows how SQLite finds the matching photos: \"scan\" visits every")
+	fmt.Println("photo, \"index\" searches an index. Unfiltered queries always scan. Run")
+	fmt.Println("olsen compact to refresh the statistics the planner chooses with.")
+	return nil
+}
+
+// benchTimes runs fn once to warm the page cache, then runs more times and
+// returns the durations sorted, fastest first
+func benchTimes(runs int, fn func() error) ([]time.Duration, error) {
+	if err := fn(); err != nil {
+		return nil, err
+	}
+	times := make([]time.Duration, runs)
+	for i := range times {
+		start := time.Now()
+		if err := fn(); err != nil {
+			return nil, err
+		}
+		times[i] = time.Since(start)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+	return times, nil
+}
+
+// percentile returns the nearest-rank pth percentile of sorted durations
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (len(sorted)*p + 99) / 100
+	return sorted[max(rank-1, 0)]
+}
+
+// formatBenchDuration writes a duration in milliseconds
+func formatBenchDuration(d time.Duration) string {
+	return fmt.Sprintf("%.1f ms", float64(d.Microseconds())/1000)
+}
+
+// queryPlan reports whether SQLite visits every photo to find those matching
+// params ("scan") or searches an index for them ("index")
+func queryPlan(db *database.DB, engine *query.Engine, params query.QueryParams) string {
+	ids, args := engine.MatchingIDs(params)
+	rows, err := db.Query("EXPLAIN QUERY PLAN "+ids, args...)
+	if err != nil {
+		return "?"
+	}
+	defer rows.Close()
+
+	plan := "index"
+	for rows.Next() {
+		var id, parent, notUsed int
+		var detail sql.NullString
+		if err := rows.Scan(&id, &parent, &notUsed, &detail); err != nil {
+			return "?"
+		}
+		// A scan visits every photo, even when it walks an index to do so
+		if strings.HasPrefix(detail.String, "SCAN p") {
+			plan = "scan"
+		}
+	}
+	return plan
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/adewale/olsen/internal/database"
+)
+
+// openCollectionDB opens an existing catalog for the collection subcommands
+func openCollectionDB(dbPath string) (*database.DB, error) {
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return nil, notFoundError("database not found: %s", dbPath)
+	}
+	db, err := database.Open(dbPath)
+	if err != nil {
+		return nil, dbError("failed to open database: %v", err)
+	}
+	return db, nil
+}
+
+// collectionCreateCommand creates an empty manual collection
+func collectionCreateCommand(dbPath, name, description string) error {
+	db, err := openCollectionDB(dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	id, err := db.CreateCollection(name, description)
+	if err != nil {
+		return usageError("%v", err)
+	}
+
+	fmt.Printf("Created collection %d: %s\n", id, name)
+	return nil
+}
+
+// collectionEditCommand adds photos to or removes them from a collection
+func collectionEditCommand(dbPath, action, collection string, photoIDs []int) error {
+	db, err := openCollectionDB(dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	c, err := db.FindCollection(collection)
+	if errors.Is(err, database.ErrCollectionNotFound) {
+		return notFoundError("%v", err)
+	}
+	if err != nil {
+		return dbError("%v", err)
+	}
+
+	var changed int64
+	if action == "add" {
+		changed, err = db.AddToCollection(c.ID, photoIDs)
+	} else {
+		changed, err = db.RemoveFromCollection(c.ID, photoIDs)
+	}
+	if err != nil {
+		return dbError("%v", err)
+	}
+
+	verb, skipped := "Added", "already members or not in the catalog"
+	if action == "remove" {
+		verb, skipped = "Removed", "not members"
+	}
+	fmt.Printf("%s %d of %d photos (collection %q)\n", verb, changed, len(photoIDs), c.Name)
+	if int(changed) < len(photoIDs) {
+		fmt.Printf("  %d skipped: %s\n", len(photoIDs)-int(changed), skipped)
+	}
+	return nil
+}
+
+// collectionListCommand prints every collection with its photo count
+func collectionListCommand(dbPath string) error {
+	db, err := openCollectionDB(dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	collections, err := db.ListCollections()
+	if err != nil {
+		return dbError("%v", err)
+	}
+	if len(collections) == 0 {
+		fmt.Println("No collections")
+		return nil
+	}
+
+	for _, c := range collections {
+		fmt.Printf("%4d  %-30s %6d photos", c.ID, c.Name, c.PhotoCount)
+		if c.Description != "" {
+			fmt.Printf("  %s", c.Description)
+		}
+		fmt.Println()
+	}
+	return nil
+}
@@ -2,85 +2,228 @@ package main
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/adewale/olsen/internal/database"
 	"github.com/adewale/olsen/internal/explorer"
 	"github.com/adewale/olsen/internal/indexer"
+	"github.com/adewale/olsen/internal/query"
 	"github.com/adewale/olsen/pkg/models"
 )
 
+// indexOptions holds the index command's optional behaviour
+type indexOptions struct {
+	PerfStats          bool
+	FollowSymlinks     bool
+	MaxDecodeDimension int
+	Progressive        bool
+	AllowUpscale       bool
+	MinFileSize        int64                        // Bytes; 0 = no lower limit
+	MaxFileSize        int64                        // Bytes, exclusive; 0 = no upper limit
+	ThumbBackground    string                       // Colour for transparent areas, as accepted by parseHexColour
+	NoThumbnails       bool                         // Metadata only; thumbnails are left pending
+	MaxFiles           int                          // Abort when more files are found; 0 = no cap
+	ModifiedAfter      time.Time                    // Skip files modified at or before this; zero = no limit
+	IncludeVideo       bool                         // Index MP4 and MOV files too
+	UseExiftool        bool                         // exiftool fallback for metadata go-exif can't parse
+	DedupThumbnails    bool                         // Share identical thumbnails between photos
+	Prune              bool                         // Remove photos whose files were deleted from the roots
+	ThumbnailQuality   map[models.ThumbnailSize]int // JPEG quality overrides from the config file
+}
+
 // indexCommand performs actual photo indexing
-func indexCommand(photoDir, dbPath string, workers int, perfstats bool) error {
-	// Validate photo directory
-	if info, err := os.Stat(photoDir); err != nil {
-		if os.IsNotExist(err) {
-			return fmt.Errorf("photo directory does not exist: %s", photoDir)
+func indexCommand(photoDirs []string, dbPath string, workers int, opts indexOptions) error {
+	// Validate photo directories
+	for _, photoDir := range photoDirs {
+		if info, err := os.Stat(photoDir); err != nil {
+			if os.IsNotExist(err) {
+				return notFoundError("photo directory does not exist: %s", photoDir)
+			}
+			return fmt.Errorf("cannot access photo directory: %v", err)
+		} else if !info.IsDir() {
+			return usageError("path is not a directory: %s", photoDir)
 		}
-		return fmt.Errorf("cannot access photo directory: %v", err)
-	} else if !info.IsDir() {
-		return fmt.Errorf("path is not a directory: %s", photoDir)
+	}
+
+	thumbBg, err := parseHexColour(opts.ThumbBackground)
+	if err != nil {
+		return usageError("invalid thumbnail background: %v", err)
 	}
 
 	// Open/create database
 	db, err := database.Open(dbPath)
 	if err != nil {
-		return fmt.Errorf("failed to open database: %v", err)
+		return dbError("failed to open database: %v", err)
 	}
 	defer db.Close()
+	db.SetThumbnailDedup(opts.DedupThumbnails)
 
 	// Create indexer engine
 	engine := indexer.NewEngine(db, workers)
+	engine.SetFollowSymlinks(opts.FollowSymlinks)
+	engine.SetMaxDecodeDimension(opts.MaxDecodeDimension)
+	engine.SetProgressiveThumbnails(opts.Progressive)
+	engine.SetAllowUpscale(opts.AllowUpscale)
+	engine.SetFileSizeRange(opts.MinFileSize, opts.MaxFileSize)
+	engine.SetThumbnailBackground(thumbBg)
+	engine.SetSkipThumbnails(opts.NoThumbnails)
+	engine.SetThumbnailQuality(opts.ThumbnailQuality)
+	engine.SetMaxFiles(opts.MaxFiles)
+	engine.SetModifiedAfter(opts.ModifiedAfter)
+	engine.SetIncludeVideo(opts.IncludeVideo)
+	engine.SetExiftoolFallback(opts.UseExiftool)
+	if opts.UseExiftool {
+		if _, err := exec.LookPath("exiftool"); err != nil {
+			fmt.Println("Warning: exiftool not found on the PATH; -use-exiftool has no effect")
+		}
+	}
 
 	// Index directory
 	fmt.Println("Indexing photos...")
-	fmt.Printf("  Directory: %s\n", photoDir)
+	if len(photoDirs) == 1 {
+		fmt.Printf("  Directory: %s\n", photoDirs[0])
+	} else {
+		fmt.Printf("  Directories: %s\n", strings.Join(photoDirs, ", "))
+	}
 	fmt.Printf("  Database: %s\n", dbPath)
 	fmt.Printf("  Workers: %d\n", workers)
+	if opts.FollowSymlinks {
+		fmt.Println("  Following symlinks: yes")
+	}
+	if opts.MaxDecodeDimension > 0 {
+		fmt.Printf("  Max decode dimension: %dpx\n", opts.MaxDecodeDimension)
+	}
+	if opts.Progressive {
+		fmt.Println("  Progressive 1024px thumbnails: yes")
+	}
+	if opts.AllowUpscale {
+		fmt.Println("  Upscale small images: yes")
+	}
+	if opts.MinFileSize > 0 || opts.MaxFileSize > 0 {
+		fmt.Printf("  File size: %s\n", fileSizeRange(opts.MinFileSize, opts.MaxFileSize))
+	}
+	if !opts.ModifiedAfter.IsZero() {
+		fmt.Printf("  Modified after: %s\n", opts.ModifiedAfter.Format("2006-01-02 15:04:05"))
+	}
+	if opts.NoThumbnails {
+		fmt.Println("  Thumbnails: skipped (metadata only)")
+	}
 	fmt.Println()
 
 	startTime := time.Now()
-	err = engine.IndexDirectory(photoDir)
+	err = engine.IndexDirectories(photoDirs)
+
+	// Record every run, failed ones included, for olsen runs
+	stats := engine.GetStats()
+	if _, recordErr := db.RecordIndexRun(absPaths(photoDirs), stats, err); recordErr != nil {
+		fmt.Printf("Warning: %v\n", recordErr)
+	}
+
+	var tooMany *indexer.TooManyFilesError
+	if errors.As(err, &tooMany) {
+		fmt.Printf("Found %d files, more than -max-files %d. First files found:\n", tooMany.Found, tooMany.Max)
+		printSample(tooMany.Paths)
+		return usageError("nothing indexed; check the directory, or raise -max-files")
+	}
 	if err != nil {
 		return fmt.Errorf("indexing failed: %v", err)
 	}
 
-	// Get final stats
-	stats := engine.GetStats()
+	// Only after a complete run, so a failed walk can't look like deletions
+	pruned := 0
+	if opts.Prune {
+		for _, photoDir := range photoDirs {
+			n, err := engine.PruneDeleted(photoDir)
+			pruned += n
+			if err != nil {
+				return dbError("pruning failed: %v", err)
+			}
+		}
+	}
 
 	fmt.Printf("\n\nIndexing complete in %s\n", time.Since(startTime).Round(time.Millisecond))
+	if len(photoDirs) > 1 {
+		fmt.Printf("  Directories: %d\n", len(photoDirs))
+	}
 	fmt.Printf("  Found: %d files\n", stats.FilesFound)
 	fmt.Printf("  Processed: %d photos\n", stats.FilesProcessed)
 	fmt.Printf("  Skipped: %d photos\n", stats.FilesSkipped)
+	if stats.FilesOutOfRange > 0 {
+		fmt.Printf("  Outside size limits: %d files (not read)\n", stats.FilesOutOfRange)
+	}
+	if !opts.ModifiedAfter.IsZero() {
+		fmt.Printf("  Not modified since: %d files (not read)\n", stats.FilesTooOld)
+	}
 	if stats.FilesFailed > 0 {
-		fmt.Printf("  Failed: %d photos\n", stats.FilesFailed)
+		fmt.Printf("  Failed: %d photos (list them with: olsen errors -db %s)\n", stats.FilesFailed, dbPath)
+	}
+	if opts.Prune {
+		fmt.Printf("  Pruned: %d deleted files removed from the database\n", pruned)
+	}
+	if opts.NoThumbnails {
+		fmt.Println("  Thumbnails pending: index again without -no-thumbnails to generate them")
 	}
 	fmt.Printf("  Database: %s\n", dbPath)
 
 	return nil
 }
 
+// absPaths makes paths absolute where it can, so recorded runs name the
+// same directories whatever directory olsen was started from
+func absPaths(paths []string) []string {
+	abs := make([]string, len(paths))
+	for i, p := range paths {
+		abs[i] = p
+		if a, err := filepath.Abs(p); err == nil {
+			abs[i] = a
+		}
+	}
+	return abs
+}
+
+// fileSizeRange describes the index size limits, e.g. "at least 1 MB, under 200 MB"
+func fileSizeRange(min, max int64) string {
+	var parts []string
+	if min > 0 {
+		parts = append(parts, "at least "+query.FormatFileSize(min))
+	}
+	if max > 0 {
+		parts = append(parts, "under "+query.FormatFileSize(max))
+	}
+	return strings.Join(parts, ", ")
+}
+
 // statsCommand displays database statistics
-func statsCommand(dbPath string) error {
+func statsCommand(dbPath string, asJSON bool) error {
 	// Check database exists
 	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
-		return fmt.Errorf("database not found: %s", dbPath)
+		return notFoundError("database not found: %s", dbPath)
 	}
 
 	// Open database
 	db, err := database.Open(dbPath)
 	if err != nil {
-		return fmt.Errorf("failed to open database: %v", err)
+		return dbError("failed to open database: %v", err)
 	}
 	defer db.Close()
 
+	if asJSON {
+		return printStatsJSON(db, dbPath)
+	}
+
 	// Get photo count
 	var photoCount int
 	err = db.QueryRow("SELECT COUNT(*) FROM photos").Scan(&photoCount)
 	if err != nil {
-		return fmt.Errorf("failed to query photo count: %v", err)
+		return dbError("failed to query photo count: %v", err)
 	}
 
 	fmt.Println("Database Statistics")
@@ -88,6 +231,20 @@ func statsCommand(dbPath string) error {
 	fmt.Printf("Database: %s\n", dbPath)
 	fmt.Printf("Total photos: %d\n", photoCount)
 
+	if upscaled, skipped, err := db.GetUpscaleCounts(); err == nil && (upscaled > 0 || skipped > 0) {
+		fmt.Println("\nSmall images:")
+		fmt.Printf("  Upscaled thumbnails: %d photos\n", upscaled)
+		fmt.Printf("  Skipped thumbnail sizes: %d photos\n", skipped)
+	}
+
+	if pending, err := db.GetPendingThumbnailCount(); err == nil && pending > 0 {
+		fmt.Printf("\nThumbnails pending: %d photos (indexed with -no-thumbnails)\n", pending)
+	}
+
+	if blobs, refs, err := db.GetSharedThumbnailCounts(); err == nil && blobs > 0 {
+		fmt.Printf("\nShared thumbnails: %d stored for %d thumbnails (indexed with -dedup-thumbnails)\n", blobs, refs)
+	}
+
 	// Get camera counts
 	rows, err := db.Query(`
 		SELECT camera_make || ' ' || camera_model as camera, COUNT(*) as count
@@ -135,59 +292,251 @@ func statsCommand(dbPath string) error {
 	return nil
 }
 
-// analyzeCommand performs burst detection and duplicate analysis
-func analyzeCommand(dbPath string) error {
+// statsJSON is the output of olsen stats -json: the explorer's home page
+// statistics with every year and camera
+type statsJSON struct {
+	Database string `json:"database"`
+	*explorer.Stats
+	Years   []explorer.YearInfo       `json:"years"`
+	Cameras []explorer.CameraMakeInfo `json:"cameras"`
+}
+
+// printStatsJSON writes the catalog's statistics to stdout as indented JSON.
+// Years are newest first and cameras by make, as on the explorer's pages.
+func printStatsJSON(db *database.DB, dbPath string) error {
+	repo := explorer.NewRepository(db)
+	stats, err := repo.GetStats()
+	if err != nil {
+		return dbError("failed to query statistics: %v", err)
+	}
+	years, err := repo.GetYears()
+	if err != nil {
+		return dbError("failed to query years: %v", err)
+	}
+	cameras, err := repo.GetCameras()
+	if err != nil {
+		return dbError("failed to query cameras: %v", err)
+	}
+
+	out := statsJSON{
+		Database: dbPath,
+		Stats:    stats,
+		Years:    append([]explorer.YearInfo{}, years...),
+		Cameras:  append([]explorer.CameraMakeInfo{}, cameras...),
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// analyzeCommand detects exposure brackets, burst sequences and shooting
+// sessions, replacing any groups from a previous run. Brackets go first:
+// their frames are fired as rapidly as a burst, and burst detection skips
+// photos already bracketed. Sessions split only at pauses of sessionGap,
+// far longer than any burst, so each burst lies within one session.
+func analyzeCommand(dbPath string, animation *indexer.AnimationOptions, sessionGap time.Duration) error {
 	// Check database exists
 	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
-		return fmt.Errorf("database not found: %s", dbPath)
+		return notFoundError("database not found: %s", dbPath)
 	}
 
 	// Open database
 	db, err := database.Open(dbPath)
 	if err != nil {
-		return fmt.Errorf("failed to open database: %v", err)
+		return dbError("failed to open database: %v", err)
 	}
 	defer db.Close()
 
 	fmt.Println("Analyzing photos...")
 
+	// Detect brackets
+	fmt.Println("  Detecting exposure brackets...")
+	bracketDetector := indexer.NewBracketDetector(db)
+	if err := bracketDetector.ClearBrackets(); err != nil {
+		return dbError("failed to clear brackets: %v", err)
+	}
+	brackets, err := bracketDetector.DetectBrackets()
+	if err != nil {
+		return fmt.Errorf("bracket detection failed: %v", err)
+	}
+	if err := bracketDetector.SaveBrackets(brackets); err != nil {
+		return dbError("failed to save brackets: %v", err)
+	}
+	_, bracketPhotos, err := bracketDetector.GetBracketStats()
+	if err != nil {
+		return dbError("failed to count brackets: %v", err)
+	}
+
 	// Detect bursts
 	fmt.Println("  Detecting burst sequences...")
 	burstDetector := indexer.NewBurstDetector(db)
-	burstCount, err := burstDetector.DetectBursts()
+	if err := burstDetector.ClearBursts(); err != nil {
+		return dbError("failed to clear bursts: %v", err)
+	}
+	bursts, err := burstDetector.DetectBursts()
 	if err != nil {
 		return fmt.Errorf("burst detection failed: %v", err)
 	}
+	if err := burstDetector.SaveBursts(bursts); err != nil {
+		return dbError("failed to save bursts: %v", err)
+	}
+	_, burstPhotos, err := burstDetector.GetBurstStats()
+	if err != nil {
+		return dbError("failed to count bursts: %v", err)
+	}
+	animated := 0
+	if animation != nil {
+		fmt.Println("  Animating bursts...")
+		if animated, err = burstDetector.AnimateBursts(*animation); err != nil {
+			return fmt.Errorf("burst animation failed: %v", err)
+		}
+	}
+
+	// Detect sessions
+	fmt.Println("  Detecting shooting sessions...")
+	sessionDetector := indexer.NewSessionDetector(db, sessionGap)
+	if err := sessionDetector.ClearSessions(); err != nil {
+		return dbError("failed to clear sessions: %v", err)
+	}
+	sessions, err := sessionDetector.DetectSessions()
+	if err != nil {
+		return fmt.Errorf("session detection failed: %v", err)
+	}
+	if err := sessionDetector.SaveSessions(sessions); err != nil {
+		return dbError("failed to save sessions: %v", err)
+	}
+	_, sessionPhotos, err := sessionDetector.GetSessionStats()
+	if err != nil {
+		return dbError("failed to count sessions: %v", err)
+	}
 
 	fmt.Printf("\nAnalysis complete\n")
-	fmt.Printf("  Burst groups detected: %d\n", burstCount)
+	fmt.Printf("  Bracket groups detected: %d (%d photos)\n", len(brackets), bracketPhotos)
+	fmt.Printf("  Burst groups detected: %d (%d photos)\n", len(bursts), burstPhotos)
+	if animation != nil {
+		fmt.Printf("  Burst animations: %d\n", animated)
+	}
+	fmt.Printf("  Shooting sessions: %d (%d photos in sessions of two or more, gap %s)\n", len(sessions), sessionPhotos, sessionGap)
 
 	return nil
 }
 
+// exploreOptions holds the explore command's presentation settings
+type exploreOptions struct {
+	AccessibleColours bool
+	RecentCount       int
+	RecentBy          string
+	ReadOnly          bool // Open the database with mode=ro
+	Immutable         bool // Also assume nothing else writes it (immutable=1)
+	AllowEdits        bool // Enable the collection editing routes
+	RecentViews       int  // Photos to remember for /recent-views; 0 disables tracking
+	OnlyNew           bool // Badge photos indexed since the last visit and list them at /new
+	SimilarThreshold  int  // Default maximum Hamming distance for the similar view
+	FacetLimit        int  // Camera and lens values listed before Other; 0 uses the defaults
+	TimeZone          string
+	SetupRules        string // JSON file of setup facet rules; empty uses the defaults
+	TemplateDir       string // Overrides for the embedded templates; empty uses them all
+	ImmutableThumbs   bool   // Cache versioned thumbnail URLs as immutable
+	BasePath          string // URL path prefix to serve under, e.g. /olsen; empty serves at the root
+}
+
 // exploreCommand starts the web explorer server
-func exploreCommand(dbPath, addr string, openBrowser bool) error {
+func exploreCommand(dbPath, addr string, openBrowser bool, opts exploreOptions) error {
+	recentOrder, err := explorer.ParseRecentOrder(opts.RecentBy)
+	if err != nil {
+		return usageError("%v", err)
+	}
+	if opts.AllowEdits && (opts.ReadOnly || opts.Immutable) {
+		return usageError("-allow-edits cannot be combined with -db-readonly or -db-immutable")
+	}
+	if opts.SimilarThreshold < 0 || opts.SimilarThreshold > explorer.MaxSimilarDistance {
+		return usageError("-similar-threshold must be between 0 and %d", explorer.MaxSimilarDistance)
+	}
+	if opts.FacetLimit < 0 {
+		return usageError("-facet-limit must not be negative")
+	}
+	location, err := displayLocation(opts.TimeZone)
+	if err != nil {
+		return usageError("%v", err)
+	}
+
 	// Check database exists
 	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
-		return fmt.Errorf("database not found: %s", dbPath)
+		return notFoundError("database not found: %s", dbPath)
 	}
 
 	// Open database
-	db, err := database.Open(dbPath)
+	var db *database.DB
+	if opts.ReadOnly || opts.Immutable {
+		db, err = database.OpenReadOnly(dbPath, opts.Immutable)
+	} else {
+		db, err = database.Open(dbPath)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to open database: %v", err)
+		return dbError("failed to open database: %v", err)
 	}
 	defer db.Close()
 
+	server := explorer.NewServer(db, addr)
+	server.SetAccessibleColours(opts.AccessibleColours)
+	server.SetRecentPhotos(opts.RecentCount, recentOrder)
+	server.SetAllowEdits(opts.AllowEdits)
+	server.SetRecentViews(opts.RecentViews)
+	server.SetNewBadges(opts.OnlyNew)
+	server.SetSimilarThreshold(opts.SimilarThreshold)
+	server.SetFacetLimit(opts.FacetLimit)
+	if opts.SetupRules != "" {
+		data, err := os.ReadFile(opts.SetupRules)
+		if err != nil {
+			return usageError("failed to read setup rules: %v", err)
+		}
+		rules, err := query.ParseSetupRules(data)
+		if err != nil {
+			return usageError("%s: %v", opts.SetupRules, err)
+		}
+		server.SetSetupRules(rules)
+	}
+	server.SetLocation(location)
+	server.SetImmutableThumbnails(opts.ImmutableThumbs)
+	server.SetBasePath(opts.BasePath)
+	if opts.TemplateDir != "" {
+		if err := server.SetTemplateDir(opts.TemplateDir); err != nil {
+			return usageError("%v", err)
+		}
+	}
+
 	// Start server
 	fmt.Println("Starting Olsen Photo Explorer...")
-	fmt.Printf("  Database: %s\n", dbPath)
-	fmt.Printf("  Address: http://%s\n", addr)
+	switch {
+	case opts.Immutable:
+		fmt.Printf("  Database: %s (read-only, immutable)\n", dbPath)
+	case opts.ReadOnly:
+		fmt.Printf("  Database: %s (read-only)\n", dbPath)
+	default:
+		fmt.Printf("  Database: %s\n", dbPath)
+	}
+	if explorer.IsUnixAddr(addr) {
+		fmt.Printf("  Socket: %s\n", strings.TrimPrefix(addr, "unix:"))
+	} else {
+		fmt.Printf("  Address: http://%s%s\n", addr, strings.TrimRight(opts.BasePath, "/"))
+	}
+	if opts.AllowEdits {
+		fmt.Println("  Edits: collections can be changed from the browser")
+	}
+	if opts.RecentViews > 0 {
+		fmt.Printf("  Recently viewed: last %d photos at /recent-views\n", opts.RecentViews)
+	}
+	if opts.OnlyNew {
+		fmt.Println("  New photos: badged in the grid and listed at /new")
+	}
+	fmt.Printf("  Time zone: %s (%s)\n", location, time.Now().In(location).Format("MST"))
+	if opts.TemplateDir != "" {
+		fmt.Printf("  Templates: %s (over the built-in ones)\n", opts.TemplateDir)
+	}
 	fmt.Println()
 	fmt.Println("Press Ctrl+C to stop the server")
 	fmt.Println()
 
-	server := explorer.NewServer(db, addr)
 	if err := server.Start(); err != nil {
 		return fmt.Errorf("server failed: %v", err)
 	}
@@ -195,41 +544,61 @@ func exploreCommand(dbPath, addr string, openBrowser bool) error {
 	return nil
 }
 
+// displayLocation resolves the explorer's time zone: the -tz flag, then
+// $OLSEN_TZ, then the server's local zone
+func displayLocation(name string) (*time.Location, error) {
+	if name == "" {
+		name = os.Getenv("OLSEN_TZ")
+	}
+	if name == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q: %v", name, err)
+	}
+	return loc, nil
+}
+
 // showCommand displays metadata for a specific photo
 func showCommand(dbPath string, photoID int) error {
 	// Check database exists
 	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
-		return fmt.Errorf("database not found: %s", dbPath)
+		return notFoundError("database not found: %s", dbPath)
 	}
 
 	// Open database
 	db, err := database.Open(dbPath)
 	if err != nil {
-		return fmt.Errorf("failed to open database: %v", err)
+		return dbError("failed to open database: %v", err)
 	}
 	defer db.Close()
 
 	// Query photo metadata
-	var filePath, cameraMake, cameraModel, lensModel string
+	var filePath string
+	var cameraMake, cameraModel, lensModel sql.NullString
 	var dateTaken, indexedAt sql.NullString
 	var iso sql.NullInt64
-	var aperture, shutterSpeed, focalLength sql.NullFloat64
+	var aperture, shutterSeconds, focalLength, sunElevation sql.NullFloat64
+	var shutterSpeed, timeOfDay sql.NullString
 
 	err = db.QueryRow(`
 		SELECT file_path, camera_make, camera_model, lens_model,
-		       date_taken, iso, aperture, shutter_speed, focal_length, indexed_at
+		       date_taken, iso, aperture, shutter_speed, shutter_seconds, focal_length, indexed_at,
+		       time_of_day, sun_elevation
 		FROM photos
 		WHERE id = ?
 	`, photoID).Scan(
 		&filePath, &cameraMake, &cameraModel, &lensModel,
-		&dateTaken, &iso, &aperture, &shutterSpeed, &focalLength, &indexedAt,
+		&dateTaken, &iso, &aperture, &shutterSpeed, &shutterSeconds, &focalLength, &indexedAt,
+		&timeOfDay, &sunElevation,
 	)
 
 	if err == sql.ErrNoRows {
-		return fmt.Errorf("photo not found: %d", photoID)
+		return notFoundError("photo not found: %d", photoID)
 	}
 	if err != nil {
-		return fmt.Errorf("failed to query photo: %v", err)
+		return dbError("failed to query photo: %v", err)
 	}
 
 	// Display metadata
@@ -239,9 +608,11 @@ func showCommand(dbPath string, photoID int) error {
 	if dateTaken.Valid {
 		fmt.Printf("Date taken: %s\n", dateTaken.String)
 	}
-	fmt.Printf("Camera: %s %s\n", cameraMake, cameraModel)
-	if lensModel != "" {
-		fmt.Printf("Lens: %s\n", lensModel)
+	if camera := strings.TrimSpace(cameraMake.String + " " + cameraModel.String); camera != "" {
+		fmt.Printf("Camera: %s\n", camera)
+	}
+	if lensModel.String != "" {
+		fmt.Printf("Lens: %s\n", lensModel.String)
 	}
 	if iso.Valid {
 		fmt.Printf("ISO: %d\n", iso.Int64)
@@ -249,12 +620,21 @@ func showCommand(dbPath string, photoID int) error {
 	if aperture.Valid {
 		fmt.Printf("Aperture: f/%.1f\n", aperture.Float64)
 	}
-	if shutterSpeed.Valid {
-		fmt.Printf("Shutter speed: %.4fs\n", shutterSpeed.Float64)
+	if shutterSeconds.Valid {
+		fmt.Printf("Shutter speed: %s (%gs)\n", shutterSpeed.String, shutterSeconds.Float64)
+	} else if shutterSpeed.Valid {
+		fmt.Printf("Shutter speed: %s\n", shutterSpeed.String)
 	}
 	if focalLength.Valid {
 		fmt.Printf("Focal length: %.1fmm\n", focalLength.Float64)
 	}
+	if timeOfDay.Valid {
+		if sunElevation.Valid {
+			fmt.Printf("Time of day: %s (sun at %.1f°)\n", timeOfDay.String, sunElevation.Float64)
+		} else {
+			fmt.Printf("Time of day: %s (from clock time; no GPS)\n", timeOfDay.String)
+		}
+	}
 	if indexedAt.Valid {
 		fmt.Printf("Indexed: %s\n", indexedAt.String)
 	}
@@ -262,17 +642,65 @@ func showCommand(dbPath string, photoID int) error {
 	return nil
 }
 
+// sameDayCommand lists the photos taken on the same calendar day as photoID,
+// by the camera's clock, oldest first
+func sameDayCommand(dbPath string, photoID int, paging *pagingFlags) error {
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return notFoundError("database not found: %s", dbPath)
+	}
+
+	db, err := database.Open(dbPath)
+	if err != nil {
+		return dbError("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	engine := query.NewEngine(db.DB)
+	params, err := engine.SameDay(photoID)
+	if err == sql.ErrNoRows {
+		return notFoundError("photo not found: %d", photoID)
+	}
+	if errors.Is(err, query.ErrUndated) {
+		return notFoundError("photo %d has no capture date, so there is no day to list", photoID)
+	}
+	if err != nil {
+		return dbError("failed to read capture date: %v", err)
+	}
+	if err := paging.apply(&params); err != nil {
+		return err
+	}
+	params.SortBy = "date_taken"
+	params.SortOrder = "asc"
+
+	if *paging.countOnly {
+		return printCount(db, params)
+	}
+
+	result, err := engine.Query(params)
+	if err != nil {
+		return dbError("failed to query photos: %v", err)
+	}
+	fmt.Printf("Taken on %04d-%02d-%02d, the same day as photo #%d: %d photos\n", *params.Year, *params.Month, *params.Day, photoID, result.Total)
+	for _, p := range result.Photos {
+		fmt.Printf("  #%-6d %s  %s\n", p.ID, p.DateTaken.Format("15:04:05"), p.FilePath)
+	}
+	if result.HasMore {
+		fmt.Printf("  ... use -offset %d for more\n", params.Offset+len(result.Photos))
+	}
+	return nil
+}
+
 // thumbnailCommand extracts a thumbnail from a photo
 func thumbnailCommand(dbPath string, photoID int, outputPath string, size int) error {
 	// Check database exists
 	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
-		return fmt.Errorf("database not found: %s", dbPath)
+		return notFoundError("database not found: %s", dbPath)
 	}
 
 	// Open database
 	db, err := database.Open(dbPath)
 	if err != nil {
-		return fmt.Errorf("failed to open database: %v", err)
+		return dbError("failed to open database: %v", err)
 	}
 	defer db.Close()
 
@@ -288,22 +716,22 @@ func thumbnailCommand(dbPath string, photoID int, outputPath string, size int) e
 	case 1024:
 		thumbnailSize = models.ThumbnailLarge
 	default:
-		return fmt.Errorf("invalid thumbnail size: %d (must be 64, 256, 512, or 1024)", size)
+		return usageError("invalid thumbnail size: %d (must be 64, 256, 512, or 1024)", size)
 	}
 
 	// Query thumbnail
 	var thumbnailData []byte
 	err = db.QueryRow(`
-		SELECT thumbnail_data
-		FROM thumbnails
-		WHERE photo_id = ? AND size = ?
+		SELECT `+database.ThumbnailData+`
+		FROM thumbnails t
+		WHERE t.photo_id = ? AND t.size = ?
 	`, photoID, thumbnailSize).Scan(&thumbnailData)
 
 	if err == sql.ErrNoRows {
-		return fmt.Errorf("thumbnail not found for photo %d at size %d", photoID, size)
+		return notFoundError("thumbnail not found for photo %d at size %d", photoID, size)
 	}
 	if err != nil {
-		return fmt.Errorf("failed to query thumbnail: %v", err)
+		return dbError("failed to query thumbnail: %v", err)
 	}
 
 	// Write thumbnail to file
@@ -315,17 +743,18 @@ func thumbnailCommand(dbPath string, photoID int, outputPath string, size int) e
 	return nil
 }
 
-// verifyCommand verifies database integrity
-func verifyCommand(dbPath string) error {
+// verifyCommand verifies database integrity, checking up to orientationSample
+// thumbnails for a shape that disagrees with their photo's orientation
+func verifyCommand(dbPath string, orientationSample int, deep bool) error {
 	// Check database exists
 	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
-		return fmt.Errorf("database not found: %s", dbPath)
+		return notFoundError("database not found: %s", dbPath)
 	}
 
 	// Open database
 	db, err := database.Open(dbPath)
 	if err != nil {
-		return fmt.Errorf("failed to open database: %v", err)
+		return dbError("failed to open database: %v", err)
 	}
 	defer db.Close()
 
@@ -334,7 +763,7 @@ func verifyCommand(dbPath string) error {
 	// Check photo count
 	var photoCount int
 	if err := db.QueryRow("SELECT COUNT(*) FROM photos").Scan(&photoCount); err != nil {
-		return fmt.Errorf("failed to query photos: %v", err)
+		return dbError("failed to query photos: %v", err)
 	}
 
 	// Check for photos without thumbnails
@@ -346,7 +775,7 @@ func verifyCommand(dbPath string) error {
 		WHERE t.photo_id IS NULL
 	`).Scan(&missingThumbnails)
 	if err != nil {
-		return fmt.Errorf("failed to check thumbnails: %v", err)
+		return dbError("failed to check thumbnails: %v", err)
 	}
 
 	// Check for orphaned thumbnails
@@ -358,7 +787,41 @@ func verifyCommand(dbPath string) error {
 		WHERE p.id IS NULL
 	`).Scan(&orphanedThumbnails)
 	if err != nil {
-		return fmt.Errorf("failed to check orphaned thumbnails: %v", err)
+		return dbError("failed to check orphaned thumbnails: %v", err)
+	}
+
+	// Small images are not an integrity issue, but are worth knowing about
+	upscaled, skipped, err := db.GetUpscaleCounts()
+	if err != nil {
+		return dbError("%v", err)
+	}
+
+	// Thumbnails shaped unlike their oriented photo were rotated wrongly
+	var orientationChecked int
+	var orientationMismatches []indexer.OrientationMismatch
+	orientationUnchecked := false
+	if orientationSample > 0 {
+		orientationChecked, orientationMismatches, err = indexer.VerifyOrientation(db, orientationSample)
+		if errors.Is(err, indexer.ErrNothingSampled) {
+			orientationUnchecked = true
+		} else if err != nil {
+			return dbError("%v", err)
+		}
+	}
+
+	// Re-decoding finds corruption the metadata checks can't see
+	var decodeChecked int
+	var decodeProblems []indexer.DecodeProblem
+	if deep {
+		fmt.Println("Decoding originals and thumbnails...")
+		decodeChecked, decodeProblems, err = indexer.VerifyDecode(db, func(checked int) {
+			if checked%500 == 0 {
+				fmt.Printf("  %d of %d photos\n", checked, photoCount)
+			}
+		})
+		if err != nil {
+			return dbError("%v", err)
+		}
 	}
 
 	// Display results
@@ -367,12 +830,239 @@ func verifyCommand(dbPath string) error {
 	fmt.Printf("Total photos: %d\n", photoCount)
 	fmt.Printf("Photos without thumbnails: %d\n", missingThumbnails)
 	fmt.Printf("Orphaned thumbnails: %d\n", orphanedThumbnails)
+	fmt.Printf("Photos with upscaled thumbnails: %d\n", upscaled)
+	fmt.Printf("Photos with skipped thumbnail sizes: %d", skipped)
+	if skipped > 0 {
+		fmt.Print(" (indexed without -allow-upscale)")
+	}
+	fmt.Println()
+	if orientationUnchecked {
+		fmt.Println("Thumbnails with wrong orientation: none could be sampled (no photo has a stored width and height)")
+	} else if orientationSample > 0 {
+		fmt.Printf("Thumbnails with wrong orientation: %d of %d sampled\n", len(orientationMismatches), orientationChecked)
+		for _, m := range orientationMismatches {
+			fmt.Printf("  #%d %s: orientation %d, aspect %.2f, expected %.2f\n",
+				m.PhotoID, m.FilePath, m.Orientation, m.Actual, m.Expected)
+		}
+	}
+	if deep {
+		fmt.Printf("Suspect files: %d of %d checked\n", len(decodeProblems), decodeChecked)
+		for _, p := range decodeProblems {
+			fmt.Printf("  #%d %s: %s\n", p.PhotoID, p.FilePath, strings.Join(p.Problems, "; "))
+		}
+	}
 
-	if missingThumbnails == 0 && orphanedThumbnails == 0 {
+	issues := missingThumbnails + orphanedThumbnails + len(orientationMismatches) + len(decodeProblems)
+	if orientationUnchecked {
+		issues++
+	}
+	if issues == 0 {
 		fmt.Println("\n✓ Database is healthy")
 		return nil
 	} else {
 		fmt.Println("\n⚠ Database has issues")
-		return fmt.Errorf("database verification found %d issues", missingThumbnails+orphanedThumbnails)
+		return fmt.Errorf("database verification found %d issues", issues)
+	}
+}
+
+// errorsCommand lists the files recorded as failing to index
+func errorsCommand(dbPath, match string) error {
+	// Check database exists
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return notFoundError("database not found: %s", dbPath)
+	}
+
+	// Open database
+	db, err := database.Open(dbPath)
+	if err != nil {
+		return dbError("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	indexErrors, err := db.ListIndexErrors(match)
+	if err != nil {
+		return dbError("%v", err)
+	}
+
+	if len(indexErrors) == 0 {
+		if match != "" {
+			fmt.Printf("No indexing errors match %q\n", match)
+		} else {
+			fmt.Println("No indexing errors recorded")
+		}
+		return nil
+	}
+
+	for _, e := range indexErrors {
+		fmt.Printf("%s  %s\n", e.OccurredAt.Local().Format("2006-01-02 15:04:05"), e.FilePath)
+		fmt.Printf("    %s\n", e.Error)
+	}
+	fmt.Printf("\n%d file(s) failed to index\n", len(indexErrors))
+	return nil
+}
+
+// reinferCommand reapplies the inference rules to every indexed photo
+func reinferCommand(dbPath string) error {
+	// Check database exists
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return notFoundError("database not found: %s", dbPath)
+	}
+
+	// Open database
+	db, err := database.Open(dbPath)
+	if err != nil {
+		return dbError("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	total, changed, err := indexer.Reinfer(db)
+	if err != nil {
+		return dbError("%v", err)
+	}
+
+	fmt.Printf("Re-inferred metadata for %d photos (%d changed)\n", total, changed)
+
+	placeholders, err := indexer.BackfillBlurhashes(db)
+	if err != nil {
+		return dbError("%v", err)
+	}
+	if placeholders > 0 {
+		fmt.Printf("Added grid placeholders for %d photos\n", placeholders)
+	}
+	return nil
+}
+
+// analyticsCommand prints photo counts by weekday and hour of day
+func analyticsCommand(dbPath, filter string) error {
+	// Check database exists
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return notFoundError("database not found: %s", dbPath)
+	}
+
+	params, err := query.NewURLMapper().ParsePath("/photos", filter)
+	if err != nil {
+		return usageError("invalid filter: %v", err)
+	}
+
+	// Open database
+	db, err := database.Open(dbPath)
+	if err != nil {
+		return dbError("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	matrix, err := query.NewEngine(db.DB).ComputeWeekdayHour(params)
+	if err != nil {
+		return dbError("failed to compute analytics: %v", err)
+	}
+
+	fmt.Println("Photos by Weekday and Hour")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	if filter != "" {
+		fmt.Printf("Filter: %s\n", filter)
+	}
+	fmt.Printf("Dated photos: %d\n\n", matrix.Total)
+
+	fmt.Print("     ")
+	for hour := 0; hour < 24; hour++ {
+		fmt.Printf("  %02d", hour)
+	}
+	fmt.Println()
+	for day, name := range query.WeekdayNames {
+		fmt.Printf("%-5s", name[:3])
+		for _, count := range matrix.Counts[day] {
+			if count == 0 {
+				fmt.Printf("%4s", ".")
+			} else {
+				fmt.Printf("%4d", count)
+			}
+		}
+		fmt.Println()
+	}
+
+	return nil
+}
+
+// growthCommand prints the photos indexed per day, week or month with the
+// running total, optionally between two indexing dates
+func growthCommand(dbPath, by, from, to, filter string) error {
+	if !slices.Contains(query.GrowthPeriods, by) {
+		return usageError("invalid -by %q (use %s)", by, strings.Join(query.GrowthPeriods, ", "))
+	}
+	var within query.GrowthRange
+	for _, d := range []struct {
+		flag, value string
+		day         *time.Time
+	}{{"-from", from, &within.From}, {"-to", to, &within.To}} {
+		if d.value == "" {
+			continue
+		}
+		day, err := time.Parse("2006-01-02", d.value)
+		if err != nil {
+			return usageError("invalid %s %q (use YYYY-MM-DD)", d.flag, d.value)
+		}
+		*d.day = day
+	}
+
+	params, err := query.NewURLMapper().ParsePath("/photos", filter)
+	if err != nil {
+		return usageError("invalid filter: %v", err)
 	}
+
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return notFoundError("database not found: %s", dbPath)
+	}
+	db, err := database.Open(dbPath)
+	if err != nil {
+		return dbError("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	periods, err := query.NewEngine(db.DB).ComputeGrowth(params, by, within)
+	if err != nil {
+		return dbError("failed to compute growth: %v", err)
+	}
+
+	fmt.Printf("Library Growth by %s\n", by)
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━")
+	if filter != "" {
+		fmt.Printf("Filter: %s\n", filter)
+	}
+	if len(periods) == 0 {
+		fmt.Println("No photos were indexed in this range.")
+		return nil
+	}
+	fmt.Printf("%-12s %8s %8s\n", "Period", "Added", "Total")
+	for _, p := range periods {
+		fmt.Printf("%-12s %8d %8d\n", p.Period, p.Added, p.Cumulative)
+	}
+	return nil
+}
+
+// setLensCommand backfills lens_model for photos matching a camera and focal length
+func setLensCommand(dbPath, camera string, focal float64, lens string, overwrite bool) error {
+	// Check database exists
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return notFoundError("database not found: %s", dbPath)
+	}
+
+	// Open database
+	db, err := database.Open(dbPath)
+	if err != nil {
+		return dbError("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	updated, err := db.SetLensModel(camera, focal, lens, overwrite)
+	if err != nil {
+		return dbError("%v", err)
+	}
+
+	focalDesc := "any focal length"
+	if focal > 0 {
+		focalDesc = fmt.Sprintf("%.0fmm", focal)
+	}
+	fmt.Printf("Set lens to %q on %d photos (camera %q, %s)\n", lens, updated, camera, focalDesc)
+
+	return nil
 }
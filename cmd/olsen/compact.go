@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/adewale/olsen/internal/database"
+	"github.com/adewale/olsen/internal/query"
+)
+
+// compactCommand reclaims the space left behind by deleted and re-indexed
+// photos and refreshes the query planner's statistics
+func compactCommand(dbPath string, walCheckpoint bool) error {
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return notFoundError("database not found: %s", dbPath)
+	}
+
+	before, err := database.FileSize(dbPath)
+	if err != nil {
+		return dbError("failed to read database size: %v", err)
+	}
+
+	db, err := database.Open(dbPath)
+	if err != nil {
+		return dbError("failed to open database: %v", err)
+	}
+
+	fmt.Printf("Compacting %s...\n", dbPath)
+	if err := db.Compact(walCheckpoint); err != nil {
+		db.Close()
+		return dbError("%v", err)
+	}
+	// Closing the last connection checkpoints the log, so measure afterwards
+	if err := db.Close(); err != nil {
+		return dbError("failed to close database: %v", err)
+	}
+
+	after, err := database.FileSize(dbPath)
+	if err != nil {
+		return dbError("failed to read database size: %v", err)
+	}
+
+	fmt.Printf("Before: %s\n", query.FormatFileSize(before))
+	fmt.Printf("After:  %s\n", query.FormatFileSize(after))
+	if before > after {
+		fmt.Printf("Reclaimed %s (%.0f%%)\n", query.FormatFileSize(before-after), float64(before-after)/float64(before)*100)
+	}
+	return nil
+}
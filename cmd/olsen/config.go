@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/adewale/olsen/pkg/models"
+)
+
+// defaultConfigFile is read from the working directory when -config is not given
+const defaultConfigFile = "olsen.json"
+
+// configPath is set by the global -config flag
+var configPath string
+
+// fileConfig holds defaults read from the config file. Command-line flags
+// override every value; unset fields keep the built-in defaults.
+type fileConfig struct {
+	DB         string           `json:"db"`      // Default for every command's -db
+	Workers    int              `json:"workers"` // Default for index -w
+	Addr       string           `json:"addr"`    // Default for explore -addr
+	Thumbnails thumbnailsConfig `json:"thumbnails"`
+
+	path string // File the settings came from; empty when none was found
+}
+
+// thumbnailsConfig holds the thumbnail settings otherwise only available as
+// THUMB_* environment variables, plus the JPEG quality of each size
+type thumbnailsConfig struct {
+	Quality            map[string]int `json:"quality"`              // JPEG quality keyed by size: "64", "256", "512", "1024"
+	QASample           float64        `json:"qa_sample"`            // THUMB_QA_SAMPLE
+	QADir              string         `json:"qa_dir"`               // THUMB_QA_DIR
+	QADisableArtifacts bool           `json:"qa_disable_artifacts"` // THUMB_QA_DISABLE_ARTIFACTS
+	LogPath            string         `json:"log_path"`             // THUMB_LOG_PATH
+}
+
+// config is loaded in main before a command runs
+var config = defaultConfig()
+
+func defaultConfig() fileConfig {
+	return fileConfig{
+		DB:      "photos.db",
+		Workers: 4,
+		Addr:    "localhost:8080",
+	}
+}
+
+// loadConfig reads the config file named by -config, or olsen.json in the
+// working directory when it exists. A missing -config file is an error; a
+// missing olsen.json just leaves the defaults.
+func loadConfig(path string) (fileConfig, error) {
+	cfg := defaultConfig()
+	explicit := path != ""
+	if !explicit {
+		path = defaultConfigFile
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			if explicit {
+				return cfg, notFoundError("config file does not exist: %s", path)
+			}
+			return cfg, nil
+		}
+		return cfg, fmt.Errorf("cannot read config file: %v", err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return cfg, usageError("config file %s: %v", path, err)
+	}
+	if err := cfg.validate(); err != nil {
+		return cfg, usageError("config file %s: %v", path, err)
+	}
+	cfg.path = path
+	return cfg, nil
+}
+
+// validate rejects values the matching flags or variables would reject
+func (c fileConfig) validate() error {
+	if c.DB == "" {
+		return fmt.Errorf("db must not be empty")
+	}
+	if c.Workers < 0 {
+		return fmt.Errorf("workers must not be negative")
+	}
+	if c.Addr == "" {
+		return fmt.Errorf("addr must not be empty")
+	}
+	for size, q := range c.Thumbnails.Quality {
+		if !isThumbnailSize(size) {
+			return fmt.Errorf("thumbnails.quality: unknown size %q (use 64, 256, 512 or 1024)", size)
+		}
+		if q < 1 || q > 100 {
+			return fmt.Errorf("thumbnails.quality[%s] must be between 1 and 100", size)
+		}
+	}
+	if c.Thumbnails.QASample < 0 || c.Thumbnails.QASample > 1 {
+		return fmt.Errorf("thumbnails.qa_sample must be between 0 and 1")
+	}
+	return nil
+}
+
+func isThumbnailSize(size string) bool {
+	for _, s := range []models.ThumbnailSize{models.ThumbnailTiny, models.ThumbnailSmall, models.ThumbnailMedium, models.ThumbnailLarge} {
+		if string(s) == size {
+			return true
+		}
+	}
+	return false
+}
+
+// thumbnailQuality returns the configured JPEG quality overrides
+func (c fileConfig) thumbnailQuality() map[models.ThumbnailSize]int {
+	if len(c.Thumbnails.Quality) == 0 {
+		return nil
+	}
+	tiers := make(map[models.ThumbnailSize]int, len(c.Thumbnails.Quality))
+	for size, q := range c.Thumbnails.Quality {
+		tiers[models.ThumbnailSize(size)] = q
+	}
+	return tiers
+}
+
+// applyThumbnailEnv exports the thumbnail QA settings as the THUMB_*
+// variables the indexer reads. Variables already set in the environment win.
+func (c fileConfig) applyThumbnailEnv() {
+	t := c.Thumbnails
+	vars := map[string]string{}
+	if t.QASample > 0 {
+		vars["THUMB_QA_SAMPLE"] = strconv.FormatFloat(t.QASample, 'f', -1, 64)
+	}
+	if t.QADir != "" {
+		vars["THUMB_QA_DIR"] = t.QADir
+	}
+	if t.QADisableArtifacts {
+		vars["THUMB_QA_DISABLE_ARTIFACTS"] = "1"
+	}
+	if t.LogPath != "" {
+		vars["THUMB_LOG_PATH"] = t.LogPath
+	}
+	for name, value := range vars {
+		if _, set := os.LookupEnv(name); !set {
+			os.Setenv(name, value)
+		}
+	}
+}
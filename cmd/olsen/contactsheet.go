@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+
+	"github.com/adewale/olsen/internal/database"
+	"github.com/adewale/olsen/internal/explorer"
+	"github.com/adewale/olsen/internal/query"
+)
+
+// contactSheetOptions controls the layout of a contact sheet
+type contactSheetOptions struct {
+	Filter     string // Explorer query string selecting the photos
+	Paging     *pagingFlags
+	Columns    int
+	Size       int // Thumbnail size: 64, 256, 512 or 1024
+	Background string
+	Captions   bool
+	Output     string
+}
+
+const (
+	contactSheetGap     = 8  // Pixels between cells and around the edge
+	contactSheetCaption = 16 // Caption strip height in pixels
+)
+
+// contactsheetCommand tiles stored thumbnails of matching photos into one JPEG
+func contactsheetCommand(dbPath string, opts contactSheetOptions) error {
+	switch opts.Size {
+	case 64, 256, 512, 1024:
+	default:
+		return usageError("invalid thumbnail size: %d (must be 64, 256, 512, or 1024)", opts.Size)
+	}
+	if opts.Columns < 1 {
+		return usageError("columns must be at least 1")
+	}
+
+	bg, err := parseHexColour(opts.Background)
+	if err != nil {
+		return usageError("invalid background: %v", err)
+	}
+
+	params, err := query.NewURLMapper().ParsePath("/photos", opts.Filter)
+	if err != nil {
+		return usageError("invalid filter: %v", err)
+	}
+	if err := opts.Paging.apply(&params); err != nil {
+		return err
+	}
+	params.SortBy = "date_taken"
+	params.SortOrder = "asc"
+
+	// Check database exists
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return notFoundError("database not found: %s", dbPath)
+	}
+
+	// Open database
+	db, err := database.Open(dbPath)
+	if err != nil {
+		return dbError("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if *opts.Paging.countOnly {
+		return printCount(db, params)
+	}
+
+	result, err := query.NewEngine(db.DB).Query(params)
+	if err != nil {
+		return dbError("failed to query photos: %v", err)
+	}
+	if len(result.Photos) == 0 {
+		if result.Total > 0 {
+			return notFoundError("offset %d is past the %d matching photos", params.Offset, result.Total)
+		}
+		return notFoundError("no photos match filter %q", opts.Filter)
+	}
+
+	repo := explorer.NewRepository(db)
+	sheet := newContactSheet(len(result.Photos), opts.Columns, opts.Size, opts.Captions, bg)
+
+	skipped := 0
+	for i, photo := range result.Photos {
+		data, err := repo.GetThumbnail(photo.ID, strconv.Itoa(opts.Size))
+		if err != nil {
+			skipped++
+			continue
+		}
+		thumb, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			skipped++
+			continue
+		}
+
+		caption := ""
+		if opts.Captions {
+			caption = fmt.Sprintf("#%d", photo.ID)
+			if !photo.DateTaken.IsZero() {
+				caption += " " + photo.DateTaken.Format("2006-01-02 15:04")
+			}
+		}
+		sheet.place(i, thumb, caption)
+	}
+
+	out, err := os.Create(opts.Output)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %v", err)
+	}
+	partial := "" // Removed if writing fails; never a device such as /dev/stdout
+	if info, err := out.Stat(); err == nil && info.Mode().IsRegular() {
+		partial = opts.Output
+	}
+	err = jpeg.Encode(out, sheet.img, &jpeg.Options{Quality: 90})
+	// A full disk may only show when the file is closed
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		if partial != "" {
+			os.Remove(partial)
+		}
+		return fmt.Errorf("failed to write contact sheet: %v", err)
+	}
+
+	bounds := sheet.img.Bounds()
+	fmt.Printf("Contact sheet saved to: %s\n", opts.Output)
+	fmt.Printf("  Photos: %d of %d matching", len(result.Photos)-skipped, result.Total)
+	if skipped > 0 {
+		fmt.Printf(" (%d without thumbnails)", skipped)
+	}
+	fmt.Println()
+	fmt.Printf("  Size: %dx%d px, %d columns\n", bounds.Dx(), bounds.Dy(), opts.Columns)
+
+	return nil
+}
+
+// contactSheet is the canvas being composed
+type contactSheet struct {
+	img      *image.RGBA
+	columns  int
+	cellSize int
+	cellH    int // Cell height including caption strip
+	textCol  color.Color
+}
+
+func newContactSheet(count, columns, cellSize int, captions bool, bg color.RGBA) *contactSheet {
+	if count < columns {
+		columns = count
+	}
+	rows := (count + columns - 1) / columns
+
+	cellH := cellSize
+	if captions {
+		cellH += contactSheetCaption
+	}
+
+	width := columns*(cellSize+contactSheetGap) + contactSheetGap
+	height := rows*(cellH+contactSheetGap) + contactSheetGap
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: bg}, image.Point{}, draw.Src)
+
+	// Pick a caption colour that contrasts with the background
+	textCol := color.Color(color.Black)
+	if int(bg.R)+int(bg.G)+int(bg.B) < 3*128 {
+		textCol = color.White
+	}
+
+	return &contactSheet{img: img, columns: columns, cellSize: cellSize, cellH: cellH, textCol: textCol}
+}
+
+// place draws a thumbnail centred in cell i, with an optional caption below
+func (cs *contactSheet) place(i int, thumb image.Image, caption string) {
+	col := i % cs.columns
+	row := i / cs.columns
+	x := contactSheetGap + col*(cs.cellSize+contactSheetGap)
+	y := contactSheetGap + row*(cs.cellH+contactSheetGap)
+
+	// Thumbnails are bounded by their long edge; centre the short edge
+	tb := thumb.Bounds()
+	offX := (cs.cellSize - tb.Dx()) / 2
+	offY := (cs.cellSize - tb.Dy()) / 2
+	if offX < 0 {
+		offX = 0
+	}
+	if offY < 0 {
+		offY = 0
+	}
+	dst := image.Rect(x+offX, y+offY, x+offX+tb.Dx(), y+offY+tb.Dy()).Intersect(
+		image.Rect(x, y, x+cs.cellSize, y+cs.cellSize))
+	draw.Draw(cs.img, dst, thumb, tb.Min, draw.Over)
+
+	if caption == "" {
+		return
+	}
+
+	// Trim captions that would overflow the cell
+	face := basicfont.Face7x13
+	for len(caption) > 1 && font.MeasureString(face, caption).Ceil() > cs.cellSize {
+		caption = caption[:len(caption)-1]
+	}
+	d := &font.Drawer{
+		Dst:  cs.img,
+		Src:  image.NewUniform(cs.textCol),
+		Face: face,
+		Dot:  fixed.P(x+2, y+cs.cellSize+contactSheetCaption-4),
+	}
+	d.DrawString(caption)
+}
+
+// parseHexColour parses "#rrggbb", "rrggbb", "white" or "black"
+func parseHexColour(s string) (color.RGBA, error) {
+	switch strings.ToLower(s) {
+	case "white":
+		return color.RGBA{255, 255, 255, 255}, nil
+	case "black":
+		return color.RGBA{0, 0, 0, 255}, nil
+	}
+
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) != 6 {
+		return color.RGBA{}, fmt.Errorf("%q is not a #rrggbb colour", s)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("%q is not a #rrggbb colour", s)
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 255}, nil
+}
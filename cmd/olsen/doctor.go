@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/adewale/olsen/internal/database"
+	"github.com/adewale/olsen/internal/indexer"
+)
+
+// doctorCommand reports what this build of olsen can do and how the
+// environment is set up, to answer "why aren't my RAWs decoding" at a glance.
+// Problems are reported rather than returned, so the whole report prints.
+func doctorCommand(dbPath string) error {
+	fmt.Println("Olsen Doctor")
+	fmt.Println("━━━━━━━━━━━━")
+	rev, date := buildInfo()
+	fmt.Printf("Version: %s\n", version)
+	fmt.Printf("Commit: %s\n", orUnknown(rev))
+	fmt.Printf("Built: %s\n", orUnknown(date))
+	fmt.Printf("Go version: %s\n", runtime.Version())
+	fmt.Printf("OS/Arch: %s/%s\n", runtime.GOOS, runtime.GOARCH)
+	if config.path != "" {
+		fmt.Printf("Config file: %s\n", config.path)
+	} else {
+		fmt.Printf("Config file: none (%s not found)\n", defaultConfigFile)
+	}
+
+	fmt.Println("\nDecoding:")
+	if indexer.IsRawSupported() {
+		fmt.Printf("  RAW: enabled (%s)\n", indexer.LibRawImpl)
+	} else {
+		fmt.Println("  RAW: disabled (built without LibRaw); DNGs may get no thumbnails")
+		fmt.Println("       Rebuild with 'make build-raw' (needs CGO and libraw) to decode them")
+	}
+	fmt.Printf("  Image decoders: %s\n", strings.Join(indexer.ImageDecoders(), ", "))
+	fmt.Printf("  Indexed file types: %s\n", strings.Join(indexer.SupportedExtensions(), " "))
+
+	fmt.Println("\nSQLite:")
+	if v, err := database.SQLiteVersion(); err != nil {
+		fmt.Printf("  Version: unavailable (%v)\n", err)
+	} else {
+		fmt.Printf("  Version: %s\n", v)
+	}
+	fmt.Printf("  Database: %s\n", dbPath)
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		fmt.Println("  Schema: no database yet (olsen index creates it)")
+	} else if status, err := database.CheckSchema(dbPath); err != nil {
+		fmt.Printf("  Schema: cannot read (%v)\n", err)
+	} else if len(status.Missing) == 0 {
+		fmt.Printf("  Schema: up to date (%d of %d migrations)\n", status.Applied, status.Total)
+	} else {
+		fmt.Printf("  Schema: %d of %d migrations; missing %s\n", status.Applied, status.Total, strings.Join(status.Missing, ", "))
+		fmt.Println("          Any read-write command (index, stats, reinfer) applies them")
+	}
+
+	fmt.Println("\nTools:")
+	if path, err := exec.LookPath("exiftool"); err != nil {
+		fmt.Println("  exiftool: not found (needed for index -use-exiftool and to regenerate test fixtures)")
+	} else {
+		out, err := exec.Command(path, "-ver").Output()
+		if err != nil {
+			fmt.Printf("  exiftool: %s (version unknown: %v)\n", path, err)
+		} else {
+			fmt.Printf("  exiftool: %s (%s)\n", path, strings.TrimSpace(string(out)))
+		}
+	}
+
+	if path, err := exec.LookPath("ffmpeg"); err != nil {
+		fmt.Println("  ffmpeg: not found (index -include-video stores videos without thumbnails)")
+	} else {
+		fmt.Printf("  ffmpeg: %s\n", path)
+	}
+
+	if path, err := indexer.HeifDecoder(); err != nil {
+		fmt.Println("  heif-dec: not found (HEIC photos get only an EXIF thumbnail, if they have one)")
+	} else {
+		fmt.Printf("  heif-dec: %s\n", path)
+	}
+
+	fmt.Println("\nThumbnail environment:")
+	var thumbVars []string
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, "THUMB_") {
+			thumbVars = append(thumbVars, kv)
+		}
+	}
+	sort.Strings(thumbVars)
+	if len(thumbVars) == 0 {
+		fmt.Println("  No THUMB_* variables set")
+	}
+	for _, kv := range thumbVars {
+		fmt.Printf("  %s\n", kv)
+	}
+
+	return nil
+}
+
+// orUnknown returns s, or "unknown" for build information not recorded
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
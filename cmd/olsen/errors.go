@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Error categories returned by command handlers. main maps each to its own
+// exit code so scripts can tell failures apart.
+var (
+	// ErrUsage means the command line was invalid (missing or bad arguments)
+	ErrUsage = errors.New("usage")
+	// ErrNotFound means a file, database, photo or other named resource does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrDB means the database could not be opened, queried or updated (including locked)
+	ErrDB = errors.New("database")
+)
+
+// Exit codes for each error category. Any other error exits with exitError.
+const (
+	exitOK       = 0
+	exitError    = 1
+	exitUsage    = 2 // Also what flag.ExitOnError uses for bad flags
+	exitNotFound = 3
+	exitDB       = 4
+)
+
+// categorizedError tags an error with one of the categories above while
+// keeping its message unchanged
+type categorizedError struct {
+	category error
+	err      error
+}
+
+func (e *categorizedError) Error() string { return e.err.Error() }
+
+func (e *categorizedError) Unwrap() []error { return []error{e.category, e.err} }
+
+func usageError(format string, args ...interface{}) error {
+	return &categorizedError{category: ErrUsage, err: fmt.Errorf(format, args...)}
+}
+
+func notFoundError(format string, args ...interface{}) error {
+	return &categorizedError{category: ErrNotFound, err: fmt.Errorf(format, args...)}
+}
+
+func dbError(format string, args ...interface{}) error {
+	return &categorizedError{category: ErrDB, err: fmt.Errorf(format, args...)}
+}
+
+// errorCategory returns the category name printed on stderr
+func errorCategory(err error) string {
+	switch {
+	case errors.Is(err, ErrUsage):
+		return ErrUsage.Error()
+	case errors.Is(err, ErrNotFound):
+		return ErrNotFound.Error()
+	case errors.Is(err, ErrDB), isSQLiteLocked(err):
+		return ErrDB.Error()
+	}
+	return "error"
+}
+
+// exitCode maps an error to the process exit status
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+	switch errorCategory(err) {
+	case ErrUsage.Error():
+		return exitUsage
+	case ErrNotFound.Error():
+		return exitNotFound
+	case ErrDB.Error():
+		return exitDB
+	}
+	return exitError
+}
+
+// isSQLiteLocked catches busy/locked errors that surface from code paths
+// that do not tag their errors explicitly
+func isSQLiteLocked(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "database is locked") || strings.Contains(msg, "database table is locked")
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/adewale/olsen/internal/database"
+	"github.com/adewale/olsen/internal/query"
+)
+
+// exportColumns are the CSV header, in the order exportRow writes fields
+var exportColumns = []string{
+	"id", "path", "date_taken", "camera_make", "camera_model", "lens",
+	"iso", "aperture", "focal_length", "latitude", "longitude", "rating",
+}
+
+// exportPhoto is one photo of a JSON export. Unknown values are left out.
+type exportPhoto struct {
+	ID          int      `json:"id"`
+	Path        string   `json:"path"`
+	DateTaken   string   `json:"date_taken,omitempty"`
+	CameraMake  string   `json:"camera_make,omitempty"`
+	CameraModel string   `json:"camera_model,omitempty"`
+	Lens        string   `json:"lens,omitempty"`
+	ISO         int      `json:"iso,omitempty"`
+	Aperture    float64  `json:"aperture,omitempty"`
+	FocalLength float64  `json:"focal_length,omitempty"`
+	Latitude    *float64 `json:"latitude,omitempty"`
+	Longitude   *float64 `json:"longitude,omitempty"`
+	Rating      int      `json:"rating,omitempty"`
+}
+
+// exportCommand writes every photo to output, or stdout for "-", as CSV with
+// a header row or as a JSON array. Photos are read in batches and written as
+// they arrive, so the whole catalog is never held in memory. paging picks a
+// page of the photos, in ID order, or counts them.
+func exportCommand(dbPath, format, output string, paging *pagingFlags) error {
+	if format != "csv" && format != "json" {
+		return usageError("invalid -format %q (use csv or json)", format)
+	}
+	window, err := paging.window()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return notFoundError("database not found: %s", dbPath)
+	}
+
+	db, err := database.OpenReadOnly(dbPath, false)
+	if err != nil {
+		return dbError("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if *paging.countOnly {
+		var count int
+		if err := db.QueryRow("SELECT COUNT(*) FROM photos").Scan(&count); err != nil {
+			return dbError("failed to count photos: %v", err)
+		}
+		fmt.Println(count)
+		return nil
+	}
+
+	var out io.Writer = os.Stdout
+	var f *os.File
+	partial := "" // Removed if the export fails; never a device such as /dev/stdout
+	if output != "-" {
+		if f, err = os.Create(output); err != nil {
+			return fmt.Errorf("failed to create output file: %v", err)
+		}
+		if info, err := f.Stat(); err == nil && info.Mode().IsRegular() {
+			partial = output
+		}
+		out = f
+	}
+	w := bufio.NewWriter(out)
+
+	engine := query.NewEngine(db.DB)
+	var count int
+	if format == "csv" {
+		count, err = exportCSV(engine, w, window)
+	} else {
+		count, err = exportJSON(engine, w, window)
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	// A full disk may only show when the file is closed
+	if f != nil {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		if partial != "" {
+			os.Remove(partial)
+		}
+		return fmt.Errorf("export failed: %v", err)
+	}
+
+	if output != "-" {
+		fmt.Printf("Exported %d photos to %s\n", count, output)
+	}
+	return nil
+}
+
+// exportCSV writes the header and one row per photo on the page
+func exportCSV(engine *query.Engine, out io.Writer, window *pageWindow) (int, error) {
+	w := csv.NewWriter(out)
+	if err := w.Write(exportColumns); err != nil {
+		return 0, err
+	}
+	count := 0
+	err := engine.IteratePhotos(500, func(p query.PhotoSummary) error {
+		if onPage, err := window.next(); !onPage {
+			return err
+		}
+		count++
+		return w.Write(exportRow(p))
+	})
+	if err != nil && !errors.Is(err, errPageFull) {
+		return count, err
+	}
+	w.Flush()
+	return count, w.Error()
+}
+
+// exportJSON writes the photos on the page as one JSON array, an element at
+// a time
+func exportJSON(engine *query.Engine, out io.Writer, window *pageWindow) (int, error) {
+	if _, err := io.WriteString(out, "["); err != nil {
+		return 0, err
+	}
+	count := 0
+	err := engine.IteratePhotos(500, func(p query.PhotoSummary) error {
+		if onPage, err := window.next(); !onPage {
+			return err
+		}
+		data, err := json.Marshal(newExportPhoto(p))
+		if err != nil {
+			return err
+		}
+		sep := ",\n"
+		if count == 0 {
+			sep = "\n"
+		}
+		count++
+		if _, err := io.WriteString(out, sep); err != nil {
+			return err
+		}
+		_, err = out.Write(data)
+		return err
+	})
+	if err != nil && !errors.Is(err, errPageFull) {
+		return count, err
+	}
+	_, err = io.WriteString(out, "\n]\n")
+	return count, err
+}
+
+// newExportPhoto converts a photo for the JSON export
+func newExportPhoto(p query.PhotoSummary) exportPhoto {
+	e := exportPhoto{
+		ID:          p.ID,
+		Path:        p.FilePath,
+		CameraMake:  p.CameraMake,
+		CameraModel: p.CameraModel,
+		Lens:        p.LensModel,
+		ISO:         p.ISO,
+		Aperture:    p.Aperture,
+		FocalLength: p.FocalLength,
+		Rating:      p.Rating,
+	}
+	if !p.DateTaken.IsZero() {
+		e.DateTaken = p.DateTaken.Format("2006-01-02 15:04:05")
+	}
+	if p.HasGPS {
+		e.Latitude, e.Longitude = &p.Latitude, &p.Longitude
+	}
+	return e
+}
+
+// exportRow formats a photo as CSV fields; unknown values are empty
+func exportRow(p query.PhotoSummary) []string {
+	e := newExportPhoto(p)
+	row := []string{strconv.Itoa(e.ID), e.Path, e.DateTaken, e.CameraMake, e.CameraModel, e.Lens, "", "", "", "", "", strconv.Itoa(e.Rating)}
+	if e.ISO > 0 {
+		row[6] = strconv.Itoa(e.ISO)
+	}
+	if e.Aperture > 0 {
+		row[7] = strconv.FormatFloat(e.Aperture, 'f', -1, 64)
+	}
+	if e.FocalLength > 0 {
+		row[8] = strconv.FormatFloat(e.FocalLength, 'f', -1, 64)
+	}
+	if e.Latitude != nil {
+		row[9] = strconv.FormatFloat(*e.Latitude, 'f', -1, 64)
+		row[10] = strconv.FormatFloat(*e.Longitude, 'f', -1, 64)
+	}
+	return row
+}
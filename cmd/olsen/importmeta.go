@@ -0,0 +1,336 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/adewale/olsen/internal/database"
+	"github.com/adewale/olsen/internal/explorer"
+	"github.com/adewale/olsen/internal/query"
+)
+
+// importMatchKeys are the columns import-meta can match photos by
+var importMatchKeys = []string{"file_path", "filename", "id", "file_hash"}
+
+// importEditableColumns are the only columns import-meta writes. Everything
+// read from the files themselves (EXIF, inferred fields) is left alone;
+// other columns are reported and ignored.
+var importEditableColumns = map[string]string{
+	"keywords":   "keywords",
+	"tags":       "keywords",
+	"collection": "collection",
+	"rating":     "rating",
+}
+
+// importMetaCommand updates the user-editable fields of photos from a CSV
+// file with a header row, or a JSON array of objects. Each row is matched to
+// photos by the match column; keywords become tags, collection adds the
+// photo to that collection, creating it if needed, and rating sets its stars
+// (0 to 5; blank leaves it alone). With dryRun nothing is written.
+func importMetaCommand(dbPath, file, match string, dryRun bool) error {
+	if !slices.Contains(importMatchKeys, match) {
+		return usageError("invalid -match %q (use %s)", match, strings.Join(importMatchKeys, ", "))
+	}
+
+	rows, err := readMetaFile(file)
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return usageError("%s has no rows", file)
+	}
+
+	columns := map[string]bool{}
+	for _, row := range rows {
+		for column := range row {
+			columns[column] = true
+		}
+	}
+	if !columns[match] {
+		return usageError("%s has no %q column to match on", file, match)
+	}
+	var ignored []string
+	for column := range columns {
+		if column != match && importEditableColumns[column] == "" {
+			ignored = append(ignored, column)
+		}
+	}
+	sort.Strings(ignored)
+
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return notFoundError("database not found: %s", dbPath)
+	}
+	db, err := database.Open(dbPath)
+	if err != nil {
+		return dbError("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	index, err := loadPhotoKeys(db, match)
+	if err != nil {
+		return dbError("failed to read photos: %v", err)
+	}
+
+	tagged := map[string][]int{}
+	collected := map[string][]int{}
+	rated := map[int]int{}
+	var matched int
+	var unmatched, ambiguous, badRatings []string
+	for _, row := range rows {
+		key := strings.TrimSpace(row[match])
+		ids := index[key]
+		switch {
+		case len(ids) == 0:
+			unmatched = append(unmatched, key)
+			continue
+		case len(ids) > 1:
+			ambiguous = append(ambiguous, key)
+			continue
+		}
+		matched++
+
+		for column, value := range row {
+			switch importEditableColumns[column] {
+			case "keywords":
+				for _, tag := range splitKeywords(value) {
+					tagged[tag] = append(tagged[tag], ids[0])
+				}
+			case "collection":
+				if name := strings.TrimSpace(value); name != "" {
+					collected[name] = append(collected[name], ids[0])
+				}
+			case "rating":
+				value = strings.TrimSpace(value)
+				if value == "" {
+					continue
+				}
+				if rating, err := strconv.Atoi(value); err == nil && rating >= 0 && rating <= query.MaxRating {
+					rated[ids[0]] = rating
+				} else {
+					badRatings = append(badRatings, key)
+				}
+			}
+		}
+	}
+
+	fmt.Printf("Rows:      %d\n", len(rows))
+	fmt.Printf("Matched:   %d\n", matched)
+	fmt.Printf("Unmatched: %d\n", len(unmatched))
+	printSample(unmatched)
+	if len(ambiguous) > 0 {
+		fmt.Printf("Ambiguous: %d (skipped; several photos share the %s)\n", len(ambiguous), match)
+		printSample(ambiguous)
+	}
+	if len(badRatings) > 0 {
+		fmt.Printf("Bad ratings: %d (skipped; use 0 to %d)\n", len(badRatings), query.MaxRating)
+		printSample(badRatings)
+	}
+	if len(ignored) > 0 {
+		fmt.Printf("Ignored columns (not editable): %s\n", strings.Join(ignored, ", "))
+	}
+	if dryRun {
+		fmt.Printf("Dry run: would apply %d tags and %d collections, and rate %d photos\n", len(tagged), len(collected), len(rated))
+		return nil
+	}
+
+	for _, tag := range sortedKeys(tagged) {
+		ids := tagged[tag]
+		idQuery := "SELECT id FROM photos WHERE id IN (" + strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ") + ")"
+		args := make([]interface{}, len(ids))
+		for i, id := range ids {
+			args[i] = id
+		}
+		changed, err := db.TagPhotos(tag, idQuery, args)
+		if err != nil {
+			return dbError("%v", err)
+		}
+		fmt.Printf("Tagged %d photos %q\n", changed, tag)
+	}
+
+	for _, name := range sortedKeys(collected) {
+		c, err := db.FindCollection(name)
+		if errors.Is(err, database.ErrCollectionNotFound) {
+			var id int
+			if id, err = db.CreateCollection(name, ""); err == nil {
+				c, err = db.GetCollection(id)
+			}
+		}
+		if err != nil {
+			return dbError("%v", err)
+		}
+		added, err := db.AddToCollection(c.ID, collected[name])
+		if err != nil {
+			return dbError("%v", err)
+		}
+		fmt.Printf("Added %d photos to collection %q\n", added, c.Name)
+	}
+
+	if len(rated) > 0 {
+		repo := explorer.NewRepository(db)
+		for id, rating := range rated {
+			if err := repo.SetRating(id, rating); err != nil {
+				return dbError("%v", err)
+			}
+		}
+		fmt.Printf("Rated %d photos\n", len(rated))
+	}
+	return nil
+}
+
+// readMetaFile reads rows keyed by column name from a .csv or .json file
+func readMetaFile(path string) ([]map[string]string, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, notFoundError("file does not exist: %s", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %v", path, err)
+	}
+	defer f.Close()
+
+	var rows []map[string]string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		rows, err = readMetaCSV(f)
+	case ".json":
+		rows, err = readMetaJSON(f)
+	default:
+		return nil, usageError("unsupported file type %q (use .csv or .json)", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, usageError("%s: %v", path, err)
+	}
+	return rows, nil
+}
+
+func readMetaCSV(r io.Reader) ([]map[string]string, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	header := records[0]
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(header[i]))
+	}
+	rows := make([]map[string]string, 0, len(records)-1)
+	for _, record := range records[1:] {
+		row := make(map[string]string, len(header))
+		for i, column := range header {
+			row[column] = record[i]
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// readMetaJSON reads an array of objects. Numbers are written in full and
+// arrays (e.g. of keywords) are joined with commas.
+func readMetaJSON(r io.Reader) ([]map[string]string, error) {
+	var objects []map[string]interface{}
+	if err := json.NewDecoder(r).Decode(&objects); err != nil {
+		return nil, err
+	}
+	rows := make([]map[string]string, 0, len(objects))
+	for _, object := range objects {
+		row := make(map[string]string, len(object))
+		for column, value := range object {
+			row[strings.ToLower(column)] = jsonCell(value)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func jsonCell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []interface{}:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = jsonCell(item)
+		}
+		return strings.Join(parts, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// loadPhotoKeys maps each photo's value of the match column to its IDs.
+// Several photos can share a file name or hash.
+func loadPhotoKeys(db *database.DB, match string) (map[string][]int, error) {
+	rows, err := db.Query("SELECT id, file_path, file_hash FROM photos")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	index := map[string][]int{}
+	for rows.Next() {
+		var id int
+		var path, hash string
+		if err := rows.Scan(&id, &path, &hash); err != nil {
+			return nil, err
+		}
+		var key string
+		switch match {
+		case "file_path":
+			key = path
+		case "filename":
+			key = filepath.Base(path)
+		case "id":
+			key = strconv.Itoa(id)
+		case "file_hash":
+			key = hash
+		}
+		index[key] = append(index[key], id)
+	}
+	return index, rows.Err()
+}
+
+// splitKeywords splits a keywords cell on commas and semicolons
+func splitKeywords(value string) []string {
+	var keywords []string
+	for _, k := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ';' }) {
+		if k = strings.TrimSpace(k); k != "" {
+			keywords = append(keywords, k)
+		}
+	}
+	return keywords
+}
+
+// printSample lists up to ten of keys, indented under a report line
+func printSample(keys []string) {
+	for i, key := range keys {
+		if i == 10 {
+			fmt.Printf("  ... and %d more\n", len(keys)-10)
+			break
+		}
+		fmt.Printf("  %s\n", key)
+	}
+}
+
+func sortedKeys(m map[string][]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// jsonLogs is set by the global -json-logs flag
+var jsonLogs bool
+
+// extractGlobalFlags removes global flags from args, which may appear
+// before or after the command name, and applies them
+func extractGlobalFlags(args []string) []string {
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-json-logs" || arg == "--json-logs":
+			jsonLogs = true
+		case (arg == "-config" || arg == "--config") && i+1 < len(args):
+			configPath = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "-config=") || strings.HasPrefix(arg, "--config="):
+			configPath = arg[strings.Index(arg, "=")+1:]
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	return rest
+}
+
+// configureLogging switches the log and slog output to JSON lines on stderr
+// when -json-logs is set. Every line carries the command name; plain
+// log.Printf calls arrive as level INFO with the text in msg, while
+// structured call sites add their own fields (counts, paths, errors).
+// Command output on stdout is unchanged.
+func configureLogging(command string) {
+	if !jsonLogs {
+		return
+	}
+	handler := slog.NewJSONHandler(os.Stderr, nil).WithAttrs([]slog.Attr{
+		slog.String("command", command),
+	})
+	slog.SetDefault(slog.New(handler))
+	log.SetFlags(0)
+}
+
+// reportError prints a failed command's error to stderr, as a JSON line
+// when -json-logs is set
+func reportError(err error) {
+	if jsonLogs {
+		slog.Error(err.Error(), "category", errorCategory(err), "exit_code", exitCode(err))
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Error [%s]: %v\n", errorCategory(err), err)
+}
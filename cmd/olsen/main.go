@@ -4,22 +4,47 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
+	"time"
+
+	"github.com/adewale/olsen/internal/explorer"
+	"github.com/adewale/olsen/internal/indexer"
+	"github.com/adewale/olsen/internal/query"
+	"github.com/adewale/olsen/pkg/models"
 )
 
-const version = "0.1.0-dev"
+// Build information, stamped at link time by make build:
+//
+//	-ldflags "-X main.version=v0.2.0 -X main.commit=1a2b3c4 -X main.buildDate=2025-06-01T12:00:00Z"
+//
+// Unset values fall back to what the Go toolchain recorded (see buildInfo).
+var (
+	version   = "dev"
+	commit    = ""
+	buildDate = ""
+)
 
 func main() {
+	os.Args = extractGlobalFlags(os.Args)
 	if len(os.Args) < 2 {
 		printUsage()
 		os.Exit(0)
 	}
 
 	command := os.Args[1]
+	configureLogging(command)
+
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		reportError(err)
+		os.Exit(exitCode(err))
+	}
+	config = cfg
+	config.applyThumbnailEnv()
 
-	var err error
 	switch command {
 	case "version", "--version", "-v":
-		fmt.Printf("olsen version %s\n", version)
+		fmt.Printf("olsen version %s\n", versionString())
 		fmt.Println("Photo indexer and explorer")
 		fmt.Println("Copyright 2025")
 		os.Exit(0)
@@ -36,19 +61,53 @@ func main() {
 		err = handleStats()
 	case "show":
 		err = handleShow()
+	case "search":
+		err = handleSearch()
+	case "path":
+		err = handlePath()
 	case "thumbnail":
 		err = handleThumbnail()
+	case "missing-thumbnails":
+		err = handleMissingThumbnails()
 	case "verify":
 		err = handleVerify()
+	case "analytics":
+		err = handleAnalytics()
+	case "set-lens":
+		err = handleSetLens()
+	case "contactsheet":
+		err = handleContactSheet()
+	case "export":
+		err = handleExport()
+	case "collection":
+		err = handleCollection()
+	case "tag":
+		err = handleTag()
+	case "import-meta":
+		err = handleImportMeta()
+	case "errors":
+		err = handleErrors()
+	case "runs":
+		err = handleRuns()
+	case "reinfer":
+		err = handleReinfer()
+	case "doctor":
+		err = handleDoctor()
+	case "compact":
+		err = handleCompact()
+	case "relocate":
+		err = handleRelocate()
+	case "bench":
+		err = handleBench()
 	default:
-		fmt.Fprintf(os.Stderr, "Error: Unknown command '%s'\n\n", command)
+		fmt.Fprintf(os.Stderr, "Error [%s]: Unknown command '%s'\n\n", ErrUsage, command)
 		printUsage()
-		os.Exit(1)
+		os.Exit(exitUsage)
 	}
 
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		reportError(err)
+		os.Exit(exitCode(err))
 	}
 }
 
@@ -56,55 +115,175 @@ func printUsage() {
 	fmt.Println("Olsen - Photo Indexer and Explorer")
 	fmt.Println("")
 	fmt.Println("Usage:")
-	fmt.Println("  olsen <command> [options]")
+	fmt.Println("  olsen [-json-logs] [-config path] <command> [options]")
+	fmt.Println("")
+	fmt.Println("Global options:")
+	fmt.Println("  -json-logs    Write log lines to stderr as JSON (level, msg, command, fields)")
+	fmt.Println("  -config path  Read default settings from this JSON file (default: ./olsen.json if present)")
 	fmt.Println("")
 	fmt.Println("Commands:")
-	fmt.Println("  index      Index photos from a directory")
-	fmt.Println("  explore    Start web interface to browse photos")
-	fmt.Println("  analyze    Detect bursts and duplicates")
-	fmt.Println("  stats      Display database statistics")
-	fmt.Println("  show       Show metadata for a specific photo")
-	fmt.Println("  thumbnail  Extract thumbnail from a photo")
-	fmt.Println("  verify     Verify database integrity")
-	fmt.Println("  version    Show version information")
-	fmt.Println("  help       Show this help message")
+	fmt.Println("  index         Index photos from a directory")
+	fmt.Println("  explore       Start web interface to browse photos")
+	fmt.Println("  analyze       Detect exposure brackets and bursts")
+	fmt.Println("  stats         Display database statistics")
+	fmt.Println("  show          Show metadata for a specific photo")
+	fmt.Println("  search        Find photos by path, camera or lens text")
+	fmt.Println("  path          Print the file path of a photo, or of every photo matching a filter")
+	fmt.Println("  thumbnail     Extract thumbnail from a photo")
+	fmt.Println("  missing-thumbnails  List photos without a thumbnail of a given size")
+	fmt.Println("  verify        Verify database integrity")
+	fmt.Println("  analytics     Show photo counts by weekday and hour")
+	fmt.Println("  set-lens      Assign a lens to photos from a camera (manual lenses)")
+	fmt.Println("  contactsheet  Tile thumbnails of matching photos into one JPEG")
+	fmt.Println("  export        Write every photo's metadata as CSV or JSON")
+	fmt.Println("  collection    Create, list and edit manual photo collections")
+	fmt.Println("  tag           Add or remove a tag on every photo matching a filter")
+	fmt.Println("  import-meta   Set keywords, collections and ratings from a CSV or JSON file")
+	fmt.Println("  errors        List files that failed to index")
+	fmt.Println("  runs          List past index runs with their counts and duration")
+	fmt.Println("  reinfer       Recompute inferred metadata without re-reading files")
+	fmt.Println("  doctor        Report RAW support, decoders, SQLite and schema status")
+	fmt.Println("  compact       Reclaim free space and refresh query statistics")
+	fmt.Println("  relocate      Re-point photos at a library moved to another directory")
+	fmt.Println("  bench         Time representative grid queries and facets on the database")
+	fmt.Println("  version       Show version information")
+	fmt.Println("  help          Show this help message")
 	fmt.Println("")
 	fmt.Println("Run 'olsen <command> --help' for more information on a command.")
+	fmt.Println("")
+	fmt.Println("Exit codes:")
+	fmt.Println("  0  success")
+	fmt.Println("  1  other error")
+	fmt.Println("  2  usage error (bad or missing arguments)")
+	fmt.Println("  3  not found (database, directory, photo, thumbnail)")
+	fmt.Println("  4  database error (cannot open, locked, query failed)")
 }
 
 func handleIndex() error {
 	fs := flag.NewFlagSet("index", flag.ExitOnError)
-	db := fs.String("db", "photos.db", "Database file path")
-	workers := fs.Int("w", 4, "Number of worker threads")
+	db := fs.String("db", config.DB, "Database file path")
+	workers := fs.Int("w", config.Workers, "Number of worker threads")
 	perfstats := fs.Bool("perfstats", false, "Enable performance statistics")
+	followSymlinks := fs.Bool("follow-symlinks", false, "Descend into symlinked directories (cycles are detected and skipped)")
+	maxDecode := fs.Int("max-decode-dimension", 0, "Cap the long edge of images decoded in memory, in px (0 = no limit, min 1024); larger JPEG/PNG/BMP files are indexed without thumbnails")
+	progressive := fs.Bool("progressive", false, "Encode the 1024px thumbnail as a progressive JPEG")
+	allowUpscale := fs.Bool("allow-upscale", false, "Enlarge images smaller than a thumbnail size instead of skipping that size")
+	minFileSize := fs.String("min-file-size", "", "Skip files smaller than this, e.g. 500KB or 2MB")
+	maxFileSize := fs.String("max-file-size", "", "Skip files of this size or larger, e.g. 200MB or 1GB")
+	thumbBg := fs.String("thumb-bg", "white", "Background for transparent areas of PNGs in thumbnails (#rrggbb, white or black)")
+	noThumbnails := fs.Bool("no-thumbnails", false, "Store metadata only; a later index without this flag generates the thumbnails")
+	maxFiles := fs.Int("max-files", 0, "Abort without indexing if more than this many files are found (0 = no limit)")
+	since := fs.String("since", "", "Only index files modified after this date (2025-01-01) or within this duration (24h); older files are not read")
+	useExiftool := fs.Bool("use-exiftool", false, "Read metadata with exiftool when the built-in EXIF parser fails (needs exiftool on the PATH)")
+	includeVideo := fs.Bool("include-video", false, "Also index MP4 and MOV videos (poster-frame thumbnails need ffmpeg on the PATH)")
+	dedupThumbnails := fs.Bool("dedup-thumbnails", false, "Store identical thumbnails once, shared by exact duplicate files")
+	prune := fs.Bool("prune", false, "Remove photos under the indexed directories whose files no longer exist")
 
 	fs.Usage = func() {
-		fmt.Println("Usage: olsen index <directory> [options]")
+		fmt.Println("Usage: olsen index [options] <directory> [directory...]")
 		fmt.Println("")
-		fmt.Println("Index photos from a directory into a SQLite database.")
+		fmt.Println("Index photos from one or more directories into a SQLite database.")
 		fmt.Println("")
 		fmt.Println("Options:")
 		fs.PrintDefaults()
 	}
 
-	if err := fs.Parse(os.Args[2:]); err != nil {
-		return err
+	// Directories may appear before, between or after the flags
+	var photoDirs []string
+	args := os.Args[2:]
+	for {
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		photoDirs = append(photoDirs, fs.Arg(0))
+		args = fs.Args()[1:]
 	}
 
-	if fs.NArg() < 1 {
+	if len(photoDirs) == 0 {
 		fs.Usage()
-		return fmt.Errorf("photo directory is required")
+		return usageError("photo directory is required")
+	}
+
+	if *maxDecode != 0 && *maxDecode < 1024 {
+		return usageError("-max-decode-dimension must be 0 or at least 1024 (the largest thumbnail size)")
+	}
+
+	var minSize, maxSize int64
+	if *minFileSize != "" {
+		size, err := query.ParseFileSize(*minFileSize)
+		if err != nil {
+			return usageError("-min-file-size: %v", err)
+		}
+		minSize = size
+	}
+	if *maxFileSize != "" {
+		size, err := query.ParseFileSize(*maxFileSize)
+		if err != nil {
+			return usageError("-max-file-size: %v", err)
+		}
+		if size == 0 {
+			return usageError("-max-file-size must be above zero")
+		}
+		maxSize = size
+	}
+	if maxSize > 0 && minSize >= maxSize {
+		return usageError("-min-file-size must be smaller than -max-file-size")
+	}
+	if *maxFiles < 0 {
+		return usageError("-max-files must not be negative")
+	}
+	var modifiedAfter time.Time
+	if *since != "" {
+		t, err := indexer.ParseSince(*since, time.Now())
+		if err != nil {
+			return usageError("-since: %v", err)
+		}
+		modifiedAfter = t
 	}
 
-	photoDir := fs.Arg(0)
-	return indexCommand(photoDir, *db, *workers, *perfstats)
+	return indexCommand(photoDirs, *db, *workers, indexOptions{
+		PerfStats:          *perfstats,
+		FollowSymlinks:     *followSymlinks,
+		MaxDecodeDimension: *maxDecode,
+		Progressive:        *progressive,
+		AllowUpscale:       *allowUpscale,
+		MinFileSize:        minSize,
+		MaxFileSize:        maxSize,
+		ThumbBackground:    *thumbBg,
+		NoThumbnails:       *noThumbnails,
+		ThumbnailQuality:   config.thumbnailQuality(),
+		MaxFiles:           *maxFiles,
+		ModifiedAfter:      modifiedAfter,
+		IncludeVideo:       *includeVideo,
+		UseExiftool:        *useExiftool,
+		DedupThumbnails:    *dedupThumbnails,
+		Prune:              *prune,
+	})
 }
 
 func handleExplore() error {
 	fs := flag.NewFlagSet("explore", flag.ExitOnError)
-	db := fs.String("db", "photos.db", "Database file path")
-	addr := fs.String("addr", "localhost:8080", "Listen address")
+	db := fs.String("db", config.DB, "Database file path")
+	addr := fs.String("addr", config.Addr, "Listen address, or unix:/path/to.sock for a Unix domain socket")
 	open := fs.Bool("open", false, "Open browser automatically")
+	accessibleColours := fs.Bool("accessible-colors", false, "Show colour facet with text labels and patterns instead of swatches alone")
+	recentCount := fs.Int("recent-count", 50, "Number of recent photos on the home page")
+	recentBy := fs.String("recent-by", "taken", "Home page ordering: taken (newest date taken) or indexed (newest added)")
+	readOnly := fs.Bool("db-readonly", false, "Open the database read-only (safe while another process is indexing)")
+	immutable := fs.Bool("db-immutable", false, "Open read-only and assume nothing modifies the database, e.g. on read-only media (implies -db-readonly)")
+	allowEdits := fs.Bool("allow-edits", false, "Allow editing collections from the browser (no authentication; use on trusted addresses only)")
+	recentViews := fs.Int("recent-views", 0, "Remember the last N photos opened and list them at /recent-views (0 = off; in memory, shared by all visitors)")
+	onlyNew := fs.Bool("only-new", false, "Badge photos indexed since this browser's last visit and list them at /new (remembered in cookies)")
+	facetLimit := fs.Int("facet-limit", 0, "Camera and lens values listed before summing the rest into Other (0 = defaults: 50 cameras, 30 lenses)")
+	setupRules := fs.String("setup-rules", "", "JSON file of rules for the setup facet (portrait, landscape, ...) combining focal category and aperture; see README")
+	tz := fs.String("tz", "", "Time zone to show capture times in, e.g. America/Los_Angeles (default $OLSEN_TZ, else the server's local zone)")
+	templateDir := fs.String("templates", "", "Directory of .html templates overriding the built-in ones (re-read on every page)")
+	basePath := fs.String("base-path", "", "URL path prefix to serve under behind a reverse proxy, e.g. /olsen (the proxy must forward the prefix)")
+	immutableThumbs := fs.Bool("immutable-thumbnails", false, "Let browsers cache versioned thumbnail URLs for a year without revalidating (they change when a photo is re-indexed)")
+	similarThreshold := fs.Int("similar-threshold", explorer.DefaultSimilarDistance, "Default maximum perceptual-hash distance for /photo/:id/similar (0-32; override per view with ?max_distance=)")
 
 	fs.Usage = func() {
 		fmt.Println("Usage: olsen explore [options]")
@@ -119,17 +298,38 @@ func handleExplore() error {
 		return err
 	}
 
-	return exploreCommand(*db, *addr, *open)
+	return exploreCommand(*db, *addr, *open, exploreOptions{
+		AccessibleColours: *accessibleColours,
+		RecentCount:       *recentCount,
+		RecentBy:          *recentBy,
+		ReadOnly:          *readOnly,
+		Immutable:         *immutable,
+		AllowEdits:        *allowEdits,
+		RecentViews:       *recentViews,
+		OnlyNew:           *onlyNew,
+		SimilarThreshold:  *similarThreshold,
+		FacetLimit:        *facetLimit,
+		SetupRules:        *setupRules,
+		TimeZone:          *tz,
+		TemplateDir:       *templateDir,
+		ImmutableThumbs:   *immutableThumbs,
+		BasePath:          *basePath,
+	})
 }
 
 func handleAnalyze() error {
 	fs := flag.NewFlagSet("analyze", flag.ExitOnError)
-	db := fs.String("db", "photos.db", "Database file path")
+	db := fs.String("db", config.DB, "Database file path")
+	animate := fs.Bool("animate", false, "Also store an animated GIF of each burst's frames, served at /api/burst/:group/animated")
+	animateSize := fs.String("animate-size", string(indexer.DefaultAnimationOptions.Size), "Thumbnail size the animation frames are taken from: 64, 256, 512 or 1024")
+	animateFPS := fs.Float64("animate-fps", indexer.DefaultAnimationOptions.FPS, "Animation frames per second (at most 50)")
+	sessionGap := fs.Duration("session-gap", indexer.DefaultSessionGap, "Start a new shooting session after a pause longer than this (at least 1m)")
 
 	fs.Usage = func() {
 		fmt.Println("Usage: olsen analyze [options]")
 		fmt.Println("")
-		fmt.Println("Detect bursts and duplicates in indexed photos.")
+		fmt.Println("Detect exposure brackets (AEB sequences for HDR), bursts and shooting")
+		fmt.Println("sessions in indexed photos, replacing the groups found by any previous run.")
 		fmt.Println("")
 		fmt.Println("Options:")
 		fs.PrintDefaults()
@@ -139,17 +339,40 @@ func handleAnalyze() error {
 		return err
 	}
 
-	return analyzeCommand(*db)
+	if *sessionGap < indexer.MinSessionGap {
+		return usageError("-session-gap must be at least %s", indexer.MinSessionGap)
+	}
+
+	var animation *indexer.AnimationOptions
+	if *animate {
+		if !isThumbnailSize(*animateSize) {
+			return usageError("-animate-size must be 64, 256, 512 or 1024")
+		}
+		if *animateFPS <= 0 || *animateFPS > 50 {
+			return usageError("-animate-fps must be above 0 and at most 50")
+		}
+		animation = &indexer.AnimationOptions{Size: models.ThumbnailSize(*animateSize), FPS: *animateFPS}
+	}
+
+	return analyzeCommand(*db, animation, *sessionGap)
 }
 
 func handleStats() error {
 	fs := flag.NewFlagSet("stats", flag.ExitOnError)
-	db := fs.String("db", "photos.db", "Database file path")
+	db := fs.String("db", config.DB, "Database file path")
+	growth := fs.Bool("growth", false, "Show photos indexed per period and the running total instead")
+	by := fs.String("by", "month", "Period for -growth: day, week or month")
+	from := fs.String("from", "", "With -growth, start at this indexing date (YYYY-MM-DD)")
+	to := fs.String("to", "", "With -growth, end at this indexing date (YYYY-MM-DD, inclusive)")
+	filter := fs.String("filter", "", "With -growth, count only photos matching this explorer query string")
+	asJSON := fs.Bool("json", false, "Print the statistics as JSON, with every year and camera")
 
 	fs.Usage = func() {
 		fmt.Println("Usage: olsen stats [options]")
 		fmt.Println("")
-		fmt.Println("Display statistics about indexed photos.")
+		fmt.Println("Display statistics about indexed photos. With -growth, show how the")
+		fmt.Println("library grew by the date photos were indexed (UTC), to check that")
+		fmt.Println("scheduled indexing is running.")
 		fmt.Println("")
 		fmt.Println("Options:")
 		fs.PrintDefaults()
@@ -159,42 +382,138 @@ func handleStats() error {
 		return err
 	}
 
-	return statsCommand(*db)
+	if *growth {
+		if *asJSON {
+			return usageError("-json does not apply to -growth")
+		}
+		return growthCommand(*db, *by, *from, *to, *filter)
+	}
+	return statsCommand(*db, *asJSON)
+}
+
+func handleSearch() error {
+	fs := flag.NewFlagSet("search", flag.ExitOnError)
+	db := fs.String("db", config.DB, "Database file path")
+	field := fs.String("field", "", "Search only this column: "+strings.Join(explorer.SearchFields, ", "))
+	paging := addPagingFlags(fs, 50)
+
+	fs.Usage = func() {
+		fmt.Println("Usage: olsen search <text> [options]")
+		fmt.Println("")
+		fmt.Println("List photos whose file path, camera make or model, or lens contains")
+		fmt.Println("the text, ignoring case, with their IDs and capture dates.")
+		fmt.Println("")
+		fmt.Println("Options:")
+		fs.PrintDefaults()
+	}
+
+	text, err := parseWithLeadingArg(fs, os.Args[2:])
+	if err != nil {
+		return err
+	}
+	if text == "" {
+		fs.Usage()
+		return usageError("search text is required")
+	}
+
+	return searchCommand(*db, text, *field, paging)
 }
 
 func handleShow() error {
 	fs := flag.NewFlagSet("show", flag.ExitOnError)
-	db := fs.String("db", "photos.db", "Database file path")
+	db := fs.String("db", config.DB, "Database file path")
+	sameDay := fs.Bool("sameday", false, "List the photos taken on the same day instead")
+	paging := addPagingFlags(fs, 100)
 
 	fs.Usage = func() {
 		fmt.Println("Usage: olsen show <photo-id> [options]")
 		fmt.Println("")
-		fmt.Println("Show metadata for a specific photo.")
+		fmt.Println("Show metadata for a specific photo. With -sameday, list every photo")
+		fmt.Println("taken on the same calendar day, oldest first; -limit, -offset and")
+		fmt.Println("-count-only page that list.")
 		fmt.Println("")
 		fmt.Println("Options:")
 		fs.PrintDefaults()
 	}
 
-	if err := fs.Parse(os.Args[2:]); err != nil {
+	arg, err := parseWithLeadingArg(fs, os.Args[2:])
+	if err != nil {
 		return err
 	}
-
-	if fs.NArg() < 1 {
+	if arg == "" {
 		fs.Usage()
-		return fmt.Errorf("photo ID is required")
+		return usageError("photo ID is required")
 	}
 
 	var photoID int
-	if _, err := fmt.Sscanf(fs.Arg(0), "%d", &photoID); err != nil {
-		return fmt.Errorf("invalid photo ID: %s", fs.Arg(0))
+	if _, err := fmt.Sscanf(arg, "%d", &photoID); err != nil {
+		return usageError("invalid photo ID: %s", arg)
 	}
 
+	if *sameDay {
+		return sameDayCommand(*db, photoID, paging)
+	}
 	return showCommand(*db, photoID)
 }
 
+func handlePath() error {
+	fs := flag.NewFlagSet("path", flag.ExitOnError)
+	db := fs.String("db", config.DB, "Database file path")
+	filter := fs.String("filter", "", "Filter as an explorer query string, e.g. \"year=2024&camera_make=Canon\"")
+	all := fs.Bool("all", false, "Print the path of every photo")
+	paging := addPagingFlags(fs, 0)
+
+	fs.Usage = func() {
+		fmt.Println("Usage: olsen path <photo-id> [options]")
+		fmt.Println("       olsen path (-filter <query> | -all) [options]")
+		fmt.Println("")
+		fmt.Println("Print the file path of a photo, or of every photo matching the filter")
+		fmt.Println("(oldest first), one per line, for piping into cp, open and the like.")
+		fmt.Println("A filter that matches nothing prints nothing. -limit, -offset and")
+		fmt.Println("-count-only page that list.")
+		fmt.Println("")
+		fmt.Println("Options:")
+		fs.PrintDefaults()
+	}
+
+	arg, err := parseWithLeadingArg(fs, os.Args[2:])
+	if err != nil {
+		return err
+	}
+	if (arg == "") == (*filter == "" && !*all) {
+		fs.Usage()
+		return usageError("give either a photo ID or -filter (use -all for every photo)")
+	}
+
+	var photoID int
+	if arg != "" {
+		if _, err := fmt.Sscanf(arg, "%d", &photoID); err != nil || photoID < 1 {
+			return usageError("invalid photo ID: %s", arg)
+		}
+	}
+	return pathCommand(*db, photoID, *filter, paging)
+}
+
+// parseWithLeadingArg parses args with fs, accepting the command's one
+// positional argument before the options as well as after them, and
+// returns that argument ("" when none was given)
+func parseWithLeadingArg(fs *flag.FlagSet, args []string) (string, error) {
+	var arg string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		arg, args = args[0], args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return "", err
+	}
+	if arg == "" {
+		arg = fs.Arg(0)
+	}
+	return arg, nil
+}
+
 func handleThumbnail() error {
 	fs := flag.NewFlagSet("thumbnail", flag.ExitOnError)
-	db := fs.String("db", "photos.db", "Database file path")
+	db := fs.String("db", config.DB, "Database file path")
 	output := fs.String("o", "thumbnail.jpg", "Output file path")
 	size := fs.Int("s", 512, "Thumbnail size (64, 256, 512, or 1024)")
 
@@ -213,26 +532,465 @@ func handleThumbnail() error {
 
 	if fs.NArg() < 1 {
 		fs.Usage()
-		return fmt.Errorf("photo ID is required")
+		return usageError("photo ID is required")
 	}
 
 	var photoID int
 	if _, err := fmt.Sscanf(fs.Arg(0), "%d", &photoID); err != nil {
-		return fmt.Errorf("invalid photo ID: %s", fs.Arg(0))
+		return usageError("invalid photo ID: %s", fs.Arg(0))
 	}
 
 	return thumbnailCommand(*db, photoID, *output, *size)
 }
 
+func handleMissingThumbnails() error {
+	fs := flag.NewFlagSet("missing-thumbnails", flag.ExitOnError)
+	db := fs.String("db", config.DB, "Database file path")
+	size := fs.Int("size", 1024, "Thumbnail size to look for (64, 256, 512, or 1024)")
+	paging := addPagingFlags(fs, 0)
+
+	fs.Usage = func() {
+		fmt.Println("Usage: olsen missing-thumbnails [options]")
+		fmt.Println("")
+		fmt.Println("Print the id and file path of every photo with no thumbnail of the")
+		fmt.Println("given size, one per line, tab separated, in id order. Photos indexed")
+		fmt.Println("before a size was configured, or skipped as smaller than it, have none.")
+		fmt.Println("The count goes to stderr, so the list can be piped on its own.")
+		fmt.Println("-limit, -offset and -count-only page the list.")
+		fmt.Println("")
+		fmt.Println("Options:")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(os.Args[2:]); err != nil {
+		return err
+	}
+	return missingThumbnailsCommand(*db, *size, paging)
+}
+
 func handleVerify() error {
 	fs := flag.NewFlagSet("verify", flag.ExitOnError)
-	db := fs.String("db", "photos.db", "Database file path")
+	db := fs.String("db", config.DB, "Database file path")
+	orientationSample := fs.Int("orientation-sample", 200, "Number of thumbnails to check against photo orientation (0 to skip)")
+	deep := fs.Bool("deep", false, "Re-decode every original and stored thumbnail to find corrupt or truncated files (slow)")
 
 	fs.Usage = func() {
 		fmt.Println("Usage: olsen verify [options]")
 		fmt.Println("")
 		fmt.Println("Verify database integrity.")
 		fmt.Println("")
+		fmt.Println("A random sample of thumbnails is checked to have the aspect ratio of")
+		fmt.Println("their photo's width and height after EXIF orientation; a mismatch")
+		fmt.Println("means the thumbnail was rotated wrongly or not at all. If none can be")
+		fmt.Println("sampled, because no photo has a stored width and height, that counts")
+		fmt.Println("as an issue.")
+		fmt.Println("")
+		fmt.Println("With -deep, every original and thumbnail is decoded again. Files that")
+		fmt.Println("are missing, changed size, fail to decode or decode to other dimensions")
+		fmt.Println("than were indexed are listed as suspect.")
+		fmt.Println("")
+		fmt.Println("Options:")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(os.Args[2:]); err != nil {
+		return err
+	}
+
+	return verifyCommand(*db, *orientationSample, *deep)
+}
+
+func handleAnalytics() error {
+	fs := flag.NewFlagSet("analytics", flag.ExitOnError)
+	db := fs.String("db", config.DB, "Database file path")
+	filter := fs.String("filter", "", "Filter as an explorer query string, e.g. \"year=2024&camera_make=Canon\"")
+
+	fs.Usage = func() {
+		fmt.Println("Usage: olsen analytics [options]")
+		fmt.Println("")
+		fmt.Println("Show a weekday × hour matrix of when photos were taken.")
+		fmt.Println("Hours are the camera clock at capture; undated photos are excluded.")
+		fmt.Println("")
+		fmt.Println("Options:")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(os.Args[2:]); err != nil {
+		return err
+	}
+
+	return analyticsCommand(*db, *filter)
+}
+
+func handleErrors() error {
+	fs := flag.NewFlagSet("errors", flag.ExitOnError)
+	db := fs.String("db", config.DB, "Database file path")
+	match := fs.String("match", "", "Only errors whose file path or message contains this text")
+
+	fs.Usage = func() {
+		fmt.Println("Usage: olsen errors [options]")
+		fmt.Println("")
+		fmt.Println("List files that failed to index, newest first, with the error for each.")
+		fmt.Println("A file's entry is removed once it indexes successfully.")
+		fmt.Println("")
+		fmt.Println("Options:")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(os.Args[2:]); err != nil {
+		return err
+	}
+
+	return errorsCommand(*db, *match)
+}
+
+func handleRuns() error {
+	fs := flag.NewFlagSet("runs", flag.ExitOnError)
+	db := fs.String("db", config.DB, "Database file path")
+	limit := fs.Int("limit", 20, "Show at most this many runs (0 = all)")
+
+	fs.Usage = func() {
+		fmt.Println("Usage: olsen runs [options]")
+		fmt.Println("")
+		fmt.Println("List past olsen index runs, newest first: when each started, whether it")
+		fmt.Println("completed, how long it took, its file counts and the directories indexed.")
+		fmt.Println("")
+		fmt.Println("Options:")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(os.Args[2:]); err != nil {
+		return err
+	}
+	if *limit < 0 {
+		return usageError("-limit must not be negative")
+	}
+
+	return runsCommand(*db, *limit)
+}
+
+func handleRelocate() error {
+	fs := flag.NewFlagSet("relocate", flag.ExitOnError)
+	db := fs.String("db", config.DB, "Database file path")
+	from := fs.String("from", "", "Directory the photos were indexed under, e.g. /Volumes/Photos")
+	to := fs.String("to", "", "Directory the photos are in now, e.g. /mnt/photos")
+	apply := fs.Bool("apply", false, "Rewrite the paths (without it, only report what would change)")
+	check := fs.Bool("check", false, "Check every new path exists first, and change nothing if any is missing")
+
+	fs.Usage = func() {
+		fmt.Println("Usage: olsen relocate -from <dir> -to <dir> [options]")
+		fmt.Println("")
+		fmt.Println("Rewrite the paths of photos indexed under one directory to the same")
+		fmt.Println("files under another, after moving a library, in one transaction.")
+		fmt.Println("Thumbnails, tags and collections are kept. By default this is a dry")
+		fmt.Println("run reporting how many photos would change.")
+		fmt.Println("")
+		fmt.Println("Options:")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(os.Args[2:]); err != nil {
+		return err
+	}
+
+	return relocateCommand(*db, *from, *to, *apply, *check)
+}
+
+func handleReinfer() error {
+	fs := flag.NewFlagSet("reinfer", flag.ExitOnError)
+	db := fs.String("db", config.DB, "Database file path")
+
+	fs.Usage = func() {
+		fmt.Println("Usage: olsen reinfer [options]")
+		fmt.Println("")
+		fmt.Println("Recompute time of day, season, focal category, shooting condition,")
+		fmt.Println("exposure value and sun elevation from the metadata already stored,")
+		fmt.Println("and add grid placeholders to photos indexed before they existed.")
+		fmt.Println("Files are not read, so this is fast even for large libraries.")
+		fmt.Println("")
+		fmt.Println("Options:")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(os.Args[2:]); err != nil {
+		return err
+	}
+
+	return reinferCommand(*db)
+}
+
+func handleSetLens() error {
+	fs := flag.NewFlagSet("set-lens", flag.ExitOnError)
+	db := fs.String("db", config.DB, "Database file path")
+	camera := fs.String("camera", "", "Camera model, or make and model (e.g. \"Leica M11\")")
+	focal := fs.Float64("focal", 0, "Only photos at this focal length in mm (0 = any)")
+	lens := fs.String("lens", "", "Lens model to assign")
+	overwrite := fs.Bool("overwrite", false, "Replace existing lens models instead of only filling empty ones")
+
+	fs.Usage = func() {
+		fmt.Println("Usage: olsen set-lens --camera <camera> --lens <lens> [options]")
+		fmt.Println("")
+		fmt.Println("Backfill the lens model for photos taken with a camera, e.g. with an")
+		fmt.Println("adapted manual lens that records no lens in EXIF.")
+		fmt.Println("")
+		fmt.Println("Options:")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(os.Args[2:]); err != nil {
+		return err
+	}
+
+	if *camera == "" || *lens == "" {
+		fs.Usage()
+		return usageError("--camera and --lens are required")
+	}
+
+	return setLensCommand(*db, *camera, *focal, *lens, *overwrite)
+}
+
+func handleCollection() error {
+	usage := func() {
+		fmt.Println("Usage: olsen collection <subcommand> [options] [arguments]")
+		fmt.Println("")
+		fmt.Println("Manage manual photo collections. <collection> is an ID or a name.")
+		fmt.Println("")
+		fmt.Println("Subcommands:")
+		fmt.Println("  create [--description <text>] <name>   Create an empty collection")
+		fmt.Println("  add <collection> <photo-id>...          Add photos to a collection")
+		fmt.Println("  remove <collection> <photo-id>...       Remove photos from a collection")
+		fmt.Println("  list                                    List collections with photo counts")
+		fmt.Println("")
+		fmt.Println("Options:")
+		fmt.Println("  -db string          Database file path (default \"photos.db\")")
+		fmt.Println("  -description string Description for create")
+	}
+
+	if len(os.Args) < 3 {
+		usage()
+		return usageError("collection subcommand is required")
+	}
+	sub := os.Args[2]
+
+	fs := flag.NewFlagSet("collection "+sub, flag.ExitOnError)
+	db := fs.String("db", config.DB, "Database file path")
+	description := fs.String("description", "", "Description for create")
+	fs.Usage = usage
+
+	if err := fs.Parse(os.Args[3:]); err != nil {
+		return err
+	}
+	args := fs.Args()
+
+	switch sub {
+	case "create":
+		if len(args) != 1 {
+			usage()
+			return usageError("collection create takes exactly one name")
+		}
+		return collectionCreateCommand(*db, args[0], *description)
+	case "add", "remove":
+		if len(args) < 2 {
+			usage()
+			return usageError("collection %s needs a collection and at least one photo ID", sub)
+		}
+		photoIDs := make([]int, 0, len(args)-1)
+		for _, a := range args[1:] {
+			var id int
+			if _, err := fmt.Sscanf(a, "%d", &id); err != nil {
+				return usageError("invalid photo ID: %s", a)
+			}
+			photoIDs = append(photoIDs, id)
+		}
+		return collectionEditCommand(*db, sub, args[0], photoIDs)
+	case "list":
+		return collectionListCommand(*db)
+	case "help", "-h", "--help":
+		usage()
+		return nil
+	}
+
+	usage()
+	return usageError("unknown collection subcommand: %s", sub)
+}
+
+func handleTag() error {
+	fs := flag.NewFlagSet("tag", flag.ExitOnError)
+	db := fs.String("db", config.DB, "Database file path")
+	filter := fs.String("filter", "", "Filter as an explorer query string, e.g. \"year=2024&month=6\"")
+	add := fs.String("add", "", "Tag to add to matching photos")
+	remove := fs.String("remove", "", "Tag to remove from matching photos")
+	all := fs.Bool("all", false, "Allow an empty filter, changing every photo")
+
+	fs.Usage = func() {
+		fmt.Println("Usage: olsen tag (-add <tag> | -remove <tag>) -filter <query> [options]")
+		fmt.Println("")
+		fmt.Println("Add a tag to, or remove it from, every photo matching the filter in one")
+		fmt.Println("transaction. Find tagged photos with tag=<name> in filters and URLs.")
+		fmt.Println("")
+		fmt.Println("Options:")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(os.Args[2:]); err != nil {
+		return err
+	}
+
+	return tagCommand(*db, *filter, *add, *remove, *all)
+}
+
+func handleImportMeta() error {
+	fs := flag.NewFlagSet("import-meta", flag.ExitOnError)
+	db := fs.String("db", config.DB, "Database file path")
+	match := fs.String("match", "file_path", "Column that identifies the photo: file_path, filename, id or file_hash")
+	dryRun := fs.Bool("dry-run", false, "Report matches without changing the database")
+
+	fs.Usage = func() {
+		fmt.Println("Usage: olsen import-meta <file.csv|file.json> [options]")
+		fmt.Println("")
+		fmt.Println("Update photos from a CSV file with a header row, or a JSON array of")
+		fmt.Println("objects. Only user-editable columns are applied: keywords (or tags),")
+		fmt.Println("split on commas or semicolons and added as tags, collection, which")
+		fmt.Println("adds the photo to that collection, and rating, 0 to 5 stars. Other")
+		fmt.Println("columns are ignored, so metadata read from the files can't be")
+		fmt.Println("overwritten.")
+		fmt.Println("")
+		fmt.Println("Options:")
+		fs.PrintDefaults()
+	}
+
+	file, err := parseWithLeadingArg(fs, os.Args[2:])
+	if err != nil {
+		return err
+	}
+	if file == "" {
+		fs.Usage()
+		return usageError("a CSV or JSON file is required")
+	}
+
+	return importMetaCommand(*db, file, *match, *dryRun)
+}
+
+func handleContactSheet() error {
+	fs := flag.NewFlagSet("contactsheet", flag.ExitOnError)
+	db := fs.String("db", config.DB, "Database file path")
+	filter := fs.String("filter", "", "Filter as an explorer query string, e.g. \"year=2024&month=6&day=1\"")
+	paging := addPagingFlags(fs, 100)
+	columns := fs.Int("columns", 6, "Number of columns")
+	size := fs.Int("s", 256, "Thumbnail size (64, 256, 512, or 1024)")
+	bg := fs.String("bg", "#ffffff", "Background colour (#rrggbb, white or black)")
+	captions := fs.Bool("captions", true, "Caption each photo with its ID and date")
+	output := fs.String("o", "contactsheet.jpg", "Output file path")
+
+	fs.Usage = func() {
+		fmt.Println("Usage: olsen contactsheet [options]")
+		fmt.Println("")
+		fmt.Println("Tile the stored thumbnails of matching photos, oldest first, into a")
+		fmt.Println("single JPEG for printing or review.")
+		fmt.Println("")
+		fmt.Println("Options:")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(os.Args[2:]); err != nil {
+		return err
+	}
+
+	return contactsheetCommand(*db, contactSheetOptions{
+		Filter:     *filter,
+		Paging:     paging,
+		Columns:    *columns,
+		Size:       *size,
+		Background: *bg,
+		Captions:   *captions,
+		Output:     *output,
+	})
+}
+
+func handleExport() error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	db := fs.String("db", config.DB, "Database file path")
+	format := fs.String("format", "csv", "Output format: csv or json")
+	output := fs.String("o", "-", "Output file path, or - for stdout")
+	paging := addPagingFlags(fs, 0)
+
+	fs.Usage = func() {
+		fmt.Println("Usage: olsen export [options]")
+		fmt.Println("")
+		fmt.Println("Write the ID, path, capture date, camera, lens, ISO, aperture, focal")
+		fmt.Println("length, GPS position and rating of every photo, in ID order, as CSV")
+		fmt.Println("with a header row or as a JSON array. -limit and -offset export one page of")
+		fmt.Println("photos; -count-only prints how many there are.")
+		fmt.Println("")
+		fmt.Println("Options:")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(os.Args[2:]); err != nil {
+		return err
+	}
+
+	return exportCommand(*db, *format, *output, paging)
+}
+
+func handleDoctor() error {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	db := fs.String("db", config.DB, "Database file path (opened read-only)")
+
+	fs.Usage = func() {
+		fmt.Println("Usage: olsen doctor [options]")
+		fmt.Println("")
+		fmt.Println("Report the version, whether RAW decoding is compiled in, the image")
+		fmt.Println("decoders available, the SQLite version, the catalog's schema state,")
+		fmt.Println("whether exiftool is installed and any THUMB_* variables in effect.")
+		fmt.Println("")
+		fmt.Println("Options:")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(os.Args[2:]); err != nil {
+		return err
+	}
+
+	return doctorCommand(*db)
+}
+
+func handleCompact() error {
+	fs := flag.NewFlagSet("compact", flag.ExitOnError)
+	db := fs.String("db", config.DB, "Database file path")
+	walCheckpoint := fs.Bool("wal-checkpoint", false, "Also truncate the write-ahead log (fails if another process is reading the database)")
+
+	fs.Usage = func() {
+		fmt.Println("Usage: olsen compact [options]")
+		fmt.Println("")
+		fmt.Println("Run VACUUM, ANALYZE and PRAGMA optimize to shrink the database after")
+		fmt.Println("deletes or re-indexing and keep queries fast. Stop any indexer first;")
+		fmt.Println("VACUUM needs free disk space of up to twice the database size.")
+		fmt.Println("")
+		fmt.Println("Options:")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(os.Args[2:]); err != nil {
+		return err
+	}
+
+	return compactCommand(*db, *walCheckpoint)
+}
+
+func handleBench() error {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	db := fs.String("db", config.DB, "Database file path")
+	runs := fs.Int("runs", 20, "Timed runs of each query, after one warm-up run")
+
+	fs.Usage = func() {
+		fmt.Println("Usage: olsen bench [options]")
+		fmt.Println("")
+		fmt.Println("Run a fixed suite of explorer grid queries and facet computations against")
+		fmt.Println("the database and report their median (p50) and p95 times, so the effect")
+		fmt.Println("of compact or a new version can be measured on your own library. The")
+		fmt.Println("database is opened read-only.")
+		fmt.Println("")
 		fmt.Println("Options:")
 		fs.PrintDefaults()
 	}
@@ -241,5 +999,5 @@ func handleVerify() error {
 		return err
 	}
 
-	return verifyCommand(*db)
+	return benchCommand(*db, *runs)
 }
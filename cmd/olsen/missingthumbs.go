@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/adewale/olsen/internal/database"
+	"github.com/adewale/olsen/internal/query"
+	"github.com/adewale/olsen/pkg/models"
+)
+
+// missingThumbnailsCommand prints the id and path of every photo without a
+// thumbnail of size, walking the catalog in batches so large libraries are
+// listed in bounded memory. paging picks a page of the list, or counts it.
+func missingThumbnailsCommand(dbPath string, size int, paging *pagingFlags) error {
+	thumbSize := models.ThumbnailSize(strconv.Itoa(size))
+	switch thumbSize {
+	case models.ThumbnailTiny, models.ThumbnailSmall, models.ThumbnailMedium, models.ThumbnailLarge:
+	default:
+		return usageError("invalid thumbnail size %d (use 64, 256, 512 or 1024)", size)
+	}
+	window, err := paging.window()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return notFoundError("database not found: %s", dbPath)
+	}
+
+	db, err := database.OpenReadOnly(dbPath, false)
+	if err != nil {
+		return dbError("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	missing, listed := 0, 0
+	err = query.NewEngine(db.DB).IteratePhotosMissingThumbnail(string(thumbSize), 500, func(p query.PhotoSummary) error {
+		missing++
+		if *paging.countOnly {
+			return nil
+		}
+		onPage, err := window.next()
+		if onPage {
+			listed++
+			fmt.Printf("%d\t%s\n", p.ID, p.FilePath)
+		}
+		return err
+	})
+	if err != nil && !errors.Is(err, errPageFull) {
+		return dbError("failed to list photos: %v", err)
+	}
+	paged := err != nil || *paging.offset > 0
+	switch {
+	case *paging.countOnly:
+		fmt.Println(missing)
+	case paged:
+		fmt.Fprintf(os.Stderr, "%d photos without a %spx thumbnail listed\n", listed, thumbSize)
+	default:
+		fmt.Fprintf(os.Stderr, "%d photos without a %spx thumbnail\n", missing, thumbSize)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/adewale/olsen/internal/database"
+	"github.com/adewale/olsen/internal/query"
+)
+
+// pagingFlags are the -limit, -offset and -count-only flags shared by every
+// command that lists photos, so scripts page through results the same way
+// as the web API's limit and offset parameters
+type pagingFlags struct {
+	limit     *int
+	offset    *int
+	countOnly *bool
+	unlimited bool // -limit 0 lists everything
+}
+
+// addPagingFlags registers the paging flags on fs. defaultLimit is the
+// command's page size when -limit is not given; 0 lists every photo.
+func addPagingFlags(fs *flag.FlagSet, defaultLimit int) *pagingFlags {
+	limitUsage := "Maximum number of photos"
+	if defaultLimit == 0 {
+		limitUsage += " (0 = no limit)"
+	}
+	return &pagingFlags{
+		limit:     fs.Int("limit", defaultLimit, limitUsage),
+		offset:    fs.Int("offset", 0, "Skip this many matching photos first"),
+		countOnly: fs.Bool("count-only", false, "Print only the number of matching photos"),
+		unlimited: defaultLimit == 0,
+	}
+}
+
+// page validates the flags and returns the offset and limit, 0 meaning no
+// limit, for commands that read their own rows
+func (p *pagingFlags) page() (offset, limit int, err error) {
+	if p.unlimited && *p.limit < 0 {
+		return 0, 0, usageError("limit must not be negative")
+	}
+	if !p.unlimited && *p.limit < 1 {
+		return 0, 0, usageError("limit must be at least 1")
+	}
+	if *p.offset < 0 {
+		return 0, 0, usageError("offset must not be negative")
+	}
+	return *p.offset, *p.limit, nil
+}
+
+// apply validates the flags and copies them into params, overriding any
+// limit or offset given in a filter query string
+func (p *pagingFlags) apply(params *query.QueryParams) error {
+	offset, limit, err := p.page()
+	if err != nil {
+		return err
+	}
+	params.Limit = limit
+	params.Offset = offset
+	return nil
+}
+
+// errPageFull stops a streamed listing once its page is complete
+var errPageFull = errors.New("page full")
+
+// pageWindow picks one page out of a stream of photos
+type pageWindow struct {
+	offset, limit, seen int
+}
+
+// window returns a pageWindow for the flags
+func (p *pagingFlags) window() (*pageWindow, error) {
+	offset, limit, err := p.page()
+	if err != nil {
+		return nil, err
+	}
+	return &pageWindow{offset: offset, limit: limit}, nil
+}
+
+// next reports whether the next photo of the stream is on the page. Past
+// the end of the page it returns errPageFull, to end the iteration.
+func (w *pageWindow) next() (bool, error) {
+	w.seen++
+	if w.seen <= w.offset {
+		return false, nil
+	}
+	if w.limit > 0 && w.seen > w.offset+w.limit {
+		return false, errPageFull
+	}
+	return true, nil
+}
+
+// printCount prints the number of photos matching params, ignoring paging,
+// for -count-only
+func printCount(db *database.DB, params query.QueryParams) error {
+	total, err := query.NewEngine(db.DB).Count(params)
+	if err != nil {
+		return dbError("failed to count photos: %v", err)
+	}
+	fmt.Println(total)
+	return nil
+}
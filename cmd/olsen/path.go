@@ -0,0 +1,81 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/adewale/olsen/internal/database"
+	"github.com/adewale/olsen/internal/query"
+)
+
+// pathCommand prints file paths, one per line, for scripts: the path of
+// photoID, or with a filter the path of every matching photo, oldest first.
+// A missing photo is an error; a filter matching nothing prints nothing.
+// paging picks a page of the filtered paths, or counts them.
+func pathCommand(dbPath string, photoID int, filter string, paging *pagingFlags) error {
+	// Check database exists
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return notFoundError("database not found: %s", dbPath)
+	}
+
+	db, err := database.Open(dbPath)
+	if err != nil {
+		return dbError("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if photoID != 0 {
+		var path string
+		err := db.QueryRow("SELECT file_path FROM photos WHERE id = ?", photoID).Scan(&path)
+		if errors.Is(err, sql.ErrNoRows) {
+			return notFoundError("photo %d not found", photoID)
+		}
+		if err != nil {
+			return dbError("failed to read photo %d: %v", photoID, err)
+		}
+		fmt.Println(path)
+		return nil
+	}
+
+	params, err := query.NewURLMapper().ParsePath("/photos", filter)
+	if err != nil {
+		return usageError("invalid filter: %v", err)
+	}
+	// -all means every photo, rejected ones included
+	params.IncludeRejected = params.IncludeRejected || filter == ""
+	offset, limit, err := paging.page()
+	if err != nil {
+		return err
+	}
+	if *paging.countOnly {
+		return printCount(db, params)
+	}
+	if limit == 0 {
+		limit = -1 // SQLite's no limit
+	}
+	ids, args := query.NewEngine(db.DB).MatchingIDs(params)
+	rows, err := db.Query(`
+		SELECT file_path FROM photos
+		WHERE id IN (`+ids+`)
+		ORDER BY date_taken IS NULL, date_taken, id
+		LIMIT ? OFFSET ?
+	`, append(args, limit, offset)...)
+	if err != nil {
+		return dbError("failed to query photos: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return dbError("failed to read photos: %v", err)
+		}
+		fmt.Println(path)
+	}
+	if err := rows.Err(); err != nil {
+		return dbError("failed to read photos: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/adewale/olsen/internal/database"
+)
+
+// maxMissingListed caps the missing files relocate -check prints
+const maxMissingListed = 10
+
+// relocateCommand re-points photos indexed under the directory from at the
+// same files under to. Without apply it only reports what would change.
+// With check it first looks for every rewritten path on disk, and refuses
+// to apply if any is missing.
+func relocateCommand(dbPath, from, to string, apply, check bool) error {
+	if from == "" || to == "" {
+		return usageError("-from and -to are required")
+	}
+	from, to = filepath.Clean(from), filepath.Clean(to)
+	if from == to {
+		return usageError("-from and -to are the same directory")
+	}
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return notFoundError("database not found: %s", dbPath)
+	}
+
+	db, err := database.Open(dbPath)
+	if err != nil {
+		return dbError("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	paths, err := db.PhotoPathsUnder(from)
+	if err != nil {
+		return dbError("%v", err)
+	}
+	if len(paths) == 0 {
+		fmt.Printf("No photos indexed under %s\n", from)
+		return nil
+	}
+	fmt.Printf("%d photos under %s\n", len(paths), from)
+	fmt.Printf("  e.g. %s\n", paths[0])
+	fmt.Printf("    -> %s\n", to+strings.TrimPrefix(paths[0], from))
+
+	if check {
+		var missing []string
+		for _, path := range paths {
+			newPath := to + strings.TrimPrefix(path, from)
+			if _, err := os.Stat(newPath); err != nil {
+				missing = append(missing, newPath)
+			}
+		}
+		if len(missing) > 0 {
+			fmt.Printf("%d files are not at their new paths:\n", len(missing))
+			for _, path := range missing[:min(len(missing), maxMissingListed)] {
+				fmt.Printf("  %s\n", path)
+			}
+			if len(missing) > maxMissingListed {
+				fmt.Printf("  ... and %d more\n", len(missing)-maxMissingListed)
+			}
+			return notFoundError("%d of %d files missing under %s; nothing changed", len(missing), len(paths), to)
+		}
+		fmt.Printf("All %d files found under %s\n", len(paths), to)
+	}
+
+	if !apply {
+		fmt.Println("Dry run: nothing changed. Run again with -apply to rewrite the paths.")
+		return nil
+	}
+
+	moved, err := db.RelocatePaths(from, to)
+	if err != nil {
+		return dbError("%v", err)
+	}
+	fmt.Printf("Relocated %d photos to %s\n", moved, to)
+	return nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/adewale/olsen/internal/database"
+)
+
+// runsCommand lists recorded index runs, newest first, at most limit of them
+// (0 for all)
+func runsCommand(dbPath string, limit int) error {
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return notFoundError("database not found: %s", dbPath)
+	}
+
+	db, err := database.OpenReadOnly(dbPath, false)
+	if err != nil {
+		return dbError("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	runs, err := db.ListIndexRuns(limit)
+	if err != nil {
+		return dbError("%v", err)
+	}
+	if len(runs) == 0 {
+		fmt.Println("No index runs recorded")
+		return nil
+	}
+
+	fmt.Printf("%-19s  %-6s  %9s  %7s  %9s  %7s  %7s  %6s  %s\n",
+		"Started", "Result", "Duration", "Found", "Processed", "Skipped", "Updated", "Failed", "Rate")
+	for _, r := range runs {
+		result := "ok"
+		if !r.Succeeded() {
+			result = "failed"
+		}
+		s := r.Stats
+		fmt.Printf("%-19s  %-6s  %9s  %7d  %9d  %7d  %7d  %6d  %.1f photos/s\n",
+			s.StartTime.Local().Format("2006-01-02 15:04:05"), result, s.Duration().Round(100*time.Millisecond),
+			s.FilesFound, s.FilesProcessed, s.FilesSkipped, s.FilesUpdated, s.FilesFailed, s.PhotosPerSecond())
+		fmt.Printf("    %s\n", strings.Join(r.Roots, ", "))
+		if r.Error != "" {
+			fmt.Printf("    Error: %s\n", r.Error)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/adewale/olsen/internal/database"
+	"github.com/adewale/olsen/internal/explorer"
+)
+
+// searchCommand prints the photos whose path, camera or lens contains text,
+// a page of them at a time, optionally searching only field
+func searchCommand(dbPath, text, field string, paging *pagingFlags) error {
+	if strings.TrimSpace(text) == "" {
+		return usageError("search text is required")
+	}
+	if field != "" && !slices.Contains(explorer.SearchFields, field) {
+		return usageError("unknown -field %q (valid: %s)", field, strings.Join(explorer.SearchFields, ", "))
+	}
+	offset, limit, err := paging.page()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return notFoundError("database not found: %s", dbPath)
+	}
+
+	db, err := database.OpenReadOnly(dbPath, false)
+	if err != nil {
+		return dbError("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	repo := explorer.NewRepository(db)
+	if *paging.countOnly {
+		count, err := repo.CountSearchResults(text, field)
+		if err != nil {
+			return dbError("search failed: %v", err)
+		}
+		fmt.Println(count)
+		return nil
+	}
+	// One more than a page, to tell whether there is another
+	photos, err := repo.SearchPhotos(text, field, limit+1, offset)
+	if err != nil {
+		return dbError("search failed: %v", err)
+	}
+	more := len(photos) > limit
+	if more {
+		photos = photos[:limit]
+	}
+	if len(photos) == 0 && offset > 0 {
+		fmt.Printf("No more photos match %q\n", text)
+		return nil
+	}
+	if len(photos) == 0 {
+		fmt.Printf("No photos match %q\n", text)
+		return nil
+	}
+
+	fmt.Printf("%-7s  %-16s  %s\n", "ID", "Taken", "Path")
+	for _, p := range photos {
+		taken := "-"
+		if !p.DateTaken.IsZero() {
+			taken = p.DateTaken.Format("2006-01-02 15:04")
+		}
+		fmt.Printf("%-7d  %-16s  %s\n", p.ID, taken, p.FilePath)
+	}
+	if more {
+		fmt.Printf("\nShowing %d matches; use -offset %d for more\n", limit, offset+limit)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/adewale/olsen/internal/database"
+	"github.com/adewale/olsen/internal/query"
+)
+
+// tagCommand adds the tag add to, or removes the tag remove from, every photo
+// matching filter. An empty filter matches the whole library, so it must be
+// asked for with all.
+func tagCommand(dbPath, filter, add, remove string, all bool) error {
+	if (add == "") == (remove == "") {
+		return usageError("give exactly one of -add or -remove")
+	}
+	if filter == "" && !all {
+		return usageError("-filter is required (use -all to change every photo)")
+	}
+
+	params, err := query.NewURLMapper().ParsePath("/photos", filter)
+	if err != nil {
+		return usageError("invalid filter: %v", err)
+	}
+	// -all means every photo, rejected ones included
+	params.IncludeRejected = params.IncludeRejected || filter == ""
+
+	// Check database exists
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return notFoundError("database not found: %s", dbPath)
+	}
+
+	// Open database
+	db, err := database.Open(dbPath)
+	if err != nil {
+		return dbError("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	engine := query.NewEngine(db.DB)
+	matched, err := engine.Count(params)
+	if err != nil {
+		return dbError("failed to count photos: %v", err)
+	}
+	if matched == 0 {
+		return notFoundError("no photos match filter %q", filter)
+	}
+
+	ids, args := engine.MatchingIDs(params)
+	if add != "" {
+		changed, err := db.TagPhotos(add, ids, args)
+		if err != nil {
+			return dbError("%v", err)
+		}
+		fmt.Printf("Tagged %d of %d matching photos %q", changed, matched, add)
+		if skipped := int64(matched) - changed; skipped > 0 {
+			fmt.Printf(" (%d already had it)", skipped)
+		}
+		fmt.Println()
+		return nil
+	}
+
+	changed, err := db.UntagPhotos(remove, ids, args)
+	if err != nil {
+		return dbError("%v", err)
+	}
+	fmt.Printf("Removed %q from %d of %d matching photos\n", remove, changed, matched)
+	return nil
+}
@@ -3,12 +3,59 @@ package main
 import (
 	"fmt"
 	"runtime"
+	"runtime/debug"
 
 	"github.com/adewale/olsen/internal/indexer"
 )
 
+// buildInfo returns the commit and build date stamped by -ldflags. A plain
+// go build from a checkout stamps neither, so the commit falls back to the
+// revision the toolchain recorded, marked -dirty for uncommitted changes.
+func buildInfo() (rev, date string) {
+	rev, date = commit, buildDate
+	if rev != "" {
+		return rev, date
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "", date
+	}
+	dirty := false
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			rev = s.Value
+		case "vcs.modified":
+			dirty = s.Value == "true"
+		}
+	}
+	if len(rev) > 12 {
+		rev = rev[:12]
+	}
+	if rev != "" && dirty {
+		rev += "-dirty"
+	}
+	return rev, date
+}
+
+// versionString is the version with whatever build information is known,
+// e.g. "v0.2.0 (commit 1a2b3c4, built 2025-06-01T12:00:00Z)" or "dev"
+func versionString() string {
+	rev, date := buildInfo()
+	switch {
+	case rev != "" && date != "":
+		return fmt.Sprintf("%s (commit %s, built %s)", version, rev, date)
+	case rev != "":
+		return fmt.Sprintf("%s (commit %s)", version, rev)
+	case date != "":
+		return fmt.Sprintf("%s (built %s)", version, date)
+	}
+	return version
+}
+
 func versionCommand() error {
 	fmt.Println("Olsen Photo Indexer")
+	fmt.Printf("Version: %s\n", versionString())
 	fmt.Printf("Go version: %s\n", runtime.Version())
 	fmt.Printf("OS/Arch: %s/%s\n", runtime.GOOS, runtime.GOARCH)
 
@@ -0,0 +1,138 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ErrNotInBurst is returned when a photo is not a member of the burst named
+var ErrNotInBurst = errors.New("photo is not in this burst")
+
+// SetBurstRepresentative makes photoID the frame that stands for its burst
+// when bursts are collapsed. The choice is pinned to the photo, so it
+// survives burst detection being re-run, which assigns new group IDs.
+func (db *DB) SetBurstRepresentative(groupID string, photoID int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var members int
+	if err := tx.QueryRow(
+		"SELECT COUNT(*) FROM photos WHERE burst_group_id = ? AND id = ?", groupID, photoID,
+	).Scan(&members); err != nil {
+		return fmt.Errorf("failed to check burst membership: %w", err)
+	}
+	if members == 0 {
+		return fmt.Errorf("%w: photo %d, burst %s", ErrNotInBurst, photoID, groupID)
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE photos
+		SET is_burst_representative = (id = ?),
+		    burst_representative_pinned = (id = ?)
+		WHERE burst_group_id = ?
+	`, photoID, photoID, groupID); err != nil {
+		return fmt.Errorf("failed to set burst representative: %w", err)
+	}
+	if _, err := tx.Exec(
+		"UPDATE burst_groups SET representative_photo_id = ? WHERE id = ?", photoID, groupID,
+	); err != nil {
+		return fmt.Errorf("failed to update burst group: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+	return nil
+}
+
+// BurstRepresentativeOf returns the burst photoID belongs to and the frame
+// that stands for it. A photo outside any burst, or in one without a
+// representative, stands for itself and has an empty groupID.
+func (db *DB) BurstRepresentativeOf(photoID int) (groupID string, representativeID int, err error) {
+	var group sql.NullString
+	var representative sql.NullInt64
+	err = db.QueryRow(`
+		SELECT p.burst_group_id, r.id
+		FROM photos p
+		LEFT JOIN photos r ON r.burst_group_id = p.burst_group_id AND r.is_burst_representative = 1
+		WHERE p.id = ?
+		LIMIT 1
+	`, photoID).Scan(&group, &representative)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to look up burst of photo %d: %w", photoID, err)
+	}
+	if !group.Valid || !representative.Valid {
+		return group.String, photoID, nil
+	}
+	return group.String, int(representative.Int64), nil
+}
+
+// reassignBurstRepresentative hands the bursts photoID stands for to the
+// middle of their other frames, as burst detection would pick, so the photo
+// can be deleted. A burst with no other frames is left without one.
+func reassignBurstRepresentative(tx *sql.Tx, photoID int) error {
+	rows, err := tx.Query("SELECT id FROM burst_groups WHERE representative_photo_id = ?", photoID)
+	if err != nil {
+		return fmt.Errorf("failed to find bursts of photo %d: %w", photoID, err)
+	}
+	var groups []string
+	for rows.Next() {
+		var group string
+		if err := rows.Scan(&group); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to find bursts of photo %d: %w", photoID, err)
+		}
+		groups = append(groups, group)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to find bursts of photo %d: %w", photoID, err)
+	}
+
+	for _, group := range groups {
+		var next sql.NullInt64
+		err := tx.QueryRow(`
+			SELECT id FROM photos
+			WHERE burst_group_id = ?1 AND id != ?2
+			ORDER BY burst_sequence, id
+			LIMIT 1 OFFSET (SELECT (COUNT(*) - 1) / 2 FROM photos WHERE burst_group_id = ?1 AND id != ?2)
+		`, group, photoID).Scan(&next)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to pick a new representative for burst %s: %w", group, err)
+		}
+		if _, err := tx.Exec("UPDATE burst_groups SET representative_photo_id = ? WHERE id = ?", next, group); err != nil {
+			return fmt.Errorf("failed to update burst group: %w", err)
+		}
+		if next.Valid {
+			if _, err := tx.Exec("UPDATE photos SET is_burst_representative = 1 WHERE id = ?", next.Int64); err != nil {
+				return fmt.Errorf("failed to set burst representative: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// SetBurstAnimation stores the animated preview of a burst
+func (db *DB) SetBurstAnimation(groupID string, data []byte) error {
+	if _, err := db.Exec("UPDATE burst_groups SET animation = ? WHERE id = ?", data, groupID); err != nil {
+		return fmt.Errorf("failed to store animation for burst %s: %w", groupID, err)
+	}
+	return nil
+}
+
+// BurstAnimation returns the animated preview of a burst, or sql.ErrNoRows
+// if the burst doesn't exist or has none
+func (db *DB) BurstAnimation(groupID string) ([]byte, error) {
+	var data []byte
+	err := db.QueryRow(
+		"SELECT animation FROM burst_groups WHERE id = ? AND animation IS NOT NULL", groupID,
+	).Scan(&data)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
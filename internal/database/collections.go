@@ -0,0 +1,176 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrCollectionNotFound is returned when a collection ID or name matches nothing
+var ErrCollectionNotFound = errors.New("collection not found")
+
+// Collection is a user-curated set of photos. Membership is manual, so a
+// collection can cut across cameras, dates and every other facet.
+type Collection struct {
+	ID          int
+	Name        string
+	Description string
+	PhotoCount  int
+	CreatedAt   time.Time
+}
+
+// CreateCollection adds an empty manual collection and returns its ID.
+// Names must be unique so they can be used in place of IDs on the command line.
+func (db *DB) CreateCollection(name, description string) (int, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return 0, fmt.Errorf("collection name is required")
+	}
+	if _, err := strconv.Atoi(name); err == nil {
+		return 0, fmt.Errorf("collection name %q must not be a number", name)
+	}
+
+	var exists int
+	if err := db.QueryRow("SELECT COUNT(*) FROM collections WHERE name = ?", name).Scan(&exists); err != nil {
+		return 0, fmt.Errorf("failed to check collection name: %w", err)
+	}
+	if exists > 0 {
+		return 0, fmt.Errorf("collection %q already exists", name)
+	}
+
+	result, err := db.Exec(
+		"INSERT INTO collections (name, description, type) VALUES (?, ?, 'manual')",
+		name, nullString(description),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create collection: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get collection ID: %w", err)
+	}
+	return int(id), nil
+}
+
+// GetCollection returns a collection with its photo count
+func (db *DB) GetCollection(id int) (*Collection, error) {
+	rows, err := db.Query(collectionQuery+" WHERE c.id = ? GROUP BY c.id", id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get collection: %w", err)
+	}
+	collections, err := scanCollections(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(collections) == 0 {
+		return nil, fmt.Errorf("%w: %d", ErrCollectionNotFound, id)
+	}
+	return &collections[0], nil
+}
+
+// FindCollection resolves a collection by numeric ID or by name
+func (db *DB) FindCollection(idOrName string) (*Collection, error) {
+	if id, err := strconv.Atoi(idOrName); err == nil {
+		return db.GetCollection(id)
+	}
+
+	var id int
+	err := db.QueryRow("SELECT id FROM collections WHERE name = ?", idOrName).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %q", ErrCollectionNotFound, idOrName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find collection: %w", err)
+	}
+	return db.GetCollection(id)
+}
+
+// ListCollections returns all collections, newest first
+func (db *DB) ListCollections() ([]Collection, error) {
+	rows, err := db.Query(collectionQuery + " GROUP BY c.id ORDER BY c.created_at DESC, c.id DESC")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list collections: %w", err)
+	}
+	return scanCollections(rows)
+}
+
+// PhotoCollections returns the collections a photo belongs to, sorted by name
+func (db *DB) PhotoCollections(photoID int) ([]Collection, error) {
+	rows, err := db.Query(collectionQuery+`
+		WHERE c.id IN (SELECT collection_id FROM collection_photos WHERE photo_id = ?)
+		GROUP BY c.id ORDER BY c.name`, photoID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get photo collections: %w", err)
+	}
+	return scanCollections(rows)
+}
+
+// AddToCollection adds photos to a collection and returns how many were
+// added. Photos already in the collection and IDs with no photo are skipped.
+func (db *DB) AddToCollection(collectionID int, photoIDs []int) (int64, error) {
+	return db.changeMembership(collectionID, photoIDs, `
+		INSERT OR IGNORE INTO collection_photos (collection_id, photo_id)
+		SELECT ?, id FROM photos WHERE id = ?`)
+}
+
+// RemoveFromCollection removes photos from a collection and returns how many
+// were members
+func (db *DB) RemoveFromCollection(collectionID int, photoIDs []int) (int64, error) {
+	return db.changeMembership(collectionID, photoIDs,
+		"DELETE FROM collection_photos WHERE collection_id = ? AND photo_id = ?")
+}
+
+// changeMembership runs stmt with (collectionID, photoID) for each photo in
+// one transaction and returns the total rows affected
+func (db *DB) changeMembership(collectionID int, photoIDs []int, stmt string) (int64, error) {
+	if _, err := db.GetCollection(collectionID); err != nil {
+		return 0, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var changed int64
+	for _, photoID := range photoIDs {
+		result, err := tx.Exec(stmt, collectionID, photoID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to update collection for photo %d: %w", photoID, err)
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		changed += n
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return changed, nil
+}
+
+// collectionQuery selects the columns scanCollections expects
+const collectionQuery = `
+	SELECT c.id, c.name, COALESCE(c.description, ''), c.created_at, COUNT(cp.photo_id)
+	FROM collections c
+	LEFT JOIN collection_photos cp ON cp.collection_id = c.id`
+
+func scanCollections(rows *sql.Rows) ([]Collection, error) {
+	defer rows.Close()
+
+	collections := []Collection{}
+	for rows.Next() {
+		var c Collection
+		if err := rows.Scan(&c.ID, &c.Name, &c.Description, &c.CreatedAt, &c.PhotoCount); err != nil {
+			return nil, fmt.Errorf("failed to scan collection: %w", err)
+		}
+		collections = append(collections, c)
+	}
+	return collections, rows.Err()
+}
@@ -0,0 +1,83 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/adewale/olsen/pkg/models"
+)
+
+func TestCollections(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	for i := 1; i <= 3; i++ {
+		photo := &models.PhotoMetadata{FilePath: fmt.Sprintf("/p%d.dng", i), FileHash: fmt.Sprintf("h%d", i), FileSize: 1}
+		if err := db.InsertPhoto(photo); err != nil {
+			t.Fatalf("InsertPhoto failed: %v", err)
+		}
+	}
+
+	id, err := db.CreateCollection("Portfolio", "Best shots")
+	if err != nil {
+		t.Fatalf("CreateCollection failed: %v", err)
+	}
+	if _, err := db.CreateCollection("Portfolio", ""); err == nil {
+		t.Error("Duplicate collection name should fail")
+	}
+	if _, err := db.CreateCollection("42", ""); err == nil {
+		t.Error("Numeric collection name should fail, it would shadow IDs")
+	}
+
+	// Photo 99 does not exist and photo 1 is given twice
+	added, err := db.AddToCollection(id, []int{1, 2, 1, 99})
+	if err != nil {
+		t.Fatalf("AddToCollection failed: %v", err)
+	}
+	if added != 2 {
+		t.Errorf("Added %d photos; want 2", added)
+	}
+
+	removed, err := db.RemoveFromCollection(id, []int{2, 3})
+	if err != nil {
+		t.Fatalf("RemoveFromCollection failed: %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("Removed %d photos; want 1", removed)
+	}
+
+	byName, err := db.FindCollection("Portfolio")
+	if err != nil {
+		t.Fatalf("FindCollection by name failed: %v", err)
+	}
+	if byName.ID != id || byName.PhotoCount != 1 || byName.Description != "Best shots" {
+		t.Errorf("FindCollection = %+v; want ID %d with 1 photo", byName, id)
+	}
+
+	memberOf, err := db.PhotoCollections(1)
+	if err != nil {
+		t.Fatalf("PhotoCollections failed: %v", err)
+	}
+	if len(memberOf) != 1 || memberOf[0].Name != "Portfolio" {
+		t.Errorf("PhotoCollections(1) = %+v; want [Portfolio]", memberOf)
+	}
+
+	if _, err := db.FindCollection("Missing"); !errors.Is(err, ErrCollectionNotFound) {
+		t.Errorf("FindCollection(Missing) error = %v; want ErrCollectionNotFound", err)
+	}
+	if _, err := db.AddToCollection(id+1, []int{1}); !errors.Is(err, ErrCollectionNotFound) {
+		t.Errorf("AddToCollection on missing collection error = %v; want ErrCollectionNotFound", err)
+	}
+
+	list, err := db.ListCollections()
+	if err != nil {
+		t.Fatalf("ListCollections failed: %v", err)
+	}
+	if len(list) != 1 {
+		t.Errorf("ListCollections returned %d; want 1", len(list))
+	}
+}
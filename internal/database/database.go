@@ -7,33 +7,34 @@
 package database
 
 import (
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
 	"time"
 
-	_ "github.com/mattn/go-sqlite3"
-
 	"github.com/adewale/olsen/pkg/models"
 )
 
 // DB wraps the SQLite database connection
 type DB struct {
 	*sql.DB
+	dedupThumbnails bool // See SetThumbnailDedup
 }
 
 // Open creates a new database connection and initializes the schema
 func Open(path string) (*DB, error) {
-	db, err := sql.Open("sqlite3", path)
+	// Foreign keys are enabled per connection, so they go in the DSN: a
+	// PRAGMA run through the pool reaches only one of its connections, and
+	// DeletePhoto relies on cascades to remove thumbnails, colours and EXIF
+	db, err := sql.Open(DriverName, path+"?_foreign_keys=on")
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	// Enable foreign keys
-	if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
-		db.Close()
-		return nil, fmt.Errorf("failed to enable foreign keys: %w", err)
-	}
-
 	// Set performance pragmas
 	if _, err := db.Exec("PRAGMA journal_mode = WAL"); err != nil {
 		db.Close()
@@ -51,80 +52,253 @@ func Open(path string) (*DB, error) {
 		return nil, fmt.Errorf("failed to create schema: %w", err)
 	}
 
+	// Bring catalogs created by older versions up to date
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate schema: %w", err)
+	}
+
 	// Insert facet metadata
 	if _, err := db.Exec(FacetMetadataInserts); err != nil {
 		db.Close()
 		return nil, fmt.Errorf("failed to insert facet metadata: %w", err)
 	}
 
-	return &DB{db}, nil
+	return &DB{DB: db}, nil
+}
+
+// readOnlyDSN builds the file: URI SQLite needs for URI parameters. The path
+// is made absolute first: a relative one would be read as a URI authority.
+func readOnlyDSN(path string, immutable bool) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve database path: %w", err)
+	}
+
+	query := url.Values{}
+	query.Set("mode", "ro")
+	if immutable {
+		query.Set("immutable", "1")
+	}
+	return (&url.URL{Scheme: "file", Path: abs, RawQuery: query.Encode()}).String(), nil
 }
 
+// OpenReadOnly opens an existing database without write access, for browsing
+// a catalog that another process may be indexing. Schema creation and
+// migrations are skipped, so the catalog must already be up to date.
+//
+// Set immutable only when nothing can change the file while it is open, such
+// as a catalog on read-only media: SQLite then skips locking entirely and
+// does not need to create WAL side files next to the database.
+func OpenReadOnly(path string, immutable bool) (*DB, error) {
+	dsn, err := readOnlyDSN(path, immutable)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open(DriverName, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// sql.Open is lazy; connect now so a missing or unreadable file fails here
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	missing, err := missingMigrations(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	if len(missing) > 0 {
+		db.Close()
+		return nil, fmt.Errorf("database schema is out of date (missing %s); open it once in read-write mode to migrate", missing[0])
+	}
+
+	return &DB{DB: db}, nil
+}
+
+// photoFileColumns are the photos columns read from the file itself, in the
+// order InsertPhoto binds them. ReplacePhoto overwrites just these, so the
+// rating, rejected, burst, bracket and session columns keep their values.
+const photoFileColumns = `file_path, file_hash, file_size, last_modified, file_format, media_type,
+	thumbnails_upscaled, thumbnails_skipped, thumbnails_pending,
+	camera_make, camera_model, lens_make, lens_model, camera_serial, camera_serial_token, software, title, caption,
+	iso, aperture, shutter_speed, shutter_seconds, exposure_compensation, focal_length, focal_length_35mm,
+	date_taken, date_digitized, time_offset,
+	width, height, orientation, color_space, duration,
+	latitude, longitude, altitude,
+	dng_version, original_raw_filename,
+	flash_fired, white_balance, focus_distance,
+	time_of_day, season, focal_category, shooting_condition, exposure_value,
+	sun_elevation, edited, perceptual_hash, blurhash, dominant_hue, dominant_rgb, colour_count`
+
+// photoFileValues are the placeholders for photoFileColumns; a missing media
+// type is stored as a photo
+const photoFileValues = `?, ?, ?, ?, ?, COALESCE(?, 'photo'),
+	?, ?, ?,
+	?, ?, ?, ?, ?, ?, ?, ?, ?,
+	?, ?, ?, ?, ?, ?, ?,
+	?, ?, ?,
+	?, ?, ?, ?, ?,
+	?, ?, ?,
+	?, ?,
+	?, ?, ?,
+	?, ?, ?, ?, ?,
+	?, ?, ?, ?, ?, ?, ?`
+
 // InsertPhoto inserts a photo and its related data into the database
 func (db *DB) InsertPhoto(photo *models.PhotoMetadata) error {
+	return db.writePhoto(photo, false)
+}
+
+// ReplacePhoto stores a photo like InsertPhoto, but if one with the same
+// path is already indexed, its row is updated in place: it keeps its ID, so
+// tags, collections and links to it survive, as do its rating and burst,
+// bracket and session assignments. Thumbnails, colours and EXIF tags are
+// replaced.
+func (db *DB) ReplacePhoto(photo *models.PhotoMetadata) error {
+	return db.writePhoto(photo, true)
+}
+
+// replacePhotoAssignments sets every file column but the path from the new
+// row, for ReplacePhoto's ON CONFLICT clause
+var replacePhotoAssignments = func() string {
+	sets := []string{"indexed_at = CURRENT_TIMESTAMP"}
+	for _, column := range strings.Split(photoFileColumns, ",") {
+		if column = strings.TrimSpace(column); column != "file_path" {
+			sets = append(sets, column+" = excluded."+column)
+		}
+	}
+	return strings.Join(sets, ", ")
+}()
+
+// writePhoto is InsertPhoto, or with replace ReplacePhoto
+func (db *DB) writePhoto(photo *models.PhotoMetadata, replace bool) error {
 	tx, err := db.Begin()
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
+	hue, rgb := dominantColour(photo.DominantColours)
+
 	// Insert photo record
-	result, err := tx.Exec(`
-		INSERT INTO photos (
-			file_path, file_hash, file_size, last_modified,
-			camera_make, camera_model, lens_make, lens_model,
-			iso, aperture, shutter_speed, exposure_compensation, focal_length, focal_length_35mm,
-			date_taken, date_digitized,
-			width, height, orientation, color_space,
-			latitude, longitude, altitude,
-			dng_version, original_raw_filename,
-			flash_fired, white_balance, focus_distance,
-			time_of_day, season, focal_category, shooting_condition,
-			perceptual_hash
-		) VALUES (
-			?, ?, ?, ?,
-			?, ?, ?, ?,
-			?, ?, ?, ?, ?, ?,
-			?, ?,
-			?, ?, ?, ?,
-			?, ?, ?,
-			?, ?,
-			?, ?, ?,
-			?, ?, ?, ?,
-			?
-		)`,
-		photo.FilePath, photo.FileHash, photo.FileSize, photo.LastModified,
+	statement := "INSERT INTO photos (" + photoFileColumns + ") VALUES (" + photoFileValues + ")"
+	if replace {
+		statement += " ON CONFLICT(file_path) DO UPDATE SET " + replacePhotoAssignments
+	}
+	result, err := tx.Exec(statement,
+		photo.FilePath, photo.FileHash, photo.FileSize, photo.LastModified, nullString(photo.FileFormat), nullString(photo.MediaType),
+		photo.ThumbnailsUpscaled, photo.ThumbnailsSkipped, photo.ThumbnailsPending,
 		nullString(photo.CameraMake), nullString(photo.CameraModel), nullString(photo.LensMake), nullString(photo.LensModel),
-		nullInt(photo.ISO), nullFloat(photo.Aperture), nullString(photo.ShutterSpeed), nullFloat(photo.ExposureCompensation), nullFloat(photo.FocalLength), nullInt(photo.FocalLength35mm),
-		nullTime(photo.DateTaken), nullTime(photo.DateDigitized),
-		nullInt(photo.Width), nullInt(photo.Height), nullInt(photo.Orientation), nullString(photo.ColourSpace),
+		nullString(photo.CameraSerial), nullString(SerialToken(photo.CameraSerial)), nullString(photo.Software),
+		nullString(photo.Title), nullString(photo.Caption),
+		nullInt(photo.ISO), nullFloat(photo.Aperture), nullString(photo.ShutterSpeed), photo.ShutterSeconds, nullFloat(photo.ExposureCompensation), nullFloat(photo.FocalLength), nullInt(photo.FocalLength35mm),
+		nullTime(photo.DateTaken), nullTime(photo.DateDigitized), nullString(photo.TimeOffset),
+		nullInt(photo.Width), nullInt(photo.Height), nullInt(photo.Orientation), nullString(photo.ColourSpace), nullFloat(photo.Duration),
 		nullFloat(photo.Latitude), nullFloat(photo.Longitude), nullFloat(photo.Altitude),
 		nullString(photo.DNGVersion), nullString(photo.OriginalRawFilename),
 		photo.FlashFired, nullString(photo.WhiteBalance), nullFloat(photo.FocusDistance),
-		nullString(photo.TimeOfDay), nullString(photo.Season), nullString(photo.FocalCategory), nullString(photo.ShootingCondition),
-		nullString(photo.PerceptualHash),
+		nullString(photo.TimeOfDay), nullString(photo.Season), nullString(photo.FocalCategory), nullString(photo.ShootingCondition), photo.ExposureValue,
+		photo.SunElevation, photo.Edited, nullString(photo.PerceptualHash), nullString(photo.Blurhash), hue, rgb, colourCount(photo.DominantColours),
 	)
 	if err != nil {
 		return fmt.Errorf("failed to insert photo: %w", err)
 	}
 
-	photoID, err := result.LastInsertId()
-	if err != nil {
+	var photoID int64
+	if replace {
+		// LastInsertId is not set when the upsert updated a row
+		if err := tx.QueryRow("SELECT id FROM photos WHERE file_path = ?", photo.FilePath).Scan(&photoID); err != nil {
+			return fmt.Errorf("failed to get photo ID: %w", err)
+		}
+		for _, table := range []string{"thumbnails", "photo_colors", "photo_exif"} {
+			if _, err := tx.Exec("DELETE FROM "+table+" WHERE photo_id = ?", photoID); err != nil {
+				return fmt.Errorf("failed to clear %s: %w", table, err)
+			}
+		}
+	} else if photoID, err = result.LastInsertId(); err != nil {
 		return fmt.Errorf("failed to get photo ID: %w", err)
 	}
 
 	// Insert thumbnails
-	for size, data := range photo.Thumbnails {
+	if err := insertThumbnails(tx, photoID, photo.Thumbnails, db.dedupThumbnails); err != nil {
+		return err
+	}
+
+	// Insert colours
+	for i, colour := range photo.DominantColours {
 		_, err := tx.Exec(`
-			INSERT INTO thumbnails (photo_id, size, data, format, quality)
-			VALUES (?, ?, ?, 'jpeg', 85)
-		`, photoID, string(size), data)
+			INSERT INTO photo_colors (photo_id, color_order, red, green, blue, weight, hue, saturation, lightness)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, photoID, i, colour.Colour.R, colour.Colour.G, colour.Colour.B, colour.Weight, colour.HSL.H, colour.HSL.S, colour.HSL.L)
 		if err != nil {
-			return fmt.Errorf("failed to insert thumbnail %s: %w", size, err)
+			return fmt.Errorf("failed to insert colour %d: %w", i, err)
 		}
 	}
 
-	// Insert colours
+	// Insert raw EXIF tags
+	for _, entry := range photo.RawExif {
+		_, err := tx.Exec(`
+			INSERT OR REPLACE INTO photo_exif (photo_id, ifd_path, tag_name, value)
+			VALUES (?, ?, ?, ?)
+		`, photoID, entry.IFD, entry.Tag, entry.Value)
+		if err != nil {
+			return fmt.Errorf("failed to insert EXIF tag %s: %w", entry.Tag, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateImageData replaces the thumbnails, colours, perceptual hash and
+// blurhash of an already indexed photo and clears its thumbnails_pending
+// flag. The photo keeps its ID, so collections, lens edits and links to it
+// survive filling in thumbnails left by index -no-thumbnails.
+func (db *DB) UpdateImageData(photo *models.PhotoMetadata) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var photoID int64
+	err = tx.QueryRow("SELECT id FROM photos WHERE file_path = ?", photo.FilePath).Scan(&photoID)
+	if err != nil {
+		return fmt.Errorf("failed to get photo ID: %w", err)
+	}
+
+	hue, rgb := dominantColour(photo.DominantColours)
+
+	_, err = tx.Exec(`
+		UPDATE photos SET
+			thumbnails_upscaled = ?, thumbnails_skipped = ?, thumbnails_pending = 0,
+			perceptual_hash = ?, blurhash = ?, dominant_hue = ?, dominant_rgb = ?, colour_count = ?
+		WHERE id = ?`,
+		photo.ThumbnailsUpscaled, photo.ThumbnailsSkipped,
+		nullString(photo.PerceptualHash), nullString(photo.Blurhash), hue, rgb, colourCount(photo.DominantColours), photoID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update photo: %w", err)
+	}
+
+	if _, err := tx.Exec("DELETE FROM thumbnails WHERE photo_id = ?", photoID); err != nil {
+		return fmt.Errorf("failed to delete thumbnails: %w", err)
+	}
+	if err := insertThumbnails(tx, photoID, photo.Thumbnails, db.dedupThumbnails); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM photo_colors WHERE photo_id = ?", photoID); err != nil {
+		return fmt.Errorf("failed to delete colours: %w", err)
+	}
 	for i, colour := range photo.DominantColours {
 		_, err := tx.Exec(`
 			INSERT INTO photo_colors (photo_id, color_order, red, green, blue, weight, hue, saturation, lightness)
@@ -156,7 +330,24 @@ func (db *DB) GetPhotoHash(filePath string) (string, error) {
 	return hash, err
 }
 
-// DeletePhoto deletes a photo and all related data (thumbnails, colors, etc.) by file path
+// ThumbnailsPending reports whether a photo was indexed metadata-only
+// (index -no-thumbnails) and still needs its thumbnails generated
+func (db *DB) ThumbnailsPending(filePath string) (bool, error) {
+	var pending bool
+	err := db.QueryRow("SELECT thumbnails_pending FROM photos WHERE file_path = ?", filePath).Scan(&pending)
+	return pending, err
+}
+
+// GetPendingThumbnailCount returns how many photos were indexed
+// metadata-only and are still waiting for thumbnails
+func (db *DB) GetPendingThumbnailCount() (int, error) {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM photos WHERE thumbnails_pending").Scan(&count)
+	return count, err
+}
+
+// DeletePhoto deletes a photo and all related data (thumbnails, colors, etc.) by file path.
+// A burst it represents is handed to another of its frames.
 func (db *DB) DeletePhoto(filePath string) error {
 	// Start transaction
 	tx, err := db.Begin()
@@ -172,13 +363,12 @@ func (db *DB) DeletePhoto(filePath string) error {
 		return fmt.Errorf("failed to get photo ID: %w", err)
 	}
 
-	// Delete related records (thumbnails will cascade delete due to FK constraint)
-	// Delete color palette entries
-	if _, err := tx.Exec("DELETE FROM color_palette WHERE photo_id = ?", photoID); err != nil {
-		return fmt.Errorf("failed to delete color palette: %w", err)
+	// A burst it stands for must not keep pointing at it
+	if err := reassignBurstRepresentative(tx, photoID); err != nil {
+		return err
 	}
 
-	// Delete the photo itself (thumbnails will cascade)
+	// Delete the photo itself (thumbnails, colours and EXIF rows cascade)
 	if _, err := tx.Exec("DELETE FROM photos WHERE id = ?", photoID); err != nil {
 		return fmt.Errorf("failed to delete photo: %w", err)
 	}
@@ -191,6 +381,62 @@ func (db *DB) DeletePhoto(filePath string) error {
 	return nil
 }
 
+// SetLensModel assigns a lens model to photos taken with the given camera,
+// typically to backfill manual lenses that record no lens in EXIF. The camera
+// matches either the model alone or "make model", case-insensitively. A
+// focalLength of 0 matches any focal length; otherwise the recorded focal
+// length must round to it. Unless overwrite is set, only photos without a
+// lens model are changed. It returns the number of photos updated.
+func (db *DB) SetLensModel(camera string, focalLength float64, lens string, overwrite bool) (int64, error) {
+	where := []string{
+		"(LOWER(camera_model) = LOWER(?) OR LOWER(camera_make || ' ' || camera_model) = LOWER(?))",
+	}
+	args := []interface{}{lens, camera, camera}
+
+	if focalLength > 0 {
+		where = append(where, "ROUND(focal_length) = ROUND(?)")
+		args = append(args, focalLength)
+	}
+	if !overwrite {
+		where = append(where, "(lens_model IS NULL OR lens_model = '')")
+	}
+
+	result, err := db.Exec("UPDATE photos SET lens_model = ? WHERE "+strings.Join(where, " AND "), args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update lens model: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// SerialToken returns a short opaque stand-in for a camera body serial
+// number, so filters on a body can be bookmarked without the serial itself
+// appearing in URLs or logs. It is a truncated SHA-256, which hides the
+// serial from casual view but is not secret: serials are short enough to
+// brute-force. Returns "" for an empty serial.
+func SerialToken(serial string) string {
+	if serial == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(serial))
+	return hex.EncodeToString(sum[:5])
+}
+
+// GetUpscaleCounts returns how many photos have an upscaled thumbnail size
+// and how many have sizes that were skipped because the image was too small
+func (db *DB) GetUpscaleCounts() (upscaled, skipped int, err error) {
+	err = db.QueryRow(`
+		SELECT
+			COUNT(CASE WHEN thumbnails_upscaled THEN 1 END),
+			COUNT(CASE WHEN thumbnails_skipped > 0 THEN 1 END)
+		FROM photos
+	`).Scan(&upscaled, &skipped)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count upscaled thumbnails: %w", err)
+	}
+	return upscaled, skipped, nil
+}
+
 // GetPhotoCount returns the total number of photos in the database
 func (db *DB) GetPhotoCount() (int, error) {
 	var count int
@@ -198,6 +444,44 @@ func (db *DB) GetPhotoCount() (int, error) {
 	return count, err
 }
 
+// dominantColour returns the hue and #rrggbb of the highest-weight palette
+// colour, the first on a tie, or NULLs for a photo without a palette
+func dominantColour(colours []models.DominantColour) (hue, rgb interface{}) {
+	if len(colours) == 0 {
+		return nil, nil
+	}
+	best := colours[0]
+	for _, c := range colours[1:] {
+		if c.Weight > best.Weight {
+			best = c
+		}
+	}
+	return best.HSL.H, fmt.Sprintf("#%02x%02x%02x", best.Colour.R, best.Colour.G, best.Colour.B)
+}
+
+// colourCount returns the number of distinct colour families in a palette,
+// or NULL without one. Colours covering under 10% of the image are ignored;
+// the rest are grouped into twelve 30-degree hue bands, with all greys
+// (saturation under 20) as one more. k-means always returns five colours, so
+// counting them directly would not tell a blue sky from a market stall.
+func colourCount(colours []models.DominantColour) interface{} {
+	if len(colours) == 0 {
+		return nil
+	}
+	families := make(map[int]bool)
+	for _, c := range colours {
+		if c.Weight < 0.1 {
+			continue
+		}
+		if c.HSL.S < 20 {
+			families[-1] = true
+		} else {
+			families[c.HSL.H%360/30] = true
+		}
+	}
+	return len(families)
+}
+
 // Helper functions to handle NULL values
 func nullString(s string) interface{} {
 	if s == "" {
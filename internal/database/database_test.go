@@ -1,7 +1,11 @@
 package database
 
 import (
+	"context"
+	"crypto/rand"
+	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -437,3 +441,243 @@ func BenchmarkPhotoExists(b *testing.B) {
 		}
 	}
 }
+
+func TestSetLensModel(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	photos := []struct {
+		path   string
+		make   string
+		model  string
+		focal  float64
+		lens   string
+		expect string
+	}{
+		{"/m11_50.dng", "Leica Camera AG", "LEICA M11", 50, "", "Voigtländer 50mm"},
+		{"/m11_50b.dng", "Leica Camera AG", "LEICA M11", 50.2, "", "Voigtländer 50mm"},
+		{"/m11_35.dng", "Leica Camera AG", "LEICA M11", 35, "", ""},                     // Different focal length
+		{"/m11_tagged.dng", "Leica Camera AG", "LEICA M11", 50, "Summilux", "Summilux"}, // Already has a lens
+		{"/q3_50.dng", "Leica Camera AG", "LEICA Q3", 50, "", ""},                       // Different camera
+	}
+	for _, p := range photos {
+		if err := db.InsertPhoto(&models.PhotoMetadata{
+			FilePath:    p.path,
+			FileHash:    p.path,
+			CameraMake:  p.make,
+			CameraModel: p.model,
+			FocalLength: p.focal,
+			LensModel:   p.lens,
+		}); err != nil {
+			t.Fatalf("Failed to insert photo: %v", err)
+		}
+	}
+
+	updated, err := db.SetLensModel("Leica M11", 50, "Voigtländer 50mm", false)
+	if err != nil {
+		t.Fatalf("SetLensModel failed: %v", err)
+	}
+	if updated != 2 {
+		t.Errorf("SetLensModel updated %d photos; want 2", updated)
+	}
+
+	for _, p := range photos {
+		var lens string
+		if err := db.QueryRow("SELECT COALESCE(lens_model, '') FROM photos WHERE file_path = ?", p.path).Scan(&lens); err != nil {
+			t.Fatalf("Failed to query lens: %v", err)
+		}
+		if lens != p.expect {
+			t.Errorf("%s lens_model = %q; want %q", p.path, lens, p.expect)
+		}
+	}
+
+	// Matching by make and model, any focal length, overwriting existing lenses
+	updated, err = db.SetLensModel("leica camera ag leica m11", 0, "Manual", true)
+	if err != nil {
+		t.Fatalf("SetLensModel with overwrite failed: %v", err)
+	}
+	if updated != 4 {
+		t.Errorf("SetLensModel with overwrite updated %d photos; want 4", updated)
+	}
+}
+
+func TestOpenReadOnly(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "catalog.db")
+
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	photo := &models.PhotoMetadata{
+		FilePath: "/test/photo.dng",
+		FileHash: "hash",
+		FileSize: 1024,
+	}
+	if err := db.InsertPhoto(photo); err != nil {
+		t.Fatalf("Failed to insert photo: %v", err)
+	}
+	db.Close()
+
+	for _, immutable := range []bool{false, true} {
+		ro, err := OpenReadOnly(dbPath, immutable)
+		if err != nil {
+			t.Fatalf("OpenReadOnly(immutable=%v) failed: %v", immutable, err)
+		}
+
+		count, err := ro.GetPhotoCount()
+		if err != nil {
+			t.Errorf("GetPhotoCount (immutable=%v) failed: %v", immutable, err)
+		}
+		if count != 1 {
+			t.Errorf("GetPhotoCount (immutable=%v) = %d; want 1", immutable, count)
+		}
+
+		photo.FilePath = "/test/other.dng"
+		if err := ro.InsertPhoto(photo); err == nil {
+			t.Errorf("InsertPhoto (immutable=%v) succeeded on read-only database", immutable)
+		}
+		ro.Close()
+	}
+}
+
+func TestOpenReadOnlyMissingFile(t *testing.T) {
+	if _, err := OpenReadOnly(filepath.Join(t.TempDir(), "missing.db"), false); err == nil {
+		t.Error("OpenReadOnly should fail for a missing database")
+	}
+}
+
+func TestOpenReadOnlyRelativePath(t *testing.T) {
+	dir := t.TempDir()
+	db, err := Open(filepath.Join(dir, "photos.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	db.Close()
+
+	// A relative path must not end up as the authority of the file: URI
+	t.Chdir(dir)
+	ro, err := OpenReadOnly("photos.db", false)
+	if err != nil {
+		t.Fatalf("OpenReadOnly with a relative path failed: %v", err)
+	}
+	ro.Close()
+}
+
+func TestInsertPhotoCameraSerial(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	photo := &models.PhotoMetadata{
+		FilePath:     "/test/body.dng",
+		FileHash:     "hash",
+		FileSize:     1024,
+		CameraSerial: "012345678901",
+	}
+	if err := db.InsertPhoto(photo); err != nil {
+		t.Fatalf("InsertPhoto failed: %v", err)
+	}
+
+	var serial, token string
+	err = db.QueryRow("SELECT camera_serial, camera_serial_token FROM photos WHERE file_path = ?", photo.FilePath).Scan(&serial, &token)
+	if err != nil {
+		t.Fatalf("Failed to read serial: %v", err)
+	}
+	if serial != photo.CameraSerial {
+		t.Errorf("camera_serial = %q; want %q", serial, photo.CameraSerial)
+	}
+	if token != SerialToken(photo.CameraSerial) {
+		t.Errorf("camera_serial_token = %q; want %q", token, SerialToken(photo.CameraSerial))
+	}
+	if len(token) != 10 {
+		t.Errorf("Token %q should be 10 hex characters", token)
+	}
+	if SerialToken("") != "" {
+		t.Error("Empty serial should have an empty token")
+	}
+}
+
+func TestCompact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "compact.db")
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// Photos with large thumbnails, deleted again as a re-index would
+	thumbnail := make([]byte, 32*1024)
+	for i := 0; i < 50; i++ {
+		rand.Read(thumbnail)
+		path := fmt.Sprintf("/photos/%d.jpg", i)
+		photo := &models.PhotoMetadata{FilePath: path, FileHash: path, FileSize: 1,
+			Thumbnails: map[models.ThumbnailSize][]byte{models.ThumbnailLarge: thumbnail}}
+		if err := db.InsertPhoto(photo); err != nil {
+			t.Fatalf("InsertPhoto failed: %v", err)
+		}
+	}
+	for i := 0; i < 50; i++ {
+		if err := db.DeletePhoto(fmt.Sprintf("/photos/%d.jpg", i)); err != nil {
+			t.Fatalf("DeletePhoto failed: %v", err)
+		}
+	}
+	if _, err := db.Exec("PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+		t.Fatalf("Failed to checkpoint: %v", err)
+	}
+	before, err := FileSize(path)
+	if err != nil {
+		t.Fatalf("FileSize failed: %v", err)
+	}
+
+	if err := db.Compact(true); err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+
+	after, err := FileSize(path)
+	if err != nil {
+		t.Fatalf("FileSize failed: %v", err)
+	}
+	if after >= before/2 {
+		t.Errorf("size after compact = %d; want well under %d", after, before)
+	}
+	if wal, err := os.Stat(path + "-wal"); err == nil && wal.Size() != 0 {
+		t.Errorf("WAL is %d bytes after checkpoint; want truncated", wal.Size())
+	}
+}
+
+func TestDeletePhotoCascadesOnEveryConnection(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "cascade.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	photo := &models.PhotoMetadata{FilePath: "/a.jpg", FileHash: "a", FileSize: 1,
+		Thumbnails: map[models.ThumbnailSize][]byte{models.ThumbnailTiny: {1}}}
+	if err := db.InsertPhoto(photo); err != nil {
+		t.Fatalf("InsertPhoto failed: %v", err)
+	}
+
+	// Hold the pool's first connection so the delete runs on a new one
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to get connection: %v", err)
+	}
+	defer conn.Close()
+
+	if err := db.DeletePhoto("/a.jpg"); err != nil {
+		t.Fatalf("DeletePhoto failed: %v", err)
+	}
+	var thumbnails int
+	if err := db.QueryRow("SELECT COUNT(*) FROM thumbnails").Scan(&thumbnails); err != nil {
+		t.Fatalf("Failed to count thumbnails: %v", err)
+	}
+	if thumbnails != 0 {
+		t.Errorf("%d thumbnails left after DeletePhoto; want 0", thumbnails)
+	}
+}
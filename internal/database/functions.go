@@ -0,0 +1,75 @@
+package database
+
+import (
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// DriverName is the database/sql driver Olsen opens catalogs with: SQLite
+// with the SQL functions below registered on every connection
+const DriverName = "sqlite3_olsen"
+
+func init() {
+	sql.Register(DriverName, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			return conn.RegisterFunc("local_time", localTime, true)
+		},
+	})
+}
+
+// zones caches time.LoadLocation, which reads the zone database each call
+var zones sync.Map
+
+// localTime implements local_time(date_taken, time_offset, zone): the
+// capture time as seen in the IANA time zone, for photos whose EXIF records
+// the camera's UTC offset. Without an offset the capture instant is unknown,
+// so the camera's clock time is returned unchanged. Either way the result
+// follows the date_taken convention of wall-clock time marked as UTC, so
+// strftime and time.RFC3339 read it like the column itself.
+func localTime(dateTaken, offset interface{}, zone string) (interface{}, error) {
+	text, ok := dateTaken.(string)
+	if !ok {
+		return dateTaken, nil // NULL, or not a date we wrote
+	}
+
+	var wall time.Time
+	var err error
+	for _, format := range sqlite3.SQLiteTimestampFormats {
+		if wall, err = time.Parse(format, text); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return dateTaken, nil
+	}
+	wall = time.Date(wall.Year(), wall.Month(), wall.Day(),
+		wall.Hour(), wall.Minute(), wall.Second(), wall.Nanosecond(), time.UTC)
+
+	if o, ok := offset.(string); ok {
+		zoneOffset, zoneErr := time.Parse("-07:00", o)
+		loc, locErr := loadZone(zone)
+		if zoneErr == nil && locErr == nil {
+			_, seconds := zoneOffset.Zone()
+			local := wall.Add(-time.Duration(seconds) * time.Second).In(loc)
+			wall = time.Date(local.Year(), local.Month(), local.Day(),
+				local.Hour(), local.Minute(), local.Second(), local.Nanosecond(), time.UTC)
+		}
+	}
+	return wall.Format(time.RFC3339), nil
+}
+
+// loadZone returns the named time zone, caching it across calls
+func loadZone(name string) (*time.Location, error) {
+	if loc, ok := zones.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	zones.Store(name, loc)
+	return loc, nil
+}
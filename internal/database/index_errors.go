@@ -0,0 +1,64 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// IndexError records a file the indexer could not process. Only the most
+// recent failure of each file is kept, and it is cleared once the file
+// indexes successfully.
+type IndexError struct {
+	FilePath   string
+	Error      string
+	OccurredAt time.Time
+}
+
+// RecordIndexError stores or replaces the failure for a file
+func (db *DB) RecordIndexError(filePath, message string) error {
+	_, err := db.Exec(`
+		INSERT INTO index_errors (file_path, error, occurred_at) VALUES (?, ?, ?)
+		ON CONFLICT(file_path) DO UPDATE SET error = excluded.error, occurred_at = excluded.occurred_at`,
+		filePath, message, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to record index error: %w", err)
+	}
+	return nil
+}
+
+// ClearIndexError forgets any recorded failure for a file
+func (db *DB) ClearIndexError(filePath string) error {
+	if _, err := db.Exec("DELETE FROM index_errors WHERE file_path = ?", filePath); err != nil {
+		return fmt.Errorf("failed to clear index error: %w", err)
+	}
+	return nil
+}
+
+// ListIndexErrors returns recorded failures, newest first. A non-empty match
+// keeps only those whose path or message contains it (case-insensitive).
+func (db *DB) ListIndexErrors(match string) ([]IndexError, error) {
+	query := "SELECT file_path, error, occurred_at FROM index_errors"
+	var args []interface{}
+	if match != "" {
+		query += " WHERE file_path LIKE ? OR error LIKE ?"
+		pattern := "%" + match + "%"
+		args = append(args, pattern, pattern)
+	}
+	query += " ORDER BY occurred_at DESC, file_path"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list index errors: %w", err)
+	}
+	defer rows.Close()
+
+	errs := []IndexError{}
+	for rows.Next() {
+		var e IndexError
+		if err := rows.Scan(&e.FilePath, &e.Error, &e.OccurredAt); err != nil {
+			return nil, fmt.Errorf("failed to scan index error: %w", err)
+		}
+		errs = append(errs, e)
+	}
+	return errs, rows.Err()
+}
@@ -0,0 +1,54 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestIndexErrors(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "errors.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.RecordIndexError("/photos/a.dng", "failed to decode image: bad header"); err != nil {
+		t.Fatalf("RecordIndexError failed: %v", err)
+	}
+	if err := db.RecordIndexError("/photos/b.jpg", "failed to calculate hash: permission denied"); err != nil {
+		t.Fatalf("RecordIndexError failed: %v", err)
+	}
+	// A second failure of the same file replaces the first
+	if err := db.RecordIndexError("/photos/a.dng", "failed to decode image: truncated"); err != nil {
+		t.Fatalf("RecordIndexError failed: %v", err)
+	}
+
+	all, err := db.ListIndexErrors("")
+	if err != nil {
+		t.Fatalf("ListIndexErrors failed: %v", err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("got %d errors; want 2", len(all))
+	}
+	if all[0].FilePath != "/photos/a.dng" || all[0].Error != "failed to decode image: truncated" {
+		t.Errorf("newest error = %+v; want the replaced a.dng entry", all[0])
+	}
+
+	for match, want := range map[string]int{"decode": 1, "B.JPG": 1, "/photos/": 2, "missing": 0} {
+		got, err := db.ListIndexErrors(match)
+		if err != nil {
+			t.Fatalf("ListIndexErrors(%q) failed: %v", match, err)
+		}
+		if len(got) != want {
+			t.Errorf("ListIndexErrors(%q) returned %d; want %d", match, len(got), want)
+		}
+	}
+
+	if err := db.ClearIndexError("/photos/a.dng"); err != nil {
+		t.Fatalf("ClearIndexError failed: %v", err)
+	}
+	all, _ = db.ListIndexErrors("")
+	if len(all) != 1 || all[0].FilePath != "/photos/b.jpg" {
+		t.Errorf("after clear: %+v; want only b.jpg", all)
+	}
+}
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/adewale/olsen/pkg/models"
+)
+
+// IndexRun is one recorded olsen index run
+type IndexRun struct {
+	ID    int64
+	Roots []string // Directories indexed
+	Stats models.IndexStats
+	Error string // Why the run stopped; empty when it completed
+}
+
+// Succeeded reports whether the run completed
+func (r IndexRun) Succeeded() bool {
+	return r.Error == ""
+}
+
+// RecordIndexRun stores the statistics of an index run over roots. A run
+// that stopped with runErr is recorded too, with the error, so a scheduled
+// job that failed shows up in the history. A run without an end time is
+// taken to have ended now.
+func (db *DB) RecordIndexRun(roots []string, stats models.IndexStats, runErr error) (int64, error) {
+	if stats.EndTime.IsZero() {
+		stats.EndTime = time.Now()
+	}
+	var message interface{}
+	if runErr != nil {
+		message = runErr.Error()
+	}
+
+	result, err := db.Exec(`
+		INSERT INTO index_runs (
+			roots, started_at, finished_at,
+			files_found, files_processed, files_skipped, files_updated, files_failed, files_out_of_range,
+			thumbnails_generated, hashes_computed, error
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		strings.Join(roots, "\n"), stats.StartTime, stats.EndTime,
+		stats.FilesFound, stats.FilesProcessed, stats.FilesSkipped, stats.FilesUpdated, stats.FilesFailed, stats.FilesOutOfRange,
+		stats.ThumbnailsGenerated, stats.HashesComputed, message,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record index run: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+// ListIndexRuns returns up to limit recorded runs, newest first; a limit of
+// 0 returns them all
+func (db *DB) ListIndexRuns(limit int) ([]IndexRun, error) {
+	query := `
+		SELECT id, roots, started_at, finished_at,
+		       files_found, files_processed, files_skipped, files_updated, files_failed, files_out_of_range,
+		       thumbnails_generated, hashes_computed, COALESCE(error, '')
+		FROM index_runs
+		ORDER BY started_at DESC, id DESC`
+	var args []interface{}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list index runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := []IndexRun{}
+	for rows.Next() {
+		var r IndexRun
+		var roots string
+		s := &r.Stats
+		err := rows.Scan(&r.ID, &roots, &s.StartTime, &s.EndTime,
+			&s.FilesFound, &s.FilesProcessed, &s.FilesSkipped, &s.FilesUpdated, &s.FilesFailed, &s.FilesOutOfRange,
+			&s.ThumbnailsGenerated, &s.HashesComputed, &r.Error)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan index run: %w", err)
+		}
+		r.Roots = strings.Split(roots, "\n")
+		runs = append(runs, r)
+	}
+	return runs, rows.Err()
+}
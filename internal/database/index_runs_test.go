@@ -0,0 +1,53 @@
+package database
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/adewale/olsen/pkg/models"
+)
+
+func TestIndexRuns(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "runs.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	start := time.Date(2024, 6, 1, 3, 0, 0, 0, time.UTC)
+	completed := models.IndexStats{
+		FilesFound: 120, FilesProcessed: 20, FilesSkipped: 100, FilesFailed: 1,
+		StartTime: start, EndTime: start.Add(10 * time.Second),
+	}
+	if _, err := db.RecordIndexRun([]string{"/photos", "/more"}, completed, nil); err != nil {
+		t.Fatalf("RecordIndexRun failed: %v", err)
+	}
+	failed := models.IndexStats{StartTime: start.Add(24 * time.Hour)}
+	if _, err := db.RecordIndexRun([]string{"/photos"}, failed, errors.New("disk unplugged")); err != nil {
+		t.Fatalf("RecordIndexRun failed: %v", err)
+	}
+
+	runs, err := db.ListIndexRuns(0)
+	if err != nil {
+		t.Fatalf("ListIndexRuns failed: %v", err)
+	}
+	if len(runs) != 2 {
+		t.Fatalf("got %d runs; want 2", len(runs))
+	}
+	if runs[0].Succeeded() || runs[0].Error != "disk unplugged" || runs[0].Stats.EndTime.IsZero() {
+		t.Errorf("newest run = %+v; want the failed one, with its error and an end time", runs[0])
+	}
+	last := runs[1]
+	if !last.Succeeded() || len(last.Roots) != 2 || last.Roots[1] != "/more" {
+		t.Errorf("oldest run = %+v; want the completed run over both roots", last)
+	}
+	if last.Stats.FilesProcessed != 20 || last.Stats.FilesFailed != 1 || last.Stats.Duration() != 10*time.Second || last.Stats.PhotosPerSecond() != 2 {
+		t.Errorf("stats = %+v; want 20 processed, 1 failed, in 10s at 2 photos/s", last.Stats)
+	}
+
+	if runs, err := db.ListIndexRuns(1); err != nil || len(runs) != 1 {
+		t.Errorf("ListIndexRuns(1) = %d runs, %v; want 1", len(runs), err)
+	}
+}
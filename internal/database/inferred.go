@@ -0,0 +1,152 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/adewale/olsen/pkg/models"
+)
+
+// InferenceInputs loads up to limit photos with an id above afterID, in id
+// order, with only the columns InferMetadata reads, so inferred fields can be
+// recomputed without touching the files. Passing the last id returned as the
+// next afterID walks the whole catalog.
+func (db *DB) InferenceInputs(afterID, limit int) ([]*models.PhotoMetadata, error) {
+	rows, err := db.Query(`
+		SELECT id, date_taken, time_offset, latitude, longitude,
+		       focal_length_35mm, iso, flash_fired, aperture, shutter_speed,
+		       software, camera_make, camera_model
+		FROM photos
+		WHERE id > ?
+		ORDER BY id
+		LIMIT ?`, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load inference inputs: %w", err)
+	}
+	defer rows.Close()
+
+	var photos []*models.PhotoMetadata
+	for rows.Next() {
+		var (
+			p                        models.PhotoMetadata
+			dateTaken                sql.NullTime
+			timeOffset, shutterSpeed sql.NullString
+			software, cameraMake     sql.NullString
+			cameraModel              sql.NullString
+			latitude, longitude      sql.NullFloat64
+			aperture                 sql.NullFloat64
+			focal35, iso             sql.NullInt64
+			flashFired               sql.NullBool
+		)
+		if err := rows.Scan(&p.ID, &dateTaken, &timeOffset, &latitude, &longitude,
+			&focal35, &iso, &flashFired, &aperture, &shutterSpeed,
+			&software, &cameraMake, &cameraModel); err != nil {
+			return nil, fmt.Errorf("failed to scan inference inputs: %w", err)
+		}
+		p.DateTaken = dateTaken.Time
+		p.TimeOffset = timeOffset.String
+		p.Latitude = latitude.Float64
+		p.Longitude = longitude.Float64
+		p.FocalLength35mm = int(focal35.Int64)
+		p.ISO = int(iso.Int64)
+		p.FlashFired = flashFired.Bool
+		p.Aperture = aperture.Float64
+		p.ShutterSpeed = shutterSpeed.String
+		p.Software = software.String
+		p.CameraMake = cameraMake.String
+		p.CameraModel = cameraModel.String
+		photos = append(photos, &p)
+	}
+	return photos, rows.Err()
+}
+
+// UpdateInferred rewrites the inferred columns of each photo, by ID, in a
+// single transaction, so callers should pass a batch rather than the catalog. It returns how many rows actually changed.
+func (db *DB) UpdateInferred(photos []*models.PhotoMetadata) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// The IS NOT guards skip rows that already hold these values, so the
+	// count reflects photos whose classification actually moved
+	stmt, err := tx.Prepare(`
+		UPDATE photos SET
+			time_of_day = ?1, season = ?2, focal_category = ?3, shooting_condition = ?4,
+			exposure_value = ?5, shutter_seconds = ?6, sun_elevation = ?7, edited = ?8
+		WHERE id = ?9 AND (
+			time_of_day IS NOT ?1 OR season IS NOT ?2 OR focal_category IS NOT ?3 OR
+			shooting_condition IS NOT ?4 OR exposure_value IS NOT ?5 OR
+			shutter_seconds IS NOT ?6 OR sun_elevation IS NOT ?7 OR edited IS NOT ?8)`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare update: %w", err)
+	}
+	defer stmt.Close()
+
+	changed := 0
+	for _, p := range photos {
+		result, err := stmt.Exec(
+			nullString(p.TimeOfDay), nullString(p.Season), nullString(p.FocalCategory), nullString(p.ShootingCondition),
+			p.ExposureValue, p.ShutterSeconds, p.SunElevation, p.Edited, p.ID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to update photo %d: %w", p.ID, err)
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		changed += int(n)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return changed, nil
+}
+
+// PhotosWithoutBlurhash returns the IDs of photos that have thumbnails but
+// no loading placeholder yet
+func (db *DB) PhotosWithoutBlurhash() ([]int, error) {
+	rows, err := db.Query(`
+		SELECT id FROM photos p
+		WHERE blurhash IS NULL
+		  AND EXISTS (SELECT 1 FROM thumbnails t WHERE t.photo_id = p.id)
+		ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find photos without placeholders: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// SmallestThumbnail returns the smallest stored thumbnail of a photo
+func (db *DB) SmallestThumbnail(photoID int) ([]byte, error) {
+	var data []byte
+	err := db.QueryRow(`
+		SELECT `+ThumbnailData+` FROM thumbnails t
+		WHERE t.photo_id = ?
+		ORDER BY CAST(t.size AS INTEGER)
+		LIMIT 1`, photoID).Scan(&data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load thumbnail for photo %d: %w", photoID, err)
+	}
+	return data, nil
+}
+
+// SetBlurhash stores a photo's loading placeholder
+func (db *DB) SetBlurhash(photoID int, blurhash string) error {
+	if _, err := db.Exec("UPDATE photos SET blurhash = ? WHERE id = ?", blurhash, photoID); err != nil {
+		return fmt.Errorf("failed to store placeholder for photo %d: %w", photoID, err)
+	}
+	return nil
+}
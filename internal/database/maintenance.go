@@ -0,0 +1,49 @@
+package database
+
+import (
+	"fmt"
+	"os"
+)
+
+// Compact rebuilds the database file to reclaim the space left by deleted
+// photos and thumbnails, then refreshes the statistics the query planner
+// uses. VACUUM needs free disk space of up to twice the database size and
+// blocks other writers while it runs.
+//
+// With checkpoint set it also copies the write-ahead log into the database
+// and truncates it, which otherwise only happens once the last connection
+// closes; use it when an explorer still has the catalog open.
+func (db *DB) Compact(checkpoint bool) error {
+	for _, stmt := range []string{"VACUUM", "ANALYZE", "PRAGMA optimize"} {
+		if _, err := db.Exec(stmt); err != nil {
+			return fmt.Errorf("%s failed: %w", stmt, err)
+		}
+	}
+	if !checkpoint {
+		return nil
+	}
+
+	// busy is 1 when another connection's read kept part of the log in use
+	var busy, logPages, checkpointed int
+	if err := db.QueryRow("PRAGMA wal_checkpoint(TRUNCATE)").Scan(&busy, &logPages, &checkpointed); err != nil {
+		return fmt.Errorf("WAL checkpoint failed: %w", err)
+	}
+	if busy != 0 {
+		return fmt.Errorf("WAL checkpoint incomplete: the database is in use by another process")
+	}
+	return nil
+}
+
+// FileSize returns the size in bytes of the database at path together with
+// its write-ahead log, which holds recent changes until a checkpoint
+func FileSize(path string) (int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	size := info.Size()
+	if wal, err := os.Stat(path + "-wal"); err == nil {
+		size += wal.Size()
+	}
+	return size, nil
+}
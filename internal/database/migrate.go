@@ -0,0 +1,297 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// columnMigration adds a column that was introduced after a table was first
+// created. Schema only creates missing tables, so catalogs built by older
+// versions need these applied explicitly.
+type columnMigration struct {
+	table      string
+	column     string
+	definition string
+}
+
+// columnMigrations lists columns added to existing tables, oldest first.
+// New columns must also be added to the CREATE TABLE in Schema.
+var columnMigrations = []columnMigration{
+	{"photos", "file_format", "TEXT"},
+	{"photos", "exposure_value", "REAL"},
+	{"photos", "camera_serial", "TEXT"},
+	{"photos", "camera_serial_token", "TEXT"},
+	{"photos", "thumbnails_upscaled", "BOOLEAN DEFAULT 0"},
+	{"photos", "thumbnails_skipped", "INTEGER DEFAULT 0"},
+	{"photos", "sun_elevation", "REAL"},
+	{"photos", "shutter_seconds", "REAL"},
+	{"photos", "time_offset", "TEXT"},
+	{"photos", "thumbnails_pending", "BOOLEAN DEFAULT 0"},
+	{"photos", "bracket_group_id", "TEXT"},
+	{"photos", "bracket_sequence", "INTEGER"},
+	{"photos", "bracket_count", "INTEGER"},
+	{"photos", "blurhash", "TEXT"},
+	{"photos", "burst_representative_pinned", "BOOLEAN DEFAULT 0"},
+	{"photos", "software", "TEXT"},
+	{"photos", "edited", "BOOLEAN DEFAULT 0"},
+	{"burst_groups", "animation", "BLOB"},
+	{"photos", "media_type", "TEXT DEFAULT 'photo'"},
+	{"photos", "duration", "REAL"},
+	{"photos", "dominant_hue", "INTEGER"},
+	{"photos", "dominant_rgb", "TEXT"},
+	{"photos", "colour_count", "INTEGER"},
+	{"photos", "title", "TEXT"},
+	{"photos", "caption", "TEXT"},
+	{"thumbnails", "content_hash", "TEXT"},
+	{"photos", "session_id", "INTEGER"},
+	{"photos", "rating", "INTEGER DEFAULT 0"},
+	{"photos", "rejected", "BOOLEAN DEFAULT 0"},
+}
+
+// columnBackfills fill a newly added column from existing data, keyed by
+// "table.column". They run only when the column is added: re-indexing skips
+// unchanged files, so older catalogs would otherwise never get a value.
+var columnBackfills = map[string]string{
+	"photos.file_format":     fileFormatBackfill,
+	"photos.shutter_seconds": shutterSecondsBackfill,
+	"photos.dominant_rgb":    dominantColourBackfill,
+	"photos.colour_count":    colourCountBackfill,
+}
+
+// fileFormatBackfill takes the format from the file extension the way the
+// indexer's fileFormat does: lower-cased, with jpg, tif and heif folded into
+// jpeg, tiff and heic. The rtrim keeps the path up to its last dot, so the
+// substr is the extension. Paths without one are left NULL.
+const fileFormatBackfill = `
+UPDATE photos SET file_format = lower(substr(file_path, length(rtrim(file_path, replace(file_path, '.', ''))) + 1))
+WHERE instr(file_path, '.') > 0;
+UPDATE photos SET file_format = CASE file_format
+	WHEN 'jpg' THEN 'jpeg'
+	WHEN 'tif' THEN 'tiff'
+	WHEN 'heif' THEN 'heic'
+	ELSE file_format
+END;
+UPDATE photos SET file_format = NULL WHERE file_format = '' OR instr(file_format, '/') > 0;
+`
+
+// shutterSecondsBackfill parses the "N", "1/N" and "N/D" shutter speeds the
+// indexer stores. Anything else (bulb, blank) casts to 0 and is left NULL.
+const shutterSecondsBackfill = `
+UPDATE photos SET shutter_seconds = CASE
+	WHEN instr(shutter_speed, '/') > 0 THEN
+		CAST(substr(shutter_speed, 1, instr(shutter_speed, '/') - 1) AS REAL) /
+		NULLIF(CAST(substr(shutter_speed, instr(shutter_speed, '/') + 1) AS REAL), 0)
+	ELSE CAST(shutter_speed AS REAL)
+END
+WHERE shutter_speed IS NOT NULL;
+UPDATE photos SET shutter_seconds = NULL WHERE shutter_seconds <= 0;
+`
+
+// dominantColourBackfill copies the highest-weight stored palette colour of
+// each photo, the same choice InsertPhoto makes. Ties go to the first in the
+// palette.
+const dominantColourBackfill = `
+UPDATE photos SET (dominant_hue, dominant_rgb) = (
+	SELECT c.hue, printf('#%02x%02x%02x', c.red, c.green, c.blue)
+	FROM photo_colors c
+	WHERE c.photo_id = photos.id
+	ORDER BY c.weight DESC, c.color_order
+	LIMIT 1
+);
+`
+
+// colourCountBackfill counts the colour families of each stored palette the
+// way colourCount does
+const colourCountBackfill = `
+UPDATE photos SET colour_count = (
+	SELECT COUNT(DISTINCT CASE WHEN c.saturation < 20 THEN -1 ELSE (c.hue % 360) / 30 END)
+	FROM photo_colors c
+	WHERE c.photo_id = photos.id AND c.weight >= 0.1
+)
+WHERE EXISTS (SELECT 1 FROM photo_colors c WHERE c.photo_id = photos.id);
+`
+
+// addedTables lists tables added to Schema after databases were already in
+// use. Open creates them; OpenReadOnly cannot, so it requires them instead.
+var addedTables = []string{"index_errors", "thumbnail_blobs", "index_runs"}
+
+// MigratedIndexes creates indexes on migrated columns. It runs after
+// migrate, since the columns may not exist until then.
+const MigratedIndexes = `
+CREATE INDEX IF NOT EXISTS idx_photos_file_format ON photos(file_format);
+CREATE INDEX IF NOT EXISTS idx_photos_exposure_value ON photos(exposure_value);
+CREATE INDEX IF NOT EXISTS idx_photos_camera_serial_token ON photos(camera_serial_token);
+CREATE INDEX IF NOT EXISTS idx_photos_shutter_seconds ON photos(shutter_seconds);
+CREATE INDEX IF NOT EXISTS idx_photos_bracket ON photos(bracket_group_id);
+CREATE INDEX IF NOT EXISTS idx_photos_software ON photos(software);
+CREATE INDEX IF NOT EXISTS idx_photos_media_type ON photos(media_type);
+CREATE INDEX IF NOT EXISTS idx_photos_dominant_hue ON photos(dominant_hue);
+CREATE INDEX IF NOT EXISTS idx_photos_colour_count ON photos(colour_count);
+CREATE INDEX IF NOT EXISTS idx_photos_session ON photos(session_id);
+CREATE INDEX IF NOT EXISTS idx_photos_rating ON photos(rating);
+CREATE INDEX IF NOT EXISTS idx_photos_rejected ON photos(rejected);
+`
+
+// legacyValueFixes rewrite values stored by older indexers into their current
+// form. Each is a no-op once applied, so they run on every open.
+const legacyValueFixes = `
+UPDATE photos SET color_space = CASE color_space
+	WHEN '[1]' THEN 'sRGB'
+	WHEN '[2]' THEN 'Adobe RGB'
+	WHEN '[65535]' THEN 'Uncalibrated'
+END
+WHERE color_space IN ('[1]', '[2]', '[65535]');
+`
+
+// migrate adds any columns from columnMigrations missing from the database
+func migrate(db *sql.DB) error {
+	for _, m := range columnMigrations {
+		exists, err := columnExists(db, m.table, m.column)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		stmt := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", m.table, m.column, m.definition)
+		if _, err := db.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to add %s.%s: %w", m.table, m.column, err)
+		}
+		if backfill, ok := columnBackfills[m.table+"."+m.column]; ok {
+			if _, err := db.Exec(backfill); err != nil {
+				return fmt.Errorf("failed to backfill %s.%s: %w", m.table, m.column, err)
+			}
+		}
+	}
+
+	if _, err := db.Exec(MigratedIndexes); err != nil {
+		return fmt.Errorf("failed to create migrated indexes: %w", err)
+	}
+
+	if _, err := db.Exec(thumbnailBlobTriggers); err != nil {
+		return fmt.Errorf("failed to create thumbnail blob triggers: %w", err)
+	}
+
+	if _, err := db.Exec(legacyValueFixes); err != nil {
+		return fmt.Errorf("failed to update legacy values: %w", err)
+	}
+
+	return nil
+}
+
+// missingMigrations lists the columnMigrations and addedTables not yet
+// applied to db, as "table.column" and "table name" respectively
+func missingMigrations(db *sql.DB) ([]string, error) {
+	var missing []string
+	for _, m := range columnMigrations {
+		exists, err := columnExists(db, m.table, m.column)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			missing = append(missing, m.table+"."+m.column)
+		}
+	}
+	for _, table := range addedTables {
+		exists, err := tableExists(db, table)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			missing = append(missing, "table "+table)
+		}
+	}
+	return missing, nil
+}
+
+// SchemaStatus describes how far a catalog is behind this build's schema.
+// There is no stored version number: Applied counts the column and table
+// migrations present, out of Total known to this build.
+type SchemaStatus struct {
+	Applied int
+	Total   int
+	Missing []string // Migrations not yet applied, e.g. "photos.time_offset"
+}
+
+// CheckSchema reports the migration state of the catalog at path without
+// modifying it; Open applies anything missing.
+func CheckSchema(path string) (SchemaStatus, error) {
+	dsn, err := readOnlyDSN(path, false)
+	if err != nil {
+		return SchemaStatus{}, err
+	}
+	db, err := sql.Open(DriverName, dsn)
+	if err != nil {
+		return SchemaStatus{}, fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	isCatalog, err := tableExists(db, "photos")
+	if err != nil {
+		return SchemaStatus{}, err
+	}
+	if !isCatalog {
+		return SchemaStatus{}, fmt.Errorf("%s is not an Olsen catalog (no photos table)", path)
+	}
+
+	missing, err := missingMigrations(db)
+	if err != nil {
+		return SchemaStatus{}, err
+	}
+	total := len(columnMigrations) + len(addedTables)
+	return SchemaStatus{Applied: total - len(missing), Total: total, Missing: missing}, nil
+}
+
+// SQLiteVersion returns the version of the SQLite library linked into this
+// build, which is independent of any catalog
+func SQLiteVersion() (string, error) {
+	db, err := sql.Open(DriverName, ":memory:")
+	if err != nil {
+		return "", err
+	}
+	defer db.Close()
+
+	var version string
+	if err := db.QueryRow("SELECT sqlite_version()").Scan(&version); err != nil {
+		return "", err
+	}
+	return version, nil
+}
+
+// tableExists reports whether the database has a table with the given name
+func tableExists(db *sql.DB, table string) (bool, error) {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to look up table %s: %w", table, err)
+	}
+	return count > 0, nil
+}
+
+// columnExists reports whether table has a column with the given name
+func columnExists(db *sql.DB, table, column string) (bool, error) {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, fmt.Errorf("failed to read columns of %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid        int
+			name       string
+			colType    string
+			notNull    int
+			defaultVal sql.NullString
+			pk         int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultVal, &pk); err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+
+	return false, rows.Err()
+}
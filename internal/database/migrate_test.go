@@ -0,0 +1,352 @@
+package database
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/adewale/olsen/pkg/models"
+)
+
+func TestMigrateAddsMissingColumns(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "old.db")
+
+	// Simulate a catalog created before any migrated column existed
+	old, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create old database: %v", err)
+	}
+	var kept []string
+	for _, line := range strings.Split(Schema, "\n") {
+		migrated := false
+		for _, m := range columnMigrations {
+			if strings.HasPrefix(strings.TrimSpace(line), m.column+" ") {
+				migrated = true
+			}
+		}
+		if !migrated {
+			kept = append(kept, line)
+		}
+	}
+	_, err = old.Exec(strings.Join(kept, "\n"))
+	if err != nil {
+		t.Fatalf("Failed to create old schema: %v", err)
+	}
+	old.Close()
+
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open failed on old database: %v", err)
+	}
+	defer db.Close()
+
+	for _, m := range columnMigrations {
+		exists, err := columnExists(db.DB, m.table, m.column)
+		if err != nil {
+			t.Fatalf("columnExists failed: %v", err)
+		}
+		if !exists {
+			t.Errorf("Column %s.%s was not added", m.table, m.column)
+		}
+	}
+
+	// Opening again must be a no-op
+	db.Close()
+	db2, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Reopen failed: %v", err)
+	}
+	defer db2.Close()
+
+	var format string
+	if _, err := db2.Exec(`INSERT INTO photos (file_path, file_hash, file_size, last_modified, file_format)
+		VALUES ('/a.jpg', 'h', 1, CURRENT_TIMESTAMP, 'jpeg')`); err != nil {
+		t.Fatalf("Insert into migrated table failed: %v", err)
+	}
+	if err := db2.QueryRow("SELECT file_format FROM photos WHERE file_path = '/a.jpg'").Scan(&format); err != nil {
+		t.Fatalf("Failed to read file_format: %v", err)
+	}
+	if format != "jpeg" {
+		t.Errorf("file_format = %q; want jpeg", format)
+	}
+}
+
+func TestCheckSchema(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "catalog.db")
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	db.Close()
+
+	status, err := CheckSchema(dbPath)
+	if err != nil {
+		t.Fatalf("CheckSchema failed: %v", err)
+	}
+	if status.Applied != status.Total || len(status.Missing) != 0 {
+		t.Errorf("fresh catalog: %+v; want everything applied", status)
+	}
+
+	// Roll back one migration as if the catalog came from an older build
+	raw, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("Failed to reopen database: %v", err)
+	}
+	if _, err := raw.Exec("ALTER TABLE photos DROP COLUMN time_offset"); err != nil {
+		t.Fatalf("Failed to drop column: %v", err)
+	}
+	raw.Close()
+
+	status, err = CheckSchema(dbPath)
+	if err != nil {
+		t.Fatalf("CheckSchema failed: %v", err)
+	}
+	if status.Applied != status.Total-1 || len(status.Missing) != 1 || status.Missing[0] != "photos.time_offset" {
+		t.Errorf("older catalog: %+v; want only photos.time_offset missing", status)
+	}
+
+	raw, err = sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("Failed to reopen database: %v", err)
+	}
+	defer raw.Close()
+	if exists, err := columnExists(raw, "photos", "time_offset"); err != nil || exists {
+		t.Errorf("time_offset exists = %v (%v); CheckSchema must not migrate the catalog", exists, err)
+	}
+}
+
+func TestInsertPhotoFileFormat(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	photo := &models.PhotoMetadata{
+		FilePath:   "/test/photo.dng",
+		FileHash:   "hash",
+		FileSize:   1024,
+		FileFormat: "dng",
+	}
+	if err := db.InsertPhoto(photo); err != nil {
+		t.Fatalf("InsertPhoto failed: %v", err)
+	}
+
+	var format string
+	if err := db.QueryRow("SELECT file_format FROM photos WHERE file_path = ?", photo.FilePath).Scan(&format); err != nil {
+		t.Fatalf("Failed to read file_format: %v", err)
+	}
+	if format != "dng" {
+		t.Errorf("file_format = %q; want dng", format)
+	}
+}
+
+func TestMigrateBackfillsFileFormat(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "old.db")
+
+	// A catalog from before file_format existed
+	old, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create old database: %v", err)
+	}
+	var kept []string
+	for _, line := range strings.Split(Schema, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "file_format ") {
+			kept = append(kept, line)
+		}
+	}
+	if _, err := old.Exec(strings.Join(kept, "\n")); err != nil {
+		t.Fatalf("Failed to create old schema: %v", err)
+	}
+	want := map[string]sql.NullString{
+		"/a.JPG":         {String: "jpeg", Valid: true},
+		"/b.jpeg":        {String: "jpeg", Valid: true},
+		"/c.tif":         {String: "tiff", Valid: true},
+		"/d.heif":        {String: "heic", Valid: true},
+		"/e.dng":         {String: "dng", Valid: true},
+		"/2024.06/f.png": {String: "png", Valid: true},
+		"/2024.06/noext": {},
+		"/photos/g.HEIC": {String: "heic", Valid: true},
+	}
+	for path := range want {
+		if _, err := old.Exec(`INSERT INTO photos (file_path, file_hash, file_size, last_modified)
+			VALUES (?, ?, 1, CURRENT_TIMESTAMP)`, path, path); err != nil {
+			t.Fatalf("Failed to insert photo: %v", err)
+		}
+	}
+	old.Close()
+
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open failed on old database: %v", err)
+	}
+	defer db.Close()
+
+	for path, w := range want {
+		var got sql.NullString
+		if err := db.QueryRow("SELECT file_format FROM photos WHERE file_path = ?", path).Scan(&got); err != nil {
+			t.Fatalf("Failed to read file_format: %v", err)
+		}
+		if got != w {
+			t.Errorf("%s: file_format = %+v; want %+v", path, got, w)
+		}
+	}
+}
+
+func TestMigrateBackfillsShutterSeconds(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "old.db")
+
+	// A catalog from before shutter_seconds existed
+	old, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create old database: %v", err)
+	}
+	var kept []string
+	for _, line := range strings.Split(Schema, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "shutter_seconds ") {
+			kept = append(kept, line)
+		}
+	}
+	if _, err := old.Exec(strings.Join(kept, "\n")); err != nil {
+		t.Fatalf("Failed to create old schema: %v", err)
+	}
+	speeds := map[string]string{"/a.dng": "1/250", "/b.dng": "2", "/c.dng": "bulb", "/d.dng": "10/4"}
+	for path, speed := range speeds {
+		if _, err := old.Exec(`INSERT INTO photos (file_path, file_hash, file_size, last_modified, shutter_speed)
+			VALUES (?, ?, 1, CURRENT_TIMESTAMP, ?)`, path, path, speed); err != nil {
+			t.Fatalf("Failed to insert photo: %v", err)
+		}
+	}
+	old.Close()
+
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open failed on old database: %v", err)
+	}
+	defer db.Close()
+
+	want := map[string]sql.NullFloat64{
+		"/a.dng": {Float64: 1.0 / 250, Valid: true},
+		"/b.dng": {Float64: 2, Valid: true},
+		"/c.dng": {},
+		"/d.dng": {Float64: 2.5, Valid: true},
+	}
+	for path, w := range want {
+		var got sql.NullFloat64
+		if err := db.QueryRow("SELECT shutter_seconds FROM photos WHERE file_path = ?", path).Scan(&got); err != nil {
+			t.Fatalf("Failed to read shutter_seconds: %v", err)
+		}
+		if got != w {
+			t.Errorf("%s: shutter_seconds = %+v; want %+v", path, got, w)
+		}
+	}
+}
+
+func TestMigrateBackfillsDominantColour(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "old.db")
+
+	// A catalog from before dominant_hue and dominant_rgb existed
+	old, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create old database: %v", err)
+	}
+	var kept []string
+	for _, line := range strings.Split(Schema, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "dominant_") {
+			kept = append(kept, line)
+		}
+	}
+	for _, stmt := range []string{
+		strings.Join(kept, "\n"),
+		`INSERT INTO photos (id, file_path, file_hash, file_size, last_modified) VALUES
+			(1, '/a.jpg', 'a', 1, CURRENT_TIMESTAMP), (2, '/b.jpg', 'b', 1, CURRENT_TIMESTAMP)`,
+		// The heaviest colour wins wherever it sits in the palette
+		`INSERT INTO photo_colors (photo_id, color_order, red, green, blue, weight, hue, saturation, lightness) VALUES
+			(1, 0, 200, 30, 30, 0.2, 0, 74, 45), (1, 1, 20, 40, 220, 0.5, 234, 83, 47)`,
+	} {
+		if _, err := old.Exec(stmt); err != nil {
+			t.Fatalf("Failed to set up old database: %v", err)
+		}
+	}
+	old.Close()
+
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open failed on old database: %v", err)
+	}
+	defer db.Close()
+
+	var hue sql.NullInt64
+	var rgb sql.NullString
+	if err := db.QueryRow("SELECT dominant_hue, dominant_rgb FROM photos WHERE id = 1").Scan(&hue, &rgb); err != nil {
+		t.Fatalf("Failed to read dominant colour: %v", err)
+	}
+	if hue.Int64 != 234 || rgb.String != "#1428dc" {
+		t.Errorf("dominant colour = %v %v; want 234 #1428dc", hue, rgb)
+	}
+	if err := db.QueryRow("SELECT dominant_hue, dominant_rgb FROM photos WHERE id = 2").Scan(&hue, &rgb); err != nil {
+		t.Fatalf("Failed to read dominant colour: %v", err)
+	}
+	if hue.Valid || rgb.Valid {
+		t.Errorf("photo without a palette: dominant colour = %v %v; want NULL", hue, rgb)
+	}
+}
+
+func TestColourCount(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "old.db")
+
+	// Two blues in the same hue band, a grey, and a red too small to count
+	palette := []models.DominantColour{
+		{HSL: models.ColourHSL{H: 212, S: 70, L: 60}, Weight: 0.4},
+		{HSL: models.ColourHSL{H: 228, S: 55, L: 30}, Weight: 0.3},
+		{HSL: models.ColourHSL{H: 40, S: 5, L: 90}, Weight: 0.25},
+		{HSL: models.ColourHSL{H: 0, S: 80, L: 50}, Weight: 0.05},
+	}
+
+	// A catalog from before colour_count existed
+	old, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create old database: %v", err)
+	}
+	var kept []string
+	for _, line := range strings.Split(Schema, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "colour_count") {
+			kept = append(kept, line)
+		}
+	}
+	if _, err := old.Exec(strings.Join(kept, "\n")); err != nil {
+		t.Fatalf("Failed to set up old database: %v", err)
+	}
+	if _, err := old.Exec(`INSERT INTO photos (id, file_path, file_hash, file_size, last_modified) VALUES
+		(1, '/a.jpg', 'a', 1, CURRENT_TIMESTAMP), (2, '/b.jpg', 'b', 1, CURRENT_TIMESTAMP)`); err != nil {
+		t.Fatalf("Failed to set up old database: %v", err)
+	}
+	for i, c := range palette {
+		if _, err := old.Exec(`INSERT INTO photo_colors (photo_id, color_order, red, green, blue, weight, hue, saturation, lightness)
+			VALUES (1, ?, 0, 0, 0, ?, ?, ?, ?)`, i, c.Weight, c.HSL.H, c.HSL.S, c.HSL.L); err != nil {
+			t.Fatalf("Failed to set up old database: %v", err)
+		}
+	}
+	old.Close()
+
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open failed on old database: %v", err)
+	}
+	defer db.Close()
+
+	// The backfill and InsertPhoto must agree
+	if err := db.InsertPhoto(&models.PhotoMetadata{FilePath: "/c.jpg", FileHash: "c", FileSize: 1, DominantColours: palette}); err != nil {
+		t.Fatalf("InsertPhoto failed: %v", err)
+	}
+	for id, want := range map[int]sql.NullInt64{1: {Int64: 2, Valid: true}, 2: {}, 3: {Int64: 2, Valid: true}} {
+		var count sql.NullInt64
+		if err := db.QueryRow("SELECT colour_count FROM photos WHERE id = ?", id).Scan(&count); err != nil {
+			t.Fatalf("Failed to read colour_count: %v", err)
+		}
+		if count != want {
+			t.Errorf("photo %d: colour_count = %v; want %v", id, count, want)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package database
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// underDir matches file_path at or below the directory ?1, whose contents
+// start with ?2, the directory followed by a separator. substr rather than
+// LIKE, so % and _ in directory names are not wildcards.
+const underDir = `(file_path = ?1 OR substr(file_path, 1, length(?2)) = ?2)`
+
+// dirPrefix returns dir followed by exactly one path separator
+func dirPrefix(dir string) string {
+	return strings.TrimSuffix(dir, string(filepath.Separator)) + string(filepath.Separator)
+}
+
+// PhotoPathsUnder returns the file paths of photos at or below dir, sorted.
+// A photo in /photos2 is not under /photos.
+func (db *DB) PhotoPathsUnder(dir string) ([]string, error) {
+	rows, err := db.Query("SELECT file_path FROM photos WHERE "+underDir+" ORDER BY file_path", dir, dirPrefix(dir))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list photos under %s: %w", dir, err)
+	}
+	defer rows.Close()
+
+	var paths []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, rows.Err()
+}
+
+// RelocatePaths rewrites the file paths of photos at or below the directory
+// from to start with to instead, in one transaction, and returns how many
+// photos moved. Index errors recorded under from move with them. It fails,
+// changing nothing, if a rewritten path is already indexed.
+func (db *DB) RelocatePaths(from, to string) (int64, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// ?3 || the rest of the path after from
+	rewrite := "?3 || substr(file_path, length(?1) + 1)"
+	result, err := tx.Exec("UPDATE photos SET file_path = "+rewrite+" WHERE "+underDir, from, dirPrefix(from), to)
+	if err != nil {
+		return 0, fmt.Errorf("failed to relocate photos: %w", err)
+	}
+	moved, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	// A newer failure already recorded at the new path wins
+	if _, err := tx.Exec("UPDATE OR IGNORE index_errors SET file_path = "+rewrite+" WHERE "+underDir, from, dirPrefix(from), to); err != nil {
+		return 0, fmt.Errorf("failed to relocate index errors: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM index_errors WHERE "+underDir, from, dirPrefix(from)); err != nil {
+		return 0, fmt.Errorf("failed to relocate index errors: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return moved, nil
+}
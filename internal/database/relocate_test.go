@@ -0,0 +1,60 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/adewale/olsen/pkg/models"
+)
+
+func TestRelocatePaths(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "relocate.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	for _, path := range []string{"/Volumes/Photos/2024/a.jpg", "/Volumes/Photos/b_%.jpg", "/Volumes/Photos2/c.jpg"} {
+		if err := db.InsertPhoto(&models.PhotoMetadata{FilePath: path, FileHash: path}); err != nil {
+			t.Fatalf("InsertPhoto failed: %v", err)
+		}
+	}
+	if err := db.RecordIndexError("/Volumes/Photos/bad.dng", "failed to decode image"); err != nil {
+		t.Fatal(err)
+	}
+
+	paths, err := db.PhotoPathsUnder("/Volumes/Photos")
+	if err != nil {
+		t.Fatalf("PhotoPathsUnder failed: %v", err)
+	}
+	if len(paths) != 2 {
+		t.Errorf("photos under /Volumes/Photos = %v; want the two in it, not /Volumes/Photos2", paths)
+	}
+
+	moved, err := db.RelocatePaths("/Volumes/Photos", "/mnt/photos")
+	if err != nil {
+		t.Fatalf("RelocatePaths failed: %v", err)
+	}
+	if moved != 2 {
+		t.Errorf("moved %d photos; want 2", moved)
+	}
+	for _, path := range []string{"/mnt/photos/2024/a.jpg", "/mnt/photos/b_%.jpg", "/Volumes/Photos2/c.jpg"} {
+		if _, err := db.GetPhotoHash(path); err != nil {
+			t.Errorf("no photo at %s after relocating: %v", path, err)
+		}
+	}
+	if errs, _ := db.ListIndexErrors(""); len(errs) != 1 || errs[0].FilePath != "/mnt/photos/bad.dng" {
+		t.Errorf("index errors = %+v; want bad.dng moved too", errs)
+	}
+
+	// A clash with a photo already at the new path changes nothing
+	if err := db.InsertPhoto(&models.PhotoMetadata{FilePath: "/new/2024/a.jpg", FileHash: "x"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.RelocatePaths("/mnt/photos", "/new"); err == nil {
+		t.Error("RelocatePaths onto an indexed path succeeded; want an error")
+	}
+	if paths, _ := db.PhotoPathsUnder("/mnt/photos"); len(paths) != 2 {
+		t.Errorf("photos under /mnt/photos after a failed relocate = %v; want both still there", paths)
+	}
+}
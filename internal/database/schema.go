@@ -11,17 +11,28 @@ CREATE TABLE IF NOT EXISTS photos (
     file_size INTEGER NOT NULL,
     indexed_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     last_modified DATETIME NOT NULL,
+    file_format TEXT,  -- dng, jpeg, png, tiff, heic (from the file extension)
+    media_type TEXT DEFAULT 'photo',  -- photo, or video for MP4/MOV (index -include-video)
+    thumbnails_upscaled BOOLEAN DEFAULT 0,  -- some size was enlarged (index -allow-upscale)
+    thumbnails_skipped INTEGER DEFAULT 0,   -- sizes not generated because the image was too small
+    thumbnails_pending BOOLEAN DEFAULT 0,   -- metadata-only (index -no-thumbnails); no thumbnails, colours or hash yet
 
     -- Camera metadata
     camera_make TEXT,
     camera_model TEXT,
     lens_make TEXT,
     lens_model TEXT,
+    camera_serial TEXT,        -- body serial number; sensitive, so never put in URLs
+    camera_serial_token TEXT,  -- SerialToken(camera_serial), the URL-safe stand-in
+    software TEXT,             -- EXIF Software, else ProcessingSoftware
+    title TEXT,                -- XMP dc:title, else EXIF XPTitle
+    caption TEXT,              -- XMP dc:description, else EXIF ImageDescription
 
     -- Exposure metadata
     iso INTEGER,
     aperture REAL,
     shutter_speed TEXT,
+    shutter_seconds REAL,  -- shutter_speed in seconds; NULL for bulb or unparseable values
     exposure_compensation REAL,
     focal_length REAL,
     focal_length_35mm INTEGER,
@@ -29,12 +40,14 @@ CREATE TABLE IF NOT EXISTS photos (
     -- Temporal metadata
     date_taken DATETIME,
     date_digitized DATETIME,
+    time_offset TEXT,     -- EXIF OffsetTimeOriginal, e.g. "+02:00"
 
     -- Image properties
     width INTEGER,
     height INTEGER,
     orientation INTEGER,
     color_space TEXT,
+    duration REAL,  -- video running time in seconds; NULL for photos
 
     -- Location metadata
     latitude REAL,
@@ -55,14 +68,40 @@ CREATE TABLE IF NOT EXISTS photos (
     season TEXT,
     focal_category TEXT,
     shooting_condition TEXT,
+    exposure_value REAL,  -- EV at ISO 100
+    sun_elevation REAL,   -- degrees above the horizon, from GPS and date_taken
+    edited BOOLEAN DEFAULT 0,  -- software names an editor rather than camera firmware
 
     -- Perceptual hash
     perceptual_hash TEXT,
 
+    -- Loading placeholder: base64 PNG of at most 4x4 averaged pixels
+    blurhash TEXT,
+
+    -- Highest-weight palette colour, for grid tinting and sort=colour
+    dominant_hue INTEGER,  -- 0-359
+    dominant_rgb TEXT,     -- #rrggbb
+    colour_count INTEGER,  -- distinct colour families in the palette, see colourCount
+
+    -- Exposure bracket (AEB) metadata, set by olsen analyze
+    bracket_group_id TEXT,
+    bracket_sequence INTEGER,
+    bracket_count INTEGER,
+
+    -- Shooting session, set by olsen analyze: the ID of the session's first photo
+    session_id INTEGER,
+
+    -- User rating: 0 (unrated) to 5 stars, set from the explorer
+    rating INTEGER DEFAULT 0,
+
+    -- Rejected while culling, set from the explorer: hidden from browsing, not deleted
+    rejected BOOLEAN DEFAULT 0,
+
     -- Burst metadata
     burst_group_id TEXT,
     burst_sequence INTEGER,
     burst_count INTEGER,
+    burst_representative_pinned BOOLEAN DEFAULT 0, -- Chosen by the user; kept when bursts are re-detected
     is_burst_representative BOOLEAN DEFAULT FALSE
 );
 
@@ -72,7 +111,8 @@ CREATE TABLE IF NOT EXISTS photos (
 CREATE TABLE IF NOT EXISTS thumbnails (
     photo_id INTEGER NOT NULL,
     size TEXT NOT NULL,  -- "64", "256", "512", "1024" (longest edge)
-    data BLOB NOT NULL,
+    data BLOB NOT NULL,  -- empty when content_hash points at a shared blob
+    content_hash TEXT,   -- thumbnail_blobs key, with index -dedup-thumbnails
     format TEXT DEFAULT 'jpeg',
     quality INTEGER DEFAULT 85,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
@@ -80,6 +120,15 @@ CREATE TABLE IF NOT EXISTS thumbnails (
     FOREIGN KEY (photo_id) REFERENCES photos(id) ON DELETE CASCADE
 );
 
+-- ============================================================
+-- THUMBNAIL BLOBS TABLE (Thumbnails shared by identical images)
+-- ============================================================
+CREATE TABLE IF NOT EXISTS thumbnail_blobs (
+    content_hash TEXT PRIMARY KEY,  -- SHA-256 of data
+    data BLOB NOT NULL,
+    refs INTEGER NOT NULL DEFAULT 0  -- thumbnails rows pointing here; kept by triggers
+);
+
 -- ============================================================
 -- PHOTO COLORS TABLE (Dominant color palette)
 -- ============================================================
@@ -98,6 +147,20 @@ CREATE TABLE IF NOT EXISTS photo_colors (
     UNIQUE(photo_id, color_order)
 );
 
+-- ============================================================
+-- RAW EXIF TABLE
+-- Complete tag dump for inspection; curated columns on photos
+-- remain the source for filtering
+-- ============================================================
+CREATE TABLE IF NOT EXISTS photo_exif (
+    photo_id INTEGER NOT NULL,
+    ifd_path TEXT NOT NULL,
+    tag_name TEXT NOT NULL,
+    value TEXT,
+    FOREIGN KEY (photo_id) REFERENCES photos(id) ON DELETE CASCADE,
+    PRIMARY KEY (photo_id, ifd_path, tag_name)
+);
+
 -- ============================================================
 -- BURST GROUPS TABLE
 -- ============================================================
@@ -109,6 +172,7 @@ CREATE TABLE IF NOT EXISTS burst_groups (
     camera_model TEXT,
     representative_photo_id INTEGER,
     time_span_seconds REAL,
+    animation BLOB,  -- animated GIF of the frames, from olsen analyze -animate
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     FOREIGN KEY (representative_photo_id) REFERENCES photos(id)
 );
@@ -149,6 +213,34 @@ CREATE TABLE IF NOT EXISTS collection_photos (
     PRIMARY KEY (collection_id, photo_id)
 );
 
+-- ============================================================
+-- INDEX ERRORS (Files the last index run could not process)
+-- ============================================================
+CREATE TABLE IF NOT EXISTS index_errors (
+    file_path TEXT PRIMARY KEY,
+    error TEXT NOT NULL,
+    occurred_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- ============================================================
+-- INDEX RUNS (History of olsen index runs)
+-- ============================================================
+CREATE TABLE IF NOT EXISTS index_runs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    roots TEXT NOT NULL,  -- Directories indexed, one per line
+    started_at DATETIME NOT NULL,
+    finished_at DATETIME NOT NULL,
+    files_found INTEGER DEFAULT 0,
+    files_processed INTEGER DEFAULT 0,
+    files_skipped INTEGER DEFAULT 0,
+    files_updated INTEGER DEFAULT 0,
+    files_failed INTEGER DEFAULT 0,
+    files_out_of_range INTEGER DEFAULT 0,
+    thumbnails_generated INTEGER DEFAULT 0,
+    hashes_computed INTEGER DEFAULT 0,
+    error TEXT  -- Why the run stopped; NULL when it completed
+);
+
 -- ============================================================
 -- FACET METADATA (For display configuration)
 -- ============================================================
@@ -180,6 +272,8 @@ CREATE INDEX IF NOT EXISTS idx_photos_time_of_day ON photos(time_of_day);
 CREATE INDEX IF NOT EXISTS idx_photos_season ON photos(season);
 CREATE INDEX IF NOT EXISTS idx_photos_focal_category ON photos(focal_category);
 CREATE INDEX IF NOT EXISTS idx_photos_shooting_condition ON photos(shooting_condition);
+CREATE INDEX IF NOT EXISTS idx_photos_file_size ON photos(file_size);
+CREATE INDEX IF NOT EXISTS idx_photos_color_space ON photos(color_space);
 
 -- Burst queries
 CREATE INDEX IF NOT EXISTS idx_photos_burst ON photos(burst_group_id);
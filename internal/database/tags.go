@@ -0,0 +1,61 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TagPhotos gives every photo selected by idQuery (SQL returning photo ids,
+// with its args) the tag name, creating the tag if needed, in one
+// transaction. It returns how many photos gained the tag; photos that
+// already had it are left alone.
+func (db *DB) TagPhotos(name, idQuery string, args []interface{}) (int64, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return 0, fmt.Errorf("tag name is required")
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("INSERT OR IGNORE INTO tags (name) VALUES (?)", name); err != nil {
+		return 0, fmt.Errorf("failed to create tag %q: %w", name, err)
+	}
+	result, err := tx.Exec(`
+		INSERT OR IGNORE INTO photo_tags (photo_id, tag_id)
+		SELECT m.id, (SELECT id FROM tags WHERE name = ?)
+		FROM (`+idQuery+`) m`, append([]interface{}{name}, args...)...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to tag photos: %w", err)
+	}
+	changed, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return changed, nil
+}
+
+// UntagPhotos removes the tag name from every photo selected by idQuery and
+// returns how many photos had it. The tag itself is kept.
+func (db *DB) UntagPhotos(name, idQuery string, args []interface{}) (int64, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return 0, fmt.Errorf("tag name is required")
+	}
+
+	result, err := db.Exec(`
+		DELETE FROM photo_tags
+		WHERE tag_id = (SELECT id FROM tags WHERE name = ?)
+		  AND photo_id IN (`+idQuery+`)`, append([]interface{}{name}, args...)...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to untag photos: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/adewale/olsen/pkg/models"
+)
+
+// ThumbnailData is the SQL expression for the image bytes of the thumbnails
+// row aliased t. A thumbnail stored with deduplication keeps an empty data
+// column and reads its bytes from the shared blob instead.
+const ThumbnailData = `COALESCE((SELECT b.data FROM thumbnail_blobs b WHERE b.content_hash = t.content_hash), t.data)`
+
+// thumbnailBlobTriggers count the thumbnails referencing each shared blob
+// and delete a blob when its last thumbnail goes. Deleting a photo cascades
+// to its thumbnails, which fires these too. They run after migrate, since
+// thumbnails.content_hash may not exist until then.
+const thumbnailBlobTriggers = `
+CREATE TRIGGER IF NOT EXISTS thumbnail_blob_ref
+AFTER INSERT ON thumbnails WHEN NEW.content_hash IS NOT NULL
+BEGIN
+	UPDATE thumbnail_blobs SET refs = refs + 1 WHERE content_hash = NEW.content_hash;
+END;
+
+CREATE TRIGGER IF NOT EXISTS thumbnail_blob_unref
+AFTER DELETE ON thumbnails WHEN OLD.content_hash IS NOT NULL
+BEGIN
+	UPDATE thumbnail_blobs SET refs = refs - 1 WHERE content_hash = OLD.content_hash;
+	DELETE FROM thumbnail_blobs WHERE content_hash = OLD.content_hash AND refs <= 0;
+END;
+`
+
+// SetThumbnailDedup controls how InsertPhoto and UpdateImageData store
+// thumbnails. Off by default, each photo keeps its own copy. On, thumbnails
+// are stored once per distinct content in thumbnail_blobs, so exact duplicate
+// files share them. Either way, thumbnails already stored are left as they
+// are, and both kinds read back the same through ThumbnailData.
+func (db *DB) SetThumbnailDedup(dedup bool) {
+	db.dedupThumbnails = dedup
+}
+
+// insertThumbnails stores a photo's thumbnails, sharing identical ones
+// through thumbnail_blobs when dedup is set
+func insertThumbnails(tx *sql.Tx, photoID int64, thumbnails map[models.ThumbnailSize][]byte, dedup bool) error {
+	for size, data := range thumbnails {
+		if !dedup {
+			_, err := tx.Exec(`
+				INSERT INTO thumbnails (photo_id, size, data, format, quality)
+				VALUES (?, ?, ?, 'jpeg', 85)
+			`, photoID, string(size), data)
+			if err != nil {
+				return fmt.Errorf("failed to insert thumbnail %s: %w", size, err)
+			}
+			continue
+		}
+
+		sum := sha256.Sum256(data)
+		hash := hex.EncodeToString(sum[:])
+		_, err := tx.Exec(`
+			INSERT INTO thumbnail_blobs (content_hash, data) VALUES (?, ?)
+			ON CONFLICT(content_hash) DO NOTHING
+		`, hash, data)
+		if err != nil {
+			return fmt.Errorf("failed to store thumbnail %s: %w", size, err)
+		}
+		// The insert trigger counts the reference
+		_, err = tx.Exec(`
+			INSERT INTO thumbnails (photo_id, size, data, content_hash, format, quality)
+			VALUES (?, ?, X'', ?, 'jpeg', 85)
+		`, photoID, string(size), hash)
+		if err != nil {
+			return fmt.Errorf("failed to insert thumbnail %s: %w", size, err)
+		}
+	}
+	return nil
+}
+
+// GetSharedThumbnailCounts returns how many shared thumbnail blobs are stored
+// and how many thumbnails reference them
+func (db *DB) GetSharedThumbnailCounts() (blobs, refs int, err error) {
+	err = db.QueryRow("SELECT COUNT(*), COALESCE(SUM(refs), 0) FROM thumbnail_blobs").Scan(&blobs, &refs)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count shared thumbnails: %w", err)
+	}
+	return blobs, refs, nil
+}
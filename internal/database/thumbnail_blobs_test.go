@@ -0,0 +1,86 @@
+package database
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/adewale/olsen/pkg/models"
+)
+
+func TestThumbnailDedup(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "dedup.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	shared := []byte("same jpeg bytes")
+	thumbnails := func() map[models.ThumbnailSize][]byte {
+		return map[models.ThumbnailSize][]byte{models.ThumbnailTiny: shared, models.ThumbnailSmall: []byte("larger jpeg")}
+	}
+
+	// Stored before dedup was turned on, so it keeps its own copy
+	if err := db.InsertPhoto(&models.PhotoMetadata{FilePath: "/own.jpg", FileHash: "a", Thumbnails: thumbnails()}); err != nil {
+		t.Fatalf("InsertPhoto failed: %v", err)
+	}
+	db.SetThumbnailDedup(true)
+	for _, path := range []string{"/a.jpg", "/copy of a.jpg"} {
+		if err := db.InsertPhoto(&models.PhotoMetadata{FilePath: path, FileHash: "a", Thumbnails: thumbnails()}); err != nil {
+			t.Fatalf("InsertPhoto(%s) failed: %v", path, err)
+		}
+	}
+
+	blobs, refs, err := db.GetSharedThumbnailCounts()
+	if err != nil || blobs != 2 || refs != 4 {
+		t.Errorf("shared = %d blobs for %d refs, %v; want 2 for 4", blobs, refs, err)
+	}
+
+	readTiny := func(path string) []byte {
+		t.Helper()
+		var data []byte
+		err := db.QueryRow(`
+			SELECT `+ThumbnailData+` FROM thumbnails t JOIN photos p ON p.id = t.photo_id
+			WHERE p.file_path = ? AND t.size = ?
+		`, path, string(models.ThumbnailTiny)).Scan(&data)
+		if err != nil {
+			t.Fatalf("reading thumbnail of %s failed: %v", path, err)
+		}
+		return data
+	}
+	for _, path := range []string{"/own.jpg", "/a.jpg", "/copy of a.jpg"} {
+		if data := readTiny(path); !bytes.Equal(data, shared) {
+			t.Errorf("thumbnail of %s = %q; want %q", path, data, shared)
+		}
+	}
+
+	// Deleting one duplicate keeps the blobs the other still uses
+	if err := db.DeletePhoto("/a.jpg"); err != nil {
+		t.Fatalf("DeletePhoto failed: %v", err)
+	}
+	if blobs, refs, _ := db.GetSharedThumbnailCounts(); blobs != 2 || refs != 2 {
+		t.Errorf("after one delete, shared = %d blobs for %d refs; want 2 for 2", blobs, refs)
+	}
+	if data := readTiny("/copy of a.jpg"); !bytes.Equal(data, shared) {
+		t.Errorf("remaining duplicate's thumbnail = %q; want %q", data, shared)
+	}
+
+	// Regenerating thumbnails releases the old blobs
+	err = db.UpdateImageData(&models.PhotoMetadata{FilePath: "/copy of a.jpg", Thumbnails: map[models.ThumbnailSize][]byte{models.ThumbnailTiny: []byte("new")}})
+	if err != nil {
+		t.Fatalf("UpdateImageData failed: %v", err)
+	}
+	if blobs, refs, _ := db.GetSharedThumbnailCounts(); blobs != 1 || refs != 1 {
+		t.Errorf("after update, shared = %d blobs for %d refs; want 1 for 1", blobs, refs)
+	}
+
+	if err := db.DeletePhoto("/copy of a.jpg"); err != nil {
+		t.Fatalf("DeletePhoto failed: %v", err)
+	}
+	if blobs, refs, _ := db.GetSharedThumbnailCounts(); blobs != 0 || refs != 0 {
+		t.Errorf("after deleting both, shared = %d blobs for %d refs; want none", blobs, refs)
+	}
+	if data := readTiny("/own.jpg"); !bytes.Equal(data, shared) {
+		t.Errorf("unshared thumbnail = %q; want it untouched", data)
+	}
+}
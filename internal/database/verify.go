@@ -0,0 +1,103 @@
+package database
+
+import "fmt"
+
+// ThumbnailShape pairs a photo's stored dimensions and EXIF orientation with
+// its largest thumbnail, so the thumbnail's shape can be checked against them
+type ThumbnailShape struct {
+	PhotoID     int
+	FilePath    string
+	Width       int
+	Height      int
+	Orientation int
+	Thumbnail   []byte
+}
+
+// SampleThumbnailShapes returns up to limit randomly chosen photos that have
+// known dimensions and at least one thumbnail
+func (db *DB) SampleThumbnailShapes(limit int) ([]ThumbnailShape, error) {
+	rows, err := db.Query(`
+		SELECT p.id, p.file_path, p.width, p.height, COALESCE(p.orientation, 1),
+		       (SELECT `+ThumbnailData+` FROM thumbnails t
+		        WHERE t.photo_id = p.id
+		        ORDER BY CAST(t.size AS INTEGER) DESC
+		        LIMIT 1)
+		FROM photos p
+		WHERE p.width > 0 AND p.height > 0
+		  AND EXISTS (SELECT 1 FROM thumbnails t WHERE t.photo_id = p.id)
+		ORDER BY RANDOM()
+		LIMIT ?`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sample thumbnails: %w", err)
+	}
+	defer rows.Close()
+
+	var shapes []ThumbnailShape
+	for rows.Next() {
+		var s ThumbnailShape
+		if err := rows.Scan(&s.PhotoID, &s.FilePath, &s.Width, &s.Height, &s.Orientation, &s.Thumbnail); err != nil {
+			return nil, err
+		}
+		shapes = append(shapes, s)
+	}
+	return shapes, rows.Err()
+}
+
+// DecodeTarget is what a deep verify needs to re-check one photo: where its
+// original is, the size and dimensions recorded when it was indexed, and its
+// stored thumbnails
+type DecodeTarget struct {
+	PhotoID    int
+	FilePath   string
+	FileSize   int64
+	Width      int
+	Height     int
+	Thumbnails map[string][]byte // Keyed by size: "64", "256", "512", "1024"
+}
+
+// DecodeTargets calls fn for every photo in id order. Thumbnails are read one
+// photo at a time, so memory stays bounded on large libraries.
+func (db *DB) DecodeTargets(fn func(DecodeTarget) error) error {
+	rows, err := db.Query("SELECT id, file_path, file_size, COALESCE(width, 0), COALESCE(height, 0) FROM photos ORDER BY id")
+	if err != nil {
+		return fmt.Errorf("failed to list photos: %w", err)
+	}
+	var targets []DecodeTarget
+	for rows.Next() {
+		var t DecodeTarget
+		if err := rows.Scan(&t.PhotoID, &t.FilePath, &t.FileSize, &t.Width, &t.Height); err != nil {
+			rows.Close()
+			return err
+		}
+		targets = append(targets, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, t := range targets {
+		thumbs, err := db.Query("SELECT t.size, "+ThumbnailData+" FROM thumbnails t WHERE t.photo_id = ?", t.PhotoID)
+		if err != nil {
+			return fmt.Errorf("failed to read thumbnails of photo %d: %w", t.PhotoID, err)
+		}
+		t.Thumbnails = map[string][]byte{}
+		for thumbs.Next() {
+			var size string
+			var data []byte
+			if err := thumbs.Scan(&size, &data); err != nil {
+				thumbs.Close()
+				return err
+			}
+			t.Thumbnails[size] = data
+		}
+		thumbs.Close()
+		if err := thumbs.Err(); err != nil {
+			return err
+		}
+		if err := fn(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package explorer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adewale/olsen/internal/database"
+	"github.com/adewale/olsen/pkg/models"
+)
+
+func TestBasePath(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "base.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+	if err := db.InsertPhoto(&models.PhotoMetadata{
+		FilePath: "/a.jpg", FileHash: "a", CameraMake: "Canon", CameraModel: "EOS R5",
+		DateTaken: time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC),
+	}); err != nil {
+		t.Fatalf("InsertPhoto failed: %v", err)
+	}
+
+	server := NewServer(db, "")
+	server.SetBasePath("/olsen/")
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	// Every root-relative link on a page must carry the prefix
+	link := regexp.MustCompile(`(?:href|src|action)="(/[^"]*)"`)
+	for _, path := range []string{"/olsen/", "/olsen/photos?camera_make=Canon", "/olsen/photo/1", "/olsen/collections"} {
+		rec := get(path)
+		if rec.Code != http.StatusOK {
+			t.Errorf("GET %s status = %d; want 200", path, rec.Code)
+			continue
+		}
+		links := link.FindAllStringSubmatch(rec.Body.String(), -1)
+		if len(links) == 0 {
+			t.Errorf("GET %s has no links", path)
+		}
+		for _, m := range links {
+			if !strings.HasPrefix(m[1], "/olsen/") {
+				t.Errorf("GET %s links to %s; want it under /olsen/", path, m[1])
+			}
+		}
+	}
+
+	body := get("/olsen/photos").Body.String()
+	for _, want := range []string{`href="/olsen/photo/1"`, `src="/olsen/api/thumbnail/1/`, `href="/olsen/photos?camera_make=Canon`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("grid has no %s", want)
+		}
+	}
+	if body := get("/olsen/api/photos").Body.String(); !strings.Contains(body, `"thumbnail":"/olsen/api/thumbnail/1/256`) {
+		t.Errorf("photos API = %s; want thumbnails under /olsen", body)
+	}
+
+	if rec := get("/olsen"); rec.Code != http.StatusMovedPermanently || rec.Header().Get("Location") != "/olsen/" {
+		t.Errorf("GET /olsen = %d to %q; want a redirect to /olsen/", rec.Code, rec.Header().Get("Location"))
+	}
+	if rec := get("/photos"); rec.Code != http.StatusNotFound {
+		t.Errorf("GET /photos outside the base path status = %d; want 404", rec.Code)
+	}
+	if rec := get("/olsen/photo/1/representative"); rec.Header().Get("Location") != "/olsen/photo/1" {
+		t.Errorf("representative redirect = %q; want /olsen/photo/1", rec.Header().Get("Location"))
+	}
+}
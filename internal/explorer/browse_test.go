@@ -0,0 +1,68 @@
+package explorer
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adewale/olsen/internal/database"
+	"github.com/adewale/olsen/pkg/models"
+)
+
+func TestBrowseLinksFindTheirPhotos(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "browse.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	equipment := []struct{ make, model, lens string }{
+		{"FUJIFILM", "X-T5", "XF16-55mmF2.8 R LM WR"},
+		{"Leica Camera AG", "M10 / M10-R", "Summicron-M 1:2/35 ASPH."},
+	}
+	for i, e := range equipment {
+		photo := &models.PhotoMetadata{
+			FilePath: fmt.Sprintf("/%d.jpg", i), FileHash: fmt.Sprint(i),
+			CameraMake: e.make, CameraModel: e.model, LensModel: e.lens,
+			DateTaken: time.Date(2024, 6, 1, 12, i, 0, 0, time.UTC),
+		}
+		if err := db.InsertPhoto(photo); err != nil {
+			t.Fatalf("InsertPhoto failed: %v", err)
+		}
+	}
+	server := NewServer(db, "")
+
+	get := func(path string) string {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s = %d", path, rec.Code)
+		}
+		return rec.Body.String()
+	}
+
+	links := regexp.MustCompile(`href="(/(?:camera|lens)/[^"]*)"`)
+	for _, page := range []string{"/cameras", "/lenses"} {
+		found := links.FindAllStringSubmatch(get(page), -1)
+		if len(found) != len(equipment) {
+			t.Fatalf("%s links %d cameras or lenses; want %d", page, len(found), len(equipment))
+		}
+		for _, link := range found {
+			path := html.UnescapeString(link[1])
+			if strings.Contains(path, "//") || strings.Contains(path, " ") {
+				t.Errorf("%s links to %q", page, path)
+				continue
+			}
+			if body := get(path); !strings.Contains(body, ">1 photos<") {
+				t.Errorf("%s from %s does not find its photo", path, page)
+			}
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package explorer
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/adewale/olsen/internal/database"
+)
+
+// handleRepresentative redirects /photo/:id/representative to the frame that
+// stands for photo id's burst when bursts are collapsed, or to the photo
+// itself when it is not hidden by collapsing. Links made this way keep
+// resolving to a visible photo as burst representatives change.
+func (s *Server) handleRepresentative(w http.ResponseWriter, r *http.Request, id int) {
+	_, representativeID, err := s.db.BurstRepresentativeOf(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		s.renderNotFound(w, fmt.Sprintf("Photo %d is not in the library.", id), "")
+		return
+	}
+	if err != nil {
+		log.Printf("Representative lookup for photo %d failed: %v", id, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, s.path(fmt.Sprintf("/photo/%d", representativeID)), http.StatusFound)
+}
+
+// handleBurstAnimation serves GET /api/burst/:group/animated, the animated
+// GIF olsen analyze -animate stored for a burst
+func (s *Server) handleBurstAnimation(w http.ResponseWriter, r *http.Request) {
+	groupID, action, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/burst/"), "/")
+	if !ok || groupID == "" || action != "animated" {
+		http.NotFound(w, r)
+		return
+	}
+
+	data, err := s.db.BurstAnimation(groupID)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "No animation for this burst (run olsen analyze -animate)", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Loading animation of burst %s failed: %v", groupID, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/gif")
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	w.Write(data)
+}
+
+// handleBurstRepresentative serves POST /api/group/:id/representative, which
+// makes the photo_id form value the frame shown for the burst when bursts
+// are collapsed (collapse_bursts=true). Requires --allow-edits.
+func (s *Server) handleBurstRepresentative(w http.ResponseWriter, r *http.Request) {
+	groupID, action, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/group/"), "/")
+	if !ok || groupID == "" || action != "representative" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.checkEdit(w, r) {
+		return
+	}
+
+	photoID, err := strconv.Atoi(r.FormValue("photo_id"))
+	if err != nil {
+		http.Error(w, "Invalid photo ID", http.StatusBadRequest)
+		return
+	}
+	err = s.db.SetBurstRepresentative(groupID, photoID)
+	if errors.Is(err, database.ErrNotInBurst) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Setting representative of burst %s failed: %v", groupID, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"group_id":       groupID,
+		"representative": photoID,
+	}); err != nil {
+		log.Printf("Failed to encode burst %s: %v", groupID, err)
+	}
+}
@@ -0,0 +1,144 @@
+package explorer
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adewale/olsen/internal/database"
+	"github.com/adewale/olsen/pkg/models"
+)
+
+func TestBurstRepresentativeAPI(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "bursts.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	base := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		photo := &models.PhotoMetadata{FilePath: fmt.Sprintf("/%d.jpg", i), FileHash: fmt.Sprint(i), DateTaken: base.Add(time.Duration(i) * time.Second)}
+		if err := db.InsertPhoto(photo); err != nil {
+			t.Fatalf("InsertPhoto failed: %v", err)
+		}
+	}
+	if _, err := db.Exec("UPDATE photos SET burst_group_id = 'b1', is_burst_representative = (id = 2) WHERE id IN (1, 2)"); err != nil {
+		t.Fatalf("Failed to group photos: %v", err)
+	}
+
+	server := NewServer(db, "")
+	post := func(path, form string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		server.router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := post("/api/group/b1/representative", "photo_id=1"); rec.Code != http.StatusForbidden {
+		t.Errorf("POST without edits status = %d; want 403", rec.Code)
+	}
+
+	server.SetAllowEdits(true)
+	if rec := post("/api/group/b1/representative", "photo_id=3"); rec.Code != http.StatusNotFound {
+		t.Errorf("POST with a photo outside the burst status = %d; want 404", rec.Code)
+	}
+	if rec := post("/api/group/b1/representative", "photo_id=1"); rec.Code != http.StatusOK {
+		t.Fatalf("POST status = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec := httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/photos?collapse_bursts=true", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, `"total":2`) || !strings.Contains(body, `"id":1,`) || strings.Contains(body, `"id":2,`) {
+		t.Errorf("collapsed listing does not show photo 1 for the burst: %s", body)
+	}
+}
+
+func TestRepresentativeLinks(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "representative.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	for i := 0; i < 3; i++ {
+		photo := &models.PhotoMetadata{FilePath: fmt.Sprintf("/%d.jpg", i), FileHash: fmt.Sprint(i), CameraMake: "Canon"}
+		if err := db.InsertPhoto(photo); err != nil {
+			t.Fatalf("InsertPhoto failed: %v", err)
+		}
+	}
+	if _, err := db.Exec("UPDATE photos SET burst_group_id = 'b1', is_burst_representative = (id = 2) WHERE id IN (1, 2)"); err != nil {
+		t.Fatalf("Failed to group photos: %v", err)
+	}
+
+	server := NewServer(db, "")
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	for path, want := range map[string]string{
+		"/photo/1/representative": "/photo/2", // Hidden frame
+		"/photo/2/representative": "/photo/2", // The representative itself
+		"/photo/3/representative": "/photo/3", // Not in a burst
+	} {
+		rec := get(path)
+		if rec.Code != http.StatusFound || rec.Header().Get("Location") != want {
+			t.Errorf("GET %s = %d to %q; want a redirect to %s", path, rec.Code, rec.Header().Get("Location"), want)
+		}
+	}
+	if rec := get("/photo/99/representative"); rec.Code != http.StatusNotFound {
+		t.Errorf("GET /photo/99/representative status = %d; want 404", rec.Code)
+	}
+
+	// The hidden frame can still be viewed, with a link to the representative
+	rec := get("/photo/1")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /photo/1 status = %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), `href="/photo/2"`) {
+		t.Error("Detail page of a hidden frame should link to its representative")
+	}
+	if strings.Contains(get("/photo/2").Body.String(), "hidden when bursts are collapsed") {
+		t.Error("Detail page of the representative should not say it is hidden")
+	}
+}
+
+func TestBurstAnimationEndpoint(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "animation.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("INSERT INTO burst_groups (id, photo_count) VALUES ('b1', 3), ('b2', 2)"); err != nil {
+		t.Fatalf("Failed to create burst groups: %v", err)
+	}
+	if err := db.SetBurstAnimation("b1", []byte("GIF89a")); err != nil {
+		t.Fatalf("SetBurstAnimation failed: %v", err)
+	}
+
+	server := NewServer(db, "")
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	rec := get("/api/burst/b1/animated")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/gif" || rec.Body.String() != "GIF89a" {
+		t.Errorf("GET b1 = %d %q %q; want the stored GIF", rec.Code, rec.Header().Get("Content-Type"), rec.Body.String())
+	}
+	for _, path := range []string{"/api/burst/b2/animated", "/api/burst/missing/animated", "/api/burst/b1"} {
+		if rec := get(path); rec.Code != http.StatusNotFound {
+			t.Errorf("GET %s status = %d; want 404", path, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package explorer
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/adewale/olsen/internal/database"
+)
+
+// SetAllowEdits enables the routes that change collections. The explorer is
+// otherwise strictly read-only, and these routes have no authentication, so
+// they should only be enabled on a trusted address.
+func (s *Server) SetAllowEdits(allow bool) {
+	s.allowEdits = allow
+}
+
+// handleCollections lists collections, and with edits allowed creates one
+// from a POSTed name and description
+func (s *Server) handleCollections(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		if !s.checkEdit(w, r) {
+			return
+		}
+		id, err := s.db.CreateCollection(r.FormValue("name"), r.FormValue("description"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Redirect(w, r, s.path(fmt.Sprintf("/collection/%d", id)), http.StatusSeeOther)
+		return
+	}
+
+	collections, err := s.db.ListCollections()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := map[string]interface{}{
+		"Title":       "Collections",
+		"Collections": collections,
+		"AllowEdits":  s.allowEdits,
+	}
+
+	s.renderTemplate(w, "collections", data)
+}
+
+// handleCollection serves /collection/:id as the photo grid filtered to the
+// collection's members. With edits allowed, POST /collection/:id/add and
+// /collection/:id/remove change membership for the photo_id form values.
+func (s *Server) handleCollection(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/collection/"), "/")
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		http.Error(w, "Invalid collection ID", http.StatusBadRequest)
+		return
+	}
+
+	if len(parts) == 2 && r.Method == http.MethodPost {
+		s.handleCollectionEdit(w, r, id, parts[1])
+		return
+	}
+	if len(parts) != 1 {
+		http.NotFound(w, r)
+		return
+	}
+
+	if _, err := s.db.GetCollection(id); err != nil {
+		if errors.Is(err, database.ErrCollectionNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Render through the regular grid so facets, paging and sorting work
+	// within the collection; their links lead on to /photos?collection=id
+	grid := r.Clone(r.Context())
+	values := r.URL.Query()
+	values.Set("collection", strconv.Itoa(id))
+	grid.URL = &url.URL{Path: "/photos", RawQuery: values.Encode()}
+	s.handleQuery(w, grid)
+}
+
+// handleCollectionEdit adds or removes the POSTed photo IDs, then returns to
+// the page the form was on
+func (s *Server) handleCollectionEdit(w http.ResponseWriter, r *http.Request, id int, action string) {
+	if !s.checkEdit(w, r) {
+		return
+	}
+
+	var photoIDs []int
+	for _, v := range r.Form["photo_id"] {
+		photoID, err := strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, "Invalid photo ID", http.StatusBadRequest)
+			return
+		}
+		photoIDs = append(photoIDs, photoID)
+	}
+
+	var err error
+	switch action {
+	case "add":
+		_, err = s.db.AddToCollection(id, photoIDs)
+	case "remove":
+		_, err = s.db.RemoveFromCollection(id, photoIDs)
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	if errors.Is(err, database.ErrCollectionNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		log.Printf("Collection %d %s failed: %v", id, action, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	back := r.Referer()
+	if back == "" {
+		back = s.path(fmt.Sprintf("/collection/%d", id))
+	}
+	http.Redirect(w, r, back, http.StatusSeeOther)
+}
+
+// checkEdit rejects the request unless edits are enabled and it comes from
+// the explorer's own pages. It also parses the form.
+func (s *Server) checkEdit(w http.ResponseWriter, r *http.Request) bool {
+	if !s.allowEdits {
+		http.Error(w, "Editing is disabled; restart the explorer with --allow-edits", http.StatusForbidden)
+		return false
+	}
+	// Browsers send Origin on cross-site form posts; refuse those
+	if origin := r.Header.Get("Origin"); origin != "" {
+		if u, err := url.Parse(origin); err != nil || u.Host != r.Host {
+			http.Error(w, "Cross-origin edit refused", http.StatusForbidden)
+			return false
+		}
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,77 @@
+package explorer
+
+import (
+	"github.com/adewale/olsen/internal/query"
+	"github.com/adewale/olsen/pkg/models"
+)
+
+// gridDensity is one layout of the photo grid, selected with ?density=
+type gridDensity struct {
+	Value     string               // density URL value; "" is the default
+	Label     string               // Link text in the density switcher
+	ThumbSize models.ThumbnailSize // Thumbnail size the grid requests
+	CellSize  int                  // Minimum column width and image height in px
+	ShowInfo  bool                 // Show camera and date under each thumbnail
+	RowThumb  models.ThumbnailSize // Thumbnail size of the justified layout, where wide photos outgrow CellSize
+
+	URL      string // This grid at this density (set per request)
+	Selected bool
+}
+
+// gridDensities holds every layout, default first. Each non-default value
+// must appear in query.GridDensities so it survives URL parsing.
+var gridDensities = []gridDensity{
+	{Value: "", Label: "Comfortable", ThumbSize: models.ThumbnailSmall, CellSize: 250, ShowInfo: true, RowThumb: models.ThumbnailMedium},
+	{Value: "compact", Label: "Compact", ThumbSize: models.ThumbnailSmall, CellSize: 140, RowThumb: models.ThumbnailMedium},
+	{Value: "dense", Label: "Dense", ThumbSize: models.ThumbnailTiny, CellSize: 64, RowThumb: models.ThumbnailSmall},
+}
+
+// densityOptions returns the layout for params and the switcher links to
+// every layout, each keeping the current filters and page
+func (s *Server) densityOptions(params query.QueryParams) (gridDensity, []gridDensity) {
+	current := gridDensities[0]
+	options := make([]gridDensity, len(gridDensities))
+	for i, d := range gridDensities {
+		p := params
+		p.Density = d.Value
+		d.URL = s.urlMapper.BuildFullURL(p)
+		d.Selected = d.Value == params.Density
+		if d.Selected {
+			current = d
+		}
+		options[i] = d
+	}
+	return current, options
+}
+
+// gridLayout is one arrangement of the photo grid, selected with ?layout=
+type gridLayout struct {
+	Value string // layout URL value; "" is the default
+	Label string // Link text in the layout switcher
+
+	URL      string // This grid in this layout (set per request)
+	Selected bool
+}
+
+// gridLayouts holds every arrangement, default first. Square cells crop
+// every photo to the same shape; justified rows keep each photo's aspect
+// ratio, with the density's CellSize as the target row height. Each
+// non-default value must appear in query.GridLayouts.
+var gridLayouts = []gridLayout{
+	{Value: "", Label: "Grid"},
+	{Value: "justified", Label: "Justified"},
+}
+
+// layoutOptions returns the switcher links to every layout, each keeping
+// the current filters, density and page
+func (s *Server) layoutOptions(params query.QueryParams) []gridLayout {
+	options := make([]gridLayout, len(gridLayouts))
+	for i, l := range gridLayouts {
+		p := params
+		p.Layout = l.Value
+		l.URL = s.urlMapper.BuildFullURL(p)
+		l.Selected = l.Value == params.Layout
+		options[i] = l
+	}
+	return options
+}
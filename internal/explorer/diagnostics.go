@@ -0,0 +1,51 @@
+package explorer
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adewale/olsen/internal/query"
+)
+
+// Diagnostic response headers, for debugging facet behaviour from the browser
+// without access to the server log
+const (
+	headerQueryTime     = "X-Olsen-Query-Time"     // Milliseconds spent on the query and facets
+	headerResultCount   = "X-Olsen-Result-Count"   // Photos matching the filters, across all pages
+	headerFacetDisabled = "X-Olsen-Facet-Disabled" // Zero-count facet values, as a query string
+)
+
+// maxDisabledFacetValues caps the facet values listed in X-Olsen-Facet-Disabled
+// so the header stays within proxy size limits
+const maxDisabledFacetValues = 100
+
+// setQueryHeaders reports how long a request's queries took since start and,
+// when total is not negative, how many photos matched
+func setQueryHeaders(w http.ResponseWriter, start time.Time, total int) {
+	ms := float64(time.Since(start).Microseconds()) / 1000
+	w.Header().Set(headerQueryTime, strconv.FormatFloat(ms, 'f', 1, 64))
+	if total >= 0 {
+		w.Header().Set(headerResultCount, strconv.Itoa(total))
+	}
+}
+
+// setFacetDisabledHeader lists the facet values that match no photos, e.g.
+// "year=2019&camera=Canon+EOS+R5", the values the UI renders as disabled
+func setFacetDisabledHeader(w http.ResponseWriter, facets ...*query.Facet) {
+	disabled := query.ZeroCountFacetValues(facets...)
+	if len(disabled) == 0 {
+		return
+	}
+	if len(disabled) > maxDisabledFacetValues {
+		disabled = disabled[:maxDisabledFacetValues]
+	}
+	pairs := make([]string, len(disabled))
+	for i, pair := range disabled {
+		name, value, _ := strings.Cut(pair, "=")
+		pairs[i] = url.QueryEscape(name) + "=" + url.QueryEscape(value)
+	}
+	w.Header().Set(headerFacetDisabled, strings.Join(pairs, "&"))
+}
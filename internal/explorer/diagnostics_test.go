@@ -0,0 +1,84 @@
+package explorer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/adewale/olsen/internal/database"
+	"github.com/adewale/olsen/internal/query"
+	"github.com/adewale/olsen/pkg/models"
+)
+
+func TestDiagnosticHeaders(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "diagnostics.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	for i, year := range []int{2023, 2024, 2024} {
+		photo := &models.PhotoMetadata{
+			FilePath:   filepath.Join("/photos", string(rune('a'+i))+".jpg"),
+			FileHash:   string(rune('a' + i)),
+			FileSize:   1,
+			DateTaken:  time.Date(year, 6, 1, 12, 0, 0, 0, time.UTC),
+			CameraMake: "Canon",
+		}
+		if err := db.InsertPhoto(photo); err != nil {
+			t.Fatalf("InsertPhoto failed: %v", err)
+		}
+	}
+
+	server := NewServer(db, "")
+	for _, path := range []string{"/photos?year=2024", "/api/photos/grid?year=2024"} {
+		rec := httptest.NewRecorder()
+		server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s status = %d; want 200", path, rec.Code)
+		}
+		if got := rec.Header().Get("X-Olsen-Result-Count"); got != "2" {
+			t.Errorf("GET %s: X-Olsen-Result-Count = %q; want 2", path, got)
+		}
+		if rec.Header().Get("X-Olsen-Query-Time") == "" {
+			t.Errorf("GET %s: X-Olsen-Query-Time missing", path)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/facet/year", nil))
+	if rec.Header().Get("X-Olsen-Query-Time") == "" {
+		t.Error("GET /api/facet/year: X-Olsen-Query-Time missing")
+	}
+	if rec.Header().Get("X-Olsen-Result-Count") != "" {
+		t.Error("GET /api/facet/year should not report a result count")
+	}
+}
+
+func TestFacetDisabledHeader(t *testing.T) {
+	facets := &query.FacetCollection{
+		Year: &query.Facet{Name: "year", Values: []query.FacetValue{
+			{Value: "2023", Count: 4},
+			{Value: "2019", Count: 0},
+		}},
+		Camera: &query.Facet{Name: "camera", Values: []query.FacetValue{
+			{Value: "Canon EOS R5", Count: 0},
+		}},
+	}
+
+	rec := httptest.NewRecorder()
+	setFacetDisabledHeader(rec, facets.All()...)
+	want := "year=2019&camera=Canon+EOS+R5"
+	if got := rec.Header().Get("X-Olsen-Facet-Disabled"); got != want {
+		t.Errorf("X-Olsen-Facet-Disabled = %q; want %q", got, want)
+	}
+
+	var none *query.FacetCollection
+	rec = httptest.NewRecorder()
+	setFacetDisabledHeader(rec, none.All()...)
+	if _, ok := rec.Header()["X-Olsen-Facet-Disabled"]; ok {
+		t.Error("X-Olsen-Facet-Disabled set without facets")
+	}
+}
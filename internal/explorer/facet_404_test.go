@@ -15,9 +15,9 @@ import (
 // - params: The parsed QueryParams (if parsing succeeded)
 //
 // Example log entries:
-//   FACET_404: No route matched - path=/invalid_path query=
-//   FACET_404: No results found - path=/color/red query= params={ColorName:[red] Limit:100 ...}
-//   FACET_404: URL parse failed - path=/color/red/year/9999 query= error=...
+//   WARN FACET_404 reason="no route matched" path=/invalid_path query=""
+//   WARN FACET_404 reason="no results found" path=/color/red query="" params="{ColorName:[red] Limit:100 ...}"
+//   WARN FACET_404 reason="URL parse failed" path=/color/red/year/9999 query="" error=...
 //
 // To create a test from a log entry:
 // 1. Copy the path and query from the log
@@ -2,6 +2,7 @@ package explorer
 
 import (
 	"bytes"
+	"html/template"
 	"strings"
 	"testing"
 
@@ -58,7 +59,7 @@ func TestYearFacetDisabledRendering(t *testing.T) {
 
 	// Render the template
 	var buf bytes.Buffer
-	err := templates.ExecuteTemplate(&buf, "grid", map[string]interface{}{
+	err := cloneTemplates(t).ExecuteTemplate(&buf, "grid", map[string]interface{}{
 		"Facets":     facets,
 		"Photos":     []PhotoCard{},
 		"TotalCount": 50,
@@ -120,7 +121,7 @@ func TestMonthFacetDisabledRendering(t *testing.T) {
 	}
 
 	var buf bytes.Buffer
-	err := templates.ExecuteTemplate(&buf, "grid", map[string]interface{}{
+	err := cloneTemplates(t).ExecuteTemplate(&buf, "grid", map[string]interface{}{
 		"Facets":     facets,
 		"Photos":     []PhotoCard{},
 		"TotalCount": 50,
@@ -168,7 +169,7 @@ func TestCameraFacetDisabledRendering(t *testing.T) {
 	}
 
 	var buf bytes.Buffer
-	err := templates.ExecuteTemplate(&buf, "grid", map[string]interface{}{
+	err := cloneTemplates(t).ExecuteTemplate(&buf, "grid", map[string]interface{}{
 		"Facets":     facets,
 		"Photos":     []PhotoCard{},
 		"TotalCount": 30,
@@ -216,7 +217,7 @@ func TestColourFacetDisabledRendering(t *testing.T) {
 	}
 
 	var buf bytes.Buffer
-	err := templates.ExecuteTemplate(&buf, "grid", map[string]interface{}{
+	err := cloneTemplates(t).ExecuteTemplate(&buf, "grid", map[string]interface{}{
 		"Facets":     facets,
 		"Photos":     []PhotoCard{},
 		"TotalCount": 20,
@@ -248,6 +249,65 @@ func TestColourFacetDisabledRendering(t *testing.T) {
 	}
 }
 
+func TestColourFacetAccessibleRendering(t *testing.T) {
+	// Setup: Colour facet rendered with --accessible-colors
+	facets := emptyFacetCollection()
+	facets.ColourName.Values = []query.FacetValue{
+		{Value: "red", Label: "Red", Count: 20, Selected: true, URL: "/photos"},
+		{Value: "blue", Label: "Blue", Count: 5, Selected: false, URL: "/photos?colour=blue"},
+		{Value: "green", Label: "Green", Count: 0, Selected: false, URL: "/photos?colour=green"},
+	}
+
+	render := func(accessible bool) string {
+		var buf bytes.Buffer
+		err := cloneTemplates(t).ExecuteTemplate(&buf, "grid", map[string]interface{}{
+			"Facets":            facets,
+			"Photos":            []PhotoCard{},
+			"TotalCount":        20,
+			"AccessibleColours": accessible,
+		})
+		if err != nil {
+			t.Fatalf("Template execution failed: %v", err)
+		}
+		return buf.String()
+	}
+
+	html := render(true)
+
+	// Every colour has a visible text label
+	for _, label := range []string{">Red<", ">Blue<", ">Green<"} {
+		if !strings.Contains(html, label) {
+			t.Errorf("Expected visible label %s in accessible colour facet", label)
+		}
+	}
+
+	// Selected state is conveyed to assistive technology
+	if !strings.Contains(html, `aria-current="true"`) {
+		t.Error("Expected aria-current on selected colour")
+	}
+	if !strings.Contains(html, `aria-label="Red, 20 photos, selected (click to remove)"`) {
+		t.Error("Expected ARIA label announcing selected state")
+	}
+
+	// Disabled state is conveyed and not clickable
+	if !strings.Contains(html, `aria-disabled="true"`) {
+		t.Error("Expected aria-disabled on colour with no results")
+	}
+	if strings.Contains(html, `href="/photos?colour=green"`) {
+		t.Error("Expected green colour NOT to be clickable (count=0)")
+	}
+
+	// Colours are distinguishable by pattern
+	if !strings.Contains(html, "pattern-diagonal") || !strings.Contains(html, "pattern-crosshatch") {
+		t.Error("Expected pattern classes on accessible swatches")
+	}
+
+	// Default rendering stays swatch-only
+	if strings.Contains(render(false), `class="color-option`) {
+		t.Error("Expected swatch-only colour facet when accessible mode is off")
+	}
+}
+
 func TestTimeOfDayChipFacetDisabledRendering(t *testing.T) {
 	// Setup: Time of Day facet (chip-style rendering)
 	facets := emptyFacetCollection()
@@ -269,7 +329,7 @@ func TestTimeOfDayChipFacetDisabledRendering(t *testing.T) {
 	}
 
 	var buf bytes.Buffer
-	err := templates.ExecuteTemplate(&buf, "grid", map[string]interface{}{
+	err := cloneTemplates(t).ExecuteTemplate(&buf, "grid", map[string]interface{}{
 		"Facets":     facets,
 		"Photos":     []PhotoCard{},
 		"TotalCount": 15,
@@ -322,7 +382,7 @@ func TestInBurstChipFacetDisabledRendering(t *testing.T) {
 	}
 
 	var buf bytes.Buffer
-	err := templates.ExecuteTemplate(&buf, "grid", map[string]interface{}{
+	err := cloneTemplates(t).ExecuteTemplate(&buf, "grid", map[string]interface{}{
 		"Facets":     facets,
 		"Photos":     []PhotoCard{},
 		"TotalCount": 10,
@@ -368,7 +428,7 @@ func TestAllFacetsDisabled_ZeroResults(t *testing.T) {
 	}
 
 	var buf bytes.Buffer
-	err := templates.ExecuteTemplate(&buf, "grid", map[string]interface{}{
+	err := cloneTemplates(t).ExecuteTemplate(&buf, "grid", map[string]interface{}{
 		"Facets":     facets,
 		"Photos":     []PhotoCard{},
 		"TotalCount": 0,
@@ -409,7 +469,7 @@ func TestMixedEnabledDisabledFacets(t *testing.T) {
 	}
 
 	var buf bytes.Buffer
-	err := templates.ExecuteTemplate(&buf, "grid", map[string]interface{}{
+	err := cloneTemplates(t).ExecuteTemplate(&buf, "grid", map[string]interface{}{
 		"Facets":     facets,
 		"Photos":     []PhotoCard{},
 		"TotalCount": 50,
@@ -482,7 +542,7 @@ func TestDisabledFacetCSSClasses(t *testing.T) {
 	}
 
 	var buf bytes.Buffer
-	err := templates.ExecuteTemplate(&buf, "grid", map[string]interface{}{
+	err := cloneTemplates(t).ExecuteTemplate(&buf, "grid", map[string]interface{}{
 		"Facets":     facets,
 		"Photos":     []PhotoCard{},
 		"TotalCount": 0,
@@ -523,7 +583,7 @@ func TestNoDisabledFacets_AllValid(t *testing.T) {
 	}
 
 	var buf bytes.Buffer
-	err := templates.ExecuteTemplate(&buf, "grid", map[string]interface{}{
+	err := cloneTemplates(t).ExecuteTemplate(&buf, "grid", map[string]interface{}{
 		"Facets":     facets,
 		"Photos":     []PhotoCard{},
 		"TotalCount": 50,
@@ -552,3 +612,15 @@ func TestNoDisabledFacets_AllValid(t *testing.T) {
 		t.Error("Expected 2024 to be clickable")
 	}
 }
+
+// cloneTemplates returns a copy of the page templates to execute, since
+// html/template refuses to Clone a set that has been executed and the server
+// clones templates for every page
+func cloneTemplates(t *testing.T) *template.Template {
+	t.Helper()
+	tmpl, err := templates.Clone()
+	if err != nil {
+		t.Fatalf("Failed to clone templates: %v", err)
+	}
+	return tmpl
+}
@@ -0,0 +1,89 @@
+package explorer
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/adewale/olsen/internal/query"
+)
+
+// growthRow is one period of the library growth table
+type growthRow struct {
+	query.GrowthPeriod
+	Width string // Bar width as a CSS percentage of the largest period
+}
+
+// handleGrowth renders /analytics/growth: photos indexed per day, week or
+// month (?by=, default month) with the running total. ?indexed_from= and
+// ?indexed_to= (YYYY-MM-DD) limit the range; other parameters filter the
+// photos as on /photos.
+func (s *Server) handleGrowth(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	by := values.Get("by")
+	if by == "" {
+		by = "month"
+	}
+	if !slices.Contains(query.GrowthPeriods, by) {
+		http.Error(w, "Invalid by; use day, week or month", http.StatusBadRequest)
+		return
+	}
+	var within query.GrowthRange
+	for name, t := range map[string]*time.Time{"indexed_from": &within.From, "indexed_to": &within.To} {
+		if v := values.Get(name); v != "" {
+			day, err := time.Parse("2006-01-02", v)
+			if err != nil {
+				http.Error(w, "Invalid "+name+"; use YYYY-MM-DD", http.StatusBadRequest)
+				return
+			}
+			*t = day
+		}
+	}
+	values.Del("by")
+	values.Del("indexed_from")
+	values.Del("indexed_to")
+	params, err := s.urlMapper.ParsePath("/photos", values.Encode())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	periods, err := s.engine.ComputeGrowth(params, by, within)
+	if err != nil {
+		slog.Error("FACET_ERROR", "reason", "growth query failed", "query", r.URL.RawQuery, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	largest := 0
+	for _, p := range periods {
+		largest = max(largest, p.Added)
+	}
+	rows := make([]growthRow, len(periods))
+	for i, p := range periods {
+		rows[i] = growthRow{GrowthPeriod: p, Width: fmt.Sprintf("%.1f%%", float64(p.Added)/float64(largest)*100)}
+	}
+
+	// Links that switch the grouping keep the range and filters
+	links := make(map[string]string, len(query.GrowthPeriods))
+	for _, period := range query.GrowthPeriods {
+		v := r.URL.Query()
+		v.Set("by", period)
+		links[period] = s.path("/analytics/growth?" + v.Encode())
+	}
+
+	data := map[string]interface{}{
+		"Title":       "Library Growth",
+		"Rows":        rows,
+		"By":          by,
+		"Links":       links,
+		"IndexedFrom": r.URL.Query().Get("indexed_from"),
+		"IndexedTo":   r.URL.Query().Get("indexed_to"),
+		"Filtered":    len(values) > 0,
+		"BackLink":    s.urlMapper.BuildFullURL(params),
+	}
+
+	s.renderTemplate(w, "growth", data)
+}
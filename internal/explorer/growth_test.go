@@ -0,0 +1,56 @@
+package explorer
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/adewale/olsen/internal/database"
+	"github.com/adewale/olsen/pkg/models"
+)
+
+func TestGrowthPage(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "growth.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	for i, at := range []string{"2024-05-30 09:00:00", "2024-06-01 10:00:00", "2024-06-01 11:00:00"} {
+		photo := &models.PhotoMetadata{FilePath: fmt.Sprintf("/%d.jpg", i), FileHash: fmt.Sprint(i)}
+		if err := db.InsertPhoto(photo); err != nil {
+			t.Fatalf("InsertPhoto failed: %v", err)
+		}
+		if _, err := db.Exec("UPDATE photos SET indexed_at = ? WHERE file_path = ?", at, photo.FilePath); err != nil {
+			t.Fatalf("Failed to set indexed_at: %v", err)
+		}
+	}
+
+	server := NewServer(db, "")
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	rec := get("/analytics/growth?by=day&indexed_from=2024-06-01")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /analytics/growth status = %d: %s", rec.Code, rec.Body.String())
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, "2024-06-01") || strings.Contains(body, "<td>2024-05-30</td>") {
+		t.Error("Growth page should list only the days in range")
+	}
+	if !strings.Contains(body, `<td class="count">3</td>`) {
+		t.Error("Running total should include photos indexed before the range")
+	}
+
+	for _, bad := range []string{"?by=year", "?indexed_to=June"} {
+		if rec := get("/analytics/growth" + bad); rec.Code != http.StatusBadRequest {
+			t.Errorf("GET /analytics/growth%s status = %d; want 400", bad, rec.Code)
+		}
+	}
+}
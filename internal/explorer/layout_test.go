@@ -0,0 +1,68 @@
+package explorer
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adewale/olsen/internal/database"
+	"github.com/adewale/olsen/pkg/models"
+)
+
+func TestJustifiedLayout(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "layout.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	for i, p := range []*models.PhotoMetadata{
+		{Width: 6000, Height: 4000, Orientation: 1}, // Landscape
+		{Width: 6000, Height: 4000, Orientation: 6}, // Shot in portrait, stored rotated
+		{Width: 9000, Height: 2000},                 // Panorama
+		{},                                          // Size unknown
+	} {
+		p.FilePath = fmt.Sprintf("/%d.jpg", i+1)
+		p.FileHash = fmt.Sprint(i)
+		p.DateTaken = time.Date(2024, 6, 1, 12, i, 0, 0, time.UTC)
+		if err := db.InsertPhoto(p); err != nil {
+			t.Fatalf("InsertPhoto failed: %v", err)
+		}
+	}
+
+	server := NewServer(db, "")
+	get := func(path string) string {
+		rec := httptest.NewRecorder()
+		server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s status = %d", path, rec.Code)
+		}
+		return rec.Body.String()
+	}
+
+	body := get("/photos?layout=justified")
+	if !strings.Contains(body, `class="grid justified"`) {
+		t.Fatal("layout=justified does not render the justified grid")
+	}
+	for _, ratio := range []string{"1.500", "0.667", "4.500", "1.000"} {
+		if !strings.Contains(body, "aspect-ratio: "+ratio) {
+			t.Errorf("justified grid has no card with aspect ratio %s", ratio)
+		}
+	}
+	if !strings.Contains(body, "/api/thumbnail/1/512") {
+		t.Error("justified grid does not request the larger row thumbnails")
+	}
+
+	// Infinite scroll pages match the layout they are appended to
+	if fragment := get("/api/photos/grid?layout=justified"); !strings.Contains(fragment, "aspect-ratio: 4.500") {
+		t.Error("grid fragment ignores layout=justified")
+	}
+
+	if body := get("/photos"); strings.Contains(body, "grid justified") || !strings.Contains(body, `href="/photos?layout=justified"`) {
+		t.Error("default grid should use square cells and link to the justified layout")
+	}
+}
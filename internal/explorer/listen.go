@@ -0,0 +1,70 @@
+package explorer
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// unixAddrPrefix marks a listen address as a Unix domain socket path, as in
+// "unix:/run/olsen.sock"
+const unixAddrPrefix = "unix:"
+
+// socketMode lets the owner and group connect, so a reverse proxy can be
+// given access by group without opening the socket to every local user
+const socketMode os.FileMode = 0660
+
+// IsUnixAddr reports whether addr names a Unix domain socket
+func IsUnixAddr(addr string) bool {
+	return strings.HasPrefix(addr, unixAddrPrefix)
+}
+
+// listen opens a TCP listener, or a Unix socket for "unix:" addresses.
+// Closing a Unix listener removes its socket file.
+func listen(addr string) (net.Listener, error) {
+	if !IsUnixAddr(addr) {
+		return net.Listen("tcp", addr)
+	}
+
+	path := strings.TrimPrefix(addr, unixAddrPrefix)
+	if path == "" {
+		return nil, fmt.Errorf("missing socket path in %q", addr)
+	}
+	if err := removeStaleSocket(path); err != nil {
+		return nil, err
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, socketMode); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("failed to set socket permissions: %w", err)
+	}
+	return ln, nil
+}
+
+// removeStaleSocket deletes a socket file left behind by a server that did
+// not shut down cleanly. It refuses to touch anything that is not a socket,
+// or a socket another server is still accepting connections on.
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s exists and is not a socket", path)
+	}
+
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		conn.Close()
+		return fmt.Errorf("%s is in use by another server", path)
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,68 @@
+package explorer
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestListenUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "olsen.sock")
+
+	ln, err := listen("unix:" + path)
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("socket not created: %v", err)
+	}
+	if info.Mode()&os.ModeSocket == 0 || info.Mode().Perm() != socketMode {
+		t.Errorf("socket mode = %v, want socket with %v", info.Mode(), socketMode)
+	}
+
+	// A second server must not take over a live socket
+	if _, err := listen("unix:" + path); err == nil {
+		t.Error("listen succeeded on a socket that is in use")
+	}
+
+	ln.Close()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("socket not removed on close: %v", err)
+	}
+}
+
+func TestListenUnixSocketStaleAndNonSocket(t *testing.T) {
+	dir := t.TempDir()
+
+	// Leave a socket file behind, as a killed server would
+	stale := filepath.Join(dir, "stale.sock")
+	ln, err := net.Listen("unix", stale)
+	if err != nil {
+		t.Fatalf("failed to create socket: %v", err)
+	}
+	ln.(*net.UnixListener).SetUnlinkOnClose(false)
+	ln.Close()
+
+	ln, err = listen("unix:" + stale)
+	if err != nil {
+		t.Fatalf("listen did not replace stale socket: %v", err)
+	}
+	ln.Close()
+
+	regular := filepath.Join(dir, "photos.db")
+	if err := os.WriteFile(regular, []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := listen("unix:" + regular); err == nil {
+		t.Error("listen replaced a regular file")
+	}
+	if _, err := os.Stat(regular); err != nil {
+		t.Errorf("regular file removed: %v", err)
+	}
+
+	if _, err := listen("unix:"); err == nil {
+		t.Error("listen accepted an empty socket path")
+	}
+}
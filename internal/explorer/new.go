@@ -0,0 +1,123 @@
+package explorer
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Cookies that remember, per browser, which photos have been seen
+const (
+	seenCookie   = "olsen_seen"   // Unix time; photos indexed after it are new
+	activeCookie = "olsen_active" // Unix time of the last page view, to tell visits apart
+)
+
+// visitGap is how long the explorer must go unused before the next page
+// view starts a new visit
+const visitGap = 30 * time.Minute
+
+// newPhotoLimit caps the photos listed at /new
+const newPhotoLimit = 500
+
+// SetNewBadges marks photos indexed since the browser's last visit with a
+// "New" badge in the grid and lists them at /new. Visits are told apart by
+// cookies: a page view after visitGap without one starts a new visit, and
+// everything indexed since the previous visit's last page view is new. Off
+// by default.
+func (s *Server) SetNewBadges(enabled bool) {
+	s.newBadges = enabled
+}
+
+// newSince returns the time photos must have been indexed after to count as
+// new for this browser, and records this page view. A first visit has
+// nothing new: there is no earlier visit to compare with.
+func (s *Server) newSince(w http.ResponseWriter, r *http.Request) time.Time {
+	now := time.Now()
+	seen := cookieTime(r, seenCookie)
+	active := cookieTime(r, activeCookie)
+	switch {
+	case seen.IsZero() || active.IsZero():
+		seen = now
+	case now.Sub(active) > visitGap:
+		seen = active
+	}
+	s.setCookieTime(w, seenCookie, seen)
+	s.setCookieTime(w, activeCookie, now)
+	return seen
+}
+
+// cookieTime reads a cookie holding a Unix time; zero when it is missing or
+// malformed
+func cookieTime(r *http.Request, name string) time.Time {
+	c, err := r.Cookie(name)
+	if err != nil {
+		return time.Time{}
+	}
+	secs, err := strconv.ParseInt(c.Value, 10, 64)
+	if err != nil || secs <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(secs, 0)
+}
+
+// setCookieTime stores t in a year-long cookie scoped to the explorer
+func (s *Server) setCookieTime(w http.ResponseWriter, name string, t time.Time) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    strconv.FormatInt(t.Unix(), 10),
+		Path:     s.path("/"),
+		MaxAge:   365 * 24 * 60 * 60,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// handleNew lists the photos indexed since the browser's last visit, most
+// recently indexed first. POST /new/seen marks them all as seen.
+func (s *Server) handleNew(w http.ResponseWriter, r *http.Request) {
+	if !s.newBadges {
+		http.Error(w, "New photo tracking is off (start the explorer with -only-new)", http.StatusNotFound)
+		return
+	}
+
+	if r.URL.Path == "/new/seen" {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		now := time.Now()
+		s.setCookieTime(w, seenCookie, now)
+		s.setCookieTime(w, activeCookie, now)
+		http.Redirect(w, r, s.path("/new"), http.StatusSeeOther)
+		return
+	}
+	if r.URL.Path != "/new" {
+		s.renderNotFound(w, "There is no page at "+r.URL.Path+".", "")
+		return
+	}
+
+	since := s.newSince(w, r)
+	photos, total, err := s.repo.GetPhotosIndexedSince(since, newPhotoLimit)
+	if err != nil {
+		log.Printf("Failed to load new photos: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Show the cut-off in the zone capture times are shown in
+	shown := since.Local()
+	if s.repo.location != nil {
+		shown = since.In(s.repo.location)
+	}
+
+	data := map[string]interface{}{
+		"Title":    "New Photos",
+		"Photos":   photos,
+		"Total":    total,
+		"Since":    shown,
+		"NewSince": since,
+		"Density":  gridDensities[0],
+	}
+	s.renderTemplate(w, "new", data)
+}
@@ -0,0 +1,90 @@
+package explorer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adewale/olsen/internal/database"
+	"github.com/adewale/olsen/pkg/models"
+)
+
+func TestNewPhotos(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "new.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	for _, p := range []*models.PhotoMetadata{
+		{FilePath: "/old.jpg", FileHash: "old", DateTaken: time.Date(2024, 6, 1, 8, 0, 0, 0, time.UTC)},
+		{FilePath: "/new.jpg", FileHash: "new", DateTaken: time.Date(2024, 6, 2, 8, 0, 0, 0, time.UTC)},
+	} {
+		if err := db.InsertPhoto(p); err != nil {
+			t.Fatalf("InsertPhoto failed: %v", err)
+		}
+	}
+	// The first photo was indexed before the last visit, the second after
+	if _, err := db.Exec("UPDATE photos SET indexed_at = datetime('now', '-2 hours') WHERE file_path = '/old.jpg'"); err != nil {
+		t.Fatal(err)
+	}
+
+	server := NewServer(db, "")
+	get := func(path string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		rec := httptest.NewRecorder()
+		server.router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := get("/new"); rec.Code != http.StatusNotFound {
+		t.Errorf("GET /new with tracking off status = %d; want 404", rec.Code)
+	}
+
+	server.SetNewBadges(true)
+
+	// A first visit has nothing to compare with
+	rec := get("/new")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Nothing new") {
+		t.Errorf("first visit: status %d; want 200 and nothing new", rec.Code)
+	}
+	if cookies := rec.Result().Cookies(); len(cookies) != 2 {
+		t.Errorf("first visit set %d cookies; want seen and active", len(cookies))
+	}
+
+	// The previous visit ended 90 minutes ago
+	unix := func(d time.Duration) string { return strconv.FormatInt(time.Now().Add(-d).Unix(), 10) }
+	lastVisit := []*http.Cookie{
+		{Name: seenCookie, Value: unix(3 * time.Hour)},
+		{Name: activeCookie, Value: unix(90 * time.Minute)},
+	}
+	body := get("/new", lastVisit...).Body.String()
+	if !strings.Contains(body, "1 photos indexed since") || !strings.Contains(body, `href="/photo/2"`) || strings.Contains(body, `href="/photo/1"`) {
+		t.Error("/new does not list just the photo indexed since the last visit")
+	}
+	grid := get("/photos", lastVisit...).Body.String()
+	if n := strings.Count(grid, `class="new-badge"`); n != 1 {
+		t.Errorf("grid shows %d new badges; want 1", n)
+	}
+
+	// Marking everything seen clears the list
+	req := httptest.NewRequest(http.MethodPost, "/new/seen", nil)
+	for _, c := range lastVisit {
+		req.AddCookie(c)
+	}
+	rec = httptest.NewRecorder()
+	server.router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("POST /new/seen status = %d; want 303", rec.Code)
+	}
+	if body := get("/new", rec.Result().Cookies()...).Body.String(); !strings.Contains(body, "Nothing new") {
+		t.Error("photos still new after marking all as seen")
+	}
+}
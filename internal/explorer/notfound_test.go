@@ -0,0 +1,56 @@
+package explorer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/adewale/olsen/internal/database"
+	"github.com/adewale/olsen/pkg/models"
+)
+
+func TestNotFoundPage(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "notfound.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+	if err := db.InsertPhoto(&models.PhotoMetadata{FilePath: "/a.jpg", FileHash: "a"}); err != nil {
+		t.Fatalf("InsertPhoto failed: %v", err)
+	}
+
+	server := NewServer(db, "")
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	for _, path := range []string{"/photo/99", "/photo/abc", "/photo/1/nope", "/api/thumbnail/99/256", "/no/such/page"} {
+		rec := get(path)
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("GET %s status = %d; want 404", path, rec.Code)
+			continue
+		}
+		body := rec.Body.String()
+		if !strings.Contains(rec.Header().Get("Content-Type"), "text/html") || !strings.Contains(body, "Not found") || !strings.Contains(body, `href="/"`) {
+			t.Errorf("GET %s = %q; want the not found page with a link home", path, body)
+		}
+		if strings.Contains(body, "Clear filters") {
+			t.Errorf("GET %s has a clear filters link; want it only on facet pages", path)
+		}
+	}
+
+	// Facet URLs that cannot be parsed link to the unfiltered grid
+	rec := httptest.NewRecorder()
+	server.renderNotFound(rec, "These filters name no photos.", "/photos")
+	if rec.Code != http.StatusNotFound || !strings.Contains(rec.Body.String(), `href="/photos"`) {
+		t.Errorf("facet not found = %d %q; want 404 with a clear filters link", rec.Code, rec.Body.String())
+	}
+
+	if rec := get("/photo/1"); rec.Code != http.StatusOK {
+		t.Errorf("GET /photo/1 status = %d; want 200", rec.Code)
+	}
+}
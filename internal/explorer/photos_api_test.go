@@ -0,0 +1,157 @@
+package explorer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/adewale/olsen/internal/database"
+	"github.com/adewale/olsen/pkg/models"
+)
+
+func TestPhotosAPICursor(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "photos_api.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	base := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		photo := &models.PhotoMetadata{FilePath: fmt.Sprintf("/%d.jpg", i), FileHash: fmt.Sprint(i), DateTaken: base.Add(time.Duration(i) * time.Hour)}
+		if err := db.InsertPhoto(photo); err != nil {
+			t.Fatalf("InsertPhoto failed: %v", err)
+		}
+	}
+
+	server := NewServer(db, "")
+	var ids []int
+	next := "/api/photos?limit=2"
+	for next != "" {
+		rec := httptest.NewRecorder()
+		server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, next, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s status = %d: %s", next, rec.Code, rec.Body.String())
+		}
+		var page struct {
+			Photos []struct{ ID int } `json:"photos"`
+			Total  int                `json:"total"`
+			Next   string             `json:"next"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&page); err != nil {
+			t.Fatalf("decode failed: %v", err)
+		}
+		if page.Total != 5 {
+			t.Errorf("total = %d; want 5", page.Total)
+		}
+		for _, p := range page.Photos {
+			ids = append(ids, p.ID)
+		}
+		next = page.Next
+	}
+
+	if fmt.Sprint(ids) != "[5 4 3 2 1]" {
+		t.Errorf("paged ids = %v; want [5 4 3 2 1]", ids)
+	}
+
+	rec := httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/photos?sort=iso", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("sort=iso status = %d; want 400", rec.Code)
+	}
+}
+
+func TestPhotosAPIPageAndFacets(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "photos_api.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	base := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		photo := &models.PhotoMetadata{FilePath: fmt.Sprintf("/%d.jpg", i), FileHash: fmt.Sprint(i), DateTaken: base.Add(time.Duration(i) * time.Hour), ISO: 100 * (i + 1)}
+		if err := db.InsertPhoto(photo); err != nil {
+			t.Fatalf("InsertPhoto failed: %v", err)
+		}
+	}
+
+	server := NewServer(db, "")
+	rec := httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/photos?sort=iso&order=asc&limit=2&page=2&facets=1", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var page struct {
+		Photos []struct {
+			ID   int
+			Path string
+			ISO  int
+		} `json:"photos"`
+		Total  int    `json:"total"`
+		Limit  int    `json:"limit"`
+		Page   int    `json:"page"`
+		Pages  int    `json:"pages"`
+		Next   string `json:"next"`
+		Prev   string `json:"prev"`
+		Facets []struct {
+			Name   string           `json:"name"`
+			Values []facetValueJSON `json:"values"`
+		} `json:"facets"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&page); err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+
+	var ids []int
+	for _, p := range page.Photos {
+		ids = append(ids, p.ID)
+	}
+	if fmt.Sprint(ids) != "[3 4]" {
+		t.Errorf("page 2 ids = %v; want [3 4] in ISO order", ids)
+	}
+	if p := page.Photos[0]; p.Path != "/2.jpg" || p.ISO != 300 {
+		t.Errorf("first photo has path %q and ISO %d; want /2.jpg and 300", p.Path, p.ISO)
+	}
+	if page.Total != 5 || page.Limit != 2 || page.Page != 2 || page.Pages != 3 {
+		t.Errorf("total %d, limit %d, page %d of %d; want 5, 2, page 2 of 3", page.Total, page.Limit, page.Page, page.Pages)
+	}
+	if page.Next != "/api/photos?facets=1&limit=2&order=asc&page=3&sort=iso" || page.Prev != "/api/photos?facets=1&limit=2&order=asc&page=1&sort=iso" {
+		t.Errorf("next %q, prev %q; want pages 3 and 1", page.Next, page.Prev)
+	}
+	years := -1
+	for _, f := range page.Facets {
+		if f.Name == "year" {
+			years = len(f.Values)
+		}
+	}
+	if years != 1 {
+		t.Errorf("year facet has %d values; want 1 (facets: %+v)", years, page.Facets)
+	}
+
+	// Without facets=1 the response leaves them out
+	rec = httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/photos?limit=2&page=1", nil))
+	var plain map[string]json.RawMessage
+	if err := json.NewDecoder(rec.Body).Decode(&plain); err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if _, ok := plain["facets"]; ok {
+		t.Error("response has facets without facets=1")
+	}
+	if _, ok := plain["prev"]; ok {
+		t.Error("page 1 has a prev link")
+	}
+
+	for _, path := range []string{"/api/photos?page=1&limit=0", "/api/photos?page=2&limit=-1", "/api/photos?limit=0"} {
+		rec = httptest.NewRecorder()
+		server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("GET %s status = %d; want 400", path, rec.Code)
+		}
+	}
+}
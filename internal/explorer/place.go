@@ -0,0 +1,89 @@
+package explorer
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/adewale/olsen/internal/query"
+)
+
+// Radius limits for the place view, in kilometres
+const (
+	DefaultPlaceRadiusKm = 1.0
+	minPlaceRadiusKm     = 0.05
+	maxPlaceRadiusKm     = 100.0
+)
+
+// kmPerDegreeLat is the length of one degree of latitude
+const kmPerDegreeLat = 111.32
+
+// placeBounds returns the box of photos within radiusKm of a point. Longitude
+// degrees shrink towards the poles, so the box widens with latitude; it is
+// capped at the poles and does not wrap across the antimeridian.
+func placeBounds(lat, lon, radiusKm float64) (latMin, latMax, lonMin, lonMax float64) {
+	dLat := radiusKm / kmPerDegreeLat
+	dLon := 180.0
+	if cos := math.Cos(lat * math.Pi / 180); cos > 0.01 {
+		dLon = math.Min(radiusKm/(kmPerDegreeLat*cos), 180)
+	}
+	round := func(v float64) float64 { return math.Round(v*1e5) / 1e5 } // About 1 m
+	return round(math.Max(lat-dLat, -90)), round(math.Min(lat+dLat, 90)),
+		round(math.Max(lon-dLon, -180)), round(math.Min(lon+dLon, 180))
+}
+
+// boundsLabel describes one axis of a GPS box for its active filter chip
+func boundsLabel(axis string, min, max *float64) string {
+	switch {
+	case min != nil && max != nil:
+		return fmt.Sprintf("%s %.4f–%.4f", axis, *min, *max)
+	case min != nil:
+		return fmt.Sprintf("%s ≥ %.4f", axis, *min)
+	case max != nil:
+		return fmt.Sprintf("%s ≤ %.4f", axis, *max)
+	default:
+		return axis + " any"
+	}
+}
+
+// handlePlace shows the photos taken near photo id: /photo/:id/place
+// redirects to the grid filtered to a box of ?radius= km (default 1) around
+// the photo's GPS position, so facets and paging work as usual. Photos
+// without a position get a page saying so.
+func (s *Server) handlePlace(w http.ResponseWriter, r *http.Request, id int) {
+	radius := DefaultPlaceRadiusKm
+	if v := r.URL.Query().Get("radius"); v != "" {
+		km, err := strconv.ParseFloat(v, 64)
+		if err != nil || math.IsNaN(km) {
+			http.Error(w, "Invalid radius", http.StatusBadRequest)
+			return
+		}
+		radius = math.Min(math.Max(km, minPlaceRadiusKm), maxPlaceRadiusKm)
+	}
+
+	photo, err := s.repo.GetPhotoByID(id)
+	if err != nil {
+		s.renderNotFound(w, fmt.Sprintf("Photo %d is not in the library.", id), "")
+		return
+	}
+
+	// The indexer stores no position when GPS is missing, which reads back as 0, 0
+	if photo.Latitude == 0 && photo.Longitude == 0 {
+		s.renderTemplate(w, "place", map[string]interface{}{
+			"Title":   "Photos From This Place",
+			"PhotoID": id,
+		})
+		return
+	}
+
+	latMin, latMax, lonMin, lonMax := placeBounds(photo.Latitude, photo.Longitude, radius)
+	grid := s.urlMapper.BuildFullURL(query.QueryParams{
+		LatMin: &latMin,
+		LatMax: &latMax,
+		LonMin: &lonMin,
+		LonMax: &lonMax,
+		Limit:  50, // The grid's default page size
+	})
+	http.Redirect(w, r, grid, http.StatusFound)
+}
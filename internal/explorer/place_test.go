@@ -0,0 +1,79 @@
+package explorer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/adewale/olsen/internal/database"
+	"github.com/adewale/olsen/pkg/models"
+)
+
+func TestPlaceView(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "place.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	for _, p := range []*models.PhotoMetadata{
+		{FilePath: "/louvre.jpg", FileHash: "a", Latitude: 48.8606, Longitude: 2.3376},
+		{FilePath: "/tuileries.jpg", FileHash: "b", Latitude: 48.8634, Longitude: 2.3275},
+		{FilePath: "/versailles.jpg", FileHash: "c", Latitude: 48.8049, Longitude: 2.1204},
+		{FilePath: "/no-gps.jpg", FileHash: "d"},
+	} {
+		if err := db.InsertPhoto(p); err != nil {
+			t.Fatalf("InsertPhoto failed: %v", err)
+		}
+	}
+
+	server := NewServer(db, "")
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	rec := get("/photo/1/place")
+	if rec.Code != http.StatusFound {
+		t.Fatalf("GET /photo/1/place status = %d; want 302", rec.Code)
+	}
+	grid := get(rec.Header().Get("Location"))
+	if got := grid.Header().Get("X-Olsen-Result-Count"); got != "2" {
+		t.Errorf("photos within 1 km of the Louvre = %s; want 2", got)
+	}
+	if !strings.Contains(grid.Body.String(), "Area lat") {
+		t.Error("Grid should show the area as an active filter")
+	}
+
+	rec = get("/photo/1/place?radius=50")
+	if got := get(rec.Header().Get("Location")).Header().Get("X-Olsen-Result-Count"); got != "3" {
+		t.Errorf("photos within 50 km = %s; want 3", got)
+	}
+
+	rec = get("/photo/4/place")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "no GPS location") {
+		t.Errorf("Photo without GPS: status %d, want a page explaining there is no location", rec.Code)
+	}
+
+	if rec := get("/photo/99/place"); rec.Code != http.StatusNotFound {
+		t.Errorf("Missing photo status = %d; want 404", rec.Code)
+	}
+}
+
+func TestPlaceBounds(t *testing.T) {
+	latMin, latMax, lonMin, lonMax := placeBounds(60, 10, 1)
+	if latMax-latMin < 0.0179 || latMax-latMin > 0.0181 {
+		t.Errorf("latitude span = %f; want about 0.018 degrees for 1 km", latMax-latMin)
+	}
+	// At 60° a degree of longitude is half as long, so the span doubles
+	if lonMax-lonMin < 0.0358 || lonMax-lonMin > 0.0362 {
+		t.Errorf("longitude span = %f; want about 0.036 degrees at 60°N", lonMax-lonMin)
+	}
+
+	if latMin, latMax, _, _ := placeBounds(89.999, 0, 10); latMax != 90 || latMin >= 90 {
+		t.Errorf("bounds near the pole = %f–%f; want capped at 90", latMin, latMax)
+	}
+}
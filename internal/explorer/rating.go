@@ -0,0 +1,84 @@
+package explorer
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/adewale/olsen/internal/query"
+)
+
+// SetRating sets a photo's star rating, 0 (unrated) to query.MaxRating. It
+// returns sql.ErrNoRows if there is no such photo.
+func (r *Repository) SetRating(photoID, rating int) error {
+	if rating < 0 || rating > query.MaxRating {
+		return fmt.Errorf("rating %d is outside 0 to %d", rating, query.MaxRating)
+	}
+	result, err := r.db.Exec("UPDATE photos SET rating = ? WHERE id = ?", rating, photoID)
+	if err != nil {
+		return fmt.Errorf("failed to set rating: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to set rating: %w", err)
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// handlePhotoRating sets a photo's rating from the form value rating, for
+// POST /api/photo/:id/rating. Like other edits it needs --allow-edits.
+func (s *Server) handlePhotoRating(w http.ResponseWriter, r *http.Request, id int) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.checkEdit(w, r) {
+		return
+	}
+
+	rating, err := strconv.Atoi(r.FormValue("rating"))
+	if err != nil || rating < 0 || rating > query.MaxRating {
+		http.Error(w, fmt.Sprintf("Rating must be a whole number from 0 to %d", query.MaxRating), http.StatusBadRequest)
+		return
+	}
+	err = s.repo.SetRating(id, rating)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Photo not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Setting rating of photo %d failed: %v", id, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"photo_id": id,
+		"rating":   rating,
+	}); err != nil {
+		log.Printf("Failed to encode rating of photo %d: %v", id, err)
+	}
+}
+
+// ratingFilterLabel names a rating filter for its active filter chip
+func ratingFilterLabel(min, max *int) string {
+	switch {
+	case min != nil && max != nil && *min == 0 && *max == 0:
+		return "Unrated"
+	case min != nil && max != nil && *min == *max:
+		return "Rated " + strings.Repeat("★", *min)
+	case min != nil && max != nil:
+		return fmt.Sprintf("Rated %d–%d stars", *min, *max)
+	case min != nil:
+		return fmt.Sprintf("Rated %d+ stars", *min)
+	default:
+		return fmt.Sprintf("Rated up to %d stars", *max)
+	}
+}
@@ -0,0 +1,66 @@
+package explorer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/adewale/olsen/internal/database"
+	"github.com/adewale/olsen/pkg/models"
+)
+
+func TestPhotoRatingAPI(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "rating.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+	if err := db.InsertPhoto(&models.PhotoMetadata{FilePath: "/a.jpg", FileHash: "a"}); err != nil {
+		t.Fatalf("InsertPhoto failed: %v", err)
+	}
+
+	server := NewServer(db, "")
+	post := func(path, rating string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(url.Values{"rating": {rating}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		server.router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := post("/api/photo/1/rating", "4"); rec.Code != http.StatusForbidden {
+		t.Errorf("rating without --allow-edits status = %d; want 403", rec.Code)
+	}
+
+	server.SetAllowEdits(true)
+	if rec := post("/api/photo/1/rating", "4"); rec.Code != http.StatusOK {
+		t.Fatalf("rating status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var rating int
+	if err := db.QueryRow("SELECT rating FROM photos WHERE id = 1").Scan(&rating); err != nil || rating != 4 {
+		t.Errorf("rating = %d, %v; want 4", rating, err)
+	}
+
+	for _, c := range []struct {
+		path, rating string
+		want         int
+	}{
+		{"/api/photo/1/rating", "6", http.StatusBadRequest},
+		{"/api/photo/1/rating", "-1", http.StatusBadRequest},
+		{"/api/photo/1/rating", "", http.StatusBadRequest},
+		{"/api/photo/99/rating", "3", http.StatusNotFound},
+	} {
+		if rec := post(c.path, c.rating); rec.Code != c.want {
+			t.Errorf("POST %s rating=%q status = %d; want %d", c.path, c.rating, rec.Code, c.want)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/photo/1/rating", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET status = %d; want 405", rec.Code)
+	}
+}
@@ -0,0 +1,84 @@
+package explorer
+
+import (
+	"log"
+	"net/http"
+	"sync"
+)
+
+// recentViews remembers the last photos opened on the detail page, newest
+// last. It lives in memory for the life of the server process and is shared
+// by every visitor, which suits a single-user local explorer.
+type recentViews struct {
+	mu       sync.Mutex
+	ids      []int
+	capacity int
+}
+
+func newRecentViews(capacity int) *recentViews {
+	return &recentViews{ids: make([]int, 0, capacity), capacity: capacity}
+}
+
+// add records a view of id. Viewing a photo again moves it to the front
+// rather than listing it twice; beyond capacity the oldest view is dropped.
+func (rv *recentViews) add(id int) {
+	rv.mu.Lock()
+	defer rv.mu.Unlock()
+
+	for i, existing := range rv.ids {
+		if existing == id {
+			rv.ids = append(rv.ids[:i], rv.ids[i+1:]...)
+			break
+		}
+	}
+	if len(rv.ids) == rv.capacity {
+		rv.ids = append(rv.ids[:0], rv.ids[1:]...)
+	}
+	rv.ids = append(rv.ids, id)
+}
+
+// list returns the recorded IDs, most recently viewed first
+func (rv *recentViews) list() []int {
+	rv.mu.Lock()
+	defer rv.mu.Unlock()
+
+	ids := make([]int, len(rv.ids))
+	for i, id := range rv.ids {
+		ids[len(ids)-1-i] = id
+	}
+	return ids
+}
+
+// SetRecentViews remembers the last count photos opened on the detail page
+// and lists them at /recent-views. Tracking is off by default (count 0):
+// the list is visible to anyone who can reach the explorer.
+func (s *Server) SetRecentViews(count int) {
+	if count <= 0 {
+		s.recentViews = nil
+		return
+	}
+	s.recentViews = newRecentViews(count)
+}
+
+// handleRecentViews shows the recently viewed photos as a grid, newest first
+func (s *Server) handleRecentViews(w http.ResponseWriter, r *http.Request) {
+	if s.recentViews == nil {
+		http.Error(w, "Recently viewed tracking is off (start the explorer with -recent-views N)", http.StatusNotFound)
+		return
+	}
+
+	photos, err := s.repo.GetPhotoCards(s.recentViews.list())
+	if err != nil {
+		log.Printf("Failed to load recently viewed photos: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := map[string]interface{}{
+		"Title":    "Recently Viewed",
+		"Photos":   photos,
+		"Density":  gridDensities[0],
+		"Capacity": s.recentViews.capacity,
+	}
+	s.renderTemplate(w, "recent_views", data)
+}
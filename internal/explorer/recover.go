@@ -0,0 +1,29 @@
+package explorer
+
+import (
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+)
+
+// recoverPanics turns a panic in next into a 500 response, logged with the
+// request and stack, so one broken handler or template fails only its own
+// request. http.ErrAbortHandler is re-raised: it is how handlers ask the
+// server to drop the connection.
+func recoverPanics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			err := recover()
+			if err == nil {
+				return
+			}
+			if err == http.ErrAbortHandler {
+				panic(err)
+			}
+			slog.Error("PANIC", "method", r.Method, "path", r.URL.Path, "query", r.URL.RawQuery,
+				"error", err, "stack", string(debug.Stack()))
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,36 @@
+package explorer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/adewale/olsen/internal/database"
+)
+
+func TestPanicRecovery(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "recover.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	server := NewServer(db, "")
+	server.router.HandleFunc("/boom", func(w http.ResponseWriter, r *http.Request) {
+		panic("template bug")
+	})
+
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/boom", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("GET /boom status = %d; want 500", rec.Code)
+	}
+
+	// The server keeps serving other requests
+	rec = httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("GET / after a panic status = %d; want 200", rec.Code)
+	}
+}
@@ -0,0 +1,78 @@
+package explorer
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// SetRejected marks a photo as rejected, hiding it from browsing, or
+// restores it. It returns sql.ErrNoRows if there is no such photo.
+func (r *Repository) SetRejected(photoID int, rejected bool) error {
+	result, err := r.db.Exec("UPDATE photos SET rejected = ? WHERE id = ?", rejected, photoID)
+	if err != nil {
+		return fmt.Errorf("failed to set rejected: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to set rejected: %w", err)
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// IsRejected reports whether a photo is rejected. It returns sql.ErrNoRows
+// if there is no such photo.
+func (r *Repository) IsRejected(photoID int) (bool, error) {
+	var rejected bool
+	err := r.db.QueryRow("SELECT COALESCE(rejected, 0) FROM photos WHERE id = ?", photoID).Scan(&rejected)
+	return rejected, err
+}
+
+// handlePhotoReject toggles whether a photo is rejected, for
+// POST /api/photo/:id/reject. The form value rejected, true or false, sets
+// it instead. Like other edits it needs --allow-edits.
+func (s *Server) handlePhotoReject(w http.ResponseWriter, r *http.Request, id int) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.checkEdit(w, r) {
+		return
+	}
+
+	var rejected bool
+	var err error
+	if v := r.FormValue("rejected"); v != "" {
+		if rejected, err = strconv.ParseBool(v); err != nil {
+			http.Error(w, "rejected must be true or false", http.StatusBadRequest)
+			return
+		}
+	} else if rejected, err = s.repo.IsRejected(id); err == nil {
+		rejected = !rejected
+	}
+	if err == nil {
+		err = s.repo.SetRejected(id, rejected)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Photo not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Rejecting photo %d failed: %v", id, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"photo_id": id,
+		"rejected": rejected,
+	}); err != nil {
+		log.Printf("Failed to encode rejected state of photo %d: %v", id, err)
+	}
+}
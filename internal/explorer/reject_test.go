@@ -0,0 +1,180 @@
+package explorer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adewale/olsen/internal/database"
+	"github.com/adewale/olsen/pkg/models"
+)
+
+func TestPhotoRejectAPI(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "reject.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+	for i := 1; i <= 3; i++ {
+		photo := &models.PhotoMetadata{
+			FilePath: fmt.Sprintf("/%d.jpg", i), FileHash: fmt.Sprint(i),
+			DateTaken: time.Date(2024, 6, 1, 12, i, 0, 0, time.UTC),
+		}
+		if err := db.InsertPhoto(photo); err != nil {
+			t.Fatalf("InsertPhoto failed: %v", err)
+		}
+	}
+
+	server := NewServer(db, "")
+	post := func(path string, form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		server.router.ServeHTTP(rec, req)
+		return rec
+	}
+	get := func(path string) string {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s = %d", path, rec.Code)
+		}
+		return rec.Body.String()
+	}
+
+	if rec := post("/api/photo/2/reject", nil); rec.Code != http.StatusForbidden {
+		t.Errorf("reject without --allow-edits status = %d; want 403", rec.Code)
+	}
+
+	// Without a value the flag toggles
+	server.SetAllowEdits(true)
+	rec := post("/api/photo/2/reject", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("reject status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var reply struct {
+		Rejected bool `json:"rejected"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&reply); err != nil || !reply.Rejected {
+		t.Errorf("reply = %+v, %v; want rejected", reply, err)
+	}
+
+	// Rejected photos are hidden unless asked for
+	if body := get("/photos"); !strings.Contains(body, ">2 photos<") {
+		t.Error("/photos does not hide the rejected photo")
+	}
+	if body := get("/photos?include_rejected=true"); !strings.Contains(body, ">3 photos<") || !strings.Contains(body, "Including rejected") {
+		t.Error("/photos?include_rejected=true does not list every photo")
+	}
+	if body := get("/rejected"); !strings.Contains(body, ">1 photos<") || !strings.Contains(body, `href="/photo/2`) {
+		t.Error("/rejected does not list the rejected photo alone")
+	}
+	if body := get("/"); !strings.Contains(body, "1 rejected") || strings.Contains(body, `href="/photo/2"`) {
+		t.Error("home page lists the rejected photo or does not link to the rejected view")
+	}
+	if body := get("/photo/2"); !strings.Contains(body, "Restore") {
+		t.Error("detail page of a rejected photo does not offer to restore it")
+	}
+
+	if rec := post("/api/photo/2/reject", nil); rec.Code != http.StatusOK {
+		t.Fatalf("second reject status = %d: %s", rec.Code, rec.Body.String())
+	}
+	if body := get("/photos"); !strings.Contains(body, ">3 photos<") {
+		t.Error("toggling again does not restore the photo")
+	}
+
+	// An explicit value sets the flag however often it is sent
+	for i := 0; i < 2; i++ {
+		if rec := post("/api/photo/3/reject", url.Values{"rejected": {"true"}}); rec.Code != http.StatusOK {
+			t.Fatalf("reject with rejected=true status = %d: %s", rec.Code, rec.Body.String())
+		}
+	}
+	var rejected bool
+	if err := db.QueryRow("SELECT rejected FROM photos WHERE id = 3").Scan(&rejected); err != nil || !rejected {
+		t.Errorf("rejected = %v, %v; want true", rejected, err)
+	}
+
+	for _, c := range []struct {
+		path, rejected string
+		want           int
+	}{
+		{"/api/photo/3/reject", "maybe", http.StatusBadRequest},
+		{"/api/photo/99/reject", "", http.StatusNotFound},
+		{"/api/photo/99/reject", "true", http.StatusNotFound},
+	} {
+		form := url.Values{}
+		if c.rejected != "" {
+			form.Set("rejected", c.rejected)
+		}
+		if rec := post(c.path, form); rec.Code != c.want {
+			t.Errorf("POST %s rejected=%q status = %d; want %d", c.path, c.rejected, rec.Code, c.want)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/photo/2/reject", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET status = %d; want 405", rec.Code)
+	}
+}
+
+func TestRejectedPhotosLeftOutOfNavigationAndCounts(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "reject.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+	for i := 1; i <= 3; i++ {
+		photo := &models.PhotoMetadata{
+			FilePath: fmt.Sprintf("/%d.jpg", i), FileHash: fmt.Sprint(i),
+			CameraMake: "FUJIFILM", CameraModel: "X-T5", LensModel: "XF35mmF1.4 R",
+			DateTaken: time.Date(2024, 6, 1, 12, i, 0, 0, time.UTC),
+		}
+		if err := db.InsertPhoto(photo); err != nil {
+			t.Fatalf("InsertPhoto failed: %v", err)
+		}
+	}
+	repo := NewRepository(db)
+	if err := repo.SetRejected(2, true); err != nil {
+		t.Fatalf("SetRejected failed: %v", err)
+	}
+
+	first, err := repo.GetPhotoByID(1)
+	if err != nil {
+		t.Fatalf("GetPhotoByID(1) failed: %v", err)
+	}
+	last, err := repo.GetPhotoByID(3)
+	if err != nil {
+		t.Fatalf("GetPhotoByID(3) failed: %v", err)
+	}
+	if first.NextID != 3 || last.PrevID != 1 {
+		t.Errorf("next of 1 = %d, prev of 3 = %d; want 3 and 1, stepping over the rejected photo", first.NextID, last.PrevID)
+	}
+
+	stats, err := repo.GetStats()
+	if err != nil {
+		t.Fatalf("GetStats failed: %v", err)
+	}
+	if stats.TotalPhotos != 2 {
+		t.Errorf("home total = %d; want 2", stats.TotalPhotos)
+	}
+	years, err := repo.GetYears()
+	if err != nil || len(years) != 1 || years[0].Count != 2 {
+		t.Errorf("GetYears = %+v, %v; want 2024 with 2 photos", years, err)
+	}
+	cameras, err := repo.GetCameras()
+	if err != nil || len(cameras) != 1 || cameras[0].TotalCount != 2 || cameras[0].Models[0].Count != 2 {
+		t.Errorf("GetCameras = %+v, %v; want one camera with 2 photos", cameras, err)
+	}
+	lenses, err := repo.GetLenses()
+	if err != nil || len(lenses) != 1 || lenses[0].Count != 2 {
+		t.Errorf("GetLenses = %+v, %v; want one lens with 2 photos", lenses, err)
+	}
+}
@@ -0,0 +1,52 @@
+package explorer
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+)
+
+// relatedSuggestions is how many related lenses or cameras are suggested
+const relatedSuggestions = 5
+
+// handleRelatedAPI returns the lenses most used with the camera in the
+// query string, or the cameras most used with the lens, e.g.
+// /api/related?camera_make=Canon&camera_model=EOS+R5. Other filters narrow
+// the photos counted. Without exactly one camera or lens the list is empty.
+func (s *Server) handleRelatedAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	params, err := s.urlMapper.ParsePath("/photos", r.URL.RawQuery)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	start := time.Now()
+	related, err := s.engine.Related(params, relatedSuggestions)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	setQueryHeaders(w, start, -1)
+
+	response := map[string]interface{}{"values": []facetValueJSON{}}
+	if related != nil {
+		values := make([]facetValueJSON, 0, len(related.Values))
+		for _, v := range related.Values {
+			values = append(values, facetValueJSON{Value: v.Value, Label: v.Label, Count: v.Count, URL: v.URL})
+		}
+		response["facet"] = related.Facet
+		response["label"] = related.Label
+		response["values"] = values
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Failed to encode related equipment: %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package explorer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adewale/olsen/internal/database"
+	"github.com/adewale/olsen/pkg/models"
+)
+
+func TestRelatedEquipment(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "related.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	lenses := []string{"RF 50mm", "RF 24-70mm", "RF 24-70mm"}
+	for i, lens := range lenses {
+		photo := &models.PhotoMetadata{
+			FilePath: fmt.Sprintf("/%d.jpg", i), FileHash: fmt.Sprint(i),
+			CameraMake: "Canon", CameraModel: "EOS R5", LensModel: lens,
+			DateTaken: time.Date(2024, 6, 1, 12, i, 0, 0, time.UTC),
+		}
+		if err := db.InsertPhoto(photo); err != nil {
+			t.Fatalf("InsertPhoto failed: %v", err)
+		}
+	}
+	server := NewServer(db, "")
+
+	rec := httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/related?camera_make=Canon&camera_model=EOS+R5", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var related struct {
+		Facet  string `json:"facet"`
+		Values []struct {
+			Value string `json:"value"`
+			Count int    `json:"count"`
+		} `json:"values"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&related); err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if related.Facet != "lens" || len(related.Values) != 2 || related.Values[0].Value != "RF 24-70mm" || related.Values[0].Count != 2 {
+		t.Errorf("related = %+v; want the two lenses, RF 24-70mm first", related)
+	}
+
+	rec = httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/photos?camera_make=Canon&camera_model=EOS+R5", nil))
+	if body := rec.Body.String(); !strings.Contains(body, "Lenses used with Canon EOS R5") {
+		t.Error("camera page does not suggest lenses")
+	}
+	rec = httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/photos", nil))
+	if body := rec.Body.String(); strings.Contains(body, "used with") {
+		t.Error("unfiltered page suggests equipment")
+	}
+}
@@ -4,15 +4,22 @@ import (
 	"database/sql"
 	"encoding/base64"
 	"fmt"
+	"math"
+	"strings"
 	"time"
 
 	"github.com/adewale/olsen/internal/database"
+	"github.com/adewale/olsen/internal/query"
 	"github.com/adewale/olsen/pkg/models"
 )
 
 // Repository provides query methods for the explorer
 type Repository struct {
 	db *database.DB
+
+	// location is the zone capture times are shown in; nil shows the
+	// camera's clock time (SetLocation)
+	location *time.Location
 }
 
 // NewRepository creates a new repository
@@ -20,14 +27,26 @@ func NewRepository(db *database.DB) *Repository {
 	return &Repository{db: db}
 }
 
-// Stats contains homepage statistics
+// SetLocation shows capture times, and groups the date pages, in loc
+func (r *Repository) SetLocation(loc *time.Location) {
+	r.location = loc
+}
+
+// dateTaken is the SQL expression for a photo's capture time in r's zone
+func (r *Repository) dateTaken() string {
+	return query.DateTakenIn("", r.location)
+}
+
+// Stats contains homepage statistics. The JSON form is what olsen stats
+// -json prints; the date range is left out when no photo has a date.
 type Stats struct {
-	TotalPhotos   int
-	CameraCount   int
-	LensCount     int
-	DateRangeFrom time.Time
-	DateRangeTo   time.Time
-	BurstCount    int
+	TotalPhotos    int       `json:"total_photos"`
+	CameraCount    int       `json:"camera_count"`
+	LensCount      int       `json:"lens_count"`
+	DateRangeFrom  time.Time `json:"date_range_from,omitzero"`
+	DateRangeTo    time.Time `json:"date_range_to,omitzero"`
+	BurstCount     int       `json:"burst_count"`
+	GeotaggedCount int       `json:"geotagged_count"` // Photos with a GPS position
 }
 
 // PhotoCard represents a photo in grid view
@@ -37,6 +56,9 @@ type PhotoCard struct {
 	CameraMake  string
 	CameraModel string
 	IndexedAt   time.Time // Used for cache busting in thumbnail URLs
+	Blurhash    string    // Base64 PNG placeholder shown while the thumbnail loads
+	DominantRGB string    // #rrggbb of the main palette colour, tinting the cell until then
+	FilePath    string    // Set by GetPhotoCards, not the grid queries
 }
 
 // PhotoDetail represents full photo details
@@ -48,6 +70,14 @@ type PhotoDetail struct {
 	CameraMake      string
 	CameraModel     string
 	LensModel       string
+	CameraSerial    string
+	SerialToken     string // Stands in for CameraSerial in links
+	Software        string // Camera firmware or the editor that last saved the file
+	Title           string // From XMP or EXIF; empty when the file has none
+	Caption         string
+	Duration        string // Running time of a video, e.g. 1:05; empty for photos
+	SessionID       int    // Shooting session from olsen analyze; 0 when not analyzed
+	Rejected        bool   // Hidden from browsing while culling
 	ISO             int
 	Aperture        float64
 	ShutterSpeed    string
@@ -61,6 +91,8 @@ type PhotoDetail struct {
 	Height          int
 	Latitude        float64
 	Longitude       float64
+	Altitude        *float64 // Metres, negative below sea level; nil when not recorded
+	SunElevation    *float64 // Degrees above the horizon at capture, from GPS
 	DominantColours []models.DominantColour
 
 	// Navigation
@@ -68,23 +100,35 @@ type PhotoDetail struct {
 	NextID int
 }
 
+// AltitudeLabel formats the altitude for display, e.g. "2350 m" or
+// "12 m below sea level"; empty when none was recorded
+func (p *PhotoDetail) AltitudeLabel() string {
+	if p.Altitude == nil {
+		return ""
+	}
+	if *p.Altitude < 0 {
+		return fmt.Sprintf("%.0f m below sea level", -*p.Altitude)
+	}
+	return fmt.Sprintf("%.0f m", *p.Altitude)
+}
+
 // YearInfo represents a year with photo count
 type YearInfo struct {
-	Year  int
-	Count int
+	Year  int `json:"year"`
+	Count int `json:"count"`
 }
 
 // CameraMakeInfo represents a camera make with models
 type CameraMakeInfo struct {
-	Make       string
-	TotalCount int
-	Models     []CameraModelInfo
+	Make       string            `json:"make"`
+	TotalCount int               `json:"count"`
+	Models     []CameraModelInfo `json:"models"`
 }
 
 // CameraModelInfo represents a camera model with count
 type CameraModelInfo struct {
-	Model string
-	Count int
+	Model string `json:"model"`
+	Count int    `json:"count"`
 }
 
 // LensInfo represents a lens with count
@@ -93,12 +137,12 @@ type LensInfo struct {
 	Count int
 }
 
-// GetStats returns homepage statistics
+// GetStats returns homepage statistics, leaving out rejected photos
 func (r *Repository) GetStats() (*Stats, error) {
 	stats := &Stats{}
 
 	// Total photos
-	err := r.db.QueryRow("SELECT COUNT(*) FROM photos").Scan(&stats.TotalPhotos)
+	err := r.db.QueryRow("SELECT COUNT(*) FROM photos WHERE rejected = 0").Scan(&stats.TotalPhotos)
 	if err != nil {
 		return nil, err
 	}
@@ -107,7 +151,7 @@ func (r *Repository) GetStats() (*Stats, error) {
 	err = r.db.QueryRow(`
 		SELECT COUNT(DISTINCT camera_make || ' ' || camera_model)
 		FROM photos
-		WHERE camera_make != '' AND camera_model != ''
+		WHERE camera_make != '' AND camera_model != '' AND rejected = 0
 	`).Scan(&stats.CameraCount)
 	if err != nil {
 		return nil, err
@@ -117,7 +161,7 @@ func (r *Repository) GetStats() (*Stats, error) {
 	err = r.db.QueryRow(`
 		SELECT COUNT(DISTINCT lens_model)
 		FROM photos
-		WHERE lens_model != ''
+		WHERE lens_model != '' AND rejected = 0
 	`).Scan(&stats.LensCount)
 	if err != nil {
 		return nil, err
@@ -128,17 +172,17 @@ func (r *Repository) GetStats() (*Stats, error) {
 	err = r.db.QueryRow(`
 		SELECT MIN(date_taken), MAX(date_taken)
 		FROM photos
-		WHERE date_taken IS NOT NULL
+		WHERE date_taken IS NOT NULL AND rejected = 0
 	`).Scan(&minDate, &maxDate)
 	if err != nil && err != sql.ErrNoRows {
 		return nil, err
 	}
 
 	if minDate.Valid {
-		stats.DateRangeFrom, _ = time.Parse(time.RFC3339, minDate.String)
+		stats.DateRangeFrom = parseStoredTime(minDate.String)
 	}
 	if maxDate.Valid {
-		stats.DateRangeTo, _ = time.Parse(time.RFC3339, maxDate.String)
+		stats.DateRangeTo = parseStoredTime(maxDate.String)
 	}
 
 	// Burst count
@@ -147,16 +191,76 @@ func (r *Repository) GetStats() (*Stats, error) {
 		return nil, err
 	}
 
+	// Geotagged count, with the HasGPS filter's null checks
+	err = r.db.QueryRow(`
+		SELECT COUNT(*) FROM photos
+		WHERE latitude IS NOT NULL AND longitude IS NOT NULL AND rejected = 0
+	`).Scan(&stats.GeotaggedCount)
+	if err != nil {
+		return nil, err
+	}
+
 	return stats, nil
 }
 
-// GetRecentPhotos returns the most recent photos
+// storedTimeLayouts are the forms a DATETIME column reads back in. The
+// driver converts plain columns to RFC 3339, but aggregates such as MIN
+// return the text as stored.
+var storedTimeLayouts = []string{time.RFC3339, "2006-01-02 15:04:05.999999999-07:00", "2006-01-02 15:04:05"}
+
+// parseStoredTime parses a DATETIME value; zero when it is in none of
+// storedTimeLayouts
+func parseStoredTime(s string) time.Time {
+	for _, layout := range storedTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// RecentOrder selects what "recent" means for the home page
+type RecentOrder string
+
+const (
+	// RecentByDateTaken orders by capture date (photos without a date are excluded)
+	RecentByDateTaken RecentOrder = "taken"
+	// RecentByIndexed orders by when the photo was added to the database
+	RecentByIndexed RecentOrder = "indexed"
+)
+
+// ParseRecentOrder validates a RecentOrder name
+func ParseRecentOrder(s string) (RecentOrder, error) {
+	switch RecentOrder(s) {
+	case RecentByDateTaken, RecentByIndexed:
+		return RecentOrder(s), nil
+	}
+	return "", fmt.Errorf("invalid recent order %q (want %q or %q)", s, RecentByDateTaken, RecentByIndexed)
+}
+
+// GetRecentPhotos returns the most recent photos by date taken
 func (r *Repository) GetRecentPhotos(limit int) ([]PhotoCard, error) {
+	return r.GetRecentPhotosOrdered(limit, RecentByDateTaken)
+}
+
+// GetRecentPhotosOrdered returns the most recent photos using the given
+// ordering, leaving out rejected photos
+func (r *Repository) GetRecentPhotosOrdered(limit int, order RecentOrder) ([]PhotoCard, error) {
+	var where, orderBy string
+	switch order {
+	case RecentByIndexed:
+		where = "rejected = 0"
+		orderBy = "indexed_at DESC, id DESC"
+	default:
+		where = "date_taken IS NOT NULL AND rejected = 0"
+		orderBy = "date_taken DESC"
+	}
+
 	rows, err := r.db.Query(`
-		SELECT id, date_taken, camera_make, camera_model, indexed_at
+		SELECT id, `+r.dateTaken()+`, camera_make, camera_model, indexed_at, blurhash, dominant_rgb
 		FROM photos
-		WHERE date_taken IS NOT NULL
-		ORDER BY date_taken DESC
+		WHERE `+where+`
+		ORDER BY `+orderBy+`
 		LIMIT ?
 	`, limit)
 	if err != nil {
@@ -170,8 +274,8 @@ func (r *Repository) GetRecentPhotos(limit int) ([]PhotoCard, error) {
 		var dateTaken sql.NullString
 		var cameraMake sql.NullString
 		var cameraModel sql.NullString
-		var indexedAt sql.NullString
-		err := rows.Scan(&p.ID, &dateTaken, &cameraMake, &cameraModel, &indexedAt)
+		var indexedAt, blurhash, dominantRGB sql.NullString
+		err := rows.Scan(&p.ID, &dateTaken, &cameraMake, &cameraModel, &indexedAt, &blurhash, &dominantRGB)
 		if err != nil {
 			return nil, err
 		}
@@ -188,6 +292,8 @@ func (r *Repository) GetRecentPhotos(limit int) ([]PhotoCard, error) {
 		if indexedAt.Valid {
 			p.IndexedAt, _ = time.Parse(time.RFC3339, indexedAt.String)
 		}
+		p.Blurhash = blurhash.String
+		p.DominantRGB = dominantRGB.String
 
 		photos = append(photos, p)
 	}
@@ -195,29 +301,127 @@ func (r *Repository) GetRecentPhotos(limit int) ([]PhotoCard, error) {
 	return photos, nil
 }
 
+// GetPhotosIndexedSince returns up to limit photos indexed after since, most
+// recently indexed first, and how many there are in all. Rejected photos
+// are left out.
+func (r *Repository) GetPhotosIndexedSince(since time.Time, limit int) ([]PhotoCard, int, error) {
+	// indexed_at is SQLite's CURRENT_TIMESTAMP, UTC without a zone
+	after := since.UTC().Format("2006-01-02 15:04:05")
+
+	var total int
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM photos WHERE julianday(indexed_at) > julianday(?) AND rejected = 0", after).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.Query(`
+		SELECT id FROM photos
+		WHERE julianday(indexed_at) > julianday(?) AND rejected = 0
+		ORDER BY indexed_at DESC, id DESC
+		LIMIT ?
+	`, after, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, 0, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	photos, err := r.GetPhotoCards(ids)
+	return photos, total, err
+}
+
+// GetPhotoCards returns cards for the given photo IDs in the same order.
+// IDs that no longer exist are left out.
+func (r *Repository) GetPhotoCards(ids []int) ([]PhotoCard, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	placeholders := make([]string, len(ids))
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+
+	rows, err := r.db.Query(`
+		SELECT id, `+r.dateTaken()+`, camera_make, camera_model, indexed_at, blurhash, dominant_rgb, file_path
+		FROM photos
+		WHERE id IN (`+strings.Join(placeholders, ", ")+`)
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byID := make(map[int]PhotoCard, len(ids))
+	for rows.Next() {
+		var p PhotoCard
+		var dateTaken, cameraMake, cameraModel, indexedAt, blurhash, dominantRGB sql.NullString
+		if err := rows.Scan(&p.ID, &dateTaken, &cameraMake, &cameraModel, &indexedAt, &blurhash, &dominantRGB, &p.FilePath); err != nil {
+			return nil, err
+		}
+		if dateTaken.Valid {
+			p.DateTaken, _ = time.Parse(time.RFC3339, dateTaken.String)
+		}
+		p.CameraMake = cameraMake.String
+		p.CameraModel = cameraModel.String
+		if indexedAt.Valid {
+			p.IndexedAt, _ = time.Parse(time.RFC3339, indexedAt.String)
+		}
+		p.Blurhash = blurhash.String
+		p.DominantRGB = dominantRGB.String
+		byID[p.ID] = p
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	photos := make([]PhotoCard, 0, len(byID))
+	for _, id := range ids {
+		if p, ok := byID[id]; ok {
+			photos = append(photos, p)
+		}
+	}
+	return photos, nil
+}
+
 // GetPhotoByID returns detailed photo information
 func (r *Repository) GetPhotoByID(id int) (*PhotoDetail, error) {
 	photo := &PhotoDetail{}
 
 	var dateTaken sql.NullString
 	var cameraMake, cameraModel, lensModel, shutterSpeed, fileHash sql.NullString
-	var iso, width, height sql.NullInt64
+	var cameraSerial, serialToken, software, title, caption sql.NullString
+	var iso, width, height, sessionID sql.NullInt64
 	var aperture, focalLength, focalLength35mm sql.NullFloat64
-	var latitude, longitude sql.NullFloat64
+	var latitude, longitude, altitude, sunElevation, duration sql.NullFloat64
 	var fileSize int64
 
 	err := r.db.QueryRow(`
-		SELECT id, date_taken, camera_make, camera_model, lens_model,
+		SELECT id, `+r.dateTaken()+`, camera_make, camera_model, lens_model,
 		       iso, aperture, shutter_speed, focal_length, focal_length_35mm,
 		       file_path, file_hash, file_size, width, height,
-		       latitude, longitude
+		       latitude, longitude, altitude, camera_serial, camera_serial_token, sun_elevation,
+		       software, duration, title, caption, session_id, COALESCE(rejected, 0)
 		FROM photos
 		WHERE id = ?
 	`, id).Scan(
 		&photo.ID, &dateTaken, &cameraMake, &cameraModel, &lensModel,
 		&iso, &aperture, &shutterSpeed, &focalLength, &focalLength35mm,
 		&photo.FilePath, &fileHash, &fileSize, &width, &height,
-		&latitude, &longitude,
+		&latitude, &longitude, &altitude, &cameraSerial, &serialToken, &sunElevation,
+		&software, &duration, &title, &caption, &sessionID, &photo.Rejected,
 	)
 	if err != nil {
 		return nil, err
@@ -236,6 +440,16 @@ func (r *Repository) GetPhotoByID(id int) (*PhotoDetail, error) {
 	if lensModel.Valid {
 		photo.LensModel = lensModel.String
 	}
+	if cameraSerial.Valid {
+		photo.CameraSerial = cameraSerial.String
+		photo.SerialToken = serialToken.String
+	}
+	if software.Valid {
+		photo.Software = software.String
+	}
+	photo.Title = title.String
+	photo.Caption = caption.String
+	photo.SessionID = int(sessionID.Int64)
 	if shutterSpeed.Valid {
 		photo.ShutterSpeed = shutterSpeed.String
 	}
@@ -268,6 +482,15 @@ func (r *Repository) GetPhotoByID(id int) (*PhotoDetail, error) {
 	if longitude.Valid {
 		photo.Longitude = longitude.Float64
 	}
+	if altitude.Valid {
+		photo.Altitude = &altitude.Float64
+	}
+	if sunElevation.Valid {
+		photo.SunElevation = &sunElevation.Float64
+	}
+	if duration.Valid {
+		photo.Duration = formatDuration(duration.Float64)
+	}
 
 	photo.FileSize = fileSize
 
@@ -299,18 +522,22 @@ func (r *Repository) GetPhotoByID(id int) (*PhotoDetail, error) {
 		}
 	}
 
-	// Get prev/next photo IDs
+	// Get prev/next photo IDs, ordered by (date_taken, id) so photos sharing
+	// a timestamp (bursts, imports) are each visited once. Rejected photos
+	// are stepped over.
 	r.db.QueryRow(`
-		SELECT id FROM photos
-		WHERE date_taken < (SELECT date_taken FROM photos WHERE id = ?)
-		ORDER BY date_taken DESC
+		SELECT p.id FROM photos p, photos c
+		WHERE c.id = ? AND p.rejected = 0
+		  AND (p.date_taken < c.date_taken OR (p.date_taken = c.date_taken AND p.id < c.id))
+		ORDER BY p.date_taken DESC, p.id DESC
 		LIMIT 1
 	`, id).Scan(&photo.PrevID)
 
 	r.db.QueryRow(`
-		SELECT id FROM photos
-		WHERE date_taken > (SELECT date_taken FROM photos WHERE id = ?)
-		ORDER BY date_taken ASC
+		SELECT p.id FROM photos p, photos c
+		WHERE c.id = ? AND p.rejected = 0
+		  AND (p.date_taken > c.date_taken OR (p.date_taken = c.date_taken AND p.id > c.id))
+		ORDER BY p.date_taken ASC, p.id ASC
 		LIMIT 1
 	`, id).Scan(&photo.NextID)
 
@@ -320,6 +547,31 @@ func (r *Repository) GetPhotoByID(id int) (*PhotoDetail, error) {
 	return photo, nil
 }
 
+// GetPhotoExif returns the raw EXIF tags stored for a photo, ordered by IFD and tag name
+func (r *Repository) GetPhotoExif(photoID int) ([]models.ExifEntry, error) {
+	rows, err := r.db.Query(`
+		SELECT ifd_path, tag_name, COALESCE(value, '')
+		FROM photo_exif
+		WHERE photo_id = ?
+		ORDER BY ifd_path, tag_name
+	`, photoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.ExifEntry{}
+	for rows.Next() {
+		var entry models.ExifEntry
+		if err := rows.Scan(&entry.IFD, &entry.Tag, &entry.Value); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
 // GetThumbnail returns thumbnail data for a photo
 // If the requested size doesn't exist, it falls back to the next smaller size
 func (r *Repository) GetThumbnail(photoID int, size string) ([]byte, error) {
@@ -345,8 +597,8 @@ func (r *Repository) GetThumbnail(photoID int, size string) ([]byte, error) {
 	// Try each size in priority order
 	for _, trySize := range sizePriority {
 		err := r.db.QueryRow(`
-			SELECT data FROM thumbnails
-			WHERE photo_id = ? AND size = ?
+			SELECT `+database.ThumbnailData+` FROM thumbnails t
+			WHERE t.photo_id = ? AND t.size = ?
 		`, photoID, trySize).Scan(&data)
 
 		if err == nil {
@@ -386,7 +638,7 @@ func (r *Repository) GetThumbnailWithTimestamp(photoID int, size string) ([]byte
 	// Try each size in priority order
 	for _, trySize := range sizePriority {
 		err := r.db.QueryRow(`
-			SELECT t.data, p.indexed_at
+			SELECT `+database.ThumbnailData+`, p.indexed_at
 			FROM thumbnails t
 			JOIN photos p ON t.photo_id = p.id
 			WHERE t.photo_id = ? AND t.size = ?
@@ -413,7 +665,7 @@ func (r *Repository) GetPhotosByYear(year int, limit, offset int) ([]PhotoCard,
 	var total int
 	err := r.db.QueryRow(`
 		SELECT COUNT(*) FROM photos
-		WHERE strftime('%Y', date_taken) = ?
+		WHERE strftime('%Y', `+r.dateTaken()+`) = ?
 	`, fmt.Sprintf("%04d", year)).Scan(&total)
 	if err != nil {
 		return nil, 0, err
@@ -421,9 +673,9 @@ func (r *Repository) GetPhotosByYear(year int, limit, offset int) ([]PhotoCard,
 
 	// Get photos
 	rows, err := r.db.Query(`
-		SELECT id, date_taken, camera_make, camera_model
+		SELECT id, `+r.dateTaken()+`, camera_make, camera_model
 		FROM photos
-		WHERE strftime('%Y', date_taken) = ?
+		WHERE strftime('%Y', `+r.dateTaken()+`) = ?
 		ORDER BY date_taken DESC
 		LIMIT ? OFFSET ?
 	`, fmt.Sprintf("%04d", year), limit, offset)
@@ -457,7 +709,7 @@ func (r *Repository) GetPhotosByMonth(year, month int, limit, offset int) ([]Pho
 	var total int
 	err := r.db.QueryRow(`
 		SELECT COUNT(*) FROM photos
-		WHERE strftime('%Y-%m', date_taken) = ?
+		WHERE strftime('%Y-%m', `+r.dateTaken()+`) = ?
 	`, fmt.Sprintf("%04d-%02d", year, month)).Scan(&total)
 	if err != nil {
 		return nil, 0, err
@@ -465,9 +717,9 @@ func (r *Repository) GetPhotosByMonth(year, month int, limit, offset int) ([]Pho
 
 	// Get photos
 	rows, err := r.db.Query(`
-		SELECT id, date_taken, camera_make, camera_model
+		SELECT id, `+r.dateTaken()+`, camera_make, camera_model
 		FROM photos
-		WHERE strftime('%Y-%m', date_taken) = ?
+		WHERE strftime('%Y-%m', `+r.dateTaken()+`) = ?
 		ORDER BY date_taken DESC
 		LIMIT ? OFFSET ?
 	`, fmt.Sprintf("%04d-%02d", year, month), limit, offset)
@@ -501,7 +753,7 @@ func (r *Repository) GetPhotosByDay(year, month, day int, limit, offset int) ([]
 	var total int
 	err := r.db.QueryRow(`
 		SELECT COUNT(*) FROM photos
-		WHERE strftime('%Y-%m-%d', date_taken) = ?
+		WHERE strftime('%Y-%m-%d', `+r.dateTaken()+`) = ?
 	`, fmt.Sprintf("%04d-%02d-%02d", year, month, day)).Scan(&total)
 	if err != nil {
 		return nil, 0, err
@@ -509,9 +761,9 @@ func (r *Repository) GetPhotosByDay(year, month, day int, limit, offset int) ([]
 
 	// Get photos
 	rows, err := r.db.Query(`
-		SELECT id, date_taken, camera_make, camera_model
+		SELECT id, `+r.dateTaken()+`, camera_make, camera_model
 		FROM photos
-		WHERE strftime('%Y-%m-%d', date_taken) = ?
+		WHERE strftime('%Y-%m-%d', `+r.dateTaken()+`) = ?
 		ORDER BY date_taken DESC
 		LIMIT ? OFFSET ?
 	`, fmt.Sprintf("%04d-%02d-%02d", year, month, day), limit, offset)
@@ -539,12 +791,12 @@ func (r *Repository) GetPhotosByDay(year, month, day int, limit, offset int) ([]
 	return photos, total, nil
 }
 
-// GetYears returns all years with photo counts
+// GetYears returns all years with photo counts, leaving out rejected photos
 func (r *Repository) GetYears() ([]YearInfo, error) {
 	rows, err := r.db.Query(`
-		SELECT strftime('%Y', date_taken) as year, COUNT(*) as count
+		SELECT strftime('%Y', ` + r.dateTaken() + `) as year, COUNT(*) as count
 		FROM photos
-		WHERE date_taken IS NOT NULL
+		WHERE date_taken IS NOT NULL AND rejected = 0
 		GROUP BY year
 		ORDER BY year DESC
 	`)
@@ -568,13 +820,14 @@ func (r *Repository) GetYears() ([]YearInfo, error) {
 	return years, nil
 }
 
-// GetCameras returns all camera makes with models
+// GetCameras returns all camera makes with models, leaving out rejected
+// photos
 func (r *Repository) GetCameras() ([]CameraMakeInfo, error) {
 	// Get all makes
 	makeRows, err := r.db.Query(`
 		SELECT camera_make, COUNT(*) as count
 		FROM photos
-		WHERE camera_make != ''
+		WHERE camera_make != '' AND rejected = 0
 		GROUP BY camera_make
 		ORDER BY camera_make
 	`)
@@ -592,7 +845,7 @@ func (r *Repository) GetCameras() ([]CameraMakeInfo, error) {
 		modelRows, err := r.db.Query(`
 			SELECT camera_model, COUNT(*) as count
 			FROM photos
-			WHERE camera_make = ? AND camera_model != ''
+			WHERE camera_make = ? AND camera_model != '' AND rejected = 0
 			GROUP BY camera_model
 			ORDER BY count DESC
 		`, make.Make)
@@ -613,12 +866,12 @@ func (r *Repository) GetCameras() ([]CameraMakeInfo, error) {
 	return makes, nil
 }
 
-// GetLenses returns all lenses with counts
+// GetLenses returns all lenses with counts, leaving out rejected photos
 func (r *Repository) GetLenses() ([]LensInfo, error) {
 	rows, err := r.db.Query(`
 		SELECT lens_model, COUNT(*) as count
 		FROM photos
-		WHERE lens_model != ''
+		WHERE lens_model != '' AND rejected = 0
 		GROUP BY lens_model
 		ORDER BY count DESC
 	`)
@@ -651,7 +904,7 @@ func (r *Repository) GetPhotosByCamera(make, model string, limit, offset int) ([
 
 	// Get photos
 	rows, err := r.db.Query(`
-		SELECT id, date_taken, camera_make, camera_model
+		SELECT id, `+r.dateTaken()+`, camera_make, camera_model
 		FROM photos
 		WHERE camera_make = ? AND camera_model = ?
 		ORDER BY date_taken DESC
@@ -695,7 +948,7 @@ func (r *Repository) GetPhotosByLens(lens string, limit, offset int) ([]PhotoCar
 
 	// Get photos
 	rows, err := r.db.Query(`
-		SELECT id, date_taken, camera_make, camera_model
+		SELECT id, `+r.dateTaken()+`, camera_make, camera_model
 		FROM photos
 		WHERE lens_model = ?
 		ORDER BY date_taken DESC
@@ -724,3 +977,13 @@ func (r *Repository) GetPhotosByLens(lens string, limit, offset int) ([]PhotoCar
 
 	return photos, total, nil
 }
+
+// formatDuration writes a video running time as m:ss, or h:mm:ss from an hour
+func formatDuration(seconds float64) string {
+	total := int(math.Round(seconds))
+	h, m, s := total/3600, total/60%60, total%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%d:%02d", m, s)
+}
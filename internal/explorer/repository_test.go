@@ -1,11 +1,18 @@
 package explorer
 
 import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/adewale/olsen/internal/database"
+	"github.com/adewale/olsen/internal/query"
+	"github.com/adewale/olsen/pkg/models"
 )
 
 // TestThumbnailFallback tests that GetThumbnail falls back to smaller sizes
@@ -254,6 +261,51 @@ func TestGetThumbnailWithTimestamp(t *testing.T) {
 }
 
 // TestDateParsing tests RFC3339 date parsing from database
+func TestImmutableThumbnails(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "immutable.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	photo := &models.PhotoMetadata{FilePath: "/a.dng", FileHash: "a", FileSize: 1,
+		Thumbnails: map[models.ThumbnailSize][]byte{models.ThumbnailSmall: []byte("jpeg")}}
+	if err := db.InsertPhoto(photo); err != nil {
+		t.Fatalf("InsertPhoto failed: %v", err)
+	}
+	indexedAt := time.Date(2025, 3, 25, 14, 30, 0, 0, time.UTC)
+	if _, err := db.Exec("UPDATE photos SET indexed_at = ?", indexedAt.Format(time.RFC3339)); err != nil {
+		t.Fatal(err)
+	}
+
+	server := NewServer(db, "")
+	cacheControl := func(url string) string {
+		rec := httptest.NewRecorder()
+		server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s = %d", url, rec.Code)
+		}
+		return rec.Header().Get("Cache-Control")
+	}
+	current := fmt.Sprintf("/api/thumbnail/1/256?v=%d", indexedAt.Unix())
+	revalidated := "public, max-age=3600, must-revalidate"
+
+	if got := cacheControl(current); got != revalidated {
+		t.Errorf("default Cache-Control = %q; want %q", got, revalidated)
+	}
+
+	server.SetImmutableThumbnails(true)
+	if got := cacheControl(current); got != "public, max-age=31536000, immutable" {
+		t.Errorf("versioned Cache-Control = %q; want a year, immutable", got)
+	}
+	// A URL from before a re-index, or none, must still be revalidated
+	for _, url := range []string{"/api/thumbnail/1/256", "/api/thumbnail/1/256?v=1"} {
+		if got := cacheControl(url); got != revalidated {
+			t.Errorf("%s: Cache-Control = %q; want %q", url, got, revalidated)
+		}
+	}
+}
+
 func TestDateParsing(t *testing.T) {
 	// Create temporary database
 	dbPath := filepath.Join(t.TempDir(), "test_dates.db")
@@ -362,3 +414,732 @@ func TestDateParsingWithNullDates(t *testing.T) {
 		}
 	})
 }
+
+// TestGetPhotoExif tests that raw EXIF tags round-trip through InsertPhoto and the API
+func TestGetPhotoExif(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test_exif.db")
+	db, err := database.Open(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	photo := &models.PhotoMetadata{
+		FilePath:     "/test/exif.dng",
+		FileHash:     "exif123",
+		FileSize:     1000,
+		LastModified: time.Now(),
+		IndexedAt:    time.Now(),
+		DateTaken:    time.Now(),
+		RawExif: []models.ExifEntry{
+			{IFD: "IFD/Exif", Tag: "MeteringMode", Value: "5"},
+			{IFD: "IFD", Tag: "Make", Value: "Leica Camera AG"},
+		},
+	}
+	if err := db.InsertPhoto(photo); err != nil {
+		t.Fatalf("Failed to insert photo: %v", err)
+	}
+
+	var photoID int
+	if err := db.QueryRow("SELECT id FROM photos WHERE file_path = ?", photo.FilePath).Scan(&photoID); err != nil {
+		t.Fatalf("Failed to get photo ID: %v", err)
+	}
+
+	repo := NewRepository(db)
+	entries, err := repo.GetPhotoExif(photoID)
+	if err != nil {
+		t.Fatalf("GetPhotoExif failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Got %d EXIF entries; want 2", len(entries))
+	}
+	// Ordered by IFD path, then tag name
+	if entries[0].Tag != "Make" || entries[1].Tag != "MeteringMode" {
+		t.Errorf("Entries = %+v; want Make then MeteringMode", entries)
+	}
+
+	server := NewServer(db, "")
+	rec := httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/photo/%d/exif", photoID), nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET exif status = %d; want 200", rec.Code)
+	}
+
+	var body struct {
+		PhotoID int `json:"photo_id"`
+		Tags    []struct {
+			IFD   string `json:"ifd"`
+			Tag   string `json:"tag"`
+			Value string `json:"value"`
+		} `json:"tags"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if body.PhotoID != photoID || len(body.Tags) != 2 || body.Tags[1].Value != "5" {
+		t.Errorf("Unexpected response: %s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/photo/99999/exif", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("GET exif for missing photo status = %d; want 404", rec.Code)
+	}
+}
+
+// TestGetRecentPhotosOrdered tests both meanings of "recent" on the home page
+func TestPrevNextWithEqualDates(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "equal_dates.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// A burst: three photos in the same second, between two others
+	burst := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	dates := []time.Time{burst.Add(-time.Minute), burst, burst, burst, burst.Add(time.Minute)}
+	for i, date := range dates {
+		photo := &models.PhotoMetadata{FilePath: fmt.Sprintf("/%d.dng", i), FileHash: fmt.Sprint(i), FileSize: 1, DateTaken: date}
+		if err := db.InsertPhoto(photo); err != nil {
+			t.Fatalf("InsertPhoto failed: %v", err)
+		}
+	}
+
+	repo := NewRepository(db)
+	var visited []int
+	for id := 1; id != 0 && len(visited) < 10; {
+		photo, err := repo.GetPhotoByID(id)
+		if err != nil {
+			t.Fatalf("GetPhotoByID(%d) failed: %v", id, err)
+		}
+		if len(visited) > 0 && photo.PrevID != visited[len(visited)-1] {
+			t.Errorf("photo %d: PrevID = %d; want %d", id, photo.PrevID, visited[len(visited)-1])
+		}
+		visited = append(visited, id)
+		id = photo.NextID
+	}
+	if fmt.Sprint(visited) != "[1 2 3 4 5]" {
+		t.Errorf("next visits %v; want every photo once, [1 2 3 4 5]", visited)
+	}
+}
+
+func TestGetRecentPhotosOrdered(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test_recent.db")
+	db, err := database.Open(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now().UTC()
+	photos := []struct {
+		path      string
+		dateTaken interface{}
+		indexedAt time.Time
+	}{
+		// Old photo indexed today
+		{"/test/old.dng", now.AddDate(-10, 0, 0).Format(time.RFC3339), now},
+		// Recent photo indexed last week
+		{"/test/new.dng", now.AddDate(0, 0, -1).Format(time.RFC3339), now.AddDate(0, 0, -7)},
+		// Undated photo indexed yesterday
+		{"/test/undated.dng", nil, now.AddDate(0, 0, -1)},
+	}
+	for _, p := range photos {
+		_, err := db.Exec(`
+			INSERT INTO photos (file_path, file_hash, file_size, indexed_at, last_modified, date_taken)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, p.path, p.path, 1000, p.indexedAt.Format(time.RFC3339), now.Format(time.RFC3339), p.dateTaken)
+		if err != nil {
+			t.Fatalf("Failed to insert photo: %v", err)
+		}
+	}
+
+	repo := NewRepository(db)
+
+	tests := []struct {
+		order RecentOrder
+		want  []int
+	}{
+		{RecentByDateTaken, []int{2, 1}},
+		{RecentByIndexed, []int{1, 3, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.order), func(t *testing.T) {
+			cards, err := repo.GetRecentPhotosOrdered(10, tt.order)
+			if err != nil {
+				t.Fatalf("GetRecentPhotosOrdered failed: %v", err)
+			}
+			var got []int
+			for _, c := range cards {
+				got = append(got, c.ID)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("IDs = %v; want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := ParseRecentOrder("newest"); err == nil {
+		t.Error("ParseRecentOrder(newest) should fail")
+	}
+}
+
+// TestAnalyticsPage tests that /analytics renders the weekday × hour grid with filters applied
+func TestAnalyticsPage(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "test_analytics.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	for i, date := range []string{"2024-06-01T09:15:00Z", "2024-06-08T09:45:00Z", "2023-06-03T18:00:00Z"} {
+		_, err := db.Exec(`
+			INSERT INTO photos (file_path, file_hash, file_size, indexed_at, last_modified, date_taken)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, fmt.Sprintf("/test/%d.dng", i), fmt.Sprintf("hash%d", i), 1000, date, date, date)
+		if err != nil {
+			t.Fatalf("Failed to insert photo: %v", err)
+		}
+	}
+
+	server := NewServer(db, "")
+	rec := httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/analytics?year=2024", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /analytics status = %d; want 200", rec.Code)
+	}
+
+	html := rec.Body.String()
+	if !strings.Contains(html, "2 dated photos") {
+		t.Error("Expected year filter to limit analytics to 2 photos")
+	}
+	if !strings.Contains(html, `title="2 photos">2</td>`) {
+		t.Error("Expected Saturday 09h cell with count 2")
+	}
+}
+
+// TestSeasonsPage tests that /seasons renders year × season counts linking to the filtered grid
+func TestSeasonsPage(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "test_seasons.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	for i, date := range []string{"2024-01-10T09:00:00Z", "2024-12-20T09:00:00Z", "2023-07-01T09:00:00Z"} {
+		season := "winter"
+		if i == 2 {
+			season = "summer"
+		}
+		_, err := db.Exec(`
+			INSERT INTO photos (file_path, file_hash, file_size, indexed_at, last_modified, date_taken, season)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`, fmt.Sprintf("/test/%d.dng", i), fmt.Sprintf("hash%d", i), 1000, date, date, date, season)
+		if err != nil {
+			t.Fatalf("Failed to insert photo: %v", err)
+		}
+	}
+
+	server := NewServer(db, "")
+	rec := httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/seasons", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /seasons status = %d; want 200", rec.Code)
+	}
+
+	html := rec.Body.String()
+	if !strings.Contains(html, "3 dated photos") {
+		t.Error("Expected 3 dated photos")
+	}
+	if !strings.Contains(html, `season=winter&amp;year=2024">2</a>`) {
+		t.Error("Expected winter 2024 cell with count 2 linking to the filtered grid")
+	}
+}
+
+func TestCollectionRoutes(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "collections.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	for _, path := range []string{"/a.dng", "/b.dng"} {
+		if err := db.InsertPhoto(&models.PhotoMetadata{FilePath: path, FileHash: path, FileSize: 1, DateTaken: time.Now()}); err != nil {
+			t.Fatalf("InsertPhoto failed: %v", err)
+		}
+	}
+	id, err := db.CreateCollection("Picks", "")
+	if err != nil {
+		t.Fatalf("CreateCollection failed: %v", err)
+	}
+	if _, err := db.AddToCollection(id, []int{1}); err != nil {
+		t.Fatalf("AddToCollection failed: %v", err)
+	}
+
+	server := NewServer(db, "")
+	post := func(path, form string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		server.router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// The collection page is the grid filtered to members
+	rec := httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/collection/%d", id), nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET collection status = %d; want 200", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "Collection: Picks") {
+		t.Error("Collection grid should show the collection as an active filter")
+	}
+
+	rec = httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/collection/999", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("GET missing collection status = %d; want 404", rec.Code)
+	}
+
+	// Edits are refused until enabled
+	addPath := fmt.Sprintf("/collection/%d/add", id)
+	if rec := post(addPath, "photo_id=2"); rec.Code != http.StatusForbidden {
+		t.Errorf("POST add without edits status = %d; want 403", rec.Code)
+	}
+
+	server.SetAllowEdits(true)
+	if rec := post(addPath, "photo_id=2"); rec.Code != http.StatusSeeOther {
+		t.Errorf("POST add status = %d; want 303", rec.Code)
+	}
+	if c, _ := db.GetCollection(id); c.PhotoCount != 2 {
+		t.Errorf("Collection has %d photos after add; want 2", c.PhotoCount)
+	}
+
+	if rec := post("/collections", "name=Trip"); rec.Code != http.StatusSeeOther {
+		t.Errorf("POST create status = %d; want 303", rec.Code)
+	}
+	if _, err := db.FindCollection("Trip"); err != nil {
+		t.Errorf("Created collection not found: %v", err)
+	}
+}
+
+func TestGridDensity(t *testing.T) {
+	for _, d := range gridDensities[1:] {
+		found := false
+		for _, v := range query.GridDensities {
+			found = found || v == d.Value
+		}
+		if !found {
+			t.Errorf("density %q is not accepted by the URL mapper", d.Value)
+		}
+	}
+
+	db, err := database.Open(filepath.Join(t.TempDir(), "density.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	photo := &models.PhotoMetadata{FilePath: "/a.dng", FileHash: "a", FileSize: 1, DateTaken: time.Now()}
+	if err := db.InsertPhoto(photo); err != nil {
+		t.Fatalf("InsertPhoto failed: %v", err)
+	}
+
+	server := NewServer(db, "")
+	rec := httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/photos?density=dense", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /photos?density=dense = %d", rec.Code)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, "/api/thumbnail/1/64?") {
+		t.Error("dense grid does not request 64px thumbnails")
+	}
+	// Facet links keep the density
+	if !strings.Contains(body, "density=dense&amp;year=") {
+		t.Error("facet links lose the density parameter")
+	}
+
+	rec = httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/photos?density=huge", nil))
+	if !strings.Contains(rec.Body.String(), "/api/thumbnail/1/256?") {
+		t.Error("unknown density does not fall back to 256px thumbnails")
+	}
+}
+
+func TestErrorsPage(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "errors.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.RecordIndexError("/photos/bad.dng", "failed to decode image: bad header"); err != nil {
+		t.Fatalf("RecordIndexError failed: %v", err)
+	}
+	if err := db.RecordIndexError("/photos/locked.jpg", "permission denied"); err != nil {
+		t.Fatalf("RecordIndexError failed: %v", err)
+	}
+
+	server := NewServer(db, "")
+	rec := httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/errors?q=decode", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /errors = %d", rec.Code)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, "/photos/bad.dng") || !strings.Contains(body, "bad header") {
+		t.Error("errors page is missing the matching error")
+	}
+	if strings.Contains(body, "/photos/locked.jpg") {
+		t.Error("errors page ignores the q filter")
+	}
+
+	rec = httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if !strings.Contains(rec.Body.String(), "2 indexing errors") {
+		t.Error("home page does not link to the errors")
+	}
+}
+
+// TestGridFragment tests that /api/photos/grid returns bare photo cards page by page
+func TestGridFragment(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "fragment.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	for i, cameraMake := range []string{"Canon", "Canon", "Canon", "Nikon"} {
+		photo := &models.PhotoMetadata{FilePath: fmt.Sprintf("/%d.dng", i), FileHash: fmt.Sprint(i), FileSize: 1,
+			CameraMake: cameraMake, DateTaken: time.Now()}
+		if err := db.InsertPhoto(photo); err != nil {
+			t.Fatalf("InsertPhoto failed: %v", err)
+		}
+	}
+
+	server := NewServer(db, "")
+	get := func(url string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s status = %d; want 200", url, rec.Code)
+		}
+		return rec
+	}
+
+	rec := get("/api/photos/grid?camera_make=Canon&limit=2&page=1")
+	body := rec.Body.String()
+	if got := strings.Count(body, `class="card"`); got != 2 {
+		t.Errorf("page 1 has %d cards; want 2", got)
+	}
+	if strings.Contains(body, "<html") || strings.Contains(body, "facet-section") {
+		t.Error("fragment includes the layout or facets")
+	}
+	if rec.Header().Get("Has-More") != "true" {
+		t.Errorf("page 1 Has-More = %q; want true", rec.Header().Get("Has-More"))
+	}
+
+	rec = get("/api/photos/grid?camera_make=Canon&limit=2&page=2")
+	if got := strings.Count(rec.Body.String(), `class="card"`); got != 1 {
+		t.Errorf("page 2 has %d cards; want 1 (filter honoured)", got)
+	}
+	if rec.Header().Get("Has-More") != "false" {
+		t.Errorf("page 2 Has-More = %q; want false", rec.Header().Get("Has-More"))
+	}
+
+	rec = get("/api/photos/grid?camera_make=Canon&limit=2&page=3")
+	if strings.TrimSpace(rec.Body.String()) != "" || rec.Header().Get("Has-More") != "false" {
+		t.Errorf("past the last page: body %q, Has-More %q; want empty, false", rec.Body.String(), rec.Header().Get("Has-More"))
+	}
+
+	// Full pages still render after a fragment has been served
+	get("/photos?camera_make=Canon")
+}
+
+func TestRecentViews(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "recent_views.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	for i := 0; i < 4; i++ {
+		photo := &models.PhotoMetadata{FilePath: fmt.Sprintf("/%d.dng", i), FileHash: fmt.Sprint(i), FileSize: 1, DateTaken: time.Now()}
+		if err := db.InsertPhoto(photo); err != nil {
+			t.Fatalf("InsertPhoto failed: %v", err)
+		}
+	}
+
+	server := NewServer(db, "")
+	get := func(url string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+		return rec
+	}
+
+	// Off by default
+	get("/photo/1")
+	if rec := get("/recent-views"); rec.Code != http.StatusNotFound {
+		t.Errorf("GET /recent-views without tracking = %d; want 404", rec.Code)
+	}
+
+	server.SetRecentViews(2)
+	for _, id := range []int{1, 2, 1, 3} {
+		get(fmt.Sprintf("/photo/%d", id))
+	}
+	if got := server.recentViews.list(); len(got) != 2 || got[0] != 3 || got[1] != 1 {
+		t.Fatalf("recent views = %v; want [3 1] (capped, repeat moved to front)", got)
+	}
+
+	rec := get("/recent-views")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /recent-views = %d; want 200", rec.Code)
+	}
+	body := rec.Body.String()
+	first, second := strings.Index(body, `href="/photo/3"`), strings.Index(body, `href="/photo/1"`)
+	if first < 0 || second < 0 || first > second {
+		t.Error("recent views grid should list photo 3 then photo 1")
+	}
+	if strings.Contains(body, `href="/photo/2"`) {
+		t.Error("photo 2 should have been dropped from the capped list")
+	}
+}
+
+func TestPhotoDetailFilteredPrevNext(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "detail.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// IDs 1-4, one day apart; photo 2 is the only Nikon
+	base := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	for i, cameraMake := range []string{"Canon", "Nikon", "Canon", "Canon"} {
+		photo := &models.PhotoMetadata{FilePath: fmt.Sprintf("/%d.dng", i), FileHash: fmt.Sprint(i), FileSize: 1,
+			CameraMake: cameraMake, DateTaken: base.AddDate(0, 0, i)}
+		if err := db.InsertPhoto(photo); err != nil {
+			t.Fatalf("InsertPhoto failed: %v", err)
+		}
+	}
+
+	server := NewServer(db, "")
+	get := func(url string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+		return rec
+	}
+
+	// Grid cards carry the filter onto the detail page
+	if body := get("/photos?camera_make=Canon").Body.String(); !strings.Contains(body, `href="/photo/3?camera_make=Canon"`) {
+		t.Error("grid card links do not carry the filter")
+	}
+
+	// Newest first within Canon: 4, 3, 1. Global date order would put Nikon 2 after 3.
+	body := get("/photo/3?camera_make=Canon").Body.String()
+	if !strings.Contains(body, `href="/photo/4?camera_make=Canon"`) || !strings.Contains(body, `href="/photo/1?camera_make=Canon"`) {
+		t.Error("filtered detail page does not link to Canon neighbours 4 and 1")
+	}
+	if !strings.Contains(body, "2 of 3") {
+		t.Error("filtered detail page does not show its position")
+	}
+
+	// A zero page size falls back to the default for the back link
+	if rec := get("/photo/3?camera_make=Canon&limit=0"); rec.Code != http.StatusOK {
+		t.Errorf("limit=0: status = %d; want 200", rec.Code)
+	}
+
+	// Without a filter, prev/next fall back to global date order
+	body = get("/photo/3").Body.String()
+	if !strings.Contains(body, `href="/photo/2"`) || !strings.Contains(body, `href="/photo/4"`) {
+		t.Error("unfiltered detail page does not link to date neighbours 2 and 4")
+	}
+
+	rec := get("/api/photo/3/filmstrip?camera_make=Canon&n=3")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("filmstrip status = %d; want 200", rec.Code)
+	}
+	var strip struct {
+		Position int `json:"position"`
+		Total    int `json:"total"`
+		PrevID   int `json:"prev_id"`
+		NextID   int `json:"next_id"`
+		Photos   []struct {
+			ID  int    `json:"id"`
+			URL string `json:"url"`
+		} `json:"photos"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &strip); err != nil {
+		t.Fatalf("Failed to decode filmstrip: %v", err)
+	}
+	if strip.Position != 1 || strip.Total != 3 || strip.PrevID != 4 || strip.NextID != 1 || len(strip.Photos) != 3 {
+		t.Errorf("filmstrip = %+v; want position 1 of 3, prev 4, next 1, 3 photos", strip)
+	}
+	if len(strip.Photos) > 0 && strip.Photos[0].URL != "/photo/4?camera_make=Canon" {
+		t.Errorf("filmstrip URL = %q", strip.Photos[0].URL)
+	}
+
+	if rec := get("/api/photo/2/filmstrip?camera_make=Canon"); rec.Code != http.StatusNotFound {
+		t.Errorf("photo outside filter: status = %d; want 404", rec.Code)
+	}
+}
+
+func TestSimilarPhotos(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "similar.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// IDs 1-5: photo 1 and its neighbours at Hamming distances 2, 8 and 20,
+	// plus one indexed without a hash
+	hashes := []string{"p:00000000000000ff", "p:00000000000000fc", "p:0000000000000000", "p:00000fffff0000ff", ""}
+	base := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	for i, hash := range hashes {
+		photo := &models.PhotoMetadata{FilePath: fmt.Sprintf("/%d.jpg", i), FileHash: fmt.Sprint(i), FileSize: 1,
+			CameraMake: "Canon", DateTaken: base.AddDate(0, 0, i), PerceptualHash: hash}
+		if err := db.InsertPhoto(photo); err != nil {
+			t.Fatalf("InsertPhoto failed: %v", err)
+		}
+	}
+
+	repo := NewRepository(db)
+	similar, err := repo.SimilarPhotos(1, 10, 100)
+	if err != nil {
+		t.Fatalf("SimilarPhotos failed: %v", err)
+	}
+	var got []string
+	for _, p := range similar {
+		got = append(got, fmt.Sprintf("%d@%d", p.ID, p.Distance))
+	}
+	if fmt.Sprint(got) != "[2@2 3@8]" {
+		t.Errorf("SimilarPhotos(1, 10) = %v; want [2@2 3@8]", got)
+	}
+	// The limit keeps the closest matches
+	if similar, _ := repo.SimilarPhotos(1, 32, 2); len(similar) != 2 || similar[1].ID != 3 {
+		t.Errorf("SimilarPhotos(1, 32, 2) = %+v; want photos 2 and 3", similar)
+	}
+	if similar, _ := repo.SimilarPhotos(5, 32, 100); similar != nil {
+		t.Errorf("photo without a hash matched %d photos", len(similar))
+	}
+
+	server := NewServer(db, "")
+	get := func(url string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+		return rec
+	}
+
+	tests := []struct {
+		url       string
+		threshold int
+		want      []string
+		notWant   []string
+	}{
+		{"/photo/1/similar", DefaultSimilarDistance, []string{"distance 2", "distance 8"}, []string{"distance 20"}},
+		{"/photo/1/similar?max_distance=4", DefaultSimilarDistance, []string{"distance 2"}, []string{"distance 8"}},
+		{"/photo/1/similar?max_distance=500", DefaultSimilarDistance, []string{"distance 20", `value="32"`}, nil},
+		{"/photo/1/similar", 4, []string{"distance 2"}, []string{"distance 8"}},
+	}
+	for _, tt := range tests {
+		server.SetSimilarThreshold(tt.threshold)
+		rec := get(tt.url)
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s (threshold %d): status = %d", tt.url, tt.threshold, rec.Code)
+			continue
+		}
+		for _, s := range tt.want {
+			if !strings.Contains(rec.Body.String(), s) {
+				t.Errorf("%s (threshold %d): missing %q", tt.url, tt.threshold, s)
+			}
+		}
+		for _, s := range tt.notWant {
+			if strings.Contains(rec.Body.String(), s) {
+				t.Errorf("%s (threshold %d): unexpected %q", tt.url, tt.threshold, s)
+			}
+		}
+	}
+
+	if rec := get("/photo/1/similar?max_distance=close"); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid max_distance: status = %d; want 400", rec.Code)
+	}
+	if rec := get("/photo/99/similar"); rec.Code != http.StatusNotFound {
+		t.Errorf("missing photo: status = %d; want 404", rec.Code)
+	}
+}
+
+func TestGridBlurhashPlaceholder(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "blurhash.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	taken := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	if err := db.InsertPhoto(&models.PhotoMetadata{FilePath: "/a.jpg", FileHash: "a", FileSize: 1, DateTaken: taken, Blurhash: "iVBORw0KGgo="}); err != nil {
+		t.Fatalf("InsertPhoto failed: %v", err)
+	}
+	if err := db.InsertPhoto(&models.PhotoMetadata{FilePath: "/b.jpg", FileHash: "b", FileSize: 1, DateTaken: taken}); err != nil {
+		t.Fatalf("InsertPhoto failed: %v", err)
+	}
+
+	server := NewServer(db, "")
+	for _, url := range []string{"/", "/photos", "/api/photos/grid"} {
+		rec := httptest.NewRecorder()
+		server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+		body := rec.Body.String()
+		if got := strings.Count(body, "background-image: url(data:image/png;base64,iVBORw0KGgo=)"); got != 1 {
+			t.Errorf("%s: %d placeholders; want 1, for the photo that has one", url, got)
+		}
+	}
+}
+
+func TestGetStatsJSON(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "stats.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	for _, p := range []*models.PhotoMetadata{
+		{FilePath: "/a.jpg", FileHash: "a", CameraMake: "Canon", CameraModel: "EOS R5", DateTaken: time.Date(2023, 4, 1, 9, 0, 0, 0, time.UTC)},
+		{FilePath: "/b.jpg", FileHash: "b", CameraMake: "Canon", CameraModel: "EOS R5", DateTaken: time.Date(2024, 8, 2, 18, 30, 0, 0, time.UTC)},
+	} {
+		if err := db.InsertPhoto(p); err != nil {
+			t.Fatalf("InsertPhoto failed: %v", err)
+		}
+	}
+
+	stats, err := NewRepository(db).GetStats()
+	if err != nil {
+		t.Fatalf("GetStats failed: %v", err)
+	}
+	data, err := json.Marshal(stats)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["total_photos"] != 2.0 || got["camera_count"] != 1.0 || got["burst_count"] != 0.0 {
+		t.Errorf("stats JSON = %s; want 2 photos from 1 camera and no bursts", data)
+	}
+	if got["date_range_from"] != "2023-04-01T09:00:00Z" || got["date_range_to"] != "2024-08-02T18:30:00Z" {
+		t.Errorf("date range = %v to %v; want the first and last capture times in RFC 3339", got["date_range_from"], got["date_range_to"])
+	}
+
+	// Without dated photos the range is left out rather than year 1
+	empty, err := database.Open(filepath.Join(t.TempDir(), "empty.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer empty.Close()
+	stats, err = NewRepository(empty).GetStats()
+	if err != nil {
+		t.Fatalf("GetStats failed: %v", err)
+	}
+	if data, _ := json.Marshal(stats); strings.Contains(string(data), "date_range") {
+		t.Errorf("empty stats JSON = %s; want no date range", data)
+	}
+}
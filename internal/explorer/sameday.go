@@ -0,0 +1,36 @@
+package explorer
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/adewale/olsen/internal/query"
+)
+
+// handleSameDay shows everything shot on the day photo id was taken:
+// /photo/:id/sameday redirects to the year, month and day grid. Undated
+// photos get a page saying so.
+func (s *Server) handleSameDay(w http.ResponseWriter, r *http.Request, id int) {
+	params, err := s.engine.SameDay(id)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		s.renderNotFound(w, fmt.Sprintf("Photo %d is not in the library.", id), "")
+		return
+	case errors.Is(err, query.ErrUndated):
+		s.renderTemplate(w, "sameday", map[string]interface{}{
+			"Title":   "Photos From This Day",
+			"PhotoID": id,
+		})
+		return
+	case err != nil:
+		log.Printf("Same-day lookup for photo %d failed: %v", id, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	params.Limit = 50 // The grid's default page size
+	http.Redirect(w, r, s.urlMapper.BuildFullURL(params), http.StatusFound)
+}
@@ -0,0 +1,56 @@
+package explorer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adewale/olsen/internal/database"
+	"github.com/adewale/olsen/pkg/models"
+)
+
+func TestSameDayView(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "sameday.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	for _, p := range []*models.PhotoMetadata{
+		{FilePath: "/morning.jpg", FileHash: "a", DateTaken: time.Date(2024, 6, 1, 8, 0, 0, 0, time.UTC)},
+		{FilePath: "/evening.jpg", FileHash: "b", DateTaken: time.Date(2024, 6, 1, 20, 0, 0, 0, time.UTC)},
+		{FilePath: "/next.jpg", FileHash: "c", DateTaken: time.Date(2024, 6, 2, 9, 0, 0, 0, time.UTC)},
+		{FilePath: "/undated.jpg", FileHash: "d"},
+	} {
+		if err := db.InsertPhoto(p); err != nil {
+			t.Fatalf("InsertPhoto failed: %v", err)
+		}
+	}
+
+	server := NewServer(db, "")
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	rec := get("/photo/1/sameday")
+	if rec.Code != http.StatusFound {
+		t.Fatalf("GET /photo/1/sameday status = %d; want 302", rec.Code)
+	}
+	if got := get(rec.Header().Get("Location")).Header().Get("X-Olsen-Result-Count"); got != "2" {
+		t.Errorf("photos from 1 June = %s; want 2", got)
+	}
+
+	rec = get("/photo/4/sameday")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "no capture date") {
+		t.Errorf("Undated photo: status %d, want a page explaining there is no date", rec.Code)
+	}
+
+	if rec := get("/photo/99/sameday"); rec.Code != http.StatusNotFound {
+		t.Errorf("Missing photo status = %d; want 404", rec.Code)
+	}
+}
@@ -0,0 +1,91 @@
+package explorer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SearchFields are the columns SearchPhotos looks in, and the names its
+// field argument accepts
+var SearchFields = []string{"file_path", "camera_make", "camera_model", "lens_model"}
+
+// searchEscaper escapes the LIKE wildcards in a search, so % and _ match
+// themselves
+var searchEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// SearchPhotos returns up to limit photos whose path, camera or lens
+// contains text, ignoring case, ordered by path and skipping the first
+// offset. field restricts the search to one of SearchFields; empty searches
+// them all.
+func (r *Repository) SearchPhotos(text, field string, limit, offset int) ([]PhotoCard, error) {
+	where, args, err := searchCondition(text, field)
+	if err != nil {
+		return nil, err
+	}
+	args = append(args, limit, offset)
+
+	rows, err := r.db.Query(`
+		SELECT id FROM photos
+		WHERE `+where+`
+		ORDER BY file_path
+		LIMIT ? OFFSET ?
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return r.GetPhotoCards(ids)
+}
+
+// CountSearchResults returns how many photos SearchPhotos would find with no
+// limit
+func (r *Repository) CountSearchResults(text, field string) (int, error) {
+	where, args, err := searchCondition(text, field)
+	if err != nil {
+		return 0, err
+	}
+	var count int
+	err = r.db.QueryRow("SELECT COUNT(*) FROM photos WHERE "+where, args...).Scan(&count)
+	return count, err
+}
+
+// searchCondition builds the WHERE condition matching text in field, or in
+// every SearchFields column when field is empty, and its arguments
+func searchCondition(text, field string) (string, []interface{}, error) {
+	if strings.TrimSpace(text) == "" {
+		return "", nil, fmt.Errorf("search text is required")
+	}
+	fields := SearchFields
+	if field != "" {
+		fields = nil
+		for _, f := range SearchFields {
+			if f == field {
+				fields = []string{f}
+			}
+		}
+		if fields == nil {
+			return "", nil, fmt.Errorf("unknown search field %q (valid: %s)", field, strings.Join(SearchFields, ", "))
+		}
+	}
+
+	pattern := "%" + searchEscaper.Replace(text) + "%"
+	conditions := make([]string, len(fields))
+	args := make([]interface{}, 0, len(fields)+2)
+	for i, f := range fields {
+		conditions[i] = f + ` LIKE ? ESCAPE '\'`
+		args = append(args, pattern)
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")", args, nil
+}
@@ -0,0 +1,68 @@
+package explorer
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/adewale/olsen/internal/database"
+	"github.com/adewale/olsen/pkg/models"
+)
+
+func TestSearchPhotos(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "search.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	for _, p := range []*models.PhotoMetadata{
+		{FilePath: "/photos/holiday/beach.jpg", FileHash: "a", CameraMake: "FUJIFILM", CameraModel: "X-T5", LensModel: "XF23mmF1.4 R"},
+		{FilePath: "/photos/work/desk.jpg", FileHash: "b", CameraMake: "Canon", CameraModel: "EOS R5", LensModel: "RF24-70mm F2.8"},
+		{FilePath: "/photos/work/100%_crop.jpg", FileHash: "c", CameraMake: "Canon", CameraModel: "EOS R6"},
+	} {
+		if err := db.InsertPhoto(p); err != nil {
+			t.Fatalf("InsertPhoto failed: %v", err)
+		}
+	}
+	repo := NewRepository(db)
+
+	tests := []struct {
+		text, field string
+		want        int
+	}{
+		{"canon", "", 2},           // any column, ignoring case
+		{"holiday", "", 1},         // path fragment
+		{"F1.4", "lens_model", 1},  // one column
+		{"canon", "file_path", 0},  // not in the paths
+		{"%", "", 1},               // wildcards match themselves
+		{"work", "camera_make", 0}, // restricted to the camera make
+	}
+	for _, tt := range tests {
+		photos, err := repo.SearchPhotos(tt.text, tt.field, 50, 0)
+		if err != nil {
+			t.Fatalf("SearchPhotos(%q, %q) failed: %v", tt.text, tt.field, err)
+		}
+		if len(photos) != tt.want {
+			t.Errorf("SearchPhotos(%q, %q) = %d photos; want %d", tt.text, tt.field, len(photos), tt.want)
+		}
+	}
+
+	photos, err := repo.SearchPhotos("canon", "", 1, 0)
+	if err != nil || len(photos) != 1 || photos[0].FilePath != "/photos/work/100%_crop.jpg" {
+		t.Errorf("SearchPhotos with limit 1 = %+v, %v; want the first match by path", photos, err)
+	}
+	photos, err = repo.SearchPhotos("canon", "", 1, 1)
+	if err != nil || len(photos) != 1 || photos[0].FilePath != "/photos/work/desk.jpg" {
+		t.Errorf("SearchPhotos with offset 1 = %+v, %v; want the second match by path", photos, err)
+	}
+	if count, err := repo.CountSearchResults("canon", ""); err != nil || count != 2 {
+		t.Errorf("CountSearchResults = %d, %v; want 2", count, err)
+	}
+
+	if _, err := repo.SearchPhotos("  ", "", 50, 0); err == nil {
+		t.Error("SearchPhotos with empty text succeeded; want an error")
+	}
+	if _, err := repo.SearchPhotos("canon", "iso", 50, 0); err == nil {
+		t.Error("SearchPhotos on an unknown field succeeded; want an error")
+	}
+}
@@ -3,17 +3,26 @@
 // It implements an HTTP server with embedded HTML templates, state machine-based
 // faceted navigation, and dynamic thumbnail serving with ETag caching. The explorer
 // provides a read-only view into the photo database with filtering by date, camera,
-// color, and other metadata dimensions.
+// color, and other metadata dimensions. The only writes are optional collection
+// edits, enabled with SetAllowEdits.
 package explorer
 
 import (
+	"bytes"
+	"context"
 	"embed"
+	"encoding/json"
 	"fmt"
 	"html/template"
 	"log"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
 	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/adewale/olsen/internal/database"
 	"github.com/adewale/olsen/internal/query"
@@ -25,7 +34,22 @@ var templateFS embed.FS
 var templates *template.Template
 
 func init() {
-	templates = template.Must(template.ParseFS(templateFS, "templates/*.html"))
+	templates = template.Must(template.New("").Funcs(templateFuncs("")).ParseFS(templateFS, "templates/*.html"))
+}
+
+// templateFuncs are the functions templates may call. base is the path the
+// explorer is served under, for prefixing links: href="{{base}}/photos".
+// cameraPath and lensPath link to a camera or lens under base.
+func templateFuncs(basePath string) template.FuncMap {
+	return template.FuncMap{
+		"base": func() string { return basePath },
+		"cameraPath": func(cameraMake, model string) string {
+			return basePath + query.CameraPath(cameraMake, model)
+		},
+		"lensPath": func(model string) string {
+			return basePath + query.LensPath(model)
+		},
+	}
 }
 
 // Server represents the HTTP server
@@ -36,6 +60,36 @@ type Server struct {
 	urlMapper *query.URLMapper
 	addr      string
 	router    *http.ServeMux
+	handler   http.Handler // router wrapped in middleware; what Start serves
+
+	// accessibleColours renders colour facet swatches with text labels,
+	// patterns and ARIA state instead of colour alone
+	accessibleColours bool
+
+	// Home page recent photos
+	recentCount int
+	recentOrder RecentOrder
+
+	// allowEdits enables the collection editing routes
+	allowEdits bool
+
+	// recentViews tracks detail page views; nil unless SetRecentViews enables it
+	recentViews *recentViews
+	newBadges   bool // Badge photos indexed since the last visit (SetNewBadges)
+
+	// similarThreshold is the similar view's default maximum Hamming distance
+	similarThreshold int
+
+	// templateDir overrides embedded templates; empty unless SetTemplateDir
+	templateDir string
+
+	// immutableThumbnails lets browsers keep versioned thumbnail URLs for a
+	// year without revalidating; see SetImmutableThumbnails
+	immutableThumbnails bool
+
+	// basePath is the path prefix the explorer is served under, such as
+	// "/olsen"; empty at the root (SetBasePath)
+	basePath string
 }
 
 // NewServer creates a new server instance
@@ -47,21 +101,95 @@ func NewServer(db *database.DB, addr string) *Server {
 		urlMapper: query.NewURLMapper(),
 		addr:      addr,
 		router:    http.NewServeMux(),
+
+		recentCount: 50,
+		recentOrder: RecentByDateTaken,
+
+		similarThreshold: DefaultSimilarDistance,
 	}
 
 	s.setupRoutes()
+	s.handler = recoverPanics(s.router)
 	return s
 }
 
+// SetBasePath serves the explorer under base, such as "/olsen", for a
+// reverse proxy that forwards that prefix unchanged. Routes are mounted
+// below it and every link the explorer generates carries it. "" or "/"
+// serves at the root.
+func (s *Server) SetBasePath(base string) {
+	base = "/" + strings.Trim(base, "/")
+	if base == "/" {
+		base = ""
+	}
+	s.basePath = base
+	s.urlMapper.SetBasePath(base)
+	s.engine.SetBasePath(base)
+
+	if base == "" {
+		s.handler = recoverPanics(s.router)
+		return
+	}
+	mux := http.NewServeMux()
+	mux.Handle(base+"/", http.StripPrefix(base, s.router))
+	mux.Handle(base, http.RedirectHandler(base+"/", http.StatusMovedPermanently))
+	s.handler = recoverPanics(mux)
+}
+
+// path returns the link to p, an absolute path within the explorer such as
+// "/photo/1", under the base path
+func (s *Server) path(p string) string {
+	return s.basePath + p
+}
+
+// ServeHTTP serves a request the way Start does, middleware included
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handler.ServeHTTP(w, r)
+}
+
 func (s *Server) setupRoutes() {
 	// Photo detail
 	s.router.HandleFunc("/photo/", s.handlePhotoDetail)
 
 	// API routes
 	s.router.HandleFunc("/api/thumbnail/", s.handleThumbnail)
+	s.router.HandleFunc("/api/photo/", s.handlePhotoAPI)
+	s.router.HandleFunc("/api/photos", s.handlePhotosAPI)
+	s.router.HandleFunc("/api/photos/grid", s.handleGridFragment)
+	s.router.HandleFunc("/api/facet/", s.handleFacetAPI)
+	s.router.HandleFunc("/api/related", s.handleRelatedAPI)
+	s.router.HandleFunc("/api/group/", s.handleBurstRepresentative)
+	s.router.HandleFunc("/api/burst/", s.handleBurstAnimation)
 
 	// Main photo browsing route - all filtering via query parameters
 	s.router.HandleFunc("/photos", s.handleQuery)
+	s.router.HandleFunc("/camera/", s.handleQuery)
+	s.router.HandleFunc("/lens/", s.handleQuery)
+
+	// Shooting sessions from olsen analyze
+	s.router.HandleFunc("/session/", s.handleSession)
+
+	// Analytics
+	s.router.HandleFunc("/analytics", s.handleAnalytics)
+	s.router.HandleFunc("/analytics/growth", s.handleGrowth)
+	s.router.HandleFunc("/seasons", s.handleSeasons)
+
+	// Recently viewed photos (SetRecentViews)
+	s.router.HandleFunc("/recent-views", s.handleRecentViews)
+
+	// Photos indexed since the last visit (SetNewBadges)
+	s.router.HandleFunc("/new", s.handleNew)
+	s.router.HandleFunc("/new/", s.handleNew)
+
+	// Files that failed to index
+	s.router.HandleFunc("/errors", s.handleErrors)
+
+	// Collections (editing requires SetAllowEdits)
+	s.router.HandleFunc("/collections", s.handleCollections)
+	s.router.HandleFunc("/collection/", s.handleCollection)
+
+	// Photos rejected while culling, hidden everywhere else
+	s.router.HandleFunc("/rejected", s.handleQuery)
 
 	// Legacy browse pages (optional - could redirect to /photos)
 	s.router.HandleFunc("/dates", s.handleDates)
@@ -72,25 +200,117 @@ func (s *Server) setupRoutes() {
 	s.router.HandleFunc("/", s.handleHome)
 }
 
-// Start starts the HTTP server
+// SetAccessibleColours switches the colour facet to its accessible rendering
+func (s *Server) SetAccessibleColours(enabled bool) {
+	s.accessibleColours = enabled
+}
+
+// SetImmutableThumbnails serves thumbnails requested with the photo's
+// current version, ?v=<indexed_at as Unix seconds> as the grid writes them,
+// as immutable for a year. Re-indexing changes the version and so the URL,
+// so browsers never need to revalidate. Unversioned or stale URLs keep the
+// default one-hour, revalidated caching.
+func (s *Server) SetImmutableThumbnails(enabled bool) {
+	s.immutableThumbnails = enabled
+}
+
+// SetFacetLimit sets how many values the camera and lens facets list before
+// summing the rest into an Other value; 0 keeps the defaults
+func (s *Server) SetFacetLimit(limit int) {
+	s.engine.SetFacetLimit(limit)
+}
+
+// SetSetupRules replaces the rules the setup facet classifies photos by;
+// nil restores query.DefaultSetupRules
+func (s *Server) SetSetupRules(rules []query.SetupRule) {
+	s.engine.SetSetupRules(rules)
+}
+
+// SetLocation shows capture times in loc, and groups photos into years,
+// months, days, weekdays and hours there. It only moves photos whose EXIF
+// records the camera's UTC offset; without one the capture instant is
+// unknown, so those keep the camera's clock time. nil shows every photo on
+// the camera's clock.
+func (s *Server) SetLocation(loc *time.Location) {
+	s.repo.SetLocation(loc)
+	s.engine.SetLocation(loc)
+}
+
+// SetRecentPhotos configures how many photos the home page shows and what
+// "recent" means. Visitors can still switch ordering with ?recent=.
+func (s *Server) SetRecentPhotos(count int, order RecentOrder) {
+	if count > 0 {
+		s.recentCount = count
+	}
+	s.recentOrder = order
+}
+
+// Start starts the HTTP server and blocks until it fails or is stopped by
+// SIGINT or SIGTERM. Addresses of the form "unix:/path/to.sock" listen on a
+// Unix domain socket, which is removed again on shutdown.
 func (s *Server) Start() error {
-	log.Printf("Starting explorer server on http://%s", s.addr)
-	return http.ListenAndServe(s.addr, s.router)
+	ln, err := listen(s.addr)
+	if err != nil {
+		return err
+	}
+	if IsUnixAddr(s.addr) {
+		log.Printf("Starting explorer server on %s", s.addr)
+	} else {
+		log.Printf("Starting explorer server on http://%s", s.addr)
+	}
+
+	srv := &http.Server{Handler: s.handler}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(stop)
+
+	shutdown := make(chan error, 1)
+	go func() {
+		if _, ok := <-stop; !ok {
+			return
+		}
+		log.Printf("Shutting down explorer server")
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		shutdown <- srv.Shutdown(ctx)
+	}()
+
+	err = srv.Serve(ln)
+	if err != http.ErrServerClosed {
+		signal.Stop(stop)
+		close(stop)
+		return err
+	}
+	return <-shutdown
 }
 
 func (s *Server) renderTemplate(w http.ResponseWriter, name string, data interface{}) {
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	s.renderTemplateStatus(w, http.StatusOK, name, data)
+}
+
+// renderTemplateStatus renders a page with the given status. The page is
+// rendered in full before anything is written, so a template error is sent
+// as a plain 500 rather than appended to half a page.
+func (s *Server) renderTemplateStatus(w http.ResponseWriter, status int, name string, data interface{}) {
+	set, err := s.templateSet()
+	if err != nil {
+		log.Printf("Template load error: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
 	// Clone the template set and add the specific content template as "content"
-	tmpl, err := templates.Clone()
+	tmpl, err := set.Clone()
 	if err != nil {
 		log.Printf("Template clone error: %v", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	tmpl.Funcs(templateFuncs(s.basePath))
 
 	// Get the named template and add it as "content"
-	contentTmpl := templates.Lookup(name)
+	contentTmpl := set.Lookup(name)
 	if contentTmpl == nil {
 		log.Printf("Template not found: %s", name)
 		http.Error(w, "Template not found", http.StatusInternalServerError)
@@ -106,16 +326,32 @@ func (s *Server) renderTemplate(w http.ResponseWriter, name string, data interfa
 	}
 
 	// Execute the layout template
-	err = tmpl.ExecuteTemplate(w, "layout.html", data)
-	if err != nil {
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, "layout.html", data); err != nil {
 		log.Printf("Template execution error: %v", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	buf.WriteTo(w)
+}
+
+// renderNotFound writes the 404 page: message, a link home and, when
+// clearURL is set, a link that drops the filters which matched nothing
+func (s *Server) renderNotFound(w http.ResponseWriter, message, clearURL string) {
+	s.renderTemplateStatus(w, http.StatusNotFound, "notfound", map[string]interface{}{
+		"Title":    "Not Found",
+		"Message":  message,
+		"ClearURL": clearURL,
+	})
 }
 
 func (s *Server) handleHome(w http.ResponseWriter, r *http.Request) {
 	if r.URL.Path != "/" {
-		// Delegate to catch-all handler
+		// Catch-all: no other route matched
+		slog.Warn("FACET_404", "reason", "no route matched", "path", r.URL.Path, "query", r.URL.RawQuery)
+		s.renderNotFound(w, fmt.Sprintf("There is no page at %s.", r.URL.Path), "")
 		return
 	}
 
@@ -125,7 +361,14 @@ func (s *Server) handleHome(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	photos, err := s.repo.GetRecentPhotos(50)
+	order := s.recentOrder
+	if v := r.URL.Query().Get("recent"); v != "" {
+		if parsed, err := ParseRecentOrder(v); err == nil {
+			order = parsed
+		}
+	}
+
+	photos, err := s.repo.GetRecentPhotosOrdered(s.recentCount, order)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -141,45 +384,542 @@ func (s *Server) handleHome(w http.ResponseWriter, r *http.Request) {
 		facets = nil
 	}
 
+	indexErrors, err := s.db.ListIndexErrors("")
+	if err != nil {
+		log.Printf("Index error lookup failed: %v", err)
+	}
+
 	data := map[string]interface{}{
-		"Title":  "Home",
-		"Stats":  stats,
-		"Photos": photos,
-		"Facets": facets,
+		"Title":       "Home",
+		"Stats":       stats,
+		"Photos":      photos,
+		"Facets":      facets,
+		"RecentOrder": string(order),
+		"ErrorCount":  len(indexErrors),
+		"RecentViews": s.recentViews != nil,
+	}
+	if s.newBadges {
+		if _, count, err := s.repo.GetPhotosIndexedSince(s.newSince(w, r), 0); err == nil {
+			data["NewCount"] = count
+		}
+	}
+	rejected := true
+	if count, err := s.engine.Count(query.QueryParams{Rejected: &rejected}); err == nil {
+		data["RejectedCount"] = count
+	} else {
+		log.Printf("Rejected photo count failed: %v", err)
 	}
 
 	s.renderTemplate(w, "home", data)
 }
 
 func (s *Server) handlePhotoDetail(w http.ResponseWriter, r *http.Request) {
-	// Extract photo ID from URL: /photo/:id
-	idStr := strings.TrimPrefix(r.URL.Path, "/photo/")
+	// Extract photo ID from URL: /photo/:id, /photo/:id/similar, /photo/:id/place
+	// or /photo/:id/sameday
+	idStr, view, hasView := strings.Cut(strings.TrimPrefix(r.URL.Path, "/photo/"), "/")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		http.Error(w, "Invalid photo ID", http.StatusBadRequest)
+		s.renderNotFound(w, fmt.Sprintf("%q is not a photo ID.", idStr), "")
+		return
+	}
+	if hasView {
+		switch view {
+		case "similar":
+			s.handleSimilar(w, r, id)
+		case "place":
+			s.handlePlace(w, r, id)
+		case "sameday":
+			s.handleSameDay(w, r, id)
+		case "representative":
+			s.handleRepresentative(w, r, id)
+		default:
+			s.renderNotFound(w, fmt.Sprintf("Photo %d has no %q view.", id, view), "")
+		}
 		return
 	}
 
 	photo, err := s.repo.GetPhotoByID(id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		s.renderNotFound(w, fmt.Sprintf("Photo %d is not in the library.", id), "")
 		return
 	}
 
 	backLink := r.Referer()
 	if backLink == "" {
-		backLink = "/"
+		backLink = s.path("/")
+	}
+
+	// Arriving from a grid, the query string carries its filters: prev/next
+	// then step through that grid's order instead of the whole library's
+	var photoQuery template.URL
+	var position, total int
+	if r.URL.RawQuery != "" {
+		params, err := s.urlMapper.ParsePath("/photos", r.URL.RawQuery)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		strip, err := s.engine.Filmstrip(params, id, 3)
+		if err != nil {
+			log.Printf("Failed to build filmstrip for photo %d: %v", id, err)
+		}
+		if strip != nil {
+			photo.PrevID, photo.NextID = strip.PrevID, strip.NextID
+			photoQuery = s.photoQuery(params)
+			position, total = strip.Position+1, strip.Total
+
+			// Back to the grid page holding this photo
+			grid := params
+			if grid.Limit <= 0 {
+				grid.Limit = 50 // Engine.Query's default page size
+			}
+			grid.Offset = strip.Position / grid.Limit * grid.Limit
+			backLink = s.urlMapper.BuildFullURL(grid)
+		}
+	}
+
+	rawExif, err := s.repo.GetPhotoExif(id)
+	if err != nil {
+		log.Printf("Failed to load raw EXIF for photo %d: %v", id, err)
+	}
+
+	if s.recentViews != nil {
+		s.recentViews.add(id)
+	}
+
+	memberOf, err := s.db.PhotoCollections(id)
+	if err != nil {
+		log.Printf("Failed to load collections for photo %d: %v", id, err)
+	}
+	var allCollections []database.Collection
+	if s.allowEdits {
+		if allCollections, err = s.db.ListCollections(); err != nil {
+			log.Printf("Failed to list collections: %v", err)
+		}
+	}
+
+	// Collapsed bursts hide this frame when another one stands for the burst
+	burstGroup, representativeID, err := s.db.BurstRepresentativeOf(id)
+	if err != nil {
+		log.Printf("Failed to look up burst representative for photo %d: %v", id, err)
 	}
 
 	data := map[string]interface{}{
-		"Title":    "Photo Detail",
-		"Photo":    photo,
-		"BackLink": backLink,
+		"Title":          "Photo Detail",
+		"Photo":          photo,
+		"RawExif":        rawExif,
+		"BackLink":       backLink,
+		"PhotoQuery":     photoQuery,
+		"Position":       position,
+		"Total":          total,
+		"Collections":    memberOf,
+		"AllCollections": allCollections,
+		"AllowEdits":     s.allowEdits,
+	}
+	if photo.Title != "" {
+		data["Title"] = photo.Title
+	}
+	if burstGroup != "" && representativeID != id {
+		data["BurstGroup"] = burstGroup
+		data["RepresentativeID"] = representativeID
 	}
 
 	s.renderTemplate(w, "detail", data)
 }
 
+// exifTagJSON is the JSON shape of a raw EXIF tag
+type exifTagJSON struct {
+	IFD   string `json:"ifd"`
+	Tag   string `json:"tag"`
+	Value string `json:"value"`
+}
+
+func (s *Server) handlePhotoAPI(w http.ResponseWriter, r *http.Request) {
+	// Parse: /api/photo/:id/:resource
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/photo/"), "/")
+	if len(parts) != 2 {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		http.Error(w, "Invalid photo ID", http.StatusBadRequest)
+		return
+	}
+
+	switch parts[1] {
+	case "exif":
+		s.handlePhotoExif(w, r, id)
+	case "filmstrip":
+		s.handlePhotoFilmstrip(w, r, id)
+	case "rating":
+		s.handlePhotoRating(w, r, id)
+	case "reject":
+		s.handlePhotoReject(w, r, id)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// handlePhotoExif returns the complete raw EXIF tag set for a photo as JSON
+func (s *Server) handlePhotoExif(w http.ResponseWriter, r *http.Request, id int) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if _, err := s.repo.GetPhotoByID(id); err != nil {
+		http.Error(w, "Photo not found", http.StatusNotFound)
+		return
+	}
+
+	entries, err := s.repo.GetPhotoExif(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	tags := make([]exifTagJSON, 0, len(entries))
+	for _, entry := range entries {
+		tags = append(tags, exifTagJSON{IFD: entry.IFD, Tag: entry.Tag, Value: entry.Value})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"photo_id": id,
+		"tags":     tags,
+	}); err != nil {
+		log.Printf("Failed to encode EXIF for photo %d: %v", id, err)
+	}
+}
+
+// photoJSON is one photo of a filmstrip or photo list response
+type photoJSON struct {
+	ID        int    `json:"id"`
+	URL       string `json:"url"`       // Detail page, keeping the filter
+	Thumbnail string `json:"thumbnail"` // 256px thumbnail
+	DateTaken string `json:"date_taken,omitempty"`
+}
+
+// photoSummaryJSON is one photo of an /api/photos response: photoJSON and
+// the rest of the query engine's PhotoSummary. Zero values are left out.
+type photoSummaryJSON struct {
+	photoJSON
+	Path            string   `json:"path"`
+	CameraMake      string   `json:"camera_make,omitempty"`
+	CameraModel     string   `json:"camera_model,omitempty"`
+	Lens            string   `json:"lens,omitempty"`
+	ISO             int      `json:"iso,omitempty"`
+	Aperture        float64  `json:"aperture,omitempty"`
+	ShutterSpeed    string   `json:"shutter_speed,omitempty"`
+	FocalLength     float64  `json:"focal_length,omitempty"`
+	FocalLength35mm int      `json:"focal_length_35mm,omitempty"`
+	Width           int      `json:"width,omitempty"`
+	Height          int      `json:"height,omitempty"`
+	TimeOfDay       string   `json:"time_of_day,omitempty"`
+	Season          string   `json:"season,omitempty"`
+	FocalCategory   string   `json:"focal_category,omitempty"`
+	BurstGroupID    string   `json:"burst_group_id,omitempty"`
+	BurstRep        bool     `json:"burst_representative,omitempty"`
+	Latitude        *float64 `json:"latitude,omitempty"`
+	Longitude       *float64 `json:"longitude,omitempty"`
+	Rating          int      `json:"rating,omitempty"`
+}
+
+// handlePhotoFilmstrip returns the n photos (default 7) around a photo
+// within the grid selected by the same filter query string as /photos:
+// /api/photo/:id/filmstrip?color=blue&n=9. With no filters that is the whole
+// library, newest first.
+func (s *Server) handlePhotoFilmstrip(w http.ResponseWriter, r *http.Request, id int) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	values := r.URL.Query()
+	size := query.DefaultFilmstripSize
+	if n := values.Get("n"); n != "" {
+		var err error
+		if size, err = strconv.Atoi(n); err != nil || size < 1 {
+			http.Error(w, "Invalid n", http.StatusBadRequest)
+			return
+		}
+		values.Del("n")
+	}
+	params, err := s.urlMapper.ParsePath("/photos", values.Encode())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	start := time.Now()
+	strip, err := s.engine.Filmstrip(params, id, size)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if strip == nil {
+		setQueryHeaders(w, start, -1)
+		http.Error(w, "Photo not found in this filter", http.StatusNotFound)
+		return
+	}
+	setQueryHeaders(w, start, strip.Total)
+
+	photoQuery := string(s.photoQuery(params))
+	photos := make([]photoJSON, 0, len(strip.Photos))
+	for _, p := range strip.Photos {
+		photo := photoJSON{
+			ID:        p.ID,
+			URL:       s.path(fmt.Sprintf("/photo/%d%s", p.ID, photoQuery)),
+			Thumbnail: s.path(fmt.Sprintf("/api/thumbnail/%d/256?v=%d", p.ID, p.IndexedAt.Unix())),
+		}
+		if !p.DateTaken.IsZero() {
+			photo.DateTaken = p.DateTaken.Format(time.RFC3339)
+		}
+		photos = append(photos, photo)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"photo_id": id,
+		"position": strip.Position,
+		"total":    strip.Total,
+		"current":  strip.Current,
+		"prev_id":  strip.PrevID,
+		"next_id":  strip.NextID,
+		"photos":   photos,
+	}); err != nil {
+		log.Printf("Failed to encode filmstrip for photo %d: %v", id, err)
+	}
+}
+
+// handlePhotosAPI returns one page of the photos matching the filters in the
+// query string as JSON. Pages are keyset-paginated: next_cursor holds the
+// after_id and after_date that fetch the following page, which stays
+// consistent while photos are being indexed. That needs date order (the
+// default). With ?page= it pages by offset like the HTML grid instead, in
+// any sort order. With ?facets=1 the response also carries every facet, as
+// the grid shows them.
+func (s *Server) handlePhotosAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	values := r.URL.Query()
+	afterID, afterDate := values.Get("after_id"), values.Get("after_date")
+	paged, withFacets := values.Get("page") != "", values.Get("facets") == "1"
+	values.Del("after_id")
+	values.Del("after_date")
+	values.Del("facets")
+	params, err := s.urlMapper.ParsePath("/photos", values.Encode())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if params.Limit < 1 {
+		http.Error(w, "limit must be at least 1", http.StatusBadRequest)
+		return
+	}
+	if paged {
+		if afterID != "" {
+			http.Error(w, "Use either page or after_id, not both", http.StatusBadRequest)
+			return
+		}
+		applyPage(r, &params)
+	} else if afterID != "" {
+		if params.AfterID, err = strconv.Atoi(afterID); err != nil || params.AfterID < 1 {
+			http.Error(w, "Invalid after_id", http.StatusBadRequest)
+			return
+		}
+		params.AfterDate = afterDate
+	}
+	if byDate, _ := query.SortsByDate(params); !byDate && !paged {
+		http.Error(w, "Paging by cursor needs date order; remove the sort parameter or page with page=", http.StatusBadRequest)
+		return
+	}
+
+	start := time.Now()
+	result, err := s.engine.Query(params)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var facets *query.FacetCollection
+	if withFacets {
+		if facets, err = s.engine.ComputeFacets(params); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	setQueryHeaders(w, start, result.Total)
+
+	photoQuery := string(s.photoQuery(params))
+	photos := make([]photoSummaryJSON, 0, len(result.Photos))
+	for _, p := range result.Photos {
+		photo := photoSummaryJSON{
+			photoJSON: photoJSON{
+				ID:        p.ID,
+				URL:       s.path(fmt.Sprintf("/photo/%d%s", p.ID, photoQuery)),
+				Thumbnail: s.path(fmt.Sprintf("/api/thumbnail/%d/256?v=%d", p.ID, p.IndexedAt.Unix())),
+			},
+			Path:            p.FilePath,
+			CameraMake:      p.CameraMake,
+			CameraModel:     p.CameraModel,
+			Lens:            p.LensModel,
+			ISO:             p.ISO,
+			Aperture:        p.Aperture,
+			ShutterSpeed:    p.ShutterSpeed,
+			FocalLength:     p.FocalLength,
+			FocalLength35mm: p.FocalLength35mm,
+			Width:           p.Width,
+			Height:          p.Height,
+			TimeOfDay:       p.TimeOfDay,
+			Season:          p.Season,
+			FocalCategory:   p.FocalCategory,
+			BurstGroupID:    p.BurstGroupID,
+			BurstRep:        p.IsBurstRep,
+			Rating:          p.Rating,
+		}
+		if !p.DateTaken.IsZero() {
+			photo.DateTaken = p.DateTaken.Format(time.RFC3339)
+		}
+		if p.HasGPS {
+			photo.Latitude, photo.Longitude = &p.Latitude, &p.Longitude
+		}
+		photos = append(photos, photo)
+	}
+
+	response := map[string]interface{}{
+		"photos":   photos,
+		"total":    result.Total,
+		"limit":    result.Limit,
+		"has_more": result.HasMore,
+	}
+	if paged {
+		page := result.Offset/result.Limit + 1
+		response["page"] = page
+		response["pages"] = (result.Total + result.Limit - 1) / result.Limit
+		link := func(page int) string {
+			q := r.URL.Query()
+			q.Set("page", strconv.Itoa(page))
+			return s.path("/api/photos?" + q.Encode())
+		}
+		if result.HasMore {
+			response["next"] = link(page + 1)
+		}
+		if page > 1 {
+			response["prev"] = link(page - 1)
+		}
+	} else {
+		response["next_cursor"] = result.NextCursor
+		if c := result.NextCursor; c != nil {
+			next := r.URL.Query()
+			next.Set("after_id", strconv.Itoa(c.AfterID))
+			next.Set("after_date", c.AfterDate)
+			response["next"] = s.path("/api/photos?" + next.Encode())
+		}
+	}
+	if facets != nil {
+		list := []facetJSON{}
+		for _, f := range facets.All() {
+			if f == nil {
+				continue
+			}
+			values := make([]facetValueJSON, 0, len(f.Values))
+			for _, v := range f.Values {
+				values = append(values, facetValueJSON{Value: v.Value, Label: v.Label, Count: v.Count, Selected: v.Selected, URL: v.URL})
+			}
+			list = append(list, facetJSON{Name: f.Name, Label: f.Label, Values: values})
+		}
+		response["facets"] = list
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Failed to encode photo list: %v", err)
+	}
+}
+
+// facetValueJSON is one value of a facet response
+type facetValueJSON struct {
+	Value    string `json:"value"`
+	Label    string `json:"label"`
+	Count    int    `json:"count"`
+	Selected bool   `json:"selected"`
+	URL      string `json:"url"` // Grid with this value toggled
+}
+
+// facetJSON is one facet of a photo list response
+type facetJSON struct {
+	Name   string           `json:"name"`
+	Label  string           `json:"label"`
+	Values []facetValueJSON `json:"values"`
+}
+
+// handleFacetAPI returns a single facet's values for the filters in the
+// query string, e.g. /api/facet/camera?year=2024, without computing the
+// other facets. Values past the facet limit are summed into "other".
+func (s *Server) handleFacetAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, "/api/facet/")
+	params, err := s.urlMapper.ParsePath("/photos", r.URL.RawQuery)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	start := time.Now()
+	facet, ok, err := s.engine.ComputeFacet(name, params)
+	if !ok {
+		http.Error(w, fmt.Sprintf("Unknown facet %q; expected one of: %s", name, strings.Join(query.FacetNames(), ", ")), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	setQueryHeaders(w, start, -1)
+	setFacetDisabledHeader(w, facet)
+
+	values := make([]facetValueJSON, 0, len(facet.Values))
+	for _, v := range facet.Values {
+		values = append(values, facetValueJSON{Value: v.Value, Label: v.Label, Count: v.Count, Selected: v.Selected, URL: v.URL})
+	}
+	response := map[string]interface{}{
+		"name":   facet.Name,
+		"label":  facet.Label,
+		"values": values,
+	}
+	if facet.Other != nil {
+		response["other"] = map[string]interface{}{
+			"count":  facet.Other.Count,
+			"hidden": facet.HiddenValues,
+			"url":    facet.Other.URL,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Failed to encode %s facet: %v", name, err)
+	}
+}
+
+// photoQuery is the query string that carries a grid's filters and sort
+// order onto its detail links, including the leading "?", or "" for the
+// unfiltered library. Paging is dropped: Filmstrip finds the photo's page.
+func (s *Server) photoQuery(params query.QueryParams) template.URL {
+	params.Offset = 0
+	params.Density = ""
+	return template.URL(s.urlMapper.BuildQueryString(params))
+}
+
 func (s *Server) handleThumbnail(w http.ResponseWriter, r *http.Request) {
 	// Parse: /api/thumbnail/:id/:size
 	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/thumbnail/"), "/")
@@ -190,7 +930,7 @@ func (s *Server) handleThumbnail(w http.ResponseWriter, r *http.Request) {
 
 	id, err := strconv.Atoi(parts[0])
 	if err != nil {
-		http.Error(w, "Invalid photo ID", http.StatusBadRequest)
+		s.renderNotFound(w, fmt.Sprintf("%q is not a photo ID.", parts[0]), "")
 		return
 	}
 
@@ -202,7 +942,7 @@ func (s *Server) handleThumbnail(w http.ResponseWriter, r *http.Request) {
 
 	thumbnail, indexedAt, err := s.repo.GetThumbnailWithTimestamp(id, size)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		s.renderNotFound(w, fmt.Sprintf("Photo %d has no %spx thumbnail.", id, size), "")
 		return
 	}
 
@@ -222,7 +962,11 @@ func (s *Server) handleThumbnail(w http.ResponseWriter, r *http.Request) {
 	// Use a shorter cache time and rely on ETags for efficient caching
 	// This prevents stale images when navigating between different filtered views
 	w.Header().Set("Content-Type", "image/jpeg")
-	w.Header().Set("Cache-Control", "public, max-age=3600, must-revalidate")
+	if s.immutableThumbnails && r.URL.Query().Get("v") == strconv.FormatInt(indexedAt.Unix(), 10) {
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		w.Header().Set("Cache-Control", "public, max-age=3600, must-revalidate")
+	}
 	w.Header().Set("ETag", etag)
 
 	w.Write(thumbnail)
@@ -288,7 +1032,7 @@ func (s *Server) handleDateRoute(w http.ResponseWriter, r *http.Request, parts [
 	var total int
 	var err error
 	var title string
-	backLink := "/dates"
+	backLink := s.path("/dates")
 
 	switch len(parts) {
 	case 1:
@@ -306,7 +1050,7 @@ func (s *Server) handleDateRoute(w http.ResponseWriter, r *http.Request, parts [
 		month, _ := strconv.Atoi(parts[1])
 		photos, total, err = s.repo.GetPhotosByMonth(year, month, limit, offset)
 		title = fmt.Sprintf("%d/%02d", year, month)
-		backLink = fmt.Sprintf("/%d", year)
+		backLink = s.path(fmt.Sprintf("/%d", year))
 	case 3:
 		// Day view
 		year, _ := strconv.Atoi(parts[0])
@@ -314,7 +1058,7 @@ func (s *Server) handleDateRoute(w http.ResponseWriter, r *http.Request, parts [
 		day, _ := strconv.Atoi(parts[2])
 		photos, total, err = s.repo.GetPhotosByDay(year, month, day, limit, offset)
 		title = fmt.Sprintf("%d/%02d/%02d", year, month, day)
-		backLink = fmt.Sprintf("/%d/%02d", year, month)
+		backLink = s.path(fmt.Sprintf("/%d/%02d", year, month))
 	}
 
 	if err != nil {
@@ -325,10 +1069,10 @@ func (s *Server) handleDateRoute(w http.ResponseWriter, r *http.Request, parts [
 	// Calculate pagination links
 	var prevPage, nextPage string
 	if page > 1 {
-		prevPage = fmt.Sprintf("%s?page=%d", r.URL.Path, page-1)
+		prevPage = fmt.Sprintf("%s?page=%d", s.path(r.URL.Path), page-1)
 	}
 	if offset+limit < total {
-		nextPage = fmt.Sprintf("%s?page=%d", r.URL.Path, page+1)
+		nextPage = fmt.Sprintf("%s?page=%d", s.path(r.URL.Path), page+1)
 	}
 
 	data := map[string]interface{}{
@@ -374,10 +1118,10 @@ func (s *Server) handleCameraRoute(w http.ResponseWriter, r *http.Request) {
 	// Calculate pagination links
 	var prevPage, nextPage string
 	if page > 1 {
-		prevPage = fmt.Sprintf("%s?page=%d", r.URL.Path, page-1)
+		prevPage = fmt.Sprintf("%s?page=%d", s.path(r.URL.Path), page-1)
 	}
 	if offset+limit < total {
-		nextPage = fmt.Sprintf("%s?page=%d", r.URL.Path, page+1)
+		nextPage = fmt.Sprintf("%s?page=%d", s.path(r.URL.Path), page+1)
 	}
 
 	data := map[string]interface{}{
@@ -387,7 +1131,7 @@ func (s *Server) handleCameraRoute(w http.ResponseWriter, r *http.Request) {
 		"Page":       page,
 		"PrevPage":   prevPage,
 		"NextPage":   nextPage,
-		"BackLink":   "/cameras",
+		"BackLink":   s.path("/cameras"),
 	}
 
 	s.renderTemplate(w, "grid", data)
@@ -420,10 +1164,10 @@ func (s *Server) handleLensRoute(w http.ResponseWriter, r *http.Request) {
 	// Calculate pagination links
 	var prevPage, nextPage string
 	if page > 1 {
-		prevPage = fmt.Sprintf("%s?page=%d", r.URL.Path, page-1)
+		prevPage = fmt.Sprintf("%s?page=%d", s.path(r.URL.Path), page-1)
 	}
 	if offset+limit < total {
-		nextPage = fmt.Sprintf("%s?page=%d", r.URL.Path, page+1)
+		nextPage = fmt.Sprintf("%s?page=%d", s.path(r.URL.Path), page+1)
 	}
 
 	data := map[string]interface{}{
@@ -433,7 +1177,7 @@ func (s *Server) handleLensRoute(w http.ResponseWriter, r *http.Request) {
 		"Page":       page,
 		"PrevPage":   prevPage,
 		"NextPage":   nextPage,
-		"BackLink":   "/lenses",
+		"BackLink":   s.path("/lenses"),
 	}
 
 	s.renderTemplate(w, "grid", data)
@@ -442,27 +1186,21 @@ func (s *Server) handleLensRoute(w http.ResponseWriter, r *http.Request) {
 // handleQuery handles query-based photo browsing using the query engine
 func (s *Server) handleQuery(w http.ResponseWriter, r *http.Request) {
 	// Parse URL path and query string into QueryParams
-	params, err := s.urlMapper.ParsePath(r.URL.Path, r.URL.RawQuery)
+	params, err := s.urlMapper.ParsePath(r.URL.EscapedPath(), r.URL.RawQuery)
 	if err != nil {
-		log.Printf("FACET_404: URL parse failed - path=%s query=%s error=%v", r.URL.Path, r.URL.RawQuery, err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Warn("FACET_404", "reason", "URL parse failed", "path", r.URL.Path, "query", r.URL.RawQuery, "error", err)
+		s.renderNotFound(w, fmt.Sprintf("These filters name no photos: %v.", err), s.path("/photos"))
 		return
 	}
 
 	// Handle pagination
-	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
-		page, _ := strconv.Atoi(pageStr)
-		if page < 1 {
-			page = 1
-		}
-		params.Offset = (page - 1) * params.Limit
-	}
+	applyPage(r, &params)
 
 	// Execute query
-	result, err := s.engine.Query(params)
+	start := time.Now()
+	result, err := s.engine.QueryCards(params)
 	if err != nil {
-		log.Printf("FACET_ERROR: Query execution failed - path=%s params=%+v error=%v",
-			r.URL.Path, params, err)
+		slog.Error("FACET_ERROR", "reason", "query execution failed", "path", r.URL.Path, "params", params, "error", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -478,14 +1216,16 @@ func (s *Server) handleQuery(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	setQueryHeaders(w, start, result.Total)
+	setFacetDisabledHeader(w, facets.All()...)
+
 	// Log facet state transitions (structured logging for monitoring)
 	// This logs all available transitions with their expected result counts
 	query.LogTransitionsSummary(params, facets, result.Total)
 
 	// Log when a facet navigation results in no photos (effectively a 404 from user perspective)
 	if result.Total == 0 {
-		log.Printf("FACET_404: No results found - path=%s query=%s params=%+v",
-			r.URL.Path, r.URL.RawQuery, params)
+		slog.Warn("FACET_404", "reason", "no results found", "path", r.URL.Path, "query", r.URL.RawQuery, "params", params)
 		// Log additional diagnostic information to detect bugs
 		query.LogSuspiciousZeroResults(params, facets)
 	}
@@ -527,9 +1267,16 @@ func (s *Server) handleQuery(w http.ResponseWriter, r *http.Request) {
 	} else if len(params.ColourName) > 0 {
 		title = strings.Title(params.ColourName[0]) + " Photos"
 	} else if len(params.TimeOfDay) > 0 {
-		title = strings.Title(params.TimeOfDay[0]) + " Photos"
+		title = query.TimeOfDayLabel(params.TimeOfDay[0]) + " Photos"
+	}
+	if params.CollectionID != nil {
+		if c, err := s.db.GetCollection(*params.CollectionID); err == nil {
+			title = c.Name
+		}
 	}
 
+	density, densities := s.densityOptions(params)
+
 	data := map[string]interface{}{
 		"Title":         title,
 		"Photos":        result.Photos,
@@ -540,23 +1287,274 @@ func (s *Server) handleQuery(w http.ResponseWriter, r *http.Request) {
 		"Facets":        facets,
 		"Breadcrumbs":   breadcrumbs,
 		"ActiveFilters": activeFilters,
-		"BackLink":      "/",
+		"BackLink":      s.path("/"),
+		"Density":       density,
+		"Densities":     densities,
+		"Layouts":       s.layoutOptions(params),
+		"Justified":     params.Layout == "justified",
+		"PhotoQuery":    s.photoQuery(params),
+		"ColourMatch":   s.colourMatchOptions(params),
+		"Related":       query.RelatedEquipment(facets, params, relatedSuggestions),
+
+		"AccessibleColours": s.accessibleColours,
+	}
+	if s.newBadges {
+		data["NewSince"] = s.newSince(w, r)
 	}
 
 	s.renderTemplate(w, "grid", data)
 }
 
-// ActiveFilter represents a currently applied filter
-type ActiveFilter struct {
-	Type      string // "color", "year", "camera", etc.
-	Label     string // "Blue", "2024", "Canon EOS R5"
-	RemoveURL string // URL to remove this filter
-}
-
-// buildActiveFilters extracts active filters from query params
-func (s *Server) buildActiveFilters(params query.QueryParams) []ActiveFilter {
-	filters := []ActiveFilter{}
-
+// applyPage turns the 1-based ?page= parameter into an offset on params
+func applyPage(r *http.Request, params *query.QueryParams) {
+	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
+		page, _ := strconv.Atoi(pageStr)
+		if page < 1 {
+			page = 1
+		}
+		params.Offset = (page - 1) * params.Limit
+	}
+}
+
+// handleGridFragment renders one page of photo cards without the layout or
+// facets, so a client can fetch the next page and append it to the grid.
+// It takes the same filters and page parameter as /photos. The Has-More
+// response header says whether a further page exists; past the last page
+// the body is empty.
+func (s *Server) handleGridFragment(w http.ResponseWriter, r *http.Request) {
+	params, err := s.urlMapper.ParsePath("/photos", r.URL.RawQuery)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	applyPage(r, &params)
+
+	start := time.Now()
+	result, err := s.engine.QueryCards(params)
+	if err != nil {
+		slog.Error("FACET_ERROR", "reason", "grid fragment query failed", "query", r.URL.RawQuery, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	setQueryHeaders(w, start, result.Total)
+
+	density, _ := s.densityOptions(params)
+	data := map[string]interface{}{
+		"Photos":     result.Photos,
+		"Density":    density,
+		"Justified":  params.Layout == "justified",
+		"PhotoQuery": s.photoQuery(params),
+	}
+	if s.newBadges {
+		data["NewSince"] = s.newSince(w, r)
+	}
+
+	// Execute a clone: html/template cannot Clone a set once it has been
+	// executed, and renderTemplate clones templates for every page
+	set, err := s.templateSet()
+	if err != nil {
+		log.Printf("Template load error: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	tmpl, err := set.Clone()
+	if err != nil {
+		log.Printf("Template clone error: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	tmpl.Funcs(templateFuncs(s.basePath))
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Has-More", strconv.FormatBool(result.HasMore))
+	if err := tmpl.ExecuteTemplate(w, "photo-cards", data); err != nil {
+		log.Printf("Template execution error: %v", err)
+	}
+}
+
+// heatmapCell is one weekday/hour cell of the analytics heatmap
+type heatmapCell struct {
+	Count     int
+	Intensity string // CSS alpha, 0-1
+}
+
+// heatmapRow is one weekday of the analytics heatmap
+type heatmapRow struct {
+	Weekday string
+	Cells   []heatmapCell
+}
+
+// handleAnalytics renders a weekday × hour heatmap of when photos were taken,
+// honouring the same filter query string as /photos
+func (s *Server) handleAnalytics(w http.ResponseWriter, r *http.Request) {
+	params, err := s.urlMapper.ParsePath("/photos", r.URL.RawQuery)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	matrix, err := s.engine.ComputeWeekdayHour(params)
+	if err != nil {
+		slog.Error("FACET_ERROR", "reason", "analytics query failed", "query", r.URL.RawQuery, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rows := make([]heatmapRow, 0, 7)
+	for day, name := range query.WeekdayNames {
+		row := heatmapRow{Weekday: name, Cells: make([]heatmapCell, 24)}
+		for hour, count := range matrix.Counts[day] {
+			intensity := 0.0
+			if matrix.MaxCount > 0 {
+				intensity = float64(count) / float64(matrix.MaxCount)
+			}
+			row.Cells[hour] = heatmapCell{Count: count, Intensity: fmt.Sprintf("%.2f", intensity)}
+		}
+		rows = append(rows, row)
+	}
+
+	hours := make([]int, 24)
+	for i := range hours {
+		hours[i] = i
+	}
+
+	data := map[string]interface{}{
+		"Title":    "Analytics",
+		"Matrix":   matrix,
+		"Rows":     rows,
+		"Hours":    hours,
+		"Filtered": r.URL.RawQuery != "",
+		"BackLink": s.urlMapper.BuildFullURL(params),
+	}
+
+	s.renderTemplate(w, "analytics", data)
+}
+
+// seasonCell is one year/season cell of the seasons matrix
+type seasonCell struct {
+	Count     int
+	Intensity string // CSS alpha, 0-1
+	URL       string // Grid filtered to this year and season
+}
+
+// seasonRow is one year of the seasons matrix
+type seasonRow struct {
+	Year    int
+	YearURL string
+	Total   int
+	Cells   []seasonCell
+}
+
+// handleSeasons renders a year × season matrix of photo counts, honouring
+// the same filter query string as /photos. Each cell links to the grid
+// narrowed to that year and season.
+func (s *Server) handleSeasons(w http.ResponseWriter, r *http.Request) {
+	params, err := s.urlMapper.ParsePath("/photos", r.URL.RawQuery)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	matrix, err := s.engine.ComputeYearSeason(params)
+	if err != nil {
+		slog.Error("FACET_ERROR", "reason", "seasons query failed", "query", r.URL.RawQuery, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	base := params
+	base.Offset = 0
+	base.Season = nil
+
+	rows := make([]seasonRow, 0, len(matrix.Rows))
+	for _, yr := range matrix.Rows {
+		year := yr.Year
+		p := base
+		p.Year = &year
+		row := seasonRow{Year: year, YearURL: s.urlMapper.BuildFullURL(p), Total: yr.Total, Cells: make([]seasonCell, len(query.SeasonNames))}
+		for i, season := range query.SeasonNames {
+			count := yr.Counts[i]
+			intensity := 0.0
+			if matrix.MaxCount > 0 {
+				intensity = float64(count) / float64(matrix.MaxCount)
+			}
+			cp := p
+			cp.Season = []string{season}
+			row.Cells[i] = seasonCell{Count: count, Intensity: fmt.Sprintf("%.2f", intensity), URL: s.urlMapper.BuildFullURL(cp)}
+		}
+		rows = append(rows, row)
+	}
+
+	data := map[string]interface{}{
+		"Title":    "Seasons",
+		"Matrix":   matrix,
+		"Rows":     rows,
+		"Seasons":  query.SeasonNames,
+		"Filtered": r.URL.RawQuery != "",
+		"BackLink": s.urlMapper.BuildFullURL(params),
+	}
+
+	s.renderTemplate(w, "seasons", data)
+}
+
+// handleErrors lists files the indexer failed on, filtered by ?q= against
+// the path and error message
+func (s *Server) handleErrors(w http.ResponseWriter, r *http.Request) {
+	match := strings.TrimSpace(r.URL.Query().Get("q"))
+	indexErrors, err := s.db.ListIndexErrors(match)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := map[string]interface{}{
+		"Title":    "Indexing errors",
+		"Errors":   indexErrors,
+		"Query":    match,
+		"BackLink": s.path("/"),
+	}
+
+	s.renderTemplate(w, "errors", data)
+}
+
+// colourMatchOption is one link of the colour facet's any/all switch
+type colourMatchOption struct {
+	Label    string
+	URL      string
+	Selected bool
+}
+
+// colourMatchOptions returns the switch between matching any and all of the
+// selected colours, or nil while no colour is selected
+func (s *Server) colourMatchOptions(params query.QueryParams) []colourMatchOption {
+	if len(params.ColourName) == 0 {
+		return nil
+	}
+	all := params.ColourMatchMode == query.ColourMatchAll
+	options := []colourMatchOption{
+		{Label: "Any", Selected: !all},
+		{Label: "All", Selected: all},
+	}
+	for i, mode := range []string{"", query.ColourMatchAll} {
+		p := params
+		p.ColourMatchMode = mode
+		p.Offset = 0
+		options[i].URL = s.urlMapper.BuildFullURL(p)
+	}
+	return options
+}
+
+// ActiveFilter represents a currently applied filter
+type ActiveFilter struct {
+	Type      string // "color", "year", "camera", etc.
+	Label     string // "Blue", "2024", "Canon EOS R5"
+	RemoveURL string // URL to remove this filter
+}
+
+// buildActiveFilters extracts active filters from query params
+func (s *Server) buildActiveFilters(params query.QueryParams) []ActiveFilter {
+	filters := []ActiveFilter{}
+
 	// Colour filter
 	if len(params.ColourName) > 0 {
 		for _, colour := range params.ColourName {
@@ -569,6 +1567,15 @@ func (s *Server) buildActiveFilters(params query.QueryParams) []ActiveFilter {
 			})
 		}
 	}
+	if params.ColourMatchMode == query.ColourMatchAll {
+		p := params
+		p.ColourMatchMode = ""
+		filters = append(filters, ActiveFilter{
+			Type:      "color_match",
+			Label:     "All selected colours",
+			RemoveURL: s.urlMapper.BuildFullURL(p),
+		})
+	}
 
 	// Year filter
 	if params.Year != nil {
@@ -637,6 +1644,43 @@ func (s *Server) buildActiveFilters(params query.QueryParams) []ActiveFilter {
 		}
 	}
 
+	// Collection filter
+	if params.CollectionID != nil {
+		p := params
+		p.CollectionID = nil
+		label := fmt.Sprintf("Collection %d", *params.CollectionID)
+		if c, err := s.db.GetCollection(*params.CollectionID); err == nil {
+			label = "Collection: " + c.Name
+		}
+		filters = append(filters, ActiveFilter{
+			Type:      "collection",
+			Label:     label,
+			RemoveURL: s.urlMapper.BuildFullURL(p),
+		})
+	}
+
+	// Tag filters
+	for _, tag := range params.Tag {
+		p := params
+		p.Tag = removeStringFromSlice(p.Tag, tag)
+		filters = append(filters, ActiveFilter{
+			Type:      "tag",
+			Label:     "Tag: " + tag,
+			RemoveURL: s.urlMapper.BuildFullURL(p),
+		})
+	}
+
+	// Camera body filters (serial tokens; the serial itself is not in the URL)
+	for _, token := range params.CameraSerial {
+		p := params
+		p.CameraSerial = removeStringFromSlice(p.CameraSerial, token)
+		filters = append(filters, ActiveFilter{
+			Type:      "camera_serial",
+			Label:     "Body " + token,
+			RemoveURL: s.urlMapper.BuildFullURL(p),
+		})
+	}
+
 	// Time of Day filters
 	if len(params.TimeOfDay) > 0 {
 		for _, tod := range params.TimeOfDay {
@@ -644,7 +1688,7 @@ func (s *Server) buildActiveFilters(params query.QueryParams) []ActiveFilter {
 			p.TimeOfDay = removeStringFromSlice(p.TimeOfDay, tod)
 			filters = append(filters, ActiveFilter{
 				Type:      "time_of_day",
-				Label:     strings.Title(tod),
+				Label:     query.TimeOfDayLabel(tod),
 				RemoveURL: s.urlMapper.BuildFullURL(p),
 			})
 		}
@@ -663,6 +1707,17 @@ func (s *Server) buildActiveFilters(params query.QueryParams) []ActiveFilter {
 		}
 	}
 
+	// Weekday filters
+	for _, weekday := range params.Weekday {
+		p := params
+		p.Weekday = removeStringFromSlice(p.Weekday, weekday)
+		filters = append(filters, ActiveFilter{
+			Type:      "weekday",
+			Label:     strings.Title(weekday),
+			RemoveURL: s.urlMapper.BuildFullURL(p),
+		})
+	}
+
 	// Focal Category filters
 	if len(params.FocalCategory) > 0 {
 		for _, fc := range params.FocalCategory {
@@ -676,6 +1731,17 @@ func (s *Server) buildActiveFilters(params query.QueryParams) []ActiveFilter {
 		}
 	}
 
+	// Setup filters
+	for _, setup := range params.Setup {
+		p := params
+		p.Setup = removeStringFromSlice(p.Setup, setup)
+		filters = append(filters, ActiveFilter{
+			Type:      "setup",
+			Label:     s.engine.SetupLabel(setup),
+			RemoveURL: s.urlMapper.BuildFullURL(p),
+		})
+	}
+
 	// Shooting Condition filters
 	if len(params.ShootingCondition) > 0 {
 		for _, sc := range params.ShootingCondition {
@@ -689,6 +1755,191 @@ func (s *Server) buildActiveFilters(params query.QueryParams) []ActiveFilter {
 		}
 	}
 
+	// Exposure value range
+	if params.EVMin != nil || params.EVMax != nil {
+		p := params
+		p.EVMin = nil
+		p.EVMax = nil
+		var label string
+		switch {
+		case params.EVMin != nil && params.EVMax != nil:
+			label = fmt.Sprintf("EV %g–%g", *params.EVMin, *params.EVMax)
+		case params.EVMin != nil:
+			label = fmt.Sprintf("EV %g+", *params.EVMin)
+		default:
+			label = fmt.Sprintf("Below EV %g", *params.EVMax)
+		}
+		filters = append(filters, ActiveFilter{
+			Type:      "exposure_value",
+			Label:     label,
+			RemoveURL: s.urlMapper.BuildFullURL(p),
+		})
+	}
+
+	// Shutter speed range
+	if params.ShutterMin != nil || params.ShutterMax != nil {
+		p := params
+		p.ShutterMin = nil
+		p.ShutterMax = nil
+		var label string
+		switch {
+		case params.ShutterMin != nil && params.ShutterMax != nil:
+			label = fmt.Sprintf("Shutter %s–%s", query.FormatShutterSeconds(*params.ShutterMin), query.FormatShutterSeconds(*params.ShutterMax))
+		case params.ShutterMin != nil:
+			label = fmt.Sprintf("Shutter %s+", query.FormatShutterSeconds(*params.ShutterMin))
+		default:
+			label = fmt.Sprintf("Shutter faster than %s", query.FormatShutterSeconds(*params.ShutterMax))
+		}
+		filters = append(filters, ActiveFilter{
+			Type:      "shutter_speed",
+			Label:     label,
+			RemoveURL: s.urlMapper.BuildFullURL(p),
+		})
+	}
+
+	// File size range
+	if params.FileSizeMin != nil || params.FileSizeMax != nil {
+		p := params
+		p.FileSizeMin = nil
+		p.FileSizeMax = nil
+		var label string
+		switch {
+		case params.FileSizeMin != nil && params.FileSizeMax != nil:
+			label = fmt.Sprintf("Size %s–%s", query.FormatFileSize(*params.FileSizeMin), query.FormatFileSize(*params.FileSizeMax))
+		case params.FileSizeMin != nil:
+			label = fmt.Sprintf("Size %s+", query.FormatFileSize(*params.FileSizeMin))
+		default:
+			label = fmt.Sprintf("Size under %s", query.FormatFileSize(*params.FileSizeMax))
+		}
+		filters = append(filters, ActiveFilter{
+			Type:      "file_size",
+			Label:     label,
+			RemoveURL: s.urlMapper.BuildFullURL(p),
+		})
+	}
+
+	// GPS bounding box, as set by /photo/:id/place
+	if params.LatMin != nil || params.LatMax != nil || params.LonMin != nil || params.LonMax != nil {
+		p := params
+		p.LatMin, p.LatMax, p.LonMin, p.LonMax = nil, nil, nil, nil
+		filters = append(filters, ActiveFilter{
+			Type:      "location",
+			Label:     "Area " + boundsLabel("lat", params.LatMin, params.LatMax) + ", " + boundsLabel("lon", params.LonMin, params.LonMax),
+			RemoveURL: s.urlMapper.BuildFullURL(p),
+		})
+	}
+
+	// GPS presence
+	if params.HasGPS != nil {
+		p := params
+		p.HasGPS = nil
+		label := "No GPS"
+		if *params.HasGPS {
+			label = "Geotagged"
+		}
+		filters = append(filters, ActiveFilter{
+			Type:      "has_gps",
+			Label:     label,
+			RemoveURL: s.urlMapper.BuildFullURL(p),
+		})
+	}
+
+	// Altitude range
+	if params.AltMin != nil || params.AltMax != nil {
+		p := params
+		p.AltMin = nil
+		p.AltMax = nil
+		var label string
+		switch {
+		case params.AltMin != nil && params.AltMax != nil:
+			label = fmt.Sprintf("Altitude %.0f–%.0f m", *params.AltMin, *params.AltMax)
+		case params.AltMin != nil:
+			label = fmt.Sprintf("Altitude %.0f m+", *params.AltMin)
+		default:
+			label = fmt.Sprintf("Altitude up to %.0f m", *params.AltMax)
+		}
+		filters = append(filters, ActiveFilter{
+			Type:      "altitude",
+			Label:     label,
+			RemoveURL: s.urlMapper.BuildFullURL(p),
+		})
+	}
+
+	// Colour data filter
+	if params.HasColours != nil {
+		p := params
+		p.HasColours = nil
+		label := "No Colour Data"
+		if *params.HasColours {
+			label = "Has Colour Data"
+		}
+		filters = append(filters, ActiveFilter{
+			Type:      "has_colours",
+			Label:     label,
+			RemoveURL: s.urlMapper.BuildFullURL(p),
+		})
+	}
+
+	// Palette size
+	if params.ColourCountMin != nil || params.ColourCountMax != nil {
+		p := params
+		p.ColourCountMin = nil
+		p.ColourCountMax = nil
+		var label string
+		switch {
+		case params.ColourCountMin == nil && *params.ColourCountMax <= 1:
+			label = "Monochromatic"
+		case params.ColourCountMin != nil && params.ColourCountMax != nil:
+			label = fmt.Sprintf("%d–%d colours", *params.ColourCountMin, *params.ColourCountMax)
+		case params.ColourCountMin != nil:
+			label = fmt.Sprintf("%d+ colours", *params.ColourCountMin)
+		default:
+			label = fmt.Sprintf("Up to %d colours", *params.ColourCountMax)
+		}
+		filters = append(filters, ActiveFilter{
+			Type:      "palette",
+			Label:     label,
+			RemoveURL: s.urlMapper.BuildFullURL(p),
+		})
+	}
+
+	// File format filters
+	for _, ff := range params.FileFormat {
+		p := params
+		p.FileFormat = removeStringFromSlice(p.FileFormat, ff)
+		filters = append(filters, ActiveFilter{
+			Type:      "file_format",
+			Label:     strings.ToUpper(ff),
+			RemoveURL: s.urlMapper.BuildFullURL(p),
+		})
+	}
+
+	// Media type filters
+	for _, mt := range params.MediaType {
+		p := params
+		p.MediaType = removeStringFromSlice(p.MediaType, mt)
+		label := "Photos"
+		if mt == "video" {
+			label = "Videos"
+		}
+		filters = append(filters, ActiveFilter{
+			Type:      "media_type",
+			Label:     label,
+			RemoveURL: s.urlMapper.BuildFullURL(p),
+		})
+	}
+
+	// Colour space filters
+	for _, cs := range params.ColourSpace {
+		p := params
+		p.ColourSpace = removeStringFromSlice(p.ColourSpace, cs)
+		filters = append(filters, ActiveFilter{
+			Type:      "color_space",
+			Label:     cs,
+			RemoveURL: s.urlMapper.BuildFullURL(p),
+		})
+	}
+
 	// Burst filter
 	if params.InBurst != nil {
 		p := params
@@ -703,6 +1954,135 @@ func (s *Server) buildActiveFilters(params query.QueryParams) []ActiveFilter {
 			RemoveURL: s.urlMapper.BuildFullURL(p),
 		})
 	}
+	if params.BurstSizeMin != nil || params.BurstSizeMax != nil {
+		p := params
+		p.BurstSizeMin = nil
+		p.BurstSizeMax = nil
+		var label string
+		switch {
+		case params.BurstSizeMin != nil && params.BurstSizeMax != nil:
+			label = fmt.Sprintf("Bursts of %d–%d", *params.BurstSizeMin, *params.BurstSizeMax)
+		case params.BurstSizeMin != nil:
+			label = fmt.Sprintf("Bursts of %d+", *params.BurstSizeMin)
+		default:
+			label = fmt.Sprintf("Bursts of up to %d", *params.BurstSizeMax)
+		}
+		filters = append(filters, ActiveFilter{
+			Type:      "burst_size",
+			Label:     label,
+			RemoveURL: s.urlMapper.BuildFullURL(p),
+		})
+	}
+	if params.RatingMin != nil || params.RatingMax != nil {
+		p := params
+		p.RatingMin = nil
+		p.RatingMax = nil
+		filters = append(filters, ActiveFilter{
+			Type:      "rating",
+			Label:     ratingFilterLabel(params.RatingMin, params.RatingMax),
+			RemoveURL: s.urlMapper.BuildFullURL(p),
+		})
+	}
+	if params.BurstGroupID != nil {
+		p := params
+		p.BurstGroupID = nil
+		filters = append(filters, ActiveFilter{
+			Type:      "burst",
+			Label:     "Burst " + *params.BurstGroupID,
+			RemoveURL: s.urlMapper.BuildFullURL(p),
+		})
+	}
+	if params.CollapseBursts {
+		p := params
+		p.CollapseBursts = false
+		filters = append(filters, ActiveFilter{
+			Type:      "collapse_bursts",
+			Label:     "Bursts Collapsed",
+			RemoveURL: s.urlMapper.BuildFullURL(p),
+		})
+	}
+
+	// Bracket filter
+	if params.InBracket != nil {
+		p := params
+		p.InBracket = nil
+		label := "Not in Bracket"
+		if *params.InBracket {
+			label = "In Bracket"
+		}
+		filters = append(filters, ActiveFilter{
+			Type:      "in_bracket",
+			Label:     label,
+			RemoveURL: s.urlMapper.BuildFullURL(p),
+		})
+	}
+
+	// Session filter
+	if params.SessionID != nil {
+		p := params
+		p.SessionID = nil
+		filters = append(filters, ActiveFilter{
+			Type:      "session",
+			Label:     fmt.Sprintf("Session %d", *params.SessionID),
+			RemoveURL: s.urlMapper.BuildFullURL(p),
+		})
+	}
+
+	// Editing filters
+	for _, software := range params.Software {
+		p := params
+		p.Software = removeStringFromSlice(p.Software, software)
+		filters = append(filters, ActiveFilter{
+			Type:      "software",
+			Label:     "Software: " + software,
+			RemoveURL: s.urlMapper.BuildFullURL(p),
+		})
+	}
+	if params.Edited != nil {
+		p := params
+		p.Edited = nil
+		label := "Out of Camera"
+		if *params.Edited {
+			label = "Edited"
+		}
+		filters = append(filters, ActiveFilter{
+			Type:      "edited",
+			Label:     label,
+			RemoveURL: s.urlMapper.BuildFullURL(p),
+		})
+	}
+	if params.Caption != "" {
+		p := params
+		p.Caption = ""
+		filters = append(filters, ActiveFilter{
+			Type:      "caption",
+			Label:     fmt.Sprintf("Caption: “%s”", params.Caption),
+			RemoveURL: s.urlMapper.BuildFullURL(p),
+		})
+	}
+
+	// Culling
+	if params.Rejected != nil {
+		p := params
+		p.Rejected = nil
+		label := "Not rejected"
+		if *params.Rejected {
+			label = "Rejected"
+		}
+		filters = append(filters, ActiveFilter{
+			Type:      "rejected",
+			Label:     label,
+			RemoveURL: s.urlMapper.BuildFullURL(p),
+		})
+	} else if params.IncludeRejected {
+		p := params
+		p.IncludeRejected = false
+		filters = append(filters, ActiveFilter{
+			Type:      "include_rejected",
+			Label:     "Including rejected",
+			RemoveURL: s.urlMapper.BuildFullURL(p),
+		})
+	}
 
 	return filters
 }
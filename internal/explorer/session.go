@@ -0,0 +1,35 @@
+package explorer
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// handleSession shows a shooting session: /session/:id redirects to the grid
+// filtered to the session, oldest photo first
+func (s *Server) handleSession(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/session/"))
+	if err != nil || id <= 0 {
+		s.renderNotFound(w, "That is not a session ID.", "")
+		return
+	}
+
+	params, err := s.engine.Session(id)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		s.renderNotFound(w, fmt.Sprintf("Session %d is not in the library. Sessions are found by olsen analyze.", id), "")
+		return
+	case err != nil:
+		log.Printf("Session lookup for %d failed: %v", id, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	params.Limit = 50 // The grid's default page size
+	http.Redirect(w, r, s.urlMapper.BuildFullURL(params), http.StatusFound)
+}
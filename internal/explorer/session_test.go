@@ -0,0 +1,67 @@
+package explorer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adewale/olsen/internal/database"
+	"github.com/adewale/olsen/pkg/models"
+)
+
+func TestSessionView(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "session.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	for _, p := range []*models.PhotoMetadata{
+		{FilePath: "/a.jpg", FileHash: "a", DateTaken: time.Date(2024, 6, 1, 8, 0, 0, 0, time.UTC)},
+		{FilePath: "/b.jpg", FileHash: "b", DateTaken: time.Date(2024, 6, 1, 8, 10, 0, 0, time.UTC)},
+		{FilePath: "/c.jpg", FileHash: "c", DateTaken: time.Date(2024, 6, 1, 14, 0, 0, 0, time.UTC)},
+	} {
+		if err := db.InsertPhoto(p); err != nil {
+			t.Fatalf("InsertPhoto failed: %v", err)
+		}
+	}
+	// As olsen analyze would assign them
+	if _, err := db.Exec("UPDATE photos SET session_id = CASE WHEN id < 3 THEN 1 ELSE 3 END"); err != nil {
+		t.Fatal(err)
+	}
+
+	server := NewServer(db, "")
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	rec := get("/session/1")
+	if rec.Code != http.StatusFound {
+		t.Fatalf("GET /session/1 status = %d; want 302", rec.Code)
+	}
+	location := rec.Header().Get("Location")
+	if !strings.Contains(location, "session=1") || !strings.Contains(location, "order=asc") {
+		t.Errorf("redirect = %q; want the session filter, oldest first", location)
+	}
+	grid := get(location)
+	if got := grid.Header().Get("X-Olsen-Result-Count"); got != "2" {
+		t.Errorf("photos in session 1 = %s; want 2", got)
+	}
+	if !strings.Contains(grid.Body.String(), "Session 1") {
+		t.Error("grid does not show the session filter chip")
+	}
+
+	if body := get("/photo/2").Body.String(); !strings.Contains(body, `href="/session/1"`) {
+		t.Error("detail page does not link to the photo's session")
+	}
+	for _, path := range []string{"/session/2", "/session/x"} {
+		if rec := get(path); rec.Code != http.StatusNotFound {
+			t.Errorf("GET %s status = %d; want 404", path, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package explorer
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/adewale/olsen/internal/indexer"
+)
+
+// Hamming distance limits for the similar view. Perceptual hashes are 64
+// bits and unrelated photos differ in about half of them, so distances past
+// MaxSimilarDistance only add noise. See indexer.AreSimilar for what the
+// distances mean.
+const (
+	DefaultSimilarDistance = 10
+	MaxSimilarDistance     = 32
+
+	maxSimilarPhotos = 100
+)
+
+// SimilarPhoto is a photo card with its distance from the photo being matched
+type SimilarPhoto struct {
+	PhotoCard
+	Distance int
+}
+
+// SimilarPhotos returns up to limit photos whose perceptual hash is within
+// maxDistance of photo id's, closest first and then by ID, leaving out
+// rejected photos. It returns nil if the photo has no hash, e.g. when it was
+// indexed without thumbnails.
+func (r *Repository) SimilarPhotos(id, maxDistance, limit int) ([]SimilarPhoto, error) {
+	var target sql.NullString
+	if err := r.db.QueryRow("SELECT perceptual_hash FROM photos WHERE id = ?", id).Scan(&target); err != nil {
+		return nil, err
+	}
+	if target.String == "" {
+		return nil, nil
+	}
+
+	rows, err := r.db.Query(`
+		SELECT id, perceptual_hash FROM photos
+		WHERE perceptual_hash IS NOT NULL AND perceptual_hash != '' AND id != ?
+		  AND rejected = 0
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// Hashes are hex strings, so the distance has to be computed here
+	// rather than in SQL
+	distances := make(map[int]int)
+	var ids []int
+	for rows.Next() {
+		var otherID int
+		var hash string
+		if err := rows.Scan(&otherID, &hash); err != nil {
+			return nil, err
+		}
+		distance, err := indexer.HammingDistance(target.String, hash)
+		if err != nil || distance > maxDistance {
+			continue
+		}
+		distances[otherID] = distance
+		ids = append(ids, otherID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Keep only the closest before loading cards: a loose threshold can
+	// match a large share of the library
+	sort.Slice(ids, func(i, j int) bool {
+		if distances[ids[i]] != distances[ids[j]] {
+			return distances[ids[i]] < distances[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+
+	// GetPhotoCards keeps the order of ids
+	cards, err := r.GetPhotoCards(ids)
+	if err != nil {
+		return nil, err
+	}
+	similar := make([]SimilarPhoto, len(cards))
+	for i, card := range cards {
+		similar[i] = SimilarPhoto{PhotoCard: card, Distance: distances[card.ID]}
+	}
+	return similar, nil
+}
+
+// SetSimilarThreshold sets the maximum Hamming distance the similar view
+// uses when the URL has no max_distance. Values are clamped to
+// 0..MaxSimilarDistance.
+func (s *Server) SetSimilarThreshold(distance int) {
+	s.similarThreshold = clampSimilarDistance(distance)
+}
+
+func clampSimilarDistance(distance int) int {
+	if distance < 0 {
+		return 0
+	}
+	if distance > MaxSimilarDistance {
+		return MaxSimilarDistance
+	}
+	return distance
+}
+
+// handleSimilar shows the photos that look like photo id: /photo/:id/similar,
+// with ?max_distance= to loosen or tighten the match for this view
+func (s *Server) handleSimilar(w http.ResponseWriter, r *http.Request, id int) {
+	maxDistance := s.similarThreshold
+	if v := r.URL.Query().Get("max_distance"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, "Invalid max_distance", http.StatusBadRequest)
+			return
+		}
+		maxDistance = clampSimilarDistance(n)
+	}
+
+	photos, err := s.repo.SimilarPhotos(id, maxDistance, maxSimilarPhotos)
+	if errors.Is(err, sql.ErrNoRows) {
+		s.renderNotFound(w, fmt.Sprintf("Photo %d is not in the library.", id), "")
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to find photos similar to %d: %v", id, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := map[string]interface{}{
+		"Title":       "Similar Photos",
+		"PhotoID":     id,
+		"Photos":      photos,
+		"MaxDistance": maxDistance,
+		"MaxAllowed":  MaxSimilarDistance,
+		"Limit":       maxSimilarPhotos,
+		"Density":     gridDensities[0],
+	}
+	s.renderTemplate(w, "similar", data)
+}
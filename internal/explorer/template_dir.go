@@ -0,0 +1,67 @@
+package explorer
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+)
+
+// requiredTemplates must still be defined once a template directory has been
+// parsed over the embedded set
+var requiredTemplates = []string{"layout.html", "grid", "detail"}
+
+// SetTemplateDir overrides the embedded templates with the .html files in
+// dir. Each file replaces the templates it defines; anything it doesn't
+// define keeps its embedded version. The directory is parsed again for every
+// page, so edits show on reload without restarting. It returns an error if
+// dir has no templates, one fails to parse, or a required template is gone.
+func (s *Server) SetTemplateDir(dir string) error {
+	if _, err := loadTemplates(dir); err != nil {
+		return err
+	}
+	s.templateDir = dir
+	return nil
+}
+
+// templateSet returns the templates to render pages with: the embedded set,
+// or the embedded set overridden by the template directory as it is now
+func (s *Server) templateSet() (*template.Template, error) {
+	if s.templateDir == "" {
+		return templates, nil
+	}
+	return loadTemplates(s.templateDir)
+}
+
+// loadTemplates parses the .html files in dir over a copy of the embedded
+// templates
+func loadTemplates(dir string) (*template.Template, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("template directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("template directory: %s is not a directory", dir)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.html"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("template directory %s has no .html files", dir)
+	}
+
+	set, err := templates.Clone()
+	if err != nil {
+		return nil, err
+	}
+	if set, err = set.ParseFiles(files...); err != nil {
+		return nil, fmt.Errorf("template directory: %w", err)
+	}
+	for _, name := range requiredTemplates {
+		if set.Lookup(name) == nil {
+			return nil, fmt.Errorf("template directory %s: required template %q is not defined", dir, name)
+		}
+	}
+	return set, nil
+}
@@ -0,0 +1,96 @@
+package explorer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/adewale/olsen/internal/database"
+)
+
+func TestTemplateDirOverridesEmbeddedTemplates(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "templates.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	dir := t.TempDir()
+	home := filepath.Join(dir, "home.html")
+	write := func(path, content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write template: %v", err)
+		}
+	}
+	write(home, `{{define "home"}}<p>custom home</p>{{end}}`)
+
+	server := NewServer(db, "")
+	if err := server.SetTemplateDir(dir); err != nil {
+		t.Fatalf("SetTemplateDir failed: %v", err)
+	}
+	get := func(path string) string {
+		rec := httptest.NewRecorder()
+		server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s status = %d: %s", path, rec.Code, rec.Body.String())
+		}
+		return rec.Body.String()
+	}
+
+	body := get("/")
+	if !strings.Contains(body, "custom home") || !strings.Contains(body, "<!DOCTYPE html>") {
+		t.Error("Home page should use the override inside the embedded layout")
+	}
+	if !strings.Contains(get("/photos"), "facet-section") {
+		t.Error("Grid page should fall back to the embedded template")
+	}
+
+	// The directory is re-read, so edits show without restarting
+	write(home, `{{define "home"}}<p>edited home</p>{{end}}`)
+	if !strings.Contains(get("/"), "edited home") {
+		t.Error("Home page should reflect the edited template")
+	}
+
+	broken := t.TempDir()
+	write(filepath.Join(broken, "grid.html"), `{{define "grid"}}{{if}}{{end}}`)
+	for name, d := range map[string]string{
+		"missing":    filepath.Join(dir, "nope"),
+		"empty":      t.TempDir(),
+		"parse fail": broken,
+	} {
+		if err := server.SetTemplateDir(d); err == nil {
+			t.Errorf("SetTemplateDir(%s) succeeded; want an error", name)
+		}
+	}
+}
+
+func TestTemplateErrorDoesNotSendPartialPage(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "templates.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// Parses, but fails once the layout has started writing
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "notfound.html"), []byte(`{{define "notfound"}}<p>{{.Message.Nope}}</p>{{end}}`), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+	server := NewServer(db, "")
+	if err := server.SetTemplateDir(dir); err != nil {
+		t.Fatalf("SetTemplateDir failed: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/no-such-page", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d; want 500", rec.Code)
+	}
+	if strings.Contains(rec.Body.String(), "<!DOCTYPE html>") {
+		t.Errorf("body = %q; want only the error, not part of the page", rec.Body.String())
+	}
+}
@@ -0,0 +1,90 @@
+package indexer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+
+	"github.com/adewale/olsen/internal/database"
+)
+
+// blurhashCells is the long edge of the placeholder image in pixels
+const blurhashCells = 4
+
+// ComputeBlurhash returns the loading placeholder stored in the blurhash
+// column: a base64 PNG of at most 4×4 pixels, each the average colour of
+// its part of img, with img's aspect ratio. It is not the BlurHash
+// algorithm, which needs JavaScript to decode; browsers scale this PNG
+// into a smooth blur on their own, and at around 100 bytes it can be
+// inlined in every grid card.
+func ComputeBlurhash(img image.Image) (string, error) {
+	b := img.Bounds()
+	if b.Empty() {
+		return "", fmt.Errorf("cannot compute placeholder of an empty image")
+	}
+
+	w, h := blurhashCells, blurhashCells
+	if b.Dx() > b.Dy() {
+		h = max(1, (blurhashCells*b.Dy()+b.Dx()/2)/b.Dx())
+	} else if b.Dy() > b.Dx() {
+		w = max(1, (blurhashCells*b.Dx()+b.Dy()/2)/b.Dy())
+	}
+
+	cells := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for cy := 0; cy < h; cy++ {
+		y0, y1 := b.Min.Y+cy*b.Dy()/h, b.Min.Y+(cy+1)*b.Dy()/h
+		for cx := 0; cx < w; cx++ {
+			x0, x1 := b.Min.X+cx*b.Dx()/w, b.Min.X+(cx+1)*b.Dx()/w
+			var r, g, bl, n uint64
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					pr, pg, pb, _ := img.At(x, y).RGBA()
+					r, g, bl = r+uint64(pr), g+uint64(pg), bl+uint64(pb)
+					n++
+				}
+			}
+			cells.SetNRGBA(cx, cy, color.NRGBA{uint8(r / n >> 8), uint8(g / n >> 8), uint8(bl / n >> 8), 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, cells); err != nil {
+		return "", fmt.Errorf("failed to encode placeholder: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// BackfillBlurhashes computes placeholders for photos indexed before they
+// existed, from each photo's smallest stored thumbnail. Re-indexing skips
+// unchanged files, so this is the only way older catalogs get them. It
+// returns the number of photos updated.
+func BackfillBlurhashes(db *database.DB) (int, error) {
+	ids, err := db.PhotosWithoutBlurhash()
+	if err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	for _, id := range ids {
+		data, err := db.SmallestThumbnail(id)
+		if err != nil {
+			return updated, err
+		}
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			continue // Left for a re-index to repair
+		}
+		hash, err := ComputeBlurhash(img)
+		if err != nil {
+			continue
+		}
+		if err := db.SetBlurhash(id, hash); err != nil {
+			return updated, err
+		}
+		updated++
+	}
+	return updated, nil
+}
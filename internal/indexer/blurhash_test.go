@@ -0,0 +1,119 @@
+package indexer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"path/filepath"
+	"testing"
+
+	"github.com/adewale/olsen/internal/database"
+	"github.com/adewale/olsen/pkg/models"
+)
+
+// decodeBlurhash decodes a placeholder back into its image
+func decodeBlurhash(t *testing.T, hash string) image.Image {
+	t.Helper()
+	data, err := base64.StdEncoding.DecodeString(hash)
+	if err != nil {
+		t.Fatalf("placeholder is not base64: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("placeholder is not a PNG: %v", err)
+	}
+	return img
+}
+
+func TestComputeBlurhash(t *testing.T) {
+	// 3:2 landscape, left half red and right half blue
+	src := image.NewRGBA(image.Rect(0, 0, 300, 200))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 300; x++ {
+			if x < 150 {
+				src.Set(x, y, color.RGBA{200, 0, 0, 255})
+			} else {
+				src.Set(x, y, color.RGBA{0, 0, 200, 255})
+			}
+		}
+	}
+
+	hash, err := ComputeBlurhash(src)
+	if err != nil {
+		t.Fatalf("ComputeBlurhash failed: %v", err)
+	}
+	if len(hash) > 200 {
+		t.Errorf("placeholder is %d bytes; want it small enough to inline", len(hash))
+	}
+
+	img := decodeBlurhash(t, hash)
+	if got := img.Bounds().Size(); got != image.Pt(4, 3) {
+		t.Errorf("placeholder size = %v; want 4x3 for a 3:2 image", got)
+	}
+	if r, _, b, _ := img.At(0, 0).RGBA(); r>>8 != 200 || b>>8 != 0 {
+		t.Errorf("left cell = (%d, _, %d); want red", r>>8, b>>8)
+	}
+	if r, _, b, _ := img.At(3, 2).RGBA(); r>>8 != 0 || b>>8 != 200 {
+		t.Errorf("right cell = (%d, _, %d); want blue", r>>8, b>>8)
+	}
+
+	// Very tall images keep at least one column
+	hash, err = ComputeBlurhash(image.NewGray(image.Rect(0, 0, 10, 400)))
+	if err != nil {
+		t.Fatalf("ComputeBlurhash failed: %v", err)
+	}
+	if got := decodeBlurhash(t, hash).Bounds().Size(); got != image.Pt(1, 4) {
+		t.Errorf("tall placeholder size = %v; want 1x4", got)
+	}
+}
+
+func TestBackfillBlurhashes(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "blurhash.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	var thumb bytes.Buffer
+	if err := jpeg.Encode(&thumb, image.NewGray(image.Rect(0, 0, 64, 48)), nil); err != nil {
+		t.Fatalf("Failed to encode thumbnail: %v", err)
+	}
+	photos := []*models.PhotoMetadata{
+		// Indexed before placeholders existed
+		{FilePath: "/old.jpg", FileHash: "a", Thumbnails: map[models.ThumbnailSize][]byte{models.ThumbnailTiny: thumb.Bytes()}},
+		// Already has one
+		{FilePath: "/new.jpg", FileHash: "b", Blurhash: "kept", Thumbnails: map[models.ThumbnailSize][]byte{models.ThumbnailTiny: thumb.Bytes()}},
+		// Indexed with -no-thumbnails: nothing to compute from
+		{FilePath: "/pending.jpg", FileHash: "c", ThumbnailsPending: true},
+	}
+	for _, p := range photos {
+		if err := db.InsertPhoto(p); err != nil {
+			t.Fatalf("InsertPhoto failed: %v", err)
+		}
+	}
+
+	updated, err := BackfillBlurhashes(db)
+	if err != nil {
+		t.Fatalf("BackfillBlurhashes failed: %v", err)
+	}
+	if updated != 1 {
+		t.Errorf("updated = %d; want 1", updated)
+	}
+
+	var old, kept string
+	db.QueryRow("SELECT blurhash FROM photos WHERE file_path = '/old.jpg'").Scan(&old)
+	db.QueryRow("SELECT blurhash FROM photos WHERE file_path = '/new.jpg'").Scan(&kept)
+	if got := decodeBlurhash(t, old).Bounds().Size(); got != image.Pt(4, 3) {
+		t.Errorf("backfilled placeholder size = %v; want 4x3", got)
+	}
+	if kept != "kept" {
+		t.Errorf("existing placeholder = %q; want it left alone", kept)
+	}
+
+	if updated, _ := BackfillBlurhashes(db); updated != 0 {
+		t.Errorf("second backfill updated %d photos; want 0", updated)
+	}
+}
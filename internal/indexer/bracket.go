@@ -0,0 +1,201 @@
+package indexer
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/adewale/olsen/internal/database"
+)
+
+// BracketDetector detects auto exposure bracketing (AEB) sequences: a few
+// consecutive frames of the same scene, each at a different exposure, taken
+// for HDR merging. Rapid frames at the same exposure are bursts instead.
+type BracketDetector struct {
+	db               *database.DB
+	maxTimeDelta     time.Duration // Maximum gap between frames, on top of the previous frame's shutter time
+	maxFocalDelta    float64       // Maximum focal length difference (mm)
+	minBracketSize   int           // Minimum frames in a bracket
+	maxBracketSize   int           // Cameras bracket at most 9 frames
+	minExposureStep  float64       // EV difference that makes two frames distinct exposures
+	minExposureRange float64       // EV between darkest and brightest frame
+}
+
+// NewBracketDetector creates a new bracket detector with default settings
+func NewBracketDetector(db *database.DB) *BracketDetector {
+	return &BracketDetector{
+		db:               db,
+		maxTimeDelta:     2 * time.Second, // Same as bursts
+		maxFocalDelta:    5.0,
+		minBracketSize:   3,
+		maxBracketSize:   9,
+		minExposureStep:  0.3, // Just under the 1/3 stop cameras bracket in
+		minExposureRange: 1.0, // ±0.5 EV; tighter spreads look like auto-exposure drift
+	}
+}
+
+// DetectBrackets finds all bracketed sequences in the database. Photos with
+// no exposure value (missing aperture, shutter speed or ISO) are never
+// bracketed.
+func (bd *BracketDetector) DetectBrackets() ([][]int, error) {
+	rows, err := bd.db.Query(`
+		SELECT id, file_path, date_taken,
+		       COALESCE(camera_make, ''), COALESCE(camera_model, ''),
+		       focal_length, exposure_value, shutter_seconds
+		FROM photos
+		WHERE date_taken IS NOT NULL
+		ORDER BY date_taken, id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var photos []Photo
+	for rows.Next() {
+		var p Photo
+		var dateTakenStr string
+		var focal, ev, shutter sql.NullFloat64
+		if err := rows.Scan(&p.ID, &p.FilePath, &dateTakenStr, &p.CameraMake, &p.CameraModel, &focal, &ev, &shutter); err != nil {
+			return nil, err
+		}
+
+		p.DateTaken, err = time.Parse("2006-01-02 15:04:05", dateTakenStr)
+		if err != nil {
+			p.DateTaken, err = time.Parse(time.RFC3339, dateTakenStr)
+			if err != nil {
+				continue // Skip photos with unparseable dates
+			}
+		}
+		p.FocalLength = focal.Float64
+		if ev.Valid {
+			p.ExposureValue = &ev.Float64
+		}
+		p.ShutterSeconds = shutter.Float64
+
+		photos = append(photos, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return bd.findBracketSequences(photos), nil
+}
+
+// findBracketSequences finds bracketed sequences in photos sorted by date.
+// A sequence grows while each new frame is the same scene as the last and
+// its exposure differs from every frame so far; a repeated exposure ends it,
+// which splits back-to-back brackets and leaves same-exposure frames to
+// burst detection.
+func (bd *BracketDetector) findBracketSequences(photos []Photo) [][]int {
+	var brackets [][]int
+	i := 0
+
+	for i < len(photos) {
+		if photos[i].ExposureValue == nil {
+			i++
+			continue
+		}
+
+		bracket := []int{i}
+		for j := i + 1; j < len(photos) && len(bracket) < bd.maxBracketSize; j++ {
+			last := photos[bracket[len(bracket)-1]]
+			candidate := photos[j]
+
+			if candidate.DateTaken.Sub(last.DateTaken) > bd.maxGap(last) ||
+				candidate.CameraMake != last.CameraMake || candidate.CameraModel != last.CameraModel ||
+				abs(candidate.FocalLength-last.FocalLength) > bd.maxFocalDelta ||
+				!bd.isNewExposure(photos, bracket, candidate) {
+				break
+			}
+			bracket = append(bracket, j)
+		}
+
+		if len(bracket) >= bd.minBracketSize && bd.exposureRange(photos, bracket) >= bd.minExposureRange {
+			ids := make([]int, len(bracket))
+			for k, idx := range bracket {
+				ids[k] = photos[idx].ID
+			}
+			brackets = append(brackets, ids)
+			i = bracket[len(bracket)-1] + 1
+		} else {
+			i++
+		}
+	}
+
+	return brackets
+}
+
+// maxGap allows for the previous frame's exposure time, since the darkest
+// frames of a bracket at dusk can be several seconds long
+func (bd *BracketDetector) maxGap(previous Photo) time.Duration {
+	return bd.maxTimeDelta + time.Duration(previous.ShutterSeconds*float64(time.Second))
+}
+
+// isNewExposure reports whether candidate has a known exposure that differs
+// from every frame already in the bracket
+func (bd *BracketDetector) isNewExposure(photos []Photo, bracket []int, candidate Photo) bool {
+	if candidate.ExposureValue == nil {
+		return false
+	}
+	for _, idx := range bracket {
+		if abs(*candidate.ExposureValue-*photos[idx].ExposureValue) < bd.minExposureStep {
+			return false
+		}
+	}
+	return true
+}
+
+// exposureRange returns the EV between the darkest and brightest frames
+func (bd *BracketDetector) exposureRange(photos []Photo, bracket []int) float64 {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, idx := range bracket {
+		ev := *photos[idx].ExposureValue
+		lo = math.Min(lo, ev)
+		hi = math.Max(hi, ev)
+	}
+	return hi - lo
+}
+
+// ClearBrackets removes all bracket assignments, so detection can be re-run
+func (bd *BracketDetector) ClearBrackets() error {
+	_, err := bd.db.Exec(`
+		UPDATE photos
+		SET bracket_group_id = NULL, bracket_sequence = NULL, bracket_count = NULL
+		WHERE bracket_group_id IS NOT NULL
+	`)
+	return err
+}
+
+// SaveBrackets stores detected brackets on their photos. The group ID is
+// derived from the first frame, so re-running detection on an unchanged
+// catalog gives the same IDs.
+func (bd *BracketDetector) SaveBrackets(brackets [][]int) error {
+	for _, bracket := range brackets {
+		if len(bracket) == 0 {
+			continue
+		}
+		groupID := fmt.Sprintf("bracket_%d", bracket[0])
+		for position, photoID := range bracket {
+			_, err := bd.db.Exec(`
+				UPDATE photos
+				SET bracket_group_id = ?, bracket_sequence = ?, bracket_count = ?
+				WHERE id = ?
+			`, groupID, position, len(bracket), photoID)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// GetBracketStats returns the number of bracket groups and the photos in them
+func (bd *BracketDetector) GetBracketStats() (int, int, error) {
+	var groups, photos int
+	err := bd.db.QueryRow(`
+		SELECT COUNT(DISTINCT bracket_group_id), COUNT(bracket_group_id) FROM photos
+	`).Scan(&groups, &photos)
+	return groups, photos, err
+}
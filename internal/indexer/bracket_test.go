@@ -0,0 +1,173 @@
+package indexer
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/adewale/olsen/internal/database"
+)
+
+func evPtr(v float64) *float64 { return &v }
+
+func TestFindBracketSequences(t *testing.T) {
+	detector := NewBracketDetector(nil)
+	baseTime := mustParseTime("2025-05-15 18:00:00")
+
+	// frame builds a Canon R5 50mm frame at the given offset and EV
+	frame := func(id int, offset time.Duration, ev *float64) Photo {
+		return Photo{ID: id, DateTaken: baseTime.Add(offset), CameraMake: "Canon", CameraModel: "R5", FocalLength: 50, ExposureValue: ev}
+	}
+
+	tests := []struct {
+		name   string
+		photos []Photo
+		want   [][]int
+	}{
+		{
+			name: "Three-shot bracket at -2, 0, +2 EV",
+			photos: []Photo{
+				frame(1, 0, evPtr(12)),
+				frame(2, 1*Second, evPtr(10)),
+				frame(3, 2*Second, evPtr(14)),
+			},
+			want: [][]int{{1, 2, 3}},
+		},
+		{
+			name: "Same-exposure burst is not a bracket",
+			photos: []Photo{
+				frame(1, 0, evPtr(12)),
+				frame(2, 1*Second, evPtr(12)),
+				frame(3, 2*Second, evPtr(12)),
+			},
+		},
+		{
+			name: "Back-to-back brackets split at the repeated exposure",
+			photos: []Photo{
+				frame(1, 0, evPtr(12)),
+				frame(2, 1*Second, evPtr(11)),
+				frame(3, 2*Second, evPtr(13)),
+				frame(4, 3*Second, evPtr(12)),
+				frame(5, 4*Second, evPtr(11)),
+				frame(6, 5*Second, evPtr(13)),
+			},
+			want: [][]int{{1, 2, 3}, {4, 5, 6}},
+		},
+		{
+			name: "Bracket followed by burst frames",
+			photos: []Photo{
+				frame(1, 0, evPtr(12)),
+				frame(2, 1*Second, evPtr(11)),
+				frame(3, 2*Second, evPtr(13)),
+				frame(4, 3*Second, evPtr(13)),
+				frame(5, 4*Second, evPtr(13)),
+			},
+			want: [][]int{{1, 2, 3}},
+		},
+		{
+			name: "Auto-exposure drift under 1 EV is not a bracket",
+			photos: []Photo{
+				frame(1, 0, evPtr(12)),
+				frame(2, 1*Second, evPtr(12.33)),
+				frame(3, 2*Second, evPtr(12.67)),
+			},
+		},
+		{
+			name: "Missing exposure ends the sequence",
+			photos: []Photo{
+				frame(1, 0, evPtr(12)),
+				frame(2, 1*Second, nil),
+				frame(3, 2*Second, evPtr(14)),
+			},
+		},
+		{
+			name: "Long exposure extends the allowed gap",
+			photos: []Photo{
+				frame(1, 0, evPtr(6)),
+				{ID: 2, DateTaken: baseTime.Add(1 * Second), CameraMake: "Canon", CameraModel: "R5", FocalLength: 50, ExposureValue: evPtr(4), ShutterSeconds: 4},
+				frame(3, 6*Second, evPtr(8)),
+			},
+			want: [][]int{{1, 2, 3}},
+		},
+		{
+			name: "Different camera breaks the sequence",
+			photos: []Photo{
+				frame(1, 0, evPtr(12)),
+				frame(2, 1*Second, evPtr(10)),
+				{ID: 3, DateTaken: baseTime.Add(2 * Second), CameraMake: "Nikon", CameraModel: "Z9", FocalLength: 50, ExposureValue: evPtr(14)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := detector.findBracketSequences(tt.photos)
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("findBracketSequences() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBracketsExcludedFromBursts(t *testing.T) {
+	db, err := database.Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// A 3-shot bracket immediately followed by a 3-shot burst: all six frames
+	// are within 2 seconds of each other, so burst detection alone would
+	// group them together
+	evs := []float64{12, 10, 14, 12, 12, 12}
+	for i, ev := range evs {
+		_, err := db.Exec(`
+			INSERT INTO photos (file_path, file_hash, file_size, last_modified, date_taken,
+				camera_make, camera_model, focal_length, exposure_value)
+			VALUES (?, ?, 1, '2025-05-15 18:00:00', ?, 'Canon', 'R5', 50, ?)
+		`, fmt.Sprintf("/frame%d.jpg", i+1), fmt.Sprintf("hash%d", i+1),
+			mustParseTime("2025-05-15 18:00:00").Add(time.Duration(i)*Second).Format("2006-01-02 15:04:05"), ev)
+		if err != nil {
+			t.Fatalf("Failed to insert photo: %v", err)
+		}
+	}
+
+	brackets := NewBracketDetector(db)
+	found, err := brackets.DetectBrackets()
+	if err != nil {
+		t.Fatalf("DetectBrackets failed: %v", err)
+	}
+	if fmt.Sprint(found) != "[[1 2 3]]" {
+		t.Fatalf("brackets = %v; want [[1 2 3]]", found)
+	}
+	if err := brackets.SaveBrackets(found); err != nil {
+		t.Fatalf("SaveBrackets failed: %v", err)
+	}
+
+	bursts, err := NewBurstDetector(db).DetectBursts()
+	if err != nil {
+		t.Fatalf("DetectBursts failed: %v", err)
+	}
+	if fmt.Sprint(bursts) != "[[4 5 6]]" {
+		t.Errorf("bursts = %v; want [[4 5 6]], without the bracketed frames", bursts)
+	}
+
+	// Re-running after a clear gives the same groups, with stable IDs
+	if err := brackets.ClearBrackets(); err != nil {
+		t.Fatalf("ClearBrackets failed: %v", err)
+	}
+	if groups, photos, _ := brackets.GetBracketStats(); groups != 0 || photos != 0 {
+		t.Errorf("after clear: %d groups, %d photos; want none", groups, photos)
+	}
+	if err := brackets.SaveBrackets(found); err != nil {
+		t.Fatalf("SaveBrackets failed: %v", err)
+	}
+	var groupID string
+	var sequence, count int
+	if err := db.QueryRow("SELECT bracket_group_id, bracket_sequence, bracket_count FROM photos WHERE id = 2").Scan(&groupID, &sequence, &count); err != nil {
+		t.Fatalf("Failed to read bracket: %v", err)
+	}
+	if groupID != "bracket_1" || sequence != 1 || count != 3 {
+		t.Errorf("photo 2 bracket = %s #%d of %d; want bracket_1 #1 of 3", groupID, sequence, count)
+	}
+}
@@ -1,6 +1,9 @@
 package indexer
 
 import (
+	"database/sql"
+	"errors"
+	"strings"
 	"time"
 
 	"github.com/adewale/olsen/internal/database"
@@ -32,15 +35,20 @@ type Photo struct {
 	CameraMake  string
 	CameraModel string
 	FocalLength float64
+
+	// Used only by BracketDetector
+	ExposureValue  *float64 // nil when exposure settings are incomplete
+	ShutterSeconds float64
 }
 
-// DetectBursts finds all burst sequences in the database
+// DetectBursts finds all burst sequences in the database. Photos already in
+// a bracket are left out, so run bracket detection first.
 func (bd *BurstDetector) DetectBursts() ([][]int, error) {
 	// Query all photos ordered by date
 	rows, err := bd.db.Query(`
 		SELECT id, file_path, date_taken, camera_make, camera_model, focal_length
 		FROM photos
-		WHERE date_taken IS NOT NULL
+		WHERE date_taken IS NOT NULL AND bracket_group_id IS NULL
 		ORDER BY date_taken
 	`)
 	if err != nil {
@@ -148,6 +156,45 @@ func abs(x float64) float64 {
 	return x
 }
 
+// ClearBursts removes all burst groups and assignments, so detection can be
+// re-run without leaving stale groups behind
+func (bd *BurstDetector) ClearBursts() error {
+	_, err := bd.db.Exec(`
+		UPDATE photos
+		SET burst_group_id = NULL, burst_sequence = NULL, burst_count = NULL, is_burst_representative = FALSE
+		WHERE burst_group_id IS NOT NULL;
+		DELETE FROM burst_groups;
+	`)
+	return err
+}
+
+// representative picks the frame that stands for a burst when bursts are
+// collapsed: a frame the user pinned (database.SetBurstRepresentative), or
+// else the middle frame, which is least likely to be caught mid-motion at
+// either end of the sequence. No sharpness score is stored to prefer the
+// sharpest frame instead.
+func (bd *BurstDetector) representative(burst []int) (int, error) {
+	placeholders := make([]string, len(burst))
+	args := make([]interface{}, len(burst))
+	for i, id := range burst {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+	var pinned int
+	err := bd.db.QueryRow(`
+		SELECT id FROM photos
+		WHERE burst_representative_pinned = 1 AND id IN (`+strings.Join(placeholders, ", ")+`)
+		ORDER BY id LIMIT 1
+	`, args...).Scan(&pinned)
+	if errors.Is(err, sql.ErrNoRows) {
+		return burst[len(burst)/2], nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return pinned, nil
+}
+
 // SaveBursts saves detected burst groups to the database
 func (bd *BurstDetector) SaveBursts(bursts [][]int) error {
 	for burstIdx, burst := range bursts {
@@ -155,13 +202,18 @@ func (bd *BurstDetector) SaveBursts(bursts [][]int) error {
 			continue
 		}
 
+		representative, err := bd.representative(burst)
+		if err != nil {
+			return err
+		}
+
 		// Generate burst group ID
 		burstGroupID := time.Now().Format("20060102150405") + "_" + string(rune('0'+burstIdx))
 
 		// Get first photo's date for burst group metadata
 		var dateTaken string
 		var cameraMake, cameraModel string
-		err := bd.db.QueryRow(`
+		err = bd.db.QueryRow(`
 			SELECT date_taken, camera_make, camera_model
 			FROM photos WHERE id = ?
 		`, burst[0]).Scan(&dateTaken, &cameraMake, &cameraModel)
@@ -188,7 +240,7 @@ func (bd *BurstDetector) SaveBursts(bursts [][]int) error {
 				id, photo_count, date_taken, camera_make, camera_model,
 				representative_photo_id, time_span_seconds
 			) VALUES (?, ?, ?, ?, ?, ?, ?)
-		`, burstGroupID, len(burst), dateTaken, cameraMake, cameraModel, burst[0], timeSpan)
+		`, burstGroupID, len(burst), dateTaken, cameraMake, cameraModel, representative, timeSpan)
 		if err != nil {
 			return err
 		}
@@ -202,7 +254,7 @@ func (bd *BurstDetector) SaveBursts(bursts [][]int) error {
 				    burst_count = ?,
 				    is_burst_representative = ?
 				WHERE id = ?
-			`, burstGroupID, position, len(burst), position == 0, photoID)
+			`, burstGroupID, position, len(burst), photoID == representative, photoID)
 			if err != nil {
 				return err
 			}
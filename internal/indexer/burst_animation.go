@@ -0,0 +1,132 @@
+package indexer
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"math"
+
+	"github.com/adewale/olsen/internal/database"
+	"github.com/adewale/olsen/pkg/models"
+)
+
+// AnimationOptions sets the size and speed of burst animations
+type AnimationOptions struct {
+	Size models.ThumbnailSize // Stored thumbnail size the frames are taken from
+	FPS  float64              // Frames per second, above 0 and at most 50
+}
+
+// DefaultAnimationOptions animates 256px thumbnails at 4 frames a second
+var DefaultAnimationOptions = AnimationOptions{Size: models.ThumbnailSmall, FPS: 4}
+
+// AnimateBursts builds an animated GIF for every burst group from its frames'
+// stored thumbnails, in burst order, and stores it with the group. Frames
+// without a thumbnail of the requested size are left out, and groups with
+// fewer than two such frames get no animation. It returns how many groups
+// were animated.
+func (bd *BurstDetector) AnimateBursts(opts AnimationOptions) (int, error) {
+	if opts.FPS <= 0 || opts.FPS > 50 {
+		return 0, fmt.Errorf("animation frame rate must be above 0 and at most 50, got %g", opts.FPS)
+	}
+
+	rows, err := bd.db.Query("SELECT id FROM burst_groups ORDER BY date_taken")
+	if err != nil {
+		return 0, err
+	}
+	var groups []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		groups = append(groups, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	animated := 0
+	for _, group := range groups {
+		frames, err := bd.burstFrames(group, opts.Size)
+		if err != nil {
+			return animated, err
+		}
+		if len(frames) < 2 {
+			continue
+		}
+		data, err := encodeAnimation(frames, opts.FPS)
+		if err != nil {
+			return animated, fmt.Errorf("failed to animate burst %s: %w", group, err)
+		}
+		if err := bd.db.SetBurstAnimation(group, data); err != nil {
+			return animated, err
+		}
+		animated++
+	}
+	return animated, nil
+}
+
+// burstFrames decodes the thumbnails of a burst's frames, in burst order
+func (bd *BurstDetector) burstFrames(group string, size models.ThumbnailSize) ([]image.Image, error) {
+	rows, err := bd.db.Query(`
+		SELECT `+database.ThumbnailData+`
+		FROM photos p
+		JOIN thumbnails t ON t.photo_id = p.id AND t.size = ?
+		WHERE p.burst_group_id = ?
+		ORDER BY p.burst_sequence
+	`, string(size), group)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var frames []image.Image
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		img, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			continue // A damaged thumbnail just drops that frame
+		}
+		frames = append(frames, img)
+	}
+	return frames, rows.Err()
+}
+
+// encodeAnimation encodes frames as a looping GIF. Frames of different shapes
+// (a rotated shot mid-burst) are centred on a canvas that fits them all.
+func encodeAnimation(frames []image.Image, fps float64) ([]byte, error) {
+	var width, height int
+	for _, f := range frames {
+		width = max(width, f.Bounds().Dx())
+		height = max(height, f.Bounds().Dy())
+	}
+	canvas := image.Rect(0, 0, width, height)
+	// GIF delays are in hundredths of a second; browsers slow anything
+	// under 2 down to about 10
+	delay := max(2, int(math.Round(100/fps)))
+
+	anim := &gif.GIF{LoopCount: 0}
+	for _, f := range frames {
+		b := f.Bounds()
+		offset := image.Pt((width-b.Dx())/2, (height-b.Dy())/2)
+		paletted := image.NewPaletted(canvas, palette.Plan9)
+		draw.FloydSteinberg.Draw(paletted, b.Sub(b.Min).Add(offset), f, b.Min)
+		anim.Image = append(anim.Image, paletted)
+		anim.Delay = append(anim.Delay, delay)
+	}
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, anim); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
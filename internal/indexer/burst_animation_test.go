@@ -0,0 +1,93 @@
+package indexer
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/adewale/olsen/internal/database"
+	"github.com/adewale/olsen/pkg/models"
+)
+
+func TestAnimateBursts(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "animate.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	thumbnail := func(w, h int, c color.Color) []byte {
+		img := image.NewRGBA(image.Rect(0, 0, w, h))
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				img.Set(x, y, c)
+			}
+		}
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, img, nil); err != nil {
+			t.Fatalf("Failed to encode thumbnail: %v", err)
+		}
+		return buf.Bytes()
+	}
+
+	base := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	sizes := [][2]int{{64, 48}, {48, 64}, {64, 48}} // The middle frame was rotated
+	for i, size := range sizes {
+		photo := &models.PhotoMetadata{
+			FilePath: fmt.Sprintf("/%d.jpg", i), FileHash: fmt.Sprint(i),
+			CameraMake: "Canon", CameraModel: "EOS R5", DateTaken: base.Add(time.Duration(i) * time.Second),
+			Thumbnails: map[models.ThumbnailSize][]byte{
+				models.ThumbnailTiny: thumbnail(size[0], size[1], color.RGBA{uint8(i * 100), 0, 0, 255}),
+			},
+		}
+		if err := db.InsertPhoto(photo); err != nil {
+			t.Fatalf("InsertPhoto failed: %v", err)
+		}
+	}
+
+	detector := NewBurstDetector(db)
+	if err := detector.SaveBursts([][]int{{1, 2, 3}}); err != nil {
+		t.Fatalf("SaveBursts failed: %v", err)
+	}
+
+	if _, err := detector.AnimateBursts(AnimationOptions{Size: models.ThumbnailTiny, FPS: 0}); err == nil {
+		t.Error("AnimateBursts with 0 fps succeeded; want an error")
+	}
+	animated, err := detector.AnimateBursts(AnimationOptions{Size: models.ThumbnailTiny, FPS: 5})
+	if err != nil {
+		t.Fatalf("AnimateBursts failed: %v", err)
+	}
+	if animated != 1 {
+		t.Errorf("animated %d bursts; want 1", animated)
+	}
+
+	var group string
+	if err := db.QueryRow("SELECT id FROM burst_groups").Scan(&group); err != nil {
+		t.Fatalf("Failed to read burst group: %v", err)
+	}
+	data, err := db.BurstAnimation(group)
+	if err != nil {
+		t.Fatalf("BurstAnimation failed: %v", err)
+	}
+	anim, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Stored animation is not a GIF: %v", err)
+	}
+	if len(anim.Image) != 3 || anim.Delay[0] != 20 {
+		t.Errorf("animation has %d frames with delay %d; want 3 frames of 20cs", len(anim.Image), anim.Delay[0])
+	}
+	if b := anim.Image[1].Bounds(); b.Dx() != 64 || b.Dy() != 64 {
+		t.Errorf("frame bounds = %v; want a 64x64 canvas fitting both orientations", b)
+	}
+
+	// No thumbnails of the size asked for: no animation
+	if animated, err := detector.AnimateBursts(AnimationOptions{Size: models.ThumbnailLarge, FPS: 5}); err != nil || animated != 0 {
+		t.Errorf("AnimateBursts(1024) = %d, %v; want 0 without 1024px thumbnails", animated, err)
+	}
+}
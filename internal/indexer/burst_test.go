@@ -1,12 +1,15 @@
 package indexer
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/adewale/olsen/internal/database"
+	"github.com/adewale/olsen/internal/query"
+	"github.com/adewale/olsen/pkg/models"
 )
 
 func TestBurstDetection(t *testing.T) {
@@ -78,6 +81,80 @@ func TestBurstDetection(t *testing.T) {
 	}
 }
 
+func TestBurstRepresentative(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "burst_rep.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	base := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 4; i++ {
+		photo := &models.PhotoMetadata{FilePath: fmt.Sprintf("/%d.jpg", i), FileHash: fmt.Sprint(i), CameraMake: "Canon", CameraModel: "EOS R5", DateTaken: base.Add(time.Duration(i) * time.Second)}
+		if err := db.InsertPhoto(photo); err != nil {
+			t.Fatalf("InsertPhoto failed: %v", err)
+		}
+	}
+	burst := []int{1, 2, 3} // Photo 4 is not in the burst
+
+	detector := NewBurstDetector(db)
+	if err := detector.SaveBursts([][]int{burst}); err != nil {
+		t.Fatalf("SaveBursts failed: %v", err)
+	}
+
+	engine := query.NewEngine(db.DB)
+	collapsed := func() string {
+		t.Helper()
+		result, err := engine.Query(query.QueryParams{CollapseBursts: true, Limit: 50, SortBy: "date_taken", SortOrder: "asc"})
+		if err != nil {
+			t.Fatalf("Query failed: %v", err)
+		}
+		var ids []int
+		for _, p := range result.Photos {
+			ids = append(ids, p.ID)
+		}
+		return fmt.Sprint(ids)
+	}
+	groupOf := func(id int) string {
+		t.Helper()
+		var group string
+		if err := db.QueryRow("SELECT burst_group_id FROM photos WHERE id = ?", id).Scan(&group); err != nil {
+			t.Fatalf("Failed to read burst group: %v", err)
+		}
+		return group
+	}
+
+	// The middle frame is the default
+	if got := collapsed(); got != "[2 4]" {
+		t.Errorf("collapsed ids = %s; want [2 4]", got)
+	}
+
+	if err := db.SetBurstRepresentative(groupOf(1), 4); err == nil {
+		t.Error("SetBurstRepresentative accepted a photo outside the burst")
+	}
+	if err := db.SetBurstRepresentative(groupOf(1), 3); err != nil {
+		t.Fatalf("SetBurstRepresentative failed: %v", err)
+	}
+	if got := collapsed(); got != "[3 4]" {
+		t.Errorf("collapsed ids after override = %s; want [3 4]", got)
+	}
+	var rep int
+	if err := db.QueryRow("SELECT representative_photo_id FROM burst_groups").Scan(&rep); err != nil || rep != 3 {
+		t.Errorf("burst_groups representative = %d (%v); want 3", rep, err)
+	}
+
+	// Re-running detection keeps the choice
+	if err := detector.ClearBursts(); err != nil {
+		t.Fatalf("ClearBursts failed: %v", err)
+	}
+	if err := detector.SaveBursts([][]int{burst}); err != nil {
+		t.Fatalf("SaveBursts failed: %v", err)
+	}
+	if got := collapsed(); got != "[3 4]" {
+		t.Errorf("collapsed ids after re-detection = %s; want [3 4]", got)
+	}
+}
+
 func TestBurstDetectorSettings(t *testing.T) {
 	db, err := database.Open(":memory:")
 	if err != nil {
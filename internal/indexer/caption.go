@@ -0,0 +1,124 @@
+package indexer
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// placeholderCaptions are ImageDescription values cameras write on every
+// frame. They describe nothing, so they are treated as no caption.
+var placeholderCaptions = map[string]bool{
+	"OLYMPUS DIGITAL CAMERA":     true,
+	"SONY DSC":                   true,
+	"DCIM":                       true,
+	"Default":                    true,
+	"default":                    true,
+	"DIGITAL CAMERA":             true,
+	"KODAK Digital Still Camera": true,
+	"Exif_JPEG_PICTURE":          true,
+}
+
+// cleanText trims a title or caption and drops camera placeholders. EXIF
+// text is nominally ASCII, but editors write UTF-8 and older ones Latin-1;
+// bytes that are not valid UTF-8 are read as Latin-1 rather than mangled.
+func cleanText(s string) string {
+	if !utf8.ValidString(s) {
+		runes := make([]rune, len(s))
+		for i := 0; i < len(s); i++ {
+			runes[i] = rune(s[i])
+		}
+		s = string(runes)
+	}
+	s = strings.TrimSpace(strings.Trim(s, "\x00"))
+	if placeholderCaptions[s] {
+		return ""
+	}
+	return s
+}
+
+// xpText decodes a Windows XPTitle or XPComment tag: UTF-16LE bytes,
+// NUL-terminated
+func xpText(val interface{}) string {
+	b, ok := val.([]uint8)
+	if !ok {
+		return ""
+	}
+	units := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		u := uint16(b[i]) | uint16(b[i+1])<<8
+		if u == 0 {
+			break
+		}
+		units = append(units, u)
+	}
+	return cleanText(string(utf16.Decode(units)))
+}
+
+// xmpLangAlt is an XMP language alternative: the same text in several
+// languages, one rdf:li per xml:lang
+type xmpLangAlt struct {
+	Items []struct {
+		Lang  string `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+		Value string `xml:",chardata"`
+	} `xml:"Alt>li"`
+}
+
+// text returns the x-default entry, else the first with any text
+func (a *xmpLangAlt) text() string {
+	if a == nil {
+		return ""
+	}
+	first := ""
+	for _, item := range a.Items {
+		value := cleanText(item.Value)
+		if item.Lang == "x-default" && value != "" {
+			return value
+		}
+		if first == "" {
+			first = value
+		}
+	}
+	return first
+}
+
+// xmpRDF is the part of an XMP packet olsen reads: the Dublin Core title
+// and description, where Lightroom, Photoshop and most DAMs keep them.
+// Elements are matched by local name, since the dc prefix may be declared
+// outside the rdf:RDF element parsed.
+type xmpRDF struct {
+	Descriptions []struct {
+		Title       *xmpLangAlt `xml:"title"`
+		Description *xmpLangAlt `xml:"description"`
+	} `xml:"Description"`
+}
+
+// readXMPText finds the XMP packet embedded in a file's bytes and returns
+// its title and description. Files without a packet, or with one that does
+// not parse, return empty strings.
+func readXMPText(data []byte) (title, description string) {
+	start := bytes.Index(data, []byte("<rdf:RDF"))
+	if start < 0 {
+		return "", ""
+	}
+	end := bytes.Index(data[start:], []byte("</rdf:RDF>"))
+	if end < 0 {
+		return "", ""
+	}
+
+	var rdf xmpRDF
+	if err := xml.Unmarshal(data[start:start+end+len("</rdf:RDF>")], &rdf); err != nil {
+		return "", ""
+	}
+	for _, d := range rdf.Descriptions {
+		if title == "" {
+			title = d.Title.text()
+		}
+		if description == "" {
+			description = d.Description.text()
+		}
+	}
+	return title, description
+}
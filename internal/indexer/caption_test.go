@@ -0,0 +1,71 @@
+package indexer
+
+import "testing"
+
+const xmpPacket = `<?xpacket begin="" id="W5M0MpCehiHzreSzNTczkc9d"?>
+<x:xmpmeta xmlns:x="adobe:ns:meta/">
+ <rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+  <rdf:Description rdf:about="" xmlns:dc="http://purl.org/dc/elements/1.1/">
+   <dc:title>
+    <rdf:Alt>
+     <rdf:li xml:lang="fr-FR">Coucher de soleil</rdf:li>
+     <rdf:li xml:lang="x-default">Sunset over the bay</rdf:li>
+    </rdf:Alt>
+   </dc:title>
+   <dc:description>
+    <rdf:Alt>
+     <rdf:li xml:lang="ja-JP">湾に沈む夕日</rdf:li>
+    </rdf:Alt>
+   </dc:description>
+  </rdf:Description>
+ </rdf:RDF>
+</x:xmpmeta>
+<?xpacket end="w"?>`
+
+func TestReadXMPText(t *testing.T) {
+	// The packet sits among image bytes, as in a JPEG APP1 segment
+	data := append([]byte("\xff\xd8\xff\xe1\x00\x10http://ns.adobe.com/xap/1.0/\x00"), xmpPacket...)
+	data = append(data, "\xff\xd9"...)
+
+	title, description := readXMPText(data)
+	if title != "Sunset over the bay" {
+		t.Errorf("title = %q; want the x-default entry", title)
+	}
+	if description != "湾に沈む夕日" {
+		t.Errorf("description = %q; want the only language given", description)
+	}
+
+	if title, description := readXMPText([]byte("\xff\xd8 no packet \xff\xd9")); title != "" || description != "" {
+		t.Errorf("no packet = %q, %q; want empty", title, description)
+	}
+	if title, _ := readXMPText([]byte("<rdf:RDF><rdf:Description>")); title != "" {
+		t.Errorf("truncated packet title = %q; want empty", title)
+	}
+}
+
+func TestCaptionText(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"  Harbour at dawn\x00\x00", "Harbour at dawn"},
+		{"Caf\xe9 terrace", "Café terrace"}, // Latin-1
+		{"Café terrace", "Café terrace"},    // UTF-8
+		{"OLYMPUS DIGITAL CAMERA         ", ""},
+		{"\x00\x00\x00", ""},
+	}
+	for _, tt := range tests {
+		if got := cleanText(tt.in); got != tt.want {
+			t.Errorf("cleanText(%q) = %q; want %q", tt.in, got, tt.want)
+		}
+	}
+
+	// XPTitle is UTF-16LE, NUL-terminated
+	xp := []uint8{'B', 0, 'e', 0, 'r', 0, 'g', 0, 0xe9, 0, 'n', 0, 0, 0}
+	if got := xpText(xp); got != "Bergén" {
+		t.Errorf("xpText = %q; want Bergén", got)
+	}
+	if got := xpText("not bytes"); got != "" {
+		t.Errorf("xpText(string) = %q; want empty", got)
+	}
+}
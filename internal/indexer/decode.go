@@ -0,0 +1,108 @@
+package indexer
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/nfnt/resize"
+)
+
+// exceedsDimension reports whether a width × height image has a long edge
+// above maxDim. A maxDim of 0 means no cap.
+func exceedsDimension(width, height, maxDim int) bool {
+	if maxDim <= 0 {
+		return false
+	}
+	return width > maxDim || height > maxDim
+}
+
+// headerSize reads an image's width and height from its header without
+// decoding the pixels. The size is as stored, before any EXIF orientation.
+func headerSize(path string) (width, height int, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+	cfg, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return 0, 0, err
+	}
+	return cfg.Width, cfg.Height, nil
+}
+
+// coversThumbnails reports whether img is big enough to generate every
+// thumbnail size from, i.e. its long edge is at least the 1024px of the
+// largest. Smaller embedded previews would leave the large sizes missing.
+func coversThumbnails(img image.Image) bool {
+	bounds := img.Bounds()
+	return bounds.Dx() >= 1024 || bounds.Dy() >= 1024
+}
+
+// downsampleToMax scales img so its long edge is at most maxDim, preserving
+// aspect ratio. Images already within the cap are returned unchanged.
+func downsampleToMax(img image.Image, maxDim int) image.Image {
+	bounds := img.Bounds()
+	if !exceedsDimension(bounds.Dx(), bounds.Dy(), maxDim) {
+		return img
+	}
+
+	// resize.Resize keeps the aspect ratio when one dimension is 0
+	if bounds.Dx() >= bounds.Dy() {
+		return resize.Resize(uint(maxDim), 0, img, resize.Lanczos3)
+	}
+	return resize.Resize(0, uint(maxDim), img, resize.Lanczos3)
+}
+
+// flattenAlpha composites img over bg when it has transparent pixels, since
+// JPEG thumbnails cannot store alpha and the encoder would otherwise show
+// transparent areas as black. Opaque images are returned unchanged.
+func flattenAlpha(img image.Image, bg color.Color) image.Image {
+	if o, ok := img.(interface{ Opaque() bool }); ok && o.Opaque() {
+		return img
+	}
+
+	bounds := img.Bounds()
+	flat := image.NewRGBA(bounds)
+	draw.Draw(flat, bounds, image.NewUniform(bg), image.Point{}, draw.Src)
+	draw.Draw(flat, bounds, img, bounds.Min, draw.Over)
+	return flat
+}
+
+// formatSignatures are minimal headers for the formats image.Decode may know
+// about. Decoding one fails with image.ErrFormat only when no decoder for
+// that format is registered, which tells us what this build can read.
+var formatSignatures = []struct{ name, header string }{
+	{"jpeg", "\xff\xd8"},
+	{"png", "\x89PNG\r\n\x1a\n"},
+	{"gif", "GIF89a"},
+	{"bmp", "BM\x00\x00\x00\x00\x00\x00\x00\x00"},
+	{"tiff", "II*\x00"},
+	{"webp", "RIFF\x00\x00\x00\x00WEBPVP8"},
+}
+
+// ImageDecoders lists the still-image formats image.Decode can read in this
+// build. RAW files are decoded separately and need IsRawSupported.
+func ImageDecoders() []string {
+	var names []string
+	for _, f := range formatSignatures {
+		if _, _, err := image.DecodeConfig(strings.NewReader(f.header)); err != image.ErrFormat {
+			names = append(names, f.name)
+		}
+	}
+	return names
+}
+
+// SupportedExtensions returns the file extensions the indexer picks up, sorted
+func SupportedExtensions() []string {
+	exts := make([]string, 0, len(supportedExts))
+	for ext := range supportedExts {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	return exts
+}
@@ -0,0 +1,245 @@
+package indexer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adewale/olsen/internal/quality"
+	"github.com/adewale/olsen/pkg/models"
+)
+
+// ErrNoExiftool is returned by ExtractMetadataExiftool when exiftool is not
+// on the PATH
+var ErrNoExiftool = errors.New("exiftool not found on PATH")
+
+// ExtractMetadataExiftool reads metadata with exiftool, for files go-exif
+// cannot parse: unusual containers and makernotes exiftool knows about.
+// Values are read as numbers (-n) and tags by family 0 group (-G0), so
+// EXIF, MakerNotes, XMP and Composite tags can be told apart.
+func ExtractMetadataExiftool(filePath string) (*models.PhotoMetadata, error) {
+	exiftool, err := exec.LookPath("exiftool")
+	if err != nil {
+		return nil, ErrNoExiftool
+	}
+
+	// exiftool would read a leading dash as an option
+	arg := filePath
+	if strings.HasPrefix(arg, "-") {
+		arg = "./" + arg
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(exiftool, "-json", "-n", "-G0", arg)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("exiftool failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	fileInfo, err := os.Stat(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+	metadata, err := metadataFromExiftool(stdout.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	metadata.FilePath = filePath
+	metadata.FileSize = fileInfo.Size()
+	metadata.LastModified = fileInfo.ModTime()
+	metadata.IndexedAt = time.Now()
+	return metadata, nil
+}
+
+// exiftoolTags are one file's tags from exiftool -json -G0, keyed
+// "Group:Tag"
+type exiftoolTags map[string]interface{}
+
+// str returns the first of keys present, as text. exiftool writes values
+// that look numeric as JSON numbers, serial numbers included.
+func (t exiftoolTags) str(keys ...string) string {
+	for _, key := range keys {
+		switch v := t[key].(type) {
+		case string:
+			if s := strings.Trim(v, "\x00 "); s != "" {
+				return s
+			}
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		}
+	}
+	return ""
+}
+
+// num returns the first of keys present as a number
+func (t exiftoolTags) num(keys ...string) (float64, bool) {
+	for _, key := range keys {
+		switch v := t[key].(type) {
+		case float64:
+			return v, true
+		case string:
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				return f, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// metadataFromExiftool maps exiftool -json -n -G0 output for one file onto
+// the fields ExtractMetadata fills from go-exif
+func metadataFromExiftool(data []byte) (*models.PhotoMetadata, error) {
+	var files []exiftoolTags
+	if err := json.Unmarshal(data, &files); err != nil {
+		return nil, fmt.Errorf("failed to parse exiftool output: %w", err)
+	}
+	if len(files) == 0 {
+		return nil, errors.New("exiftool returned no metadata")
+	}
+	t := files[0]
+	m := &models.PhotoMetadata{}
+
+	// Camera and lens; the Composite LensID decodes makernote lens types
+	m.CameraMake = t.str("EXIF:Make", "XMP:Make")
+	m.CameraModel = t.str("EXIF:Model", "XMP:Model")
+	m.LensMake = t.str("EXIF:LensMake")
+	m.LensModel = t.str("EXIF:LensModel", "Composite:LensID", "XMP:Lens")
+	m.CameraSerial = t.str("EXIF:SerialNumber", "EXIF:CameraSerialNumber", "MakerNotes:SerialNumber")
+	m.Software = t.str("EXIF:Software", "EXIF:ProcessingSoftware", "XMP:CreatorTool")
+	m.Title = cleanText(t.str("XMP:Title", "EXIF:XPTitle"))
+	m.Caption = cleanText(t.str("XMP:Description", "EXIF:ImageDescription", "EXIF:XPComment"))
+
+	// Exposure
+	if iso, ok := t.num("EXIF:ISO", "Composite:ISO"); ok {
+		m.ISO = int(iso)
+	}
+	if f, ok := t.num("EXIF:FNumber", "Composite:Aperture"); ok {
+		m.Aperture = f
+	}
+	if s, ok := t.num("EXIF:ExposureTime", "Composite:ShutterSpeed"); ok && s > 0 {
+		m.ShutterSpeed = shutterSpeedString(s)
+	}
+	if ev, ok := t.num("EXIF:ExposureCompensation"); ok {
+		m.ExposureCompensation = ev
+	}
+	if fl, ok := t.num("EXIF:FocalLength"); ok {
+		m.FocalLength = fl
+	}
+	if fl, ok := t.num("EXIF:FocalLengthIn35mmFormat"); ok {
+		m.FocalLength35mm = int(fl)
+	}
+
+	// Dates. XMP dates may carry fractions and a zone after the seconds,
+	// which the EXIF fields keep separately.
+	if s := t.str("EXIF:DateTimeOriginal", "EXIF:ModifyDate", "XMP:DateTimeOriginal"); s != "" {
+		if d, err := parseExifDateTime(truncateDate(s)); err == nil {
+			m.DateTaken = d
+		}
+	}
+	if s := t.str("EXIF:CreateDate"); s != "" {
+		if d, err := parseExifDateTime(truncateDate(s)); err == nil {
+			m.DateDigitized = d
+		}
+	}
+	m.TimeOffset = t.str("EXIF:OffsetTimeOriginal")
+
+	// Image properties. Composite:ImageSize is the full image even in RAW
+	// files whose IFD0 describes a preview.
+	if w, h, ok := parseImageSize(t.str("Composite:ImageSize")); ok {
+		m.Width, m.Height = w, h
+	} else {
+		if w, ok := t.num("EXIF:ExifImageWidth", "File:ImageWidth"); ok {
+			m.Width = int(w)
+		}
+		if h, ok := t.num("EXIF:ExifImageHeight", "File:ImageHeight"); ok {
+			m.Height = int(h)
+		}
+	}
+	if o, ok := t.num("EXIF:Orientation"); ok {
+		m.Orientation = int(o)
+	}
+	if cs, ok := t.num("EXIF:ColorSpace"); ok {
+		m.ColourSpace = exifColourSpace(uint16(cs))
+		if m.ColourSpace == "Uncalibrated" && t.str("EXIF:InteropIndex") == "R03" {
+			m.ColourSpace = quality.ColourSpaceAdobeRGB
+		}
+	}
+
+	// Location: the Composite values are already signed by their Ref tags
+	if lat, ok := t.num("Composite:GPSLatitude"); ok {
+		m.Latitude = lat
+	}
+	if lon, ok := t.num("Composite:GPSLongitude"); ok {
+		m.Longitude = lon
+	}
+	if alt, ok := t.num("Composite:GPSAltitude"); ok {
+		m.Altitude = alt
+	}
+
+	// Lighting; the white balance is written as go-exif formats it, so a
+	// photo reads the same whichever parser handled it
+	if flash, ok := t.num("EXIF:Flash"); ok {
+		m.FlashFired = int(flash)&0x01 != 0
+	}
+	if wb, ok := t.num("EXIF:WhiteBalance"); ok {
+		m.WhiteBalance = fmt.Sprintf("[%d]", int(wb))
+	}
+
+	// Keep every tag for the raw EXIF view
+	keys := make([]string, 0, len(t))
+	for key := range t {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		group, tag, ok := strings.Cut(key, ":")
+		if !ok || group == "ExifTool" || group == "File" {
+			continue
+		}
+		if value := t.str(key); value != "" {
+			m.RawExif = append(m.RawExif, models.ExifEntry{IFD: group, Tag: tag, Value: value})
+		}
+	}
+
+	return m, nil
+}
+
+// shutterSpeedString writes an exposure time in seconds the way
+// ExtractMetadata does: "1/250" below a second, "2" or "2.5" above
+func shutterSpeedString(seconds float64) string {
+	if seconds < 1 {
+		return fmt.Sprintf("1/%d", int(math.Round(1/seconds)))
+	}
+	return strconv.FormatFloat(seconds, 'f', -1, 64)
+}
+
+// truncateDate cuts a date down to "YYYY:MM:DD HH:MM:SS"
+func truncateDate(s string) string {
+	if len(s) > 19 {
+		return s[:19]
+	}
+	return s
+}
+
+// parseImageSize parses a Composite:ImageSize, "6000 4000" with -n
+func parseImageSize(s string) (int, int, bool) {
+	fields := strings.FieldsFunc(s, func(r rune) bool { return r == ' ' || r == 'x' })
+	if len(fields) != 2 {
+		return 0, 0, false
+	}
+	w, errW := strconv.Atoi(fields[0])
+	h, errH := strconv.Atoi(fields[1])
+	if errW != nil || errH != nil || w <= 0 || h <= 0 {
+		return 0, 0, false
+	}
+	return w, h, true
+}
@@ -0,0 +1,141 @@
+package indexer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/adewale/olsen/internal/database"
+)
+
+// exiftoolJSON is trimmed exiftool -json -n -G0 output for a RAW file
+const exiftoolJSON = `[{
+  "SourceFile": "a.rw2",
+  "ExifTool:ExifToolVersion": 12.76,
+  "File:FileType": "RW2",
+  "EXIF:Make": "Panasonic",
+  "EXIF:Model": "DC-S5M2",
+  "EXIF:SerialNumber": "0123456",
+  "EXIF:ISO": 800,
+  "EXIF:FNumber": 2.8,
+  "EXIF:ExposureTime": 0.004,
+  "EXIF:ExposureCompensation": -0.33,
+  "EXIF:FocalLength": 35,
+  "EXIF:DateTimeOriginal": "2024:06:01 18:42:07",
+  "EXIF:OffsetTimeOriginal": "+02:00",
+  "EXIF:Orientation": 6,
+  "EXIF:ColorSpace": 65535,
+  "EXIF:InteropIndex": "R03",
+  "EXIF:Flash": 16,
+  "EXIF:WhiteBalance": 1,
+  "EXIF:ImageDescription": "OLYMPUS DIGITAL CAMERA",
+  "XMP:Title": "Harbour at dawn",
+  "MakerNotes:LensType": "LUMIX S 35/F1.8",
+  "Composite:LensID": "LUMIX S 35/F1.8",
+  "Composite:ImageSize": "6000 4000",
+  "Composite:GPSLatitude": -33.8568,
+  "Composite:GPSLongitude": 151.2153,
+  "Composite:GPSAltitude": -4.5
+}]`
+
+func TestMetadataFromExiftool(t *testing.T) {
+	m, err := metadataFromExiftool([]byte(exiftoolJSON))
+	if err != nil {
+		t.Fatalf("metadataFromExiftool failed: %v", err)
+	}
+
+	if m.CameraMake != "Panasonic" || m.CameraModel != "DC-S5M2" || m.LensModel != "LUMIX S 35/F1.8" {
+		t.Errorf("camera = %q %q, lens %q; want Panasonic DC-S5M2 with the makernote lens", m.CameraMake, m.CameraModel, m.LensModel)
+	}
+	if m.CameraSerial != "0123456" {
+		t.Errorf("CameraSerial = %q; want 0123456 kept as text", m.CameraSerial)
+	}
+	if m.ISO != 800 || m.Aperture != 2.8 || m.ShutterSpeed != "1/250" || m.ExposureCompensation != -0.33 || m.FocalLength != 35 {
+		t.Errorf("exposure = ISO %d f/%g %s %+g EV %gmm; want ISO 800 f/2.8 1/250 -0.33 EV 35mm",
+			m.ISO, m.Aperture, m.ShutterSpeed, m.ExposureCompensation, m.FocalLength)
+	}
+	if want := time.Date(2024, 6, 1, 18, 42, 7, 0, time.UTC); !m.DateTaken.Equal(want) || m.TimeOffset != "+02:00" {
+		t.Errorf("taken = %v %s; want %v +02:00", m.DateTaken, m.TimeOffset, want)
+	}
+	if m.Width != 6000 || m.Height != 4000 || m.Orientation != 6 {
+		t.Errorf("size = %dx%d orientation %d; want 6000x4000 orientation 6", m.Width, m.Height, m.Orientation)
+	}
+	if m.ColourSpace != "Adobe RGB" {
+		t.Errorf("ColourSpace = %q; want Adobe RGB from the R03 interop index", m.ColourSpace)
+	}
+	if m.Latitude != -33.8568 || m.Longitude != 151.2153 || m.Altitude != -4.5 {
+		t.Errorf("location = %g, %g, %gm; want the signed Composite values", m.Latitude, m.Longitude, m.Altitude)
+	}
+	if m.Title != "Harbour at dawn" || m.Caption != "" {
+		t.Errorf("title %q, caption %q; want the XMP title and no placeholder caption", m.Title, m.Caption)
+	}
+	if m.FlashFired || m.WhiteBalance != "[1]" {
+		t.Errorf("flash %v, white balance %q; want not fired, [1]", m.FlashFired, m.WhiteBalance)
+	}
+	for _, e := range m.RawExif {
+		if e.IFD == "ExifTool" || e.IFD == "File" {
+			t.Errorf("raw EXIF includes %s:%s; want exiftool's own and file tags left out", e.IFD, e.Tag)
+		}
+	}
+}
+
+// fakeExiftool puts an exiftool on the PATH that prints output
+func fakeExiftool(t *testing.T, output string) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "out.json"), []byte(output), 0644); err != nil {
+		t.Fatal(err)
+	}
+	script := "#!/bin/sh\nexec /bin/cat '" + filepath.Join(dir, "out.json") + "'\n"
+	if err := os.WriteFile(filepath.Join(dir, "exiftool"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+}
+
+func TestExiftoolFallback(t *testing.T) {
+	dir := t.TempDir()
+	// No EXIF at all, so go-exif fails on it
+	createTestJPEGWithEXIF(t, filepath.Join(dir, "a.jpg"))
+
+	db, err := database.Open(filepath.Join(t.TempDir(), "exiftool.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// Without exiftool installed the fallback does nothing
+	t.Setenv("PATH", "")
+	if _, err := ExtractMetadataExiftool(filepath.Join(dir, "a.jpg")); !errors.Is(err, ErrNoExiftool) {
+		t.Errorf("ExtractMetadataExiftool without exiftool = %v; want ErrNoExiftool", err)
+	}
+	engine := NewEngine(db, 1)
+	engine.SetExiftoolFallback(true)
+	if err := engine.IndexDirectory(dir); err != nil {
+		t.Fatalf("IndexDirectory without exiftool failed: %v", err)
+	}
+	var cameraMake string
+	if err := db.QueryRow("SELECT COALESCE(camera_make, '') FROM photos").Scan(&cameraMake); err != nil || cameraMake != "" {
+		t.Errorf("camera_make = %q, %v; want none without exiftool", cameraMake, err)
+	}
+
+	if err := db.DeletePhoto(filepath.Join(dir, "a.jpg")); err != nil {
+		t.Fatal(err)
+	}
+	fakeExiftool(t, exiftoolJSON)
+	engine = NewEngine(db, 1)
+	engine.SetExiftoolFallback(true)
+	if err := engine.IndexDirectory(dir); err != nil {
+		t.Fatalf("IndexDirectory with exiftool failed: %v", err)
+	}
+	var model string
+	var iso int
+	if err := db.QueryRow("SELECT camera_make, camera_model, iso FROM photos").Scan(&cameraMake, &model, &iso); err != nil {
+		t.Fatalf("Failed to read photo: %v", err)
+	}
+	if cameraMake != "Panasonic" || model != "DC-S5M2" || iso != 800 {
+		t.Errorf("photo = %s %s ISO %d; want the exiftool metadata", cameraMake, model, iso)
+	}
+}
@@ -0,0 +1,416 @@
+package indexer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	exif "github.com/dsoprea/go-exif/v3"
+	exifcommon "github.com/dsoprea/go-exif/v3/common"
+)
+
+// isHEIFExtension reports whether ext names a HEIF image: .heic from phones,
+// or the generic .heif some cameras write
+func isHEIFExtension(ext string) bool {
+	switch strings.ToLower(ext) {
+	case ".heic", ".heif":
+		return true
+	}
+	return false
+}
+
+// maxMetaSize bounds the HEIF meta box read into memory. It holds item
+// descriptions and properties, a few KB even with a large ICC profile.
+const maxMetaSize = 16 << 20
+
+// heifImage is what the indexer reads from a HEIF container: the primary
+// image's properties and the EXIF block, without decoding any pixels
+type heifImage struct {
+	Width, Height int    // As coded (ispe), before any rotation
+	Rotation      int    // Quarter turns anticlockwise to display it (irot)
+	Exif          []byte // From the TIFF header on; nil without an Exif item
+	ICC           []byte // Embedded colour profile (colr), if any
+}
+
+// heifLocation is where an item's data lives (an iloc entry)
+type heifLocation struct {
+	method  uint64 // 0: file offsets, 1: offsets into the idat box
+	base    uint64
+	extents [][2]uint64 // offset, length
+}
+
+// readHEIF reads the meta box of a HEIF file of size bytes. HEIF is an ISO
+// base media file like MP4: items (the image, its tiles, the EXIF block) are
+// described in meta and stored in mdat or, rarely, meta's idat box.
+func readHEIF(r io.ReaderAt, size int64) (*heifImage, error) {
+	meta, err := findBox(r, 0, size, "meta")
+	if err != nil {
+		return nil, fmt.Errorf("not a HEIF file: %w", err)
+	}
+	if meta.size > maxMetaSize {
+		return nil, fmt.Errorf("meta box is too large (%d bytes)", meta.size)
+	}
+	data := make([]byte, meta.size)
+	if _, err := r.ReadAt(data, meta.offset); err != nil {
+		return nil, fmt.Errorf("failed to read meta box: %w", err)
+	}
+	if len(data) < 4 {
+		return nil, errors.New("meta box is truncated")
+	}
+
+	// meta is a full box: version and flags precede its children
+	children := childBoxes(data[4:])
+	if len(children["pitm"]) == 0 || len(children["iinf"]) == 0 || len(children["iloc"]) == 0 {
+		return nil, errors.New("meta box has no primary item")
+	}
+	primary := fullBoxReader(children["pitm"][0])
+	primaryID := primary.uint(2)
+	if primary.version >= 1 {
+		primaryID = primary.uint(4)
+	}
+	if primary.err != nil {
+		return nil, primary.err
+	}
+
+	heif := &heifImage{}
+	if len(children["iprp"]) > 0 {
+		heif.readProperties(childBoxes(children["iprp"][0]), primaryID)
+	}
+
+	types := parseIinf(children["iinf"][0])
+	locations, err := parseIloc(children["iloc"][0])
+	if err != nil {
+		return nil, err
+	}
+	var idat []byte
+	if len(children["idat"]) > 0 {
+		idat = children["idat"][0]
+	}
+	for id, kind := range types {
+		if kind != "Exif" {
+			continue
+		}
+		loc, ok := locations[id]
+		if !ok {
+			continue
+		}
+		block, err := readHEIFItem(r, idat, loc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read EXIF item: %w", err)
+		}
+		// The block starts with the offset of the TIFF header within it,
+		// after the "Exif\0\0" a JPEG APP1 segment would have
+		if len(block) < 4 {
+			return nil, errors.New("EXIF item is truncated")
+		}
+		start := 4 + uint64(binary.BigEndian.Uint32(block[:4]))
+		if start > uint64(len(block)) {
+			return nil, errors.New("EXIF item is truncated")
+		}
+		heif.Exif = block[start:]
+		break
+	}
+	return heif, nil
+}
+
+// readProperties reads the size, rotation and colour profile associated
+// with item id from an iprp box's children
+func (h *heifImage) readProperties(iprp map[string][][]byte, id uint64) {
+	if len(iprp["ipco"]) == 0 || len(iprp["ipma"]) == 0 {
+		return
+	}
+	properties := boxList(iprp["ipco"][0])
+
+	// Associations refer to properties by their 1-based position in ipco
+	ipma := fullBoxReader(iprp["ipma"][0])
+	for entries := ipma.uint(4); entries > 0 && ipma.err == nil; entries-- {
+		itemID := ipma.uint(2)
+		if ipma.version >= 1 {
+			itemID = ipma.uint(4)
+		}
+		for n := ipma.uint(1); n > 0 && ipma.err == nil; n-- {
+			var index uint64
+			if ipma.flags&1 != 0 {
+				index = ipma.uint(2) & 0x7fff
+			} else {
+				index = ipma.uint(1) & 0x7f
+			}
+			if itemID != id || index == 0 || index > uint64(len(properties)) {
+				continue
+			}
+			h.applyProperty(properties[index-1])
+		}
+	}
+}
+
+// applyProperty records one of the item properties the indexer uses
+func (h *heifImage) applyProperty(p rawBox) {
+	switch p.kind {
+	case "ispe":
+		r := fullBoxReader(p.data)
+		w, ht := r.uint(4), r.uint(4)
+		if r.err == nil {
+			h.Width, h.Height = int(w), int(ht)
+		}
+	case "irot":
+		if len(p.data) > 0 {
+			h.Rotation = int(p.data[0] & 3)
+		}
+	case "colr":
+		if len(p.data) > 4 && (string(p.data[:4]) == "prof" || string(p.data[:4]) == "rICC") {
+			h.ICC = p.data[4:]
+		}
+	}
+}
+
+// orientation returns the EXIF orientation equivalent to the rotation
+func (h *heifImage) orientation() int {
+	return [4]int{1, 8, 3, 6}[h.Rotation]
+}
+
+// parseIinf returns the type of each item with an infe of version 2 or
+// later; earlier versions have no item type
+func parseIinf(data []byte) map[uint64]string {
+	r := fullBoxReader(data)
+	if r.version == 0 {
+		r.uint(2)
+	} else {
+		r.uint(4)
+	}
+	if r.err != nil {
+		return nil
+	}
+
+	types := map[uint64]string{}
+	for _, b := range boxList(r.rest()) {
+		if b.kind != "infe" {
+			continue
+		}
+		infe := fullBoxReader(b.data)
+		if infe.version < 2 {
+			continue
+		}
+		id := infe.uint(2)
+		if infe.version >= 3 {
+			id = infe.uint(4)
+		}
+		infe.uint(2) // protection index
+		kind := infe.bytes(4)
+		if infe.err == nil {
+			types[id] = string(kind)
+		}
+	}
+	return types
+}
+
+// parseIloc returns each item's location
+func parseIloc(data []byte) (map[uint64]heifLocation, error) {
+	r := fullBoxReader(data)
+	sizes := r.uint(2)
+	offsetSize, lengthSize := int(sizes>>12&0xf), int(sizes>>8&0xf)
+	baseSize, indexSize := int(sizes>>4&0xf), int(sizes&0xf)
+	if r.version == 0 {
+		indexSize = 0
+	}
+	count := r.uint(2)
+	if r.version >= 2 {
+		count = r.uint(4)
+	}
+
+	locations := map[uint64]heifLocation{}
+	for ; count > 0 && r.err == nil; count-- {
+		id := r.uint(2)
+		if r.version >= 2 {
+			id = r.uint(4)
+		}
+		var loc heifLocation
+		if r.version >= 1 {
+			loc.method = r.uint(2) & 0xf
+		}
+		r.uint(2) // data reference index
+		loc.base = r.uint(baseSize)
+		for extents := r.uint(2); extents > 0 && r.err == nil; extents-- {
+			r.uint(indexSize)
+			offset := r.uint(offsetSize)
+			length := r.uint(lengthSize)
+			loc.extents = append(loc.extents, [2]uint64{offset, length})
+		}
+		locations[id] = loc
+	}
+	if r.err != nil {
+		return nil, fmt.Errorf("malformed iloc box: %w", r.err)
+	}
+	return locations, nil
+}
+
+// readHEIFItem reads and joins an item's extents
+func readHEIFItem(r io.ReaderAt, idat []byte, loc heifLocation) ([]byte, error) {
+	var data []byte
+	for _, e := range loc.extents {
+		offset, length := loc.base+e[0], e[1]
+		if length > maxMetaSize || uint64(len(data))+length > maxMetaSize {
+			return nil, fmt.Errorf("item is too large (%d bytes)", length)
+		}
+		switch loc.method {
+		case 0:
+			chunk := make([]byte, length)
+			if _, err := r.ReadAt(chunk, int64(offset)); err != nil {
+				return nil, err
+			}
+			data = append(data, chunk...)
+		case 1:
+			if offset+length > uint64(len(idat)) {
+				return nil, errors.New("item runs past the idat box")
+			}
+			data = append(data, idat[offset:offset+length]...)
+		default:
+			return nil, fmt.Errorf("unsupported item construction method %d", loc.method)
+		}
+	}
+	return data, nil
+}
+
+// boxReader reads big-endian fields from a box payload. After a short
+// read every further field is zero and err is set.
+type boxReader struct {
+	data    []byte
+	version byte
+	flags   uint32
+	err     error
+}
+
+// fullBoxReader reads a full box's version and flags, then its fields
+func fullBoxReader(data []byte) *boxReader {
+	r := &boxReader{data: data}
+	vf := r.uint(4)
+	r.version, r.flags = byte(vf>>24), uint32(vf&0xffffff)
+	return r
+}
+
+// bytes returns the next n bytes
+func (r *boxReader) bytes(n int) []byte {
+	if r.err != nil || n > len(r.data) {
+		r.err = errors.New("box is truncated")
+		return make([]byte, n)
+	}
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b
+}
+
+// uint reads an n-byte unsigned integer; n may be 0
+func (r *boxReader) uint(n int) uint64 {
+	var v uint64
+	for _, b := range r.bytes(n) {
+		v = v<<8 | uint64(b)
+	}
+	return v
+}
+
+// rest returns the unread payload
+func (r *boxReader) rest() []byte {
+	return r.data
+}
+
+// ErrNoHeifDecoder is returned by DecodeHEIF when neither of libheif's
+// command-line decoders is on the PATH
+var ErrNoHeifDecoder = errors.New("heif-dec or heif-convert not found on PATH")
+
+// HeifDecoder returns the path of libheif's command-line decoder: heif-dec,
+// or heif-convert as it was called before libheif 1.17
+func HeifDecoder() (string, error) {
+	for _, name := range []string{"heif-dec", "heif-convert"} {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", ErrNoHeifDecoder
+}
+
+// DecodeHEIF decodes the primary image of a HEIF file with libheif's
+// decoder, which applies the container's rotation and mirroring, so the
+// image comes back upright. HEIF images are HEVC-coded, which Go has no
+// decoder for; without libheif it returns ErrNoHeifDecoder.
+func DecodeHEIF(path string) (image.Image, error) {
+	decoder, err := HeifDecoder()
+	if err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp("", "olsen-heif-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	// The decoder would read a leading dash as an option
+	arg := path
+	if strings.HasPrefix(arg, "-") {
+		arg = "./" + arg
+	}
+	out := filepath.Join(dir, "image.jpg")
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(decoder, "-q", "95", arg, out)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s failed: %v: %s", filepath.Base(decoder), err, strings.TrimSpace(stderr.String()))
+	}
+	f, err := os.Open(out)
+	if err != nil {
+		return nil, fmt.Errorf("%s wrote no image: %w", filepath.Base(decoder), err)
+	}
+	defer f.Close()
+	img, err := jpeg.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s output: %w", filepath.Base(decoder), err)
+	}
+	return img, nil
+}
+
+// ExtractHEIFPreview returns the JPEG thumbnail some HEIF files keep in
+// their EXIF block (IFD1), for when the image itself cannot be decoded. It
+// is small and not rotated: EXIF Orientation applies to it.
+func ExtractHEIFPreview(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	heif, err := readHEIF(f, info.Size())
+	if err != nil {
+		return nil, err
+	}
+	if heif.Exif == nil {
+		return nil, errors.New("no EXIF block to take a preview from")
+	}
+
+	ifdMapping, err := exifcommon.NewIfdMappingWithStandard()
+	if err != nil {
+		return nil, err
+	}
+	_, index, err := exif.Collect(ifdMapping, exif.NewTagIndex(), heif.Exif)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse EXIF: %w", err)
+	}
+	ifd1 := index.RootIfd.NextIfd()
+	if ifd1 == nil {
+		return nil, errors.New("EXIF has no thumbnail")
+	}
+	data, err := ifd1.Thumbnail()
+	if err != nil {
+		return nil, fmt.Errorf("EXIF has no thumbnail: %w", err)
+	}
+	return jpeg.Decode(bytes.NewReader(data))
+}
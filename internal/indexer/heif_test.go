@@ -0,0 +1,231 @@
+package indexer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/adewale/olsen/internal/database"
+	"github.com/adewale/olsen/internal/quality"
+)
+
+// testTIFF builds a little-endian TIFF header and IFD0 with Make, Model and
+// Orientation, as the EXIF block of a photo. Orientation 0 leaves it out.
+func testTIFF(make, model string, orientation uint16) []byte {
+	make += "\x00"
+	model += "\x00"
+	entries := 2
+	if orientation != 0 {
+		entries++
+	}
+	dataStart := 8 + 2 + entries*12 + 4
+
+	b := []byte("II*\x00")
+	b = binary.LittleEndian.AppendUint32(b, 8)
+	b = binary.LittleEndian.AppendUint16(b, uint16(entries))
+	entry := func(tag, kind uint16, count, value uint32) {
+		b = binary.LittleEndian.AppendUint16(b, tag)
+		b = binary.LittleEndian.AppendUint16(b, kind)
+		b = binary.LittleEndian.AppendUint32(b, count)
+		b = binary.LittleEndian.AppendUint32(b, value)
+	}
+	entry(0x010f, 2, uint32(len(make)), uint32(dataStart))
+	entry(0x0110, 2, uint32(len(model)), uint32(dataStart+len(make)))
+	if orientation != 0 {
+		entry(0x0112, 3, 1, uint32(orientation))
+	}
+	b = binary.LittleEndian.AppendUint32(b, 0) // No IFD1
+	b = append(b, make...)
+	return append(b, model...)
+}
+
+// fullBox prefixes payload with a full box's version and zero flags
+func fullBox(version byte, payload ...[]byte) []byte {
+	return append([]byte{version, 0, 0, 0}, bytes.Join(payload, nil)...)
+}
+
+// writeTestHEIC writes a HEIF file as phones lay it out: a meta box
+// describing a 4032x3024 HEVC image turned a quarter clockwise (irot 3),
+// with an Exif item and an ICC profile, followed by mdat. The HEVC data is
+// filler; nothing here decodes it.
+func writeTestHEIC(t *testing.T, path string, tiff, icc []byte) {
+	t.Helper()
+
+	hevc := bytes.Repeat([]byte{0x26, 0x01}, 64)
+	exifItem := append([]byte{0, 0, 0, 6}, "Exif\x00\x00"...)
+	exifItem = append(exifItem, tiff...)
+
+	infe := func(id uint16, kind string) []byte {
+		return mp4Box("infe", fullBox(2, binary.BigEndian.AppendUint16(nil, id), []byte{0, 0}, []byte(kind), []byte{0}))
+	}
+	// iloc version 0 with 4-byte offsets and lengths; the offsets are filled
+	// in once the meta box's size is known
+	iloc := func(hevcOffset, exifOffset uint32) []byte {
+		b := []byte{0x44, 0x00, 0, 2}
+		for _, item := range []struct {
+			id             uint16
+			offset, length uint32
+		}{{1, hevcOffset, uint32(len(hevc))}, {2, exifOffset, uint32(len(exifItem))}} {
+			b = binary.BigEndian.AppendUint16(b, item.id)
+			b = append(b, 0, 0, 0, 1) // data reference index, one extent
+			b = binary.BigEndian.AppendUint32(b, item.offset)
+			b = binary.BigEndian.AppendUint32(b, item.length)
+		}
+		return mp4Box("iloc", fullBox(0, b))
+	}
+	ispe := mp4Box("ispe", fullBox(0, binary.BigEndian.AppendUint32(binary.BigEndian.AppendUint32(nil, 4032), 3024)))
+	properties := [][]byte{mp4Box("hvcC", make([]byte, 23)), ispe, mp4Box("irot", []byte{3})}
+	// One entry, for item 1: hvcC (essential), ispe, irot (essential)
+	associations := []byte{0, 0, 0, 1, 0, 1, 3, 0x81, 0x02, 0x83}
+	if icc != nil {
+		properties = append(properties, mp4Box("colr", []byte("prof"), icc))
+		associations = append(associations, 0x04)
+		associations[6] = 4
+	}
+	ipco := mp4Box("ipco", properties...)
+	ipma := mp4Box("ipma", fullBox(0, associations))
+
+	meta := func(hevcOffset, exifOffset uint32) []byte {
+		return mp4Box("meta", fullBox(0,
+			mp4Box("hdlr", fullBox(0, make([]byte, 4), []byte("pict"), make([]byte, 13))),
+			mp4Box("pitm", fullBox(0, []byte{0, 1})),
+			mp4Box("iinf", fullBox(0, []byte{0, 2}, infe(1, "hvc1"), infe(2, "Exif"))),
+			iloc(hevcOffset, exifOffset),
+			mp4Box("iprp", ipco, ipma),
+		))
+	}
+
+	ftyp := mp4Box("ftyp", []byte("heic\x00\x00\x00\x00mif1heic"))
+	mdatStart := uint32(len(ftyp)+len(meta(0, 0))) + 8
+	data := append(ftyp, meta(mdatStart, mdatStart+uint32(len(hevc)))...)
+	data = append(data, mp4Box("mdat", hevc, exifItem)...)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("Failed to write test HEIC: %v", err)
+	}
+}
+
+func TestReadHEIF(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "IMG_0001.HEIC")
+	writeTestHEIC(t, path, testTIFF("Apple", "iPhone 15 Pro", 6), testICCProfile("Display P3", true))
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	info, _ := f.Stat()
+	heif, err := readHEIF(f, info.Size())
+	if err != nil {
+		t.Fatalf("readHEIF failed: %v", err)
+	}
+	if heif.Width != 4032 || heif.Height != 3024 || heif.orientation() != 6 {
+		t.Errorf("image = %dx%d orientation %d; want 4032x3024 orientation 6", heif.Width, heif.Height, heif.orientation())
+	}
+	if !bytes.HasPrefix(heif.Exif, []byte("II*\x00")) {
+		t.Errorf("EXIF block starts %q; want the TIFF header", heif.Exif[:min(8, len(heif.Exif))])
+	}
+
+	m, err := ExtractMetadata(path)
+	if err != nil {
+		t.Fatalf("ExtractMetadata failed: %v", err)
+	}
+	if m.CameraMake != "Apple" || m.CameraModel != "iPhone 15 Pro" || m.Orientation != 6 {
+		t.Errorf("metadata = %q %q orientation %d; want Apple iPhone 15 Pro orientation 6", m.CameraMake, m.CameraModel, m.Orientation)
+	}
+	if m.Width != 4032 || m.Height != 3024 {
+		t.Errorf("size = %dx%d; want 4032x3024 from ispe", m.Width, m.Height)
+	}
+
+	profile, err := ReadColourProfile(path)
+	if err != nil || profile == nil || profile.ColourSpace != quality.ColourSpaceDisplayP3 {
+		t.Errorf("ReadColourProfile = %+v, %v; want Display P3", profile, err)
+	}
+
+	// Orientation falls back to irot when EXIF leaves it out
+	writeTestHEIC(t, path, testTIFF("Apple", "iPhone 15 Pro", 0), nil)
+	if m, err = ExtractMetadata(path); err != nil || m.Orientation != 6 {
+		t.Errorf("orientation without the EXIF tag = %d, %v; want 6 from irot", m.Orientation, err)
+	}
+	if profile, err = ReadColourProfile(path); err != nil || profile != nil {
+		t.Errorf("ReadColourProfile without colr = %+v, %v; want none", profile, err)
+	}
+}
+
+// fakeHeifDec puts a heif-dec on the PATH that writes src as its output
+func fakeHeifDec(t *testing.T, src string) {
+	t.Helper()
+	dir := t.TempDir()
+	script := "#!/bin/sh\nexec /bin/cp '" + src + "' \"$4\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "heif-dec"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+}
+
+func TestIndexHEIC(t *testing.T) {
+	dir := t.TempDir()
+	writeTestHEIC(t, filepath.Join(dir, "IMG_0001.heic"), testTIFF("Apple", "iPhone 15 Pro", 6), nil)
+
+	db, err := database.Open(filepath.Join(t.TempDir(), "heic.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	count := func() (format, camera string, thumbnails int) {
+		t.Helper()
+		err := db.QueryRow(`
+			SELECT file_format, camera_model,
+			       (SELECT COUNT(*) FROM thumbnails t WHERE t.photo_id = p.id)
+			FROM photos p
+		`).Scan(&format, &camera, &thumbnails)
+		if err != nil {
+			t.Fatalf("HEIC not indexed: %v", err)
+		}
+		return format, camera, thumbnails
+	}
+
+	// Without libheif and with no EXIF thumbnail, only metadata is stored
+	t.Setenv("PATH", "")
+	if err := NewEngine(db, 1).IndexDirectory(dir); err != nil {
+		t.Fatalf("IndexDirectory failed: %v", err)
+	}
+	if format, camera, thumbnails := count(); format != "heic" || camera != "iPhone 15 Pro" || thumbnails != 0 {
+		t.Errorf("photo = %s %s with %d thumbnails; want heic iPhone 15 Pro with none", format, camera, thumbnails)
+	}
+
+	// The decoder's output is already upright, so it is not turned again
+	decoded := filepath.Join(t.TempDir(), "decoded.jpg")
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 300, 400)), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(decoded, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fakeHeifDec(t, decoded)
+	if err := db.DeletePhoto(filepath.Join(dir, "IMG_0001.heic")); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewEngine(db, 1).IndexDirectory(dir); err != nil {
+		t.Fatalf("IndexDirectory with heif-dec failed: %v", err)
+	}
+	if _, _, thumbnails := count(); thumbnails == 0 {
+		t.Fatal("no thumbnails generated from the decoded image")
+	}
+	var thumb []byte
+	if err := db.QueryRow("SELECT " + database.ThumbnailData + " FROM thumbnails t WHERE size = '256'").Scan(&thumb); err != nil {
+		t.Fatalf("Failed to read thumbnail: %v", err)
+	}
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(thumb))
+	if err != nil {
+		t.Fatalf("Failed to decode thumbnail: %v", err)
+	}
+	if cfg.Width >= cfg.Height {
+		t.Errorf("thumbnail is %dx%d; want portrait like the decoded image", cfg.Width, cfg.Height)
+	}
+}
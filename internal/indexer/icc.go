@@ -0,0 +1,311 @@
+package indexer
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/adewale/olsen/internal/quality"
+)
+
+// maxICCProfileSize bounds the profile read from a file. Real profiles are
+// a few KB; anything this large is corrupt or not worth keeping in memory.
+const maxICCProfileSize = 4 << 20
+
+// ColourProfile describes an ICC profile embedded in an image file
+type ColourProfile struct {
+	Description string // The profile's own name, e.g. "Adobe RGB (1998)"
+	ColourSpace string // Normalised name stored in photos.color_space
+}
+
+// ReadColourProfile returns the ICC profile embedded in a JPEG (APP2
+// segments), PNG (iCCP chunk) or HEIF (colr property), or nil if the file
+// has none. Only the header is read, so this is cheap even for large files.
+func ReadColourProfile(filePath string) (*ColourProfile, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var profile []byte
+	if isHEIFExtension(filepath.Ext(filePath)) {
+		profile, err = readHEIFICC(file)
+	} else {
+		r := bufio.NewReader(file)
+		magic, peekErr := r.Peek(8)
+		if peekErr != nil {
+			return nil, nil // Too short to be an image with a profile
+		}
+		switch {
+		case magic[0] == 0xFF && magic[1] == 0xD8:
+			profile, err = readJPEGICC(r)
+		case bytes.Equal(magic, []byte("\x89PNG\r\n\x1a\n")):
+			profile, err = readPNGICC(r)
+		default:
+			return nil, nil
+		}
+	}
+	if err != nil || profile == nil {
+		return nil, err
+	}
+
+	desc := iccDescription(profile)
+	return &ColourProfile{Description: desc, ColourSpace: classifyICC(desc)}, nil
+}
+
+// readHEIFICC returns the profile in the primary image's colr property
+func readHEIFICC(file *os.File) ([]byte, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	heif, err := readHEIF(file, info.Size())
+	if err != nil {
+		return nil, err
+	}
+	if len(heif.ICC) > maxICCProfileSize {
+		return nil, fmt.Errorf("ICC profile is too large (%d bytes)", len(heif.ICC))
+	}
+	return heif.ICC, nil
+}
+
+// readJPEGICC reassembles the ICC_PROFILE APP2 chunks that precede the
+// image data. Profiles over 64 KB are split across several numbered chunks.
+func readJPEGICC(r *bufio.Reader) ([]byte, error) {
+	if _, err := r.Discard(2); err != nil { // SOI
+		return nil, err
+	}
+
+	chunks := map[byte][]byte{}
+	var count byte
+	total := 0
+	for {
+		// Markers may be padded with any number of 0xFF fill bytes
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, nil
+		}
+		if b != 0xFF {
+			return nil, nil // Not at a marker: malformed, so give up quietly
+		}
+		marker := b
+		for marker == 0xFF {
+			if marker, err = r.ReadByte(); err != nil {
+				return nil, nil
+			}
+		}
+
+		// Standalone markers carry no length
+		if marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) {
+			continue
+		}
+		// Start of scan or end of image: no more metadata segments
+		if marker == 0xDA || marker == 0xD9 {
+			break
+		}
+
+		var length uint16
+		if err := binary.Read(r, binary.BigEndian, &length); err != nil || length < 2 {
+			return nil, nil
+		}
+		segment := int(length) - 2
+
+		if marker != 0xE2 || segment < 14 {
+			if _, err := r.Discard(segment); err != nil {
+				return nil, nil
+			}
+			continue
+		}
+
+		data := make([]byte, segment)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, nil
+		}
+		if !bytes.HasPrefix(data, []byte("ICC_PROFILE\x00")) {
+			continue
+		}
+		total += len(data) - 14
+		if total > maxICCProfileSize {
+			return nil, errors.New("ICC profile too large")
+		}
+		chunks[data[12]] = data[14:]
+		count = data[13]
+	}
+
+	if count == 0 {
+		return nil, nil
+	}
+	var profile []byte
+	for seq := byte(1); seq <= count; seq++ {
+		chunk, ok := chunks[seq]
+		if !ok {
+			return nil, fmt.Errorf("ICC profile chunk %d of %d missing", seq, count)
+		}
+		profile = append(profile, chunk...)
+	}
+	return profile, nil
+}
+
+// readPNGICC returns the decompressed iCCP chunk, which must come before
+// the first IDAT chunk
+func readPNGICC(r *bufio.Reader) ([]byte, error) {
+	if _, err := r.Discard(8); err != nil {
+		return nil, err
+	}
+
+	for {
+		var header struct {
+			Length uint32
+			Type   [4]byte
+		}
+		if err := binary.Read(r, binary.BigEndian, &header); err != nil {
+			return nil, nil
+		}
+		chunkType := string(header.Type[:])
+		if chunkType == "IDAT" || chunkType == "IEND" {
+			return nil, nil
+		}
+		if chunkType != "iCCP" {
+			if _, err := r.Discard(int(header.Length) + 4); err != nil { // data + CRC
+				return nil, nil
+			}
+			continue
+		}
+
+		if header.Length > maxICCProfileSize {
+			return nil, errors.New("ICC profile too large")
+		}
+		data := make([]byte, header.Length)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, nil
+		}
+
+		// Profile name, NUL, compression method (0 = zlib), compressed profile
+		nul := bytes.IndexByte(data, 0)
+		if nul < 0 || nul+2 > len(data) || data[nul+1] != 0 {
+			return nil, errors.New("malformed iCCP chunk")
+		}
+		zr, err := zlib.NewReader(bytes.NewReader(data[nul+2:]))
+		if err != nil {
+			return nil, fmt.Errorf("malformed iCCP chunk: %w", err)
+		}
+		defer zr.Close()
+		profile, err := io.ReadAll(io.LimitReader(zr, maxICCProfileSize))
+		if err != nil {
+			return nil, fmt.Errorf("malformed iCCP chunk: %w", err)
+		}
+		return profile, nil
+	}
+}
+
+// iccDescription returns the text of the profile's 'desc' tag, which holds
+// the human-readable profile name in both ICC v2 (textDescriptionType) and
+// v4 (multiLocalizedUnicodeType) profiles. Returns "" if there is none.
+func iccDescription(profile []byte) string {
+	if len(profile) < 132 {
+		return ""
+	}
+	tagCount := binary.BigEndian.Uint32(profile[128:132])
+	for i := 0; i < int(tagCount); i++ {
+		entry := 132 + i*12
+		if entry+12 > len(profile) {
+			return ""
+		}
+		if string(profile[entry:entry+4]) != "desc" {
+			continue
+		}
+		offset := int(binary.BigEndian.Uint32(profile[entry+4 : entry+8]))
+		size := int(binary.BigEndian.Uint32(profile[entry+8 : entry+12]))
+		if offset < 0 || size < 12 || offset+size > len(profile) {
+			return ""
+		}
+		return decodeICCText(profile[offset : offset+size])
+	}
+	return ""
+}
+
+// decodeICCText decodes a 'desc' or 'mluc' tag, using the first record of
+// an mluc tag
+func decodeICCText(tag []byte) string {
+	switch string(tag[:4]) {
+	case "desc":
+		n := int(binary.BigEndian.Uint32(tag[8:12]))
+		if 12+n > len(tag) {
+			return ""
+		}
+		return strings.TrimRight(string(tag[12:12+n]), "\x00 ")
+	case "mluc":
+		if len(tag) < 28 || binary.BigEndian.Uint32(tag[8:12]) == 0 {
+			return ""
+		}
+		length := int(binary.BigEndian.Uint32(tag[20:24]))
+		offset := int(binary.BigEndian.Uint32(tag[24:28]))
+		if offset+length > len(tag) || length%2 != 0 {
+			return ""
+		}
+		units := make([]uint16, length/2)
+		for i := range units {
+			units[i] = binary.BigEndian.Uint16(tag[offset+2*i:])
+		}
+		return strings.TrimRight(string(utf16.Decode(units)), "\x00 ")
+	}
+	return ""
+}
+
+// classifyICC maps a profile description to the colour space name stored in
+// the catalog. The common wide-gamut profiles get a fixed name, so all
+// variants ("Adobe RGB (1998)", "Compatible with Adobe RGB (1998)") facet
+// together; anything else keeps its own description.
+func classifyICC(desc string) string {
+	lower := strings.ToLower(desc)
+	switch {
+	case strings.Contains(lower, "srgb"), strings.Contains(lower, "61966-2"):
+		return quality.ColourSpaceSRGB
+	case strings.Contains(lower, "adobe rgb"), strings.Contains(lower, "adobergb"):
+		return quality.ColourSpaceAdobeRGB
+	case strings.Contains(lower, "display p3"):
+		return quality.ColourSpaceDisplayP3
+	case strings.Contains(lower, "prophoto"):
+		return "ProPhoto RGB"
+	case desc == "":
+		return "ICC profile"
+	}
+	return desc
+}
+
+// exifColourSpace names the EXIF ColorSpace tag value. Cameras set in Adobe
+// RGB mode usually record 0xFFFF (uncalibrated) and mark the file as Adobe
+// RGB through the interoperability index instead; see ExtractMetadata.
+func exifColourSpace(val interface{}) string {
+	var v uint16
+	switch t := val.(type) {
+	case []uint16:
+		if len(t) == 0 {
+			return ""
+		}
+		v = t[0]
+	case uint16:
+		v = t
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+
+	switch v {
+	case 1:
+		return quality.ColourSpaceSRGB
+	case 2:
+		return quality.ColourSpaceAdobeRGB // Non-standard, but some cameras write it
+	case 0xFFFF:
+		return "Uncalibrated"
+	}
+	return fmt.Sprintf("%d", v)
+}
@@ -0,0 +1,217 @@
+package indexer
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/adewale/olsen/internal/database"
+)
+
+// testICCProfile builds a minimal ICC profile whose only tag is 'desc'.
+// With mluc set it uses the v4 multiLocalizedUnicodeType, otherwise the v2
+// textDescriptionType.
+func testICCProfile(desc string, mluc bool) []byte {
+	var tag bytes.Buffer
+	if mluc {
+		units := utf16.Encode([]rune(desc))
+		tag.WriteString("mluc")
+		binary.Write(&tag, binary.BigEndian, []uint32{0, 1, 12})
+		tag.WriteString("enUS")
+		binary.Write(&tag, binary.BigEndian, []uint32{uint32(2 * len(units)), 28})
+		binary.Write(&tag, binary.BigEndian, units)
+	} else {
+		tag.WriteString("desc")
+		binary.Write(&tag, binary.BigEndian, []uint32{0, uint32(len(desc) + 1)})
+		tag.WriteString(desc + "\x00")
+		tag.Write(make([]byte, 4+4+2+1+67)) // Empty Unicode and ScriptCode descriptions
+	}
+
+	const tagOffset = 128 + 4 + 12
+	header := make([]byte, 128)
+	binary.BigEndian.PutUint32(header[0:], uint32(tagOffset+tag.Len()))
+	copy(header[12:], "mntrRGB XYZ ")
+	copy(header[36:], "acsp")
+
+	var profile bytes.Buffer
+	profile.Write(header)
+	binary.Write(&profile, binary.BigEndian, uint32(1))
+	profile.WriteString("desc")
+	binary.Write(&profile, binary.BigEndian, []uint32{tagOffset, uint32(tag.Len())})
+	profile.Write(tag.Bytes())
+	return profile.Bytes()
+}
+
+// writeJPEGWithICC encodes img as a JPEG with profile embedded in an APP2
+// segment straight after SOI, as cameras and editors write it
+func writeJPEGWithICC(t *testing.T, path string, img image.Image, profile []byte) {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatalf("Failed to encode JPEG: %v", err)
+	}
+	encoded := buf.Bytes()
+
+	var out bytes.Buffer
+	out.Write(encoded[:2]) // SOI
+	if profile != nil {
+		out.Write([]byte{0xFF, 0xE2})
+		binary.Write(&out, binary.BigEndian, uint16(2+14+len(profile)))
+		out.WriteString("ICC_PROFILE\x00")
+		out.Write([]byte{1, 1}) // Chunk 1 of 1
+		out.Write(profile)
+	}
+	out.Write(encoded[2:])
+	if err := os.WriteFile(path, out.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to write JPEG: %v", err)
+	}
+}
+
+// writePNGWithICC encodes img as a PNG with profile in an iCCP chunk after IHDR
+func writePNGWithICC(t *testing.T, path string, img image.Image, profile []byte) {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("Failed to encode PNG: %v", err)
+	}
+	encoded := buf.Bytes()
+
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	zw.Write(profile)
+	zw.Close()
+	chunk := append([]byte("iCCP"), append([]byte("Display P3\x00\x00"), compressed.Bytes()...)...)
+
+	const ihdrEnd = 8 + 8 + 13 + 4
+	var out bytes.Buffer
+	out.Write(encoded[:ihdrEnd])
+	binary.Write(&out, binary.BigEndian, uint32(len(chunk)-4))
+	out.Write(chunk)
+	binary.Write(&out, binary.BigEndian, crc32.ChecksumIEEE(chunk))
+	out.Write(encoded[ihdrEnd:])
+	if err := os.WriteFile(path, out.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to write PNG: %v", err)
+	}
+}
+
+func TestReadColourProfile(t *testing.T) {
+	dir := t.TempDir()
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+
+	adobe := filepath.Join(dir, "adobe.jpg")
+	writeJPEGWithICC(t, adobe, img, testICCProfile("Adobe RGB (1998)", false))
+	p3 := filepath.Join(dir, "p3.png")
+	writePNGWithICC(t, p3, img, testICCProfile("Display P3", true))
+	plain := filepath.Join(dir, "plain.jpg")
+	writeJPEGWithICC(t, plain, img, nil)
+
+	tests := []struct {
+		path        string
+		description string
+		colourSpace string
+	}{
+		{adobe, "Adobe RGB (1998)", "Adobe RGB"},
+		{p3, "Display P3", "Display P3"},
+	}
+	for _, tt := range tests {
+		profile, err := ReadColourProfile(tt.path)
+		if err != nil {
+			t.Fatalf("%s: ReadColourProfile failed: %v", filepath.Base(tt.path), err)
+		}
+		if profile == nil {
+			t.Fatalf("%s: no profile found", filepath.Base(tt.path))
+		}
+		if profile.Description != tt.description || profile.ColourSpace != tt.colourSpace {
+			t.Errorf("%s: got %+v; want %q / %q", filepath.Base(tt.path), *profile, tt.description, tt.colourSpace)
+		}
+	}
+
+	if profile, err := ReadColourProfile(plain); err != nil || profile != nil {
+		t.Errorf("plain JPEG: got %+v, %v; want no profile", profile, err)
+	}
+}
+
+func TestClassifyICC(t *testing.T) {
+	tests := map[string]string{
+		"sRGB IEC61966-2.1":                "sRGB",
+		"Compatible with Adobe RGB (1998)": "Adobe RGB",
+		"Display P3":                       "Display P3",
+		"ProPhoto RGB":                     "ProPhoto RGB",
+		"Camera RGB Profile":               "Camera RGB Profile",
+		"":                                 "ICC profile",
+	}
+	for desc, want := range tests {
+		if got := classifyICC(desc); got != want {
+			t.Errorf("classifyICC(%q) = %q; want %q", desc, got, want)
+		}
+	}
+}
+
+func TestAdobeRGBThumbnailConvertedToSRGB(t *testing.T) {
+	// Left half a muted red, right half neutral grey. In Adobe RGB the red is
+	// more saturated than the same numbers in sRGB, so the thumbnail's red
+	// channel must rise; grey sits on the neutral axis and must not move.
+	src := image.NewRGBA(image.Rect(0, 0, 400, 300))
+	for y := 0; y < 300; y++ {
+		for x := 0; x < 400; x++ {
+			if x < 200 {
+				src.Set(x, y, color.RGBA{200, 100, 100, 255})
+			} else {
+				src.Set(x, y, color.RGBA{128, 128, 128, 255})
+			}
+		}
+	}
+
+	dir := t.TempDir()
+	writeJPEGWithICC(t, filepath.Join(dir, "adobe.jpg"), src, testICCProfile("Adobe RGB (1998)", false))
+
+	db, err := database.Open(filepath.Join(t.TempDir(), "icc.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if err := NewEngine(db, 1).IndexDirectory(dir); err != nil {
+		t.Fatalf("IndexDirectory failed: %v", err)
+	}
+
+	var colourSpace string
+	if err := db.QueryRow("SELECT color_space FROM photos").Scan(&colourSpace); err != nil {
+		t.Fatalf("Failed to read color_space: %v", err)
+	}
+	if colourSpace != "Adobe RGB" {
+		t.Errorf("color_space = %q; want Adobe RGB", colourSpace)
+	}
+
+	var data []byte
+	if err := db.QueryRow("SELECT data FROM thumbnails WHERE size = '256'").Scan(&data); err != nil {
+		t.Fatalf("Failed to read thumbnail: %v", err)
+	}
+	thumb, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Failed to decode thumbnail: %v", err)
+	}
+
+	// Adobe RGB (200, 100, 100) is sRGB (228, 100, 100); allow for JPEG loss
+	b := thumb.Bounds()
+	r, g, bl, _ := thumb.At(b.Dx()/4, b.Dy()/2).RGBA()
+	if r>>8 < 220 || r>>8 > 236 || g>>8 < 92 || g>>8 > 108 || bl>>8 < 92 || bl>>8 > 108 {
+		t.Errorf("red half = (%d, %d, %d); want about (228, 100, 100)", r>>8, g>>8, bl>>8)
+	}
+	r, g, bl, _ = thumb.At(3*b.Dx()/4, b.Dy()/2).RGBA()
+	for _, c := range []uint32{r >> 8, g >> 8, bl >> 8} {
+		if c < 124 || c > 132 {
+			t.Errorf("grey half = (%d, %d, %d); want about (128, 128, 128)", r>>8, g>>8, bl>>8)
+			break
+		}
+	}
+}
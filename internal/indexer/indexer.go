@@ -10,14 +10,17 @@ import (
 	"bytes"
 	"context"
 	"crypto/sha256"
+	"errors"
 	"fmt"
 	_ "golang.org/x/image/bmp"
 	_ "golang.org/x/image/tiff"
 	"image"
+	"image/color"
 	"image/jpeg"
 	_ "image/png"
 	"io"
 	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
@@ -45,6 +48,32 @@ type Engine struct {
 	qualityConfig    quality.ThumbnailConfig
 	qualityLogger    *quality.Logger
 	artifactManager  *quality.ArtifactManager
+	followSymlinks   bool
+
+	// maxDecodeDimension caps the long edge of decoded images (0 = no cap)
+	maxDecodeDimension int
+
+	// minFileSize and maxFileSize bound the files indexed, in bytes (0 = no bound)
+	minFileSize int64
+	maxFileSize int64
+
+	// thumbBackground fills transparent areas of images with an alpha channel
+	thumbBackground color.Color
+
+	// skipThumbnails stores metadata only, leaving thumbnails pending
+	skipThumbnails bool
+
+	// maxFiles aborts a run that finds more files than this (0 = no cap)
+	maxFiles int
+
+	// modifiedAfter skips files last modified at or before it (zero = none)
+	modifiedAfter time.Time
+
+	// includeVideo picks up MP4 and MOV files as well as photos
+	includeVideo bool
+
+	// exiftoolFallback reads metadata with exiftool when go-exif fails
+	exiftoolFallback bool
 }
 
 // NewEngine creates a new indexer engine
@@ -99,6 +128,7 @@ func NewEngine(db *database.DB, workerCount int) *Engine {
 		qualityConfig:   qualityConfig,
 		qualityLogger:   qualityLogger,
 		artifactManager: artifactManager,
+		thumbBackground: color.White,
 		stats: models.IndexStats{
 			StartTime: time.Now(),
 		},
@@ -120,21 +150,225 @@ func (e *Engine) EnablePerfTracking() {
 	e.perfStats = make([]models.PerfStats, 0)
 }
 
+// SetFollowSymlinks controls whether symlinked directories are traversed
+// when discovering files. It is off by default.
+func (e *Engine) SetFollowSymlinks(follow bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.followSymlinks = follow
+}
+
+// SetIncludeVideo controls whether MP4 and MOV files are indexed. Videos get
+// their capture date, size and duration from the file headers, and their
+// thumbnails and colours from a poster frame decoded by ffmpeg; without
+// ffmpeg they are indexed with metadata only. It is off by default.
+func (e *Engine) SetIncludeVideo(include bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.includeVideo = include
+}
+
+// SetExiftoolFallback controls whether metadata go-exif cannot parse is read
+// with exiftool instead, before falling back to file information alone. It
+// does nothing when exiftool isn't installed. It is off by default.
+func (e *Engine) SetExiftoolFallback(fallback bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.exiftoolFallback = fallback
+}
+
+// SetMaxDecodeDimension bounds the size of the image held in memory while a
+// file is processed. RAW files over the cap use their embedded preview when it
+// covers the largest thumbnail, and are otherwise downsampled straight after
+// decoding, before the thumbnail pipeline; thumbnails, and the colour palette
+// and perceptual hash derived from them, then come from the smaller image.
+// JPEG, PNG and BMP files over the cap are not decoded at all, since the
+// standard decoders cannot decode at reduced scale, and are indexed with
+// metadata only. A value of 0 disables the cap.
+func (e *Engine) SetMaxDecodeDimension(maxDim int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.maxDecodeDimension = maxDim
+}
+
+// SetProgressiveThumbnails makes the 1024px thumbnail a progressive JPEG, so
+// the explorer's detail view can show a coarse preview while it loads. The
+// smaller sizes stay baseline.
+func (e *Engine) SetProgressiveThumbnails(progressive bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.qualityConfig.Progressive = progressive
+}
+
+// SetAllowUpscale controls images smaller than a thumbnail size. By default
+// that size is skipped (and an image smaller than every size is stored as-is
+// as the tiny thumbnail); with upscaling allowed every size is generated, so
+// the grid stays uniform at the cost of soft thumbnails.
+func (e *Engine) SetAllowUpscale(allow bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.qualityConfig.AllowUpscale = allow
+}
+
+// SetThumbnailBackground sets the colour that transparent areas of PNGs and
+// other images with an alpha channel are flattened onto (default white).
+// Colour palettes and perceptual hashes are computed from the flattened image.
+func (e *Engine) SetThumbnailBackground(bg color.Color) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.thumbBackground = bg
+}
+
+// SetThumbnailQuality overrides the JPEG quality of individual thumbnail
+// sizes; sizes not in tiers keep their defaults.
+func (e *Engine) SetThumbnailQuality(tiers map[models.ThumbnailSize]int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for size, q := range tiers {
+		e.qualityConfig.QualityTiers[size] = q
+	}
+}
+
+// SetSkipThumbnails switches to a metadata-only pass: EXIF is extracted and
+// stored without decoding the image, so no thumbnails, colour palette or
+// perceptual hash are computed and the photo is marked as pending. A later
+// index without this option generates them, even for unchanged files.
+func (e *Engine) SetSkipThumbnails(skip bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.skipThumbnails = skip
+}
+
+// SetFileSizeRange limits indexing to files of at least min bytes and less
+// than max bytes; 0 leaves that end open. Files outside the range are
+// skipped before they are read, and counted in IndexStats.FilesOutOfRange.
+func (e *Engine) SetFileSizeRange(min, max int64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.minFileSize = min
+	e.maxFileSize = max
+}
+
+// SetModifiedAfter limits indexing to files modified after t; the zero
+// time removes the limit. Older files are dropped while the directories are
+// walked, so they are never read or hashed, and are counted in
+// IndexStats.FilesTooOld. Photos already indexed from them are kept.
+func (e *Engine) SetModifiedAfter(t time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.modifiedAfter = t
+}
+
+// ParseSince reads a modified-after limit as a date (2025-01-01, taken as
+// local midnight), a date and time (2025-01-01T18:30:00, local unless it
+// has a zone offset), or a Go duration counted back from now (24h, 90m)
+func ParseSince(s string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		if d <= 0 {
+			return time.Time{}, fmt.Errorf("duration %q must be positive", s)
+		}
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02", "2006-01-02T15:04:05", "2006-01-02 15:04:05"} {
+		if t, err := time.ParseInLocation(layout, s, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (use a date like 2025-01-01 or a duration like 24h)", s)
+}
+
+// SetMaxFiles makes IndexDirectories refuse to index when it finds more than
+// max files, before any is processed; 0 removes the cap
+func (e *Engine) SetMaxFiles(max int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.maxFiles = max
+}
+
+// TooManyFilesError is returned by IndexDirectories when the files found
+// exceed SetMaxFiles. Nothing has been indexed.
+type TooManyFilesError struct {
+	Found int
+	Max   int
+	Paths []string // The first few files found, to show what was matched
+}
+
+func (err *TooManyFilesError) Error() string {
+	return fmt.Sprintf("found %d files, more than the limit of %d", err.Found, err.Max)
+}
+
+// inSizeRange reports whether a file's size is within SetFileSizeRange.
+// Files that cannot be stat'ed are kept so the worker reports the error.
+func (e *Engine) inSizeRange(path string) bool {
+	if e.minFileSize == 0 && e.maxFileSize == 0 {
+		return true
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return true
+	}
+	size := info.Size()
+	return size >= e.minFileSize && (e.maxFileSize == 0 || size < e.maxFileSize)
+}
+
 // IndexDirectory recursively indexes all DNG files in a directory
 func (e *Engine) IndexDirectory(rootPath string) error {
-	log.Printf("Starting indexing of %s with %d workers\n", rootPath, e.workerCount)
+	return e.IndexDirectories([]string{rootPath})
+}
+
+// IndexDirectories indexes several root directories in one run. Files from
+// all roots are fed to a single worker pool and counted in one set of stats.
+// Roots may overlap (a root nested inside another, or the same root given
+// twice); each file is processed once, under the path from the first root
+// that contains it.
+func (e *Engine) IndexDirectories(rootPaths []string) error {
+	log.Printf("Starting indexing of %s with %d workers\n", strings.Join(rootPaths, ", "), e.workerCount)
 
 	// Find all DNG files
-	files, err := e.findDNGFiles(rootPath)
-	if err != nil {
-		return fmt.Errorf("failed to find DNG files: %w", err)
+	var files []string
+	seen := make(map[string]bool)
+	duplicates := 0
+	outOfRange := 0
+	tooOld := 0
+	for _, rootPath := range rootPaths {
+		found, older, err := e.findDNGFiles(rootPath)
+		if err != nil {
+			return fmt.Errorf("failed to find DNG files in %s: %w", rootPath, err)
+		}
+		tooOld += older
+		for _, file := range found {
+			key := file
+			if abs, err := filepath.Abs(file); err == nil {
+				key = abs
+			}
+			if seen[key] {
+				duplicates++
+				continue
+			}
+			seen[key] = true
+			if !e.inSizeRange(file) {
+				outOfRange++
+				continue
+			}
+			files = append(files, file)
+		}
 	}
 
 	e.mu.Lock()
 	e.stats.FilesFound = len(files)
+	e.stats.FilesOutOfRange = outOfRange
+	e.stats.FilesTooOld = tooOld
+	maxFiles := e.maxFiles
 	e.mu.Unlock()
 
-	log.Printf("Found %d DNG files\n", len(files))
+	if maxFiles > 0 && len(files) > maxFiles {
+		return &TooManyFilesError{Found: len(files), Max: maxFiles, Paths: files[:min(len(files), 10)]}
+	}
+
+	slog.Info("Found DNG files", "files_found", len(files), "roots", len(rootPaths), "duplicates_skipped", duplicates, "out_of_size_range", outOfRange, "too_old", tooOld)
 
 	if len(files) == 0 {
 		return nil
@@ -164,15 +398,16 @@ func (e *Engine) IndexDirectory(rootPath string) error {
 	e.mu.Unlock()
 
 	// Print summary
-	log.Printf("\nIndexing complete!")
-	log.Printf("  Files found: %d\n", e.stats.FilesFound)
-	log.Printf("  Files processed: %d\n", e.stats.FilesProcessed)
-	log.Printf("  Files skipped: %d\n", e.stats.FilesSkipped)
-	log.Printf("  Files updated: %d\n", e.stats.FilesUpdated)
-	log.Printf("  Files failed: %d\n", e.stats.FilesFailed)
-	log.Printf("  Thumbnails generated: %d\n", e.stats.ThumbnailsGenerated)
-	log.Printf("  Duration: %v\n", e.stats.Duration())
-	log.Printf("  Rate: %.2f photos/second\n", e.stats.PhotosPerSecond())
+	slog.Info("Indexing complete",
+		"files_found", e.stats.FilesFound,
+		"files_processed", e.stats.FilesProcessed,
+		"files_skipped", e.stats.FilesSkipped,
+		"files_updated", e.stats.FilesUpdated,
+		"files_failed", e.stats.FilesFailed,
+		"thumbnails_generated", e.stats.ThumbnailsGenerated,
+		"duration", e.stats.Duration().Round(time.Millisecond).String(),
+		"photos_per_second", fmt.Sprintf("%.2f", e.stats.PhotosPerSecond()),
+	)
 
 	return nil
 }
@@ -185,6 +420,9 @@ func (e *Engine) worker(id int, workChan <-chan string, wg *sync.WaitGroup) {
 		perfStats, err := e.processFile(filePath)
 		if err != nil {
 			log.Printf("Worker %d: Failed to process %s: %v\n", id, filePath, err)
+			if recordErr := e.db.RecordIndexError(filePath, err.Error()); recordErr != nil {
+				log.Printf("Warning: %v", recordErr)
+			}
 			e.mu.Lock()
 			e.stats.FilesFailed++
 			if e.perfTracking {
@@ -194,6 +432,9 @@ func (e *Engine) worker(id int, workChan <-chan string, wg *sync.WaitGroup) {
 			}
 			e.mu.Unlock()
 		} else {
+			if clearErr := e.db.ClearIndexError(filePath); clearErr != nil {
+				log.Printf("Warning: %v", clearErr)
+			}
 			e.mu.Lock()
 			e.stats.FilesProcessed++
 			processed := e.stats.FilesProcessed
@@ -208,8 +449,8 @@ func (e *Engine) worker(id int, workChan <-chan string, wg *sync.WaitGroup) {
 
 			// Report progress every 100 files (legacy logging)
 			if processed%100 == 0 {
-				log.Printf("Progress: %d/%d files processed (%.1f%%)\n",
-					processed, total, float64(processed)/float64(total)*100)
+				slog.Info("Progress", "processed", processed, "total", total,
+					"percent", fmt.Sprintf("%.1f", float64(processed)/float64(total)*100))
 			}
 			e.mu.Unlock()
 
@@ -242,12 +483,21 @@ func (e *Engine) processFile(filePath string) (models.PerfStats, error) {
 		return perf, fmt.Errorf("failed to calculate hash: %w", err)
 	}
 
+	e.mu.Lock()
+	skipThumbnails := e.skipThumbnails
+	e.mu.Unlock()
+
 	// Check if already indexed
 	exists, err := e.db.PhotoExists(filePath)
 	if err != nil {
 		return perf, fmt.Errorf("failed to check if photo exists: %w", err)
 	}
 
+	// fillPending is set for an unchanged photo that an earlier
+	// -no-thumbnails run left without thumbnails: its image data is added
+	// to the existing row rather than re-inserting the photo
+	fillPending := false
+
 	if exists {
 		// Check if file has been modified by comparing hashes
 		existingHash, err := e.db.GetPhotoHash(filePath)
@@ -256,19 +506,31 @@ func (e *Engine) processFile(filePath string) (models.PerfStats, error) {
 		}
 
 		if existingHash == currentHash {
-			// File unchanged, skip
-			e.mu.Lock()
-			e.stats.FilesSkipped++
-			e.mu.Unlock()
-			perf.WasSkipped = true
-			perf.TotalTime = time.Since(startTime)
-			return perf, nil
-		}
+			// An unchanged file still needs a full pass if an earlier
+			// -no-thumbnails run left its thumbnails pending
+			pending := false
+			if !skipThumbnails {
+				pending, err = e.db.ThumbnailsPending(filePath)
+				if err != nil {
+					return perf, fmt.Errorf("failed to check for pending thumbnails: %w", err)
+				}
+			}
 
-		// File has been modified, delete the old entry and re-index
-		log.Printf("File modified, re-indexing: %s", filePath)
-		if err := e.db.DeletePhoto(filePath); err != nil {
-			return perf, fmt.Errorf("failed to delete old photo entry: %w", err)
+			if !pending {
+				// File unchanged, skip
+				e.mu.Lock()
+				e.stats.FilesSkipped++
+				e.mu.Unlock()
+				perf.WasSkipped = true
+				perf.TotalTime = time.Since(startTime)
+				return perf, nil
+			}
+			log.Printf("Generating pending thumbnails: %s", filePath)
+			fillPending = true
+		} else {
+			// Re-indexed in place by ReplacePhoto, keeping the photo's ID
+			// and what the user set on it
+			log.Printf("File modified, re-indexing: %s", filePath)
 		}
 		e.mu.Lock()
 		e.stats.FilesUpdated++
@@ -278,7 +540,9 @@ func (e *Engine) processFile(filePath string) (models.PerfStats, error) {
 
 	// Check if this is a RAW file
 	ext := strings.ToLower(filepath.Ext(filePath))
-	isRawFile := ext == ".dng" || ext == ".cr2" || ext == ".nef" || ext == ".raf" || ext == ".arw"
+	isRawFile := isRawExtension(ext)
+	isVideo := isVideoExtension(ext)
+	isHEIF := isHEIFExtension(ext)
 
 	var metadata *models.PhotoMetadata
 	var img image.Image
@@ -286,8 +550,27 @@ func (e *Engine) processFile(filePath string) (models.PerfStats, error) {
 	// Metadata extraction
 	metadataStart := time.Now()
 
-	// Extract EXIF metadata using go-exif (works for both RAW and JPEG)
-	metadata, err = ExtractMetadata(filePath)
+	// Extract EXIF metadata using go-exif (works for both RAW and JPEG).
+	// Videos have no EXIF; their headers give the date and size.
+	if isVideo {
+		metadata, err = ExtractVideoMetadata(filePath)
+		if err != nil {
+			return perf, fmt.Errorf("failed to read video headers: %w", err)
+		}
+	} else {
+		metadata, err = ExtractMetadata(filePath)
+		e.mu.Lock()
+		fallback := e.exiftoolFallback
+		e.mu.Unlock()
+		if err != nil && fallback {
+			if m, toolErr := ExtractMetadataExiftool(filePath); toolErr == nil {
+				log.Printf("Read metadata of %s with exiftool (go-exif: %v)", filepath.Base(filePath), err)
+				metadata, err = m, nil
+			} else if !errors.Is(toolErr, ErrNoExiftool) {
+				log.Printf("Warning: exiftool could not read %s either: %v", filepath.Base(filePath), toolErr)
+			}
+		}
+	}
 	if err != nil {
 		// If EXIF extraction fails, create basic metadata from file info
 		fileInfo, statErr := os.Stat(filePath)
@@ -303,15 +586,114 @@ func (e *Engine) processFile(filePath string) (models.PerfStats, error) {
 		}
 	}
 
+	// An embedded ICC profile describes the pixels more reliably than the EXIF
+	// ColorSpace tag, which cannot express Display P3 at all
+	var profile *ColourProfile
+	if !isVideo {
+		if profile, err = ReadColourProfile(filePath); err != nil {
+			log.Printf("Warning: could not read ICC profile of %s: %v", filepath.Base(filePath), err)
+		}
+	}
+	if profile != nil {
+		metadata.ColourSpace = profile.ColourSpace
+	}
+
+	// PNG, BMP and JPEGs without EXIF dimensions leave the size unset; the
+	// image header has it, stored unrotated as EXIF would give it
+	if !isVideo && !isRawFile && !isHEIF && (metadata.Width == 0 || metadata.Height == 0) {
+		if width, height, err := headerSize(filePath); err == nil {
+			metadata.Width, metadata.Height = width, height
+		}
+	}
+
 	// Use the hash we already calculated
 	metadata.FileHash = currentHash
+	metadata.FileFormat = fileFormat(ext)
 	perf.MetadataTime = time.Since(metadataStart)
 
+	// Metadata-only pass: store what EXIF gives us and leave the image alone
+	if skipThumbnails {
+		metadata.ThumbnailsPending = true
+
+		inferStart := time.Now()
+		InferMetadata(metadata)
+		perf.InferenceTime = time.Since(inferStart)
+
+		dbStart := time.Now()
+		if err := e.db.ReplacePhoto(metadata); err != nil {
+			return perf, fmt.Errorf("failed to insert photo: %w", err)
+		}
+		perf.DatabaseTime = time.Since(dbStart)
+		perf.TotalTime = time.Since(startTime)
+		return perf, nil
+	}
+
 	// Image decoding
 	decodeStart := time.Now()
 
+	e.mu.Lock()
+	maxDecode := e.maxDecodeDimension
+	background := e.thumbBackground
+	e.mu.Unlock()
+
+	// For oversized RAW files, prefer the embedded preview over a full decode,
+	// provided it is big enough for the largest thumbnail
+	if isRawFile && exceedsDimension(metadata.Width, metadata.Height, maxDecode) {
+		if preview, err := ExtractEmbeddedJPEG(filePath); err == nil && coversThumbnails(preview) {
+			img = preview
+			log.Printf("Using embedded preview for %s (%dx%d exceeds max decode dimension %d)",
+				filepath.Base(filePath), metadata.Width, metadata.Height, maxDecode)
+		}
+	}
+
+	// A video's thumbnails come from its poster frame. Without one it is
+	// stored with metadata only, like an undecodable RAW file.
+	if isVideo {
+		frame, frameErr := PosterFrame(filePath, metadata.Duration)
+		if frameErr != nil {
+			log.Printf("%s indexed with metadata only (no thumbnail): %v", filepath.Base(filePath), frameErr)
+			perf.ImageDecodeTime = time.Since(decodeStart)
+			InferMetadata(metadata)
+
+			dbStart := time.Now()
+			if err := e.storePhoto(metadata, fillPending); err != nil {
+				return perf, err
+			}
+			perf.DatabaseTime = time.Since(dbStart)
+			perf.TotalTime = time.Since(startTime)
+			return perf, nil
+		}
+		img = frame
+	}
+
+	// HEIF images are HEVC, which only libheif's decoder reads; it returns
+	// them upright. Failing that, the EXIF thumbnail stands in, and without
+	// one the photo is stored with metadata only.
+	upright := false
+	if isHEIF {
+		var decodeErr error
+		if img, decodeErr = DecodeHEIF(filePath); decodeErr == nil {
+			upright = true
+		} else if preview, previewErr := ExtractHEIFPreview(filePath); previewErr == nil {
+			log.Printf("Using the EXIF thumbnail of %s: %v", filepath.Base(filePath), decodeErr)
+			img = preview
+		} else {
+			log.Printf("%s indexed with metadata only (no thumbnail): %v", filepath.Base(filePath), decodeErr)
+			perf.ImageDecodeTime = time.Since(decodeStart)
+			InferMetadata(metadata)
+
+			dbStart := time.Now()
+			if err := e.storePhoto(metadata, fillPending); err != nil {
+				return perf, err
+			}
+			perf.DatabaseTime = time.Since(dbStart)
+			perf.TotalTime = time.Since(startTime)
+			return perf, nil
+		}
+	}
+
 	// Try RAW decode if applicable
-	if isRawFile && IsRawSupported() {
+	if img == nil && isRawFile && IsRawSupported() {
 		// Try to decode RAW image
 		var decodeErr error
 		img, decodeErr = DecodeRaw(filePath)
@@ -335,18 +717,35 @@ func (e *Engine) processFile(filePath string) (models.PerfStats, error) {
 		}
 		defer file.Close()
 
+		// The standard decoders cannot decode at reduced scale, so an image
+		// over the cap is not decoded at all: its header gives the size
+		oversized := false
+		if maxDecode > 0 {
+			if cfg, _, err := image.DecodeConfig(file); err == nil {
+				oversized = exceedsDimension(cfg.Width, cfg.Height, maxDecode)
+			}
+			if _, err := file.Seek(0, io.SeekStart); err != nil {
+				return perf, fmt.Errorf("failed to rewind image: %w", err)
+			}
+		}
+
 		var decodeErr error
-		img, _, decodeErr = image.Decode(file)
+		if oversized {
+			decodeErr = fmt.Errorf("image exceeds max decode dimension %d", maxDecode)
+		} else {
+			img, _, decodeErr = image.Decode(file)
+		}
 		if decodeErr != nil {
-			// For RAW files that can't be decoded, we can still store metadata
-			if isRawFile {
-				log.Printf("RAW file %s indexed with metadata only (no thumbnail)", filepath.Base(filePath))
+			// For RAW files that can't be decoded, and images too large to
+			// decode, we can still store metadata
+			if isRawFile || oversized {
+				log.Printf("%s indexed with metadata only (no thumbnail): %v", filepath.Base(filePath), decodeErr)
 				perf.ImageDecodeTime = time.Since(decodeStart)
 
 				// Store metadata without thumbnails/colours
 				dbStart := time.Now()
-				if err := e.db.InsertPhoto(metadata); err != nil {
-					return perf, fmt.Errorf("failed to insert photo: %w", err)
+				if err := e.storePhoto(metadata, fillPending); err != nil {
+					return perf, err
 				}
 				perf.DatabaseTime = time.Since(dbStart)
 				perf.TotalTime = time.Since(startTime)
@@ -355,19 +754,35 @@ func (e *Engine) processFile(filePath string) (models.PerfStats, error) {
 			return perf, fmt.Errorf("failed to decode image: %w", decodeErr)
 		}
 	}
+
+	// Drop a full-size RAW decode before the thumbnail pipeline makes its own
+	// copies. LibRaw cannot decode at reduced scale, so this bounds memory from
+	// here on rather than during the decode itself.
+	img = downsampleToMax(img, maxDecode)
+	img = flattenAlpha(img, background)
 	perf.ImageDecodeTime = time.Since(decodeStart)
 
 	// Generate thumbnails with quality instrumentation
 	thumbnailStart := time.Now()
 
-	// Prepare image metadata for quality pipeline
+	// Prepare image metadata for quality pipeline. LibRaw renders RAW files
+	// to sRGB whatever colour space the camera recorded.
 	imgMeta := quality.ImageMetadata{
-		FilePath:      filePath,
-		Orientation:   metadata.Orientation,
-		ColorSpace:    metadata.ColourSpace,
-		HasICCProfile: false, // TODO: Detect ICC profiles
-		Width:         img.Bounds().Dx(),
-		Height:        img.Bounds().Dy(),
+		FilePath:    filePath,
+		Orientation: metadata.Orientation,
+		ColorSpace:  metadata.ColourSpace,
+		Width:       img.Bounds().Dx(),
+		Height:      img.Bounds().Dy(),
+	}
+	if isRawFile {
+		imgMeta.ColorSpace = quality.ColourSpaceSRGB
+	}
+	if upright {
+		imgMeta.Orientation = 1
+	}
+	if profile != nil {
+		imgMeta.HasICCProfile = true
+		imgMeta.ICCDescription = profile.Description
 	}
 
 	// Generate thumbnails with diagnostics
@@ -381,9 +796,10 @@ func (e *Engine) processFile(filePath string) (models.PerfStats, error) {
 	// store the original image as the tiny thumbnail
 	if len(thumbnails) == 0 {
 		log.Printf("No thumbnails generated for %s (image too small), storing original as TINY thumbnail", filepath.Base(filePath))
-		// Encode original image as JPEG
+		// Encode original image as JPEG, in sRGB like the generated sizes
+		small, _ := quality.ConvertToSRGB(img, imgMeta.ColorSpace)
 		var buf bytes.Buffer
-		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85}); err != nil {
+		if err := jpeg.Encode(&buf, small, &jpeg.Options{Quality: 85}); err != nil {
 			return perf, fmt.Errorf("failed to encode original as thumbnail: %w", err)
 		}
 		thumbnails = map[models.ThumbnailSize][]byte{
@@ -392,6 +808,8 @@ func (e *Engine) processFile(filePath string) (models.PerfStats, error) {
 	}
 
 	metadata.Thumbnails = thumbnails
+	metadata.ThumbnailsUpscaled = diag.IsUpscale()
+	metadata.ThumbnailsSkipped = len(diag.Pipeline.Resize.SkippedSizes)
 	perf.ThumbnailTime = time.Since(thumbnailStart)
 
 	e.mu.Lock()
@@ -460,6 +878,15 @@ func (e *Engine) processFile(filePath string) (models.PerfStats, error) {
 	e.stats.HashesComputed++
 	e.mu.Unlock()
 
+	// Placeholder shown in the grid while the thumbnail loads
+	if len(thumbData) > 0 {
+		blurhash, err := ComputeBlurhash(thumbImg)
+		if err != nil {
+			return perf, err
+		}
+		metadata.Blurhash = blurhash
+	}
+
 	// Infer metadata
 	inferStart := time.Now()
 	InferMetadata(metadata)
@@ -467,8 +894,8 @@ func (e *Engine) processFile(filePath string) (models.PerfStats, error) {
 
 	// Store in database
 	dbStart := time.Now()
-	if err := e.db.InsertPhoto(metadata); err != nil {
-		return perf, fmt.Errorf("failed to insert photo: %w", err)
+	if err := e.storePhoto(metadata, fillPending); err != nil {
+		return perf, err
 	}
 	perf.DatabaseTime = time.Since(dbStart)
 
@@ -476,34 +903,170 @@ func (e *Engine) processFile(filePath string) (models.PerfStats, error) {
 	return perf, nil
 }
 
+// storePhoto inserts a newly indexed photo or updates a modified one in
+// place, or for fillPending adds the image data to the photo's existing row.
+// Either way an indexed photo keeps its ID.
+func (e *Engine) storePhoto(metadata *models.PhotoMetadata, fillPending bool) error {
+	if fillPending {
+		if err := e.db.UpdateImageData(metadata); err != nil {
+			return fmt.Errorf("failed to update photo: %w", err)
+		}
+		return nil
+	}
+	if err := e.db.ReplacePhoto(metadata); err != nil {
+		return fmt.Errorf("failed to insert photo: %w", err)
+	}
+	return nil
+}
+
+// supportedExts lists the (lower-case) file extensions the indexer picks up
+var supportedExts = map[string]bool{
+	".dng":  true,
+	".jpg":  true,
+	".jpeg": true,
+	".bmp":  true,
+	".png":  true,
+	".heic": true,
+	".heif": true,
+}
+
+// isRawExtension reports whether ext names a camera RAW format
+func isRawExtension(ext string) bool {
+	switch strings.ToLower(ext) {
+	case ".dng", ".cr2", ".nef", ".raf", ".arw":
+		return true
+	}
+	return false
+}
+
+// fileFormat maps a file extension to the format name stored in the
+// file_format column, folding spelling variants such as .jpg/.jpeg
+func fileFormat(ext string) string {
+	ext = strings.TrimPrefix(strings.ToLower(ext), ".")
+	switch ext {
+	case "jpg", "jpeg":
+		return "jpeg"
+	case "tif", "tiff":
+		return "tiff"
+	case "heif":
+		return "heic"
+	}
+	return ext
+}
+
 // findDNGFiles recursively finds all supported image files in a directory
-// Supports: DNG, JPEG, JPG, BMP
-func (e *Engine) findDNGFiles(rootPath string) ([]string, error) {
-	var files []string
+// Supports: DNG, JPEG, JPG, BMP, PNG, HEIC, HEIF, and MP4, M4V and MOV with
+// SetIncludeVideo. Files not modified after SetModifiedAfter are left out
+// and counted in older.
+func (e *Engine) findDNGFiles(rootPath string) (files []string, older int, err error) {
+	e.mu.Lock()
+	follow := e.followSymlinks
+	includeVideo := e.includeVideo
+	modifiedAfter := e.modifiedAfter
+	e.mu.Unlock()
 
-	supportedExts := map[string]bool{
-		".dng":  true,
-		".jpg":  true,
-		".jpeg": true,
-		".bmp":  true,
+	indexable := func(path string, info os.FileInfo) bool {
+		ext := strings.ToLower(filepath.Ext(path))
+		if !supportedExts[ext] && !(includeVideo && videoExts[ext]) {
+			return false
+		}
+		if !modifiedAfter.IsZero() && !info.ModTime().After(modifiedAfter) {
+			older++
+			return false
+		}
+		return true
 	}
 
-	err := filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
+	if follow {
+		files, err = findFilesFollowingSymlinks(rootPath, indexable)
+		return files, older, err
+	}
+
+	err = filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
 
-		if !info.IsDir() {
-			ext := strings.ToLower(filepath.Ext(path))
-			if supportedExts[ext] {
-				files = append(files, path)
+		if info.IsDir() {
+			return nil
+		}
+		// Walk reports a symlinked file itself; judge its target's age
+		if info.Mode()&os.ModeSymlink != 0 {
+			if target, err := os.Stat(path); err == nil {
+				info = target
 			}
 		}
+		if indexable(path, info) {
+			files = append(files, path)
+		}
 
 		return nil
 	})
 
 	if err != nil {
+		return nil, older, err
+	}
+
+	return files, older, nil
+}
+
+// findFilesFollowingSymlinks walks rootPath like findDNGFiles but descends
+// into symlinked directories as well.
+//
+// Cycle detection: every directory is resolved to its real path with
+// filepath.EvalSymlinks before it is entered, and each real path is entered
+// at most once. A link back to an ancestor (or two links to the same target)
+// therefore resolves to a path already in the visited set and is skipped,
+// so the walk always terminates and no directory is scanned twice.
+func findFilesFollowingSymlinks(rootPath string, indexable func(string, os.FileInfo) bool) ([]string, error) {
+	var files []string
+	visited := make(map[string]bool)
+
+	var walk func(dir string) error
+	walk = func(dir string) error {
+		realDir, err := filepath.EvalSymlinks(dir)
+		if err != nil {
+			return err
+		}
+		if visited[realDir] {
+			return nil
+		}
+		visited[realDir] = true
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			path := filepath.Join(dir, entry.Name())
+
+			// os.Stat follows links, so symlinked directories report IsDir
+			info, err := os.Stat(path)
+			if err != nil {
+				// Dangling symlink: nothing to index
+				if entry.Type()&os.ModeSymlink != 0 {
+					continue
+				}
+				return err
+			}
+
+			if info.IsDir() {
+				if err := walk(path); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if indexable(path, info) {
+				files = append(files, path)
+			}
+		}
+
+		return nil
+	}
+
+	if err := walk(rootPath); err != nil {
 		return nil, err
 	}
 
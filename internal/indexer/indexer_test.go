@@ -2,12 +2,16 @@ package indexer
 
 import (
 	"bytes"
+	"errors"
 	"image"
 	"image/color"
 	"image/jpeg"
+	"image/png"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/adewale/olsen/internal/database"
 )
@@ -64,6 +68,7 @@ func TestFindDNGFiles(t *testing.T) {
 		"subdir/nested/photo4.dng",
 		"image.jpg", // Should be included (JPEG support)
 		"scan.bmp",  // Should be included (BMP support)
+		"logo.png",  // Should be included (PNG support)
 		"doc.txt",   // Should be ignored
 		"file.pdf",  // Should be ignored
 	}
@@ -87,13 +92,13 @@ func TestFindDNGFiles(t *testing.T) {
 	defer db.Close()
 
 	engine := NewEngine(db, 1)
-	files, err := engine.findDNGFiles(tmpDir)
+	files, _, err := engine.findDNGFiles(tmpDir)
 	if err != nil {
 		t.Fatalf("findDNGFiles failed: %v", err)
 	}
 
-	// Should find 6 image files (4 DNG + 1 JPEG + 1 BMP)
-	expectedCount := 6
+	// Should find 7 image files (4 DNG + 1 JPEG + 1 BMP + 1 PNG)
+	expectedCount := 7
 	if len(files) != expectedCount {
 		t.Errorf("Found %d image files; want %d", len(files), expectedCount)
 	}
@@ -105,6 +110,7 @@ func TestFindDNGFiles(t *testing.T) {
 		".jpg":  true,
 		".jpeg": true,
 		".bmp":  true,
+		".png":  true,
 	}
 
 	for _, file := range files {
@@ -115,6 +121,74 @@ func TestFindDNGFiles(t *testing.T) {
 	}
 }
 
+func TestFindDNGFilesFollowSymlinks(t *testing.T) {
+	tmpDir := t.TempDir()
+	root := filepath.Join(tmpDir, "root")
+	external := filepath.Join(tmpDir, "external")
+
+	for _, file := range []string{
+		filepath.Join(root, "photo1.dng"),
+		filepath.Join(root, "subdir", "photo2.jpg"),
+		filepath.Join(external, "photo3.dng"),
+	} {
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(file, []byte("test"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	links := map[string]string{
+		filepath.Join(root, "linked"):             external, // Symlink to a directory outside the root
+		filepath.Join(root, "subdir", "loop"):     root,     // Cycle back to the root
+		filepath.Join(root, "linked-again"):       external, // Second link to the same target
+		filepath.Join(root, "dangling"):           filepath.Join(tmpDir, "missing"),
+		filepath.Join(external, "back-to-root"):   root,
+		filepath.Join(root, "subdir", "selfloop"): filepath.Join(root, "subdir"),
+	}
+	for link, target := range links {
+		if err := os.Symlink(target, link); err != nil {
+			t.Skipf("Symlinks not supported: %v", err)
+		}
+	}
+
+	db, err := database.Open(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	engine := NewEngine(db, 1)
+
+	// Default: symlinked directories are not traversed
+	files, _, err := engine.findDNGFiles(root)
+	if err != nil {
+		t.Fatalf("findDNGFiles failed: %v", err)
+	}
+	if len(files) != 2 {
+		t.Errorf("Found %d files without following symlinks; want 2: %v", len(files), files)
+	}
+
+	engine.SetFollowSymlinks(true)
+	files, _, err = engine.findDNGFiles(root)
+	if err != nil {
+		t.Fatalf("findDNGFiles with symlinks failed: %v", err)
+	}
+	if len(files) != 3 {
+		t.Errorf("Found %d files following symlinks; want 3: %v", len(files), files)
+	}
+
+	seen := make(map[string]bool)
+	for _, file := range files {
+		base := filepath.Base(file)
+		if seen[base] {
+			t.Errorf("File %s found more than once", base)
+		}
+		seen[base] = true
+	}
+}
+
 func TestNewEngine(t *testing.T) {
 	db, err := database.Open(":memory:")
 	if err != nil {
@@ -348,3 +422,631 @@ func BenchmarkComputePerceptualHash(b *testing.B) {
 		}
 	}
 }
+
+func TestDownsampleToMax(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height int
+		maxDim        int
+		wantW, wantH  int
+	}{
+		{"Landscape over cap", 4000, 3000, 1000, 1000, 750},
+		{"Portrait over cap", 3000, 6000, 1500, 750, 1500},
+		{"Within cap", 800, 600, 1000, 800, 600},
+		{"No cap", 4000, 3000, 0, 4000, 3000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := image.NewRGBA(image.Rect(0, 0, tt.width, tt.height))
+			got := downsampleToMax(img, tt.maxDim).Bounds()
+			if got.Dx() != tt.wantW || got.Dy() != tt.wantH {
+				t.Errorf("downsampleToMax(%dx%d, %d) = %dx%d; want %dx%d",
+					tt.width, tt.height, tt.maxDim, got.Dx(), got.Dy(), tt.wantW, tt.wantH)
+			}
+		})
+	}
+}
+
+func TestMaxDecodeDimensionSkipsOversizedJPEG(t *testing.T) {
+	dir := t.TempDir()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1200, 100)), nil); err != nil {
+		t.Fatalf("Failed to encode JPEG: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "wide.jpg"), buf.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to write JPEG: %v", err)
+	}
+
+	db, err := database.Open(filepath.Join(t.TempDir(), "decode.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	engine := NewEngine(db, 1)
+	engine.SetMaxDecodeDimension(1024)
+	if err := engine.IndexDirectory(dir); err != nil {
+		t.Fatalf("IndexDirectory failed: %v", err)
+	}
+
+	// Indexed from its metadata, without decoding the pixels
+	if count, _ := db.GetPhotoCount(); count != 1 {
+		t.Errorf("photo count = %d, want 1", count)
+	}
+	var thumbnails int
+	if err := db.QueryRow("SELECT COUNT(*) FROM thumbnails").Scan(&thumbnails); err != nil {
+		t.Fatalf("Failed to count thumbnails: %v", err)
+	}
+	if thumbnails != 0 {
+		t.Errorf("%d thumbnails stored for an image over the cap, want 0", thumbnails)
+	}
+}
+
+func TestCoversThumbnails(t *testing.T) {
+	if coversThumbnails(image.NewRGBA(image.Rect(0, 0, 640, 480))) {
+		t.Error("640x480 preview should not cover the 1024px thumbnail")
+	}
+	if !coversThumbnails(image.NewRGBA(image.Rect(0, 0, 768, 1024))) {
+		t.Error("768x1024 preview should cover the 1024px thumbnail")
+	}
+}
+
+func TestFileFormat(t *testing.T) {
+	tests := map[string]string{
+		".dng":  "dng",
+		".JPG":  "jpeg",
+		".jpeg": "jpeg",
+		".tif":  "tiff",
+		".png":  "png",
+		".heif": "heic",
+	}
+	for ext, want := range tests {
+		if got := fileFormat(ext); got != want {
+			t.Errorf("fileFormat(%q) = %q; want %q", ext, got, want)
+		}
+	}
+}
+
+func TestIndexDirectoriesOverlapping(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "nested")
+	other := t.TempDir()
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatalf("Failed to create dir: %v", err)
+	}
+	createTestJPEGWithEXIF(t, filepath.Join(root, "a.jpg"))
+	createTestJPEGWithEXIF(t, filepath.Join(nested, "b.jpg"))
+	createTestJPEGWithEXIF(t, filepath.Join(other, "c.jpg"))
+
+	db, err := database.Open(filepath.Join(t.TempDir(), "multi.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// nested is inside root and root is listed twice
+	engine := NewEngine(db, 2)
+	if err := engine.IndexDirectories([]string{root, nested, other, root}); err != nil {
+		t.Fatalf("IndexDirectories failed: %v", err)
+	}
+
+	stats := engine.GetStats()
+	if stats.FilesFound != 3 {
+		t.Errorf("FilesFound = %d; want 3 (overlaps counted once)", stats.FilesFound)
+	}
+	if stats.FilesProcessed+stats.FilesFailed != 3 {
+		t.Errorf("processed %d + failed %d; want 3 files handled", stats.FilesProcessed, stats.FilesFailed)
+	}
+
+	count, err := db.GetPhotoCount()
+	if err != nil {
+		t.Fatalf("GetPhotoCount failed: %v", err)
+	}
+	if count != stats.FilesProcessed {
+		t.Errorf("photo count = %d; want %d", count, stats.FilesProcessed)
+	}
+}
+
+func TestAllowUpscaleTinyImage(t *testing.T) {
+	// 200x150 is above the 64px size but below 256, 512 and 1024
+	dir := t.TempDir()
+	img := image.NewRGBA(image.Rect(0, 0, 200, 150))
+	for y := 0; y < 150; y++ {
+		for x := 0; x < 200; x++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 90, 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90}); err != nil {
+		t.Fatalf("Failed to encode JPEG: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "tiny.jpg"), buf.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to write fixture: %v", err)
+	}
+
+	tests := []struct {
+		allowUpscale   bool
+		wantUpscaled   bool
+		wantSkipped    int
+		wantThumbnails int
+	}{
+		{false, false, 3, 1},
+		{true, true, 0, 4},
+	}
+
+	for _, tt := range tests {
+		db, err := database.Open(filepath.Join(t.TempDir(), "upscale.db"))
+		if err != nil {
+			t.Fatalf("Failed to open database: %v", err)
+		}
+
+		engine := NewEngine(db, 1)
+		engine.SetAllowUpscale(tt.allowUpscale)
+		if err := engine.IndexDirectory(dir); err != nil {
+			t.Fatalf("IndexDirectory failed: %v", err)
+		}
+
+		var upscaled bool
+		var skipped, thumbnails int
+		err = db.QueryRow(`
+			SELECT p.thumbnails_upscaled, p.thumbnails_skipped, COUNT(t.photo_id)
+			FROM photos p LEFT JOIN thumbnails t ON t.photo_id = p.id
+			GROUP BY p.id
+		`).Scan(&upscaled, &skipped, &thumbnails)
+		if err != nil {
+			t.Fatalf("allowUpscale=%v: failed to read photo: %v", tt.allowUpscale, err)
+		}
+		if upscaled != tt.wantUpscaled || skipped != tt.wantSkipped || thumbnails != tt.wantThumbnails {
+			t.Errorf("allowUpscale=%v: upscaled=%v skipped=%d thumbnails=%d; want %v, %d, %d",
+				tt.allowUpscale, upscaled, skipped, thumbnails, tt.wantUpscaled, tt.wantSkipped, tt.wantThumbnails)
+		}
+
+		gotUpscaled, gotSkipped, err := db.GetUpscaleCounts()
+		if err != nil {
+			t.Fatalf("GetUpscaleCounts failed: %v", err)
+		}
+		if (gotUpscaled == 1) != tt.wantUpscaled || (gotSkipped == 1) != (tt.wantSkipped > 0) {
+			t.Errorf("allowUpscale=%v: GetUpscaleCounts = %d, %d", tt.allowUpscale, gotUpscaled, gotSkipped)
+		}
+		db.Close()
+	}
+}
+
+func TestIndexErrorsRecorded(t *testing.T) {
+	dir := t.TempDir()
+	broken := filepath.Join(dir, "broken.jpg")
+	if err := os.WriteFile(broken, []byte("not a jpeg"), 0644); err != nil {
+		t.Fatalf("Failed to write fixture: %v", err)
+	}
+
+	db, err := database.Open(filepath.Join(t.TempDir(), "errors.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	engine := NewEngine(db, 1)
+	if err := engine.IndexDirectory(dir); err != nil {
+		t.Fatalf("IndexDirectory failed: %v", err)
+	}
+
+	indexErrors, err := db.ListIndexErrors("")
+	if err != nil {
+		t.Fatalf("ListIndexErrors failed: %v", err)
+	}
+	if len(indexErrors) != 1 || indexErrors[0].FilePath != broken || indexErrors[0].Error == "" {
+		t.Fatalf("index errors = %+v; want one entry for %s", indexErrors, broken)
+	}
+
+	// Replacing the file with a valid image clears the entry on the next run
+	createTestJPEGWithEXIF(t, broken)
+	if err := NewEngine(db, 1).IndexDirectory(dir); err != nil {
+		t.Fatalf("IndexDirectory failed: %v", err)
+	}
+	indexErrors, _ = db.ListIndexErrors("")
+	if len(indexErrors) != 0 {
+		t.Errorf("index errors after fix = %+v; want none", indexErrors)
+	}
+}
+
+func TestFileSizeRangeSkipsBeforeReading(t *testing.T) {
+	dir := t.TempDir()
+	// A file this small would fail to decode, so an index error proves it was read
+	if err := os.WriteFile(filepath.Join(dir, "tiny.jpg"), []byte("not a jpeg"), 0644); err != nil {
+		t.Fatalf("Failed to write fixture: %v", err)
+	}
+	createTestJPEGWithEXIF(t, filepath.Join(dir, "photo.jpg"))
+
+	db, err := database.Open(filepath.Join(t.TempDir(), "sizes.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	engine := NewEngine(db, 1)
+	engine.SetFileSizeRange(100, 0)
+	if err := engine.IndexDirectory(dir); err != nil {
+		t.Fatalf("IndexDirectory failed: %v", err)
+	}
+
+	stats := engine.GetStats()
+	if stats.FilesOutOfRange != 1 || stats.FilesFound != 1 || stats.FilesProcessed != 1 {
+		t.Errorf("stats = %d out of range, %d found, %d processed; want 1, 1, 1",
+			stats.FilesOutOfRange, stats.FilesFound, stats.FilesProcessed)
+	}
+	if indexErrors, _ := db.ListIndexErrors(""); len(indexErrors) != 0 {
+		t.Errorf("index errors = %+v; the small file should not have been read", indexErrors)
+	}
+
+	// An upper bound below every file skips them all
+	engine = NewEngine(db, 1)
+	engine.SetFileSizeRange(0, 5)
+	if err := engine.IndexDirectory(dir); err != nil {
+		t.Fatalf("IndexDirectory failed: %v", err)
+	}
+	if stats := engine.GetStats(); stats.FilesOutOfRange != 2 || stats.FilesFound != 0 {
+		t.Errorf("max 5 bytes: %d out of range, %d found; want 2, 0", stats.FilesOutOfRange, stats.FilesFound)
+	}
+}
+
+func TestReindexModifiedFileKeepsUserData(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "photo.jpg")
+	createTestJPEGWithEXIF(t, path)
+
+	db, err := database.Open(filepath.Join(t.TempDir(), "reindex.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if err := NewEngine(db, 1).IndexDirectory(dir); err != nil {
+		t.Fatalf("IndexDirectory failed: %v", err)
+	}
+	var id, thumbnails int
+	if err := db.QueryRow("SELECT id, (SELECT COUNT(*) FROM thumbnails WHERE photo_id = photos.id) FROM photos").Scan(&id, &thumbnails); err != nil {
+		t.Fatalf("Photo not indexed: %v", err)
+	}
+	if _, err := db.Exec("UPDATE photos SET rating = 4 WHERE id = ?", id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.TagPhotos("keeper", "SELECT id FROM photos WHERE id = ?", []interface{}{id}); err != nil {
+		t.Fatalf("TagPhotos failed: %v", err)
+	}
+	collection, err := db.CreateCollection("Favourites", "")
+	if err != nil {
+		t.Fatalf("CreateCollection failed: %v", err)
+	}
+	if _, err := db.AddToCollection(collection, []int{id}); err != nil {
+		t.Fatalf("AddToCollection failed: %v", err)
+	}
+
+	// Bytes after the JPEG's end marker change the hash but not the image
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte("edited"))
+	f.Close()
+
+	engine := NewEngine(db, 1)
+	if err := engine.IndexDirectory(dir); err != nil {
+		t.Fatalf("Re-index failed: %v", err)
+	}
+	if stats := engine.GetStats(); stats.FilesUpdated != 1 || stats.FilesFailed != 0 {
+		t.Fatalf("stats = %d updated, %d failed; want 1, 0", stats.FilesUpdated, stats.FilesFailed)
+	}
+
+	var newID, rating, newThumbnails, tags, members int
+	var hash string
+	err = db.QueryRow(`
+		SELECT id, rating, file_hash,
+		       (SELECT COUNT(*) FROM thumbnails WHERE photo_id = photos.id),
+		       (SELECT COUNT(*) FROM photo_tags WHERE photo_id = photos.id),
+		       (SELECT COUNT(*) FROM collection_photos WHERE photo_id = photos.id)
+		FROM photos
+	`).Scan(&newID, &rating, &hash, &newThumbnails, &tags, &members)
+	if err != nil {
+		t.Fatalf("Photo missing after re-index: %v", err)
+	}
+	if newID != id || rating != 4 || tags != 1 || members != 1 {
+		t.Errorf("after re-index: id %d, rating %d, %d tags, %d collections; want id %d, rating 4, 1 tag, 1 collection", newID, rating, tags, members, id)
+	}
+	if newThumbnails != thumbnails {
+		t.Errorf("%d thumbnails after re-index; want the %d replaced, not added to", newThumbnails, thumbnails)
+	}
+	if hash, _ := calculateFileHash(path); hash == "" {
+		t.Fatal("could not hash the edited file")
+	} else if stored, _ := db.GetPhotoHash(path); stored != hash {
+		t.Errorf("stored hash %s; want the edited file's %s", stored, hash)
+	}
+}
+
+func TestModifiedAfterSkipsOlderFiles(t *testing.T) {
+	dir := t.TempDir()
+	// Unreadable as a JPEG, so an index error proves it was read
+	old := filepath.Join(dir, "old.jpg")
+	if err := os.WriteFile(old, []byte("not a jpeg"), 0644); err != nil {
+		t.Fatalf("Failed to write fixture: %v", err)
+	}
+	lastWeek := time.Now().Add(-7 * 24 * time.Hour)
+	if err := os.Chtimes(old, lastWeek, lastWeek); err != nil {
+		t.Fatal(err)
+	}
+	createTestJPEGWithEXIF(t, filepath.Join(dir, "new.jpg"))
+
+	db, err := database.Open(filepath.Join(t.TempDir(), "since.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	for _, follow := range []bool{false, true} {
+		engine := NewEngine(db, 1)
+		engine.SetFollowSymlinks(follow)
+		engine.SetModifiedAfter(time.Now().Add(-24 * time.Hour))
+		if err := engine.IndexDirectory(dir); err != nil {
+			t.Fatalf("IndexDirectory failed: %v", err)
+		}
+		stats := engine.GetStats()
+		if stats.FilesTooOld != 1 || stats.FilesFound != 1 {
+			t.Errorf("follow symlinks %v: %d too old, %d found; want 1, 1", follow, stats.FilesTooOld, stats.FilesFound)
+		}
+	}
+	if indexErrors, _ := db.ListIndexErrors(""); len(indexErrors) != 0 {
+		t.Errorf("index errors = %+v; the old file should not have been read", indexErrors)
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"24h", time.Date(2025, 6, 14, 12, 0, 0, 0, time.UTC)},
+		{"90m", time.Date(2025, 6, 15, 10, 30, 0, 0, time.UTC)},
+		{"2025-01-01", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"2025-01-01T18:30:00", time.Date(2025, 1, 1, 18, 30, 0, 0, time.UTC)},
+		{"2025-01-01T18:30:00+02:00", time.Date(2025, 1, 1, 16, 30, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := ParseSince(tt.in, now)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("ParseSince(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range []string{"", "yesterday", "-24h", "0s", "2025-13-01"} {
+		if _, err := ParseSince(in, now); err == nil {
+			t.Errorf("ParseSince(%q) succeeded; want an error", in)
+		}
+	}
+}
+
+func TestMaxFilesAbortsBeforeIndexing(t *testing.T) {
+	dir := t.TempDir()
+	createTestJPEGWithEXIF(t, filepath.Join(dir, "a.jpg"))
+	createTestJPEGWithEXIF(t, filepath.Join(dir, "b.jpg"))
+
+	db, err := database.Open(filepath.Join(t.TempDir(), "maxfiles.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	engine := NewEngine(db, 1)
+	engine.SetMaxFiles(1)
+	err = engine.IndexDirectory(dir)
+	var tooMany *TooManyFilesError
+	if !errors.As(err, &tooMany) {
+		t.Fatalf("IndexDirectory error = %v; want TooManyFilesError", err)
+	}
+	if tooMany.Found != 2 || tooMany.Max != 1 || len(tooMany.Paths) != 2 {
+		t.Errorf("error = %+v; want 2 found, max 1, both paths listed", tooMany)
+	}
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM photos").Scan(&count); err != nil || count != 0 {
+		t.Errorf("photos = %d, %v; want none indexed", count, err)
+	}
+
+	// At the cap the run goes ahead
+	engine = NewEngine(db, 1)
+	engine.SetMaxFiles(2)
+	if err := engine.IndexDirectory(dir); err != nil {
+		t.Fatalf("IndexDirectory at the cap failed: %v", err)
+	}
+	if stats := engine.GetStats(); stats.FilesProcessed != 2 {
+		t.Errorf("processed %d files; want 2", stats.FilesProcessed)
+	}
+}
+
+func TestImageDecoders(t *testing.T) {
+	decoders := strings.Join(ImageDecoders(), " ")
+	for _, want := range []string{"jpeg", "png"} {
+		if !strings.Contains(decoders, want) {
+			t.Errorf("ImageDecoders() = %q; want %s, which the indexer always imports", decoders, want)
+		}
+	}
+}
+
+func TestNoThumbnailsLeavesThumbnailsPending(t *testing.T) {
+	dir := t.TempDir()
+	createTestJPEGWithEXIF(t, filepath.Join(dir, "photo.jpg"))
+
+	db, err := database.Open(filepath.Join(t.TempDir(), "pending.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	thumbnailCount := func() int {
+		var count int
+		if err := db.QueryRow("SELECT COUNT(*) FROM thumbnails").Scan(&count); err != nil {
+			t.Fatalf("Failed to count thumbnails: %v", err)
+		}
+		return count
+	}
+
+	engine := NewEngine(db, 1)
+	engine.SetSkipThumbnails(true)
+	if err := engine.IndexDirectory(dir); err != nil {
+		t.Fatalf("IndexDirectory failed: %v", err)
+	}
+	if pending, _ := db.GetPendingThumbnailCount(); pending != 1 {
+		t.Errorf("after -no-thumbnails: pending = %d, want 1", pending)
+	}
+	if n := thumbnailCount(); n != 0 {
+		t.Errorf("after -no-thumbnails: %d thumbnails stored, want 0", n)
+	}
+
+	var photoID int
+	if err := db.QueryRow("SELECT id FROM photos").Scan(&photoID); err != nil {
+		t.Fatalf("Failed to get photo ID: %v", err)
+	}
+	collectionID, err := db.CreateCollection("Keepers", "")
+	if err != nil {
+		t.Fatalf("CreateCollection failed: %v", err)
+	}
+	if _, err := db.AddToCollection(collectionID, []int{photoID}); err != nil {
+		t.Fatalf("AddToCollection failed: %v", err)
+	}
+
+	// Running the metadata-only pass again leaves the file alone
+	engine = NewEngine(db, 1)
+	engine.SetSkipThumbnails(true)
+	if err := engine.IndexDirectory(dir); err != nil {
+		t.Fatalf("IndexDirectory failed: %v", err)
+	}
+	if stats := engine.GetStats(); stats.FilesSkipped != 1 {
+		t.Errorf("second -no-thumbnails run skipped %d files, want 1", stats.FilesSkipped)
+	}
+
+	// A full index fills in the unchanged file's thumbnails
+	engine = NewEngine(db, 1)
+	if err := engine.IndexDirectory(dir); err != nil {
+		t.Fatalf("IndexDirectory failed: %v", err)
+	}
+	if stats := engine.GetStats(); stats.FilesUpdated != 1 || stats.FilesSkipped != 0 {
+		t.Errorf("full index: %d updated, %d skipped; want 1, 0", stats.FilesUpdated, stats.FilesSkipped)
+	}
+	if pending, _ := db.GetPendingThumbnailCount(); pending != 0 {
+		t.Errorf("after full index: pending = %d, want 0", pending)
+	}
+	if n := thumbnailCount(); n == 0 {
+		t.Error("after full index: no thumbnails stored")
+	}
+	if count, _ := db.GetPhotoCount(); count != 1 {
+		t.Errorf("photo count = %d, want 1", count)
+	}
+
+	// Filling in thumbnails updates the photo in place
+	var id int
+	if err := db.QueryRow("SELECT id FROM photos").Scan(&id); err != nil || id != photoID {
+		t.Errorf("photo ID after full index = %d (%v), want %d", id, err, photoID)
+	}
+	if member, _ := db.PhotoCollections(photoID); len(member) != 1 {
+		t.Errorf("photo is in %d collections after full index, want 1", len(member))
+	}
+}
+
+func TestTransparentPNGThumbnailBackground(t *testing.T) {
+	// 300x300 PNG, transparent except for an opaque blue square in the middle
+	src := image.NewNRGBA(image.Rect(0, 0, 300, 300))
+	for y := 100; y < 200; y++ {
+		for x := 100; x < 200; x++ {
+			src.SetNRGBA(x, y, color.NRGBA{0, 0, 255, 255})
+		}
+	}
+	dir := t.TempDir()
+	f, err := os.Create(filepath.Join(dir, "logo.png"))
+	if err != nil {
+		t.Fatalf("Failed to create fixture: %v", err)
+	}
+	if err := png.Encode(f, src); err != nil {
+		t.Fatalf("Failed to encode fixture: %v", err)
+	}
+	f.Close()
+
+	db, err := database.Open(filepath.Join(t.TempDir(), "png.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	engine := NewEngine(db, 1)
+	engine.SetThumbnailBackground(color.RGBA{255, 0, 0, 255})
+	if err := engine.IndexDirectory(dir); err != nil {
+		t.Fatalf("IndexDirectory failed: %v", err)
+	}
+
+	var data []byte
+	if err := db.QueryRow("SELECT data FROM thumbnails WHERE size = '256'").Scan(&data); err != nil {
+		t.Fatalf("Failed to read thumbnail: %v", err)
+	}
+	thumb, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Failed to decode thumbnail: %v", err)
+	}
+
+	b := thumb.Bounds()
+	for _, p := range []image.Point{b.Min, {b.Max.X - 1, b.Min.Y}, {b.Min.X, b.Max.Y - 1}, {b.Max.X - 1, b.Max.Y - 1}} {
+		r, g, bl, _ := thumb.At(p.X, p.Y).RGBA()
+		if r>>8 < 200 || g>>8 > 60 || bl>>8 > 60 {
+			t.Errorf("corner %v = (%d, %d, %d); want the red background", p, r>>8, g>>8, bl>>8)
+		}
+	}
+	r, g, bl, _ := thumb.At(b.Dx()/2, b.Dy()/2).RGBA()
+	if bl>>8 < 200 || r>>8 > 60 || g>>8 > 60 {
+		t.Errorf("centre = (%d, %d, %d); want the opaque blue square", r>>8, g>>8, bl>>8)
+	}
+}
+
+func TestPNGSizeFromImageHeader(t *testing.T) {
+	// PNG has no EXIF, so the size comes from the image itself
+	dir := t.TempDir()
+	f, err := os.Create(filepath.Join(dir, "wide.png"))
+	if err != nil {
+		t.Fatalf("Failed to create fixture: %v", err)
+	}
+	if err := png.Encode(f, image.NewRGBA(image.Rect(0, 0, 600, 400))); err != nil {
+		t.Fatalf("Failed to encode fixture: %v", err)
+	}
+	f.Close()
+
+	for _, skip := range []bool{false, true} {
+		db, err := database.Open(filepath.Join(t.TempDir(), "size.db"))
+		if err != nil {
+			t.Fatalf("Failed to open database: %v", err)
+		}
+		engine := NewEngine(db, 1)
+		engine.SetSkipThumbnails(skip)
+		if err := engine.IndexDirectory(dir); err != nil {
+			t.Fatalf("IndexDirectory failed: %v", err)
+		}
+		var width, height int
+		if err := db.QueryRow("SELECT width, height FROM photos").Scan(&width, &height); err != nil {
+			t.Fatalf("Failed to read size: %v", err)
+		}
+		if width != 600 || height != 400 {
+			t.Errorf("size with skip thumbnails %v = %dx%d; want 600x400", skip, width, height)
+		}
+		db.Close()
+	}
+}
+
+func TestFlattenAlphaLeavesOpaqueImages(t *testing.T) {
+	opaque := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for i := 3; i < len(opaque.Pix); i += 4 {
+		opaque.Pix[i] = 255
+	}
+	if got := flattenAlpha(opaque, color.White); got != image.Image(opaque) {
+		t.Error("opaque image was copied; want it returned unchanged")
+	}
+
+	transparent := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	flat := flattenAlpha(transparent, color.White)
+	if r, g, b, a := flat.At(0, 0).RGBA(); r>>8 != 255 || g>>8 != 255 || b>>8 != 255 || a>>8 != 255 {
+		t.Errorf("transparent pixel = (%d, %d, %d, %d); want opaque white", r>>8, g>>8, b>>8, a>>8)
+	}
+}
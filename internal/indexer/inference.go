@@ -1,20 +1,84 @@
 package indexer
 
 import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/adewale/olsen/internal/database"
 	"github.com/adewale/olsen/pkg/models"
 )
 
 // InferMetadata adds inferred metadata based on extracted EXIF data
 func InferMetadata(metadata *models.PhotoMetadata) {
 	metadata.TimeOfDay = inferTimeOfDay(metadata.DateTaken)
+	metadata.SunElevation = nil
+	if elevation, morning, ok := sunAtCapture(metadata); ok {
+		metadata.SunElevation = &elevation
+		metadata.TimeOfDay = timeOfDayFromSun(elevation, morning, metadata.DateTaken.Hour())
+	}
 	metadata.Season = inferSeason(metadata.DateTaken)
 	metadata.FocalCategory = inferFocalCategory(metadata.FocalLength35mm)
 	metadata.ShootingCondition = inferShootingCondition(metadata.ISO, metadata.FlashFired)
+	metadata.Edited = inferEdited(metadata.Software, metadata.CameraMake, metadata.CameraModel)
+	metadata.ExposureValue = computeExposureValue(metadata.Aperture, metadata.ShutterSpeed, metadata.ISO)
+	metadata.ShutterSeconds = nil
+	if seconds, ok := parseShutterSpeed(metadata.ShutterSpeed); ok {
+		metadata.ShutterSeconds = &seconds
+	}
+}
+
+// Reinfer recomputes the inferred columns of every indexed photo from the
+// metadata already stored, without reading any files. It is how changes to
+// the rules in InferMetadata reach an existing library. It returns how many
+// photos were examined and how many had a value change.
+func Reinfer(db *database.DB) (total, changed int, err error) {
+	return reinfer(db, 500)
+}
+
+// reinfer walks the catalog batchSize photos at a time in id order, resuming
+// after the last id seen, so memory and each write transaction stay bounded
+// however large the library is
+func reinfer(db *database.DB, batchSize int) (total, changed int, err error) {
+	afterID := 0
+	for {
+		photos, err := db.InferenceInputs(afterID, batchSize)
+		if err != nil {
+			return total, changed, err
+		}
+		for _, photo := range photos {
+			InferMetadata(photo)
+		}
+		n, err := db.UpdateInferred(photos)
+		if err != nil {
+			return total, changed, err
+		}
+		total += len(photos)
+		changed += n
+
+		if len(photos) < batchSize {
+			return total, changed, nil
+		}
+		afterID = photos[len(photos)-1].ID
+	}
+}
+
+// sunAtCapture returns the sun's elevation at capture, rounded to a tenth of
+// a degree, and whether it was before solar noon. ok is false when the photo
+// has no date or no GPS position.
+func sunAtCapture(metadata *models.PhotoMetadata) (elevation float64, morning bool, ok bool) {
+	if metadata.DateTaken.IsZero() || (metadata.Latitude == 0 && metadata.Longitude == 0) {
+		return 0, false, false
+	}
+	instant := captureInstant(metadata.DateTaken, metadata.TimeOffset, metadata.Longitude)
+	elevation, morning = sunPosition(instant, metadata.Latitude, metadata.Longitude)
+	return math.Round(elevation*10) / 10, morning, true
 }
 
-// inferTimeOfDay classifies the time of day based on the hour of capture
+// inferTimeOfDay classifies the time of day based on the hour of capture.
+// It is the fallback for photos without GPS, where the sun's position is unknown.
 func inferTimeOfDay(dateTaken time.Time) string {
 	if dateTaken.IsZero() {
 		return ""
@@ -62,23 +126,29 @@ func inferSeason(dateTaken time.Time) string {
 	}
 }
 
+// Upper bounds (inclusive, 35mm equivalent) of the focal length categories.
+// Anything longer than telephotoMaxFocal is super_telephoto.
+const (
+	wideMaxFocal      = 34
+	normalMaxFocal    = 70
+	telephotoMaxFocal = 200
+)
+
 // inferFocalCategory classifies focal length into categories
 func inferFocalCategory(focalLength35mm int) string {
-	if focalLength35mm == 0 {
+	if focalLength35mm <= 0 {
 		return ""
 	}
 
 	switch {
-	case focalLength35mm < 35:
+	case focalLength35mm <= wideMaxFocal:
 		return "wide"
-	case focalLength35mm >= 35 && focalLength35mm <= 70:
+	case focalLength35mm <= normalMaxFocal:
 		return "normal"
-	case focalLength35mm >= 71 && focalLength35mm <= 200:
+	case focalLength35mm <= telephotoMaxFocal:
 		return "telephoto"
-	case focalLength35mm > 200:
-		return "super_telephoto"
 	default:
-		return ""
+		return "super_telephoto"
 	}
 }
 
@@ -103,3 +173,103 @@ func inferShootingCondition(iso int, flashFired bool) string {
 		return ""
 	}
 }
+
+// photoEditors are substrings of the Software tag written by editing and RAW
+// conversion programs, checked before the firmware rules since some carry
+// version numbers or camera maker names ("Canon Digital Photo Professional")
+var photoEditors = []string{
+	"adobe", "lightroom", "photoshop", "capture one", "darktable", "rawtherapee",
+	"gimp", "affinity", "luminar", "dxo", "snapseed", "pixelmator", "acdsee",
+	"on1 photo", "digital photo professional", "silkypix", "picasa", "aperture",
+	"iridient", "polarr", "vsco", "paint.net", "lightzone", "capture nx", "photolab",
+}
+
+// inCameraSoftware are substrings of Software values written by cameras and
+// phones, plus metadata tools that rewrite tags without touching the pixels,
+// so a file they saved still counts as out of camera
+var inCameraSoftware = []string{"firmware", "digital camera", "hdr+", "exiftool", "exiv2"}
+
+// firmwareVersion matches Software values that are only a version number,
+// as cameras and phones write: "Ver.1.00", "Firmware Version 1.0.2", "17.1.1"
+var firmwareVersion = regexp.MustCompile(`(?i)^(firmware\s*)?(ver(sion)?[.:]?\s*)?v?\d+(\.\d+)+\b`)
+
+// inferEdited reports whether software, the EXIF Software tag, names a
+// program that processed the image rather than the camera's firmware.
+// Firmware is recognised by a bare version number, wording such as
+// "firmware", or the camera's make or model in the string ("ILCE-7M3 v3.01",
+// "Digital Camera X-T3 Ver3.00"). Any other value counts as edited.
+func inferEdited(software, cameraMake, cameraModel string) bool {
+	s := strings.ToLower(strings.TrimSpace(software))
+	if s == "" {
+		return false
+	}
+	for _, editor := range photoEditors {
+		if strings.Contains(s, editor) {
+			return true
+		}
+	}
+	for _, camera := range inCameraSoftware {
+		if strings.Contains(s, camera) {
+			return false
+		}
+	}
+	if firmwareVersion.MatchString(s) {
+		return false
+	}
+	if model := strings.ToLower(strings.TrimSpace(cameraModel)); model != "" && strings.Contains(s, model) {
+		return false
+	}
+	// Makes are often longer than the brand, e.g. "NIKON CORPORATION"
+	if brand, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(cameraMake)), " "); brand != "" && strings.HasPrefix(s, brand) {
+		return false
+	}
+	return true
+}
+
+// computeExposureValue returns the ISO 100 exposure value of a shot,
+// EV = log2(N²/t) - log2(ISO/100), rounded to a tenth of a stop. Normalising
+// to ISO 100 makes the value track scene brightness, so shots of similar
+// light compare equal whatever settings were used. It returns nil when any
+// of the three inputs is missing.
+func computeExposureValue(aperture float64, shutterSpeed string, iso int) *float64 {
+	seconds, ok := parseShutterSpeed(shutterSpeed)
+	if !ok || aperture <= 0 || iso <= 0 {
+		return nil
+	}
+
+	ev := math.Log2(aperture*aperture/seconds) - math.Log2(float64(iso)/100)
+	ev = math.Round(ev*10) / 10
+	return &ev
+}
+
+// parseShutterSpeed converts a shutter speed such as "1/250", "0.004", "2"
+// or "1/250s" to seconds. It reports false for empty or invalid input.
+func parseShutterSpeed(s string) (float64, bool) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimSuffix(s, "s")
+	s = strings.TrimSuffix(s, "\"")
+	if s == "" {
+		return 0, false
+	}
+
+	var seconds float64
+	if num, den, found := strings.Cut(s, "/"); found {
+		n, err1 := strconv.ParseFloat(strings.TrimSpace(num), 64)
+		d, err2 := strconv.ParseFloat(strings.TrimSpace(den), 64)
+		if err1 != nil || err2 != nil || d == 0 {
+			return 0, false
+		}
+		seconds = n / d
+	} else {
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, false
+		}
+		seconds = v
+	}
+
+	if seconds <= 0 || math.IsInf(seconds, 0) || math.IsNaN(seconds) {
+		return 0, false
+	}
+	return seconds, true
+}
@@ -1,9 +1,15 @@
 package indexer
 
 import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/adewale/olsen/internal/database"
+	"github.com/adewale/olsen/internal/query"
 	"github.com/adewale/olsen/pkg/models"
 )
 
@@ -137,6 +143,34 @@ func TestInferShootingCondition(t *testing.T) {
 	}
 }
 
+func TestInferEdited(t *testing.T) {
+	tests := []struct {
+		software, make, model string
+		expected              bool
+	}{
+		{"Adobe Photoshop Lightroom Classic 13.0 (Macintosh)", "Canon", "Canon EOS R5", true},
+		{"Adobe Photoshop Lightroom", "NIKON CORPORATION", "NIKON Z 6", true},
+		{"Capture One 23 Macintosh", "FUJIFILM", "X-T4", true},
+		{"darktable 4.6.0", "SONY", "ILCE-7M3", true},
+		{"Canon Digital Photo Professional 4", "Canon", "Canon EOS R5", true},
+		{"Ver.1.00 ", "NIKON CORPORATION", "NIKON Z 6", false},
+		{"Firmware Version 1.8.1", "Canon", "Canon EOS R5", false},
+		{"ILCE-7M3 v3.01", "SONY", "ILCE-7M3", false},
+		{"Digital Camera X-T3 Ver3.00", "FUJIFILM", "X-T3", false},
+		{"17.1.1", "Apple", "iPhone 15 Pro", false},
+		{"HDR+ 1.0.540104767zd", "Google", "Pixel 8", false},
+		{"Leica Camera AG", "LEICA CAMERA AG", "LEICA Q2", false},
+		{"ExifTool 12.60", "Canon", "Canon EOS R5", false},
+		{"", "Canon", "Canon EOS R5", false},
+	}
+
+	for _, tt := range tests {
+		if got := inferEdited(tt.software, tt.make, tt.model); got != tt.expected {
+			t.Errorf("inferEdited(%q, %q, %q) = %v; want %v", tt.software, tt.make, tt.model, got, tt.expected)
+		}
+	}
+}
+
 func TestInferMetadata(t *testing.T) {
 	metadata := &models.PhotoMetadata{
 		DateTaken:       time.Date(2025, 10, 4, 16, 30, 0, 0, time.UTC), // 16:30 is afternoon
@@ -160,3 +194,297 @@ func TestInferMetadata(t *testing.T) {
 		t.Errorf("ShootingCondition = %s; want moderate", metadata.ShootingCondition)
 	}
 }
+
+func TestParseShutterSpeed(t *testing.T) {
+	tests := []struct {
+		input string
+		want  float64
+		ok    bool
+	}{
+		{"1/250", 0.004, true},
+		{"1/1000", 0.001, true},
+		{"0.004", 0.004, true},
+		{"2", 2, true},
+		{"30/10", 3, true},
+		{"1/250s", 0.004, true},
+		{"", 0, false},
+		{"1/0", 0, false},
+		{"fast", 0, false},
+		{"0", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseShutterSpeed(tt.input)
+		if ok != tt.ok || math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("parseShutterSpeed(%q) = %v, %v; want %v, %v", tt.input, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestComputeExposureValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		aperture float64
+		shutter  string
+		iso      int
+		want     float64
+	}{
+		// Canon R5 fixtures: f/1.4, 1/1000, ISO 100
+		{"Canon R5 fixture", 1.4, "1/1000", 100, 10.9},
+		// Leica M11 fixture: f/2, 1/250, ISO 10000
+		{"Leica M11 fixture", 2, "1/250", 10000, 3.3},
+		{"Sunny 16", 16, "1/100", 100, 14.6},
+		{"ISO doubles, EV drops a stop", 16, "1/100", 200, 13.6},
+		{"Long exposure", 2, "4", 100, 0},
+		{"Decimal shutter", 8, "0.004", 100, 14},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := computeExposureValue(tt.aperture, tt.shutter, tt.iso)
+			if got == nil {
+				t.Fatalf("computeExposureValue = nil; want %.1f", tt.want)
+			}
+			if *got != tt.want {
+				t.Errorf("computeExposureValue = %.1f; want %.1f", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComputeExposureValueMissing(t *testing.T) {
+	if ev := computeExposureValue(0, "1/250", 100); ev != nil {
+		t.Errorf("missing aperture: got %v; want nil", *ev)
+	}
+	if ev := computeExposureValue(2.8, "", 100); ev != nil {
+		t.Errorf("missing shutter: got %v; want nil", *ev)
+	}
+	if ev := computeExposureValue(2.8, "1/250", 0); ev != nil {
+		t.Errorf("missing ISO: got %v; want nil", *ev)
+	}
+}
+
+func TestSunPositionParis(t *testing.T) {
+	// Solar noon at the June solstice: 90° - 48.86° + 23.44°
+	noon := captureInstant(time.Date(2025, 6, 21, 13, 50, 0, 0, time.UTC), "+02:00", 2.3522)
+	elevation, _ := sunPosition(noon, 48.8566, 2.3522)
+	if math.Abs(elevation-64.6) > 0.3 {
+		t.Errorf("solstice noon elevation = %.2f; want about 64.6", elevation)
+	}
+}
+
+func TestInferTimeOfDayFromSun(t *testing.T) {
+	// Paris, matching the GPS of testdata fixture 07 (winter night)
+	const lat, lon = 48.8566, 2.3522
+
+	tests := []struct {
+		name      string
+		date      time.Time
+		offset    string
+		want      string
+		clockWant string // what the hour-only heuristic would have said
+	}{
+		{"fixture 07 winter night", time.Date(2025, 12, 5, 23, 30, 0, 0, time.UTC), "", "night", "night"},
+		{"summer evening sun still up", time.Date(2025, 6, 21, 21, 30, 0, 0, time.UTC), "+02:00", "golden_hour_evening", "blue_hour"},
+		{"summer sunrise", time.Date(2025, 6, 21, 6, 15, 0, 0, time.UTC), "+02:00", "golden_hour_morning", "golden_hour_morning"},
+		{"winter dusk", time.Date(2025, 12, 5, 17, 30, 0, 0, time.UTC), "+01:00", "blue_hour", "afternoon"},
+		{"summer midday", time.Date(2025, 6, 21, 13, 50, 0, 0, time.UTC), "+02:00", "midday", "midday"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metadata := &models.PhotoMetadata{
+				DateTaken:  tt.date,
+				TimeOffset: tt.offset,
+				Latitude:   lat,
+				Longitude:  lon,
+			}
+			InferMetadata(metadata)
+
+			if metadata.SunElevation == nil {
+				t.Fatal("SunElevation not set for a photo with GPS")
+			}
+			if metadata.TimeOfDay != tt.want {
+				t.Errorf("TimeOfDay = %s (sun at %v°); want %s", metadata.TimeOfDay, *metadata.SunElevation, tt.want)
+			}
+			if clock := inferTimeOfDay(tt.date); clock != tt.clockWant {
+				t.Errorf("clock heuristic = %s; want %s", clock, tt.clockWant)
+			}
+		})
+	}
+}
+
+func TestInferTimeOfDayWithoutGPS(t *testing.T) {
+	metadata := &models.PhotoMetadata{
+		DateTaken: time.Date(2025, 6, 21, 21, 30, 0, 0, time.UTC),
+	}
+	InferMetadata(metadata)
+
+	if metadata.SunElevation != nil {
+		t.Errorf("SunElevation = %v; want nil without GPS", *metadata.SunElevation)
+	}
+	if metadata.TimeOfDay != "blue_hour" {
+		t.Errorf("TimeOfDay = %s; want clock-based blue_hour", metadata.TimeOfDay)
+	}
+}
+
+func TestInferShutterSeconds(t *testing.T) {
+	// Shutter speeds used by the testdata DNG fixtures, plus values with no duration
+	tests := []struct {
+		shutter string
+		want    float64 // 0 means nil
+	}{
+		{"1/1000", 0.001},
+		{"1/30", 1.0 / 30},
+		{"2", 2},
+		{"1/2000", 0.0005},
+		{"bulb", 0},
+		{"", 0},
+	}
+
+	for _, tt := range tests {
+		metadata := &models.PhotoMetadata{ShutterSpeed: tt.shutter}
+		InferMetadata(metadata)
+
+		switch {
+		case tt.want == 0 && metadata.ShutterSeconds != nil:
+			t.Errorf("ShutterSeconds(%q) = %v; want nil", tt.shutter, *metadata.ShutterSeconds)
+		case tt.want != 0 && metadata.ShutterSeconds == nil:
+			t.Errorf("ShutterSeconds(%q) = nil; want %v", tt.shutter, tt.want)
+		case tt.want != 0 && *metadata.ShutterSeconds != tt.want:
+			t.Errorf("ShutterSeconds(%q) = %v; want %v", tt.shutter, *metadata.ShutterSeconds, tt.want)
+		}
+	}
+}
+
+func TestReinferUpdatesFocalCategory(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "reinfer.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	taken := time.Date(2024, 6, 21, 20, 30, 0, 0, time.UTC)
+	photos := []*models.PhotoMetadata{
+		{FilePath: "/test/50mm.dng", FileHash: "a", FocalLength35mm: 50, DateTaken: taken},
+		{FilePath: "/test/24mm.dng", FileHash: "b", FocalLength35mm: 24, DateTaken: taken,
+			Latitude: 48.8566, Longitude: 2.3522, TimeOffset: "+02:00"},
+	}
+	for _, p := range photos {
+		InferMetadata(p)
+		if err := db.InsertPhoto(p); err != nil {
+			t.Fatalf("InsertPhoto failed: %v", err)
+		}
+	}
+	sunBefore := *photos[1].SunElevation
+
+	// Unchanged rules leave the library as it is
+	total, changed, err := Reinfer(db)
+	if err != nil {
+		t.Fatalf("Reinfer failed: %v", err)
+	}
+	if total != 2 || changed != 0 {
+		t.Errorf("Reinfer with unchanged rules = %d total, %d changed; want 2, 0", total, changed)
+	}
+
+	// A category stored by older rules is brought up to date, a photo per
+	// batch to walk the catalog in more than one
+	if _, err := db.Exec("UPDATE photos SET focal_category = 'telephoto' WHERE file_path = ?", "/test/50mm.dng"); err != nil {
+		t.Fatalf("Failed to set focal_category: %v", err)
+	}
+	total, changed, err = reinfer(db, 1)
+	if err != nil {
+		t.Fatalf("reinfer failed: %v", err)
+	}
+	if total != 2 || changed != 1 {
+		t.Errorf("reinfer in batches of 1 = %d total, %d changed; want 2, 1", total, changed)
+	}
+
+	var category string
+	if err := db.QueryRow("SELECT focal_category FROM photos WHERE file_path = ?", "/test/50mm.dng").Scan(&category); err != nil {
+		t.Fatalf("Failed to read focal_category: %v", err)
+	}
+	if category != "normal" {
+		t.Errorf("focal_category = %q; want normal", category)
+	}
+
+	// The stored time offset keeps the sun position identical to indexing
+	var sun float64
+	if err := db.QueryRow("SELECT sun_elevation FROM photos WHERE file_path = ?", "/test/24mm.dng").Scan(&sun); err != nil {
+		t.Fatalf("Failed to read sun_elevation: %v", err)
+	}
+	if sun != sunBefore {
+		t.Errorf("sun_elevation after reinfer = %v; want %v", sun, sunBefore)
+	}
+}
+
+// TestTimeOfDayVocabulary checks that every time of day InferMetadata can
+// produce, from the clock or from the sun, is known to the query package:
+// it has a facet entry with a proper label, a path and a query string that
+// parse back to it.
+func TestTimeOfDayVocabulary(t *testing.T) {
+	produced := make(map[string]bool)
+	for hour := 0; hour < 24; hour++ {
+		metadata := &models.PhotoMetadata{DateTaken: time.Date(2025, 6, 21, hour, 30, 0, 0, time.UTC)}
+		InferMetadata(metadata)
+		produced[metadata.TimeOfDay] = true
+	}
+	for elevation := -20.0; elevation <= 60; elevation += 0.5 {
+		for _, hour := range []int{9, 12, 16} {
+			produced[timeOfDayFromSun(elevation, hour < 12, hour)] = true
+		}
+	}
+
+	db, err := database.Open(filepath.Join(t.TempDir(), "tod.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	known := make(map[string]bool)
+	for _, v := range query.TimeOfDayValues {
+		known[v] = true
+	}
+	mapper := query.NewURLMapper()
+	for value := range produced {
+		if !known[value] {
+			t.Errorf("%s is inferred but missing from query.TimeOfDayValues", value)
+		}
+
+		params, err := mapper.ParsePath("/"+value, "")
+		if err != nil || len(params.TimeOfDay) != 1 || params.TimeOfDay[0] != value {
+			t.Errorf("path /%s parsed to %v, %v", value, params.TimeOfDay, err)
+		}
+		qs := mapper.BuildQueryString(params)
+		if reparsed, _ := mapper.ParsePath("/photos", qs[1:]); len(reparsed.TimeOfDay) != 1 || reparsed.TimeOfDay[0] != value {
+			t.Errorf("%s does not round-trip through %q", value, qs)
+		}
+
+		photo := &models.PhotoMetadata{FilePath: value, FileHash: value, TimeOfDay: value}
+		if err := db.InsertPhoto(photo); err != nil {
+			t.Fatalf("InsertPhoto failed: %v", err)
+		}
+	}
+
+	facets, err := query.NewEngine(db.DB).ComputeFacets(query.QueryParams{})
+	if err != nil {
+		t.Fatalf("ComputeFacets failed: %v", err)
+	}
+	labels := make(map[string]string)
+	var order []string
+	for _, v := range facets.TimeOfDay.Values {
+		labels[v.Value] = v.Label
+		order = append(order, v.Value)
+	}
+	for value := range produced {
+		label, ok := labels[value]
+		if !ok {
+			t.Errorf("%s has no facet entry", value)
+		} else if label != query.TimeOfDayLabel(value) || strings.Contains(label, "_") {
+			t.Errorf("%s facet label = %q", value, label)
+		}
+	}
+	if fmt.Sprint(order) != fmt.Sprint(query.TimeOfDayValues) {
+		t.Errorf("facet order = %v; want %v", order, query.TimeOfDayValues)
+	}
+}
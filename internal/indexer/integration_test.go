@@ -213,7 +213,7 @@ func TestIntegrationFileTypeSupport(t *testing.T) {
 	engine := NewEngine(db, 2)
 
 	// Find all files
-	files, err := engine.findDNGFiles(testDataPath)
+	files, _, err := engine.findDNGFiles(testDataPath)
 	if err != nil {
 		t.Fatalf("Failed to find files: %v", err)
 	}
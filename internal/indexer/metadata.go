@@ -1,14 +1,17 @@
 package indexer
 
 import (
+	"bytes"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
 	exif "github.com/dsoprea/go-exif/v3"
 	exifcommon "github.com/dsoprea/go-exif/v3/common"
 
+	"github.com/adewale/olsen/internal/quality"
 	"github.com/adewale/olsen/pkg/models"
 )
 
@@ -32,8 +35,21 @@ func ExtractMetadata(filePath string) (*models.PhotoMetadata, error) {
 		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
 
+	// HEIF keeps EXIF in an item of its own. Taking it from there avoids
+	// mistaking bytes of the HEVC image data for a TIFF header.
+	exifData := data
+	var heif *heifImage
+	if isHEIFExtension(filepath.Ext(filePath)) {
+		if heif, err = readHEIF(bytes.NewReader(data), int64(len(data))); err != nil {
+			return nil, fmt.Errorf("failed to read HEIF container: %w", err)
+		}
+		if heif.Exif != nil {
+			exifData = heif.Exif
+		}
+	}
+
 	// Parse EXIF
-	rawExif, err := exif.SearchAndExtractExif(data)
+	rawExif, err := exif.SearchAndExtractExif(exifData)
 	if err != nil {
 		return nil, fmt.Errorf("failed to extract EXIF: %w", err)
 	}
@@ -51,6 +67,8 @@ func ExtractMetadata(filePath string) (*models.PhotoMetadata, error) {
 		IndexedAt:    time.Now(),
 	}
 
+	var interop string // Interoperability index, e.g. "R98" (sRGB) or "R03" (Adobe RGB)
+
 	// Process all EXIF tags
 	for _, entry := range entries {
 		tagName := entry.TagName
@@ -60,6 +78,15 @@ func ExtractMetadata(filePath string) (*models.PhotoMetadata, error) {
 			continue
 		}
 
+		// Keep every tag for the raw EXIF view
+		if formatted := formatExifValue(entry); formatted != "" {
+			metadata.RawExif = append(metadata.RawExif, models.ExifEntry{
+				IFD:   entry.IfdPath,
+				Tag:   tagName,
+				Value: formatted,
+			})
+		}
+
 		switch tagName {
 		// Camera metadata
 		case "Make":
@@ -70,8 +97,31 @@ func ExtractMetadata(filePath string) (*models.PhotoMetadata, error) {
 			metadata.LensMake = strings.Trim(fmt.Sprintf("%v", val), "\x00 ")
 		case "LensModel":
 			metadata.LensModel = strings.Trim(fmt.Sprintf("%v", val), "\x00 ")
+		case "BodySerialNumber", "CameraSerialNumber":
+			// Prefer the EXIF tag; the DNG one is only a fallback
+			if tagName == "BodySerialNumber" || metadata.CameraSerial == "" {
+				metadata.CameraSerial = strings.Trim(fmt.Sprintf("%v", val), "\x00 ")
+			}
+
+		// Title and caption; XMP, read below, takes precedence
+		case "ImageDescription":
+			if text, ok := val.(string); ok {
+				metadata.Caption = cleanText(text)
+			}
+		case "XPTitle":
+			metadata.Title = xpText(val)
+		case "XPComment":
+			if metadata.Caption == "" {
+				metadata.Caption = xpText(val)
+			}
 
 		// Exposure metadata
+		case "Software", "ProcessingSoftware":
+			// Software is the program that wrote the file; ProcessingSoftware
+			// (DNG) is only a fallback
+			if tagName == "Software" || metadata.Software == "" {
+				metadata.Software = strings.Trim(fmt.Sprintf("%v", val), "\x00 ")
+			}
 		case "ISOSpeedRatings", "PhotographicSensitivity":
 			if iso, ok := val.([]uint16); ok && len(iso) > 0 {
 				metadata.ISO = int(iso[0])
@@ -113,6 +163,10 @@ func ExtractMetadata(filePath string) (*models.PhotoMetadata, error) {
 					}
 				}
 			}
+		case "OffsetTimeOriginal":
+			if offset, ok := val.(string); ok {
+				metadata.TimeOffset = strings.TrimSpace(offset)
+			}
 		case "DateTimeDigitized":
 			if dateStr, ok := val.(string); ok {
 				if t, err := parseExifDateTime(dateStr); err == nil {
@@ -148,7 +202,11 @@ func ExtractMetadata(filePath string) (*models.PhotoMetadata, error) {
 				metadata.Orientation = int(v[0])
 			}
 		case "ColorSpace":
-			metadata.ColourSpace = fmt.Sprintf("%v", val)
+			metadata.ColourSpace = exifColourSpace(val)
+		case "InteroperabilityIndex":
+			if idx, ok := val.(string); ok {
+				interop = strings.Trim(idx, "\x00 ")
+			}
 
 		// GPS metadata
 		case "GPSLatitude":
@@ -160,7 +218,7 @@ func ExtractMetadata(filePath string) (*models.PhotoMetadata, error) {
 				metadata.Longitude = lon
 			}
 		case "GPSAltitude":
-			if rats, ok := val.([]exifcommon.Rational); ok && len(rats) > 0 {
+			if rats, ok := val.([]exifcommon.Rational); ok && len(rats) > 0 && rats[0].Denominator != 0 {
 				metadata.Altitude = float64(rats[0].Numerator) / float64(rats[0].Denominator)
 			}
 
@@ -182,6 +240,31 @@ func ExtractMetadata(filePath string) (*models.PhotoMetadata, error) {
 		}
 	}
 
+	// The container's image properties stand in for EXIF tags left out
+	if heif != nil {
+		if metadata.Width == 0 || metadata.Height == 0 {
+			metadata.Width, metadata.Height = heif.Width, heif.Height
+		}
+		if metadata.Orientation == 0 {
+			metadata.Orientation = heif.orientation()
+		}
+	}
+
+	// DCF marks Adobe RGB files as uncalibrated with interoperability index R03
+	if metadata.ColourSpace == "Uncalibrated" && interop == "R03" {
+		metadata.ColourSpace = quality.ColourSpaceAdobeRGB
+	}
+
+	// XMP holds what editing software wrote, in any language; EXIF text is
+	// ASCII and often a camera placeholder
+	title, description := readXMPText(data)
+	if title != "" {
+		metadata.Title = title
+	}
+	if description != "" {
+		metadata.Caption = description
+	}
+
 	// Apply GPS reference directions
 	for _, entry := range entries {
 		val := entry.Value
@@ -198,12 +281,38 @@ func ExtractMetadata(filePath string) (*models.PhotoMetadata, error) {
 			if ref, ok := val.(string); ok && (ref == "W" || ref == "w") {
 				metadata.Longitude = -metadata.Longitude
 			}
+		case "GPSAltitudeRef":
+			if belowSeaLevel(val) {
+				metadata.Altitude = -metadata.Altitude
+			}
 		}
 	}
 
 	return metadata, nil
 }
 
+// belowSeaLevel reports whether a GPSAltitudeRef value is 1, meaning
+// GPSAltitude is a depth below sea level rather than a height above it
+func belowSeaLevel(val interface{}) bool {
+	switch v := val.(type) {
+	case []uint8:
+		return len(v) > 0 && v[0] == 1
+	case uint8:
+		return v == 1
+	}
+	return false
+}
+
+// formatExifValue returns a printable form of a tag value, preferring the
+// library's own formatting
+func formatExifValue(entry exif.ExifTag) string {
+	formatted := entry.Formatted
+	if formatted == "" {
+		formatted = fmt.Sprintf("%v", entry.Value)
+	}
+	return strings.Trim(formatted, "\x00 ")
+}
+
 // parseGPSCoordinate parses GPS coordinate from EXIF rational array
 func parseGPSCoordinate(entry *exif.ExifTag) float64 {
 	if entry.Value == nil {
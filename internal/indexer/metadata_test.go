@@ -264,3 +264,22 @@ func TestParseExifDateTime_EdgeCases(t *testing.T) {
 		}
 	})
 }
+
+func TestBelowSeaLevel(t *testing.T) {
+	tests := []struct {
+		val  interface{}
+		want bool
+	}{
+		{[]uint8{1}, true},
+		{[]uint8{0}, false},
+		{uint8(1), true},
+		{[]uint8{}, false},
+		{"1", false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := belowSeaLevel(tt.val); got != tt.want {
+			t.Errorf("belowSeaLevel(%#v) = %v; want %v", tt.val, got, tt.want)
+		}
+	}
+}
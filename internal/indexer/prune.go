@@ -0,0 +1,44 @@
+package indexer
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// PruneDeleted removes the photos indexed under rootPath whose files no
+// longer exist, with their thumbnails, colours and EXIF, and returns how
+// many it removed. rootPath is matched the way IndexDirectory records
+// paths, so pass the same root that was indexed. Photos elsewhere in the
+// catalog are never touched. Only a missing file counts as deleted: one
+// that can't be read is kept. A missing root is an error rather than a
+// reason to prune everything under it, as happens when a drive is not
+// mounted.
+func (e *Engine) PruneDeleted(rootPath string) (int, error) {
+	info, err := os.Stat(rootPath)
+	if err != nil {
+		return 0, fmt.Errorf("cannot prune %s: %w", rootPath, err)
+	}
+	if !info.IsDir() {
+		return 0, fmt.Errorf("cannot prune %s: not a directory", rootPath)
+	}
+
+	paths, err := e.db.PhotoPathsUnder(filepath.Clean(rootPath))
+	if err != nil {
+		return 0, err
+	}
+
+	pruned := 0
+	for _, path := range paths {
+		if _, err := os.Lstat(path); !os.IsNotExist(err) {
+			continue
+		}
+		if err := e.db.DeletePhoto(path); err != nil {
+			return pruned, fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+		log.Printf("Pruned deleted file %s", path)
+		pruned++
+	}
+	return pruned, nil
+}
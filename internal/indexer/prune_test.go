@@ -0,0 +1,117 @@
+package indexer
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/adewale/olsen/internal/database"
+	"github.com/adewale/olsen/pkg/models"
+)
+
+func TestPruneDeleted(t *testing.T) {
+	root := t.TempDir()
+	other := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "kept.jpg"), []byte("jpeg"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := database.Open(filepath.Join(t.TempDir(), "prune.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// kept.jpg is on disk; the others were deleted, but gone.jpg in another
+	// root is outside the one being pruned
+	for _, path := range []string{
+		filepath.Join(root, "kept.jpg"),
+		filepath.Join(root, "deleted.jpg"),
+		filepath.Join(root, "sub", "deleted.jpg"),
+		filepath.Join(other, "gone.jpg"),
+	} {
+		if err := db.InsertPhoto(&models.PhotoMetadata{FilePath: path, FileHash: path}); err != nil {
+			t.Fatalf("InsertPhoto failed: %v", err)
+		}
+	}
+
+	engine := NewEngine(db, 1)
+	pruned, err := engine.PruneDeleted(root + string(filepath.Separator))
+	if err != nil {
+		t.Fatalf("PruneDeleted failed: %v", err)
+	}
+	if pruned != 2 {
+		t.Errorf("pruned %d photos; want the 2 deleted under the root", pruned)
+	}
+	count, _ := db.GetPhotoCount()
+	if count != 2 {
+		t.Errorf("%d photos left; want kept.jpg and the one outside the root", count)
+	}
+
+	// An unmounted root must not look like every file was deleted
+	if _, err := engine.PruneDeleted(filepath.Join(root, "missing")); err == nil {
+		t.Error("PruneDeleted on a missing root succeeded; want an error")
+	}
+}
+
+func TestPruneDeletedBurstRepresentative(t *testing.T) {
+	root := t.TempDir()
+	db, err := database.Open(filepath.Join(t.TempDir(), "prune_burst.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// A burst of three, with only the first and last frames still on disk
+	base := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		path := filepath.Join(root, fmt.Sprintf("%d.jpg", i))
+		if i != 1 {
+			if err := os.WriteFile(path, []byte("jpeg"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		photo := &models.PhotoMetadata{FilePath: path, FileHash: path, CameraMake: "Canon", CameraModel: "EOS R5", DateTaken: base.Add(time.Duration(i) * time.Second)}
+		if err := db.InsertPhoto(photo); err != nil {
+			t.Fatalf("InsertPhoto failed: %v", err)
+		}
+	}
+	if err := NewBurstDetector(db).SaveBursts([][]int{{1, 2, 3}}); err != nil {
+		t.Fatalf("SaveBursts failed: %v", err)
+	}
+	representative := func() (id sql.NullInt64) {
+		t.Helper()
+		if err := db.QueryRow("SELECT representative_photo_id FROM burst_groups").Scan(&id); err != nil {
+			t.Fatalf("Failed to read burst group: %v", err)
+		}
+		return id
+	}
+	if rep := representative(); rep.Int64 != 2 {
+		t.Fatalf("representative = %v; want the middle frame, 2", rep)
+	}
+
+	// Deleting the representative hands the burst to another frame
+	engine := NewEngine(db, 1)
+	if pruned, err := engine.PruneDeleted(root); err != nil || pruned != 1 {
+		t.Fatalf("PruneDeleted = %d, %v; want 1 pruned", pruned, err)
+	}
+	rep := representative()
+	var flagged bool
+	if err := db.QueryRow("SELECT is_burst_representative FROM photos WHERE id = ?", rep.Int64).Scan(&flagged); err != nil || !flagged || rep.Int64 == 2 {
+		t.Errorf("representative after prune = %v (flagged %v, %v); want another frame of the burst", rep, flagged, err)
+	}
+
+	// Once every frame is gone the burst has none
+	for i := 0; i < 3; i++ {
+		os.Remove(filepath.Join(root, fmt.Sprintf("%d.jpg", i)))
+	}
+	if pruned, err := engine.PruneDeleted(root); err != nil || pruned != 2 {
+		t.Fatalf("PruneDeleted = %d, %v; want 2 pruned", pruned, err)
+	}
+	if rep := representative(); rep.Valid {
+		t.Errorf("representative of an empty burst = %v; want NULL", rep)
+	}
+}
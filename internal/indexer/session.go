@@ -0,0 +1,124 @@
+package indexer
+
+import (
+	"time"
+
+	"github.com/adewale/olsen/internal/database"
+)
+
+// DefaultSessionGap is the longest pause between two shots of one session
+const DefaultSessionGap = 30 * time.Minute
+
+// MinSessionGap keeps sessions coarser than bursts and brackets, whose
+// frames are at most a few seconds apart: with a gap at least this long,
+// every burst and bracket falls inside a single session
+const MinSessionGap = time.Minute
+
+// SessionDetector groups the library into shooting sessions: maximal runs
+// of photos, in capture order and across all cameras, where no two
+// consecutive shots are more than maxGap apart. Every dated photo belongs to
+// exactly one session, even if it is the only photo in it.
+type SessionDetector struct {
+	db     *database.DB
+	maxGap time.Duration
+}
+
+// NewSessionDetector creates a session detector that starts a new session
+// after any pause longer than maxGap
+func NewSessionDetector(db *database.DB, maxGap time.Duration) *SessionDetector {
+	return &SessionDetector{db: db, maxGap: maxGap}
+}
+
+// DetectSessions reads every dated photo and returns the sessions, each as
+// photo IDs in capture order
+func (sd *SessionDetector) DetectSessions() ([][]int, error) {
+	rows, err := sd.db.Query(`
+		SELECT id, file_path, date_taken
+		FROM photos
+		WHERE date_taken IS NOT NULL
+		ORDER BY date_taken, id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var photos []Photo
+	for rows.Next() {
+		var p Photo
+		var dateTakenStr string
+		if err := rows.Scan(&p.ID, &p.FilePath, &dateTakenStr); err != nil {
+			return nil, err
+		}
+
+		p.DateTaken, err = time.Parse("2006-01-02 15:04:05", dateTakenStr)
+		if err != nil {
+			p.DateTaken, err = time.Parse(time.RFC3339, dateTakenStr)
+			if err != nil {
+				continue // Skip photos with unparseable dates
+			}
+		}
+		photos = append(photos, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sd.findSessions(photos), nil
+}
+
+// findSessions splits photos sorted by date wherever consecutive shots are
+// more than maxGap apart
+func (sd *SessionDetector) findSessions(photos []Photo) [][]int {
+	var sessions [][]int
+	for i, p := range photos {
+		if i == 0 || p.DateTaken.Sub(photos[i-1].DateTaken) > sd.maxGap {
+			sessions = append(sessions, nil)
+		}
+		last := len(sessions) - 1
+		sessions[last] = append(sessions[last], p.ID)
+	}
+	return sessions
+}
+
+// ClearSessions removes all session assignments, so detection can be re-run
+func (sd *SessionDetector) ClearSessions() error {
+	_, err := sd.db.Exec("UPDATE photos SET session_id = NULL WHERE session_id IS NOT NULL")
+	return err
+}
+
+// SaveSessions stores detected sessions on their photos. A session's ID is
+// its first photo's ID, so re-running detection on an unchanged catalog
+// gives the same IDs.
+func (sd *SessionDetector) SaveSessions(sessions [][]int) error {
+	tx, err := sd.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare("UPDATE photos SET session_id = ? WHERE id = ?")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, session := range sessions {
+		for _, photoID := range session {
+			if _, err := stmt.Exec(session[0], photoID); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// GetSessionStats returns the number of sessions and of photos in sessions
+// of more than one photo
+func (sd *SessionDetector) GetSessionStats() (sessions, grouped int, err error) {
+	err = sd.db.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(CASE WHEN n > 1 THEN n END), 0)
+		FROM (SELECT COUNT(*) AS n FROM photos WHERE session_id IS NOT NULL GROUP BY session_id)
+	`).Scan(&sessions, &grouped)
+	return sessions, grouped, err
+}
@@ -0,0 +1,74 @@
+package indexer
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/adewale/olsen/internal/database"
+	"github.com/adewale/olsen/pkg/models"
+)
+
+func TestDetectSessions(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "sessions.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	start := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	offsets := []time.Duration{
+		0, 20 * time.Minute, 45 * time.Minute, // Gaps of 20 and 25 minutes: one session
+		2 * time.Hour,                                                         // Alone
+		5 * time.Hour, 5*time.Hour + time.Second, 5*time.Hour + 2*time.Second, // A burst
+	}
+	for i, offset := range offsets {
+		photo := &models.PhotoMetadata{FilePath: fmt.Sprintf("/%d.jpg", i+1), FileHash: fmt.Sprint(i), DateTaken: start.Add(offset)}
+		if err := db.InsertPhoto(photo); err != nil {
+			t.Fatalf("InsertPhoto failed: %v", err)
+		}
+	}
+	if err := db.InsertPhoto(&models.PhotoMetadata{FilePath: "/undated.jpg", FileHash: "u"}); err != nil {
+		t.Fatalf("InsertPhoto failed: %v", err)
+	}
+
+	detector := NewSessionDetector(db, DefaultSessionGap)
+	sessions, err := detector.DetectSessions()
+	if err != nil {
+		t.Fatalf("DetectSessions failed: %v", err)
+	}
+	if got := fmt.Sprint(sessions); got != "[[1 2 3] [4] [5 6 7]]" {
+		t.Errorf("sessions = %s; want [[1 2 3] [4] [5 6 7]]", got)
+	}
+	if err := detector.SaveSessions(sessions); err != nil {
+		t.Fatalf("SaveSessions failed: %v", err)
+	}
+
+	var got []string
+	rows, err := db.Query("SELECT id, COALESCE(session_id, 0) FROM photos ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id, session int
+		if err := rows.Scan(&id, &session); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, fmt.Sprintf("%d:%d", id, session))
+	}
+	if fmt.Sprint(got) != "[1:1 2:1 3:1 4:4 5:5 6:5 7:5 8:0]" {
+		t.Errorf("session_id by photo = %v; want each session keyed by its first photo, none for the undated one", got)
+	}
+
+	count, grouped, err := detector.GetSessionStats()
+	if err != nil || count != 3 || grouped != 6 {
+		t.Errorf("stats = %d sessions, %d grouped, %v; want 3 and 6", count, grouped, err)
+	}
+
+	// A shorter gap splits the morning apart
+	if sessions, _ := NewSessionDetector(db, 15*time.Minute).DetectSessions(); len(sessions) != 5 {
+		t.Errorf("with a 15 minute gap, got %d sessions; want 5", len(sessions))
+	}
+}
@@ -0,0 +1,87 @@
+package indexer
+
+import (
+	"math"
+	"time"
+)
+
+// Sun elevation bands used to classify twilight, in degrees. These follow
+// the usual photographic definitions: golden hour while the sun is low
+// enough for warm, soft light, blue hour during civil twilight once the
+// warm light has gone.
+const (
+	goldenHourMax = 6.0
+	blueHourMax   = -4.0
+	blueHourMin   = -6.0
+)
+
+// captureInstant converts the zone-less EXIF wall-clock time to a real
+// instant. With an EXIF offset the result is exact. Without one the time is
+// treated as local mean solar time at the given longitude, which is within
+// an hour or so of civil time in most places but ignores daylight saving.
+func captureInstant(dateTaken time.Time, offset string, longitude float64) time.Time {
+	wall := time.Date(dateTaken.Year(), dateTaken.Month(), dateTaken.Day(),
+		dateTaken.Hour(), dateTaken.Minute(), dateTaken.Second(), dateTaken.Nanosecond(), time.UTC)
+
+	if t, err := time.Parse("-07:00", offset); err == nil {
+		_, seconds := t.Zone()
+		return wall.Add(-time.Duration(seconds) * time.Second)
+	}
+	return wall.Add(-time.Duration(longitude / 15 * float64(time.Hour)))
+}
+
+// sunPosition returns the sun's elevation above the horizon in degrees at
+// instant t and location (latitude, longitude), and whether it is before
+// solar noon. It uses the low-precision formulae from the Astronomical
+// Almanac, accurate to about 0.1° for current dates, which is far finer
+// than the twilight bands need.
+func sunPosition(t time.Time, latitude, longitude float64) (elevation float64, morning bool) {
+	// Days since J2000.0 (2000-01-01 12:00 UTC)
+	n := float64(t.UTC().Sub(time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC))) / float64(24*time.Hour)
+
+	meanLongitude := 280.460 + 0.9856474*n
+	meanAnomaly := radians(357.528 + 0.9856003*n)
+	eclipticLongitude := radians(meanLongitude + 1.915*math.Sin(meanAnomaly) + 0.020*math.Sin(2*meanAnomaly))
+	obliquity := radians(23.439 - 0.0000004*n)
+
+	rightAscension := math.Atan2(math.Cos(obliquity)*math.Sin(eclipticLongitude), math.Cos(eclipticLongitude))
+	declination := math.Asin(math.Sin(obliquity) * math.Sin(eclipticLongitude))
+
+	// Greenwich mean sidereal time in degrees, then the local hour angle
+	siderealTime := 280.46061837 + 360.98564736629*n
+	hourAngle := radians(siderealTime+longitude) - rightAscension
+
+	lat := radians(latitude)
+	sinElevation := math.Sin(lat)*math.Sin(declination) + math.Cos(lat)*math.Cos(declination)*math.Cos(hourAngle)
+	elevation = math.Asin(sinElevation) * 180 / math.Pi
+
+	// A negative hour angle (mod 360°) means the sun has yet to cross the meridian
+	return elevation, math.Sin(hourAngle) < 0
+}
+
+// timeOfDayFromSun classifies time of day from the sun's elevation. Twilight
+// and night come from the sun; in full daylight the clock hour still decides
+// between morning, midday and afternoon.
+func timeOfDayFromSun(elevation float64, morning bool, hour int) string {
+	switch {
+	case elevation < blueHourMin:
+		return "night"
+	case elevation < blueHourMax:
+		return "blue_hour"
+	case elevation < goldenHourMax:
+		if morning {
+			return "golden_hour_morning"
+		}
+		return "golden_hour_evening"
+	case hour < 11:
+		return "morning"
+	case hour < 15:
+		return "midday"
+	default:
+		return "afternoon"
+	}
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
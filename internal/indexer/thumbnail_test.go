@@ -2,11 +2,13 @@ package indexer
 
 import (
 	"bytes"
+	"context"
 	"image"
 	"image/color"
 	"image/jpeg"
 	"testing"
 
+	"github.com/adewale/olsen/internal/quality"
 	"github.com/adewale/olsen/pkg/models"
 )
 
@@ -200,6 +202,103 @@ func TestThumbnailAspectRatioSquare(t *testing.T) {
 }
 
 // Helper function to create a solid color image
+func TestProgressiveLargeThumbnail(t *testing.T) {
+	// Odd output height (1024x683) exercises partial MCUs
+	img := createColourGradientImage(1600, 1067)
+	meta := quality.ImageMetadata{Orientation: 1, Width: 1600, Height: 1067}
+
+	cfg := quality.DefaultThumbnailConfig()
+	baseline, _, err := quality.GenerateThumbnailsWithDiag(context.Background(), img, meta, cfg)
+	if err != nil {
+		t.Fatalf("Baseline generation failed: %v", err)
+	}
+	cfg.Progressive = true
+	progressive, _, err := quality.GenerateThumbnailsWithDiag(context.Background(), img, meta, cfg)
+	if err != nil {
+		t.Fatalf("Progressive generation failed: %v", err)
+	}
+
+	// SOF2 marks a progressive frame; only the large size should have one
+	if !bytes.Contains(progressive[models.ThumbnailLarge], []byte{0xff, 0xc2}) {
+		t.Error("Large thumbnail is not progressive")
+	}
+	if bytes.Contains(progressive[models.ThumbnailMedium], []byte{0xff, 0xc2}) {
+		t.Error("Medium thumbnail should stay baseline")
+	}
+
+	want, err := jpeg.Decode(bytes.NewReader(baseline[models.ThumbnailLarge]))
+	if err != nil {
+		t.Fatalf("Baseline thumbnail is not a valid JPEG: %v", err)
+	}
+	got, err := jpeg.Decode(bytes.NewReader(progressive[models.ThumbnailLarge]))
+	if err != nil {
+		t.Fatalf("Progressive thumbnail is not a valid JPEG: %v", err)
+	}
+	if got.Bounds() != want.Bounds() {
+		t.Fatalf("Progressive bounds = %v; want %v", got.Bounds(), want.Bounds())
+	}
+	if diff := meanAbsDiff(got, want); diff > 2 {
+		t.Errorf("Progressive thumbnail differs from baseline by %.2f per channel on average", diff)
+	}
+}
+
+func TestEncodeProgressiveJPEGSmallImage(t *testing.T) {
+	// Smaller than one MCU in both directions
+	img := createColourGradientImage(37, 19)
+
+	var buf bytes.Buffer
+	if err := quality.EncodeProgressiveJPEG(&buf, img, 90); err != nil {
+		t.Fatalf("EncodeProgressiveJPEG failed: %v", err)
+	}
+	decoded, err := jpeg.Decode(&buf)
+	if err != nil {
+		t.Fatalf("Progressive JPEG does not decode: %v", err)
+	}
+	if decoded.Bounds().Dx() != 37 || decoded.Bounds().Dy() != 19 {
+		t.Errorf("Decoded size = %v; want 37x19", decoded.Bounds())
+	}
+	if diff := meanAbsDiff(decoded, img); diff > 4 {
+		t.Errorf("Decoded image differs from source by %.2f per channel on average", diff)
+	}
+}
+
+// createColourGradientImage creates an image with smooth colour gradients, which
+// gives the encoder non-trivial AC coefficients
+func createColourGradientImage(width, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{
+				R: uint8(255 * x / width),
+				G: uint8(255 * y / height),
+				B: uint8(128 + 127*(x-y)/(width+height)),
+				A: 255,
+			})
+		}
+	}
+	return img
+}
+
+// meanAbsDiff returns the mean absolute difference per RGB channel
+func meanAbsDiff(a, b image.Image) float64 {
+	var sum, n float64
+	bounds := a.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			ar, ag, ab, _ := a.At(x, y).RGBA()
+			br, bg, bb, _ := b.At(x, y).RGBA()
+			for _, d := range []int{int(ar>>8) - int(br>>8), int(ag>>8) - int(bg>>8), int(ab>>8) - int(bb>>8)} {
+				if d < 0 {
+					d = -d
+				}
+				sum += float64(d)
+			}
+			n += 3
+		}
+	}
+	return sum / n
+}
+
 func createSolidColorImage(width, height int, c color.RGBA) image.Image {
 	img := image.NewRGBA(image.Rect(0, 0, width, height))
 	for y := 0; y < height; y++ {
@@ -0,0 +1,163 @@
+package indexer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/adewale/olsen/internal/database"
+	"github.com/adewale/olsen/internal/quality"
+	"github.com/adewale/olsen/pkg/models"
+)
+
+// orientationTolerance is how far, relative to the expected value, a
+// thumbnail's aspect ratio may stray before it counts as a mismatch. It
+// absorbs the rounding of thumbnail dimensions to whole pixels; a missed or
+// doubled rotation inverts the ratio and is far outside it.
+const orientationTolerance = 0.05
+
+// OrientationMismatch is a photo whose thumbnail has a different shape from
+// its stored dimensions after EXIF orientation, the sign of an orientation bug
+type OrientationMismatch struct {
+	PhotoID     int
+	FilePath    string
+	Orientation int
+	Expected    float64 // width / height of the oriented photo
+	Actual      float64 // width / height of the thumbnail
+}
+
+// ErrNothingSampled is returned by VerifyOrientation when there are
+// thumbnails but none of them could be checked, so no check was made
+var ErrNothingSampled = errors.New("no thumbnail could be checked: photos have no stored width and height, or thumbnails don't decode")
+
+// VerifyOrientation compares the aspect ratio of up to sample randomly chosen
+// thumbnails with the oriented ratio of their photos' stored width, height
+// and orientation. Thumbnails that cannot be decoded are not counted as
+// checked; if that leaves none while thumbnails exist, it returns
+// ErrNothingSampled.
+func VerifyOrientation(db *database.DB, sample int) (checked int, mismatches []OrientationMismatch, err error) {
+	shapes, err := db.SampleThumbnailShapes(sample)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	for _, s := range shapes {
+		cfg, _, err := image.DecodeConfig(bytes.NewReader(s.Thumbnail))
+		if err != nil || cfg.Width == 0 || cfg.Height == 0 {
+			continue
+		}
+		checked++
+
+		w, h := quality.OrientedSize(s.Width, s.Height, s.Orientation)
+		expected := float64(w) / float64(h)
+		actual := float64(cfg.Width) / float64(cfg.Height)
+		if math.Abs(actual-expected) > expected*orientationTolerance {
+			mismatches = append(mismatches, OrientationMismatch{
+				PhotoID:     s.PhotoID,
+				FilePath:    s.FilePath,
+				Orientation: s.Orientation,
+				Expected:    expected,
+				Actual:      actual,
+			})
+		}
+	}
+
+	if checked == 0 {
+		var thumbnailed bool
+		if err := db.QueryRow("SELECT EXISTS (SELECT 1 FROM thumbnails)").Scan(&thumbnailed); err != nil {
+			return 0, nil, fmt.Errorf("failed to check for thumbnails: %w", err)
+		}
+		if thumbnailed {
+			return 0, nil, ErrNothingSampled
+		}
+	}
+	return checked, mismatches, nil
+}
+
+// DecodeProblem is a photo whose original or stored thumbnails no longer
+// decode the way they did when it was indexed
+type DecodeProblem struct {
+	PhotoID  int
+	FilePath string
+	Problems []string
+}
+
+// VerifyDecode re-reads every photo's original and stored thumbnails and
+// reports those that look corrupt: an original that is missing, has changed
+// size, fails to decode or decodes to other dimensions than were stored, and
+// thumbnails that fail to decode. A truncated copy typically shows up as a
+// smaller file that stops decoding part way through. progress, when not nil,
+// is called after each photo with the number checked so far.
+//
+// RAW files are only decoded when RAW support is built in. Their decoded size
+// is not compared, since decoders crop differently from the EXIF dimensions,
+// and a RAW that no longer decodes only counts if it had thumbnails, i.e. it
+// decoded when it was indexed.
+func VerifyDecode(db *database.DB, progress func(checked int)) (checked int, problems []DecodeProblem, err error) {
+	err = db.DecodeTargets(func(t database.DecodeTarget) error {
+		if found := checkDecode(t); len(found) > 0 {
+			problems = append(problems, DecodeProblem{PhotoID: t.PhotoID, FilePath: t.FilePath, Problems: found})
+		}
+		checked++
+		if progress != nil {
+			progress(checked)
+		}
+		return nil
+	})
+	return checked, problems, err
+}
+
+// checkDecode returns what is wrong with one photo's files
+func checkDecode(t database.DecodeTarget) []string {
+	var found []string
+	for _, size := range []models.ThumbnailSize{models.ThumbnailTiny, models.ThumbnailSmall, models.ThumbnailMedium, models.ThumbnailLarge} {
+		if data, ok := t.Thumbnails[string(size)]; ok {
+			if _, _, err := image.Decode(bytes.NewReader(data)); err != nil {
+				found = append(found, fmt.Sprintf("%spx thumbnail does not decode: %v", size, err))
+			}
+		}
+	}
+
+	info, err := os.Stat(t.FilePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return append(found, "original is missing")
+		}
+		return append(found, fmt.Sprintf("original cannot be read: %v", err))
+	}
+	if info.Size() != t.FileSize {
+		found = append(found, fmt.Sprintf("original is %d bytes, was %d when indexed", info.Size(), t.FileSize))
+	}
+
+	if isRawExtension(filepath.Ext(t.FilePath)) {
+		if !IsRawSupported() {
+			return found
+		}
+		if _, err := DecodeRaw(t.FilePath); err != nil {
+			if _, embeddedErr := ExtractEmbeddedJPEG(t.FilePath); embeddedErr != nil && len(t.Thumbnails) > 0 {
+				found = append(found, fmt.Sprintf("original no longer decodes: %v", err))
+			}
+		}
+		return found
+	}
+
+	f, err := os.Open(t.FilePath)
+	if err != nil {
+		return append(found, fmt.Sprintf("original cannot be read: %v", err))
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return append(found, fmt.Sprintf("original does not decode: %v", err))
+	}
+	// Some software records the dimensions after applying the orientation
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	if t.Width > 0 && t.Height > 0 && !(w == t.Width && h == t.Height) && !(w == t.Height && h == t.Width) {
+		found = append(found, fmt.Sprintf("original decodes to %dx%d, was %dx%d when indexed", w, h, t.Width, t.Height))
+	}
+	return found
+}
@@ -0,0 +1,163 @@
+package indexer
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/adewale/olsen/internal/database"
+	"github.com/adewale/olsen/pkg/models"
+)
+
+func TestVerifyOrientation(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "verify.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	thumbnail := func(w, h int) map[models.ThumbnailSize][]byte {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, w, h)), nil); err != nil {
+			t.Fatalf("Failed to encode thumbnail: %v", err)
+		}
+		return map[models.ThumbnailSize][]byte{models.ThumbnailSmall: buf.Bytes()}
+	}
+	photos := []*models.PhotoMetadata{
+		// Landscape, no rotation
+		{FilePath: "/landscape.jpg", FileHash: "a", Width: 6000, Height: 4000, Orientation: 1, Thumbnails: thumbnail(256, 171)},
+		// Portrait shot stored sideways, correctly rotated
+		{FilePath: "/portrait.jpg", FileHash: "b", Width: 6000, Height: 4000, Orientation: 6, Thumbnails: thumbnail(171, 256)},
+		// Portrait shot whose rotation was never applied
+		{FilePath: "/sideways.jpg", FileHash: "c", Width: 6000, Height: 4000, Orientation: 8, Thumbnails: thumbnail(256, 171)},
+		// Unknown dimensions cannot be checked
+		{FilePath: "/unknown.jpg", FileHash: "d", Orientation: 6, Thumbnails: thumbnail(256, 171)},
+	}
+	for _, p := range photos {
+		if err := db.InsertPhoto(p); err != nil {
+			t.Fatalf("InsertPhoto failed: %v", err)
+		}
+	}
+
+	checked, mismatches, err := VerifyOrientation(db, 10)
+	if err != nil {
+		t.Fatalf("VerifyOrientation failed: %v", err)
+	}
+	if checked != 3 {
+		t.Errorf("checked = %d; want 3", checked)
+	}
+	if len(mismatches) != 1 || mismatches[0].FilePath != "/sideways.jpg" {
+		t.Fatalf("mismatches = %+v; want only /sideways.jpg", mismatches)
+	}
+	if m := mismatches[0]; m.Expected >= 1 || m.Actual <= 1 {
+		t.Errorf("mismatch ratios = %.2f expected, %.2f actual; want portrait expected, landscape actual", m.Expected, m.Actual)
+	}
+
+	if checked, _, _ := VerifyOrientation(db, 2); checked != 2 {
+		t.Errorf("checked with sample 2 = %d; want 2", checked)
+	}
+}
+
+func TestVerifyOrientationNothingSampled(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "verify.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// An empty library has nothing to check and nothing wrong
+	if checked, _, err := VerifyOrientation(db, 10); checked != 0 || err != nil {
+		t.Errorf("empty library = %d checked, %v; want 0, nil", checked, err)
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 256, 171)), nil); err != nil {
+		t.Fatalf("Failed to encode thumbnail: %v", err)
+	}
+	photo := &models.PhotoMetadata{
+		FilePath: "/unknown.png", FileHash: "a",
+		Thumbnails: map[models.ThumbnailSize][]byte{models.ThumbnailSmall: buf.Bytes()},
+	}
+	if err := db.InsertPhoto(photo); err != nil {
+		t.Fatalf("InsertPhoto failed: %v", err)
+	}
+	if _, _, err := VerifyOrientation(db, 10); !errors.Is(err, ErrNothingSampled) {
+		t.Errorf("thumbnails without dimensions = %v; want ErrNothingSampled", err)
+	}
+}
+
+func TestVerifyDecode(t *testing.T) {
+	dir := t.TempDir()
+	db, err := database.Open(filepath.Join(dir, "verify.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 120, 80)), nil); err != nil {
+		t.Fatalf("Failed to encode image: %v", err)
+	}
+	good := buf.Bytes()
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+		return path
+	}
+	thumbs := map[models.ThumbnailSize][]byte{models.ThumbnailTiny: good}
+
+	photos := []*models.PhotoMetadata{
+		{FilePath: write("good.jpg", good), FileSize: int64(len(good)), Width: 120, Height: 80, Thumbnails: thumbs},
+		// Stored after rotation by the camera; still fine
+		{FilePath: write("rotated.jpg", good), FileSize: int64(len(good)), Width: 80, Height: 120, Thumbnails: thumbs},
+		{FilePath: write("resized.jpg", good), FileSize: int64(len(good)), Width: 6000, Height: 4000, Thumbnails: thumbs},
+		// Truncated after indexing
+		{FilePath: write("truncated.jpg", good[:len(good)/2]), FileSize: int64(len(good)), Width: 120, Height: 80, Thumbnails: thumbs},
+		{FilePath: filepath.Join(dir, "missing.jpg"), FileSize: 1},
+		{FilePath: write("bad-thumb.jpg", good), FileSize: int64(len(good)), Width: 120, Height: 80,
+			Thumbnails: map[models.ThumbnailSize][]byte{models.ThumbnailTiny: good[:20]}},
+	}
+	for i, p := range photos {
+		p.FileHash = string(rune('a' + i))
+		if err := db.InsertPhoto(p); err != nil {
+			t.Fatalf("InsertPhoto failed: %v", err)
+		}
+	}
+
+	calls := 0
+	checked, problems, err := VerifyDecode(db, func(int) { calls++ })
+	if err != nil {
+		t.Fatalf("VerifyDecode failed: %v", err)
+	}
+	if checked != len(photos) || calls != len(photos) {
+		t.Errorf("checked = %d, progress calls = %d; want %d", checked, calls, len(photos))
+	}
+
+	want := map[string]string{
+		"resized.jpg":   "decodes to 120x80, was 6000x4000",
+		"truncated.jpg": "does not decode",
+		"missing.jpg":   "missing",
+		"bad-thumb.jpg": "64px thumbnail does not decode",
+	}
+	if len(problems) != len(want) {
+		t.Errorf("problems = %+v; want %d", problems, len(want))
+	}
+	for _, p := range problems {
+		name := filepath.Base(p.FilePath)
+		if w, ok := want[name]; !ok || !strings.Contains(strings.Join(p.Problems, "; "), w) {
+			t.Errorf("%s: problems %q; want one containing %q", name, p.Problems, w)
+		}
+	}
+	for _, p := range problems {
+		if filepath.Base(p.FilePath) == "truncated.jpg" && !strings.Contains(strings.Join(p.Problems, "; "), "bytes, was") {
+			t.Errorf("truncated.jpg: problems %q; want the size change reported", p.Problems)
+		}
+	}
+}
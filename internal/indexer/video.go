@@ -0,0 +1,305 @@
+package indexer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adewale/olsen/pkg/models"
+)
+
+// videoExts lists the video extensions picked up with SetIncludeVideo. All
+// are ISO base media files (MP4 and its QuickTime ancestor).
+var videoExts = map[string]bool{
+	".mp4": true,
+	".m4v": true,
+	".mov": true,
+}
+
+// isVideoExtension reports whether ext names a video format the indexer reads
+func isVideoExtension(ext string) bool {
+	return videoExts[strings.ToLower(ext)]
+}
+
+// quickTimeEpoch is the zero of the creation times in MP4 and MOV headers
+var quickTimeEpoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// VideoInfo is the capture metadata read from a video's headers
+type VideoInfo struct {
+	Created  time.Time // Zero when the file doesn't record it
+	Duration float64   // Seconds
+	Width    int       // As displayed, after any rotation in the track matrix
+	Height   int
+}
+
+// ReadVideoInfo reads the creation time and duration from the movie header
+// (mvhd) and the size from the first video track header (tkhd) of an MP4 or
+// MOV file. Only the headers are read, so it is cheap even for long videos.
+func ReadVideoInfo(path string) (*VideoInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	moov, err := findBox(f, 0, info.Size(), "moov")
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, moov.size)
+	if _, err := f.ReadAt(data, moov.offset); err != nil {
+		return nil, fmt.Errorf("failed to read moov box: %w", err)
+	}
+	return parseMoov(data)
+}
+
+// box is an ISO BMFF box's payload, located in the file
+type box struct {
+	kind   string
+	offset int64 // Start of the payload, after the header
+	size   int64 // Payload length
+}
+
+// maxMoovSize bounds the movie box read into memory. It holds only headers
+// and sample tables, a few MB even for hours of footage.
+const maxMoovSize = 64 << 20
+
+// findBox scans the boxes between start and end for the first of kind
+func findBox(r io.ReaderAt, start, end int64, kind string) (box, error) {
+	for pos := start; pos+8 <= end; {
+		var header [16]byte
+		if _, err := r.ReadAt(header[:8], pos); err != nil {
+			return box{}, fmt.Errorf("failed to read box header: %w", err)
+		}
+		size := int64(binary.BigEndian.Uint32(header[:4]))
+		name := string(header[4:8])
+		headerLen := int64(8)
+		switch size {
+		case 0: // Runs to the end of the file
+			size = end - pos
+		case 1: // 64-bit size follows the type
+			if _, err := r.ReadAt(header[8:16], pos+8); err != nil {
+				return box{}, fmt.Errorf("failed to read box header: %w", err)
+			}
+			size = int64(binary.BigEndian.Uint64(header[8:16]))
+			headerLen = 16
+		}
+		if size < headerLen || pos+size > end {
+			return box{}, fmt.Errorf("malformed %q box at offset %d", name, pos)
+		}
+		if name == kind {
+			if kind == "moov" && size-headerLen > maxMoovSize {
+				return box{}, fmt.Errorf("moov box is too large (%d bytes)", size-headerLen)
+			}
+			return box{kind: name, offset: pos + headerLen, size: size - headerLen}, nil
+		}
+		pos += size
+	}
+	return box{}, fmt.Errorf("no %q box found; not an MP4 or MOV file", kind)
+}
+
+// rawBox is one child box: its type and payload
+type rawBox struct {
+	kind string
+	data []byte
+}
+
+// boxList splits a container box's payload into its children, in order
+func boxList(data []byte) []rawBox {
+	var boxes []rawBox
+	for len(data) >= 8 {
+		size := uint64(binary.BigEndian.Uint32(data[:4]))
+		name := string(data[4:8])
+		headerLen := uint64(8)
+		switch size {
+		case 0:
+			size = uint64(len(data))
+		case 1:
+			if len(data) < 16 {
+				return boxes
+			}
+			size = binary.BigEndian.Uint64(data[8:16])
+			headerLen = 16
+		}
+		if size < headerLen || size > uint64(len(data)) {
+			return boxes
+		}
+		boxes = append(boxes, rawBox{kind: name, data: data[headerLen:size]})
+		data = data[size:]
+	}
+	return boxes
+}
+
+// childBoxes splits a container box's payload into its children by type
+func childBoxes(data []byte) map[string][][]byte {
+	children := map[string][][]byte{}
+	for _, b := range boxList(data) {
+		children[b.kind] = append(children[b.kind], b.data)
+	}
+	return children
+}
+
+// parseMoov reads a moov box payload
+func parseMoov(moov []byte) (*VideoInfo, error) {
+	children := childBoxes(moov)
+	if len(children["mvhd"]) == 0 {
+		return nil, errors.New("moov box has no movie header")
+	}
+	info := &VideoInfo{}
+	if err := parseMvhd(children["mvhd"][0], info); err != nil {
+		return nil, err
+	}
+
+	for _, trak := range children["trak"] {
+		tracks := childBoxes(trak)
+		if len(tracks["tkhd"]) == 0 || len(tracks["mdia"]) == 0 || !isVideoTrack(tracks["mdia"][0]) {
+			continue
+		}
+		if err := parseTkhd(tracks["tkhd"][0], info); err != nil {
+			return nil, err
+		}
+		break
+	}
+	return info, nil
+}
+
+// parseMvhd reads the creation time and duration from a movie header
+func parseMvhd(data []byte, info *VideoInfo) error {
+	if len(data) < 4 {
+		return errors.New("movie header is truncated")
+	}
+	var created, timescale, duration uint64
+	switch data[0] {
+	case 0:
+		if len(data) < 20 {
+			return errors.New("movie header is truncated")
+		}
+		created = uint64(binary.BigEndian.Uint32(data[4:8]))
+		timescale = uint64(binary.BigEndian.Uint32(data[12:16]))
+		duration = uint64(binary.BigEndian.Uint32(data[16:20]))
+	case 1:
+		if len(data) < 32 {
+			return errors.New("movie header is truncated")
+		}
+		created = binary.BigEndian.Uint64(data[4:12])
+		timescale = uint64(binary.BigEndian.Uint32(data[20:24]))
+		duration = binary.BigEndian.Uint64(data[24:32])
+	default:
+		return fmt.Errorf("unsupported movie header version %d", data[0])
+	}
+
+	// Many cameras leave the creation time at zero rather than the 1904 epoch
+	if created > 0 {
+		info.Created = quickTimeEpoch.Add(time.Duration(created) * time.Second)
+	}
+	if timescale > 0 {
+		info.Duration = float64(duration) / float64(timescale)
+	}
+	return nil
+}
+
+// isVideoTrack reports whether a track's media box declares a video handler
+func isVideoTrack(mdia []byte) bool {
+	hdlr := childBoxes(mdia)["hdlr"]
+	// version/flags, pre_defined, then the handler type
+	return len(hdlr) > 0 && len(hdlr[0]) >= 12 && string(hdlr[0][8:12]) == "vide"
+}
+
+// parseTkhd reads the displayed size from a track header. Phones record
+// portrait video as landscape frames with a 90-degree rotation matrix, so
+// the size is swapped when the matrix turns the frame on its side.
+func parseTkhd(data []byte, info *VideoInfo) error {
+	if len(data) < 4 {
+		return errors.New("track header is truncated")
+	}
+	var matrix int
+	switch data[0] {
+	case 0:
+		matrix = 40
+	case 1:
+		matrix = 52
+	default:
+		return fmt.Errorf("unsupported track header version %d", data[0])
+	}
+	if len(data) < matrix+44 {
+		return errors.New("track header is truncated")
+	}
+
+	// Width and height are 16.16 fixed point, after the 3x3 matrix
+	info.Width = int(binary.BigEndian.Uint32(data[matrix+36:matrix+40]) >> 16)
+	info.Height = int(binary.BigEndian.Uint32(data[matrix+40:matrix+44]) >> 16)
+	if a := binary.BigEndian.Uint32(data[matrix : matrix+4]); a == 0 {
+		info.Width, info.Height = info.Height, info.Width
+	}
+	return nil
+}
+
+// ExtractVideoMetadata builds the metadata of a video from its headers.
+// Videos carry no EXIF, so camera and exposure fields are left empty.
+func ExtractVideoMetadata(path string) (*models.PhotoMetadata, error) {
+	fileInfo, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+	info, err := ReadVideoInfo(path)
+	if err != nil {
+		return nil, err
+	}
+	return &models.PhotoMetadata{
+		FilePath:     path,
+		FileSize:     fileInfo.Size(),
+		LastModified: fileInfo.ModTime(),
+		IndexedAt:    time.Now(),
+		MediaType:    models.MediaTypeVideo,
+		DateTaken:    info.Created,
+		Duration:     info.Duration,
+		Width:        info.Width,
+		Height:       info.Height,
+	}, nil
+}
+
+// ErrNoFFmpeg is returned by PosterFrame when ffmpeg is not on the PATH
+var ErrNoFFmpeg = errors.New("ffmpeg not found on PATH")
+
+// PosterFrame decodes the frame one second into a video (or half way through
+// a shorter one) with ffmpeg, which applies any rotation. Without ffmpeg it
+// returns ErrNoFFmpeg and the video is indexed without thumbnails.
+func PosterFrame(path string, duration float64) (image.Image, error) {
+	ffmpeg, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		return nil, ErrNoFFmpeg
+	}
+	seek := min(1, duration/2)
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(ffmpeg,
+		"-v", "error",
+		"-ss", strconv.FormatFloat(seek, 'f', 3, 64),
+		"-i", path,
+		"-frames:v", "1",
+		"-f", "image2pipe", "-c:v", "png", "-",
+	)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("ffmpeg failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	img, _, err := image.Decode(&stdout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode poster frame: %w", err)
+	}
+	return img, nil
+}
@@ -0,0 +1,144 @@
+package indexer
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/adewale/olsen/internal/database"
+)
+
+// mp4Box encodes an ISO BMFF box around payload
+func mp4Box(kind string, payload ...[]byte) []byte {
+	size := 8
+	for _, p := range payload {
+		size += len(p)
+	}
+	b := binary.BigEndian.AppendUint32(nil, uint32(size))
+	b = append(b, kind...)
+	for _, p := range payload {
+		b = append(b, p...)
+	}
+	return b
+}
+
+// writeTestMP4 writes a minimal MP4: a version 0 movie header and one video
+// track, with no media data. rotated sets a 90-degree track matrix.
+func writeTestMP4(t *testing.T, path string, created time.Time, seconds, width, height int, rotated bool) {
+	t.Helper()
+
+	mvhd := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd[4:], uint32(created.Sub(quickTimeEpoch)/time.Second))
+	binary.BigEndian.PutUint32(mvhd[12:], 600) // timescale
+	binary.BigEndian.PutUint32(mvhd[16:], uint32(seconds*600))
+
+	tkhd := make([]byte, 84)
+	if rotated {
+		binary.BigEndian.PutUint32(tkhd[44:], 0x00010000) // b
+		binary.BigEndian.PutUint32(tkhd[52:], 0xFFFF0000) // c
+		binary.BigEndian.PutUint32(tkhd[72:], 0x40000000) // w
+	} else {
+		binary.BigEndian.PutUint32(tkhd[40:], 0x00010000) // a
+		binary.BigEndian.PutUint32(tkhd[56:], 0x00010000) // d
+		binary.BigEndian.PutUint32(tkhd[72:], 0x40000000) // w
+	}
+	binary.BigEndian.PutUint32(tkhd[76:], uint32(width)<<16)
+	binary.BigEndian.PutUint32(tkhd[80:], uint32(height)<<16)
+
+	hdlr := make([]byte, 25)
+	copy(hdlr[8:], "vide")
+
+	data := append(mp4Box("ftyp", []byte("isom\x00\x00\x02\x00isommp41")),
+		mp4Box("moov",
+			mp4Box("mvhd", mvhd),
+			mp4Box("trak", mp4Box("tkhd", tkhd), mp4Box("mdia", mp4Box("hdlr", hdlr))),
+		)...)
+	data = append(data, mp4Box("mdat")...)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("Failed to write test MP4: %v", err)
+	}
+}
+
+func TestReadVideoInfo(t *testing.T) {
+	dir := t.TempDir()
+	created := time.Date(2024, 6, 1, 9, 30, 0, 0, time.UTC)
+
+	landscape := filepath.Join(dir, "landscape.mp4")
+	writeTestMP4(t, landscape, created, 12, 1920, 1080, false)
+	info, err := ReadVideoInfo(landscape)
+	if err != nil {
+		t.Fatalf("ReadVideoInfo failed: %v", err)
+	}
+	if !info.Created.Equal(created) || info.Duration != 12 || info.Width != 1920 || info.Height != 1080 {
+		t.Errorf("info = %+v; want created %v, 12s, 1920x1080", info, created)
+	}
+
+	// Phones store portrait video as rotated landscape frames
+	portrait := filepath.Join(dir, "portrait.mov")
+	writeTestMP4(t, portrait, created, 3, 1920, 1080, true)
+	if info, err = ReadVideoInfo(portrait); err != nil {
+		t.Fatalf("ReadVideoInfo(portrait) failed: %v", err)
+	}
+	if info.Width != 1080 || info.Height != 1920 {
+		t.Errorf("portrait size = %dx%d; want 1080x1920", info.Width, info.Height)
+	}
+
+	notVideo := filepath.Join(dir, "photo.mp4")
+	if err := os.WriteFile(notVideo, []byte("\xff\xd8\xff\xe0 not a movie"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadVideoInfo(notVideo); err == nil {
+		t.Error("ReadVideoInfo accepted a file with no movie box")
+	}
+}
+
+func TestIncludeVideo(t *testing.T) {
+	// Without ffmpeg, videos are indexed with metadata only
+	t.Setenv("PATH", "")
+
+	dir := t.TempDir()
+	createTestJPEGWithEXIF(t, filepath.Join(dir, "photo.jpg"))
+	writeTestMP4(t, filepath.Join(dir, "clip.mov"), time.Date(2024, 6, 1, 18, 0, 0, 0, time.UTC), 65, 1280, 720, false)
+
+	db, err := database.Open(filepath.Join(t.TempDir(), "video.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	engine := NewEngine(db, 1)
+	if err := engine.IndexDirectory(dir); err != nil {
+		t.Fatalf("IndexDirectory failed: %v", err)
+	}
+	var videos int
+	if err := db.QueryRow("SELECT COUNT(*) FROM photos WHERE media_type = 'video'").Scan(&videos); err != nil || videos != 0 {
+		t.Errorf("videos = %d, %v; want none indexed without SetIncludeVideo", videos, err)
+	}
+
+	engine = NewEngine(db, 1)
+	engine.SetIncludeVideo(true)
+	if err := engine.IndexDirectory(dir); err != nil {
+		t.Fatalf("IndexDirectory with video failed: %v", err)
+	}
+
+	var mediaType, format string
+	var duration float64
+	var width, height, thumbnails int
+	err = db.QueryRow(`
+		SELECT media_type, file_format, duration, width, height,
+		       (SELECT COUNT(*) FROM thumbnails t WHERE t.photo_id = p.id)
+		FROM photos p WHERE file_path LIKE '%clip.mov'
+	`).Scan(&mediaType, &format, &duration, &width, &height, &thumbnails)
+	if err != nil {
+		t.Fatalf("Video not indexed: %v", err)
+	}
+	if mediaType != "video" || format != "mov" || duration != 65 || width != 1280 || height != 720 || thumbnails != 0 {
+		t.Errorf("video = %s %s %gs %dx%d, %d thumbnails; want video mov 65s 1280x720, none",
+			mediaType, format, duration, width, height, thumbnails)
+	}
+	if err := db.QueryRow("SELECT media_type FROM photos WHERE file_path LIKE '%photo.jpg'").Scan(&mediaType); err != nil || mediaType != "photo" {
+		t.Errorf("photo media_type = %q, %v; want photo", mediaType, err)
+	}
+}
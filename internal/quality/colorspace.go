@@ -0,0 +1,119 @@
+package quality
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// Colour space names as stored in photos.color_space
+const (
+	ColourSpaceSRGB      = "sRGB"
+	ColourSpaceAdobeRGB  = "Adobe RGB"
+	ColourSpaceDisplayP3 = "Display P3"
+)
+
+// gamutConversion maps 8-bit values in a wide-gamut RGB space to sRGB:
+// decode to linear light, apply a 3×3 matrix, clip and re-encode
+type gamutConversion struct {
+	toLinear [256]float64
+	matrix   [3][3]float64
+}
+
+// srgbEncodeSteps is the resolution of the linear → sRGB lookup. 4096 steps
+// keeps the error in the darkest shadows below one 8-bit level.
+const srgbEncodeSteps = 4096
+
+var srgbEncode = func() (table [srgbEncodeSteps + 1]uint8) {
+	for i := range table {
+		v := float64(i) / srgbEncodeSteps
+		if v <= 0.0031308 {
+			v *= 12.92
+		} else {
+			v = 1.055*math.Pow(v, 1/2.4) - 0.055
+		}
+		table[i] = uint8(math.Round(v * 255))
+	}
+	return table
+}()
+
+func newGamutConversion(decode func(float64) float64, matrix [3][3]float64) *gamutConversion {
+	c := &gamutConversion{matrix: matrix}
+	for i := range c.toLinear {
+		c.toLinear[i] = decode(float64(i) / 255)
+	}
+	return c
+}
+
+func srgbDecode(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+// gamutConversions holds the spaces converted to sRGB. Both use the D65 white
+// point, so no chromatic adaptation is needed; matrices are linear RGB → linear
+// sRGB. Other spaces (ProPhoto RGB, camera profiles) are passed through.
+var gamutConversions = map[string]*gamutConversion{
+	ColourSpaceAdobeRGB: newGamutConversion(
+		func(v float64) float64 { return math.Pow(v, 563.0/256.0) },
+		[3][3]float64{
+			{1.3983557, -0.3983557, 0},
+			{0, 1, 0},
+			{0, -0.0429289, 1.0429289},
+		},
+	),
+	ColourSpaceDisplayP3: newGamutConversion(
+		srgbDecode,
+		[3][3]float64{
+			{1.2249401, -0.2249404, 0},
+			{-0.0420569, 1.0420571, 0},
+			{-0.0196376, -0.0786361, 1.0982735},
+		},
+	),
+}
+
+// CanConvertToSRGB reports whether ConvertToSRGB changes images in colourSpace
+func CanConvertToSRGB(colourSpace string) bool {
+	return gamutConversions[colourSpace] != nil
+}
+
+// ConvertToSRGB re-encodes img, whose pixels are in colourSpace, as sRGB so
+// browsers (which assume untagged JPEGs are sRGB) show the intended colours
+// instead of desaturated ones. Images in sRGB or an unsupported space are
+// returned unchanged, with false.
+func ConvertToSRGB(img image.Image, colourSpace string) (image.Image, bool) {
+	conv := gamutConversions[colourSpace]
+	if conv == nil {
+		return img, false
+	}
+
+	bounds := img.Bounds()
+	out := image.NewNRGBA(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			r, g, b := conv.toLinear[c.R], conv.toLinear[c.G], conv.toLinear[c.B]
+			m := &conv.matrix
+			out.SetNRGBA(x, y, color.NRGBA{
+				R: encodeLinear(m[0][0]*r + m[0][1]*g + m[0][2]*b),
+				G: encodeLinear(m[1][0]*r + m[1][1]*g + m[1][2]*b),
+				B: encodeLinear(m[2][0]*r + m[2][1]*g + m[2][2]*b),
+				A: c.A,
+			})
+		}
+	}
+	return out, true
+}
+
+// encodeLinear clips a linear-light value to [0, 1] and encodes it as sRGB
+func encodeLinear(v float64) uint8 {
+	if v <= 0 {
+		return 0
+	}
+	if v >= 1 {
+		return 255
+	}
+	return srgbEncode[int(v*srgbEncodeSteps+0.5)]
+}
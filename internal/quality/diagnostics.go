@@ -28,7 +28,8 @@ type ResizeDiag struct {
 	Filter         string      `json:"filter"`
 	PreSharpen     SharpenDiag `json:"pre_sharpen"`
 	PostSharpen    SharpenDiag `json:"post_sharpen"`
-	Upscale        bool        `json:"upscale"`
+	Upscale        bool        `json:"upscale"`                 // Any size was enlarged from a smaller source
+	SkippedSizes   []string    `json:"skipped_sizes,omitempty"` // Sizes not generated because upscaling is off
 }
 
 // EncodeDiag contains diagnostics about encoding
@@ -135,7 +136,7 @@ func (d *ImageDiag) HasWarnings() bool {
 	return len(d.Warnings) > 0
 }
 
-// IsUpscale returns true if the image was upscaled
+// IsUpscale returns true if any thumbnail size was upscaled
 func (d *ImageDiag) IsUpscale() bool {
 	return d.Pipeline.Resize.Upscale
 }
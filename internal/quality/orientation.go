@@ -90,6 +90,15 @@ func ApplyOrientation(img image.Image, orientation int) (image.Image, bool) {
 	return result, true
 }
 
+// OrientedSize returns the dimensions of a width × height image once its
+// EXIF orientation is applied. Orientations 5-8 turn the image on its side.
+func OrientedSize(width, height, orientation int) (int, int) {
+	if orientation >= 5 && orientation <= 8 {
+		return height, width
+	}
+	return width, height
+}
+
 // OrientationString returns a human-readable description of the orientation
 func OrientationString(orientation int) string {
 	switch orientation {
@@ -33,9 +33,12 @@ type ThumbnailConfig struct {
 	SharpenRadius float64
 
 	// Policies
-	AllowUpscale bool
+	AllowUpscale bool // Enlarge images smaller than a size; otherwise that size is skipped
 	LinearResize bool // Gamma-correct resizing
 
+	// Encoding
+	Progressive bool // Encode the largest size (ThumbnailLarge) as progressive JPEG
+
 	// QA/Sampling
 	QASample           float64 // 0.01 = 1%
 	QADir              string  // Where to store artifacts
@@ -63,7 +66,7 @@ func DefaultThumbnailConfig() ThumbnailConfig {
 type ImageMetadata struct {
 	FilePath       string
 	Orientation    int    // EXIF orientation (1-8)
-	ColorSpace     string // "sRGB", "AdobeRGB", etc.
+	ColorSpace     string // Colour space of the pixels: ColourSpaceSRGB, ColourSpaceAdobeRGB, ...
 	HasICCProfile  bool
 	ICCDescription string
 	Width          int
@@ -111,14 +114,19 @@ func GenerateThumbnailsWithDiag(ctx context.Context, img image.Image, meta Image
 	}
 	diag.TimingMS.Orient = msSince(orientStart)
 
-	// Stage 2: Color space (stub for now - ICC handling would go here)
-	colorStart := time.Now()
+	// Stage 2: Color space. Wide-gamut sources are converted to sRGB per
+	// thumbnail after resizing, which is far cheaper than converting the
+	// full-size image; the timing covers all sizes.
 	diag.Pipeline.ColorspaceIn = meta.ColorSpace
-	diag.Pipeline.ColorspaceOut = "sRGB" // Assume sRGB output for now
-	if !meta.HasICCProfile && meta.ColorSpace == "" {
+	diag.Pipeline.ColorspaceOut = ColourSpaceSRGB
+	convertColour := CanConvertToSRGB(meta.ColorSpace)
+	switch {
+	case !meta.HasICCProfile && meta.ColorSpace == "":
 		diag.AddWarning("icc_missing_assumed_srgb")
+	case !convertColour && meta.ColorSpace != ColourSpaceSRGB && meta.ColorSpace != "Uncalibrated":
+		diag.AddWarning(fmt.Sprintf("colorspace_unsupported_assumed_srgb: %s", meta.ColorSpace))
+		diag.Pipeline.ColorspaceOut = meta.ColorSpace
 	}
-	diag.TimingMS.Color = msSince(colorStart)
 
 	// Stage 3: Generate thumbnails for each size
 	thumbnails := make(map[models.ThumbnailSize][]byte)
@@ -147,13 +155,11 @@ func GenerateThumbnailsWithDiag(ctx context.Context, img image.Image, meta Image
 		if longEdge < size.maxDimension {
 			if !cfg.AllowUpscale {
 				diag.AddWarning(fmt.Sprintf("upscale_detected: %dx%d -> %d (skipped)", width, height, size.maxDimension))
-				// Skip this size or use original
+				diag.Pipeline.Resize.SkippedSizes = append(diag.Pipeline.Resize.SkippedSizes, string(size.name))
 				continue
 			}
 			diag.AddWarning(fmt.Sprintf("upscale_detected: %dx%d -> %d", width, height, size.maxDimension))
-			if size.name == models.ThumbnailMedium {
-				diag.Pipeline.Resize.Upscale = true
-			}
+			diag.Pipeline.Resize.Upscale = true
 		}
 
 		// Calculate dimensions preserving aspect ratio
@@ -169,6 +175,12 @@ func GenerateThumbnailsWithDiag(ctx context.Context, img image.Image, meta Image
 		// Resize
 		thumb := resize.Resize(newWidth, newHeight, img, cfg.Filter)
 
+		if convertColour {
+			colorStart := time.Now()
+			thumb, _ = ConvertToSRGB(thumb, meta.ColorSpace)
+			diag.TimingMS.Color += msSince(colorStart)
+		}
+
 		resizeTime := msSince(resizeStart)
 		if size.name == models.ThumbnailMedium {
 			diag.TimingMS.Resize = resizeTime
@@ -201,7 +213,13 @@ func GenerateThumbnailsWithDiag(ctx context.Context, img image.Image, meta Image
 		}
 
 		var buf bytes.Buffer
-		if err := jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: quality}); err != nil {
+		var err error
+		if cfg.Progressive && size.name == models.ThumbnailLarge {
+			err = EncodeProgressiveJPEG(&buf, thumb, quality)
+		} else {
+			err = jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: quality})
+		}
+		if err != nil {
 			return nil, diag, fmt.Errorf("failed to encode thumbnail %s: %w", size.name, err)
 		}
 
@@ -227,6 +245,9 @@ func GenerateThumbnailsWithDiag(ctx context.Context, img image.Image, meta Image
 			// Generate reference thumbnail
 			refStart := time.Now()
 			reference := generateReferenceThumbnail(img, 512)
+			if convertColour {
+				reference, _ = ConvertToSRGB(reference, meta.ColorSpace)
+			}
 
 			// Compute metrics
 			metrics, err := ComputeAllMetrics(reference, mediumThumb)
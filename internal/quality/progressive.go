@@ -0,0 +1,496 @@
+package quality
+
+import (
+	"bufio"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+	"math"
+)
+
+// EncodeProgressiveJPEG writes img as a progressive JPEG (SOF2) with 4:2:0
+// chroma subsampling and the standard Annex K Huffman tables. image/jpeg only
+// writes baseline files, so this is a small self-contained encoder.
+//
+// The scan script sends DC for all components first, then the low-frequency
+// luma AC coefficients, the chroma AC and finally the remaining luma AC, so a
+// browser can paint a coarse full-size preview after the first few percent of
+// the file. Spectral selection only: successive approximation is not used.
+// quality follows the image/jpeg scale (1-100).
+func EncodeProgressiveJPEG(w io.Writer, img image.Image, quality int) error {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= 0 || height <= 0 || width >= 1<<16 || height >= 1<<16 {
+		return errors.New("progressive jpeg: invalid image size")
+	}
+
+	if quality < 1 {
+		quality = 1
+	} else if quality > 100 {
+		quality = 100
+	}
+	quant := [2][64]int32{scaleQuant(&baseLuminanceQuant, quality), scaleQuant(&baseChrominanceQuant, quality)}
+
+	e := &progressiveEncoder{w: bufio.NewWriter(w), width: width, height: height}
+	e.transform(img, &quant)
+
+	e.writeMarkerHeaders(&quant)
+	e.writeDCScan()
+	e.writeACScan(0, 1, 5)
+	e.writeACScan(1, 1, 63)
+	e.writeACScan(2, 1, 63)
+	e.writeACScan(0, 6, 63)
+	e.writeBytes(0xff, 0xd9) // EOI
+
+	if e.err != nil {
+		return e.err
+	}
+	return e.w.Flush()
+}
+
+// Component layout: luma is sampled 2x2 per MCU, each chroma plane 1x1
+var (
+	componentSampling = [3]int{2, 1, 1}
+	componentTable    = [3]int{0, 1, 1} // quantisation and Huffman table per component
+)
+
+type progressiveEncoder struct {
+	w             *bufio.Writer
+	err           error
+	width, height int
+
+	// Quantised coefficients in zigzag order, per component, on a grid of
+	// blockCols[c] x blockRows[c] blocks covering whole MCUs
+	coeffs    [3][][64]int32
+	blockCols [3]int
+	blockRows [3]int
+
+	bits  uint32
+	nBits uint
+}
+
+// transform converts img to YCbCr, subsamples chroma and stores quantised
+// DCT coefficients for every block
+func (e *progressiveEncoder) transform(img image.Image, quant *[2][64]int32) {
+	mcuCols := (e.width + 15) / 16
+	mcuRows := (e.height + 15) / 16
+	padW, padH := mcuCols*16, mcuRows*16
+
+	// Full-resolution planes, edge pixels replicated into the padding
+	planes := [3][]float64{make([]float64, padW*padH), make([]float64, padW*padH), make([]float64, padW*padH)}
+	b := img.Bounds()
+	for y := 0; y < padH; y++ {
+		sy := b.Min.Y + min(y, e.height-1)
+		for x := 0; x < padW; x++ {
+			sx := b.Min.X + min(x, e.width-1)
+			c := color.RGBAModel.Convert(img.At(sx, sy)).(color.RGBA)
+			yy, cb, cr := color.RGBToYCbCr(c.R, c.G, c.B)
+			i := y*padW + x
+			planes[0][i] = float64(yy)
+			planes[1][i] = float64(cb)
+			planes[2][i] = float64(cr)
+		}
+	}
+
+	for c := 0; c < 3; c++ {
+		s := componentSampling[c]
+		e.blockCols[c] = mcuCols * s
+		e.blockRows[c] = mcuRows * s
+		e.coeffs[c] = make([][64]int32, e.blockCols[c]*e.blockRows[c])
+
+		// Pixels per block sample in each direction (2 for subsampled chroma)
+		step := 2 / s
+		var block [64]float64
+		for by := 0; by < e.blockRows[c]; by++ {
+			for bx := 0; bx < e.blockCols[c]; bx++ {
+				for y := 0; y < 8; y++ {
+					for x := 0; x < 8; x++ {
+						px := (bx*8 + x) * step
+						py := (by*8 + y) * step
+						var sum float64
+						for dy := 0; dy < step; dy++ {
+							for dx := 0; dx < step; dx++ {
+								sum += planes[c][(py+dy)*padW+px+dx]
+							}
+						}
+						block[y*8+x] = sum/float64(step*step) - 128
+					}
+				}
+				fdctQuantize(&block, &quant[componentTable[c]], &e.coeffs[c][by*e.blockCols[c]+bx])
+			}
+		}
+	}
+}
+
+// dctCos[u][x] = C(u)/2 * cos((2x+1)uπ/16)
+var dctCos = func() (t [8][8]float64) {
+	for u := 0; u < 8; u++ {
+		cu := 1.0
+		if u == 0 {
+			cu = 1 / math.Sqrt2
+		}
+		for x := 0; x < 8; x++ {
+			t[u][x] = cu / 2 * math.Cos(float64(2*x+1)*float64(u)*math.Pi/16)
+		}
+	}
+	return t
+}()
+
+// fdctQuantize applies a separable 8x8 forward DCT to block and writes the
+// quantised coefficients to out in zigzag order
+func fdctQuantize(block *[64]float64, quant *[64]int32, out *[64]int32) {
+	var rows [64]float64
+	for y := 0; y < 8; y++ {
+		for u := 0; u < 8; u++ {
+			var sum float64
+			for x := 0; x < 8; x++ {
+				sum += dctCos[u][x] * block[y*8+x]
+			}
+			rows[y*8+u] = sum
+		}
+	}
+	for v := 0; v < 8; v++ {
+		for u := 0; u < 8; u++ {
+			var sum float64
+			for y := 0; y < 8; y++ {
+				sum += dctCos[v][y] * rows[y*8+u]
+			}
+			zz := naturalToZigzag[v*8+u]
+			out[zz] = int32(math.Round(sum / float64(quant[zz])))
+		}
+	}
+}
+
+func (e *progressiveEncoder) writeBytes(b ...byte) {
+	if e.err == nil {
+		_, e.err = e.w.Write(b)
+	}
+}
+
+func (e *progressiveEncoder) writeMarkerHeaders(quant *[2][64]int32) {
+	e.writeBytes(0xff, 0xd8) // SOI
+
+	// DQT: both tables, 8-bit precision, zigzag order
+	e.writeBytes(0xff, 0xdb, 0, 2+2*65)
+	for t := 0; t < 2; t++ {
+		e.writeBytes(byte(t))
+		for _, q := range quant[t] {
+			e.writeBytes(byte(q))
+		}
+	}
+
+	// SOF2: progressive DCT, Huffman coded
+	e.writeBytes(0xff, 0xc2, 0, 8+3*3, 8,
+		byte(e.height>>8), byte(e.height), byte(e.width>>8), byte(e.width), 3)
+	for c := 0; c < 3; c++ {
+		s := byte(componentSampling[c])
+		e.writeBytes(byte(c+1), s<<4|s, byte(componentTable[c]))
+	}
+
+	// DHT: DC and AC tables for luma (0) and chroma (1)
+	for _, spec := range []struct {
+		class, id byte
+		table     *huffmanSpec
+	}{
+		{0, 0, &huffDCLuminance}, {1, 0, &huffACLuminance},
+		{0, 1, &huffDCChrominance}, {1, 1, &huffACChrominance},
+	} {
+		n := 2 + 1 + 16 + len(spec.table.values)
+		e.writeBytes(0xff, 0xc4, byte(n>>8), byte(n), spec.class<<4|spec.id)
+		e.writeBytes(spec.table.counts[:]...)
+		e.writeBytes(spec.table.values...)
+	}
+}
+
+// writeSOS starts a scan over the given components and spectral band
+func (e *progressiveEncoder) writeSOS(components []int, ss, se byte) {
+	n := 6 + 2*len(components)
+	e.writeBytes(0xff, 0xda, byte(n>>8), byte(n), byte(len(components)))
+	for _, c := range components {
+		t := byte(componentTable[c])
+		e.writeBytes(byte(c+1), t<<4|t)
+	}
+	e.writeBytes(ss, se, 0) // Ah = Al = 0: no successive approximation
+}
+
+// writeDCScan codes every component's DC coefficient, interleaved by MCU
+func (e *progressiveEncoder) writeDCScan() {
+	e.writeSOS([]int{0, 1, 2}, 0, 0)
+
+	var prev [3]int32
+	mcuCols := e.blockCols[1]
+	mcuRows := e.blockRows[1]
+	for my := 0; my < mcuRows; my++ {
+		for mx := 0; mx < mcuCols; mx++ {
+			for c := 0; c < 3; c++ {
+				s := componentSampling[c]
+				for v := 0; v < s; v++ {
+					for h := 0; h < s; h++ {
+						idx := (my*s+v)*e.blockCols[c] + mx*s + h
+						dc := e.coeffs[c][idx][0]
+						e.emitValue(dcCodes[componentTable[c]], dc-prev[c])
+						prev[c] = dc
+					}
+				}
+			}
+		}
+	}
+	e.flushBits()
+}
+
+// writeACScan codes coefficients ss..se of one component. Single-component
+// scans cover only the blocks inside the component's own dimensions, not
+// the MCU padding.
+func (e *progressiveEncoder) writeACScan(c int, ss, se int) {
+	e.writeSOS([]int{c}, byte(ss), byte(se))
+
+	s := componentSampling[c]
+	compW := (e.width*s + 1) / 2 // ceil(width * s / maxSampling)
+	compH := (e.height*s + 1) / 2
+	cols := (compW + 7) / 8
+	rows := (compH + 7) / 8
+
+	codes := acCodes[componentTable[c]]
+	for by := 0; by < rows; by++ {
+		for bx := 0; bx < cols; bx++ {
+			coeffs := &e.coeffs[c][by*e.blockCols[c]+bx]
+			run := 0
+			for k := ss; k <= se; k++ {
+				v := coeffs[k]
+				if v == 0 {
+					run++
+					continue
+				}
+				for run > 15 {
+					e.emitCode(codes[0xf0]) // ZRL: 16 zeros
+					run -= 16
+				}
+				size := bitLength(v)
+				e.emitCode(codes[run<<4|size])
+				e.emitBits(valueBits(v, size), uint(size))
+				run = 0
+			}
+			if run > 0 {
+				e.emitCode(codes[0x00]) // EOB (end-of-band run of 1)
+			}
+		}
+	}
+	e.flushBits()
+}
+
+// emitValue writes a DC difference as a size category followed by its bits
+func (e *progressiveEncoder) emitValue(codes []huffCode, v int32) {
+	size := bitLength(v)
+	e.emitCode(codes[size])
+	if size > 0 {
+		e.emitBits(valueBits(v, size), uint(size))
+	}
+}
+
+func (e *progressiveEncoder) emitCode(code huffCode) {
+	e.emitBits(uint32(code.code), uint(code.length))
+}
+
+// emitBits appends the low n bits of v to the entropy-coded segment,
+// stuffing a zero byte after every 0xFF
+func (e *progressiveEncoder) emitBits(v uint32, n uint) {
+	e.bits = e.bits<<n | v&(1<<n-1)
+	e.nBits += n
+	for e.nBits >= 8 {
+		b := byte(e.bits >> (e.nBits - 8))
+		e.writeBytes(b)
+		if b == 0xff {
+			e.writeBytes(0)
+		}
+		e.nBits -= 8
+	}
+}
+
+// flushBits pads the final byte of a scan with 1 bits
+func (e *progressiveEncoder) flushBits() {
+	if e.nBits > 0 {
+		e.emitBits(1<<(8-e.nBits)-1, 8-e.nBits)
+	}
+	e.bits = 0
+}
+
+// bitLength returns the JPEG size category of v: the bits needed for |v|
+func bitLength(v int32) int {
+	if v < 0 {
+		v = -v
+	}
+	n := 0
+	for v > 0 {
+		n++
+		v >>= 1
+	}
+	return n
+}
+
+// valueBits encodes v in size bits; negative values use one's complement
+func valueBits(v int32, size int) uint32 {
+	if v < 0 {
+		v += 1<<size - 1
+	}
+	return uint32(v)
+}
+
+// scaleQuant scales a base quantisation table (natural order) for quality
+// using the libjpeg formula, as image/jpeg does, returning it in zigzag order
+func scaleQuant(base *[64]int32, quality int) [64]int32 {
+	scale := 200 - 2*quality
+	if quality < 50 {
+		scale = 5000 / quality
+	}
+	var out [64]int32
+	for i, q := range base {
+		v := (q*int32(scale) + 50) / 100
+		if v < 1 {
+			v = 1
+		} else if v > 255 {
+			v = 255
+		}
+		out[naturalToZigzag[i]] = v
+	}
+	return out
+}
+
+// Annex K.1 quantisation tables, natural (row-major) order
+var (
+	baseLuminanceQuant = [64]int32{
+		16, 11, 10, 16, 24, 40, 51, 61,
+		12, 12, 14, 19, 26, 58, 60, 55,
+		14, 13, 16, 24, 40, 57, 69, 56,
+		14, 17, 22, 29, 51, 87, 80, 62,
+		18, 22, 37, 56, 68, 109, 103, 77,
+		24, 35, 55, 64, 81, 104, 113, 92,
+		49, 64, 78, 87, 103, 121, 120, 101,
+		72, 92, 95, 98, 112, 100, 103, 99,
+	}
+	baseChrominanceQuant = [64]int32{
+		17, 18, 24, 47, 99, 99, 99, 99,
+		18, 21, 26, 66, 99, 99, 99, 99,
+		24, 26, 56, 99, 99, 99, 99, 99,
+		47, 66, 99, 99, 99, 99, 99, 99,
+		99, 99, 99, 99, 99, 99, 99, 99,
+		99, 99, 99, 99, 99, 99, 99, 99,
+		99, 99, 99, 99, 99, 99, 99, 99,
+		99, 99, 99, 99, 99, 99, 99, 99,
+	}
+)
+
+// naturalToZigzag maps a row-major coefficient index to its zigzag position
+var naturalToZigzag = func() (t [64]int) {
+	zigzag := [64]int{
+		0, 1, 8, 16, 9, 2, 3, 10,
+		17, 24, 32, 25, 18, 11, 4, 5,
+		12, 19, 26, 33, 40, 48, 41, 34,
+		27, 20, 13, 6, 7, 14, 21, 28,
+		35, 42, 49, 56, 57, 50, 43, 36,
+		29, 22, 15, 23, 30, 37, 44, 51,
+		58, 59, 52, 45, 38, 31, 39, 46,
+		53, 60, 61, 54, 47, 55, 62, 63,
+	}
+	for zz, natural := range zigzag {
+		t[natural] = zz
+	}
+	return t
+}()
+
+// huffmanSpec is a DHT table: counts[i] codes of length i+1, then the symbols
+type huffmanSpec struct {
+	counts [16]byte
+	values []byte
+}
+
+type huffCode struct {
+	code   uint16
+	length uint8
+}
+
+// Annex K.3 Huffman tables
+var (
+	huffDCLuminance = huffmanSpec{
+		counts: [16]byte{0, 1, 5, 1, 1, 1, 1, 1, 1, 0, 0, 0, 0, 0, 0, 0},
+		values: []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11},
+	}
+	huffDCChrominance = huffmanSpec{
+		counts: [16]byte{0, 3, 1, 1, 1, 1, 1, 1, 1, 1, 1, 0, 0, 0, 0, 0},
+		values: []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11},
+	}
+	huffACLuminance = huffmanSpec{
+		counts: [16]byte{0, 2, 1, 3, 3, 2, 4, 3, 5, 5, 4, 4, 0, 0, 1, 0x7d},
+		values: []byte{
+			0x01, 0x02, 0x03, 0x00, 0x04, 0x11, 0x05, 0x12,
+			0x21, 0x31, 0x41, 0x06, 0x13, 0x51, 0x61, 0x07,
+			0x22, 0x71, 0x14, 0x32, 0x81, 0x91, 0xa1, 0x08,
+			0x23, 0x42, 0xb1, 0xc1, 0x15, 0x52, 0xd1, 0xf0,
+			0x24, 0x33, 0x62, 0x72, 0x82, 0x09, 0x0a, 0x16,
+			0x17, 0x18, 0x19, 0x1a, 0x25, 0x26, 0x27, 0x28,
+			0x29, 0x2a, 0x34, 0x35, 0x36, 0x37, 0x38, 0x39,
+			0x3a, 0x43, 0x44, 0x45, 0x46, 0x47, 0x48, 0x49,
+			0x4a, 0x53, 0x54, 0x55, 0x56, 0x57, 0x58, 0x59,
+			0x5a, 0x63, 0x64, 0x65, 0x66, 0x67, 0x68, 0x69,
+			0x6a, 0x73, 0x74, 0x75, 0x76, 0x77, 0x78, 0x79,
+			0x7a, 0x83, 0x84, 0x85, 0x86, 0x87, 0x88, 0x89,
+			0x8a, 0x92, 0x93, 0x94, 0x95, 0x96, 0x97, 0x98,
+			0x99, 0x9a, 0xa2, 0xa3, 0xa4, 0xa5, 0xa6, 0xa7,
+			0xa8, 0xa9, 0xaa, 0xb2, 0xb3, 0xb4, 0xb5, 0xb6,
+			0xb7, 0xb8, 0xb9, 0xba, 0xc2, 0xc3, 0xc4, 0xc5,
+			0xc6, 0xc7, 0xc8, 0xc9, 0xca, 0xd2, 0xd3, 0xd4,
+			0xd5, 0xd6, 0xd7, 0xd8, 0xd9, 0xda, 0xe1, 0xe2,
+			0xe3, 0xe4, 0xe5, 0xe6, 0xe7, 0xe8, 0xe9, 0xea,
+			0xf1, 0xf2, 0xf3, 0xf4, 0xf5, 0xf6, 0xf7, 0xf8,
+			0xf9, 0xfa,
+		},
+	}
+	huffACChrominance = huffmanSpec{
+		counts: [16]byte{0, 2, 1, 2, 4, 4, 3, 4, 7, 5, 4, 4, 0, 1, 2, 0x77},
+		values: []byte{
+			0x00, 0x01, 0x02, 0x03, 0x11, 0x04, 0x05, 0x21,
+			0x31, 0x06, 0x12, 0x41, 0x51, 0x07, 0x61, 0x71,
+			0x13, 0x22, 0x32, 0x81, 0x08, 0x14, 0x42, 0x91,
+			0xa1, 0xb1, 0xc1, 0x09, 0x23, 0x33, 0x52, 0xf0,
+			0x15, 0x62, 0x72, 0xd1, 0x0a, 0x16, 0x24, 0x34,
+			0xe1, 0x25, 0xf1, 0x17, 0x18, 0x19, 0x1a, 0x26,
+			0x27, 0x28, 0x29, 0x2a, 0x35, 0x36, 0x37, 0x38,
+			0x39, 0x3a, 0x43, 0x44, 0x45, 0x46, 0x47, 0x48,
+			0x49, 0x4a, 0x53, 0x54, 0x55, 0x56, 0x57, 0x58,
+			0x59, 0x5a, 0x63, 0x64, 0x65, 0x66, 0x67, 0x68,
+			0x69, 0x6a, 0x73, 0x74, 0x75, 0x76, 0x77, 0x78,
+			0x79, 0x7a, 0x82, 0x83, 0x84, 0x85, 0x86, 0x87,
+			0x88, 0x89, 0x8a, 0x92, 0x93, 0x94, 0x95, 0x96,
+			0x97, 0x98, 0x99, 0x9a, 0xa2, 0xa3, 0xa4, 0xa5,
+			0xa6, 0xa7, 0xa8, 0xa9, 0xaa, 0xb2, 0xb3, 0xb4,
+			0xb5, 0xb6, 0xb7, 0xb8, 0xb9, 0xba, 0xc2, 0xc3,
+			0xc4, 0xc5, 0xc6, 0xc7, 0xc8, 0xc9, 0xca, 0xd2,
+			0xd3, 0xd4, 0xd5, 0xd6, 0xd7, 0xd8, 0xd9, 0xda,
+			0xe2, 0xe3, 0xe4, 0xe5, 0xe6, 0xe7, 0xe8, 0xe9,
+			0xea, 0xf2, 0xf3, 0xf4, 0xf5, 0xf6, 0xf7, 0xf8,
+			0xf9, 0xfa,
+		},
+	}
+)
+
+// Code lookup tables indexed by symbol, built from the specs above
+var (
+	dcCodes = [2][]huffCode{buildHuffCodes(&huffDCLuminance), buildHuffCodes(&huffDCChrominance)}
+	acCodes = [2][]huffCode{buildHuffCodes(&huffACLuminance), buildHuffCodes(&huffACChrominance)}
+)
+
+// buildHuffCodes assigns canonical codes (Annex C) to a table's symbols
+func buildHuffCodes(spec *huffmanSpec) []huffCode {
+	codes := make([]huffCode, 256)
+	code := uint16(0)
+	k := 0
+	for length := 1; length <= 16; length++ {
+		for i := 0; i < int(spec.counts[length-1]); i++ {
+			codes[spec.values[k]] = huffCode{code: code, length: uint8(length)}
+			code++
+			k++
+		}
+		code <<= 1
+	}
+	return codes
+}
@@ -0,0 +1,57 @@
+package query
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/adewale/olsen/internal/database"
+	"github.com/adewale/olsen/pkg/models"
+)
+
+func TestAltitudeFilter(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "altitude.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	for _, p := range []*models.PhotoMetadata{
+		{FilePath: "/summit.jpg", FileHash: "a", Altitude: 3200},
+		{FilePath: "/valley.jpg", FileHash: "b", Altitude: 450},
+		{FilePath: "/dead-sea.jpg", FileHash: "c", Altitude: -420},
+		{FilePath: "/no-gps.jpg", FileHash: "d"},
+	} {
+		if err := db.InsertPhoto(p); err != nil {
+			t.Fatalf("InsertPhoto failed: %v", err)
+		}
+	}
+
+	engine := NewEngine(db.DB)
+	high, low, zero := 2000.0, 500.0, 0.0
+	tests := []struct {
+		name   string
+		params QueryParams
+		want   int
+	}{
+		{"high elevation", QueryParams{AltMin: &high}, 1},
+		{"up to 500 m", QueryParams{AltMax: &low}, 2},
+		{"below sea level", QueryParams{AltMax: &zero}, 1},
+		{"range", QueryParams{AltMin: &zero, AltMax: &high}, 1},
+	}
+	for _, tt := range tests {
+		tt.params.Limit = 50
+		if total, err := engine.Count(tt.params); err != nil || total != tt.want {
+			t.Errorf("%s: Count = %d, %v; want %d", tt.name, total, err, tt.want)
+		}
+	}
+
+	mapper := NewURLMapper()
+	query := mapper.BuildQueryString(QueryParams{AltMin: &high, Limit: 50})
+	if query != "?alt_min=2000" {
+		t.Errorf("BuildQueryString = %q; want ?alt_min=2000", query)
+	}
+	params, _ := mapper.ParsePath("/photos", "alt_min=2000&alt_max=-10.5")
+	if params.AltMin == nil || *params.AltMin != 2000 || params.AltMax == nil || *params.AltMax != -10.5 {
+		t.Errorf("parsed altitude range = %v, %v; want 2000, -10.5", params.AltMin, params.AltMax)
+	}
+}
@@ -0,0 +1,64 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WeekdayNames are the row labels of a WeekdayHourMatrix, matching
+// SQLite's strftime('%w') numbering (0 = Sunday)
+var WeekdayNames = [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"}
+
+// WeekdayHourMatrix counts photos by day of week (rows) and hour of day (columns)
+type WeekdayHourMatrix struct {
+	Counts   [7][24]int // Counts[weekday][hour]
+	Total    int        // Photos counted (dated photos only)
+	MaxCount int        // Largest single cell, for heatmap scaling
+}
+
+// ComputeWeekdayHour aggregates photos matching params by weekday and hour
+// of date_taken. Undated photos are excluded.
+//
+// Timezone: EXIF capture times carry no zone, so the indexer stores them as
+// the camera's wall-clock time, and hours reflect the camera clock at
+// capture. With SetLocation, photos whose EXIF also records the camera's UTC
+// offset are counted by the hour in that zone instead.
+func (e *Engine) ComputeWeekdayHour(params QueryParams) (*WeekdayHourMatrix, error) {
+	where, args := e.buildWhereClause(params)
+	// Also drops unparseable dates, which strftime maps to NULL
+	where = append(where, "strftime('%w', "+e.dateTaken()+") IS NOT NULL")
+
+	query := fmt.Sprintf(`
+		SELECT
+			CAST(strftime('%%w', %[1]s) AS INTEGER) as weekday,
+			CAST(strftime('%%H', %[1]s) AS INTEGER) as hour,
+			COUNT(*) as count
+		FROM photos p
+		WHERE %[2]s
+		GROUP BY weekday, hour
+	`, e.dateTaken(), strings.Join(where, " AND "))
+
+	rows, err := e.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute weekday/hour counts: %w", err)
+	}
+	defer rows.Close()
+
+	matrix := &WeekdayHourMatrix{}
+	for rows.Next() {
+		var weekday, hour, count int
+		if err := rows.Scan(&weekday, &hour, &count); err != nil {
+			return nil, err
+		}
+		if weekday < 0 || weekday > 6 || hour < 0 || hour > 23 {
+			continue
+		}
+		matrix.Counts[weekday][hour] = count
+		matrix.Total += count
+		if count > matrix.MaxCount {
+			matrix.MaxCount = count
+		}
+	}
+
+	return matrix, rows.Err()
+}
@@ -0,0 +1,50 @@
+package query
+
+import (
+	"testing"
+)
+
+func TestComputeWeekdayHour(t *testing.T) {
+	db := setupTestDBWithSchema(t)
+	defer db.Close()
+
+	insertTestPhotos(t, db, []TestPhoto{
+		{CameraMake: "Canon", DateTaken: "2024-06-01 09:15:00"}, // Saturday 09h
+		{CameraMake: "Canon", DateTaken: "2024-06-08 09:45:00"}, // Saturday 09h
+		{CameraMake: "Nikon", DateTaken: "2024-06-03 18:00:00"}, // Monday 18h
+		{CameraMake: "Nikon", DateTaken: "2024-06-02 23:59:00"}, // Sunday 23h
+		{CameraMake: "Canon"}, // Undated - excluded
+	})
+
+	engine := NewEngine(db)
+
+	matrix, err := engine.ComputeWeekdayHour(QueryParams{})
+	if err != nil {
+		t.Fatalf("ComputeWeekdayHour failed: %v", err)
+	}
+
+	if matrix.Total != 4 {
+		t.Errorf("Total = %d; want 4 (undated photos excluded)", matrix.Total)
+	}
+	if matrix.Counts[6][9] != 2 {
+		t.Errorf("Saturday 09h = %d; want 2", matrix.Counts[6][9])
+	}
+	if matrix.Counts[1][18] != 1 {
+		t.Errorf("Monday 18h = %d; want 1", matrix.Counts[1][18])
+	}
+	if matrix.Counts[0][23] != 1 {
+		t.Errorf("Sunday 23h = %d; want 1", matrix.Counts[0][23])
+	}
+	if matrix.MaxCount != 2 {
+		t.Errorf("MaxCount = %d; want 2", matrix.MaxCount)
+	}
+
+	// Active filters are honoured
+	matrix, err = engine.ComputeWeekdayHour(QueryParams{CameraMake: []string{"Nikon"}})
+	if err != nil {
+		t.Fatalf("ComputeWeekdayHour with filter failed: %v", err)
+	}
+	if matrix.Total != 2 || matrix.Counts[6][9] != 0 {
+		t.Errorf("Nikon matrix Total = %d, Saturday 09h = %d; want 2, 0", matrix.Total, matrix.Counts[6][9])
+	}
+}
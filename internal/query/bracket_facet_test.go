@@ -0,0 +1,60 @@
+package query
+
+import (
+	"testing"
+)
+
+func TestBracketFilterAndFacet(t *testing.T) {
+	db := setupTestDBWithSchema(t)
+	defer db.Close()
+
+	insertTestPhotos(t, db, []TestPhoto{
+		{FilePath: "/a.dng", CameraMake: "Canon", DateTaken: "2024-06-01 09:00:00"},
+		{FilePath: "/b.dng", CameraMake: "Canon", DateTaken: "2024-06-01 09:00:01"},
+		{FilePath: "/c.dng", CameraMake: "Canon", DateTaken: "2024-06-01 09:00:02"},
+		{FilePath: "/d.dng", CameraMake: "Canon", DateTaken: "2024-06-03 09:00:00"},
+	})
+	if _, err := db.Exec("UPDATE photos SET bracket_group_id = 'bracket_1' WHERE file_path IN ('/a.dng', '/b.dng', '/c.dng')"); err != nil {
+		t.Fatalf("Failed to set bracket_group_id: %v", err)
+	}
+
+	engine := NewEngine(db)
+	inBracket := false
+	params := QueryParams{InBracket: &inBracket, Limit: 50}
+
+	result, err := engine.Query(params)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if result.Total != 1 {
+		t.Errorf("Total = %d; want 1 photo outside brackets", result.Total)
+	}
+
+	facets, err := engine.ComputeFacets(params)
+	if err != nil {
+		t.Fatalf("ComputeFacets failed: %v", err)
+	}
+	if facets.InBracket == nil || len(facets.InBracket.Values) != 2 {
+		t.Fatalf("InBracket facet = %+v; want 2 values", facets.InBracket)
+	}
+	for _, v := range facets.InBracket.Values {
+		switch v.Value {
+		case "yes":
+			if v.Count != 3 || v.Selected || v.URL != "/photos?in_bracket=true" {
+				t.Errorf("yes = %+v; want 3 unselected, linking to in_bracket=true", v)
+			}
+		case "no":
+			if v.Count != 1 || !v.Selected || v.URL != "/photos" {
+				t.Errorf("no = %+v; want 1 selected, linking to /photos", v)
+			}
+		}
+	}
+
+	parsed, err := NewURLMapper().ParsePath("/photos", "in_bracket=1")
+	if err != nil {
+		t.Fatalf("ParsePath failed: %v", err)
+	}
+	if parsed.InBracket == nil || !*parsed.InBracket {
+		t.Errorf("parsed InBracket = %v; want true", parsed.InBracket)
+	}
+}
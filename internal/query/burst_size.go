@@ -0,0 +1,36 @@
+package query
+
+// burstSizeBuckets group bursts by their number of frames (photos.burst_count,
+// set by olsen analyze): short sequences, ordinary bursts, and long action
+// sequences
+var burstSizeBuckets = []rangeBucket{
+	{value: "2-3", label: "2–3 frames", min: rangeBound(2), max: rangeBound(4)},
+	{value: "4-9", label: "4–9 frames", min: rangeBound(4), max: rangeBound(10)},
+	{value: "10plus", label: "10+ frames", min: rangeBound(10)},
+}
+
+// BurstSizeBucketRange returns the BurstSizeMin/BurstSizeMax bounds of a
+// burst size facet value, both inclusive. Unknown values return nil bounds.
+func BurstSizeBucketRange(value string) (min, max *int) {
+	return intBucketRange(burstSizeBuckets, value)
+}
+
+// computeBurstSizeFacet computes the burst size facet. Photos outside a
+// burst are not counted.
+func (e *Engine) computeBurstSizeFacet(params QueryParams) (*Facet, error) {
+	paramsWithoutSize := params
+	paramsWithoutSize.BurstSizeMin = nil
+	paramsWithoutSize.BurstSizeMax = nil
+
+	selMin, selMax := intSelection(params.BurstSizeMin, params.BurstSizeMax)
+	values, err := e.computeRangeFacetValues(paramsWithoutSize, "burst_count", burstSizeBuckets, selMin, selMax)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Facet{
+		Name:   "burst_size",
+		Label:  "Burst size",
+		Values: values,
+	}, nil
+}
@@ -0,0 +1,64 @@
+package query
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestBurstSizeFilterAndFacet(t *testing.T) {
+	db := setupTestDBWithSchema(t)
+	defer db.Close()
+
+	// A 2-frame burst, a 12-frame burst and one photo on its own
+	var photos []TestPhoto
+	for i := 0; i < 15; i++ {
+		photos = append(photos, TestPhoto{FilePath: fmt.Sprintf("/%02d.dng", i), DateTaken: fmt.Sprintf("2024-06-01 09:00:%02d", i)})
+	}
+	insertTestPhotos(t, db, photos)
+	for _, burst := range []struct {
+		group    string
+		from, to int
+	}{{"short", 1, 2}, {"long", 3, 14}} {
+		if _, err := db.Exec("UPDATE photos SET burst_group_id = ?, burst_count = ? WHERE id BETWEEN ? AND ?",
+			burst.group, burst.to-burst.from+1, burst.from, burst.to); err != nil {
+			t.Fatalf("Failed to set burst: %v", err)
+		}
+	}
+
+	engine := NewEngine(db)
+	min, max := BurstSizeBucketRange("10plus")
+	params := QueryParams{BurstSizeMin: min, BurstSizeMax: max, Limit: 50}
+	if max != nil {
+		t.Errorf("10+ bucket max = %d; want open-ended", *max)
+	}
+
+	result, err := engine.Query(params)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if result.Total != 12 {
+		t.Fatalf("got %d photos; want the 12 frames of the long burst", result.Total)
+	}
+
+	facets, err := engine.ComputeFacets(params)
+	if err != nil {
+		t.Fatalf("ComputeFacets failed: %v", err)
+	}
+	if facets.BurstSize == nil || len(facets.BurstSize.Values) != 2 {
+		t.Fatalf("BurstSize facet = %+v; want the 2–3 and 10+ buckets", facets.BurstSize)
+	}
+	for _, v := range facets.BurstSize.Values {
+		switch v.Value {
+		case "2-3":
+			if v.Count != 2 || v.Selected || v.URL != "/photos?burst_size_max=3&burst_size_min=2" {
+				t.Errorf("2-3 = %d selected=%v %q", v.Count, v.Selected, v.URL)
+			}
+		case "10plus":
+			if v.Count != 12 || !v.Selected || v.URL != "/photos" {
+				t.Errorf("10plus = %d selected=%v %q; want 12, selected, /photos", v.Count, v.Selected, v.URL)
+			}
+		default:
+			t.Errorf("unexpected bucket %q", v.Value)
+		}
+	}
+}
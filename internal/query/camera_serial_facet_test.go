@@ -0,0 +1,70 @@
+package query
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCameraSerialFilterAndFacet(t *testing.T) {
+	db := setupTestDBWithSchema(t)
+	defer db.Close()
+
+	insertTestPhotos(t, db, []TestPhoto{
+		{FilePath: "/a.dng", CameraMake: "Leica", CameraModel: "M11", DateTaken: "2024-06-01 09:00:00"},
+		{FilePath: "/b.dng", CameraMake: "Leica", CameraModel: "M11", DateTaken: "2024-06-02 09:00:00"},
+		{FilePath: "/c.dng", CameraMake: "Leica", CameraModel: "M11", DateTaken: "2024-06-03 09:00:00"},
+		{FilePath: "/d.dng", CameraMake: "Leica", CameraModel: "M11", DateTaken: "2024-06-04 09:00:00"},
+	})
+	bodies := map[string][2]string{
+		"/a.dng": {"5551234", "tok1"},
+		"/b.dng": {"5551234", "tok1"},
+		"/c.dng": {"5559876", "tok2"},
+	}
+	for path, body := range bodies {
+		if _, err := db.Exec("UPDATE photos SET camera_serial = ?, camera_serial_token = ? WHERE file_path = ?", body[0], body[1], path); err != nil {
+			t.Fatalf("Failed to set serial: %v", err)
+		}
+	}
+
+	engine := NewEngine(db)
+	params := QueryParams{CameraSerial: []string{"tok2"}, Limit: 50}
+
+	result, err := engine.Query(params)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if result.Total != 1 {
+		t.Errorf("Total = %d; want 1 photo from the second body", result.Total)
+	}
+
+	facets, err := engine.ComputeFacets(params)
+	if err != nil {
+		t.Fatalf("ComputeFacets failed: %v", err)
+	}
+	if facets.CameraSerial == nil || len(facets.CameraSerial.Values) != 2 {
+		t.Fatalf("CameraSerial facet = %+v; want 2 bodies", facets.CameraSerial)
+	}
+	first := facets.CameraSerial.Values[0]
+	if first.Value != "tok1" || first.Count != 2 || first.Selected {
+		t.Errorf("First body = %+v; want tok1 with 2 photos, unselected", first)
+	}
+	if first.Label != "Leica M11 …1234" {
+		t.Errorf("Label = %q; want masked serial", first.Label)
+	}
+	if !facets.CameraSerial.Values[1].Selected {
+		t.Error("tok2 should be selected")
+	}
+
+	// The token, not the serial, goes in the URL
+	url := NewURLMapper().BuildFullURL(params)
+	if !strings.Contains(url, "body=tok2") || strings.Contains(url, "5559876") {
+		t.Errorf("URL %q should carry the token only", url)
+	}
+	parsed, err := NewURLMapper().ParsePath("/photos", "body=tok2")
+	if err != nil {
+		t.Fatalf("ParsePath failed: %v", err)
+	}
+	if len(parsed.CameraSerial) != 1 || parsed.CameraSerial[0] != "tok2" {
+		t.Errorf("Parsed CameraSerial = %v; want [tok2]", parsed.CameraSerial)
+	}
+}
@@ -0,0 +1,56 @@
+package query
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestCaptionFilter(t *testing.T) {
+	db := setupTestDBWithSchema(t)
+	defer db.Close()
+
+	insertTestPhotos(t, db, []TestPhoto{
+		{FilePath: "/bay.dng", DateTaken: "2024-06-01 19:00:00"},
+		{FilePath: "/pier.dng", DateTaken: "2024-06-02 19:00:00"},
+		{FilePath: "/sale.dng", DateTaken: "2024-06-03 19:00:00"},
+		{FilePath: "/none.dng", DateTaken: "2024-06-04 19:00:00"},
+	})
+	for id, text := range map[int][2]string{
+		1: {"Sunset over the bay", ""},
+		2: {"", "Fishermen at SUNSET"},
+		3: {"Market", "Everything 50% off"},
+	} {
+		if _, err := db.Exec("UPDATE photos SET title = NULLIF(?, ''), caption = NULLIF(?, '') WHERE id = ?", text[0], text[1], id); err != nil {
+			t.Fatalf("Failed to set title and caption: %v", err)
+		}
+	}
+
+	engine := NewEngine(db)
+	tests := []struct {
+		caption string
+		want    int
+	}{
+		{"sunset", 2}, // title or caption, either case
+		{"bay", 1},
+		{"50%", 1}, // wildcards match literally
+		{"5_%", 0},
+		{"harbour", 0},
+	}
+	for _, tt := range tests {
+		params, err := NewURLMapper().ParsePath("/photos", "caption="+url.QueryEscape(tt.caption))
+		if err != nil {
+			t.Fatalf("ParsePath failed: %v", err)
+		}
+		result, err := engine.Query(params)
+		if err != nil {
+			t.Fatalf("Query(caption=%s) failed: %v", tt.caption, err)
+		}
+		if result.Total != tt.want {
+			t.Errorf("caption=%s matched %d photos; want %d", tt.caption, result.Total, tt.want)
+		}
+	}
+
+	if url := NewURLMapper().BuildFullURL(QueryParams{Caption: "golden hour", Limit: 50}); url != "/photos?caption=golden+hour" {
+		t.Errorf("URL = %q", url)
+	}
+}
@@ -0,0 +1,156 @@
+package query
+
+import (
+	"testing"
+)
+
+func TestColourMatchMode(t *testing.T) {
+	db := setupTestDBWithSchema(t)
+	defer db.Close()
+
+	insertTestPhotos(t, db, []TestPhoto{
+		{FilePath: "/red.jpg", CameraMake: "Canon", DateTaken: "2024-06-01 09:00:00"},
+		{FilePath: "/red_green.jpg", CameraMake: "Canon", DateTaken: "2024-06-02 09:00:00"},
+	})
+	colours := []struct {
+		photoID, order, hue int
+	}{
+		{1, 0, 5},   // red
+		{2, 0, 350}, // red, on the far side of the hue wheel
+		{2, 1, 120}, // green
+	}
+	for _, c := range colours {
+		if _, err := db.Exec(`INSERT INTO photo_colors (photo_id, color_order, red, green, blue, weight, hue, saturation, lightness)
+			VALUES (?, ?, 0, 0, 0, 0.5, ?, 70, 50)`, c.photoID, c.order, c.hue); err != nil {
+			t.Fatalf("Failed to insert colour: %v", err)
+		}
+	}
+
+	engine := NewEngine(db)
+	tests := []struct {
+		mode string
+		want []string
+	}{
+		{"", []string{"/red.jpg", "/red_green.jpg"}},
+		{ColourMatchAny, []string{"/red.jpg", "/red_green.jpg"}},
+		{ColourMatchAll, []string{"/red_green.jpg"}},
+	}
+	for _, tt := range tests {
+		result, err := engine.Query(QueryParams{
+			ColourName:      []string{"red", "green"},
+			ColourMatchMode: tt.mode,
+			SortBy:          "date_taken",
+			SortOrder:       "asc",
+			Limit:           50,
+		})
+		if err != nil {
+			t.Fatalf("mode %q: Query failed: %v", tt.mode, err)
+		}
+		var got []string
+		for _, p := range result.Photos {
+			got = append(got, p.FilePath)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("mode %q: got %v, want %v", tt.mode, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("mode %q: got %v, want %v", tt.mode, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestColourMatchModeURL(t *testing.T) {
+	mapper := NewURLMapper()
+
+	params, err := mapper.ParsePath("/photos", "color=red&color=green&color_match=all")
+	if err != nil {
+		t.Fatalf("ParsePath failed: %v", err)
+	}
+	if params.ColourMatchMode != ColourMatchAll {
+		t.Errorf("ColourMatchMode = %q, want %q", params.ColourMatchMode, ColourMatchAll)
+	}
+	if got := mapper.BuildQueryString(params); got != "?color=red&color=green&color_match=all" {
+		t.Errorf("BuildQueryString = %q", got)
+	}
+
+	// The default is left out of URLs, and unknown modes are ignored
+	params, _ = mapper.ParsePath("/photos", "color=red&color=green&color_match=some")
+	if params.ColourMatchMode != "" {
+		t.Errorf("unknown mode parsed as %q", params.ColourMatchMode)
+	}
+	params.ColourMatchMode = ColourMatchAny
+	if got := mapper.BuildQueryString(params); got != "?color=red&color=green" {
+		t.Errorf("BuildQueryString = %q", got)
+	}
+
+	// The mode survives while the first colours are being picked
+	params = QueryParams{ColourName: []string{"red"}, ColourMatchMode: ColourMatchAll, Limit: 50}
+	if got := mapper.BuildQueryString(params); got != "?color=red&color_match=all" {
+		t.Errorf("BuildQueryString = %q", got)
+	}
+}
+
+func TestColourFacetMatchAll(t *testing.T) {
+	db := setupTestDBWithSchema(t)
+	defer db.Close()
+
+	insertTestPhotos(t, db, []TestPhoto{
+		{FilePath: "/red.jpg", CameraMake: "Canon", DateTaken: "2024-06-01 09:00:00"},
+		{FilePath: "/red_green.jpg", CameraMake: "Canon", DateTaken: "2024-06-02 09:00:00"},
+		{FilePath: "/green.jpg", CameraMake: "Canon", DateTaken: "2024-06-03 09:00:00"},
+	})
+	colours := []struct {
+		photoID, order, hue int
+	}{
+		{1, 0, 5},   // red
+		{2, 0, 5},   // red
+		{2, 1, 120}, // green
+		{3, 0, 120}, // green
+	}
+	for _, c := range colours {
+		if _, err := db.Exec(`INSERT INTO photo_colors (photo_id, color_order, red, green, blue, weight, hue, saturation, lightness)
+			VALUES (?, ?, 0, 0, 0, 0.5, ?, 70, 50)`, c.photoID, c.order, c.hue); err != nil {
+			t.Fatalf("Failed to insert colour: %v", err)
+		}
+	}
+
+	engine := NewEngine(db)
+	tests := []struct {
+		mode  string
+		green int // Photos the green value adds to (any) or keeps of (all) red
+	}{
+		{ColourMatchAny, 2},
+		{ColourMatchAll, 1},
+	}
+	for _, tt := range tests {
+		params := QueryParams{ColourName: []string{"red"}, ColourMatchMode: tt.mode, Limit: 50}
+		facets, err := engine.ComputeFacets(params)
+		if err != nil {
+			t.Fatalf("mode %q: ComputeFacets failed: %v", tt.mode, err)
+		}
+		for _, v := range facets.ColourName.Values {
+			if v.Value != "green" {
+				continue
+			}
+			if v.Count != tt.green {
+				t.Errorf("mode %q: green count = %d, want %d", tt.mode, v.Count, tt.green)
+			}
+
+			// Following the value gives as many photos as it shows
+			if tt.mode == ColourMatchAll {
+				params.ColourName = append(params.ColourName, "green")
+				result, err := engine.Query(params)
+				if err != nil {
+					t.Fatalf("Query failed: %v", err)
+				}
+				if result.Total != v.Count {
+					t.Errorf("red and green: %d photos, facet showed %d", result.Total, v.Count)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package query
+
+import (
+	"testing"
+)
+
+func TestSortByColour(t *testing.T) {
+	db := setupTestDBWithSchema(t)
+	defer db.Close()
+
+	insertTestPhotos(t, db, []TestPhoto{
+		{FilePath: "/blue.jpg", DateTaken: "2024-06-01 09:00:00"},
+		{FilePath: "/grey.jpg", DateTaken: "2024-06-01 10:00:00"},
+		{FilePath: "/red.jpg", DateTaken: "2024-06-01 11:00:00"},
+		{FilePath: "/green.jpg", DateTaken: "2024-06-01 12:00:00"},
+	})
+	for _, stmt := range []string{
+		"UPDATE photos SET dominant_hue = 230, dominant_rgb = '#1428dc' WHERE file_path = '/blue.jpg'",
+		"UPDATE photos SET dominant_hue = 2, dominant_rgb = '#c81e1e' WHERE file_path = '/red.jpg'",
+		"UPDATE photos SET dominant_hue = 120, dominant_rgb = '#1ec81e' WHERE file_path = '/green.jpg'",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("Failed to set colours: %v", err)
+		}
+	}
+
+	params, err := NewURLMapper().ParsePath("/photos", "sort=colour&order=asc")
+	if err != nil {
+		t.Fatalf("ParsePath failed: %v", err)
+	}
+	params.Limit = 50
+	result, err := NewEngine(db).QueryCards(params)
+	if err != nil {
+		t.Fatalf("QueryCards failed: %v", err)
+	}
+
+	// Round the wheel from red, with the photo without a palette last
+	want := []string{"#c81e1e", "#1ec81e", "#1428dc", ""}
+	if len(result.Photos) != len(want) {
+		t.Fatalf("got %d cards; want %d", len(result.Photos), len(want))
+	}
+	for i, card := range result.Photos {
+		if card.DominantRGB != want[i] {
+			t.Errorf("card %d DominantRGB = %q; want %q", i, card.DominantRGB, want[i])
+		}
+	}
+	if url := NewURLMapper().BuildFullURL(params); url != "/photos?order=asc&sort=colour" {
+		t.Errorf("BuildFullURL = %q; want the colour sort kept", url)
+	}
+}
@@ -0,0 +1,65 @@
+package query
+
+import (
+	"testing"
+)
+
+func TestColourSpaceFilterAndFacet(t *testing.T) {
+	db := setupTestDBWithSchema(t)
+	defer db.Close()
+
+	insertTestPhotos(t, db, []TestPhoto{
+		{FilePath: "/a.jpg", CameraMake: "Canon", DateTaken: "2024-06-01 09:00:00"},
+		{FilePath: "/b.jpg", CameraMake: "Canon", DateTaken: "2024-06-02 09:00:00"},
+		{FilePath: "/c.jpg", CameraMake: "Nikon", DateTaken: "2024-06-03 09:00:00"},
+		{FilePath: "/d.jpg", CameraMake: "Nikon", DateTaken: "2024-06-04 09:00:00"},
+	})
+	for path, space := range map[string]string{"/a.jpg": "sRGB", "/b.jpg": "sRGB", "/c.jpg": "Adobe RGB"} {
+		if _, err := db.Exec("UPDATE photos SET color_space = ? WHERE file_path = ?", space, path); err != nil {
+			t.Fatalf("Failed to set color_space: %v", err)
+		}
+	}
+
+	engine := NewEngine(db)
+	params := QueryParams{ColourSpace: []string{"Adobe RGB"}, Limit: 50}
+
+	result, err := engine.Query(params)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if result.Total != 1 {
+		t.Errorf("Total = %d; want 1 Adobe RGB photo", result.Total)
+	}
+
+	facets, err := engine.ComputeFacets(params)
+	if err != nil {
+		t.Fatalf("ComputeFacets failed: %v", err)
+	}
+	// The photo with no recorded colour space is left out
+	if facets.ColourSpace == nil || len(facets.ColourSpace.Values) != 2 {
+		t.Fatalf("ColourSpace facet = %+v; want 2 values", facets.ColourSpace)
+	}
+
+	counts := map[string]int{}
+	for _, v := range facets.ColourSpace.Values {
+		counts[v.Value] = v.Count
+		if v.Selected != (v.Value == "Adobe RGB") {
+			t.Errorf("%s Selected = %v", v.Value, v.Selected)
+		}
+		if v.Value == "sRGB" && v.URL != "/photos?color_space=Adobe+RGB&color_space=sRGB" {
+			t.Errorf("sRGB URL = %q", v.URL)
+		}
+	}
+	if counts["sRGB"] != 2 || counts["Adobe RGB"] != 1 {
+		t.Errorf("counts = %v; want sRGB=2 Adobe RGB=1", counts)
+	}
+
+	// URL round trip
+	parsed, err := NewURLMapper().ParsePath("/photos", "color_space=Display+P3&color_space=sRGB")
+	if err != nil {
+		t.Fatalf("ParsePath failed: %v", err)
+	}
+	if len(parsed.ColourSpace) != 2 || parsed.ColourSpace[0] != "Display P3" || parsed.ColourSpace[1] != "sRGB" {
+		t.Errorf("parsed ColourSpace = %v", parsed.ColourSpace)
+	}
+}
@@ -0,0 +1,40 @@
+package query
+
+import (
+	"testing"
+)
+
+func TestCountMatchesQueryTotal(t *testing.T) {
+	db := setupTestDBWithSchema(t)
+	defer db.Close()
+
+	insertTestPhotos(t, db, []TestPhoto{
+		{FilePath: "/a.dng", CameraMake: "Canon", DateTaken: "2024-06-01 09:00:00"},
+		{FilePath: "/b.dng", CameraMake: "Canon", DateTaken: "2024-06-02 09:00:00"},
+		{FilePath: "/c.dng", CameraMake: "Canon", DateTaken: "2025-06-03 09:00:00"},
+		{FilePath: "/d.dng", CameraMake: "Nikon", DateTaken: "2024-06-04 09:00:00"},
+	})
+	engine := NewEngine(db)
+
+	// Paging must not change the count, only which rows Query returns
+	params := QueryParams{CameraMake: []string{"Canon"}, Year: intPtr(2024), Limit: 1, Offset: 1}
+	total, err := engine.Count(params)
+	if err != nil {
+		t.Fatalf("Count failed: %v", err)
+	}
+	if total != 2 {
+		t.Errorf("Count = %d; want 2", total)
+	}
+
+	result, err := engine.Query(params)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if result.Total != total || len(result.Photos) != 1 {
+		t.Errorf("Query: Total = %d with %d photos; want %d with 1", result.Total, len(result.Photos), total)
+	}
+
+	if all, err := engine.Count(QueryParams{}); err != nil || all != 4 {
+		t.Errorf("Count(no filters) = %d, %v; want 4", all, err)
+	}
+}
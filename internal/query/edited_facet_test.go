@@ -0,0 +1,65 @@
+package query
+
+import (
+	"testing"
+)
+
+func TestEditedFilterAndFacet(t *testing.T) {
+	db := setupTestDBWithSchema(t)
+	defer db.Close()
+
+	insertTestPhotos(t, db, []TestPhoto{
+		{FilePath: "/a.jpg", CameraMake: "Canon", DateTaken: "2024-06-01 09:00:00"},
+		{FilePath: "/b.jpg", CameraMake: "Canon", DateTaken: "2024-06-01 10:00:00"},
+		{FilePath: "/c.jpg", CameraMake: "Canon", DateTaken: "2024-06-01 11:00:00"},
+	})
+	for _, stmt := range []string{
+		"UPDATE photos SET software = 'Firmware Version 1.1.0' WHERE file_path = '/a.jpg'",
+		"UPDATE photos SET software = 'Adobe Photoshop Lightroom Classic 13.2', edited = 1 WHERE file_path IN ('/b.jpg', '/c.jpg')",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("Failed to set software: %v", err)
+		}
+	}
+
+	engine := NewEngine(db)
+	edited := true
+	params := QueryParams{Edited: &edited, Limit: 50}
+
+	result, err := engine.Query(params)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if result.Total != 2 {
+		t.Errorf("Total = %d; want 2 edited photos", result.Total)
+	}
+
+	facets, err := engine.ComputeFacets(params)
+	if err != nil {
+		t.Fatalf("ComputeFacets failed: %v", err)
+	}
+	if facets.Edited == nil || len(facets.Edited.Values) != 2 {
+		t.Fatalf("Edited facet = %+v; want 2 values", facets.Edited)
+	}
+	for _, v := range facets.Edited.Values {
+		switch v.Value {
+		case "yes":
+			if v.Count != 2 || !v.Selected || v.URL != "/photos" {
+				t.Errorf("yes = %+v; want 2 selected, linking to /photos", v)
+			}
+		case "no":
+			if v.Count != 1 || v.Selected || v.URL != "/photos?edited=false" {
+				t.Errorf("no = %+v; want 1 unselected, linking to edited=false", v)
+			}
+		}
+	}
+
+	parsed, err := NewURLMapper().ParsePath("/photos", "software=Firmware+Version+1.1.0")
+	if err != nil {
+		t.Fatalf("ParsePath failed: %v", err)
+	}
+	parsed.Limit = 50
+	if total, err := engine.Count(parsed); err != nil || total != 1 {
+		t.Errorf("Count(software=firmware) = %d, %v; want 1", total, err)
+	}
+}
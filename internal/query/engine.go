@@ -16,6 +16,20 @@ import (
 // Engine handles query execution
 type Engine struct {
 	db *sql.DB
+
+	// facetLimit caps the camera and lens facets; 0 uses their defaults
+	facetLimit int
+
+	// location is the zone dates are shown and grouped in; nil uses the
+	// camera's clock time (SetLocation)
+	location *time.Location
+
+	// basePath prefixes the facet URLs the engine builds (SetBasePath)
+	basePath string
+
+	// setupRules classify photos for the setup facet; nil uses
+	// DefaultSetupRules (SetSetupRules)
+	setupRules []SetupRule
 }
 
 // NewEngine creates a new query engine
@@ -23,11 +37,77 @@ func NewEngine(db *sql.DB) *Engine {
 	return &Engine{db: db}
 }
 
+// SetFacetLimit sets how many values the camera and lens facets show before
+// summing the rest into Other. 0 restores the defaults (50 cameras, 30 lenses).
+func (e *Engine) SetFacetLimit(limit int) {
+	if limit < 0 {
+		limit = 0
+	}
+	e.facetLimit = limit
+}
+
+// SetBasePath prefixes the facet URLs ComputeFacets and ComputeFacet build
+// with base, such as "/olsen", for an explorer served below the root
+func (e *Engine) SetBasePath(base string) {
+	e.basePath = base
+}
+
+// urlMapper returns a URLMapper building links under the engine's base path
+func (e *Engine) urlMapper() *URLMapper {
+	m := NewURLMapper()
+	m.SetBasePath(e.basePath)
+	return m
+}
+
 // Query executes a query with the given parameters
 func (e *Engine) Query(params QueryParams) (*QueryResult, error) {
 	startTime := time.Now()
+	params = withPageDefaults(params)
+
+	photos, page, err := queryPage(e, params, e.photoSummaryColumns(), e.scanPhotoSummary,
+		func(p PhotoSummary) int { return p.ID })
+	if err != nil {
+		return nil, err
+	}
+
+	return &QueryResult{
+		Photos:      photos,
+		Total:       page.total,
+		Limit:       params.Limit,
+		Offset:      params.Offset,
+		HasMore:     page.hasMore,
+		QueryTimeMs: time.Since(startTime).Milliseconds(),
+		NextCursor:  page.nextCursor,
+	}, nil
+}
 
-	// Set defaults
+// QueryCards runs the same query as Query but reads only the columns a grid
+// card shows, which keeps large pages cheap to scan. Filtering, sorting and
+// paging are identical.
+func (e *Engine) QueryCards(params QueryParams) (*CardResult, error) {
+	startTime := time.Now()
+	params = withPageDefaults(params)
+
+	cards, page, err := queryPage(e, params, e.photoCardColumns(), scanPhotoCard,
+		func(c PhotoCard) int { return c.ID })
+	if err != nil {
+		return nil, err
+	}
+
+	return &CardResult{
+		Photos:      cards,
+		Total:       page.total,
+		Limit:       params.Limit,
+		Offset:      params.Offset,
+		HasMore:     page.hasMore,
+		QueryTimeMs: time.Since(startTime).Milliseconds(),
+		NextCursor:  page.nextCursor,
+	}, nil
+}
+
+// withPageDefaults fills in the page size and date order Query and
+// QueryCards use when params leave them unset
+func withPageDefaults(params QueryParams) QueryParams {
 	if params.Limit <= 0 {
 		params.Limit = 50
 	}
@@ -38,80 +118,218 @@ func (e *Engine) Query(params QueryParams) (*QueryResult, error) {
 		params.SortBy = "date_taken"
 		params.SortOrder = "desc"
 	}
+	if usesKeyset(params) {
+		params.Offset = 0
+	}
+	return params
+}
+
+// pageInfo is what a page of results knows besides its rows
+type pageInfo struct {
+	total      int
+	hasMore    bool
+	nextCursor *Cursor
+}
+
+// queryPage fetches one page of photos matching params, selecting columns
+// and reading each row with scan; idOf gives a row's photo ID for the cursor
+func queryPage[T any](e *Engine, params QueryParams, columns string, scan func(*sql.Rows) (T, error), idOf func(T) int) ([]T, pageInfo, error) {
+	var page pageInfo
 
 	// Build query
-	query, args := e.buildQuery(params)
+	query, args, err := e.buildQuery(params, columns)
+	if err != nil {
+		return nil, page, err
+	}
 
-	// Execute count query
+	// Execute count query over the whole result set, before any cursor
+	_, countArgs := e.buildWhereClause(params)
 	countQuery := e.buildCountQuery(params)
-	var total int
-	err := e.db.QueryRow(countQuery, args...).Scan(&total)
+	err = e.db.QueryRow(countQuery, countArgs...).Scan(&page.total)
 	if err != nil {
-		return nil, fmt.Errorf("failed to count results: %w", err)
+		return nil, page, fmt.Errorf("failed to count results: %w", err)
 	}
 
 	// Execute main query
 	rows, err := e.db.Query(query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute query: %w", err)
+		return nil, page, fmt.Errorf("failed to execute query: %w", err)
 	}
 	defer rows.Close()
 
 	// Parse results
-	photos := []PhotoSummary{}
+	items := []T{}
+	for rows.Next() {
+		item, err := scan(rows)
+		if err != nil {
+			return nil, page, fmt.Errorf("failed to scan photo: %w", err)
+		}
+		items = append(items, item)
+	}
+
+	// The query fetches one row past the page, so whether another page
+	// follows does not depend on a count taken separately
+	page.hasMore = len(items) > params.Limit
+	if page.hasMore {
+		items = items[:params.Limit]
+	}
+
+	if byDate, _ := SortsByDate(params); byDate && page.hasMore {
+		if page.nextCursor, err = e.cursorAfter(idOf(items[len(items)-1])); err != nil {
+			return nil, page, err
+		}
+	}
+
+	return items, page, nil
+}
+
+// Count returns how many photos match params without fetching any rows.
+// Limit, offset and sort order are ignored.
+func (e *Engine) Count(params QueryParams) (int, error) {
+	_, args := e.buildWhereClause(params)
+	var total int
+	if err := e.db.QueryRow(e.buildCountQuery(params), args...).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to count results: %w", err)
+	}
+	return total, nil
+}
+
+// MatchingIDs returns SQL selecting the id of every photo matching params,
+// ignoring paging, with its arguments. It is meant as a subquery for
+// statements that change the matching photos.
+func (e *Engine) MatchingIDs(params QueryParams) (string, []interface{}) {
+	where, args := e.buildWhereClause(params)
+	query := "SELECT p.id FROM photos p"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	return query, args
+}
+
+// IteratePhotos calls fn for every photo in id order, fetching batchSize rows
+// at a time. Each batch resumes after the last id seen (keyset pagination),
+// so memory stays bounded and rows added or removed during the walk do not
+// shift later pages. Iteration stops at the first error returned by fn.
+func (e *Engine) IteratePhotos(batchSize int, fn func(PhotoSummary) error) error {
+	return e.iteratePhotos("", nil, batchSize, fn)
+}
+
+// IteratePhotosMissingThumbnail is IteratePhotos over just the photos with no
+// thumbnail of size ("64", "256", "512" or "1024"), such as those indexed
+// before a size was configured or skipped as too small
+func (e *Engine) IteratePhotosMissingThumbnail(size string, batchSize int, fn func(PhotoSummary) error) error {
+	return e.iteratePhotos(
+		"NOT EXISTS (SELECT 1 FROM thumbnails t WHERE t.photo_id = p.id AND t.size = ?)",
+		[]interface{}{size}, batchSize, fn)
+}
+
+// iteratePhotos walks the photos matching condition, an SQL expression over
+// p taking args, in batches for IteratePhotos. An empty condition matches
+// every photo.
+func (e *Engine) iteratePhotos(condition string, args []interface{}, batchSize int, fn func(PhotoSummary) error) error {
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+
+	query := "SELECT " + e.photoSummaryColumns() + " FROM photos p WHERE p.id > ?"
+	if condition != "" {
+		query += " AND " + condition
+	}
+	query += " ORDER BY p.id LIMIT ?"
+
+	lastID := 0
+	for {
+		batch, err := e.fetchPhotoBatch(query, lastID, batchSize, args)
+		if err != nil {
+			return err
+		}
+
+		for _, photo := range batch {
+			if err := fn(photo); err != nil {
+				return err
+			}
+		}
+
+		if len(batch) < batchSize {
+			return nil
+		}
+		lastID = batch[len(batch)-1].ID
+	}
+}
+
+// fetchPhotoBatch reads one page for iteratePhotos. The rows are closed
+// before fn runs, so callbacks are free to query or write the database.
+func (e *Engine) fetchPhotoBatch(query string, afterID, limit int, args []interface{}) ([]PhotoSummary, error) {
+	queryArgs := append([]interface{}{afterID}, args...)
+	rows, err := e.db.Query(query, append(queryArgs, limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch photos after id %d: %w", afterID, err)
+	}
+	defer rows.Close()
+
+	batch := make([]PhotoSummary, 0, limit)
 	for rows.Next() {
 		photo, err := e.scanPhotoSummary(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan photo: %w", err)
 		}
-		photos = append(photos, photo)
+		batch = append(batch, photo)
 	}
+	return batch, rows.Err()
+}
 
-	queryTime := time.Since(startTime).Milliseconds()
+// photoSummaryColumns are the columns scanPhotoSummary expects, in order
+func (e *Engine) photoSummaryColumns() string {
+	return `
+			p.id, p.file_path, ` + e.dateTaken() + `,
+			p.camera_make, p.camera_model, p.lens_model,
+			p.iso, p.aperture, p.shutter_speed, p.focal_length, p.focal_length_35mm,
+			p.width, p.height,
+			p.time_of_day, p.season, p.focal_category,
+			p.burst_group_id, p.is_burst_representative,
+			p.latitude, p.longitude,
+			p.indexed_at, p.blurhash, p.rating
+		`
+}
 
-	return &QueryResult{
-		Photos:      photos,
-		Total:       total,
-		Limit:       params.Limit,
-		Offset:      params.Offset,
-		HasMore:     params.Offset+params.Limit < total,
-		QueryTimeMs: queryTime,
-	}, nil
+// photoCardColumns are the columns scanPhotoCard expects, in order
+func (e *Engine) photoCardColumns() string {
+	return "p.id, " + e.dateTaken() + ", p.camera_make, p.camera_model, p.indexed_at, p.blurhash, p.dominant_rgb, " +
+		// Orientations 5 to 8 turn the image a quarter, as the thumbnails are
+		"CASE WHEN p.orientation BETWEEN 5 AND 8 THEN p.height ELSE p.width END, " +
+		"CASE WHEN p.orientation BETWEEN 5 AND 8 THEN p.width ELSE p.height END"
 }
 
-// buildQuery constructs the SQL query from parameters
-func (e *Engine) buildQuery(params QueryParams) (string, []interface{}) {
+// buildQuery constructs the SQL query selecting columns from parameters
+func (e *Engine) buildQuery(params QueryParams, columns string) (string, []interface{}, error) {
 	var where []string
 	var args []interface{}
 
 	// Build WHERE clauses
 	where, args = e.buildWhereClause(params)
+	if usesKeyset(params) {
+		cond, keysetArgs, err := keysetCondition(params)
+		if err != nil {
+			return "", nil, err
+		}
+		where = append(where, cond)
+		args = append(args, keysetArgs...)
+	}
 
 	// Build ORDER BY
 	orderBy := e.buildOrderBy(params)
 
 	// Construct full query
-	query := `
-		SELECT
-			p.id, p.file_path, p.date_taken,
-			p.camera_make, p.camera_model, p.lens_model,
-			p.iso, p.aperture, p.shutter_speed, p.focal_length, p.focal_length_35mm,
-			p.width, p.height,
-			p.time_of_day, p.season, p.focal_category,
-			p.burst_group_id, p.is_burst_representative,
-			p.latitude, p.longitude,
-			p.indexed_at
-		FROM photos p
-	`
+	query := "SELECT " + columns + " FROM photos p"
 
 	if len(where) > 0 {
 		query += " WHERE " + strings.Join(where, " AND ")
 	}
 
 	query += " " + orderBy
-	query += fmt.Sprintf(" LIMIT %d OFFSET %d", params.Limit, params.Offset)
+	query += fmt.Sprintf(" LIMIT %d OFFSET %d", params.Limit+1, params.Offset)
 
-	return query, args
+	return query, args, nil
 }
 
 // buildCountQuery constructs the count query
@@ -126,6 +344,10 @@ func (e *Engine) buildCountQuery(params QueryParams) string {
 	return query
 }
 
+// likeEscaper escapes the LIKE wildcards in text matched literally, for
+// patterns declared with ESCAPE '\'
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 // buildWhereClause builds WHERE conditions and arguments
 func (e *Engine) buildWhereClause(params QueryParams) ([]string, []interface{}) {
 	var where []string
@@ -137,20 +359,20 @@ func (e *Engine) buildWhereClause(params QueryParams) ([]string, []interface{})
 			// Filter for photos without dates (unknown year)
 			where = append(where, "p.date_taken IS NULL")
 		} else {
-			where = append(where, "strftime('%Y', p.date_taken) = ?")
+			where = append(where, "strftime('%Y', "+e.dateTaken()+") = ?")
 			args = append(args, fmt.Sprintf("%04d", *params.Year))
 		}
 	}
 	if params.Month != nil {
 		// ✅ State machine model: Month is independent of Year
 		// Apply month filter even when Year is not set (for facet computation)
-		where = append(where, "strftime('%m', p.date_taken) = ?")
+		where = append(where, "strftime('%m', "+e.dateTaken()+") = ?")
 		args = append(args, fmt.Sprintf("%02d", *params.Month))
 	}
 	if params.Day != nil {
 		// ✅ State machine model: Day is independent of Month and Year
 		// Apply day filter even when Month/Year are not set (for facet computation)
-		where = append(where, "strftime('%d', p.date_taken) = ?")
+		where = append(where, "strftime('%d', "+e.dateTaken()+") = ?")
 		args = append(args, fmt.Sprintf("%02d", *params.Day))
 	}
 	if params.DateFrom != nil {
@@ -177,6 +399,12 @@ func (e *Engine) buildWhereClause(params QueryParams) ([]string, []interface{})
 		}
 		where = append(where, fmt.Sprintf("p.season IN (%s)", strings.Join(placeholders, ", ")))
 	}
+	if len(params.Weekday) > 0 {
+		if cond, weekdayArgs := weekdayCondition(e.dateTaken(), params.Weekday); cond != "" {
+			where = append(where, cond)
+			args = append(args, weekdayArgs...)
+		}
+	}
 
 	// Equipment filters
 	if len(params.CameraMake) > 0 {
@@ -245,6 +473,30 @@ func (e *Engine) buildWhereClause(params QueryParams) ([]string, []interface{})
 		where = append(where, "p.focal_length_35mm <= ?")
 		args = append(args, *params.FocalLength35mmMax)
 	}
+	if params.EVMin != nil {
+		where = append(where, "p.exposure_value >= ?")
+		args = append(args, *params.EVMin)
+	}
+	if params.EVMax != nil {
+		where = append(where, "p.exposure_value < ?")
+		args = append(args, *params.EVMax)
+	}
+	if params.ShutterMin != nil {
+		where = append(where, "p.shutter_seconds >= ?")
+		args = append(args, *params.ShutterMin)
+	}
+	if params.ShutterMax != nil {
+		where = append(where, "p.shutter_seconds < ?")
+		args = append(args, *params.ShutterMax)
+	}
+	if params.FileSizeMin != nil {
+		where = append(where, "p.file_size >= ?")
+		args = append(args, *params.FileSizeMin)
+	}
+	if params.FileSizeMax != nil {
+		where = append(where, "p.file_size < ?")
+		args = append(args, *params.FileSizeMax)
+	}
 
 	// Categorical filters
 	if len(params.FocalCategory) > 0 {
@@ -255,6 +507,16 @@ func (e *Engine) buildWhereClause(params QueryParams) ([]string, []interface{})
 		}
 		where = append(where, fmt.Sprintf("p.focal_category IN (%s)", strings.Join(placeholders, ", ")))
 	}
+	if len(params.Setup) > 0 {
+		setup, setupArgs := e.setupCase()
+		args = append(args, setupArgs...)
+		placeholders := make([]string, len(params.Setup))
+		for i, s := range params.Setup {
+			placeholders[i] = "?"
+			args = append(args, s)
+		}
+		where = append(where, fmt.Sprintf("(%s) IN (%s)", setup, strings.Join(placeholders, ", ")))
+	}
 	if len(params.ShootingCondition) > 0 {
 		placeholders := make([]string, len(params.ShootingCondition))
 		for i, cond := range params.ShootingCondition {
@@ -281,6 +543,14 @@ func (e *Engine) buildWhereClause(params QueryParams) ([]string, []interface{})
 		where = append(where, "p.longitude <= ?")
 		args = append(args, *params.LonMax)
 	}
+	if params.AltMin != nil {
+		where = append(where, "p.altitude >= ?")
+		args = append(args, *params.AltMin)
+	}
+	if params.AltMax != nil {
+		where = append(where, "p.altitude <= ?")
+		args = append(args, *params.AltMax)
+	}
 	if params.HasGPS != nil {
 		if *params.HasGPS {
 			where = append(where, "(p.latitude IS NOT NULL AND p.longitude IS NOT NULL)")
@@ -317,7 +587,11 @@ func (e *Engine) buildWhereClause(params QueryParams) ([]string, []interface{})
 			}
 		}
 		if len(colourConditions) > 0 {
-			where = append(where, "("+strings.Join(colourConditions, " OR ")+")")
+			joiner := " OR "
+			if params.ColourMatchMode == ColourMatchAll {
+				joiner = " AND "
+			}
+			where = append(where, "("+strings.Join(colourConditions, joiner)+")")
 		}
 	}
 
@@ -346,6 +620,21 @@ func (e *Engine) buildWhereClause(params QueryParams) ([]string, []interface{})
 		where = append(where, "EXISTS (SELECT 1 FROM photo_colors pc WHERE pc.photo_id = p.id AND pc.lightness <= ?)")
 		args = append(args, *params.LightMax)
 	}
+	if params.HasColours != nil {
+		if *params.HasColours {
+			where = append(where, "EXISTS (SELECT 1 FROM photo_colors pc WHERE pc.photo_id = p.id)")
+		} else {
+			where = append(where, "NOT EXISTS (SELECT 1 FROM photo_colors pc WHERE pc.photo_id = p.id)")
+		}
+	}
+	if params.ColourCountMin != nil {
+		where = append(where, "p.colour_count >= ?")
+		args = append(args, *params.ColourCountMin)
+	}
+	if params.ColourCountMax != nil {
+		where = append(where, "p.colour_count <= ?")
+		args = append(args, *params.ColourCountMax)
+	}
 
 	// Burst filters
 	if params.InBurst != nil {
@@ -355,14 +644,50 @@ func (e *Engine) buildWhereClause(params QueryParams) ([]string, []interface{})
 			where = append(where, "p.burst_group_id IS NULL")
 		}
 	}
+	if params.InBracket != nil {
+		if *params.InBracket {
+			where = append(where, "p.bracket_group_id IS NOT NULL")
+		} else {
+			where = append(where, "p.bracket_group_id IS NULL")
+		}
+	}
 	if params.BurstGroupID != nil {
 		where = append(where, "p.burst_group_id = ?")
 		args = append(args, *params.BurstGroupID)
 	}
+	if params.SessionID != nil {
+		where = append(where, "p.session_id = ?")
+		args = append(args, *params.SessionID)
+	}
+	if params.RatingMin != nil {
+		where = append(where, "p.rating >= ?")
+		args = append(args, *params.RatingMin)
+	}
+	if params.RatingMax != nil {
+		where = append(where, "p.rating <= ?")
+		args = append(args, *params.RatingMax)
+	}
+	if params.BurstSizeMin != nil {
+		where = append(where, "p.burst_count >= ?")
+		args = append(args, *params.BurstSizeMin)
+	}
+	if params.BurstSizeMax != nil {
+		where = append(where, "p.burst_count <= ?")
+		args = append(args, *params.BurstSizeMax)
+	}
 	if params.IsBurstRep != nil {
 		where = append(where, "p.is_burst_representative = ?")
 		args = append(args, *params.IsBurstRep)
 	}
+	if params.CollapseBursts {
+		where = append(where, "(p.burst_group_id IS NULL OR p.is_burst_representative = 1)")
+	}
+	if params.Rejected != nil {
+		where = append(where, "p.rejected = ?")
+		args = append(args, *params.Rejected)
+	} else if !params.IncludeRejected {
+		where = append(where, "p.rejected = 0")
+	}
 
 	// Image properties
 	if params.WidthMin != nil {
@@ -421,11 +746,68 @@ func (e *Engine) buildWhereClause(params QueryParams) ([]string, []interface{})
 		}
 		where = append(where, fmt.Sprintf("p.color_space IN (%s)", strings.Join(placeholders, ", ")))
 	}
+	if len(params.CameraSerial) > 0 {
+		placeholders := make([]string, len(params.CameraSerial))
+		for i, token := range params.CameraSerial {
+			placeholders[i] = "?"
+			args = append(args, token)
+		}
+		where = append(where, fmt.Sprintf("p.camera_serial_token IN (%s)", strings.Join(placeholders, ", ")))
+	}
+	if len(params.Software) > 0 {
+		placeholders := make([]string, len(params.Software))
+		for i, software := range params.Software {
+			placeholders[i] = "?"
+			args = append(args, software)
+		}
+		where = append(where, fmt.Sprintf("p.software IN (%s)", strings.Join(placeholders, ", ")))
+	}
+	if params.Edited != nil {
+		where = append(where, "p.edited = ?")
+		args = append(args, *params.Edited)
+	}
+	if params.Caption != "" {
+		pattern := "%" + likeEscaper.Replace(params.Caption) + "%"
+		where = append(where, `(p.title LIKE ? ESCAPE '\' OR p.caption LIKE ? ESCAPE '\')`)
+		args = append(args, pattern, pattern)
+	}
+	if params.CollectionID != nil {
+		where = append(where, "p.id IN (SELECT photo_id FROM collection_photos WHERE collection_id = ?)")
+		args = append(args, *params.CollectionID)
+	}
+	if len(params.Tag) > 0 {
+		placeholders := make([]string, len(params.Tag))
+		for i, tag := range params.Tag {
+			placeholders[i] = "?"
+			args = append(args, tag)
+		}
+		where = append(where, fmt.Sprintf(`p.id IN (
+			SELECT pt.photo_id FROM photo_tags pt JOIN tags t ON t.id = pt.tag_id
+			WHERE t.name IN (%s))`, strings.Join(placeholders, ", ")))
+	}
+	if len(params.FileFormat) > 0 {
+		placeholders := make([]string, len(params.FileFormat))
+		for i, ff := range params.FileFormat {
+			placeholders[i] = "?"
+			args = append(args, ff)
+		}
+		where = append(where, fmt.Sprintf("p.file_format IN (%s)", strings.Join(placeholders, ", ")))
+	}
+	if len(params.MediaType) > 0 {
+		placeholders := make([]string, len(params.MediaType))
+		for i, mt := range params.MediaType {
+			placeholders[i] = "?"
+			args = append(args, mt)
+		}
+		where = append(where, fmt.Sprintf("COALESCE(p.media_type, 'photo') IN (%s)", strings.Join(placeholders, ", ")))
+	}
 
 	return where, args
 }
 
-// buildOrderBy constructs ORDER BY clause
+// buildOrderBy constructs ORDER BY clause. Ties (bursts share a timestamp
+// to the second) are broken by id, so pages never overlap or skip photos and
+// Filmstrip can locate a photo within them.
 func (e *Engine) buildOrderBy(params QueryParams) string {
 	order := "DESC"
 	if params.SortOrder == "asc" {
@@ -434,17 +816,20 @@ func (e *Engine) buildOrderBy(params QueryParams) string {
 
 	switch params.SortBy {
 	case "date_taken":
-		return fmt.Sprintf("ORDER BY p.date_taken %s", order)
+		return fmt.Sprintf("ORDER BY p.date_taken %s, p.id %s", order, order)
 	case "camera":
-		return fmt.Sprintf("ORDER BY p.camera_make %s, p.camera_model %s", order, order)
+		return fmt.Sprintf("ORDER BY p.camera_make %s, p.camera_model %s, p.id %s", order, order, order)
 	case "focal_length":
-		return fmt.Sprintf("ORDER BY p.focal_length %s", order)
+		return fmt.Sprintf("ORDER BY p.focal_length %s, p.id %s", order, order)
 	case "iso":
-		return fmt.Sprintf("ORDER BY p.iso %s", order)
+		return fmt.Sprintf("ORDER BY p.iso %s, p.id %s", order, order)
 	case "aperture":
-		return fmt.Sprintf("ORDER BY p.aperture %s", order)
+		return fmt.Sprintf("ORDER BY p.aperture %s, p.id %s", order, order)
+	case "colour", "color":
+		// Round the colour wheel; photos without a palette come last
+		return fmt.Sprintf("ORDER BY p.dominant_hue IS NULL, p.dominant_hue %s, p.id %s", order, order)
 	default:
-		return "ORDER BY p.date_taken DESC"
+		return "ORDER BY p.date_taken DESC, p.id DESC"
 	}
 }
 
@@ -461,7 +846,8 @@ func (e *Engine) scanPhotoSummary(rows *sql.Rows) (PhotoSummary, error) {
 	var burstGroupID sql.NullString
 	var isBurstRep sql.NullBool
 	var latitude, longitude sql.NullFloat64
-	var indexedAt sql.NullString
+	var indexedAt, blurhash sql.NullString
+	var rating sql.NullInt64
 
 	err := rows.Scan(
 		&p.ID, &p.FilePath, &dateTaken,
@@ -471,11 +857,12 @@ func (e *Engine) scanPhotoSummary(rows *sql.Rows) (PhotoSummary, error) {
 		&timeOfDay, &season, &focalCategory,
 		&burstGroupID, &isBurstRep,
 		&latitude, &longitude,
-		&indexedAt,
+		&indexedAt, &blurhash, &rating,
 	)
 	if err != nil {
 		return p, err
 	}
+	p.Rating = int(rating.Int64)
 
 	// Parse nullable fields
 	if dateTaken.Valid {
@@ -535,6 +922,29 @@ func (e *Engine) scanPhotoSummary(rows *sql.Rows) (PhotoSummary, error) {
 	if indexedAt.Valid {
 		p.IndexedAt, _ = time.Parse(time.RFC3339, indexedAt.String)
 	}
+	p.Blurhash = blurhash.String
 
 	return p, nil
 }
+
+func scanPhotoCard(rows *sql.Rows) (PhotoCard, error) {
+	var c PhotoCard
+	var dateTaken, cameraMake, cameraModel, indexedAt, blurhash, dominantRGB sql.NullString
+	var width, height sql.NullInt64
+	if err := rows.Scan(&c.ID, &dateTaken, &cameraMake, &cameraModel, &indexedAt, &blurhash, &dominantRGB, &width, &height); err != nil {
+		return c, err
+	}
+	if dateTaken.Valid {
+		c.DateTaken, _ = time.Parse(time.RFC3339, dateTaken.String)
+	}
+	if indexedAt.Valid {
+		c.IndexedAt, _ = time.Parse(time.RFC3339, indexedAt.String)
+	}
+	c.CameraMake = cameraMake.String
+	c.CameraModel = cameraModel.String
+	c.Blurhash = blurhash.String
+	c.DominantRGB = dominantRGB.String
+	c.Width = int(width.Int64)
+	c.Height = int(height.Int64)
+	return c, nil
+}
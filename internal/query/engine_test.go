@@ -1,7 +1,10 @@
 package query
 
 import (
+	"fmt"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/adewale/olsen/internal/database"
 )
@@ -441,3 +444,51 @@ func BenchmarkQuery(b *testing.B) {
 		}
 	})
 }
+
+// BenchmarkComputeFacetsLarge measures a grid page's facets on a generated
+// catalog big enough for the facet queries to dominate
+func BenchmarkComputeFacetsLarge(b *testing.B) {
+	db, err := database.Open(filepath.Join(b.TempDir(), "facets.db"))
+	if err != nil {
+		b.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		b.Fatal(err)
+	}
+	cameras := []string{"Canon", "Nikon", "Sony", "Fujifilm", "Leica"}
+	base := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 50000; i++ {
+		date := base.Add(time.Duration(i*7919%(10*365*24)) * time.Hour)
+		_, err := tx.Exec(`
+			INSERT INTO photos (file_path, file_hash, file_size, indexed_at, last_modified,
+				date_taken, camera_make, camera_model, lens_model, iso, aperture, focal_length,
+				time_of_day, season, focal_category, shooting_condition)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			fmt.Sprintf("/p/%d.jpg", i), fmt.Sprint(i), 1000+i*37%(50<<20), date, date,
+			date, cameras[i%5], fmt.Sprintf("Model %d", i%13), fmt.Sprintf("Lens %d", i%17),
+			100<<(i%6), 1.4+float64(i%8), float64(14+i%186),
+			[]string{"morning", "afternoon", "evening", "night"}[i%4],
+			[]string{"winter", "spring", "summer", "autumn"}[i/7%4],
+			[]string{"wide", "normal", "telephoto"}[i%3],
+			[]string{"bright", "normal", "low_light"}[i/3%3])
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		b.Fatal(err)
+	}
+
+	engine := NewEngine(db.DB)
+	year := 2020
+	params := QueryParams{Year: &year, Limit: 50}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := engine.ComputeFacets(params); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
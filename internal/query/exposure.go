@@ -0,0 +1,166 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// rangeBucket is one range of a bucketed numeric facet (exposure value,
+// shutter speed, file size). Bounds follow the EVMin/EVMax convention: min inclusive,
+// max exclusive, nil for open ends.
+type rangeBucket struct {
+	value string
+	label string
+	min   *float64
+	max   *float64
+}
+
+func rangeBound(v float64) *float64 { return &v }
+
+// intBucketRange returns the bounds of the bucket named value as inclusive
+// integers, for filters over counts such as ColourCountMin/ColourCountMax.
+// Unknown values return nil bounds.
+func intBucketRange(buckets []rangeBucket, value string) (min, max *int) {
+	for _, b := range buckets {
+		if b.value != value {
+			continue
+		}
+		if b.min != nil {
+			n := int(*b.min)
+			min = &n
+		}
+		if b.max != nil {
+			n := int(*b.max) - 1
+			max = &n
+		}
+		return min, max
+	}
+	return nil, nil
+}
+
+// intSelection converts inclusive integer bounds back to the bucket
+// convention, so computeRangeFacetValues can select the matching bucket
+func intSelection(min, max *int) (selMin, selMax *float64) {
+	if min != nil {
+		selMin = rangeBound(float64(*min))
+	}
+	if max != nil {
+		selMax = rangeBound(float64(*max + 1))
+	}
+	return selMin, selMax
+}
+
+// evBuckets group EV into three-stop bands, roughly from night scenes to
+// bright sun on snow
+var evBuckets = []rangeBucket{
+	{value: "lt3", label: "Below EV 3 (night)", max: rangeBound(3)},
+	{value: "3-6", label: "EV 3–6 (dim interior)", min: rangeBound(3), max: rangeBound(6)},
+	{value: "6-9", label: "EV 6–9 (interior, dusk)", min: rangeBound(6), max: rangeBound(9)},
+	{value: "9-12", label: "EV 9–12 (overcast)", min: rangeBound(9), max: rangeBound(12)},
+	{value: "12-15", label: "EV 12–15 (sunny)", min: rangeBound(12), max: rangeBound(15)},
+	{value: "15plus", label: "EV 15+ (bright sun)", min: rangeBound(15)},
+}
+
+// EVBucketRange returns the EVMin/EVMax bounds of an exposure value facet
+// value. Unknown values return nil bounds.
+func EVBucketRange(value string) (min, max *float64) {
+	for _, b := range evBuckets {
+		if b.value == value {
+			return b.min, b.max
+		}
+	}
+	return nil, nil
+}
+
+// bucketCase builds a SQL CASE expression mapping column to a bucket value.
+// Bounds are written at full precision so values on a boundary such as
+// 1/60s land in the same bucket as the URL filter puts them.
+func bucketCase(column string, buckets []rangeBucket) string {
+	var sb strings.Builder
+	sb.WriteString("CASE")
+	for _, b := range buckets {
+		if b.max == nil {
+			fmt.Fprintf(&sb, " ELSE '%s'", b.value)
+			continue
+		}
+		fmt.Fprintf(&sb, " WHEN %s < %s THEN '%s'", column, strconv.FormatFloat(*b.max, 'g', -1, 64), b.value)
+	}
+	sb.WriteString(" END")
+	return sb.String()
+}
+
+// computeExposureValueFacet computes the exposure value bucket facet
+func (e *Engine) computeExposureValueFacet(params QueryParams) (*Facet, error) {
+	paramsWithoutEV := params
+	paramsWithoutEV.EVMin = nil
+	paramsWithoutEV.EVMax = nil
+
+	values, err := e.computeRangeFacetValues(paramsWithoutEV, "exposure_value", evBuckets, params.EVMin, params.EVMax)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Facet{
+		Name:   "exposure_value",
+		Label:  "Exposure (EV)",
+		Values: values,
+	}, nil
+}
+
+// computeRangeFacetValues counts photos per bucket of column under params,
+// which must already exclude the facet's own range. Buckets are emitted in
+// order, skipping empty ones; the one matching [selMin, selMax) is selected.
+func (e *Engine) computeRangeFacetValues(params QueryParams, column string, buckets []rangeBucket, selMin, selMax *float64) ([]FacetValue, error) {
+	where, args := e.buildWhereClause(params)
+	where = append(where, column+" IS NOT NULL")
+
+	query := fmt.Sprintf(`
+		SELECT %s as bucket, COUNT(*) as count
+		FROM photos p
+		WHERE %s
+		GROUP BY bucket
+	`, bucketCase(column, buckets), strings.Join(where, " AND "))
+
+	rows, err := e.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var bucket string
+		var count int
+		if err := rows.Scan(&bucket, &count); err != nil {
+			return nil, err
+		}
+		counts[bucket] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	values := []FacetValue{}
+	for _, b := range buckets {
+		count, ok := counts[b.value]
+		if !ok {
+			continue
+		}
+		values = append(values, FacetValue{
+			Value:    b.value,
+			Label:    b.label,
+			Count:    count,
+			Selected: sameBound(selMin, b.min) && sameBound(selMax, b.max),
+		})
+	}
+	return values, nil
+}
+
+// sameBound reports whether two optional bounds are equal
+func sameBound(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
@@ -0,0 +1,70 @@
+package query
+
+import (
+	"testing"
+)
+
+func TestExposureValueFilterAndFacet(t *testing.T) {
+	db := setupTestDBWithSchema(t)
+	defer db.Close()
+
+	insertTestPhotos(t, db, []TestPhoto{
+		{FilePath: "/night.dng", CameraMake: "Canon", DateTaken: "2024-06-01 23:00:00"},
+		{FilePath: "/sun1.dng", CameraMake: "Canon", DateTaken: "2024-06-02 12:00:00"},
+		{FilePath: "/sun2.dng", CameraMake: "Canon", DateTaken: "2024-06-03 12:00:00"},
+		{FilePath: "/edge.dng", CameraMake: "Canon", DateTaken: "2024-06-04 12:00:00"},
+		{FilePath: "/unknown.dng", CameraMake: "Canon", DateTaken: "2024-06-05 12:00:00"},
+	})
+	for path, ev := range map[string]float64{"/night.dng": 1.5, "/sun1.dng": 13.2, "/sun2.dng": 14.9, "/edge.dng": 15} {
+		if _, err := db.Exec("UPDATE photos SET exposure_value = ? WHERE file_path = ?", ev, path); err != nil {
+			t.Fatalf("Failed to set exposure_value: %v", err)
+		}
+	}
+
+	engine := NewEngine(db)
+	min, max := EVBucketRange("12-15")
+	params := QueryParams{EVMin: min, EVMax: max, Limit: 50}
+
+	result, err := engine.Query(params)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if result.Total != 2 {
+		t.Errorf("Total = %d; want 2 (EV 15 belongs to the next bucket)", result.Total)
+	}
+
+	facets, err := engine.ComputeFacets(params)
+	if err != nil {
+		t.Fatalf("ComputeFacets failed: %v", err)
+	}
+	if facets.ExposureValue == nil {
+		t.Fatal("ExposureValue facet is nil")
+	}
+
+	want := []struct {
+		value    string
+		count    int
+		selected bool
+	}{
+		{"lt3", 1, false},
+		{"12-15", 2, true},
+		{"15plus", 1, false},
+	}
+	if len(facets.ExposureValue.Values) != len(want) {
+		t.Fatalf("got %d EV buckets; want %d: %+v", len(facets.ExposureValue.Values), len(want), facets.ExposureValue.Values)
+	}
+	for i, w := range want {
+		v := facets.ExposureValue.Values[i]
+		if v.Value != w.value || v.Count != w.count || v.Selected != w.selected {
+			t.Errorf("bucket %d = {%s %d %v}; want {%s %d %v}", i, v.Value, v.Count, v.Selected, w.value, w.count, w.selected)
+		}
+	}
+
+	// Selecting another bucket replaces the range
+	if url := facets.ExposureValue.Values[2].URL; url != "/photos?ev_min=15" {
+		t.Errorf("15plus URL = %q; want /photos?ev_min=15", url)
+	}
+	if url := facets.ExposureValue.Values[1].URL; url != "/photos" {
+		t.Errorf("selected bucket URL = %q; want /photos", url)
+	}
+}
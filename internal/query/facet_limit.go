@@ -0,0 +1,53 @@
+package query
+
+// Values the camera and lens facets show by default. Everything past them
+// is summed into the facet's Other value.
+const (
+	defaultCameraFacetLimit = 50
+	defaultLensFacetLimit   = 30
+)
+
+// facetLimitFor returns how many values the named facet shows, or 0 for
+// all of them when the URL asks for it to be expanded
+func (e *Engine) facetLimitFor(name string, params QueryParams, defaultLimit int) int {
+	if isExpanded(params, name) {
+		return 0
+	}
+	if e.facetLimit > 0 {
+		return e.facetLimit
+	}
+	return defaultLimit
+}
+
+func isExpanded(params QueryParams, name string) bool {
+	for _, f := range params.Expand {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+// truncateFacet keeps the first limit values of a facet sorted by count and
+// sums the rest into facet.Other. Selected values past the limit are kept,
+// so a filter in use never vanishes from its facet.
+func truncateFacet(facet *Facet, limit int) {
+	if limit <= 0 || len(facet.Values) <= limit {
+		return
+	}
+
+	kept := facet.Values[:limit:limit]
+	other := FacetValue{Value: "other", Label: "Other"}
+	for _, v := range facet.Values[limit:] {
+		if v.Selected {
+			kept = append(kept, v)
+			continue
+		}
+		other.Count += v.Count
+		facet.HiddenValues++
+	}
+	facet.Values = kept
+	if facet.HiddenValues > 0 {
+		facet.Other = &other
+	}
+}
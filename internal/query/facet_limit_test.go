@@ -0,0 +1,87 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestLensFacetOtherBucket(t *testing.T) {
+	db := setupTestDBWithSchema(t)
+	defer db.Close()
+
+	// 3 + 2 + 1 + 1 photos across four lenses
+	var photos []TestPhoto
+	for i, lens := range []string{"A", "A", "A", "B", "B", "C", "D"} {
+		photos = append(photos, TestPhoto{
+			FilePath:    fmt.Sprintf("/%d.jpg", i),
+			CameraMake:  "Canon",
+			CameraModel: "EOS R5",
+			LensModel:   lens,
+			DateTaken:   "2024-06-01 09:00:00",
+		})
+	}
+	insertTestPhotos(t, db, photos)
+
+	engine := NewEngine(db)
+	engine.SetFacetLimit(2)
+
+	facets, err := engine.ComputeFacets(QueryParams{Limit: 50})
+	if err != nil {
+		t.Fatalf("ComputeFacets failed: %v", err)
+	}
+	lens := facets.Lens
+	if len(lens.Values) != 2 || lens.Values[0].Value != "A" || lens.Values[1].Value != "B" {
+		t.Fatalf("Lens values = %+v; want A and B", lens.Values)
+	}
+	if lens.Other == nil || lens.Other.Count != 2 || lens.HiddenValues != 2 {
+		t.Fatalf("Other = %+v (%d hidden); want 2 photos in 2 values", lens.Other, lens.HiddenValues)
+	}
+	if !strings.Contains(lens.Other.URL, "expand=lens") {
+		t.Errorf("Other URL = %q; want it to expand the lens facet", lens.Other.URL)
+	}
+	if lens.CollapseURL != "" {
+		t.Errorf("CollapseURL = %q; want none for a truncated facet", lens.CollapseURL)
+	}
+
+	// A selected value past the limit stays listed
+	facets, err = engine.ComputeFacets(QueryParams{LensModel: []string{"D"}, Limit: 50})
+	if err != nil {
+		t.Fatalf("ComputeFacets failed: %v", err)
+	}
+	found := false
+	for _, v := range facets.Lens.Values {
+		if v.Value == "D" && v.Selected {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Selected lens D missing from %+v", facets.Lens.Values)
+	}
+
+	// Following the Other link lists every lens and offers the way back
+	expanded, err := NewURLMapper().ParsePath("/photos", "expand=lens")
+	if err != nil {
+		t.Fatalf("ParsePath failed: %v", err)
+	}
+	facets, err = engine.ComputeFacets(expanded)
+	if err != nil {
+		t.Fatalf("ComputeFacets failed: %v", err)
+	}
+	if len(facets.Lens.Values) != 4 || facets.Lens.Other != nil {
+		t.Errorf("Expanded lens facet = %+v; want all 4 values and no Other", facets.Lens)
+	}
+	if facets.Lens.CollapseURL == "" || strings.Contains(facets.Lens.CollapseURL, "expand=") {
+		t.Errorf("CollapseURL = %q; want the view without expand", facets.Lens.CollapseURL)
+	}
+}
+
+func TestExpandParsing(t *testing.T) {
+	params, err := NewURLMapper().ParsePath("/photos", "expand=camera&expand=camera&expand=bogus")
+	if err != nil {
+		t.Fatalf("ParsePath failed: %v", err)
+	}
+	if len(params.Expand) != 1 || params.Expand[0] != "camera" {
+		t.Errorf("Expand = %v; want [camera]", params.Expand)
+	}
+}
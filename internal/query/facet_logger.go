@@ -1,9 +1,9 @@
 package query
 
 import (
-	"encoding/json"
 	"fmt"
 	"log"
+	"log/slog"
 	"strings"
 )
 
@@ -267,14 +267,8 @@ func LogTransitions(params QueryParams, facets *FacetCollection, totalResults in
 		return
 	}
 
-	// Log in JSON format for easy parsing
-	jsonData, err := json.Marshal(transitionLog)
-	if err != nil {
-		log.Printf("FACET_TRANSITIONS_ERROR: Failed to marshal transition log: %v", err)
-		return
-	}
-
-	log.Printf("FACET_TRANSITIONS: %s", string(jsonData))
+	// Emitted as one attribute so JSON log handlers nest it as an object
+	slog.Info("FACET_TRANSITIONS", "transitions", transitionLog)
 }
 
 // LogTransitionsSummary logs a compact summary of available transitions
@@ -285,18 +279,12 @@ func LogTransitionsSummary(params QueryParams, facets *FacetCollection, totalRes
 	}
 
 	// Build compact summary
-	var parts []string
-
-	// Current state
-	stateDesc := buildStateDescription(transitionLog.CurrentState)
-	parts = append(parts, "state="+stateDesc)
-
-	// Results
-	parts = append(parts, fmt.Sprintf("results=%d", totalResults))
-
-	// Enabled vs disabled transitions
-	parts = append(parts, fmt.Sprintf("enabled=%d", transitionLog.EnabledCount))
-	parts = append(parts, fmt.Sprintf("disabled=%d", transitionLog.DisabledCount))
+	attrs := []any{
+		"state", buildStateDescription(transitionLog.CurrentState),
+		"results", totalResults,
+		"enabled", transitionLog.EnabledCount,
+		"disabled", transitionLog.DisabledCount,
+	}
 
 	// Log disabled transitions (these are the critical ones - should not be clickable)
 	var disabledTransitions []string
@@ -307,10 +295,10 @@ func LogTransitionsSummary(params QueryParams, facets *FacetCollection, totalRes
 	}
 
 	if len(disabledTransitions) > 0 {
-		parts = append(parts, "disabled_facets=["+strings.Join(disabledTransitions, ",")+"]")
+		attrs = append(attrs, "disabled_facets", disabledTransitions)
 	}
 
-	log.Printf("FACET_STATE: %s", strings.Join(parts, " "))
+	slog.Info("FACET_STATE", attrs...)
 }
 
 // buildStateDescription creates a compact description of the current state
@@ -435,6 +423,23 @@ func LogSuspiciousZeroResults(params QueryParams, facets *FacetCollection) {
 	}
 }
 
+// ZeroCountFacetValues lists the values of the given facets that match no
+// photos, the ones the UI renders as disabled, as "facet=value" pairs in order
+func ZeroCountFacetValues(facets ...*Facet) []string {
+	var disabled []string
+	for _, facet := range facets {
+		if facet == nil {
+			continue
+		}
+		for _, v := range facet.Values {
+			if v.Count == 0 {
+				disabled = append(disabled, facet.Name+"="+v.Value)
+			}
+		}
+	}
+	return disabled
+}
+
 // detectTransition identifies which facet changed between two states
 func detectTransition(prev, current QueryParams) (facetType string, value string) {
 	// Check Year
@@ -0,0 +1,57 @@
+package query
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/adewale/olsen/internal/database"
+	"github.com/adewale/olsen/pkg/models"
+)
+
+func TestComputeFacet(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "facet.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	for _, p := range []*models.PhotoMetadata{
+		{FilePath: "/a.jpg", FileHash: "a", CameraMake: "Canon", CameraModel: "R5"},
+		{FilePath: "/b.jpg", FileHash: "b", CameraMake: "Canon", CameraModel: "R5"},
+		{FilePath: "/c.jpg", FileHash: "c", CameraMake: "Nikon", CameraModel: "Z6"},
+	} {
+		if err := db.InsertPhoto(p); err != nil {
+			t.Fatalf("InsertPhoto failed: %v", err)
+		}
+	}
+
+	engine := NewEngine(db.DB)
+	params := QueryParams{Limit: 50}
+	facet, ok, err := engine.ComputeFacet("camera", params)
+	if !ok || err != nil {
+		t.Fatalf("ComputeFacet(camera) = ok %v, err %v", ok, err)
+	}
+	all, err := engine.ComputeFacets(params)
+	if err != nil {
+		t.Fatalf("ComputeFacets failed: %v", err)
+	}
+	if len(facet.Values) != len(all.Camera.Values) {
+		t.Fatalf("camera facet has %d values; ComputeFacets has %d", len(facet.Values), len(all.Camera.Values))
+	}
+	for i, v := range facet.Values {
+		want := all.Camera.Values[i]
+		if v.Value != want.Value || v.Count != want.Count || v.URL != want.URL {
+			t.Errorf("value %d = %+v; want %+v", i, v, want)
+		}
+		if v.URL == "" {
+			t.Errorf("value %s has no URL", v.Value)
+		}
+	}
+
+	if _, ok, _ := engine.ComputeFacet("colour", params); !ok {
+		t.Error("colour should be accepted as an alias of color")
+	}
+	if _, ok, _ := engine.ComputeFacet("nonsense", params); ok {
+		t.Error("unknown facet name was accepted")
+	}
+}
@@ -33,21 +33,74 @@ func (b *FacetURLBuilder) BuildURLsForFacets(facets *FacetCollection, baseParams
 	if facets.Lens != nil {
 		b.buildLensURLs(facets.Lens, baseParams)
 	}
+	if facets.CameraSerial != nil {
+		b.buildCameraSerialURLs(facets.CameraSerial, baseParams)
+	}
+	for _, facet := range []*Facet{facets.Camera, facets.Lens} {
+		if facet != nil {
+			b.buildExpandURLs(facet, baseParams)
+		}
+	}
 	if facets.TimeOfDay != nil {
 		b.buildTimeOfDayURLs(facets.TimeOfDay, baseParams)
 	}
 	if facets.Season != nil {
 		b.buildSeasonURLs(facets.Season, baseParams)
 	}
+	if facets.Weekday != nil {
+		b.buildWeekdayURLs(facets.Weekday, baseParams)
+	}
 	if facets.FocalCategory != nil {
 		b.buildFocalCategoryURLs(facets.FocalCategory, baseParams)
 	}
+	if facets.Setup != nil {
+		b.buildSetupURLs(facets.Setup, baseParams)
+	}
 	if facets.ShootingCondition != nil {
 		b.buildShootingConditionURLs(facets.ShootingCondition, baseParams)
 	}
 	if facets.InBurst != nil {
 		b.buildBurstURLs(facets.InBurst, baseParams)
 	}
+	if facets.InBracket != nil {
+		b.buildBracketURLs(facets.InBracket, baseParams)
+	}
+	if facets.HasGPS != nil {
+		b.buildHasGPSURLs(facets.HasGPS, baseParams)
+	}
+	if facets.Edited != nil {
+		b.buildEditedURLs(facets.Edited, baseParams)
+	}
+	if facets.Rating != nil {
+		b.buildRatingURLs(facets.Rating, baseParams)
+	}
+	if facets.FileFormat != nil {
+		b.buildFileFormatURLs(facets.FileFormat, baseParams)
+	}
+	if facets.MediaType != nil {
+		b.buildMediaTypeURLs(facets.MediaType, baseParams)
+	}
+	if facets.ColourSpace != nil {
+		b.buildColourSpaceURLs(facets.ColourSpace, baseParams)
+	}
+	if facets.ExposureValue != nil {
+		b.buildExposureValueURLs(facets.ExposureValue, baseParams)
+	}
+	if facets.ShutterSpeed != nil {
+		b.buildShutterSpeedURLs(facets.ShutterSpeed, baseParams)
+	}
+	if facets.FileSize != nil {
+		b.buildFileSizeURLs(facets.FileSize, baseParams)
+	}
+	if facets.HasColours != nil {
+		b.buildHasColoursURLs(facets.HasColours, baseParams)
+	}
+	if facets.Palette != nil {
+		b.buildPaletteURLs(facets.Palette, baseParams)
+	}
+	if facets.BurstSize != nil {
+		b.buildBurstSizeURLs(facets.BurstSize, baseParams)
+	}
 }
 
 func (b *FacetURLBuilder) buildColourURLs(facet *Facet, baseParams QueryParams) {
@@ -132,6 +185,35 @@ func (b *FacetURLBuilder) buildLensURLs(facet *Facet, baseParams QueryParams) {
 	}
 }
 
+// buildExpandURLs links a truncated facet's Other value to the same view
+// with the facet listed in full, and an expanded facet back to its top values
+func (b *FacetURLBuilder) buildExpandURLs(facet *Facet, baseParams QueryParams) {
+	if facet.Other != nil {
+		p := baseParams
+		p.Offset = 0
+		p.Expand = append(append([]string(nil), p.Expand...), facet.Name)
+		facet.Other.URL = b.mapper.BuildFullURL(p)
+	}
+	if isExpanded(baseParams, facet.Name) {
+		p := baseParams
+		p.Offset = 0
+		p.Expand = removeFromSlice(p.Expand, facet.Name)
+		facet.CollapseURL = b.mapper.BuildFullURL(p)
+	}
+}
+
+func (b *FacetURLBuilder) buildCameraSerialURLs(facet *Facet, baseParams QueryParams) {
+	for i := range facet.Values {
+		p := baseParams
+		if facet.Values[i].Selected {
+			p.CameraSerial = removeFromSlice(p.CameraSerial, facet.Values[i].Value)
+		} else {
+			p.CameraSerial = append(p.CameraSerial, facet.Values[i].Value)
+		}
+		facet.Values[i].URL = b.mapper.BuildFullURL(p)
+	}
+}
+
 func (b *FacetURLBuilder) buildTimeOfDayURLs(facet *Facet, baseParams QueryParams) {
 	for i := range facet.Values {
 		p := baseParams
@@ -158,6 +240,18 @@ func (b *FacetURLBuilder) buildSeasonURLs(facet *Facet, baseParams QueryParams)
 	}
 }
 
+func (b *FacetURLBuilder) buildWeekdayURLs(facet *Facet, baseParams QueryParams) {
+	for i := range facet.Values {
+		p := baseParams
+		if facet.Values[i].Selected {
+			p.Weekday = removeFromSlice(p.Weekday, facet.Values[i].Value)
+		} else {
+			p.Weekday = append(p.Weekday, facet.Values[i].Value)
+		}
+		facet.Values[i].URL = b.mapper.BuildFullURL(p)
+	}
+}
+
 func (b *FacetURLBuilder) buildFocalCategoryURLs(facet *Facet, baseParams QueryParams) {
 	for i := range facet.Values {
 		p := baseParams
@@ -170,6 +264,18 @@ func (b *FacetURLBuilder) buildFocalCategoryURLs(facet *Facet, baseParams QueryP
 	}
 }
 
+func (b *FacetURLBuilder) buildSetupURLs(facet *Facet, baseParams QueryParams) {
+	for i := range facet.Values {
+		p := baseParams
+		if facet.Values[i].Selected {
+			p.Setup = removeFromSlice(p.Setup, facet.Values[i].Value)
+		} else {
+			p.Setup = append(p.Setup, facet.Values[i].Value)
+		}
+		facet.Values[i].URL = b.mapper.BuildFullURL(p)
+	}
+}
+
 func (b *FacetURLBuilder) buildShootingConditionURLs(facet *Facet, baseParams QueryParams) {
 	for i := range facet.Values {
 		p := baseParams
@@ -182,6 +288,122 @@ func (b *FacetURLBuilder) buildShootingConditionURLs(facet *Facet, baseParams Qu
 	}
 }
 
+func (b *FacetURLBuilder) buildFileFormatURLs(facet *Facet, baseParams QueryParams) {
+	for i := range facet.Values {
+		p := baseParams
+		if facet.Values[i].Selected {
+			p.FileFormat = removeFromSlice(p.FileFormat, facet.Values[i].Value)
+		} else {
+			p.FileFormat = append(p.FileFormat, facet.Values[i].Value)
+		}
+		facet.Values[i].URL = b.mapper.BuildFullURL(p)
+	}
+}
+
+func (b *FacetURLBuilder) buildMediaTypeURLs(facet *Facet, baseParams QueryParams) {
+	for i := range facet.Values {
+		p := baseParams
+		if facet.Values[i].Selected {
+			p.MediaType = removeFromSlice(p.MediaType, facet.Values[i].Value)
+		} else {
+			p.MediaType = append(p.MediaType, facet.Values[i].Value)
+		}
+		facet.Values[i].URL = b.mapper.BuildFullURL(p)
+	}
+}
+
+func (b *FacetURLBuilder) buildColourSpaceURLs(facet *Facet, baseParams QueryParams) {
+	for i := range facet.Values {
+		p := baseParams
+		if facet.Values[i].Selected {
+			p.ColourSpace = removeFromSlice(p.ColourSpace, facet.Values[i].Value)
+		} else {
+			p.ColourSpace = append(p.ColourSpace, facet.Values[i].Value)
+		}
+		facet.Values[i].URL = b.mapper.BuildFullURL(p)
+	}
+}
+
+func (b *FacetURLBuilder) buildExposureValueURLs(facet *Facet, baseParams QueryParams) {
+	for i := range facet.Values {
+		p := baseParams
+		if facet.Values[i].Selected {
+			// Already selected - remove EV range
+			p.EVMin = nil
+			p.EVMax = nil
+		} else {
+			// EV buckets are exclusive: replace any existing range
+			p.EVMin, p.EVMax = EVBucketRange(facet.Values[i].Value)
+		}
+		facet.Values[i].URL = b.mapper.BuildFullURL(p)
+	}
+}
+
+func (b *FacetURLBuilder) buildShutterSpeedURLs(facet *Facet, baseParams QueryParams) {
+	for i := range facet.Values {
+		p := baseParams
+		if facet.Values[i].Selected {
+			p.ShutterMin = nil
+			p.ShutterMax = nil
+		} else {
+			p.ShutterMin, p.ShutterMax = ShutterBucketRange(facet.Values[i].Value)
+		}
+		facet.Values[i].URL = b.mapper.BuildFullURL(p)
+	}
+}
+
+func (b *FacetURLBuilder) buildFileSizeURLs(facet *Facet, baseParams QueryParams) {
+	for i := range facet.Values {
+		p := baseParams
+		if facet.Values[i].Selected {
+			p.FileSizeMin = nil
+			p.FileSizeMax = nil
+		} else {
+			p.FileSizeMin, p.FileSizeMax = FileSizeBucketRange(facet.Values[i].Value)
+		}
+		facet.Values[i].URL = b.mapper.BuildFullURL(p)
+	}
+}
+
+func (b *FacetURLBuilder) buildHasColoursURLs(facet *Facet, baseParams QueryParams) {
+	for i := range facet.Values {
+		p := baseParams
+		if facet.Values[i].Selected {
+			p.HasColours = nil
+		} else {
+			hasColours := facet.Values[i].Value == "yes"
+			p.HasColours = &hasColours
+		}
+		facet.Values[i].URL = b.mapper.BuildFullURL(p)
+	}
+}
+
+func (b *FacetURLBuilder) buildPaletteURLs(facet *Facet, baseParams QueryParams) {
+	for i := range facet.Values {
+		p := baseParams
+		if facet.Values[i].Selected {
+			p.ColourCountMin = nil
+			p.ColourCountMax = nil
+		} else {
+			p.ColourCountMin, p.ColourCountMax = PaletteBucketRange(facet.Values[i].Value)
+		}
+		facet.Values[i].URL = b.mapper.BuildFullURL(p)
+	}
+}
+
+func (b *FacetURLBuilder) buildBurstSizeURLs(facet *Facet, baseParams QueryParams) {
+	for i := range facet.Values {
+		p := baseParams
+		if facet.Values[i].Selected {
+			p.BurstSizeMin = nil
+			p.BurstSizeMax = nil
+		} else {
+			p.BurstSizeMin, p.BurstSizeMax = BurstSizeBucketRange(facet.Values[i].Value)
+		}
+		facet.Values[i].URL = b.mapper.BuildFullURL(p)
+	}
+}
+
 func (b *FacetURLBuilder) buildBurstURLs(facet *Facet, baseParams QueryParams) {
 	for i := range facet.Values {
 		p := baseParams
@@ -196,3 +418,55 @@ func (b *FacetURLBuilder) buildBurstURLs(facet *Facet, baseParams QueryParams) {
 		facet.Values[i].URL = b.mapper.BuildFullURL(p)
 	}
 }
+
+func (b *FacetURLBuilder) buildBracketURLs(facet *Facet, baseParams QueryParams) {
+	for i := range facet.Values {
+		p := baseParams
+		if facet.Values[i].Selected {
+			p.InBracket = nil
+		} else {
+			inBracket := facet.Values[i].Value == "yes"
+			p.InBracket = &inBracket
+		}
+		facet.Values[i].URL = b.mapper.BuildFullURL(p)
+	}
+}
+
+func (b *FacetURLBuilder) buildHasGPSURLs(facet *Facet, baseParams QueryParams) {
+	for i := range facet.Values {
+		p := baseParams
+		if facet.Values[i].Selected {
+			p.HasGPS = nil
+		} else {
+			hasGPS := facet.Values[i].Value == "yes"
+			p.HasGPS = &hasGPS
+		}
+		facet.Values[i].URL = b.mapper.BuildFullURL(p)
+	}
+}
+
+func (b *FacetURLBuilder) buildRatingURLs(facet *Facet, baseParams QueryParams) {
+	for i := range facet.Values {
+		p := baseParams
+		if facet.Values[i].Selected {
+			p.RatingMin = nil
+			p.RatingMax = nil
+		} else {
+			p.RatingMin, p.RatingMax = RatingBucketRange(facet.Values[i].Value)
+		}
+		facet.Values[i].URL = b.mapper.BuildFullURL(p)
+	}
+}
+
+func (b *FacetURLBuilder) buildEditedURLs(facet *Facet, baseParams QueryParams) {
+	for i := range facet.Values {
+		p := baseParams
+		if facet.Values[i].Selected {
+			p.Edited = nil
+		} else {
+			edited := facet.Values[i].Value == "yes"
+			p.Edited = &edited
+		}
+		facet.Values[i].URL = b.mapper.BuildFullURL(p)
+	}
+}
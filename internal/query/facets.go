@@ -2,72 +2,155 @@ package query
 
 import (
 	"fmt"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
 )
 
+// maxFacetWorkers bounds how many facet queries ComputeFacets runs at once.
+// *sql.DB is safe for concurrent use and SQLite in WAL mode serves readers in
+// parallel, each on its own connection. The queries are CPU-bound, so fewer
+// workers run when fewer CPUs are available.
+const maxFacetWorkers = 4
+
+// facetOrder lists the facets ComputeFacets fills, with the wording used in
+// their errors
+var facetOrder = []struct{ name, label string }{
+	{"camera", "camera"},
+	{"lens", "lens"},
+	{"camera_serial", "camera serial"},
+	{"year", "year"},
+	{"month", "month"},
+	{"time_of_day", "time of day"},
+	{"season", "season"},
+	{"weekday", "weekday"},
+	{"focal_category", "focal category"},
+	{"setup", "setup"},
+	{"shooting_condition", "shooting condition"},
+	{"in_burst", "burst"},
+	{"burst_size", "burst size"},
+	{"in_bracket", "bracket"},
+	{"has_gps", "geotagged"},
+	{"edited", "editing"},
+	{"rating", "rating"},
+	{"media_type", "media type"},
+	{"file_format", "file format"},
+	{"color_space", "colour space"},
+	{"exposure_value", "exposure value"},
+	{"shutter_speed", "shutter speed"},
+	{"file_size", "file size"},
+	{"has_colours", "colour data"},
+	{"palette", "palette"},
+	{"color", "colour"},
+}
+
 // ComputeFacets calculates facet counts based on current query parameters
-// Facets respect active filters but exclude their own dimension
+// Facets respect active filters but exclude their own dimension. The facets
+// are independent queries and run concurrently; URLs are added once all of
+// them are in, and the first failing facet in facetOrder is reported.
 func (e *Engine) ComputeFacets(params QueryParams) (*FacetCollection, error) {
-	facets := &FacetCollection{}
-
-	// Compute each facet dimension
-	var err error
-
-	facets.Camera, err = e.computeCameraFacet(params)
-	if err != nil {
-		return nil, fmt.Errorf("failed to compute camera facet: %w", err)
+	results := make([]*Facet, len(facetOrder))
+	errs := make([]error, len(facetOrder))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, min(maxFacetWorkers, runtime.GOMAXPROCS(0)))
+	for i, f := range facetOrder {
+		dim := facetDimensions[f.name]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i], errs[i] = dim.compute(e, params)
+		}()
 	}
+	wg.Wait()
 
-	facets.Lens, err = e.computeLensFacet(params)
-	if err != nil {
-		return nil, fmt.Errorf("failed to compute lens facet: %w", err)
-	}
-
-	facets.Year, err = e.computeYearFacet(params)
-	if err != nil {
-		return nil, fmt.Errorf("failed to compute year facet: %w", err)
+	facets := &FacetCollection{}
+	for i, f := range facetOrder {
+		if errs[i] != nil {
+			return nil, fmt.Errorf("failed to compute %s facet: %w", f.label, errs[i])
+		}
+		facetDimensions[f.name].set(facets, results[i])
 	}
 
-	facets.Month, err = e.computeMonthFacet(params)
-	if err != nil {
-		return nil, fmt.Errorf("failed to compute month facet: %w", err)
-	}
+	// Add URLs to all facet values
+	builder := NewFacetURLBuilder(e.urlMapper())
+	builder.BuildURLsForFacets(facets, params)
 
-	facets.TimeOfDay, err = e.computeTimeOfDayFacet(params)
-	if err != nil {
-		return nil, fmt.Errorf("failed to compute time of day facet: %w", err)
-	}
+	return facets, nil
+}
 
-	facets.Season, err = e.computeSeasonFacet(params)
-	if err != nil {
-		return nil, fmt.Errorf("failed to compute season facet: %w", err)
-	}
+// facetDimension computes one facet and stores it in its FacetCollection
+// field, so the collection's URL builder can fill in its URLs
+type facetDimension struct {
+	compute func(*Engine, QueryParams) (*Facet, error)
+	set     func(*FacetCollection, *Facet)
+}
 
-	facets.FocalCategory, err = e.computeFocalCategoryFacet(params)
-	if err != nil {
-		return nil, fmt.Errorf("failed to compute focal category facet: %w", err)
-	}
+// facetDimensions maps facet names, as in Facet.Name, to their dimension.
+// "colour" and "colour_space" are accepted as well as the URL spellings.
+var facetDimensions = map[string]facetDimension{
+	"camera":             {(*Engine).computeCameraFacet, func(c *FacetCollection, f *Facet) { c.Camera = f }},
+	"lens":               {(*Engine).computeLensFacet, func(c *FacetCollection, f *Facet) { c.Lens = f }},
+	"camera_serial":      {(*Engine).computeCameraSerialFacet, func(c *FacetCollection, f *Facet) { c.CameraSerial = f }},
+	"year":               {(*Engine).computeYearFacet, func(c *FacetCollection, f *Facet) { c.Year = f }},
+	"month":              {(*Engine).computeMonthFacet, func(c *FacetCollection, f *Facet) { c.Month = f }},
+	"time_of_day":        {(*Engine).computeTimeOfDayFacet, func(c *FacetCollection, f *Facet) { c.TimeOfDay = f }},
+	"season":             {(*Engine).computeSeasonFacet, func(c *FacetCollection, f *Facet) { c.Season = f }},
+	"weekday":            {(*Engine).computeWeekdayFacet, func(c *FacetCollection, f *Facet) { c.Weekday = f }},
+	"focal_category":     {(*Engine).computeFocalCategoryFacet, func(c *FacetCollection, f *Facet) { c.FocalCategory = f }},
+	"setup":              {(*Engine).computeSetupFacet, func(c *FacetCollection, f *Facet) { c.Setup = f }},
+	"shooting_condition": {(*Engine).computeShootingConditionFacet, func(c *FacetCollection, f *Facet) { c.ShootingCondition = f }},
+	"in_burst":           {(*Engine).computeBurstFacet, func(c *FacetCollection, f *Facet) { c.InBurst = f }},
+	"burst_size":         {(*Engine).computeBurstSizeFacet, func(c *FacetCollection, f *Facet) { c.BurstSize = f }},
+	"in_bracket":         {(*Engine).computeBracketFacet, func(c *FacetCollection, f *Facet) { c.InBracket = f }},
+	"has_gps":            {(*Engine).computeHasGPSFacet, func(c *FacetCollection, f *Facet) { c.HasGPS = f }},
+	"edited":             {(*Engine).computeEditedFacet, func(c *FacetCollection, f *Facet) { c.Edited = f }},
+	"rating":             {(*Engine).computeRatingFacet, func(c *FacetCollection, f *Facet) { c.Rating = f }},
+	"media_type":         {(*Engine).computeMediaTypeFacet, func(c *FacetCollection, f *Facet) { c.MediaType = f }},
+	"file_format":        {(*Engine).computeFileFormatFacet, func(c *FacetCollection, f *Facet) { c.FileFormat = f }},
+	"color_space":        {(*Engine).computeColourSpaceFacet, func(c *FacetCollection, f *Facet) { c.ColourSpace = f }},
+	"colour_space":       {(*Engine).computeColourSpaceFacet, func(c *FacetCollection, f *Facet) { c.ColourSpace = f }},
+	"exposure_value":     {(*Engine).computeExposureValueFacet, func(c *FacetCollection, f *Facet) { c.ExposureValue = f }},
+	"shutter_speed":      {(*Engine).computeShutterSpeedFacet, func(c *FacetCollection, f *Facet) { c.ShutterSpeed = f }},
+	"file_size":          {(*Engine).computeFileSizeFacet, func(c *FacetCollection, f *Facet) { c.FileSize = f }},
+	"has_colours":        {(*Engine).computeHasColoursFacet, func(c *FacetCollection, f *Facet) { c.HasColours = f }},
+	"palette":            {(*Engine).computePaletteFacet, func(c *FacetCollection, f *Facet) { c.Palette = f }},
+	"color":              {(*Engine).computeColourFacet, func(c *FacetCollection, f *Facet) { c.ColourName = f }},
+	"colour":             {(*Engine).computeColourFacet, func(c *FacetCollection, f *Facet) { c.ColourName = f }},
+}
 
-	facets.ShootingCondition, err = e.computeShootingConditionFacet(params)
-	if err != nil {
-		return nil, fmt.Errorf("failed to compute shooting condition facet: %w", err)
+// FacetNames returns the names ComputeFacet accepts, sorted
+func FacetNames() []string {
+	names := make([]string, 0, len(facetDimensions))
+	for name := range facetDimensions {
+		names = append(names, name)
 	}
+	sort.Strings(names)
+	return names
+}
 
-	facets.InBurst, err = e.computeBurstFacet(params)
-	if err != nil {
-		return nil, fmt.Errorf("failed to compute burst facet: %w", err)
+// ComputeFacet calculates a single facet dimension, with URLs, for widgets
+// that need one facet without the cost of ComputeFacets. ok is false for an
+// unknown name.
+func (e *Engine) ComputeFacet(name string, params QueryParams) (facet *Facet, ok bool, err error) {
+	dim, ok := facetDimensions[name]
+	if !ok {
+		return nil, false, nil
 	}
-
-	facets.ColourName, err = e.computeColourFacet(params)
+	facet, err = dim.compute(e, params)
 	if err != nil {
-		return nil, fmt.Errorf("failed to compute colour facet: %w", err)
+		return nil, true, fmt.Errorf("failed to compute %s facet: %w", name, err)
 	}
 
-	// Add URLs to all facet values
-	builder := NewFacetURLBuilder(NewURLMapper())
+	facets := &FacetCollection{}
+	dim.set(facets, facet)
+	builder := NewFacetURLBuilder(e.urlMapper())
 	builder.BuildURLsForFacets(facets, params)
 
-	return facets, nil
+	return facet, true, nil
 }
 
 // computeCameraFacet computes camera make/model facet
@@ -96,8 +179,7 @@ func (e *Engine) computeCameraFacet(params QueryParams) (*Facet, error) {
 		FROM photos p
 		%s
 		GROUP BY camera_make, camera_model
-		ORDER BY count DESC
-		LIMIT 50
+		ORDER BY count DESC, camera_make, camera_model
 	`, whereClause)
 
 	rows, err := e.db.Query(query, args...)
@@ -138,11 +220,13 @@ func (e *Engine) computeCameraFacet(params QueryParams) (*Facet, error) {
 		})
 	}
 
-	return &Facet{
+	facet := &Facet{
 		Name:   "camera",
 		Label:  "Camera",
 		Values: values,
-	}, nil
+	}
+	truncateFacet(facet, e.facetLimitFor(facet.Name, params, defaultCameraFacetLimit))
+	return facet, rows.Err()
 }
 
 // computeLensFacet computes lens facet
@@ -169,8 +253,7 @@ func (e *Engine) computeLensFacet(params QueryParams) (*Facet, error) {
 		FROM photos p
 		%s
 		GROUP BY lens_model
-		ORDER BY count DESC
-		LIMIT 30
+		ORDER BY count DESC, lens_model
 	`, whereClause)
 
 	rows, err := e.db.Query(query, args...)
@@ -203,13 +286,84 @@ func (e *Engine) computeLensFacet(params QueryParams) (*Facet, error) {
 		})
 	}
 
-	return &Facet{
+	facet := &Facet{
 		Name:   "lens",
 		Label:  "Lens",
 		Values: values,
+	}
+	truncateFacet(facet, e.facetLimitFor(facet.Name, params, defaultLensFacetLimit))
+	return facet, rows.Err()
+}
+
+// computeCameraSerialFacet computes the camera body facet. Values are serial
+// tokens; labels show the model and only the last few characters of the
+// serial, which is enough to tell two bodies apart.
+func (e *Engine) computeCameraSerialFacet(params QueryParams) (*Facet, error) {
+	paramsWithoutSerial := params
+	paramsWithoutSerial.CameraSerial = nil
+
+	where, args := e.buildWhereClause(paramsWithoutSerial)
+	where = append(where, "camera_serial_token IS NOT NULL AND camera_serial_token != ''")
+
+	query := fmt.Sprintf(`
+		SELECT camera_serial_token, COALESCE(MIN(camera_make), ''), COALESCE(MIN(camera_model), ''),
+		       COALESCE(MIN(camera_serial), ''), COUNT(*) as count
+		FROM photos p
+		WHERE %s
+		GROUP BY camera_serial_token
+		ORDER BY count DESC, camera_serial_token
+	`, strings.Join(where, " AND "))
+
+	rows, err := e.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := []FacetValue{}
+	for rows.Next() {
+		var token, cameraMake, cameraModel, serial string
+		var count int
+		if err := rows.Scan(&token, &cameraMake, &cameraModel, &serial, &count); err != nil {
+			return nil, err
+		}
+
+		selected := false
+		for _, t := range params.CameraSerial {
+			if token == t {
+				selected = true
+				break
+			}
+		}
+
+		values = append(values, FacetValue{
+			Value:    token,
+			Label:    cameraBodyLabel(cameraMake, cameraModel, serial),
+			Count:    count,
+			Selected: selected,
+		})
+	}
+
+	return &Facet{
+		Name:   "camera_serial",
+		Label:  "Camera body",
+		Values: values,
 	}, nil
 }
 
+// cameraBodyLabel formats a body as "Make Model …1234", masking all but the
+// last four characters of the serial
+func cameraBodyLabel(cameraMake, cameraModel, serial string) string {
+	label := strings.TrimSpace(cameraMake + " " + cameraModel)
+	if label == "" {
+		label = "Unknown camera"
+	}
+	if len(serial) > 4 {
+		serial = serial[len(serial)-4:]
+	}
+	return label + " …" + serial
+}
+
 // computeYearFacet computes year facet
 func (e *Engine) computeYearFacet(params QueryParams) (*Facet, error) {
 	paramsWithoutYear := params
@@ -229,7 +383,7 @@ func (e *Engine) computeYearFacet(params QueryParams) (*Facet, error) {
 	// Query for years (including Unknown for NULL dates)
 	query := fmt.Sprintf(`
 		SELECT
-			COALESCE(strftime('%%Y', date_taken), 'unknown') as year,
+			COALESCE(strftime('%%Y', %s), 'unknown') as year,
 			COUNT(*) as count
 		FROM photos p
 		%s
@@ -240,7 +394,7 @@ func (e *Engine) computeYearFacet(params QueryParams) (*Facet, error) {
 				ELSE 0
 			END,
 			year DESC
-	`, whereClause)
+	`, e.dateTaken(), whereClause)
 
 	rows, err := e.db.Query(query, args...)
 	if err != nil {
@@ -313,13 +467,13 @@ func (e *Engine) computeMonthFacet(params QueryParams) (*Facet, error) {
 	// Query for months within the selected year
 	query := fmt.Sprintf(`
 		SELECT
-			strftime('%%m', date_taken) as month,
+			strftime('%%m', %s) as month,
 			COUNT(*) as count
 		FROM photos p
 		%s
 		GROUP BY month
 		ORDER BY month ASC
-	`, whereClause)
+	`, e.dateTaken(), whereClause)
 
 	rows, err := e.db.Query(query, args...)
 	if err != nil {
@@ -389,14 +543,8 @@ func (e *Engine) computeTimeOfDayFacet(params QueryParams) (*Facet, error) {
 		FROM photos p
 		%s
 		GROUP BY time_of_day
-		ORDER BY
-			CASE time_of_day
-				WHEN 'morning' THEN 1
-				WHEN 'afternoon' THEN 2
-				WHEN 'evening' THEN 3
-				WHEN 'night' THEN 4
-			END
-	`, whereClause)
+		ORDER BY %s
+	`, whereClause, timeOfDayOrder("time_of_day"))
 
 	rows, err := e.db.Query(query, args...)
 	if err != nil {
@@ -420,12 +568,9 @@ func (e *Engine) computeTimeOfDayFacet(params QueryParams) (*Facet, error) {
 			}
 		}
 
-		// Capitalize label
-		label := strings.Title(tod)
-
 		values = append(values, FacetValue{
 			Value:    tod,
-			Label:    label,
+			Label:    TimeOfDayLabel(tod),
 			Count:    count,
 			Selected: selected,
 		})
@@ -652,6 +797,183 @@ func (e *Engine) computeShootingConditionFacet(params QueryParams) (*Facet, erro
 	}, nil
 }
 
+// computeFileFormatFacet computes file format facet
+func (e *Engine) computeFileFormatFacet(params QueryParams) (*Facet, error) {
+	paramsWithoutFF := params
+	paramsWithoutFF.FileFormat = nil
+
+	where, args := e.buildWhereClause(paramsWithoutFF)
+	where = append(where, "file_format IS NOT NULL AND file_format != ''")
+
+	query := fmt.Sprintf(`
+		SELECT file_format, COUNT(*) as count
+		FROM photos p
+		WHERE %s
+		GROUP BY file_format
+		ORDER BY count DESC, file_format
+	`, strings.Join(where, " AND "))
+
+	rows, err := e.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := []FacetValue{}
+	for rows.Next() {
+		var ff string
+		var count int
+		if err := rows.Scan(&ff, &count); err != nil {
+			return nil, err
+		}
+
+		selected := false
+		for _, f := range params.FileFormat {
+			if ff == f {
+				selected = true
+				break
+			}
+		}
+
+		values = append(values, FacetValue{
+			Value:    ff,
+			Label:    strings.ToUpper(ff),
+			Count:    count,
+			Selected: selected,
+		})
+	}
+
+	return &Facet{
+		Name:   "file_format",
+		Label:  "Format",
+		Values: values,
+	}, nil
+}
+
+// mediaTypeLabels are the facet labels of the media_type values
+var mediaTypeLabels = map[string]string{
+	"photo": "Photos",
+	"video": "Videos",
+}
+
+// computeMediaTypeFacet splits photos from videos (index -include-video).
+// Rows indexed before the media_type column existed count as photos.
+func (e *Engine) computeMediaTypeFacet(params QueryParams) (*Facet, error) {
+	paramsWithoutMT := params
+	paramsWithoutMT.MediaType = nil
+
+	where, args := e.buildWhereClause(paramsWithoutMT)
+	whereClause := ""
+	if len(where) > 0 {
+		whereClause = "WHERE " + strings.Join(where, " AND ")
+	}
+
+	query := fmt.Sprintf(`
+		SELECT COALESCE(p.media_type, 'photo') AS media_type, COUNT(*) as count
+		FROM photos p
+		%s
+		GROUP BY 1
+		ORDER BY count DESC, media_type
+	`, whereClause)
+
+	rows, err := e.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := []FacetValue{}
+	for rows.Next() {
+		var mt string
+		var count int
+		if err := rows.Scan(&mt, &count); err != nil {
+			return nil, err
+		}
+
+		selected := false
+		for _, m := range params.MediaType {
+			if mt == m {
+				selected = true
+				break
+			}
+		}
+
+		label := mediaTypeLabels[mt]
+		if label == "" {
+			label = mt
+		}
+		values = append(values, FacetValue{
+			Value:    mt,
+			Label:    label,
+			Count:    count,
+			Selected: selected,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &Facet{
+		Name:   "media_type",
+		Label:  "Media Type",
+		Values: values,
+	}, nil
+}
+
+// computeColourSpaceFacet computes the colour space facet from the embedded
+// ICC profile or EXIF ColorSpace tag recorded at index time
+func (e *Engine) computeColourSpaceFacet(params QueryParams) (*Facet, error) {
+	paramsWithoutCS := params
+	paramsWithoutCS.ColourSpace = nil
+
+	where, args := e.buildWhereClause(paramsWithoutCS)
+	where = append(where, "p.color_space IS NOT NULL AND p.color_space != ''")
+
+	query := fmt.Sprintf(`
+		SELECT p.color_space, COUNT(*) as count
+		FROM photos p
+		WHERE %s
+		GROUP BY p.color_space
+		ORDER BY count DESC, p.color_space
+	`, strings.Join(where, " AND "))
+
+	rows, err := e.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := []FacetValue{}
+	for rows.Next() {
+		var cs string
+		var count int
+		if err := rows.Scan(&cs, &count); err != nil {
+			return nil, err
+		}
+
+		selected := false
+		for _, c := range params.ColourSpace {
+			if cs == c {
+				selected = true
+				break
+			}
+		}
+
+		values = append(values, FacetValue{
+			Value:    cs,
+			Label:    cs,
+			Count:    count,
+			Selected: selected,
+		})
+	}
+
+	return &Facet{
+		Name:   "color_space",
+		Label:  "Colour Space",
+		Values: values,
+	}, rows.Err()
+}
+
 // computeBurstFacet computes burst facet
 func (e *Engine) computeBurstFacet(params QueryParams) (*Facet, error) {
 	paramsWithoutBurst := params
@@ -716,10 +1038,245 @@ func (e *Engine) computeBurstFacet(params QueryParams) (*Facet, error) {
 	}, nil
 }
 
+// computeBracketFacet counts photos in and out of exposure brackets, which
+// olsen analyze detects separately from bursts
+func (e *Engine) computeBracketFacet(params QueryParams) (*Facet, error) {
+	paramsWithoutBracket := params
+	paramsWithoutBracket.InBracket = nil
+
+	where, args := e.buildWhereClause(paramsWithoutBracket)
+	whereClause := ""
+	if len(where) > 0 {
+		whereClause = "WHERE " + strings.Join(where, " AND ")
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			CASE WHEN bracket_group_id IS NOT NULL THEN 'yes' ELSE 'no' END as in_bracket,
+			COUNT(*) as count
+		FROM photos p
+		%s
+		GROUP BY in_bracket
+	`, whereClause)
+
+	rows, err := e.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := []FacetValue{}
+	for rows.Next() {
+		var inBracket string
+		var count int
+		if err := rows.Scan(&inBracket, &count); err != nil {
+			return nil, err
+		}
+
+		selected := params.InBracket != nil && *params.InBracket == (inBracket == "yes")
+
+		label := "Not in Bracket"
+		if inBracket == "yes" {
+			label = "In Bracket"
+		}
+
+		values = append(values, FacetValue{
+			Value:    inBracket,
+			Label:    label,
+			Count:    count,
+			Selected: selected,
+		})
+	}
+
+	return &Facet{
+		Name:   "in_bracket",
+		Label:  "Bracket",
+		Values: values,
+	}, rows.Err()
+}
+
+// computeHasGPSFacet counts photos with and without a GPS position, using
+// the same null checks as the HasGPS filter
+func (e *Engine) computeHasGPSFacet(params QueryParams) (*Facet, error) {
+	paramsWithoutGPS := params
+	paramsWithoutGPS.HasGPS = nil
+
+	where, args := e.buildWhereClause(paramsWithoutGPS)
+	whereClause := ""
+	if len(where) > 0 {
+		whereClause = "WHERE " + strings.Join(where, " AND ")
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			CASE WHEN p.latitude IS NOT NULL AND p.longitude IS NOT NULL THEN 'yes' ELSE 'no' END as has_gps,
+			COUNT(*) as count
+		FROM photos p
+		%s
+		GROUP BY has_gps
+	`, whereClause)
+
+	rows, err := e.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := []FacetValue{}
+	for rows.Next() {
+		var hasGPS string
+		var count int
+		if err := rows.Scan(&hasGPS, &count); err != nil {
+			return nil, err
+		}
+
+		selected := params.HasGPS != nil && *params.HasGPS == (hasGPS == "yes")
+
+		label := "No GPS"
+		if hasGPS == "yes" {
+			label = "Geotagged"
+		}
+
+		values = append(values, FacetValue{
+			Value:    hasGPS,
+			Label:    label,
+			Count:    count,
+			Selected: selected,
+		})
+	}
+
+	return &Facet{
+		Name:   "has_gps",
+		Label:  "Location",
+		Values: values,
+	}, rows.Err()
+}
+
+// computeEditedFacet counts photos saved by an editor and photos straight out
+// of the camera, as classified from their Software tag at index time
+func (e *Engine) computeEditedFacet(params QueryParams) (*Facet, error) {
+	paramsWithoutEdited := params
+	paramsWithoutEdited.Edited = nil
+
+	where, args := e.buildWhereClause(paramsWithoutEdited)
+	whereClause := ""
+	if len(where) > 0 {
+		whereClause = "WHERE " + strings.Join(where, " AND ")
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			CASE WHEN p.edited = 1 THEN 'yes' ELSE 'no' END as edited,
+			COUNT(*) as count
+		FROM photos p
+		%s
+		GROUP BY edited
+	`, whereClause)
+
+	rows, err := e.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := []FacetValue{}
+	for rows.Next() {
+		var edited string
+		var count int
+		if err := rows.Scan(&edited, &count); err != nil {
+			return nil, err
+		}
+
+		selected := params.Edited != nil && *params.Edited == (edited == "yes")
+
+		label := "Out of Camera"
+		if edited == "yes" {
+			label = "Edited"
+		}
+
+		values = append(values, FacetValue{
+			Value:    edited,
+			Label:    label,
+			Count:    count,
+			Selected: selected,
+		})
+	}
+
+	return &Facet{
+		Name:   "edited",
+		Label:  "Editing",
+		Values: values,
+	}, rows.Err()
+}
+
+// computeHasColoursFacet counts photos with and without dominant colour data.
+// Photos without any are usually files whose image failed to decode.
+func (e *Engine) computeHasColoursFacet(params QueryParams) (*Facet, error) {
+	paramsWithoutHC := params
+	paramsWithoutHC.HasColours = nil
+
+	where, args := e.buildWhereClause(paramsWithoutHC)
+	whereClause := ""
+	if len(where) > 0 {
+		whereClause = "WHERE " + strings.Join(where, " AND ")
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			CASE WHEN EXISTS (SELECT 1 FROM photo_colors pc WHERE pc.photo_id = p.id)
+				THEN 'yes' ELSE 'no' END as has_colours,
+			COUNT(*) as count
+		FROM photos p
+		%s
+		GROUP BY has_colours
+		ORDER BY has_colours DESC
+	`, whereClause)
+
+	rows, err := e.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := []FacetValue{}
+	for rows.Next() {
+		var hasColours string
+		var count int
+		if err := rows.Scan(&hasColours, &count); err != nil {
+			return nil, err
+		}
+
+		selected := params.HasColours != nil && *params.HasColours == (hasColours == "yes")
+
+		label := "No colour data"
+		if hasColours == "yes" {
+			label = "Has colour data"
+		}
+
+		values = append(values, FacetValue{
+			Value:    hasColours,
+			Label:    label,
+			Count:    count,
+			Selected: selected,
+		})
+	}
+
+	return &Facet{
+		Name:   "has_colours",
+		Label:  "Colour data",
+		Values: values,
+	}, nil
+}
+
 // computeColourFacet computes colour name facet
 func (e *Engine) computeColourFacet(params QueryParams) (*Facet, error) {
+	// Matching any colour, a value adds to the selection, so it is counted
+	// without the selected colours. Matching all, it narrows the results,
+	// so the selected colours stay in the filter.
 	paramsWithoutColour := params
-	paramsWithoutColour.ColourName = nil
+	if params.ColourMatchMode != ColourMatchAll {
+		paramsWithoutColour.ColourName = nil
+	}
 
 	where, args := e.buildWhereClause(paramsWithoutColour)
 	whereClause := ""
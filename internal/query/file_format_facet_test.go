@@ -0,0 +1,65 @@
+package query
+
+import (
+	"testing"
+)
+
+func TestFileFormatFilterAndFacet(t *testing.T) {
+	db := setupTestDBWithSchema(t)
+	defer db.Close()
+
+	insertTestPhotos(t, db, []TestPhoto{
+		{FilePath: "/a.dng", CameraMake: "Canon", DateTaken: "2024-06-01 09:00:00"},
+		{FilePath: "/b.dng", CameraMake: "Canon", DateTaken: "2024-06-02 09:00:00"},
+		{FilePath: "/c.jpg", CameraMake: "Nikon", DateTaken: "2024-06-03 09:00:00"},
+	})
+	for path, format := range map[string]string{"/a.dng": "dng", "/b.dng": "dng", "/c.jpg": "jpeg"} {
+		if _, err := db.Exec("UPDATE photos SET file_format = ? WHERE file_path = ?", format, path); err != nil {
+			t.Fatalf("Failed to set file_format: %v", err)
+		}
+	}
+
+	engine := NewEngine(db)
+	params := QueryParams{FileFormat: []string{"jpeg"}, Limit: 50}
+
+	result, err := engine.Query(params)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if result.Total != 1 {
+		t.Errorf("Total = %d; want 1 JPEG", result.Total)
+	}
+
+	facets, err := engine.ComputeFacets(params)
+	if err != nil {
+		t.Fatalf("ComputeFacets failed: %v", err)
+	}
+	if facets.FileFormat == nil || len(facets.FileFormat.Values) != 2 {
+		t.Fatalf("FileFormat facet = %+v; want 2 values", facets.FileFormat)
+	}
+
+	// The facet ignores its own filter so other formats stay reachable
+	counts := map[string]int{}
+	for _, v := range facets.FileFormat.Values {
+		counts[v.Value] = v.Count
+		if v.Selected != (v.Value == "jpeg") {
+			t.Errorf("%s Selected = %v", v.Value, v.Selected)
+		}
+		if v.Value == "dng" && v.URL != "/photos?file_format=jpeg&file_format=dng" {
+			t.Errorf("dng URL = %q", v.URL)
+		}
+	}
+	if counts["dng"] != 2 || counts["jpeg"] != 1 {
+		t.Errorf("counts = %v; want dng=2 jpeg=1", counts)
+	}
+
+	// URL round trip
+	mapper := NewURLMapper()
+	parsed, err := mapper.ParsePath("/photos", "file_format=dng&file_format=tiff")
+	if err != nil {
+		t.Fatalf("ParsePath failed: %v", err)
+	}
+	if len(parsed.FileFormat) != 2 || parsed.FileFormat[0] != "dng" || parsed.FileFormat[1] != "tiff" {
+		t.Errorf("parsed FileFormat = %v", parsed.FileFormat)
+	}
+}
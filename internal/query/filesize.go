@@ -0,0 +1,123 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// fileSizeUnits are the suffixes ParseFileSize accepts, longest first so
+// "MiB" is not read as "B". KB, MB and GB are decimal, as cameras and
+// most file browsers report them; the IEC forms are binary.
+var fileSizeUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{"kib", 1 << 10}, {"mib", 1 << 20}, {"gib", 1 << 30},
+	{"kb", 1e3}, {"mb", 1e6}, {"gb", 1e9},
+	{"k", 1e3}, {"m", 1e6}, {"g", 1e9},
+	{"b", 1},
+}
+
+// ParseFileSize parses a size such as "500KB", "2MB", "1.5 GB", "4MiB" or a
+// plain number of bytes. Units are case-insensitive.
+func ParseFileSize(s string) (int64, error) {
+	text := strings.ToLower(strings.TrimSpace(s))
+	multiplier := int64(1)
+	for _, u := range fileSizeUnits {
+		if strings.HasSuffix(text, u.suffix) {
+			text = strings.TrimSpace(strings.TrimSuffix(text, u.suffix))
+			multiplier = u.bytes
+			break
+		}
+	}
+
+	n, err := strconv.ParseFloat(text, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid file size %q (expected e.g. 500KB, 2MB or 1GB)", s)
+	}
+	return int64(n * float64(multiplier)), nil
+}
+
+// FormatFileSize writes a byte count with a decimal unit, e.g. "2.5 MB"
+func FormatFileSize(bytes int64) string {
+	switch {
+	case bytes >= 1e9:
+		return fmt.Sprintf("%.3g GB", float64(bytes)/1e9)
+	case bytes >= 1e6:
+		return fmt.Sprintf("%.3g MB", float64(bytes)/1e6)
+	case bytes >= 1e3:
+		return fmt.Sprintf("%.3g KB", float64(bytes)/1e3)
+	default:
+		return fmt.Sprintf("%d B", bytes)
+	}
+}
+
+// fileSizeParam writes a size for a URL, using the largest decimal unit that
+// divides it exactly so bucket links read size_min=10MB rather than bytes
+func fileSizeParam(bytes int64) string {
+	switch {
+	case bytes != 0 && bytes%1e9 == 0:
+		return fmt.Sprintf("%dGB", bytes/1e9)
+	case bytes != 0 && bytes%1e6 == 0:
+		return fmt.Sprintf("%dMB", bytes/1e6)
+	case bytes != 0 && bytes%1e3 == 0:
+		return fmt.Sprintf("%dKB", bytes/1e3)
+	default:
+		return strconv.FormatInt(bytes, 10)
+	}
+}
+
+// fileSizeBuckets separate previews and exported thumbnails from camera
+// JPEGs, ordinary raw files, high-resolution raws and stitched panoramas
+var fileSizeBuckets = []rangeBucket{
+	{value: "lt1mb", label: "Under 1 MB", max: rangeBound(1e6)},
+	{value: "1-10mb", label: "1–10 MB", min: rangeBound(1e6), max: rangeBound(10e6)},
+	{value: "10-30mb", label: "10–30 MB", min: rangeBound(10e6), max: rangeBound(30e6)},
+	{value: "30-60mb", label: "30–60 MB", min: rangeBound(30e6), max: rangeBound(60e6)},
+	{value: "60mbplus", label: "60 MB+", min: rangeBound(60e6)},
+}
+
+// FileSizeBucketRange returns the FileSizeMin/FileSizeMax bounds of a file
+// size facet value. Unknown values return nil bounds.
+func FileSizeBucketRange(value string) (min, max *int64) {
+	for _, b := range fileSizeBuckets {
+		if b.value == value {
+			return sizeBound(b.min), sizeBound(b.max)
+		}
+	}
+	return nil, nil
+}
+
+func sizeBound(v *float64) *int64 {
+	if v == nil {
+		return nil
+	}
+	n := int64(*v)
+	return &n
+}
+
+func floatBound(v *int64) *float64 {
+	if v == nil {
+		return nil
+	}
+	return rangeBound(float64(*v))
+}
+
+// computeFileSizeFacet computes the file size bucket facet
+func (e *Engine) computeFileSizeFacet(params QueryParams) (*Facet, error) {
+	paramsWithoutSize := params
+	paramsWithoutSize.FileSizeMin = nil
+	paramsWithoutSize.FileSizeMax = nil
+
+	values, err := e.computeRangeFacetValues(paramsWithoutSize, "file_size", fileSizeBuckets, floatBound(params.FileSizeMin), floatBound(params.FileSizeMax))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Facet{
+		Name:   "file_size",
+		Label:  "File size",
+		Values: values,
+	}, nil
+}
@@ -0,0 +1,118 @@
+package query
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseFileSize(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int64
+	}{
+		{"1024", 1024},
+		{"500KB", 500e3},
+		{"2MB", 2e6},
+		{"2mb", 2e6},
+		{"1.5 GB", 1.5e9},
+		{"4MiB", 4 << 20},
+		{"10k", 10e3},
+		{"0", 0},
+	}
+	for _, tt := range tests {
+		got, err := ParseFileSize(tt.input)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseFileSize(%q) = %d, %v; want %d", tt.input, got, err, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", "MB", "2XB", "-1MB"} {
+		if _, err := ParseFileSize(bad); err == nil {
+			t.Errorf("ParseFileSize(%q) succeeded; want error", bad)
+		}
+	}
+}
+
+func TestFileSizeFilterAndFacet(t *testing.T) {
+	db := setupTestDBWithSchema(t)
+	defer db.Close()
+
+	insertTestPhotos(t, db, []TestPhoto{
+		{FilePath: "/thumb.jpg", CameraMake: "Nikon", DateTaken: "2024-06-01 09:00:00"},
+		{FilePath: "/camera.jpg", CameraMake: "Nikon", DateTaken: "2024-06-02 09:00:00"},
+		{FilePath: "/boundary.dng", CameraMake: "Nikon", DateTaken: "2024-06-03 09:00:00"},
+		{FilePath: "/raw.dng", CameraMake: "Nikon", DateTaken: "2024-06-04 09:00:00"},
+		{FilePath: "/pano.dng", CameraMake: "Nikon", DateTaken: "2024-06-05 09:00:00"},
+	})
+	sizes := map[string]int64{
+		"/thumb.jpg":    40e3,
+		"/camera.jpg":   6e6,
+		"/boundary.dng": 10e6, // lower bound of 10-30mb
+		"/raw.dng":      25e6,
+		"/pano.dng":     400e6,
+	}
+	for path, size := range sizes {
+		if _, err := db.Exec("UPDATE photos SET file_size = ? WHERE file_path = ?", size, path); err != nil {
+			t.Fatalf("Failed to set file_size: %v", err)
+		}
+	}
+
+	engine := NewEngine(db)
+	mapper := NewURLMapper()
+
+	// Human suffixes are accepted in URLs
+	params, err := mapper.ParsePath("/photos", "size_min=1MB&size_max=30MB")
+	if err != nil {
+		t.Fatalf("ParsePath failed: %v", err)
+	}
+	result, err := engine.Query(params)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if result.Total != 3 {
+		t.Errorf("1MB <= size < 30MB matched %d photos; want 3", result.Total)
+	}
+
+	facets, err := engine.ComputeFacets(QueryParams{})
+	if err != nil {
+		t.Fatalf("ComputeFacets failed: %v", err)
+	}
+	counts := map[string]int{}
+	for _, v := range facets.FileSize.Values {
+		counts[v.Value] = v.Count
+	}
+	want := map[string]int{"lt1mb": 1, "1-10mb": 1, "10-30mb": 2, "60mbplus": 1}
+	for value, count := range want {
+		if counts[value] != count {
+			t.Errorf("file size bucket %s = %d; want %d", value, counts[value], count)
+		}
+	}
+	if _, ok := counts["30-60mb"]; ok {
+		t.Error("empty 30-60mb bucket should be omitted")
+	}
+
+	// Bucket links carry readable bounds and select the bucket when followed
+	NewFacetURLBuilder(mapper).BuildURLsForFacets(facets, QueryParams{})
+	var link string
+	for _, v := range facets.FileSize.Values {
+		if v.Value == "10-30mb" {
+			link = v.URL
+		}
+	}
+	if !strings.Contains(link, "size_max=30MB") || !strings.Contains(link, "size_min=10MB") {
+		t.Fatalf("10-30mb URL = %q; want size_min=10MB and size_max=30MB", link)
+	}
+	params, err = mapper.ParsePath("/photos", link[strings.Index(link, "?")+1:])
+	if err != nil {
+		t.Fatalf("ParsePath(%q) failed: %v", link, err)
+	}
+	facets, err = engine.ComputeFacets(params)
+	if err != nil {
+		t.Fatalf("ComputeFacets failed: %v", err)
+	}
+	for _, v := range facets.FileSize.Values {
+		if v.Selected != (v.Value == "10-30mb") {
+			t.Errorf("bucket %s selected = %v", v.Value, v.Selected)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package query
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Filmstrip sizes: the default and the most a caller can ask for
+const (
+	DefaultFilmstripSize = 7
+	MaxFilmstripSize     = 51
+)
+
+// Filmstrip is the window of a filtered, sorted result set around one photo
+type Filmstrip struct {
+	Photos   []PhotoSummary
+	Current  int // Index of the photo in Photos
+	Position int // 0-based position of the photo in the whole result set
+	Total    int
+	PrevID   int // 0 at the start of the set
+	NextID   int // 0 at the end of the set
+}
+
+// Filmstrip returns up to size photos centred on photoID within the photos
+// matching params, in the same order Query returns them, so previous and
+// next follow the grid the user came from. params' limit and offset are
+// ignored. It returns nil if the photo does not match params.
+func (e *Engine) Filmstrip(params QueryParams, photoID, size int) (*Filmstrip, error) {
+	if size < 3 {
+		size = 3 // Room for the previous and next photo
+	}
+	if size > MaxFilmstripSize {
+		size = MaxFilmstripSize
+	}
+	if params.SortBy == "" {
+		params.SortBy = "date_taken"
+		params.SortOrder = "desc"
+	}
+
+	position, err := e.position(params, photoID)
+	if err != nil || position < 0 {
+		return nil, err
+	}
+
+	params.Limit = size
+	params.Offset = position - size/2
+	if params.Offset < 0 {
+		params.Offset = 0
+	}
+	result, err := e.Query(params)
+	if err != nil {
+		return nil, err
+	}
+
+	strip := &Filmstrip{
+		Photos:   result.Photos,
+		Current:  position - params.Offset,
+		Position: position,
+		Total:    result.Total,
+	}
+	// The catalog can change between the two queries; only link neighbours
+	// when the window still lines up
+	if strip.Current >= len(strip.Photos) || strip.Photos[strip.Current].ID != photoID {
+		return nil, fmt.Errorf("photo %d moved while building its filmstrip", photoID)
+	}
+	if strip.Current > 0 {
+		strip.PrevID = strip.Photos[strip.Current-1].ID
+	}
+	if strip.Current+1 < len(strip.Photos) {
+		strip.NextID = strip.Photos[strip.Current+1].ID
+	}
+	return strip, nil
+}
+
+// position returns photoID's 0-based row in the result set of params, or -1
+// if it is not in the set. buildOrderBy ends in a unique column, so the row
+// number agrees with the LIMIT/OFFSET windows Query uses.
+func (e *Engine) position(params QueryParams, photoID int) (int, error) {
+	where, args := e.buildWhereClause(params)
+
+	inner := "SELECT p.id, ROW_NUMBER() OVER (" + e.buildOrderBy(params) + ") - 1 AS position FROM photos p"
+	if len(where) > 0 {
+		inner += " WHERE " + strings.Join(where, " AND ")
+	}
+
+	var position int
+	err := e.db.QueryRow("SELECT position FROM ("+inner+") WHERE id = ?", append(args, photoID)...).Scan(&position)
+	if errors.Is(err, sql.ErrNoRows) {
+		return -1, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to find photo %d: %w", photoID, err)
+	}
+	return position, nil
+}
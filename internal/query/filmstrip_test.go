@@ -0,0 +1,85 @@
+package query
+
+import (
+	"testing"
+)
+
+func TestFilmstrip(t *testing.T) {
+	db := setupTestDBWithSchema(t)
+	defer db.Close()
+
+	// IDs 1-6; photos 4 and 5 share a timestamp, as burst frames often do
+	insertTestPhotos(t, db, []TestPhoto{
+		{FilePath: "/1.dng", CameraMake: "Canon", DateTaken: "2024-06-01 09:00:00"},
+		{FilePath: "/2.dng", CameraMake: "Nikon", DateTaken: "2024-06-02 09:00:00"},
+		{FilePath: "/3.dng", CameraMake: "Canon", DateTaken: "2024-06-03 09:00:00"},
+		{FilePath: "/4.dng", CameraMake: "Canon", DateTaken: "2024-06-04 09:00:00"},
+		{FilePath: "/5.dng", CameraMake: "Canon", DateTaken: "2024-06-04 09:00:00"},
+		{FilePath: "/6.dng", CameraMake: "Nikon", DateTaken: "2024-06-05 09:00:00"},
+	})
+	engine := NewEngine(db)
+	canon := QueryParams{CameraMake: []string{"Canon"}}
+
+	ids := func(strip *Filmstrip) []int {
+		var out []int
+		for _, p := range strip.Photos {
+			out = append(out, p.ID)
+		}
+		return out
+	}
+
+	t.Run("NeighboursWithinFilter", func(t *testing.T) {
+		// Canon, newest first: 5, 4, 3, 1. Nikon photo 2 is skipped.
+		strip, err := engine.Filmstrip(canon, 3, 3)
+		if err != nil || strip == nil {
+			t.Fatalf("Filmstrip = %v, %v", strip, err)
+		}
+		if strip.PrevID != 4 || strip.NextID != 1 {
+			t.Errorf("prev/next = %d/%d; want 4/1", strip.PrevID, strip.NextID)
+		}
+		if strip.Position != 2 || strip.Total != 4 || strip.Photos[strip.Current].ID != 3 {
+			t.Errorf("position %d of %d, current %d; want 2 of 4 at photo 3", strip.Position, strip.Total, strip.Current)
+		}
+	})
+
+	t.Run("TiesBrokenByID", func(t *testing.T) {
+		strip, err := engine.Filmstrip(canon, 4, 3)
+		if err != nil || strip == nil {
+			t.Fatalf("Filmstrip = %v, %v", strip, err)
+		}
+		if strip.PrevID != 5 || strip.NextID != 3 {
+			t.Errorf("prev/next = %d/%d; want 5/3", strip.PrevID, strip.NextID)
+		}
+	})
+
+	t.Run("EndsOfSet", func(t *testing.T) {
+		first, _ := engine.Filmstrip(canon, 5, 7)
+		if first == nil || first.PrevID != 0 || first.NextID != 4 || first.Current != 0 {
+			t.Errorf("first = %+v; want no prev, next 4", first)
+		}
+		if got := ids(first); len(got) != 4 {
+			t.Errorf("window = %v; want all 4 Canon photos", got)
+		}
+		last, _ := engine.Filmstrip(canon, 1, 3)
+		if last == nil || last.PrevID != 3 || last.NextID != 0 {
+			t.Errorf("last = %+v; want prev 3, no next", last)
+		}
+	})
+
+	t.Run("SortOrderFollowed", func(t *testing.T) {
+		asc := canon
+		asc.SortBy = "date_taken"
+		asc.SortOrder = "asc"
+		strip, _ := engine.Filmstrip(asc, 3, 3)
+		if strip == nil || strip.PrevID != 1 || strip.NextID != 4 {
+			t.Errorf("ascending = %+v; want prev 1, next 4", strip)
+		}
+	})
+
+	t.Run("PhotoOutsideFilter", func(t *testing.T) {
+		strip, err := engine.Filmstrip(canon, 2, 3)
+		if err != nil || strip != nil {
+			t.Errorf("Filmstrip(Nikon photo, Canon filter) = %+v, %v; want nil, nil", strip, err)
+		}
+	})
+}
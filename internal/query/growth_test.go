@@ -0,0 +1,76 @@
+package query
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/adewale/olsen/internal/database"
+	"github.com/adewale/olsen/pkg/models"
+)
+
+func TestComputeGrowth(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "growth.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	indexed := []string{
+		"2024-05-30 09:00:00", // Thursday
+		"2024-06-01 10:00:00", // Saturday, same week
+		"2024-06-01 22:00:00",
+		"2024-06-03 08:00:00", // Monday
+		"2024-07-15 12:00:00",
+	}
+	for i, at := range indexed {
+		photo := &models.PhotoMetadata{FilePath: fmt.Sprintf("/%d.jpg", i), FileHash: fmt.Sprint(i), CameraMake: "Canon"}
+		if i == 4 {
+			photo.CameraMake = "Nikon"
+		}
+		if err := db.InsertPhoto(photo); err != nil {
+			t.Fatalf("InsertPhoto failed: %v", err)
+		}
+		if _, err := db.Exec("UPDATE photos SET indexed_at = ? WHERE file_path = ?", at, photo.FilePath); err != nil {
+			t.Fatalf("Failed to set indexed_at: %v", err)
+		}
+	}
+
+	engine := NewEngine(db.DB)
+	summarise := func(periods []GrowthPeriod) string {
+		s := ""
+		for _, p := range periods {
+			s += fmt.Sprintf("%s+%d=%d ", p.Period, p.Added, p.Cumulative)
+		}
+		return s
+	}
+	tests := []struct {
+		name   string
+		params QueryParams
+		by     string
+		within GrowthRange
+		want   string
+	}{
+		{"by day", QueryParams{}, "day", GrowthRange{}, "2024-05-30+1=1 2024-06-01+2=3 2024-06-03+1=4 2024-07-15+1=5 "},
+		{"by week", QueryParams{}, "week", GrowthRange{}, "2024-05-27+3=3 2024-06-03+1=4 2024-07-15+1=5 "},
+		{"by month", QueryParams{}, "month", GrowthRange{}, "2024-05+1=1 2024-06+3=4 2024-07+1=5 "},
+		{"range keeps earlier photos in the total", QueryParams{}, "month",
+			GrowthRange{From: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC)},
+			"2024-06+3=4 "},
+		{"filtered", QueryParams{CameraMake: []string{"Nikon"}}, "month", GrowthRange{}, "2024-07+1=1 "},
+	}
+	for _, tt := range tests {
+		periods, err := engine.ComputeGrowth(tt.params, tt.by, tt.within)
+		if err != nil {
+			t.Fatalf("%s: ComputeGrowth failed: %v", tt.name, err)
+		}
+		if got := summarise(periods); got != tt.want {
+			t.Errorf("%s: got %q; want %q", tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := engine.ComputeGrowth(QueryParams{}, "year", GrowthRange{}); err == nil {
+		t.Error("ComputeGrowth accepted an unknown period")
+	}
+}
@@ -0,0 +1,62 @@
+package query
+
+import (
+	"testing"
+)
+
+func TestHasColoursFilterAndFacet(t *testing.T) {
+	db := setupTestDBWithSchema(t)
+	defer db.Close()
+
+	insertTestPhotos(t, db, []TestPhoto{
+		{FilePath: "/a.dng", CameraMake: "Canon", DateTaken: "2024-06-01 09:00:00"},
+		{FilePath: "/b.dng", CameraMake: "Canon", DateTaken: "2024-06-02 09:00:00"},
+		{FilePath: "/failed.dng", CameraMake: "Leica", DateTaken: "2024-06-03 09:00:00"},
+	})
+	// Only the first two decoded; the third has no palette
+	for _, id := range []int{1, 2} {
+		if _, err := db.Exec(`INSERT INTO photo_colors (photo_id, color_order, red, green, blue, weight, hue, saturation, lightness)
+			VALUES (?, 0, 200, 20, 20, 1.0, 0, 80, 45)`, id); err != nil {
+			t.Fatalf("Failed to insert colour: %v", err)
+		}
+	}
+
+	engine := NewEngine(db)
+	noColours := false
+	params := QueryParams{HasColours: &noColours, Limit: 50}
+
+	result, err := engine.Query(params)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if result.Total != 1 || result.Photos[0].FilePath != "/failed.dng" {
+		t.Fatalf("got %d photos; want only /failed.dng", result.Total)
+	}
+
+	facets, err := engine.ComputeFacets(params)
+	if err != nil {
+		t.Fatalf("ComputeFacets failed: %v", err)
+	}
+	if facets.HasColours == nil || len(facets.HasColours.Values) != 2 {
+		t.Fatalf("HasColours facet = %+v; want yes and no", facets.HasColours)
+	}
+
+	for _, v := range facets.HasColours.Values {
+		switch v.Value {
+		case "yes":
+			if v.Count != 2 || v.Selected {
+				t.Errorf("yes = %d selected=%v; want 2, unselected", v.Count, v.Selected)
+			}
+			if v.URL != "/photos?has_colors=true" {
+				t.Errorf("yes URL = %q", v.URL)
+			}
+		case "no":
+			if v.Count != 1 || !v.Selected {
+				t.Errorf("no = %d selected=%v; want 1, selected", v.Count, v.Selected)
+			}
+			if v.URL != "/photos" {
+				t.Errorf("no URL = %q; want /photos", v.URL)
+			}
+		}
+	}
+}
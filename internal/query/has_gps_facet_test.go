@@ -0,0 +1,57 @@
+package query
+
+import (
+	"testing"
+)
+
+func TestHasGPSFacet(t *testing.T) {
+	db := setupTestDBWithSchema(t)
+	defer db.Close()
+
+	insertTestPhotos(t, db, []TestPhoto{
+		{FilePath: "/a.jpg", CameraMake: "Canon", DateTaken: "2024-06-01 09:00:00"},
+		{FilePath: "/b.jpg", CameraMake: "Canon", DateTaken: "2024-06-01 10:00:00"},
+		{FilePath: "/c.jpg", CameraMake: "Nikon", DateTaken: "2024-06-01 11:00:00"},
+	})
+	// A latitude alone is not a position
+	for _, stmt := range []string{
+		"UPDATE photos SET latitude = 51.5, longitude = -0.1 WHERE file_path IN ('/a.jpg', '/c.jpg')",
+		"UPDATE photos SET latitude = 48.8 WHERE file_path = '/b.jpg'",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("Failed to set GPS: %v", err)
+		}
+	}
+
+	engine := NewEngine(db)
+	params := QueryParams{CameraMake: []string{"Canon"}, Limit: 50}
+	facets, err := engine.ComputeFacets(params)
+	if err != nil {
+		t.Fatalf("ComputeFacets failed: %v", err)
+	}
+	if facets.HasGPS == nil || len(facets.HasGPS.Values) != 2 {
+		t.Fatalf("HasGPS facet = %+v; want 2 values", facets.HasGPS)
+	}
+	for _, v := range facets.HasGPS.Values {
+		switch v.Value {
+		case "yes":
+			if v.Label != "Geotagged" || v.Count != 1 || v.Selected || v.URL != "/photos?camera_make=Canon&has_gps=true" {
+				t.Errorf("yes = %+v; want 1 Geotagged, linking to has_gps=true", v)
+			}
+		case "no":
+			if v.Count != 1 || v.Selected {
+				t.Errorf("no = %+v; want 1 unselected", v)
+			}
+		}
+	}
+
+	// Each value's count matches the results of following its link
+	for _, v := range facets.HasGPS.Values {
+		hasGPS := v.Value == "yes"
+		p := params
+		p.HasGPS = &hasGPS
+		if total, err := engine.Count(p); err != nil || total != v.Count {
+			t.Errorf("Count(has_gps=%v) = %d, %v; want %d", hasGPS, total, err, v.Count)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestIteratePhotos(t *testing.T) {
+	db := setupTestDBWithSchema(t)
+	defer db.Close()
+
+	var photos []TestPhoto
+	for i := 0; i < 7; i++ {
+		photos = append(photos, TestPhoto{
+			FilePath:   fmt.Sprintf("/photo%d.dng", i),
+			CameraMake: "Canon",
+			DateTaken:  fmt.Sprintf("2024-06-%02d 12:00:00", i+1),
+		})
+	}
+	insertTestPhotos(t, db, photos)
+
+	engine := NewEngine(db)
+
+	t.Run("VisitsAllInIDOrder", func(t *testing.T) {
+		var ids []int
+		err := engine.IteratePhotos(3, func(p PhotoSummary) error {
+			ids = append(ids, p.ID)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("IteratePhotos failed: %v", err)
+		}
+		if len(ids) != 7 {
+			t.Fatalf("visited %d photos; want 7", len(ids))
+		}
+		for i := 1; i < len(ids); i++ {
+			if ids[i] <= ids[i-1] {
+				t.Errorf("ids not ascending: %v", ids)
+				break
+			}
+		}
+	})
+
+	t.Run("ExactMultipleOfBatch", func(t *testing.T) {
+		count := 0
+		if err := engine.IteratePhotos(7, func(PhotoSummary) error { count++; return nil }); err != nil {
+			t.Fatalf("IteratePhotos failed: %v", err)
+		}
+		if count != 7 {
+			t.Errorf("visited %d photos; want 7", count)
+		}
+	})
+
+	t.Run("StopsOnError", func(t *testing.T) {
+		stop := errors.New("stop")
+		count := 0
+		err := engine.IteratePhotos(2, func(PhotoSummary) error {
+			count++
+			if count == 3 {
+				return stop
+			}
+			return nil
+		})
+		if !errors.Is(err, stop) {
+			t.Errorf("err = %v; want stop", err)
+		}
+		if count != 3 {
+			t.Errorf("visited %d photos; want 3", count)
+		}
+	})
+
+	t.Run("CallbackMayWrite", func(t *testing.T) {
+		// Deleting rows mid-walk must not skip or repeat the remaining ones
+		count := 0
+		err := engine.IteratePhotos(2, func(p PhotoSummary) error {
+			count++
+			_, err := db.Exec("DELETE FROM photos WHERE id = ?", p.ID)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("IteratePhotos failed: %v", err)
+		}
+		if count != 7 {
+			t.Errorf("visited %d photos; want 7", count)
+		}
+	})
+
+	t.Run("MissingThumbnail", func(t *testing.T) {
+		db := setupTestDBWithSchema(t)
+		defer db.Close()
+		insertTestPhotos(t, db, photos[:4])
+		for _, thumb := range []struct {
+			id   int
+			size string
+		}{{1, "64"}, {1, "1024"}, {2, "64"}, {3, "1024"}} {
+			if _, err := db.Exec("INSERT INTO thumbnails (photo_id, size, data) VALUES (?, ?, x'00')", thumb.id, thumb.size); err != nil {
+				t.Fatalf("Failed to insert thumbnail: %v", err)
+			}
+		}
+
+		var ids []int
+		err := NewEngine(db).IteratePhotosMissingThumbnail("1024", 1, func(p PhotoSummary) error {
+			ids = append(ids, p.ID)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("IteratePhotosMissingThumbnail failed: %v", err)
+		}
+		if fmt.Sprint(ids) != "[2 4]" {
+			t.Errorf("photos missing 1024px = %v; want [2 4]", ids)
+		}
+	})
+}
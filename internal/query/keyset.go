@@ -0,0 +1,71 @@
+package query
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Cursor marks the last photo of a keyset page; passing it back as
+// QueryParams.AfterID and AfterDate fetches the page after it
+type Cursor struct {
+	AfterID   int    `json:"after_id"`
+	AfterDate string `json:"after_date,omitempty"` // Stored date_taken; "" when the photo has no date
+}
+
+// usesKeyset reports whether params asks for the page after a cursor
+func usesKeyset(params QueryParams) bool {
+	return params.AfterID > 0
+}
+
+// SortsByDate reports whether buildOrderBy orders params by date_taken and
+// id, the only order keyset pagination supports, and in which direction
+func SortsByDate(params QueryParams) (byDate, ascending bool) {
+	switch params.SortBy {
+	case "camera", "focal_length", "iso", "aperture", "colour", "color":
+		return false, false
+	case "date_taken":
+		return true, params.SortOrder == "asc"
+	default:
+		return true, false
+	}
+}
+
+// keysetCondition selects the rows after the cursor in date order. SQLite
+// sorts NULL dates first ascending and last descending, so undated photos
+// are compared by id alone among themselves.
+func keysetCondition(params QueryParams) (string, []interface{}, error) {
+	byDate, ascending := SortsByDate(params)
+	if !byDate {
+		return "", nil, fmt.Errorf("keyset pagination requires date order, not sort %q", params.SortBy)
+	}
+
+	if ascending {
+		if params.AfterDate == "" {
+			return "((p.date_taken IS NULL AND p.id > ?) OR p.date_taken IS NOT NULL)", []interface{}{params.AfterID}, nil
+		}
+		return "(p.date_taken > ? OR (p.date_taken = ? AND p.id > ?))",
+			[]interface{}{params.AfterDate, params.AfterDate, params.AfterID}, nil
+	}
+	if params.AfterDate == "" {
+		return "(p.date_taken IS NULL AND p.id < ?)", []interface{}{params.AfterID}, nil
+	}
+	return "(p.date_taken < ? OR (p.date_taken = ? AND p.id < ?) OR p.date_taken IS NULL)",
+		[]interface{}{params.AfterDate, params.AfterDate, params.AfterID}, nil
+}
+
+// cursorAfter returns the cursor continuing after photoID. The stored
+// date_taken text is read back, cast so the driver does not reformat it as a
+// time, because the sort compares the stored text and the summary's date may
+// be converted to another zone (SetLocation).
+func (e *Engine) cursorAfter(photoID int) (*Cursor, error) {
+	var dateTaken sql.NullString
+	err := e.db.QueryRow("SELECT CAST(date_taken AS TEXT) FROM photos WHERE id = ?", photoID).Scan(&dateTaken)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("photo %d was removed while paging", photoID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to build cursor: %w", err)
+	}
+	return &Cursor{AfterID: photoID, AfterDate: dateTaken.String}, nil
+}
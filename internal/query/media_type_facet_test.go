@@ -0,0 +1,54 @@
+package query
+
+import (
+	"testing"
+)
+
+func TestMediaTypeFilterAndFacet(t *testing.T) {
+	db := setupTestDBWithSchema(t)
+	defer db.Close()
+
+	insertTestPhotos(t, db, []TestPhoto{
+		{FilePath: "/a.jpg", CameraMake: "Canon", DateTaken: "2024-06-01 09:00:00"},
+		{FilePath: "/b.jpg", CameraMake: "Canon", DateTaken: "2024-06-01 10:00:00"},
+		{FilePath: "/c.mov", DateTaken: "2024-06-01 11:00:00"},
+	})
+	if _, err := db.Exec("UPDATE photos SET media_type = 'video', duration = 12.5 WHERE file_path = '/c.mov'"); err != nil {
+		t.Fatalf("Failed to mark video: %v", err)
+	}
+
+	engine := NewEngine(db)
+	params, err := NewURLMapper().ParsePath("/photos", "media_type=video")
+	if err != nil {
+		t.Fatalf("ParsePath failed: %v", err)
+	}
+	params.Limit = 50
+
+	result, err := engine.Query(params)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if result.Total != 1 || result.Photos[0].FilePath != "/c.mov" {
+		t.Errorf("Query(media_type=video) = %d photos; want only /c.mov", result.Total)
+	}
+
+	facets, err := engine.ComputeFacets(params)
+	if err != nil {
+		t.Fatalf("ComputeFacets failed: %v", err)
+	}
+	if facets.MediaType == nil || len(facets.MediaType.Values) != 2 {
+		t.Fatalf("MediaType facet = %+v; want photo and video", facets.MediaType)
+	}
+	for _, v := range facets.MediaType.Values {
+		switch v.Value {
+		case "photo":
+			if v.Count != 2 || v.Selected || v.Label != "Photos" {
+				t.Errorf("photo = %+v; want 2 unselected Photos", v)
+			}
+		case "video":
+			if v.Count != 1 || !v.Selected || v.URL != "/photos" {
+				t.Errorf("video = %+v; want 1 selected, linking to /photos", v)
+			}
+		}
+	}
+}
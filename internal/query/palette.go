@@ -0,0 +1,37 @@
+package query
+
+// paletteBuckets group photos by how many colour families their palette
+// holds (photos.colour_count): one for monochrome and single-hue scenes, a
+// few for clean compositions, more for busy ones
+var paletteBuckets = []rangeBucket{
+	{value: "monochromatic", label: "Monochromatic", max: rangeBound(2)},
+	{value: "limited", label: "Limited (2–3 colours)", min: rangeBound(2), max: rangeBound(4)},
+	{value: "varied", label: "Varied (4+ colours)", min: rangeBound(4)},
+}
+
+// PaletteBucketRange returns the ColourCountMin/ColourCountMax bounds of a
+// palette facet value. Both are inclusive, unlike the bucket's own max.
+// Unknown values return nil bounds.
+func PaletteBucketRange(value string) (min, max *int) {
+	return intBucketRange(paletteBuckets, value)
+}
+
+// computePaletteFacet computes the palette facet. Photos without colour
+// data are not counted.
+func (e *Engine) computePaletteFacet(params QueryParams) (*Facet, error) {
+	paramsWithoutCount := params
+	paramsWithoutCount.ColourCountMin = nil
+	paramsWithoutCount.ColourCountMax = nil
+
+	selMin, selMax := intSelection(params.ColourCountMin, params.ColourCountMax)
+	values, err := e.computeRangeFacetValues(paramsWithoutCount, "colour_count", paletteBuckets, selMin, selMax)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Facet{
+		Name:   "palette",
+		Label:  "Palette",
+		Values: values,
+	}, nil
+}
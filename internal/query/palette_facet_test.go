@@ -0,0 +1,68 @@
+package query
+
+import (
+	"testing"
+)
+
+func TestPaletteFilterAndFacet(t *testing.T) {
+	db := setupTestDBWithSchema(t)
+	defer db.Close()
+
+	insertTestPhotos(t, db, []TestPhoto{
+		{FilePath: "/fog.dng", DateTaken: "2024-06-01 09:00:00"},
+		{FilePath: "/beach.dng", DateTaken: "2024-06-02 09:00:00"},
+		{FilePath: "/market.dng", DateTaken: "2024-06-03 09:00:00"},
+		{FilePath: "/failed.dng", DateTaken: "2024-06-04 09:00:00"},
+	})
+	// The indexer sets colour_count with the palette; the last has neither
+	for id, count := range map[int]int{1: 1, 2: 3, 3: 5} {
+		if _, err := db.Exec("UPDATE photos SET colour_count = ? WHERE id = ?", count, id); err != nil {
+			t.Fatalf("Failed to set colour_count: %v", err)
+		}
+	}
+
+	engine := NewEngine(db)
+	min, max := PaletteBucketRange("limited")
+	params := QueryParams{ColourCountMin: min, ColourCountMax: max, Limit: 50}
+
+	result, err := engine.Query(params)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if result.Total != 1 || result.Photos[0].FilePath != "/beach.dng" {
+		t.Fatalf("got %d photos; want only /beach.dng", result.Total)
+	}
+	if url := NewURLMapper().BuildFullURL(params); url != "/photos?color_count_max=3&color_count_min=2" {
+		t.Errorf("URL = %q", url)
+	}
+
+	facets, err := engine.ComputeFacets(params)
+	if err != nil {
+		t.Fatalf("ComputeFacets failed: %v", err)
+	}
+	if facets.Palette == nil || len(facets.Palette.Values) != 3 {
+		t.Fatalf("Palette facet = %+v; want three buckets", facets.Palette)
+	}
+	for _, v := range facets.Palette.Values {
+		if v.Count != 1 {
+			t.Errorf("%s = %d; want 1, the photo without a palette left out", v.Value, v.Count)
+		}
+		if v.Selected != (v.Value == "limited") {
+			t.Errorf("%s selected = %v", v.Value, v.Selected)
+		}
+		switch v.Value {
+		case "monochromatic":
+			if v.URL != "/photos?color_count_max=1" {
+				t.Errorf("monochromatic URL = %q", v.URL)
+			}
+		case "limited":
+			if v.URL != "/photos" {
+				t.Errorf("limited URL = %q; want /photos", v.URL)
+			}
+		case "varied":
+			if v.URL != "/photos?color_count_min=4" {
+				t.Errorf("varied URL = %q", v.URL)
+			}
+		}
+	}
+}
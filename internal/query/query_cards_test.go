@@ -0,0 +1,108 @@
+package query
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/adewale/olsen/internal/database"
+)
+
+func TestQueryCardsMatchesQuery(t *testing.T) {
+	db := setupTestDBWithSchema(t)
+	defer db.Close()
+
+	var photos []TestPhoto
+	for i := 0; i < 12; i++ {
+		photos = append(photos, TestPhoto{
+			FilePath:   fmt.Sprintf("/%d.jpg", i),
+			CameraMake: []string{"Canon", "Nikon"}[i%2],
+			DateTaken:  fmt.Sprintf("2024-06-%02d 09:00:00", 1+i/2), // Pairs share a date
+		})
+	}
+	insertTestPhotos(t, db, photos)
+
+	engine := NewEngine(db)
+	for name, params := range map[string]QueryParams{
+		"default":  {Limit: 5},
+		"filtered": {CameraMake: []string{"Canon"}, Limit: 4, Offset: 2},
+		"sorted":   {SortBy: "camera", SortOrder: "asc", Limit: 5},
+		"keyset":   {Limit: 5, AfterID: 10, AfterDate: "2024-06-05T09:00:00Z"},
+	} {
+		full, err := engine.Query(params)
+		if err != nil {
+			t.Fatalf("%s: Query failed: %v", name, err)
+		}
+		cards, err := engine.QueryCards(params)
+		if err != nil {
+			t.Fatalf("%s: QueryCards failed: %v", name, err)
+		}
+
+		if cards.Total != full.Total || cards.HasMore != full.HasMore || len(cards.Photos) != len(full.Photos) {
+			t.Errorf("%s: cards total %d, more %v, %d photos; Query gave %d, %v, %d", name,
+				cards.Total, cards.HasMore, len(cards.Photos), full.Total, full.HasMore, len(full.Photos))
+			continue
+		}
+		for i, c := range cards.Photos {
+			p := full.Photos[i]
+			if c.ID != p.ID || !c.DateTaken.Equal(p.DateTaken) || c.CameraMake != p.CameraMake {
+				t.Errorf("%s: card %d = %+v; want photo %d %v %s", name, i, c, p.ID, p.DateTaken, p.CameraMake)
+			}
+		}
+		if fmt.Sprint(cards.NextCursor) != fmt.Sprint(full.NextCursor) {
+			t.Errorf("%s: cursor = %v; want %v", name, cards.NextCursor, full.NextCursor)
+		}
+	}
+}
+
+// BenchmarkQueryCards compares a grid page read with Query and QueryCards
+func BenchmarkQueryCards(b *testing.B) {
+	db, err := database.Open(filepath.Join(b.TempDir(), "cards.db"))
+	if err != nil {
+		b.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		b.Fatal(err)
+	}
+	base := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 20000; i++ {
+		date := base.Add(time.Duration(i*7919%(10*365*24)) * time.Hour)
+		_, err := tx.Exec(`
+			INSERT INTO photos (file_path, file_hash, file_size, indexed_at, last_modified,
+				date_taken, camera_make, camera_model, lens_model, iso, aperture, focal_length,
+				width, height, time_of_day, season, focal_category, latitude, longitude, blurhash)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			fmt.Sprintf("/library/%d/IMG_%05d.jpg", i%100, i), fmt.Sprint(i), 1000+i, date, date,
+			date, "Canon", fmt.Sprintf("Model %d", i%13), fmt.Sprintf("Lens %d", i%17),
+			100<<(i%6), 1.4+float64(i%8), float64(14+i%186), 6000, 4000,
+			"morning", "summer", "normal", 51.5, -0.1, "iVBORw0KGgoAAAANSUhEUgAAAAQAAAADCAIAAAA7ljmRAAAAEklEQVR4nGNgYGD4z8DAwMAAAA0HAQFvF5nZAAAAAElFTkSuQmCC")
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		b.Fatal(err)
+	}
+
+	engine := NewEngine(db.DB)
+	params := QueryParams{Limit: 500}
+
+	b.Run("Query", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := engine.Query(params); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("QueryCards", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := engine.QueryCards(params); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
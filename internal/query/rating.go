@@ -0,0 +1,41 @@
+package query
+
+// MaxRating is the highest star rating a photo can have; 0 means unrated
+const MaxRating = 5
+
+// ratingBuckets list unrated photos, then each star level. Every bucket is
+// a single level, so choosing one filters to exactly that rating.
+var ratingBuckets = []rangeBucket{
+	{value: "0", label: "Unrated", min: rangeBound(0), max: rangeBound(1)},
+	{value: "1", label: "★", min: rangeBound(1), max: rangeBound(2)},
+	{value: "2", label: "★★", min: rangeBound(2), max: rangeBound(3)},
+	{value: "3", label: "★★★", min: rangeBound(3), max: rangeBound(4)},
+	{value: "4", label: "★★★★", min: rangeBound(4), max: rangeBound(5)},
+	{value: "5", label: "★★★★★", min: rangeBound(5), max: rangeBound(6)},
+}
+
+// RatingBucketRange returns the RatingMin/RatingMax bounds of a rating facet
+// value, both inclusive. Unknown values return nil bounds.
+func RatingBucketRange(value string) (min, max *int) {
+	return intBucketRange(ratingBuckets, value)
+}
+
+// computeRatingFacet computes the rating facet, counting photos at each star
+// level
+func (e *Engine) computeRatingFacet(params QueryParams) (*Facet, error) {
+	paramsWithoutRating := params
+	paramsWithoutRating.RatingMin = nil
+	paramsWithoutRating.RatingMax = nil
+
+	selMin, selMax := intSelection(params.RatingMin, params.RatingMax)
+	values, err := e.computeRangeFacetValues(paramsWithoutRating, "rating", ratingBuckets, selMin, selMax)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Facet{
+		Name:   "rating",
+		Label:  "Rating",
+		Values: values,
+	}, nil
+}
@@ -0,0 +1,62 @@
+package query
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRatingFilterAndFacet(t *testing.T) {
+	db := setupTestDBWithSchema(t)
+	defer db.Close()
+
+	var photos []TestPhoto
+	for i := 0; i < 6; i++ {
+		photos = append(photos, TestPhoto{FilePath: fmt.Sprintf("/%d.jpg", i), DateTaken: fmt.Sprintf("2024-06-01 09:00:%02d", i)})
+	}
+	insertTestPhotos(t, db, photos)
+	// Two 5-star keepers, one 3-star and three unrated
+	if _, err := db.Exec("UPDATE photos SET rating = CASE WHEN id <= 2 THEN 5 WHEN id = 3 THEN 3 ELSE 0 END"); err != nil {
+		t.Fatalf("Failed to set ratings: %v", err)
+	}
+
+	engine := NewEngine(db)
+	three := 3
+	result, err := engine.Query(QueryParams{RatingMin: &three, Limit: 50})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if result.Total != 3 {
+		t.Errorf("rating_min=3 matched %d photos; want 3", result.Total)
+	}
+
+	min, max := RatingBucketRange("5")
+	params := QueryParams{RatingMin: min, RatingMax: max, Limit: 50}
+	facets, err := engine.ComputeFacets(params)
+	if err != nil {
+		t.Fatalf("ComputeFacets failed: %v", err)
+	}
+	if facets.Rating == nil {
+		t.Fatal("no rating facet")
+	}
+	want := []string{"0:3:false:/photos?rating_max=0&rating_min=0", "3:1:false:/photos?rating_max=3&rating_min=3", "5:2:true:/photos"}
+	var got []string
+	for _, v := range facets.Rating.Values {
+		got = append(got, fmt.Sprintf("%s:%d:%v:%s", v.Value, v.Count, v.Selected, v.URL))
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("rating facet = %v; want %v", got, want)
+	}
+
+	// The URL round-trips through the mapper
+	mapper := NewURLMapper()
+	parsed, err := mapper.ParsePath("/photos", "rating_min=4&rating_max=5")
+	if err != nil {
+		t.Fatalf("ParsePath failed: %v", err)
+	}
+	if parsed.RatingMin == nil || *parsed.RatingMin != 4 || parsed.RatingMax == nil || *parsed.RatingMax != 5 {
+		t.Errorf("parsed rating = %v, %v; want 4 to 5", parsed.RatingMin, parsed.RatingMax)
+	}
+	if q := mapper.BuildQueryString(parsed); q != "?rating_max=5&rating_min=4" {
+		t.Errorf("BuildQueryString = %q; want ?rating_max=5&rating_min=4", q)
+	}
+}
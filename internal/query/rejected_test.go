@@ -0,0 +1,60 @@
+package query
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRejectedPhotosHiddenByDefault(t *testing.T) {
+	db := setupTestDBWithSchema(t)
+	defer db.Close()
+
+	var photos []TestPhoto
+	for i := 0; i < 5; i++ {
+		photos = append(photos, TestPhoto{FilePath: fmt.Sprintf("/%d.jpg", i), DateTaken: fmt.Sprintf("2024-06-01 09:00:%02d", i)})
+	}
+	insertTestPhotos(t, db, photos)
+	if _, err := db.Exec("UPDATE photos SET rejected = 1 WHERE id IN (2, 4)"); err != nil {
+		t.Fatalf("Failed to reject photos: %v", err)
+	}
+
+	engine := NewEngine(db)
+	mapper := NewURLMapper()
+	for _, c := range []struct {
+		path, query string
+		want        int
+	}{
+		{"/photos", "", 3},
+		{"/photos", "include_rejected=true", 5},
+		{"/photos", "rejected=true", 2},
+		{"/photos", "rejected=false", 3},
+		{"/rejected", "", 2},
+	} {
+		params, err := mapper.ParsePath(c.path, c.query)
+		if err != nil {
+			t.Fatalf("ParsePath(%q, %q) failed: %v", c.path, c.query, err)
+		}
+		result, err := engine.Query(params)
+		if err != nil {
+			t.Fatalf("Query failed: %v", err)
+		}
+		if result.Total != c.want {
+			t.Errorf("%s?%s matched %d photos; want %d", c.path, c.query, result.Total, c.want)
+		}
+	}
+
+	// Facet counts leave rejected photos out too
+	facets, err := engine.ComputeFacets(QueryParams{Limit: 50})
+	if err != nil {
+		t.Fatalf("ComputeFacets failed: %v", err)
+	}
+	if facets.Year == nil || len(facets.Year.Values) != 1 || facets.Year.Values[0].Count != 3 {
+		t.Errorf("year facet = %+v; want 2024 with 3 photos", facets.Year)
+	}
+
+	// Links from the rejected view stay in it
+	rejected := true
+	if q := mapper.BuildQueryString(QueryParams{Rejected: &rejected, IncludeRejected: true, Limit: 50}); q != "?include_rejected=true&rejected=true" {
+		t.Errorf("BuildQueryString = %q; want ?include_rejected=true&rejected=true", q)
+	}
+}
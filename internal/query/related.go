@@ -0,0 +1,74 @@
+package query
+
+// Related suggests where to go from one camera or one lens: the lenses most
+// used with the camera, or the cameras most used with the lens, among the
+// photos the other filters match
+type Related struct {
+	Facet  string       // Facet the values come from: "lens" or "camera"
+	Label  string       // e.g. "Lenses used with Canon EOS R5"
+	Values []FacetValue // Most photos first; each URL adds the value to the filter
+}
+
+// relatedFacet names the facet to suggest from for params: lenses when it
+// selects one camera and no lens, cameras when it selects one lens and no
+// camera, and "" otherwise
+func relatedFacet(params QueryParams) (name, label string) {
+	switch {
+	case len(params.CameraModel) == 1 && len(params.CameraMake) <= 1 && len(params.LensModel) == 0:
+		camera := params.CameraModel[0]
+		if len(params.CameraMake) == 1 {
+			camera = params.CameraMake[0] + " " + camera
+		}
+		return "lens", "Lenses used with " + camera
+	case len(params.LensModel) == 1 && len(params.CameraMake) == 0 && len(params.CameraModel) == 0:
+		return "camera", "Cameras used with " + params.LensModel[0]
+	}
+	return "", ""
+}
+
+// RelatedEquipment picks up to n suggestions from facets already computed
+// for params. It returns nil when params selects neither one camera nor one
+// lens, or when no photo has the other on record.
+func RelatedEquipment(facets *FacetCollection, params QueryParams, n int) *Related {
+	name, label := relatedFacet(params)
+	if name == "" || facets == nil {
+		return nil
+	}
+	facet := facets.Lens
+	if name == "camera" {
+		facet = facets.Camera
+	}
+	if facet == nil {
+		return nil
+	}
+
+	related := &Related{Facet: name, Label: label}
+	for _, v := range facet.Values {
+		if len(related.Values) == n {
+			break
+		}
+		if !v.Selected && v.Count > 0 {
+			related.Values = append(related.Values, v)
+		}
+	}
+	if len(related.Values) == 0 {
+		return nil
+	}
+	return related
+}
+
+// Related computes RelatedEquipment for params, computing only the one facet
+// it draws on
+func (e *Engine) Related(params QueryParams, n int) (*Related, error) {
+	name, _ := relatedFacet(params)
+	if name == "" {
+		return nil, nil
+	}
+	facet, _, err := e.ComputeFacet(name, params)
+	if err != nil {
+		return nil, err
+	}
+	facets := &FacetCollection{}
+	facetDimensions[name].set(facets, facet)
+	return RelatedEquipment(facets, params, n), nil
+}
@@ -0,0 +1,71 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRelatedEquipment(t *testing.T) {
+	db := setupTestDBWithSchema(t)
+	defer db.Close()
+
+	var photos []TestPhoto
+	add := func(n int, cameraMake, model, lens string) {
+		for i := 0; i < n; i++ {
+			photos = append(photos, TestPhoto{
+				FilePath:   fmt.Sprintf("/%d.jpg", len(photos)),
+				FileHash:   fmt.Sprintf("h%d", len(photos)),
+				CameraMake: cameraMake, CameraModel: model, LensModel: lens,
+				DateTaken: "2024-06-01 12:00:00",
+			})
+		}
+	}
+	add(3, "Canon", "EOS R5", "RF 50mm")
+	add(5, "Canon", "EOS R5", "RF 24-70mm")
+	add(1, "Canon", "EOS R5", "")
+	add(2, "Canon", "EOS R6", "RF 50mm")
+	add(4, "Sony", "A7 IV", "FE 35mm")
+	insertTestPhotos(t, db, photos)
+	engine := NewEngine(db)
+
+	related, err := engine.Related(QueryParams{CameraMake: []string{"Canon"}, CameraModel: []string{"EOS R5"}, Limit: 50}, 5)
+	if err != nil {
+		t.Fatalf("Related failed: %v", err)
+	}
+	if related == nil || related.Facet != "lens" || related.Label != "Lenses used with Canon EOS R5" {
+		t.Fatalf("related = %+v; want lenses used with Canon EOS R5", related)
+	}
+	var got []string
+	for _, v := range related.Values {
+		got = append(got, fmt.Sprintf("%s:%d", v.Value, v.Count))
+	}
+	if strings.Join(got, " ") != "RF 24-70mm:5 RF 50mm:3" {
+		t.Errorf("lenses = %v; want RF 24-70mm:5 RF 50mm:3, most used first", got)
+	}
+	if !strings.Contains(related.Values[0].URL, "lens=RF+24-70mm") || !strings.Contains(related.Values[0].URL, "camera_model=EOS+R5") {
+		t.Errorf("URL = %q; want the camera kept and the lens added", related.Values[0].URL)
+	}
+
+	// The other way round, from facets already computed for the grid
+	params := QueryParams{LensModel: []string{"RF 50mm"}, Limit: 50}
+	facets, err := engine.ComputeFacets(params)
+	if err != nil {
+		t.Fatalf("ComputeFacets failed: %v", err)
+	}
+	related = RelatedEquipment(facets, params, 1)
+	if related == nil || related.Facet != "camera" || len(related.Values) != 1 || related.Values[0].Count != 3 {
+		t.Errorf("related = %+v; want the one camera used most with RF 50mm", related)
+	}
+
+	for _, params := range []QueryParams{
+		{Limit: 50},
+		{CameraMake: []string{"Canon"}, Limit: 50},
+		{CameraModel: []string{"EOS R5"}, LensModel: []string{"RF 50mm"}, Limit: 50},
+		{CameraModel: []string{"EOS R5", "EOS R6"}, Limit: 50},
+	} {
+		if related, err := engine.Related(params, 5); err != nil || related != nil {
+			t.Errorf("Related(%+v) = %+v, %v; want none", params, related, err)
+		}
+	}
+}
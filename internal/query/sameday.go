@@ -0,0 +1,33 @@
+package query
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ErrUndated is returned for photos without a capture date
+var ErrUndated = errors.New("photo has no capture date")
+
+// SameDay returns filters for the photos taken on the same calendar day as
+// photo id, in the engine's time zone, so the day matches the year, month
+// and day facets. It returns sql.ErrNoRows when the photo does not exist and
+// ErrUndated when it has no date.
+func (e *Engine) SameDay(id int) (QueryParams, error) {
+	var day sql.NullString
+	err := e.db.QueryRow(
+		"SELECT strftime('%Y-%m-%d', "+e.dateTaken()+") FROM photos p WHERE p.id = ?", id,
+	).Scan(&day)
+	if err != nil {
+		return QueryParams{}, err
+	}
+	if !day.Valid {
+		return QueryParams{}, fmt.Errorf("%w: photo %d", ErrUndated, id)
+	}
+
+	var year, month, dayOfMonth int
+	if _, err := fmt.Sscanf(day.String, "%d-%d-%d", &year, &month, &dayOfMonth); err != nil {
+		return QueryParams{}, fmt.Errorf("unexpected capture day %q: %w", day.String, err)
+	}
+	return QueryParams{Year: &year, Month: &month, Day: &dayOfMonth}, nil
+}
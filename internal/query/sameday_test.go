@@ -0,0 +1,51 @@
+package query
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/adewale/olsen/internal/database"
+	"github.com/adewale/olsen/pkg/models"
+)
+
+func TestSameDay(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "sameday.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	for _, p := range []*models.PhotoMetadata{
+		{FilePath: "/morning.jpg", FileHash: "a", DateTaken: time.Date(2024, 6, 1, 8, 0, 0, 0, time.UTC)},
+		{FilePath: "/night.jpg", FileHash: "b", DateTaken: time.Date(2024, 6, 1, 23, 30, 0, 0, time.UTC)},
+		{FilePath: "/next.jpg", FileHash: "c", DateTaken: time.Date(2024, 6, 2, 0, 10, 0, 0, time.UTC)},
+		{FilePath: "/undated.jpg", FileHash: "d"},
+	} {
+		if err := db.InsertPhoto(p); err != nil {
+			t.Fatalf("InsertPhoto failed: %v", err)
+		}
+	}
+
+	engine := NewEngine(db.DB)
+	params, err := engine.SameDay(2)
+	if err != nil {
+		t.Fatalf("SameDay failed: %v", err)
+	}
+	if *params.Year != 2024 || *params.Month != 6 || *params.Day != 1 {
+		t.Errorf("SameDay = %d-%d-%d; want 2024-6-1", *params.Year, *params.Month, *params.Day)
+	}
+	params.Limit = 50
+	if total, err := engine.Count(params); err != nil || total != 2 {
+		t.Errorf("Count = %d, %v; want 2", total, err)
+	}
+
+	if _, err := engine.SameDay(4); !errors.Is(err, ErrUndated) {
+		t.Errorf("SameDay(undated) error = %v; want ErrUndated", err)
+	}
+	if _, err := engine.SameDay(99); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("SameDay(missing) error = %v; want sql.ErrNoRows", err)
+	}
+}
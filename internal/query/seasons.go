@@ -0,0 +1,92 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SeasonNames are the columns of a YearSeasonMatrix, in calendar order and
+// spelled as the indexer stores them
+var SeasonNames = [4]string{"spring", "summer", "autumn", "winter"}
+
+// YearSeasonRow is one year of a YearSeasonMatrix
+type YearSeasonRow struct {
+	Year   int
+	Counts [4]int // Indexed like SeasonNames
+	Total  int
+}
+
+// YearSeasonMatrix counts photos by year (rows, newest first) and season
+type YearSeasonMatrix struct {
+	Rows     []YearSeasonRow
+	Total    int // Photos counted (dated photos with a season only)
+	MaxCount int // Largest single cell, for heatmap scaling
+}
+
+// ComputeYearSeason aggregates photos matching params by year of date_taken
+// and season. Any year or season filter in params is ignored so that every
+// cell of the matrix can be shown.
+//
+// Winter is not carried across the year boundary: the indexer assigns the
+// season from the month alone, so "winter 2024" is January, February and
+// December 2024. That is exactly the set matched by year=2024&season=winter,
+// which keeps each cell's count equal to the grid it links to.
+func (e *Engine) ComputeYearSeason(params QueryParams) (*YearSeasonMatrix, error) {
+	params.Year = nil
+	params.Season = nil
+
+	where, args := e.buildWhereClause(params)
+	where = append(where,
+		"p.season IS NOT NULL AND p.season != ''",
+		"strftime('%Y', "+e.dateTaken()+") IS NOT NULL")
+
+	query := fmt.Sprintf(`
+		SELECT
+			CAST(strftime('%%Y', %s) AS INTEGER) as year,
+			p.season,
+			COUNT(*) as count
+		FROM photos p
+		WHERE %s
+		GROUP BY year, p.season
+		ORDER BY year DESC
+	`, e.dateTaken(), strings.Join(where, " AND "))
+
+	rows, err := e.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute year/season counts: %w", err)
+	}
+	defer rows.Close()
+
+	matrix := &YearSeasonMatrix{}
+	for rows.Next() {
+		var year, count int
+		var season string
+		if err := rows.Scan(&year, &season, &count); err != nil {
+			return nil, err
+		}
+
+		col := -1
+		for i, name := range SeasonNames {
+			if name == season {
+				col = i
+				break
+			}
+		}
+		if col < 0 {
+			continue
+		}
+
+		if n := len(matrix.Rows); n == 0 || matrix.Rows[n-1].Year != year {
+			matrix.Rows = append(matrix.Rows, YearSeasonRow{Year: year})
+		}
+		row := &matrix.Rows[len(matrix.Rows)-1]
+		row.Counts[col] += count
+		row.Total += count
+		matrix.Total += count
+		if row.Counts[col] > matrix.MaxCount {
+			matrix.MaxCount = row.Counts[col]
+		}
+	}
+
+	return matrix, rows.Err()
+}
@@ -0,0 +1,67 @@
+package query
+
+import (
+	"testing"
+)
+
+func TestComputeYearSeason(t *testing.T) {
+	db := setupTestDBWithSchema(t)
+	defer db.Close()
+
+	insertTestPhotos(t, db, []TestPhoto{
+		{CameraMake: "Canon", DateTaken: "2024-01-10 10:00:00"}, // winter 2024
+		{CameraMake: "Canon", DateTaken: "2024-12-20 10:00:00"}, // winter 2024, not 2025
+		{CameraMake: "Nikon", DateTaken: "2024-07-04 10:00:00"}, // summer 2024
+		{CameraMake: "Nikon", DateTaken: "2023-10-01 10:00:00"}, // autumn 2023
+		{CameraMake: "Canon"}, // Undated - excluded
+	})
+	seasons := map[int]string{1: "winter", 2: "winter", 3: "summer", 4: "autumn"}
+	for id, season := range seasons {
+		if _, err := db.Exec("UPDATE photos SET season = ? WHERE id = ?", season, id); err != nil {
+			t.Fatalf("Failed to set season: %v", err)
+		}
+	}
+
+	engine := NewEngine(db)
+
+	// Year and season filters are dropped so the whole matrix is shown
+	year := 2023
+	matrix, err := engine.ComputeYearSeason(QueryParams{Year: &year, Season: []string{"summer"}})
+	if err != nil {
+		t.Fatalf("ComputeYearSeason failed: %v", err)
+	}
+
+	if matrix.Total != 4 {
+		t.Errorf("Total = %d; want 4 (undated photos excluded)", matrix.Total)
+	}
+	if len(matrix.Rows) != 2 || matrix.Rows[0].Year != 2024 || matrix.Rows[1].Year != 2023 {
+		t.Fatalf("Rows = %+v; want 2024 then 2023", matrix.Rows)
+	}
+	if got := matrix.Rows[0].Counts; got != [4]int{0, 1, 0, 2} {
+		t.Errorf("2024 counts = %v; want [0 1 0 2]", got)
+	}
+	if got := matrix.Rows[1].Counts; got != [4]int{0, 0, 1, 0} {
+		t.Errorf("2023 counts = %v; want [0 0 1 0]", got)
+	}
+	if matrix.MaxCount != 2 {
+		t.Errorf("MaxCount = %d; want 2", matrix.MaxCount)
+	}
+
+	// Each cell matches the grid it links to
+	results, err := engine.Query(QueryParams{Year: intPtr(2024), Season: []string{"winter"}, Limit: 10})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if results.Total != 2 {
+		t.Errorf("year=2024&season=winter total = %d; want 2", results.Total)
+	}
+
+	// Other filters are honoured
+	matrix, err = engine.ComputeYearSeason(QueryParams{CameraMake: []string{"Nikon"}})
+	if err != nil {
+		t.Fatalf("ComputeYearSeason with filter failed: %v", err)
+	}
+	if matrix.Total != 2 || matrix.Rows[0].Counts[3] != 0 {
+		t.Errorf("Nikon matrix Total = %d, 2024 winter = %d; want 2, 0", matrix.Total, matrix.Rows[0].Counts[3])
+	}
+}
@@ -0,0 +1,17 @@
+package query
+
+import "database/sql"
+
+// Session returns the grid query for shooting session id, as assigned by
+// olsen analyze, oldest photo first so the session reads in shooting order.
+// It returns sql.ErrNoRows when no photo is in that session.
+func (e *Engine) Session(id int) (QueryParams, error) {
+	var exists bool
+	if err := e.db.QueryRow("SELECT EXISTS(SELECT 1 FROM photos WHERE session_id = ?)", id).Scan(&exists); err != nil {
+		return QueryParams{}, err
+	}
+	if !exists {
+		return QueryParams{}, sql.ErrNoRows
+	}
+	return QueryParams{SessionID: &id, SortBy: "date_taken", SortOrder: "asc"}, nil
+}
@@ -0,0 +1,215 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// SetupRule classifies photos by how they were shot: a focal category and
+// an aperture range that together suggest a kind of photography, such as a
+// telephoto wide open for portraits. Aperture bounds are f-numbers, both
+// inclusive; 0 leaves that end open.
+type SetupRule struct {
+	Value           string   `json:"value"`
+	Label           string   `json:"label"`
+	FocalCategories []string `json:"focal_categories"`
+	ApertureMin     float64  `json:"aperture_min,omitempty"`
+	ApertureMax     float64  `json:"aperture_max,omitempty"`
+}
+
+// DefaultSetupRules are the setups the facet offers unless SetSetupRules
+// replaces them. The first matching rule wins, so macro only takes the
+// telephoto shots portrait left, and street only the wide shots landscape
+// left:
+//
+//   - portrait: telephoto at f/2.8 or wider, to blur the background
+//   - macro: telephoto at f/11 or narrower, for depth of field up close
+//   - landscape: wide at f/8 or narrower, sharp from front to back
+//   - street: wide or normal at f/4 to f/11, zone focusing distance
+var DefaultSetupRules = []SetupRule{
+	{Value: "portrait", Label: "Portrait (tele, f/2.8 or wider)", FocalCategories: []string{"telephoto"}, ApertureMax: 2.8},
+	{Value: "macro", Label: "Macro-ish (tele, f/11 or narrower)", FocalCategories: []string{"telephoto"}, ApertureMin: 11},
+	{Value: "landscape", Label: "Landscape (wide, f/8 or narrower)", FocalCategories: []string{"wide"}, ApertureMin: 8},
+	{Value: "street", Label: "Street (wide to normal, f/4–f/11)", FocalCategories: []string{"wide", "normal"}, ApertureMin: 4, ApertureMax: 11},
+}
+
+// matches reports whether a photo with focalCategory and aperture fits r
+func (r SetupRule) matches(focalCategory string, aperture float64) bool {
+	if r.ApertureMin > 0 && aperture < r.ApertureMin {
+		return false
+	}
+	if r.ApertureMax > 0 && aperture > r.ApertureMax {
+		return false
+	}
+	for _, fc := range r.FocalCategories {
+		if fc == focalCategory {
+			return true
+		}
+	}
+	return false
+}
+
+// ClassifySetup returns the value of the first rule a photo matches, or ""
+// when it matches none or lacks a focal category or aperture. The setup
+// facet and filter apply the same rules in SQL.
+func ClassifySetup(rules []SetupRule, focalCategory string, aperture float64) string {
+	if focalCategory == "" || aperture <= 0 {
+		return ""
+	}
+	for _, r := range rules {
+		if r.matches(focalCategory, aperture) {
+			return r.Value
+		}
+	}
+	return ""
+}
+
+// ParseSetupRules reads rules from JSON, a list of objects with the
+// SetupRule fields, and checks them
+func ParseSetupRules(data []byte) ([]SetupRule, error) {
+	var rules []SetupRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("invalid setup rules: %w", err)
+	}
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("invalid setup rules: no rules")
+	}
+	seen := make(map[string]bool)
+	for i, r := range rules {
+		switch {
+		case r.Value == "":
+			return nil, fmt.Errorf("setup rule %d has no value", i+1)
+		case seen[r.Value]:
+			return nil, fmt.Errorf("setup rule %q is listed twice", r.Value)
+		case len(r.FocalCategories) == 0:
+			return nil, fmt.Errorf("setup rule %q has no focal categories", r.Value)
+		case r.ApertureMin < 0 || r.ApertureMax < 0:
+			return nil, fmt.Errorf("setup rule %q has a negative aperture", r.Value)
+		case r.ApertureMax > 0 && r.ApertureMin > r.ApertureMax:
+			return nil, fmt.Errorf("setup rule %q has aperture_min above aperture_max", r.Value)
+		}
+		seen[r.Value] = true
+		if r.Label == "" {
+			rules[i].Label = strings.Title(r.Value)
+		}
+	}
+	return rules, nil
+}
+
+// SetSetupRules replaces the rules the setup facet and filter classify
+// photos by; nil restores DefaultSetupRules
+func (e *Engine) SetSetupRules(rules []SetupRule) {
+	e.setupRules = rules
+}
+
+// SetupRules returns the rules in use
+func (e *Engine) SetupRules() []SetupRule {
+	if e.setupRules == nil {
+		return DefaultSetupRules
+	}
+	return e.setupRules
+}
+
+// SetupLabel returns the display name of a setup value
+func (e *Engine) SetupLabel(value string) string {
+	for _, r := range e.SetupRules() {
+		if r.Value == value {
+			return r.Label
+		}
+	}
+	return strings.Title(value)
+}
+
+// setupCase builds a SQL CASE expression giving the setup of the photos
+// row aliased p, NULL when no rule matches, and its arguments. It is
+// ClassifySetup in SQL; photos without an aperture are never classified.
+func (e *Engine) setupCase() (string, []interface{}) {
+	var sb strings.Builder
+	var args []interface{}
+	sb.WriteString("CASE WHEN p.aperture IS NULL OR p.aperture <= 0 THEN NULL")
+	for _, r := range e.SetupRules() {
+		placeholders := make([]string, len(r.FocalCategories))
+		for i, fc := range r.FocalCategories {
+			placeholders[i] = "?"
+			args = append(args, fc)
+		}
+		fmt.Fprintf(&sb, " WHEN p.focal_category IN (%s)", strings.Join(placeholders, ", "))
+		if r.ApertureMin > 0 {
+			sb.WriteString(" AND p.aperture >= ?")
+			args = append(args, r.ApertureMin)
+		}
+		if r.ApertureMax > 0 {
+			sb.WriteString(" AND p.aperture <= ?")
+			args = append(args, r.ApertureMax)
+		}
+		sb.WriteString(" THEN ?")
+		args = append(args, r.Value)
+	}
+	sb.WriteString(" END")
+	return sb.String(), args
+}
+
+// computeSetupFacet computes the setup facet, listing setups in rule order.
+// Photos matching no rule are not counted.
+func (e *Engine) computeSetupFacet(params QueryParams) (*Facet, error) {
+	paramsWithoutSetup := params
+	paramsWithoutSetup.Setup = nil
+
+	setup, args := e.setupCase()
+	where, whereArgs := e.buildWhereClause(paramsWithoutSetup)
+	where = append(where, "p.focal_category IS NOT NULL")
+	args = append(args, whereArgs...)
+
+	query := fmt.Sprintf(`
+		SELECT setup, COUNT(*) as count
+		FROM (SELECT %s AS setup FROM photos p WHERE %s)
+		WHERE setup IS NOT NULL
+		GROUP BY setup
+	`, setup, strings.Join(where, " AND "))
+
+	rows, err := e.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var value string
+		var count int
+		if err := rows.Scan(&value, &count); err != nil {
+			return nil, err
+		}
+		counts[value] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	values := []FacetValue{}
+	for _, r := range e.SetupRules() {
+		selected := false
+		for _, s := range params.Setup {
+			if s == r.Value {
+				selected = true
+				break
+			}
+		}
+		if counts[r.Value] == 0 && !selected {
+			continue
+		}
+		values = append(values, FacetValue{
+			Value:    r.Value,
+			Label:    r.Label,
+			Count:    counts[r.Value],
+			Selected: selected,
+		})
+	}
+
+	return &Facet{
+		Name:   "setup",
+		Label:  "Setup",
+		Values: values,
+	}, nil
+}
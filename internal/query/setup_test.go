@@ -0,0 +1,122 @@
+package query
+
+import (
+	"testing"
+)
+
+func TestClassifySetup(t *testing.T) {
+	tests := []struct {
+		focal    string
+		aperture float64
+		want     string
+	}{
+		{"telephoto", 1.8, "portrait"},
+		{"telephoto", 2.8, "portrait"}, // upper bound is inclusive
+		{"telephoto", 5.6, ""},
+		{"telephoto", 11, "macro"},
+		{"wide", 8, "landscape"},
+		{"wide", 16, "landscape"}, // landscape comes before street
+		{"wide", 5.6, "street"},
+		{"normal", 11, "street"},
+		{"normal", 1.4, ""},
+		{"super_telephoto", 2.8, ""},
+		{"", 2.8, ""},        // no focal length
+		{"telephoto", 0, ""}, // no aperture
+	}
+	for _, tt := range tests {
+		if got := ClassifySetup(DefaultSetupRules, tt.focal, tt.aperture); got != tt.want {
+			t.Errorf("ClassifySetup(%q, f/%g) = %q; want %q", tt.focal, tt.aperture, got, tt.want)
+		}
+	}
+}
+
+func TestParseSetupRules(t *testing.T) {
+	rules, err := ParseSetupRules([]byte(`[{"value": "astro", "focal_categories": ["wide"], "aperture_max": 2.8}]`))
+	if err != nil {
+		t.Fatalf("ParseSetupRules failed: %v", err)
+	}
+	if len(rules) != 1 || rules[0].Label != "Astro" || rules[0].ApertureMax != 2.8 {
+		t.Errorf("rules = %+v; want one astro rule labelled from its value", rules)
+	}
+
+	for _, bad := range []string{
+		`[]`,
+		`{"value": "astro"}`,
+		`[{"focal_categories": ["wide"]}]`,
+		`[{"value": "astro"}]`,
+		`[{"value": "a", "focal_categories": ["wide"]}, {"value": "a", "focal_categories": ["normal"]}]`,
+		`[{"value": "a", "focal_categories": ["wide"], "aperture_min": 8, "aperture_max": 4}]`,
+	} {
+		if _, err := ParseSetupRules([]byte(bad)); err == nil {
+			t.Errorf("ParseSetupRules(%s) succeeded; want an error", bad)
+		}
+	}
+}
+
+func TestSetupFilterAndFacet(t *testing.T) {
+	db := setupTestDBWithSchema(t)
+	defer db.Close()
+
+	insertTestPhotos(t, db, []TestPhoto{
+		{FilePath: "/portrait.jpg", DateTaken: "2024-06-01 09:00:00"},
+		{FilePath: "/portrait2.jpg", DateTaken: "2024-06-02 09:00:00"},
+		{FilePath: "/landscape.jpg", DateTaken: "2024-06-03 09:00:00"},
+		{FilePath: "/street.jpg", DateTaken: "2024-06-04 09:00:00"},
+		{FilePath: "/other.jpg", DateTaken: "2024-06-05 09:00:00"},
+		{FilePath: "/unknown.jpg", DateTaken: "2024-06-06 09:00:00"},
+	})
+	shots := map[string][]interface{}{
+		"/portrait.jpg":  {"telephoto", 1.8},
+		"/portrait2.jpg": {"telephoto", 2.8},
+		"/landscape.jpg": {"wide", 11.0},
+		"/street.jpg":    {"normal", 5.6},
+		"/other.jpg":     {"telephoto", 5.6},
+		"/unknown.jpg":   {nil, nil},
+	}
+	for path, s := range shots {
+		if _, err := db.Exec("UPDATE photos SET focal_category = ?, aperture = ? WHERE file_path = ?", s[0], s[1], path); err != nil {
+			t.Fatalf("Failed to set focal category: %v", err)
+		}
+	}
+
+	engine := NewEngine(db)
+	params, err := NewURLMapper().ParsePath("/photos", "setup=portrait")
+	if err != nil {
+		t.Fatalf("ParsePath failed: %v", err)
+	}
+	result, err := engine.Query(params)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if result.Total != 2 {
+		t.Errorf("setup=portrait matched %d photos; want 2", result.Total)
+	}
+
+	facets, err := engine.ComputeFacets(params)
+	if err != nil {
+		t.Fatalf("ComputeFacets failed: %v", err)
+	}
+	var got []string
+	for _, v := range facets.Setup.Values {
+		got = append(got, v.Value)
+		if v.Value == "portrait" && (!v.Selected || v.Count != 2) {
+			t.Errorf("portrait = %d photos, selected %v; want 2, selected", v.Count, v.Selected)
+		}
+		if v.Value == "landscape" && v.URL != "/photos?setup=portrait&setup=landscape" {
+			t.Errorf("landscape URL = %q; want it added to the selection", v.URL)
+		}
+	}
+	if len(got) != 3 || got[0] != "portrait" || got[1] != "landscape" || got[2] != "street" {
+		t.Errorf("setup facet = %v; want portrait, landscape, street in rule order", got)
+	}
+
+	// Custom rules replace the defaults
+	engine.SetSetupRules([]SetupRule{{Value: "tele", Label: "Any telephoto", FocalCategories: []string{"telephoto"}}})
+	result, err = engine.Query(QueryParams{Setup: []string{"tele"}, Limit: 50})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if result.Total != 3 {
+		t.Errorf("custom rule matched %d photos; want 3", result.Total)
+	}
+}
@@ -0,0 +1,55 @@
+package query
+
+import (
+	"fmt"
+	"math"
+)
+
+// shutterBuckets group exposure times by how they are usually shot: fast
+// enough to freeze action, ordinary handheld, slow enough to risk shake,
+// and long exposures on a tripod
+var shutterBuckets = []rangeBucket{
+	{value: "fast", label: "Fast (under 1/500s)", max: rangeBound(1.0 / 500)},
+	{value: "normal", label: "Normal (1/500–1/60s)", min: rangeBound(1.0 / 500), max: rangeBound(1.0 / 60)},
+	{value: "slow", label: "Slow (1/60–1s)", min: rangeBound(1.0 / 60), max: rangeBound(1)},
+	{value: "long", label: "Long exposure (1s+)", min: rangeBound(1)},
+}
+
+// ShutterBucketRange returns the ShutterMin/ShutterMax bounds of a shutter
+// speed facet value. Unknown values return nil bounds.
+func ShutterBucketRange(value string) (min, max *float64) {
+	for _, b := range shutterBuckets {
+		if b.value == value {
+			return b.min, b.max
+		}
+	}
+	return nil, nil
+}
+
+// FormatShutterSeconds writes an exposure time the way cameras show it:
+// "1/250s" below a second, "2s" or "2.5s" above
+func FormatShutterSeconds(seconds float64) string {
+	if seconds > 0 && seconds < 1 {
+		return fmt.Sprintf("1/%gs", math.Round(1/seconds))
+	}
+	return fmt.Sprintf("%gs", seconds)
+}
+
+// computeShutterSpeedFacet computes the shutter speed bucket facet. Photos
+// without a parseable shutter speed (bulb, missing EXIF) are not counted.
+func (e *Engine) computeShutterSpeedFacet(params QueryParams) (*Facet, error) {
+	paramsWithoutShutter := params
+	paramsWithoutShutter.ShutterMin = nil
+	paramsWithoutShutter.ShutterMax = nil
+
+	values, err := e.computeRangeFacetValues(paramsWithoutShutter, "shutter_seconds", shutterBuckets, params.ShutterMin, params.ShutterMax)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Facet{
+		Name:   "shutter_speed",
+		Label:  "Shutter speed",
+		Values: values,
+	}, nil
+}
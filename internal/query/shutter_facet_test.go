@@ -0,0 +1,97 @@
+package query
+
+import (
+	"testing"
+)
+
+func TestShutterSpeedFilterAndFacet(t *testing.T) {
+	db := setupTestDBWithSchema(t)
+	defer db.Close()
+
+	insertTestPhotos(t, db, []TestPhoto{
+		{FilePath: "/fast.dng", CameraMake: "Nikon", DateTaken: "2024-06-01 09:00:00"},
+		{FilePath: "/normal.dng", CameraMake: "Nikon", DateTaken: "2024-06-02 09:00:00"},
+		{FilePath: "/boundary.dng", CameraMake: "Nikon", DateTaken: "2024-06-03 09:00:00"},
+		{FilePath: "/long.dng", CameraMake: "Nikon", DateTaken: "2024-06-04 09:00:00"},
+		{FilePath: "/bulb.dng", CameraMake: "Nikon", DateTaken: "2024-06-05 09:00:00"},
+	})
+	seconds := map[string]interface{}{
+		"/fast.dng":     1.0 / 2000,
+		"/normal.dng":   1.0 / 250,
+		"/boundary.dng": 1.0 / 60, // lower bound of "slow"
+		"/long.dng":     30.0,
+		"/bulb.dng":     nil,
+	}
+	for path, s := range seconds {
+		if _, err := db.Exec("UPDATE photos SET shutter_seconds = ? WHERE file_path = ?", s, path); err != nil {
+			t.Fatalf("Failed to set shutter_seconds: %v", err)
+		}
+	}
+
+	engine := NewEngine(db)
+	mapper := NewURLMapper()
+
+	// "Slower than 1 second"
+	params, err := mapper.ParsePath("/photos", "shutter_min=1")
+	if err != nil {
+		t.Fatalf("ParsePath failed: %v", err)
+	}
+	result, err := engine.Query(params)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if result.Total != 1 {
+		t.Errorf("shutter_min=1 matched %d photos; want 1", result.Total)
+	}
+
+	facets, err := engine.ComputeFacets(QueryParams{Limit: 50})
+	if err != nil {
+		t.Fatalf("ComputeFacets failed: %v", err)
+	}
+	counts := map[string]int{}
+	for _, v := range facets.ShutterSpeed.Values {
+		counts[v.Value] = v.Count
+	}
+	want := map[string]int{"fast": 1, "normal": 1, "slow": 1, "long": 1}
+	for bucket, n := range want {
+		if counts[bucket] != n {
+			t.Errorf("bucket %s = %d; want %d (all: %v)", bucket, counts[bucket], n, counts)
+		}
+	}
+
+	// Selecting a bucket through its URL filters to exactly that bucket
+	for _, v := range facets.ShutterSpeed.Values {
+		if v.Value != "slow" {
+			continue
+		}
+		p, err := mapper.ParsePath("/photos", v.URL[len("/photos?"):])
+		if err != nil {
+			t.Fatalf("ParsePath(%s) failed: %v", v.URL, err)
+		}
+		result, err := engine.Query(p)
+		if err != nil {
+			t.Fatalf("Query failed: %v", err)
+		}
+		if result.Total != 1 || result.Photos[0].FilePath != "/boundary.dng" {
+			t.Errorf("slow bucket URL %s matched %d photos; want only the 1/60s photo", v.URL, result.Total)
+		}
+		selected, err := engine.ComputeFacets(p)
+		if err != nil {
+			t.Fatalf("ComputeFacets failed: %v", err)
+		}
+		for _, sv := range selected.ShutterSpeed.Values {
+			if sv.Selected != (sv.Value == "slow") {
+				t.Errorf("bucket %s Selected = %v", sv.Value, sv.Selected)
+			}
+		}
+	}
+}
+
+func TestFormatShutterSeconds(t *testing.T) {
+	tests := map[float64]string{1.0 / 500: "1/500s", 1.0 / 60: "1/60s", 1: "1s", 2.5: "2.5s"}
+	for seconds, want := range tests {
+		if got := FormatShutterSeconds(seconds); got != want {
+			t.Errorf("FormatShutterSeconds(%v) = %q; want %q", seconds, got, want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package query
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/adewale/olsen/internal/database"
+	"github.com/adewale/olsen/pkg/models"
+)
+
+func TestTagMatchingPhotos(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "tags.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	for _, p := range []*models.PhotoMetadata{
+		{FilePath: "/a.jpg", FileHash: "a", CameraMake: "Canon"},
+		{FilePath: "/b.jpg", FileHash: "b", CameraMake: "Canon"},
+		{FilePath: "/c.jpg", FileHash: "c", CameraMake: "Nikon"},
+	} {
+		if err := db.InsertPhoto(p); err != nil {
+			t.Fatalf("InsertPhoto failed: %v", err)
+		}
+	}
+
+	engine := NewEngine(db.DB)
+	ids, args := engine.MatchingIDs(QueryParams{CameraMake: []string{"Canon"}})
+	changed, err := db.TagPhotos("vacation", ids, args)
+	if err != nil {
+		t.Fatalf("TagPhotos failed: %v", err)
+	}
+	if changed != 2 {
+		t.Errorf("tagged %d photos; want 2", changed)
+	}
+	if changed, _ := db.TagPhotos("vacation", ids, args); changed != 0 {
+		t.Errorf("tagging again changed %d photos; want 0", changed)
+	}
+
+	tagged := QueryParams{Tag: []string{"vacation"}, Limit: 50}
+	if total, _ := engine.Count(tagged); total != 2 {
+		t.Errorf("photos tagged vacation = %d; want 2", total)
+	}
+
+	if removed, err := db.UntagPhotos("vacation", "SELECT id FROM photos WHERE file_path = ?", []interface{}{"/a.jpg"}); err != nil || removed != 1 {
+		t.Errorf("UntagPhotos = %d, %v; want 1", removed, err)
+	}
+	if total, _ := engine.Count(tagged); total != 1 {
+		t.Errorf("photos tagged vacation after removal = %d; want 1", total)
+	}
+
+	mapper := NewURLMapper()
+	if got := mapper.BuildQueryString(tagged); got != "?tag=vacation" {
+		t.Errorf("BuildQueryString = %q; want ?tag=vacation", got)
+	}
+	params, _ := mapper.ParsePath("/photos", "tag=vacation&tag=family")
+	if len(params.Tag) != 2 || params.Tag[1] != "family" {
+		t.Errorf("parsed tags = %v; want [vacation family]", params.Tag)
+	}
+}
@@ -0,0 +1,106 @@
+package query
+
+import (
+	"strconv"
+	"strings"
+)
+
+// TimeOfDayValues are the time_of_day values the indexer infers, in the
+// order they occur through a day. blue_hour covers both dawn and dusk.
+var TimeOfDayValues = []string{
+	"golden_hour_morning",
+	"morning",
+	"midday",
+	"afternoon",
+	"golden_hour_evening",
+	"blue_hour",
+	"night",
+}
+
+var timeOfDayLabels = map[string]string{
+	"golden_hour_morning": "Golden Hour (Morning)",
+	"morning":             "Morning",
+	"midday":              "Midday",
+	"afternoon":           "Afternoon",
+	"golden_hour_evening": "Golden Hour (Evening)",
+	"blue_hour":           "Blue Hour",
+	"night":               "Night",
+}
+
+// timeOfDayAliases select several stored values at once
+var timeOfDayAliases = map[string][]string{
+	"golden_hour": {"golden_hour_morning", "golden_hour_evening"},
+}
+
+// legacyTimeOfDayPaths still parse so old links keep working, although the
+// indexer no longer infers them
+var legacyTimeOfDayPaths = map[string]bool{
+	"evening": true,
+}
+
+// TimeOfDayLabel returns the display name of a time_of_day value
+func TimeOfDayLabel(value string) string {
+	if label, ok := timeOfDayLabels[value]; ok {
+		return label
+	}
+	return strings.Title(strings.ReplaceAll(value, "_", " "))
+}
+
+// expandTimeOfDay splits comma-separated lists, lower-cases names and
+// replaces aliases such as golden_hour with the values they stand for,
+// dropping repeats. Other names are kept even if unknown, so they match
+// nothing rather than everything.
+func expandTimeOfDay(names []string) []string {
+	var values []string
+	seen := make(map[string]bool)
+	add := func(v string) {
+		if !seen[v] {
+			seen[v] = true
+			values = append(values, v)
+		}
+	}
+	for _, list := range names {
+		for _, name := range strings.Split(list, ",") {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+			if expanded, ok := timeOfDayAliases[name]; ok {
+				for _, v := range expanded {
+					add(v)
+				}
+			} else {
+				add(name)
+			}
+		}
+	}
+	return values
+}
+
+// parseTimeOfDayPath parses a path segment such as "golden_hour" or
+// "blue_hour,night". ok is false unless every name is a known value or
+// alias, so other top-level paths are left to the remaining routes.
+func parseTimeOfDayPath(segment string) (values []string, ok bool) {
+	names := strings.Split(segment, ",")
+	for _, name := range names {
+		name = strings.ToLower(name)
+		_, known := timeOfDayLabels[name]
+		_, alias := timeOfDayAliases[name]
+		if !known && !alias && !legacyTimeOfDayPaths[name] {
+			return nil, false
+		}
+	}
+	return expandTimeOfDay(names), true
+}
+
+// timeOfDayOrder is an SQL expression sorting time_of_day values in
+// TimeOfDayValues order, with unknown values last
+func timeOfDayOrder(column string) string {
+	var b strings.Builder
+	b.WriteString("CASE " + column)
+	for i, v := range TimeOfDayValues {
+		b.WriteString(" WHEN '" + v + "' THEN " + strconv.Itoa(i))
+	}
+	b.WriteString(" ELSE 99 END")
+	return b.String()
+}
@@ -0,0 +1,36 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DateTakenIn returns the SQL expression for the capture time of photos in
+// table (an alias such as "p", or "" for an unaliased table) as seen in loc.
+// It calls local_time, which connections opened by the database package
+// provide, so only photos with a recorded EXIF offset move; the others keep
+// the camera's clock time. A nil loc gives the plain date_taken column.
+func DateTakenIn(table string, loc *time.Location) string {
+	prefix := ""
+	if table != "" {
+		prefix = table + "."
+	}
+	if loc == nil {
+		return prefix + "date_taken"
+	}
+	zone := strings.ReplaceAll(loc.String(), "'", "''")
+	return fmt.Sprintf("local_time(%sdate_taken, %stime_offset, '%s')", prefix, prefix, zone)
+}
+
+// SetLocation makes the engine group and filter photos by date, weekday and
+// hour in loc, and return their capture times in it. nil, the default, uses
+// the camera's clock time as stored.
+func (e *Engine) SetLocation(loc *time.Location) {
+	e.location = loc
+}
+
+// dateTaken is DateTakenIn for the photos table, aliased p, in the engine's zone
+func (e *Engine) dateTaken() string {
+	return DateTakenIn("p", e.location)
+}
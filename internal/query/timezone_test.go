@@ -0,0 +1,73 @@
+package query
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/adewale/olsen/internal/database"
+	"github.com/adewale/olsen/pkg/models"
+)
+
+func TestEngineLocation(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "tz.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	photos := []*models.PhotoMetadata{
+		// New Year's Eve in Berlin: still 2024 in Los Angeles
+		{FilePath: "/eve.jpg", FileHash: "a", DateTaken: time.Date(2024, 12, 31, 23, 30, 0, 0, time.UTC), TimeOffset: "+01:00"},
+		// New Year's morning in Berlin: 4pm on the 31st in Los Angeles
+		{FilePath: "/morning.jpg", FileHash: "b", DateTaken: time.Date(2025, 1, 1, 1, 0, 0, 0, time.UTC), TimeOffset: "+01:00"},
+		// No offset recorded, so the camera's clock is all there is
+		{FilePath: "/unknown.jpg", FileHash: "c", DateTaken: time.Date(2025, 1, 1, 1, 0, 0, 0, time.UTC)},
+	}
+	for _, p := range photos {
+		if err := db.InsertPhoto(p); err != nil {
+			t.Fatalf("InsertPhoto failed: %v", err)
+		}
+	}
+
+	yearCounts := func(engine *Engine) map[string]int {
+		t.Helper()
+		facets, err := engine.ComputeFacets(QueryParams{Limit: 50})
+		if err != nil {
+			t.Fatalf("ComputeFacets failed: %v", err)
+		}
+		counts := make(map[string]int)
+		for _, v := range facets.Year.Values {
+			counts[v.Value] = v.Count
+		}
+		return counts
+	}
+
+	engine := NewEngine(db.DB)
+	if got := yearCounts(engine); got["2024"] != 1 || got["2025"] != 2 {
+		t.Errorf("camera clock years = %v; want 2024:1 2025:2", got)
+	}
+
+	la, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %v", err)
+	}
+	engine.SetLocation(la)
+	if got := yearCounts(engine); got["2024"] != 2 || got["2025"] != 1 {
+		t.Errorf("Los Angeles years = %v; want 2024:2 2025:1", got)
+	}
+
+	year := 2024
+	result, err := engine.Query(QueryParams{Year: &year, Limit: 50})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if result.Total != 2 {
+		t.Fatalf("2024 photos in Los Angeles = %d; want 2", result.Total)
+	}
+	for _, p := range result.Photos {
+		if p.FilePath == "/morning.jpg" && !p.DateTaken.Equal(time.Date(2024, 12, 31, 16, 0, 0, 0, time.UTC)) {
+			t.Errorf("morning photo shown at %v; want 2024-12-31 16:00", p.DateTaken)
+		}
+	}
+}
@@ -10,8 +10,9 @@ type QueryParams struct {
 	Day       *int
 	DateFrom  *time.Time
 	DateTo    *time.Time
-	TimeOfDay []string // morning, afternoon, evening, night
+	TimeOfDay []string // TimeOfDayValues; URLs also accept golden_hour for both golden hours
 	Season    []string // spring, summer, fall, winter
+	Weekday   []string // sunday … saturday (lower-case WeekdayNames); never matches undated photos
 
 	// Equipment filters
 	CameraMake  []string
@@ -19,6 +20,10 @@ type QueryParams struct {
 	LensMake    []string
 	LensModel   []string
 
+	// CameraSerial selects individual bodies by camera_serial_token
+	// (database.SerialToken), never by raw serial number
+	CameraSerial []string
+
 	// Technical filters (ranges)
 	ISOMin             *int
 	ISOMax             *int
@@ -28,16 +33,25 @@ type QueryParams struct {
 	FocalLengthMax     *float64
 	FocalLength35mmMin *int
 	FocalLength35mmMax *int
+	EVMin              *float64 // Exposure value at ISO 100, inclusive
+	EVMax              *float64 // Exclusive, so adjacent EV ranges do not overlap
+	ShutterMin         *float64 // Exposure time in seconds, inclusive
+	ShutterMax         *float64 // Exclusive, like EVMax
+	FileSizeMin        *int64   // Bytes, inclusive
+	FileSizeMax        *int64   // Bytes, exclusive like EVMax
 
 	// Categorical filters
 	FocalCategory     []string // wide, normal, telephoto
 	ShootingCondition []string // bright, normal, low_light
+	Setup             []string // Values of the engine's SetupRules, e.g. portrait
 
 	// Location filters
 	LatMin *float64
 	LatMax *float64
 	LonMin *float64
 	LonMax *float64
+	AltMin *float64 // Metres relative to sea level (negative below), inclusive
+	AltMax *float64 // Inclusive
 	HasGPS *bool
 
 	// Colour filters
@@ -49,11 +63,42 @@ type QueryParams struct {
 	SatMax     *int
 	LightMin   *int // 0-100
 	LightMax   *int
+	HasColours *bool // photos with/without dominant colour rows (none means the decode failed)
+
+	// ColourCountMin and ColourCountMax bound the number of colour families
+	// in the palette (photos.colour_count), both inclusive
+	ColourCountMin *int
+	ColourCountMax *int
+
+	// ColourMatchMode combines several ColourName values: ColourMatchAny
+	// (the default, also used when empty) matches photos with any of the
+	// colours, ColourMatchAll only photos that have every one of them
+	ColourMatchMode string
 
 	// Burst filters
 	InBurst      *bool
 	BurstGroupID *string
 	IsBurstRep   *bool // only burst representatives
+	BurstSizeMin *int  // Frames in the photo's burst, inclusive
+	BurstSizeMax *int  // Inclusive
+
+	// CollapseBursts shows each burst as its representative frame alone
+	CollapseBursts bool
+
+	// Exposure bracket (AEB) filter
+	InBracket *bool
+
+	// Shooting session filter: the session_id olsen analyze assigned
+	SessionID *int
+
+	// User rating, 0 (unrated) to 5 stars
+	RatingMin *int // Inclusive
+	RatingMax *int // Inclusive
+
+	// Culling: rejected photos are left out unless IncludeRejected is set.
+	// Rejected filters on the flag instead, true for the /rejected view.
+	Rejected        *bool
+	IncludeRejected bool
 
 	// Image properties
 	WidthMin         *int
@@ -68,17 +113,51 @@ type QueryParams struct {
 	// Other filters
 	FlashFired   *bool
 	WhiteBalance []string
-	ColourSpace  []string
+	ColourSpace  []string // sRGB, Adobe RGB, Display P3, Uncalibrated, ...
+	FileFormat   []string // dng, jpeg, png, tiff, heic
+	MediaType    []string // photo, video
+	Software     []string // EXIF Software, exactly as stored
+	Edited       *bool    // Software names an editor rather than camera firmware
+	Caption      string   // Text in the title or caption; ASCII letters match either case
+
+	// Manual collection membership (collections / collection_photos)
+	CollectionID *int
+
+	// Keywords (tags / photo_tags); photos with any of the tags match
+	Tag []string
 
 	// Pagination
 	Limit  int
 	Offset int
 
+	// Keyset pagination: continue after this photo instead of skipping Offset
+	// rows, so photos added or removed meanwhile don't shift the pages. Only
+	// date order is supported; take both values from QueryResult.NextCursor.
+	AfterID   int
+	AfterDate string // Stored date_taken of AfterID; "" when it has none
+
 	// Sorting
 	SortBy    string // date_taken, date_taken_desc, camera, focal_length, iso, aperture
 	SortOrder string // asc, desc
+
+	// Presentation (carried in URLs, ignored by queries)
+	Density string   // Grid density: "" (comfortable), "compact" or "dense"; see GridDensities
+	Layout  string   // Grid layout: "" (square cells) or "justified"; see GridLayouts
+	Expand  []string // Facets listed in full rather than their top values ("camera", "lens")
 }
 
+// Accepted values of QueryParams.ColourMatchMode
+const (
+	ColourMatchAny = "any"
+	ColourMatchAll = "all"
+)
+
+// GridDensities lists the accepted values of the density URL parameter
+var GridDensities = []string{"compact", "dense"}
+
+// GridLayouts lists the accepted values of the layout URL parameter
+var GridLayouts = []string{"justified"}
+
 // PhotoSummary is a lightweight photo representation for query results
 type PhotoSummary struct {
 	ID              int
@@ -100,10 +179,45 @@ type PhotoSummary struct {
 	InBurst         bool
 	BurstGroupID    string
 	IndexedAt       time.Time // Used for cache busting in thumbnail URLs
+	Blurhash        string    // Base64 PNG placeholder; empty until indexed or backfilled
 	IsBurstRep      bool
 	HasGPS          bool
 	Latitude        float64
 	Longitude       float64
+	Rating          int // Stars, 0 (unrated) to MaxRating
+}
+
+// PhotoCard is the part of a photo a grid card shows (Engine.QueryCards)
+type PhotoCard struct {
+	ID          int
+	DateTaken   time.Time
+	CameraMake  string
+	CameraModel string
+	IndexedAt   time.Time // Used for cache busting in thumbnail URLs
+	Blurhash    string    // Base64 PNG placeholder; empty until indexed or backfilled
+	DominantRGB string    // #rrggbb of the main palette colour, for tinting; empty without a palette
+	Width       int       // As displayed, after the EXIF orientation; 0 when unknown
+	Height      int
+}
+
+// AspectRatio returns the card's displayed width over height, or 1 when its
+// dimensions are unknown
+func (c PhotoCard) AspectRatio() float64 {
+	if c.Width <= 0 || c.Height <= 0 {
+		return 1
+	}
+	return float64(c.Width) / float64(c.Height)
+}
+
+// CardResult is a QueryResult holding PhotoCards instead of PhotoSummaries
+type CardResult struct {
+	Photos      []PhotoCard
+	Total       int
+	Limit       int
+	Offset      int
+	HasMore     bool
+	QueryTimeMs int64
+	NextCursor  *Cursor
 }
 
 // QueryResult contains the query results and metadata
@@ -115,6 +229,10 @@ type QueryResult struct {
 	HasMore     bool
 	Facets      *FacetCollection
 	QueryTimeMs int64
+
+	// NextCursor continues after the last photo for keyset pagination; nil
+	// on the last page. Only set when the query sorts by date.
+	NextCursor *Cursor
 }
 
 // Facet represents a single facet dimension
@@ -123,6 +241,12 @@ type Facet struct {
 	Label    string
 	Values   []FacetValue
 	Selected []string
+
+	// Other sums the values beyond the facet limit; nil when all are shown.
+	// Its URL lists the facet in full.
+	Other        *FacetValue
+	HiddenValues int    // Number of values summed into Other
+	CollapseURL  string // Set when the facet is expanded; back to the top values
 }
 
 // FacetValue represents a single value within a facet
@@ -142,19 +266,50 @@ type FacetValue struct {
 type FacetCollection struct {
 	Camera            *Facet
 	Lens              *Facet
+	CameraSerial      *Facet
 	Year              *Facet
 	Month             *Facet
 	TimeOfDay         *Facet
 	Season            *Facet
+	Weekday           *Facet
 	FocalCategory     *Facet
+	Setup             *Facet
 	ShootingCondition *Facet
 	InBurst           *Facet
+	BurstSize         *Facet
+	InBracket         *Facet
+	HasGPS            *Facet
+	Edited            *Facet
+	Rating            *Facet
+	FileFormat        *Facet
+	MediaType         *Facet
+	ColourSpace       *Facet
+	ShutterSpeed      *Facet
+	FileSize          *Facet
+	ExposureValue     *Facet
+	HasColours        *Facet
+	Palette           *Facet
 	ColourName        *Facet
 	ImageOrientation  *Facet
 	ISO               *Facet
 	Aperture          *Facet
 }
 
+// All returns every facet in the collection in display order; facets that
+// were not computed are nil
+func (c *FacetCollection) All() []*Facet {
+	if c == nil {
+		return nil
+	}
+	return []*Facet{
+		c.Year, c.Month, c.Weekday, c.TimeOfDay, c.Season,
+		c.Camera, c.CameraSerial, c.Lens, c.FocalCategory, c.Setup, c.ShootingCondition,
+		c.ExposureValue, c.ShutterSpeed, c.ISO, c.Aperture,
+		c.Rating, c.InBurst, c.BurstSize, c.InBracket, c.HasGPS, c.Edited, c.MediaType, c.FileFormat, c.FileSize, c.ColourSpace,
+		c.ImageOrientation, c.HasColours, c.Palette, c.ColourName,
+	}
+}
+
 // RangeFilter represents a min/max range
 type RangeFilter struct {
 	Min *float64
@@ -8,14 +8,26 @@ import (
 )
 
 // URLMapper handles conversion between URLs and QueryParams
-type URLMapper struct{}
+type URLMapper struct {
+	// basePath prefixes every URL built; see SetBasePath
+	basePath string
+}
 
 // NewURLMapper creates a new URL mapper
 func NewURLMapper() *URLMapper {
 	return &URLMapper{}
 }
 
-// ParsePath converts a URL path to QueryParams
+// SetBasePath prefixes the URLs the mapper builds with base, such as
+// "/olsen", for an explorer served below the root. Parsing is unaffected:
+// paths reach ParsePath with the prefix already removed.
+func (m *URLMapper) SetBasePath(base string) {
+	m.basePath = base
+}
+
+// ParsePath converts a URL path to QueryParams. path must still be
+// percent-encoded (url.URL.EscapedPath), so an encoded "/" or "-" in a
+// camera or lens name is not mistaken for a separator.
 // Supports patterns like:
 //
 //	/2025/10/04          - year/month/day
@@ -25,7 +37,9 @@ func NewURLMapper() *URLMapper {
 //	/lens/Canon-RF-24-70 - lens
 //	/color/blue          - colour search
 //	/morning             - time of day
+//	/weekday/saturday    - day of the week
 //	/bursts              - photos in bursts
+//	/rejected            - photos rejected while culling
 func (m *URLMapper) ParsePath(path string, queryString string) (QueryParams, error) {
 	params := QueryParams{
 		Limit: 50, // default
@@ -52,15 +66,15 @@ func (m *URLMapper) ParsePath(path string, queryString string) (QueryParams, err
 	switch segments[0] {
 	case "camera":
 		if len(segments) >= 3 {
-			params.CameraMake = []string{segments[1]}
-			params.CameraModel = []string{strings.ReplaceAll(segments[2], "-", " ")}
+			params.CameraMake = []string{parsePathSegment(segments[1])}
+			params.CameraModel = []string{parsePathSegment(segments[2])}
 		} else if len(segments) == 2 {
-			params.CameraMake = []string{segments[1]}
+			params.CameraMake = []string{parsePathSegment(segments[1])}
 		}
 
 	case "lens":
 		if len(segments) >= 2 {
-			params.LensModel = []string{strings.ReplaceAll(segments[1], "-", " ")}
+			params.LensModel = []string{parsePathSegment(segments[1])}
 		}
 
 	case "color":
@@ -68,12 +82,18 @@ func (m *URLMapper) ParsePath(path string, queryString string) (QueryParams, err
 			params.ColourName = []string{segments[1]}
 		}
 
+	case "weekday":
+		if len(segments) >= 2 {
+			params.Weekday = parseWeekdays(segments[1:2])
+		}
+
 	case "bursts":
 		inBurst := true
 		params.InBurst = &inBurst
 
-	case "morning", "afternoon", "evening", "night", "blue_hour", "golden_hour_morning", "golden_hour_evening", "midday":
-		params.TimeOfDay = []string{segments[0]}
+	case "rejected":
+		rejected := true
+		params.Rejected = &rejected
 
 	case "spring", "summer", "fall", "winter":
 		params.Season = []string{segments[0]}
@@ -82,6 +102,11 @@ func (m *URLMapper) ParsePath(path string, queryString string) (QueryParams, err
 		params.FocalCategory = []string{segments[0]}
 
 	default:
+		if tod, ok := parseTimeOfDayPath(segments[0]); ok {
+			params.TimeOfDay = tod
+			break
+		}
+
 		// Try to parse as year/month/day
 		if year, err := strconv.Atoi(segments[0]); err == nil && year >= 1900 && year <= 2100 {
 			params.Year = &year
@@ -111,6 +136,66 @@ func (m *URLMapper) ParsePath(path string, queryString string) (QueryParams, err
 	return params, nil
 }
 
+// pathSegment writes a camera or lens name as a path segment: spaces become
+// dashes, as in /camera/Canon/EOS-R5, and anything else that is not plain
+// text, dashes included, is percent-encoded so the name reads back exactly
+func pathSegment(name string) string {
+	escaped := strings.ReplaceAll(url.PathEscape(name), "-", "%2D")
+	return strings.ReplaceAll(escaped, "%20", "-")
+}
+
+// parsePathSegment reverses pathSegment. Undecodable pieces are kept as
+// they are rather than failing the whole URL.
+func parsePathSegment(segment string) string {
+	words := strings.Split(segment, "-")
+	for i, word := range words {
+		if decoded, err := url.PathUnescape(word); err == nil {
+			words[i] = decoded
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// CameraPath builds the /camera/:make/:model path for a camera, or
+// /camera/:make when model is empty. An empty make can't be a path segment
+// (the router would fold the double slash away), so it falls back to the
+// /photos query form.
+func CameraPath(cameraMake, model string) string {
+	if cameraMake == "" {
+		values := url.Values{"camera_make": {""}}
+		if model != "" {
+			values.Set("camera_model", model)
+		}
+		return "/photos?" + values.Encode()
+	}
+	path := "/camera/" + pathSegment(cameraMake)
+	if model != "" {
+		path += "/" + pathSegment(model)
+	}
+	return path
+}
+
+// LensPath builds the /lens/:model path for a lens, falling back to the
+// /photos query form for an empty model as CameraPath does
+func LensPath(model string) string {
+	if model == "" {
+		return "/photos?lens="
+	}
+	return "/lens/" + pathSegment(model)
+}
+
+// parseWeekdays keeps the recognised weekday names, lower-cased so they
+// match facet values
+func parseWeekdays(names []string) []string {
+	var weekdays []string
+	for _, name := range names {
+		if _, ok := weekdayNumber(name); ok {
+			weekdays = append(weekdays, strings.ToLower(name))
+		}
+	}
+	return weekdays
+}
+
 // parseQueryString parses URL query parameters into QueryParams
 func (m *URLMapper) parseQueryString(values url.Values, params *QueryParams) {
 	// Temporal filters
@@ -154,6 +239,29 @@ func (m *URLMapper) parseQueryString(values url.Values, params *QueryParams) {
 		params.SortOrder = order
 	}
 
+	// Grid density and layout; unknown values fall back to the default
+	if density := values.Get("density"); density != "" {
+		for _, d := range GridDensities {
+			if density == d {
+				params.Density = d
+			}
+		}
+	}
+	if layout := values.Get("layout"); layout != "" {
+		for _, l := range GridLayouts {
+			if layout == l {
+				params.Layout = l
+			}
+		}
+	}
+
+	// Facets to list in full
+	for _, name := range values["expand"] {
+		if (name == "camera" || name == "lens") && !isExpanded(*params, name) {
+			params.Expand = append(params.Expand, name)
+		}
+	}
+
 	// Equipment filters
 	if make := values["camera_make"]; len(make) > 0 {
 		params.CameraMake = append(params.CameraMake, make...)
@@ -164,14 +272,20 @@ func (m *URLMapper) parseQueryString(values url.Values, params *QueryParams) {
 	if lens := values["lens"]; len(lens) > 0 {
 		params.LensModel = append(params.LensModel, lens...)
 	}
+	if body := values["body"]; len(body) > 0 {
+		params.CameraSerial = append(params.CameraSerial, body...)
+	}
 
 	// Time filters
 	if tod := values["time_of_day"]; len(tod) > 0 {
-		params.TimeOfDay = append(params.TimeOfDay, tod...)
+		params.TimeOfDay = expandTimeOfDay(append(params.TimeOfDay, tod...))
 	}
 	if season := values["season"]; len(season) > 0 {
 		params.Season = append(params.Season, season...)
 	}
+	if weekday := values["weekday"]; len(weekday) > 0 {
+		params.Weekday = append(params.Weekday, parseWeekdays(weekday)...)
+	}
 
 	// Technical filters
 	if isoMin := values.Get("iso_min"); isoMin != "" {
@@ -205,10 +319,55 @@ func (m *URLMapper) parseQueryString(values url.Values, params *QueryParams) {
 		}
 	}
 
+	if evMin := values.Get("ev_min"); evMin != "" {
+		if v, err := strconv.ParseFloat(evMin, 64); err == nil {
+			params.EVMin = &v
+		}
+	}
+	if evMax := values.Get("ev_max"); evMax != "" {
+		if v, err := strconv.ParseFloat(evMax, 64); err == nil {
+			params.EVMax = &v
+		}
+	}
+	if shutterMin := values.Get("shutter_min"); shutterMin != "" {
+		if v, err := strconv.ParseFloat(shutterMin, 64); err == nil && v > 0 {
+			params.ShutterMin = &v
+		}
+	}
+	if shutterMax := values.Get("shutter_max"); shutterMax != "" {
+		if v, err := strconv.ParseFloat(shutterMax, 64); err == nil && v > 0 {
+			params.ShutterMax = &v
+		}
+	}
+	if sizeMin := values.Get("size_min"); sizeMin != "" {
+		if v, err := ParseFileSize(sizeMin); err == nil {
+			params.FileSizeMin = &v
+		}
+	}
+	if sizeMax := values.Get("size_max"); sizeMax != "" {
+		if v, err := ParseFileSize(sizeMax); err == nil && v > 0 {
+			params.FileSizeMax = &v
+		}
+	}
+
+	if altMin := values.Get("alt_min"); altMin != "" {
+		if v, err := strconv.ParseFloat(altMin, 64); err == nil {
+			params.AltMin = &v
+		}
+	}
+	if altMax := values.Get("alt_max"); altMax != "" {
+		if v, err := strconv.ParseFloat(altMax, 64); err == nil {
+			params.AltMax = &v
+		}
+	}
+
 	// Categorical filters
 	if fc := values["focal_category"]; len(fc) > 0 {
 		params.FocalCategory = append(params.FocalCategory, fc...)
 	}
+	if setup := values["setup"]; len(setup) > 0 {
+		params.Setup = append(params.Setup, setup...)
+	}
 	if sc := values["shooting_condition"]; len(sc) > 0 {
 		params.ShootingCondition = append(params.ShootingCondition, sc...)
 	}
@@ -217,6 +376,36 @@ func (m *URLMapper) parseQueryString(values url.Values, params *QueryParams) {
 	if color := values["color"]; len(color) > 0 {
 		params.ColourName = append(params.ColourName, color...)
 	}
+	if match := values.Get("color_match"); match == ColourMatchAny || match == ColourMatchAll {
+		params.ColourMatchMode = match
+	}
+
+	// File format filters
+	if ff := values["file_format"]; len(ff) > 0 {
+		params.FileFormat = append(params.FileFormat, ff...)
+	}
+
+	// Media type filters
+	if mt := values["media_type"]; len(mt) > 0 {
+		params.MediaType = append(params.MediaType, mt...)
+	}
+
+	// Colour space filters
+	if cs := values["color_space"]; len(cs) > 0 {
+		params.ColourSpace = append(params.ColourSpace, cs...)
+	}
+
+	// Collection filter
+	if c := values.Get("collection"); c != "" {
+		if id, err := strconv.Atoi(c); err == nil {
+			params.CollectionID = &id
+		}
+	}
+
+	// Tag filter
+	if tags := values["tag"]; len(tags) > 0 {
+		params.Tag = append(params.Tag, tags...)
+	}
 
 	// Burst filter
 	if burst := values.Get("in_burst"); burst != "" {
@@ -228,6 +417,100 @@ func (m *URLMapper) parseQueryString(values url.Values, params *QueryParams) {
 			params.InBurst = &inBurst
 		}
 	}
+	if group := values.Get("burst"); group != "" {
+		params.BurstGroupID = &group
+	}
+	if sizeMin := values.Get("burst_size_min"); sizeMin != "" {
+		if v, err := strconv.Atoi(sizeMin); err == nil {
+			params.BurstSizeMin = &v
+		}
+	}
+	if sizeMax := values.Get("burst_size_max"); sizeMax != "" {
+		if v, err := strconv.Atoi(sizeMax); err == nil {
+			params.BurstSizeMax = &v
+		}
+	}
+	if ratingMin := values.Get("rating_min"); ratingMin != "" {
+		if v, err := strconv.Atoi(ratingMin); err == nil {
+			params.RatingMin = &v
+		}
+	}
+	if ratingMax := values.Get("rating_max"); ratingMax != "" {
+		if v, err := strconv.Atoi(ratingMax); err == nil {
+			params.RatingMax = &v
+		}
+	}
+	if collapse := values.Get("collapse_bursts"); collapse == "true" || collapse == "1" {
+		params.CollapseBursts = true
+	}
+
+	// Rejected photos are hidden unless asked for
+	if rejected := values.Get("rejected"); rejected == "true" || rejected == "1" {
+		r := true
+		params.Rejected = &r
+	} else if rejected == "false" || rejected == "0" {
+		r := false
+		params.Rejected = &r
+	}
+	if include := values.Get("include_rejected"); include == "true" || include == "1" {
+		params.IncludeRejected = true
+	}
+
+	// Bracket filter
+	if bracket := values.Get("in_bracket"); bracket != "" {
+		if bracket == "true" || bracket == "1" {
+			inBracket := true
+			params.InBracket = &inBracket
+		} else if bracket == "false" || bracket == "0" {
+			inBracket := false
+			params.InBracket = &inBracket
+		}
+	}
+
+	// Session filter
+	if session := values.Get("session"); session != "" {
+		if v, err := strconv.Atoi(session); err == nil {
+			params.SessionID = &v
+		}
+	}
+
+	// Editing filters
+	if software := values["software"]; len(software) > 0 {
+		params.Software = append(params.Software, software...)
+	}
+	if edited := values.Get("edited"); edited != "" {
+		if edited == "true" || edited == "1" {
+			isEdited := true
+			params.Edited = &isEdited
+		} else if edited == "false" || edited == "0" {
+			isEdited := false
+			params.Edited = &isEdited
+		}
+	}
+	if caption := strings.TrimSpace(values.Get("caption")); caption != "" {
+		params.Caption = caption
+	}
+
+	// Colour data filter
+	if hasColours := values.Get("has_colors"); hasColours != "" {
+		if hasColours == "true" || hasColours == "1" {
+			has := true
+			params.HasColours = &has
+		} else if hasColours == "false" || hasColours == "0" {
+			has := false
+			params.HasColours = &has
+		}
+	}
+	if countMin := values.Get("color_count_min"); countMin != "" {
+		if v, err := strconv.Atoi(countMin); err == nil {
+			params.ColourCountMin = &v
+		}
+	}
+	if countMax := values.Get("color_count_max"); countMax != "" {
+		if v, err := strconv.Atoi(countMax); err == nil {
+			params.ColourCountMax = &v
+		}
+	}
 
 	// GPS filter
 	if hasGPS := values.Get("has_gps"); hasGPS != "" {
@@ -239,12 +522,24 @@ func (m *URLMapper) parseQueryString(values url.Values, params *QueryParams) {
 			params.HasGPS = &gps
 		}
 	}
+	for name, bound := range map[string]**float64{
+		"lat_min": &params.LatMin,
+		"lat_max": &params.LatMax,
+		"lon_min": &params.LonMin,
+		"lon_max": &params.LonMax,
+	} {
+		if s := values.Get(name); s != "" {
+			if v, err := strconv.ParseFloat(s, 64); err == nil {
+				*bound = &v
+			}
+		}
+	}
 }
 
 // BuildPath converts QueryParams to a URL path
 // Always returns /photos - all filtering is done via query parameters
 func (m *URLMapper) BuildPath(params QueryParams) string {
-	return "/photos"
+	return m.basePath + "/photos"
 }
 
 // BuildQueryString converts QueryParams to URL query parameters
@@ -280,10 +575,18 @@ func (m *URLMapper) BuildQueryString(params QueryParams) string {
 		values.Add("lens", l)
 	}
 
+	// Camera body filters (serial tokens)
+	for _, token := range params.CameraSerial {
+		values.Add("body", token)
+	}
+
 	// Colour filters
 	for _, c := range params.ColourName {
 		values.Add("color", c)
 	}
+	if params.ColourMatchMode == ColourMatchAll {
+		values.Set("color_match", ColourMatchAll)
+	}
 
 	// Time of day filters
 	for _, t := range params.TimeOfDay {
@@ -295,20 +598,100 @@ func (m *URLMapper) BuildQueryString(params QueryParams) string {
 		values.Add("season", s)
 	}
 
+	// Weekday filters
+	for _, w := range params.Weekday {
+		values.Add("weekday", w)
+	}
+
 	// Focal category filters
 	for _, f := range params.FocalCategory {
 		values.Add("focal_category", f)
 	}
 
+	// Setup filters
+	for _, s := range params.Setup {
+		values.Add("setup", s)
+	}
+
 	// Shooting condition filters
 	for _, sc := range params.ShootingCondition {
 		values.Add("shooting_condition", sc)
 	}
 
+	// File format filters
+	for _, ff := range params.FileFormat {
+		values.Add("file_format", ff)
+	}
+
+	// Media type filters
+	for _, mt := range params.MediaType {
+		values.Add("media_type", mt)
+	}
+
+	// Colour space filters
+	for _, cs := range params.ColourSpace {
+		values.Add("color_space", cs)
+	}
+
+	// Collection filter
+	if params.CollectionID != nil {
+		values.Set("collection", strconv.Itoa(*params.CollectionID))
+	}
+
+	// Tag filter
+	for _, tag := range params.Tag {
+		values.Add("tag", tag)
+	}
+
 	// Burst filter
 	if params.InBurst != nil {
 		values.Set("in_burst", strconv.FormatBool(*params.InBurst))
 	}
+	if params.BurstGroupID != nil {
+		values.Set("burst", *params.BurstGroupID)
+	}
+	if params.BurstSizeMin != nil {
+		values.Set("burst_size_min", strconv.Itoa(*params.BurstSizeMin))
+	}
+	if params.BurstSizeMax != nil {
+		values.Set("burst_size_max", strconv.Itoa(*params.BurstSizeMax))
+	}
+	if params.RatingMin != nil {
+		values.Set("rating_min", strconv.Itoa(*params.RatingMin))
+	}
+	if params.RatingMax != nil {
+		values.Set("rating_max", strconv.Itoa(*params.RatingMax))
+	}
+	if params.CollapseBursts {
+		values.Set("collapse_bursts", "true")
+	}
+	if params.Rejected != nil {
+		values.Set("rejected", strconv.FormatBool(*params.Rejected))
+	}
+	if params.IncludeRejected {
+		values.Set("include_rejected", "true")
+	}
+
+	// Bracket filter
+	if params.InBracket != nil {
+		values.Set("in_bracket", strconv.FormatBool(*params.InBracket))
+	}
+
+	// Session filter
+	if params.SessionID != nil {
+		values.Set("session", strconv.Itoa(*params.SessionID))
+	}
+
+	// Editing filters
+	for _, software := range params.Software {
+		values.Add("software", software)
+	}
+	if params.Edited != nil {
+		values.Set("edited", strconv.FormatBool(*params.Edited))
+	}
+	if params.Caption != "" {
+		values.Set("caption", params.Caption)
+	}
 
 	// Pagination
 	if params.Limit != 50 {
@@ -326,6 +709,17 @@ func (m *URLMapper) BuildQueryString(params QueryParams) string {
 		values.Set("order", params.SortOrder)
 	}
 
+	// Grid density
+	if params.Density != "" {
+		values.Set("density", params.Density)
+	}
+	if params.Layout != "" {
+		values.Set("layout", params.Layout)
+	}
+	for _, name := range params.Expand {
+		values.Add("expand", name)
+	}
+
 	// Technical ranges
 	if params.ISOMin != nil {
 		values.Set("iso_min", strconv.Itoa(*params.ISOMin))
@@ -345,11 +739,57 @@ func (m *URLMapper) BuildQueryString(params QueryParams) string {
 	if params.FocalLengthMax != nil {
 		values.Set("focal_max", fmt.Sprintf("%.0f", *params.FocalLengthMax))
 	}
+	if params.EVMin != nil {
+		values.Set("ev_min", strconv.FormatFloat(*params.EVMin, 'f', -1, 64))
+	}
+	if params.EVMax != nil {
+		values.Set("ev_max", strconv.FormatFloat(*params.EVMax, 'f', -1, 64))
+	}
+	if params.ShutterMin != nil {
+		values.Set("shutter_min", strconv.FormatFloat(*params.ShutterMin, 'f', -1, 64))
+	}
+	if params.ShutterMax != nil {
+		values.Set("shutter_max", strconv.FormatFloat(*params.ShutterMax, 'f', -1, 64))
+	}
+	if params.FileSizeMin != nil {
+		values.Set("size_min", fileSizeParam(*params.FileSizeMin))
+	}
+	if params.FileSizeMax != nil {
+		values.Set("size_max", fileSizeParam(*params.FileSizeMax))
+	}
+
+	if params.AltMin != nil {
+		values.Set("alt_min", strconv.FormatFloat(*params.AltMin, 'f', -1, 64))
+	}
+	if params.AltMax != nil {
+		values.Set("alt_max", strconv.FormatFloat(*params.AltMax, 'f', -1, 64))
+	}
 
 	// GPS filter
 	if params.HasGPS != nil {
 		values.Set("has_gps", strconv.FormatBool(*params.HasGPS))
 	}
+	for name, bound := range map[string]*float64{
+		"lat_min": params.LatMin,
+		"lat_max": params.LatMax,
+		"lon_min": params.LonMin,
+		"lon_max": params.LonMax,
+	} {
+		if bound != nil {
+			values.Set(name, strconv.FormatFloat(*bound, 'f', -1, 64))
+		}
+	}
+
+	// Colour data filter
+	if params.HasColours != nil {
+		values.Set("has_colors", strconv.FormatBool(*params.HasColours))
+	}
+	if params.ColourCountMin != nil {
+		values.Set("color_count_min", strconv.Itoa(*params.ColourCountMin))
+	}
+	if params.ColourCountMax != nil {
+		values.Set("color_count_max", strconv.Itoa(*params.ColourCountMax))
+	}
 
 	if len(values) == 0 {
 		return ""
@@ -374,7 +814,7 @@ type Breadcrumb struct {
 // BuildBreadcrumbs generates breadcrumb trail from QueryParams
 func (m *URLMapper) BuildBreadcrumbs(params QueryParams) []Breadcrumb {
 	crumbs := []Breadcrumb{
-		{Label: "Home", URL: "/"},
+		{Label: "Home", URL: m.basePath + "/"},
 	}
 
 	// Temporal breadcrumbs - ✅ State machine model: filters are independent
@@ -410,7 +850,7 @@ func (m *URLMapper) BuildBreadcrumbs(params QueryParams) []Breadcrumb {
 		if params.Year == nil {
 			crumbs = append(crumbs, Breadcrumb{
 				Label: params.CameraMake[0],
-				URL:   fmt.Sprintf("/camera/%s", strings.ReplaceAll(params.CameraMake[0], " ", "-")),
+				URL:   m.basePath + CameraPath(params.CameraMake[0], ""),
 			})
 		}
 
@@ -426,14 +866,14 @@ func (m *URLMapper) BuildBreadcrumbs(params QueryParams) []Breadcrumb {
 	if len(params.ColourName) > 0 && params.Year == nil && len(params.CameraMake) == 0 {
 		crumbs = append(crumbs, Breadcrumb{
 			Label: strings.Title(params.ColourName[0]),
-			URL:   fmt.Sprintf("/color/%s", params.ColourName[0]),
+			URL:   fmt.Sprintf("%s/color/%s", m.basePath, params.ColourName[0]),
 		})
 	}
 
 	if len(params.TimeOfDay) > 0 && params.Year == nil && len(params.CameraMake) == 0 {
 		crumbs = append(crumbs, Breadcrumb{
-			Label: strings.Title(params.TimeOfDay[0]),
-			URL:   fmt.Sprintf("/%s", params.TimeOfDay[0]),
+			Label: TimeOfDayLabel(params.TimeOfDay[0]),
+			URL:   fmt.Sprintf("%s/%s", m.basePath, params.TimeOfDay[0]),
 		})
 	}
 
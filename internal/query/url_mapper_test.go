@@ -1,6 +1,8 @@
 package query
 
 import (
+	"net/url"
+	"strings"
 	"testing"
 )
 
@@ -78,6 +80,36 @@ func TestParsePathLegacyRoutes(t *testing.T) {
 				Limit:     50,
 			},
 		},
+		{
+			name: "Time of day - golden hour covers morning and evening",
+			path: "/golden_hour",
+			want: QueryParams{
+				TimeOfDay: []string{"golden_hour_morning", "golden_hour_evening"},
+				Limit:     50,
+			},
+		},
+		{
+			name: "Time of day - several in one path",
+			path: "/blue_hour,night",
+			want: QueryParams{
+				TimeOfDay: []string{"blue_hour", "night"},
+				Limit:     50,
+			},
+		},
+		{
+			name:        "Time of day - golden hour in query string",
+			path:        "/photos",
+			queryString: "time_of_day=golden_hour&time_of_day=golden_hour_evening,midday",
+			want: QueryParams{
+				TimeOfDay: []string{"golden_hour_morning", "golden_hour_evening", "midday"},
+				Limit:     50,
+			},
+		},
+		{
+			name: "Time of day - unknown name in list is not a time of day",
+			path: "/blue_hour,brunch",
+			want: QueryParams{Limit: 50},
+		},
 		{
 			name: "Season - spring",
 			path: "/spring",
@@ -151,6 +183,29 @@ func TestParsePathLegacyRoutes(t *testing.T) {
 				Limit: 50,
 			},
 		},
+		{
+			name: "Weekday",
+			path: "/weekday/saturday",
+			want: QueryParams{
+				Weekday: []string{"saturday"},
+				Limit:   50,
+			},
+		},
+		{
+			name: "Weekday is case-insensitive",
+			path: "/weekday/Sunday",
+			want: QueryParams{
+				Weekday: []string{"sunday"},
+				Limit:   50,
+			},
+		},
+		{
+			name: "Invalid weekday",
+			path: "/weekday/funday",
+			want: QueryParams{
+				Limit: 50,
+			},
+		},
 		{
 			name: "Invalid month",
 			path: "/2025/13",
@@ -205,6 +260,9 @@ func TestParsePathLegacyRoutes(t *testing.T) {
 			if !equalStringSlice(got.Season, tt.want.Season) {
 				t.Errorf("Season = %v, want %v", got.Season, tt.want.Season)
 			}
+			if !equalStringSlice(got.Weekday, tt.want.Weekday) {
+				t.Errorf("Weekday = %v, want %v", got.Weekday, tt.want.Weekday)
+			}
 			if !equalStringSlice(got.FocalCategory, tt.want.FocalCategory) {
 				t.Errorf("FocalCategory = %v, want %v", got.FocalCategory, tt.want.FocalCategory)
 			}
@@ -344,6 +402,17 @@ func TestParseQueryString(t *testing.T) {
 				Limit:      100,
 			},
 		},
+		{
+			name:        "Weekend with year and month",
+			path:        "/photos",
+			queryString: "year=2024&month=6&weekday=saturday&weekday=sunday&weekday=someday",
+			want: QueryParams{
+				Year:    intPtr(2024),
+				Month:   intPtr(6),
+				Weekday: []string{"saturday", "sunday"},
+				Limit:   50,
+			},
+		},
 		{
 			name:        "Legacy path with query string override",
 			path:        "/2024",
@@ -401,6 +470,14 @@ func TestBuildQueryString(t *testing.T) {
 			},
 			want: "?camera_make=Canon&color=red&color=blue&year=2025",
 		},
+		{
+			name: "Weekdays",
+			params: QueryParams{
+				Weekday: []string{"saturday", "sunday"},
+				Limit:   50,
+			},
+			want: "?weekday=saturday&weekday=sunday",
+		},
 		{
 			name: "Pagination non-default",
 			params: QueryParams{
@@ -451,6 +528,94 @@ func TestBuildFullURL(t *testing.T) {
 	}
 }
 
+func TestSpecialCharacterRoundTrip(t *testing.T) {
+	mapper := NewURLMapper()
+
+	cameras := []struct{ make, model string }{
+		{"Fujifilm", "X-T5 (α)"},
+		{"Phase One", "IQ4 150MP + XT"},
+		{"Leica Camera AG", "100% Q3/43 #2"},
+		{"Ricoh", "GR IIIx & GR III?"},
+		{"大疆", "Mavic 3 Pro – Hasselblad"},
+	}
+	for _, c := range cameras {
+		t.Run(c.model, func(t *testing.T) {
+			want := QueryParams{CameraMake: []string{c.make}, CameraModel: []string{c.model}, LensModel: []string{c.model}, Limit: 50}
+
+			// Query form, through net/url as a browser request would arrive
+			u, err := url.Parse(mapper.BuildFullURL(want))
+			if err != nil {
+				t.Fatalf("BuildFullURL wrote an invalid URL: %v", err)
+			}
+			for _, r := range u.String() {
+				if r <= ' ' || r > '~' {
+					t.Fatalf("URL %q has an unencoded %q", u, r)
+				}
+			}
+			got, err := mapper.ParsePath(u.EscapedPath(), u.RawQuery)
+			if err != nil {
+				t.Fatalf("ParsePath failed: %v", err)
+			}
+			compareQueryParams(t, got, want)
+
+			// Path form
+			if u, err = url.Parse(CameraPath(c.make, c.model)); err != nil {
+				t.Fatalf("CameraPath wrote an invalid path: %v", err)
+			}
+			if got, err = mapper.ParsePath(u.EscapedPath(), ""); err != nil {
+				t.Fatalf("ParsePath failed: %v", err)
+			}
+			if !equalStringSlice(got.CameraMake, want.CameraMake) || !equalStringSlice(got.CameraModel, want.CameraModel) {
+				t.Errorf("%s read back as %q %q", u, got.CameraMake, got.CameraModel)
+			}
+		})
+	}
+}
+
+func TestCameraAndLensPathRoundTrip(t *testing.T) {
+	mapper := NewURLMapper()
+	read := func(t *testing.T, path string) QueryParams {
+		t.Helper()
+		u, err := url.Parse(path)
+		if err != nil {
+			t.Fatalf("%q is not a valid URL: %v", path, err)
+		}
+		if strings.Contains(u.Path, "//") {
+			t.Fatalf("%q has an empty segment the router would fold away", path)
+		}
+		params, err := mapper.ParsePath(u.EscapedPath(), u.RawQuery)
+		if err != nil {
+			t.Fatalf("ParsePath(%q) failed: %v", path, err)
+		}
+		return params
+	}
+
+	cameras := []struct{ make, model string }{
+		{"FUJIFILM", "X-T5"},
+		{"Phase One", "IQ4 150MP"},
+		{"Leica/Leitz", "M10 / M10-R"},
+		{"", "X-T5"},
+		{"Canon", ""},
+		{"", ""},
+	}
+	for _, c := range cameras {
+		params := read(t, CameraPath(c.make, c.model))
+		wantModel := []string{c.model}
+		if c.model == "" {
+			wantModel = nil
+		}
+		if !equalStringSlice(params.CameraMake, []string{c.make}) || !equalStringSlice(params.CameraModel, wantModel) {
+			t.Errorf("CameraPath(%q, %q) = %s read back as %q %q", c.make, c.model, CameraPath(c.make, c.model), params.CameraMake, params.CameraModel)
+		}
+	}
+
+	for _, lens := range []string{"XF16-55mmF2.8 R LM WR", "EF 24-70mm f/2.8L II USM", " leading space", ""} {
+		if params := read(t, LensPath(lens)); !equalStringSlice(params.LensModel, []string{lens}) {
+			t.Errorf("LensPath(%q) = %s read back as %q", lens, LensPath(lens), params.LensModel)
+		}
+	}
+}
+
 // Helper functions
 
 func intPtr(i int) *int {
@@ -527,6 +692,9 @@ func compareQueryParams(t *testing.T, got, want QueryParams) {
 	if !equalStringSlice(got.Season, want.Season) {
 		t.Errorf("Season = %v, want %v", got.Season, want.Season)
 	}
+	if !equalStringSlice(got.Weekday, want.Weekday) {
+		t.Errorf("Weekday = %v, want %v", got.Weekday, want.Weekday)
+	}
 	if !equalStringSlice(got.FocalCategory, want.FocalCategory) {
 		t.Errorf("FocalCategory = %v, want %v", got.FocalCategory, want.FocalCategory)
 	}
@@ -577,3 +745,32 @@ func equalFloat64Ptr(a, b *float64) bool {
 	}
 	return *a == *b
 }
+
+func TestDensityRoundTrip(t *testing.T) {
+	mapper := NewURLMapper()
+
+	for _, density := range GridDensities {
+		params, err := mapper.ParsePath("/photos", "year=2025&density="+density)
+		if err != nil {
+			t.Fatalf("ParsePath() error = %v", err)
+		}
+		if params.Density != density {
+			t.Errorf("Density = %q; want %q", params.Density, density)
+		}
+		again, err := mapper.ParsePath("/photos", strings.TrimPrefix(mapper.BuildQueryString(params), "?"))
+		if err != nil {
+			t.Fatalf("ParsePath() error = %v", err)
+		}
+		if again.Density != density {
+			t.Errorf("Density lost in round trip: %q", mapper.BuildQueryString(params))
+		}
+	}
+
+	params, err := mapper.ParsePath("/photos", "density=huge")
+	if err != nil {
+		t.Fatalf("ParsePath() error = %v", err)
+	}
+	if params.Density != "" {
+		t.Errorf("Density = %q; want unknown value ignored", params.Density)
+	}
+}
@@ -0,0 +1,94 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// weekdayNumber maps a weekday name ("saturday", case-insensitive) to
+// SQLite's strftime('%w') value, 0 = Sunday
+func weekdayNumber(name string) (int, bool) {
+	for i, day := range WeekdayNames {
+		if strings.EqualFold(name, day) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// weekdayCondition builds the WHERE condition for QueryParams.Weekday on the
+// capture time expression dateTaken. Unknown names are ignored, like unknown
+// colour names. Undated photos never match, since strftime returns NULL for
+// them.
+func weekdayCondition(dateTaken string, weekdays []string) (string, []interface{}) {
+	var placeholders []string
+	var args []interface{}
+	for _, name := range weekdays {
+		if day, ok := weekdayNumber(name); ok {
+			placeholders = append(placeholders, "?")
+			args = append(args, strconv.Itoa(day))
+		}
+	}
+	if len(placeholders) == 0 {
+		return "", nil
+	}
+	return fmt.Sprintf("strftime('%%w', %s) IN (%s)", dateTaken, strings.Join(placeholders, ", ")), args
+}
+
+// computeWeekdayFacet counts photos by the day of the week they were taken,
+// Sunday first like WeekdayNames. Undated photos are left out.
+func (e *Engine) computeWeekdayFacet(params QueryParams) (*Facet, error) {
+	paramsWithoutWeekday := params
+	paramsWithoutWeekday.Weekday = nil
+
+	where, args := e.buildWhereClause(paramsWithoutWeekday)
+	where = append(where, "strftime('%w', "+e.dateTaken()+") IS NOT NULL")
+
+	query := fmt.Sprintf(`
+		SELECT CAST(strftime('%%w', %s) AS INTEGER) as weekday, COUNT(*) as count
+		FROM photos p
+		WHERE %s
+		GROUP BY weekday
+		ORDER BY weekday
+	`, e.dateTaken(), strings.Join(where, " AND "))
+
+	rows, err := e.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := []FacetValue{}
+	for rows.Next() {
+		var weekday, count int
+		if err := rows.Scan(&weekday, &count); err != nil {
+			return nil, err
+		}
+		if weekday < 0 || weekday > 6 {
+			continue
+		}
+
+		value := strings.ToLower(WeekdayNames[weekday])
+		selected := false
+		for _, w := range params.Weekday {
+			if strings.EqualFold(w, value) {
+				selected = true
+				break
+			}
+		}
+
+		values = append(values, FacetValue{
+			Value:    value,
+			Label:    WeekdayNames[weekday],
+			Count:    count,
+			Selected: selected,
+		})
+	}
+
+	return &Facet{
+		Name:   "weekday",
+		Label:  "Day of Week",
+		Values: values,
+	}, rows.Err()
+}
@@ -0,0 +1,66 @@
+package query
+
+import (
+	"testing"
+)
+
+func TestWeekdayFilterAndFacet(t *testing.T) {
+	db := setupTestDBWithSchema(t)
+	defer db.Close()
+
+	insertTestPhotos(t, db, []TestPhoto{
+		{FilePath: "/sat.jpg", CameraMake: "Canon", DateTaken: "2024-06-01 09:00:00"},     // Saturday
+		{FilePath: "/sun.jpg", CameraMake: "Canon", DateTaken: "2024-06-02 09:00:00"},     // Sunday
+		{FilePath: "/mon.jpg", CameraMake: "Canon", DateTaken: "2024-06-03 09:00:00"},     // Monday
+		{FilePath: "/sat-may.jpg", CameraMake: "Canon", DateTaken: "2024-05-25 09:00:00"}, // Saturday
+	})
+	if _, err := db.Exec(`INSERT INTO photos (file_path, file_hash, file_size, last_modified, camera_make)
+		VALUES ('/undated.jpg', 'h', 1, CURRENT_TIMESTAMP, 'Canon')`); err != nil {
+		t.Fatalf("Failed to insert undated photo: %v", err)
+	}
+
+	engine := NewEngine(db)
+	june := 6
+	params := QueryParams{Month: &june, Weekday: []string{"saturday", "sunday"}, Limit: 50}
+
+	result, err := engine.Query(params)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if result.Total != 2 {
+		t.Errorf("June weekend photos = %d, want 2", result.Total)
+	}
+
+	facets, err := engine.ComputeFacets(params)
+	if err != nil {
+		t.Fatalf("ComputeFacets failed: %v", err)
+	}
+	if facets.Weekday == nil {
+		t.Fatal("Weekday facet missing")
+	}
+
+	// Counts ignore the weekday selection but keep the month; undated photos are left out
+	want := []struct {
+		value    string
+		count    int
+		selected bool
+		url      string
+	}{
+		{"sunday", 1, true, "/photos?month=6&weekday=saturday"},
+		{"monday", 1, false, "/photos?month=6&weekday=saturday&weekday=sunday&weekday=monday"},
+		{"saturday", 1, true, "/photos?month=6&weekday=sunday"},
+	}
+	if len(facets.Weekday.Values) != len(want) {
+		t.Fatalf("Weekday facet = %+v, want %d values", facets.Weekday.Values, len(want))
+	}
+	for i, w := range want {
+		got := facets.Weekday.Values[i]
+		if got.Value != w.value || got.Count != w.count || got.Selected != w.selected {
+			t.Errorf("value %d = %s %d selected=%v; want %s %d selected=%v",
+				i, got.Value, got.Count, got.Selected, w.value, w.count, w.selected)
+		}
+		if got.URL != w.url {
+			t.Errorf("%s URL = %q, want %q", w.value, got.URL, w.url)
+		}
+	}
+}
@@ -38,38 +38,58 @@ type DominantColour struct {
 	Weight float64
 }
 
+// Media types stored in the media_type column
+const (
+	MediaTypePhoto = "photo"
+	MediaTypeVideo = "video"
+)
+
 // PhotoMetadata contains all metadata for a photo
 type PhotoMetadata struct {
 	ID           int
 	FilePath     string
 	FileHash     string
 	FileSize     int64
+	FileFormat   string // dng, jpeg, png, tiff, heic, mp4, mov, ...
+	MediaType    string // MediaTypePhoto or MediaTypeVideo; empty means photo
 	LastModified time.Time
 	IndexedAt    time.Time
 
 	// Camera & Lens
-	CameraMake  string
-	CameraModel string
-	LensMake    string
-	LensModel   string
+	CameraMake   string
+	CameraModel  string
+	LensMake     string
+	LensModel    string
+	CameraSerial string // Body serial (EXIF BodySerialNumber, else DNG CameraSerialNumber)
+	Software     string // EXIF Software, else ProcessingSoftware: camera firmware or the editor that saved the file
+	Title        string // XMP dc:title, else EXIF XPTitle
+	Caption      string // XMP dc:description, else EXIF ImageDescription or XPComment
 
 	// Exposure Settings
 	ISO                  int
 	Aperture             float64
 	ShutterSpeed         string
+	ShutterSeconds       *float64 // ShutterSpeed in seconds; nil for bulb or unparseable values
 	ExposureCompensation float64
 	FocalLength          float64
 	FocalLength35mm      int
 
+	// Thumbnail generation for images smaller than some sizes
+	ThumbnailsUpscaled bool // At least one size was enlarged
+	ThumbnailsSkipped  int  // Sizes not generated because upscaling was off
+	ThumbnailsPending  bool // Indexed with -no-thumbnails; a later full index generates them
+
 	// Temporal
 	DateTaken     time.Time
 	DateDigitized time.Time
+	TimeOffset    string // EXIF OffsetTimeOriginal, e.g. "+02:00"; DateTaken itself carries no zone
 
 	// Image Properties
 	Width       int
 	Height      int
 	Orientation int
 	ColourSpace string
+	Duration    float64 // Running time of a video in seconds; 0 for photos
 
 	// Location
 	Latitude  float64
@@ -90,6 +110,9 @@ type PhotoMetadata struct {
 	Season            string
 	FocalCategory     string
 	ShootingCondition string
+	ExposureValue     *float64 // EV at ISO 100; nil when exposure settings are incomplete
+	SunElevation      *float64 // Degrees above the horizon at capture; nil without GPS
+	Edited            bool     // Software names an editor rather than camera firmware
 
 	// Visual Analysis
 	Thumbnails      map[ThumbnailSize][]byte
@@ -98,6 +121,12 @@ type PhotoMetadata struct {
 	// Perceptual Hash
 	PerceptualHash string
 
+	// Blurhash is the grid's loading placeholder (see indexer.ComputeBlurhash)
+	Blurhash string
+
+	// Raw EXIF (complete tag dump for inspection; not used for filtering)
+	RawExif []ExifEntry
+
 	// Burst Detection
 	BurstGroupID          string
 	BurstSequence         int
@@ -111,6 +140,13 @@ type PhotoMetadata struct {
 	SimilarityScore         float64
 }
 
+// ExifEntry is a single raw EXIF tag as read from the file
+type ExifEntry struct {
+	IFD   string // IFD path, e.g. "IFD/Exif"
+	Tag   string // Tag name, e.g. "MeteringMode"
+	Value string // Human-readable value
+}
+
 // IndexStats tracks indexing progress
 type IndexStats struct {
 	FilesFound          int
@@ -118,6 +154,8 @@ type IndexStats struct {
 	FilesSkipped        int
 	FilesUpdated        int
 	FilesFailed         int
+	FilesOutOfRange     int // Skipped by the file size limits, not counted in FilesFound
+	FilesTooOld         int // Skipped by the modified-after limit, not counted in FilesFound
 	ThumbnailsGenerated int
 	HashesComputed      int
 	StartTime           time.Time